@@ -1,20 +1,93 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
 
 	"rulestack/internal/api"
 	"rulestack/internal/config"
 	"rulestack/internal/db"
+	"rulestack/internal/tracing"
 )
 
+var (
+	configFile  string
+	printConfig bool
+	flagValues  = map[string]*string{}
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "rulestack-api",
+	Short: "Run the rulestack registry API server",
+	Long: `Run the rulestack registry API server.
+
+Every setting can be given as a flag, an environment variable or a key in a
+TOML config file (--config or CONFIG_FILE), in that order of precedence.
+Config file keys are the flag names with underscores, e.g. cache_ttl = "1m".
+
+Examples:
+  rulestack-api --config /etc/rulestack/api.toml
+  rulestack-api --port 9000 --cache-size 0
+  rulestack-api --config api.toml --print-config`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := map[string]string{}
+		for key, value := range flagValues {
+			if cmd.Flags().Changed(key) {
+				flags[key] = *value
+			}
+		}
+		if !cmd.Flags().Changed("config") {
+			configFile = os.Getenv(config.ConfigFileEnv)
+		}
+
+		values, err := config.Resolve(flags, configFile)
+		if err != nil {
+			return err
+		}
+		if printConfig {
+			values.Print(os.Stdout)
+			_, err := values.Config()
+			return err
+		}
+
+		cfg, err := values.Config()
+		if err != nil {
+			return err
+		}
+		serve(cfg)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&configFile, "config", "", "TOML config file (env: "+config.ConfigFileEnv+")")
+	rootCmd.Flags().BoolVar(&printConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	for _, setting := range config.Settings {
+		usage := fmt.Sprintf("%s (env: %s)", setting.Usage, setting.Env)
+		flagValues[setting.Key] = rootCmd.Flags().String(setting.Key, setting.Default, usage)
+	}
+}
+
 func main() {
-	// Load configuration from system environment variables
-	cfg := config.Load()
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(cfg config.Config) {
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "rulestack-api")
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Connect to database
 	database, err := db.Connect(cfg.DBURL)
@@ -28,68 +101,11 @@ func main() {
 		log.Fatal("Database health check failed:", err)
 	}
 
-	// Ensure root user exists
-	if err := ensureRootUser(database); err != nil {
+	// Ensure root user exists, with hardcoded defaults
+	if _, err := api.EnsureRootUser(database, "root1234"); err != nil {
 		log.Printf("Warning: Failed to ensure root user exists: %v", err)
 		// Don't fail startup, just log the error
 	}
 
-	// Create storage directory if it doesn't exist
-	if err := os.MkdirAll(cfg.StoragePath, 0o755); err != nil {
-		log.Fatal("Failed to create storage directory:", err)
-	}
-
-	// Set up router
-	r := mux.NewRouter()
-
-	// Register API routes
-	api.RegisterRoutes(r, database, cfg)
-
-	log.Printf("API server starting on port %s", cfg.APIPort)
-	log.Printf("Storage path: %s", cfg.StoragePath)
-	log.Fatal(http.ListenAndServe(":"+cfg.APIPort, r))
-}
-
-// ensureRootUser checks if a root user exists and creates one if not
-func ensureRootUser(database *db.DB) error {
-	// Check if any root user exists
-	var exists bool
-	err := database.QueryRow(`
-		SELECT EXISTS(
-			SELECT 1 FROM rulestack.users WHERE role = 'root'
-		)
-	`).Scan(&exists)
-
-	if err != nil {
-		return err
-	}
-
-	if exists {
-		log.Println("Root user already exists")
-		return nil
-	}
-
-	// Create the root user with hardcoded defaults
-	log.Println("Creating default root user...")
-
-	user := &db.CreateUserRequest{
-		Username: "root",
-		Email:    "root@rulestack.init",
-		Password: "root1234",
-		Role:     db.RoleRoot,
-	}
-
-	// Use the existing CreateUser method to create the root user
-	_, err = database.CreateUser(*user)
-	if err != nil {
-		// Check if it's a duplicate error (race condition)
-		if err.Error() == "username or email already exists" {
-			log.Println("Root user was created by another process")
-			return nil
-		}
-		return err
-	}
-
-	log.Println("Root user created successfully")
-	return nil
+	log.Fatal(api.Serve(database, cfg))
 }
@@ -2,9 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"rulestack/internal/auth"
 	"rulestack/internal/db"
@@ -46,14 +49,14 @@ func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request) {
 	// Only admins can create accounts with publisher or admin roles
 	if req.Role != db.RoleUser {
 		user := getUserFromContext(r.Context())
-		if user == nil || !user.Role.HasPermission("admin") {
+		if user == nil || !user.Role.HasPermission(db.PermissionAdmin) {
 			writeError(w, http.StatusForbidden, "Only admins can create accounts with elevated permissions")
 			return
 		}
 	}
 
 	// Create user
-	user, err := s.DB.CreateUser(req)
+	user, err := s.DB.WithContext(r.Context()).CreateUser(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
 			writeError(w, http.StatusConflict, "Username or email already exists")
@@ -90,7 +93,7 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user
-	user, err := s.DB.GetUserByUsername(req.Username)
+	user, err := s.DB.WithContext(r.Context()).GetUserByUsername(req.Username)
 	if err != nil {
 		writeError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
@@ -102,8 +105,9 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT token (use development duration for long-lived tokens)
-	jwtManager := auth.NewJWTManager(s.Config.JWTSecret, auth.DevelopmentTokenDuration)
+	// Generate JWT token
+	lifetime := s.tokenLifetime()
+	jwtManager := auth.NewJWTManager(s.Config.JWTSecret, lifetime)
 	tokenString, tokenHash, expiresAt, err := jwtManager.GenerateToken(user)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to generate token")
@@ -112,15 +116,15 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Store session in database
 	userAgent := r.Header.Get("User-Agent")
-	ipAddress := getClientIP(r)
-	session, err := s.DB.CreateUserSession(user.ID, tokenHash, expiresAt, &userAgent, &ipAddress)
+	ipAddress := s.getClientIP(r)
+	session, err := s.DB.WithContext(r.Context()).CreateUserSession(user.ID, tokenHash, expiresAt, &userAgent, &ipAddress)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to create session")
 		return
 	}
 
 	// Update last login
-	if err := s.DB.UpdateLastLogin(user.ID); err != nil {
+	if err := s.DB.WithContext(r.Context()).UpdateLastLogin(user.ID); err != nil {
 		// Log but don't fail
 		writeError(w, http.StatusInternalServerError, "Failed to update last login")
 		return
@@ -130,6 +134,7 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"token":      tokenString,
 		"expires_at": expiresAt,
+		"expires_in": int(lifetime.Seconds()),
 		"user": map[string]interface{}{
 			"id":       user.ID,
 			"username": user.Username,
@@ -138,10 +143,39 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		},
 		"session_id": session.ID,
 	}
+	if s.Config.SessionIdleTimeout > 0 {
+		response["idle_timeout"] = int(s.Config.SessionIdleTimeout.Seconds())
+	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
+// tokenLifetime is how long the token of a new login is valid: the configured
+// lifetime, cut short by the absolute session lifetime
+func (s *Server) tokenLifetime() time.Duration {
+	lifetime := s.Config.TokenLifetime
+	if lifetime <= 0 {
+		lifetime = auth.DefaultTokenDuration
+	}
+	if s.Config.SessionMaxLifetime > 0 && s.Config.SessionMaxLifetime < lifetime {
+		lifetime = s.Config.SessionMaxLifetime
+	}
+	return lifetime
+}
+
+// sessionExpiry returns why the session policy ends a session at now, or ""
+// while it may still be used. The policy also ends sessions whose tokens were
+// issued before it was tightened.
+func (s *Server) sessionExpiry(session *db.UserSession, now time.Time) string {
+	if s.Config.SessionMaxLifetime > 0 && now.Sub(session.CreatedAt) > s.Config.SessionMaxLifetime {
+		return "Session expired; log in again"
+	}
+	if s.Config.SessionIdleTimeout > 0 && now.Sub(session.LastUsed) > s.Config.SessionIdleTimeout {
+		return "Session expired after inactivity; log in again"
+	}
+	return ""
+}
+
 // logoutHandler handles user logout
 func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
@@ -153,7 +187,7 @@ func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	session := getUserSessionFromContext(r.Context())
 	if session != nil {
 		// Delete the session
-		if _, err := s.DB.Exec(`DELETE FROM user_sessions WHERE id = $1`, session.ID); err != nil {
+		if _, err := s.DB.ExecContext(r.Context(), `DELETE FROM user_sessions WHERE id = $1`, session.ID); err != nil {
 			writeError(w, http.StatusInternalServerError, "Failed to logout")
 			return
 		}
@@ -195,7 +229,7 @@ func (s *Server) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Change password
-	if err := s.DB.ChangeUserPassword(user.ID, req.NewPassword); err != nil {
+	if err := s.DB.WithContext(r.Context()).ChangeUserPassword(user.ID, req.NewPassword); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to change password")
 		return
 	}
@@ -205,9 +239,9 @@ func (s *Server) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	query := `DELETE FROM user_sessions WHERE user_id = $1`
 	if session != nil {
 		query += ` AND id != $2`
-		_, _ = s.DB.Exec(query, user.ID, session.ID)
+		_, _ = s.DB.ExecContext(r.Context(), query, user.ID, session.ID)
 	} else {
-		_, _ = s.DB.Exec(query, user.ID)
+		_, _ = s.DB.ExecContext(r.Context(), query, user.ID)
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
@@ -228,7 +262,7 @@ func (s *Server) deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete user account
-	if err := s.DB.DeleteUser(user.ID); err != nil {
+	if err := s.DB.WithContext(r.Context()).DeleteUser(user.ID); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to delete account")
 		return
 	}
@@ -244,23 +278,109 @@ func (s *Server) profileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	profile, err := s.DB.WithContext(r.Context()).GetUserProfile(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get profile")
+		return
+	}
+
 	response := map[string]interface{}{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"role":       user.Role,
-		"created_at": user.CreatedAt,
-		"updated_at": user.UpdatedAt,
-		"last_login": user.LastLogin,
+		"id":           user.ID,
+		"username":     user.Username,
+		"email":        user.Email,
+		"role":         user.Role,
+		"created_at":   user.CreatedAt,
+		"updated_at":   user.UpdatedAt,
+		"last_login":   user.LastLogin,
+		"display_name": profile.DisplayName,
+		"url":          profile.URL,
+		"avatar_url":   profile.AvatarURL,
+		"bio":          profile.Bio,
 	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
+// updateProfileHandler changes the public profile fields of the current user.
+// Fields left out of the request keep their value; empty strings clear them.
+func (s *Server) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	profile, err := database.GetUserProfile(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get profile")
+		return
+	}
+
+	fields := []struct {
+		name   string
+		value  *string
+		target **string
+		max    int
+		isURL  bool
+	}{
+		{"display_name", req.DisplayName, &profile.DisplayName, 100, false},
+		{"url", req.URL, &profile.URL, 500, true},
+		{"avatar_url", req.AvatarURL, &profile.AvatarURL, 500, true},
+		{"bio", req.Bio, &profile.Bio, 1000, false},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if len(value) > field.max {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", field.name, field.max))
+			return
+		}
+		if value == "" {
+			*field.target = nil
+			continue
+		}
+		if field.isURL && !isWebURL(value) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be an http or https URL", field.name))
+			return
+		}
+		*field.target = &value
+	}
+
+	if err := database.UpdateUserProfile(user.ID, *profile); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// updateProfileRequest is the body of a profile update; nil fields are unchanged
+type updateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	URL         *string `json:"url"`
+	AvatarURL   *string `json:"avatar_url"`
+	Bio         *string `json:"bio"`
+}
+
+// isWebURL reports whether s is an absolute http or https URL
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 // listUsersHandler returns all users (admin only)
 func (s *Server) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
-	if user == nil || !user.Role.HasPermission("admin") {
+	if user == nil || !user.Role.HasPermission(db.PermissionAdmin) {
 		writeError(w, http.StatusForbidden, "Admin access required")
 		return
 	}
@@ -282,7 +402,7 @@ func (s *Server) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get users
-	users, err := s.DB.ListUsers(limit, offset)
+	users, err := s.DB.WithContext(r.Context()).ListUsers(limit, offset)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to retrieve users")
 		return
@@ -305,10 +425,23 @@ func (s *Server) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, response)
 }
 
+// permissionsHandler returns the permissions each role grants (admin only)
+func (s *Server) permissionsHandler(w http.ResponseWriter, r *http.Request) {
+	roles := map[db.UserRole][]db.Permission{db.RoleRoot: db.KnownPermissions}
+	for role, permissions := range db.ActivePermissionMatrix() {
+		roles[role] = permissions
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"roles":       roles,
+		"permissions": db.KnownPermissions,
+	})
+}
+
 // adminDeleteUserHandler allows admins to delete other users
 func (s *Server) adminDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
-	if user == nil || !user.Role.HasPermission("admin") {
+	if user == nil || !user.Role.HasPermission(db.PermissionAdmin) {
 		writeError(w, http.StatusForbidden, "Admin access required")
 		return
 	}
@@ -328,14 +461,14 @@ func (s *Server) adminDeleteUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Check if target user exists
-	targetUser, err := s.DB.GetUserByID(userID)
+	targetUser, err := s.DB.WithContext(r.Context()).GetUserByID(userID)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
 	// Delete user account
-	if err := s.DB.DeleteUser(targetUser.ID); err != nil {
+	if err := s.DB.WithContext(r.Context()).DeleteUser(targetUser.ID); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to delete user")
 		return
 	}
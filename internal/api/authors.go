@@ -0,0 +1,67 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+	"rulestack/internal/version"
+)
+
+// authorPage is a user's public profile with the packages they publish
+type authorPage struct {
+	db.Author
+	Packages []db.SearchResult `json:"packages"` // Latest version each, newest package first
+}
+
+// authorHandler returns the public page of an author: their profile and the
+// latest version of each package they published
+func (s *Server) authorHandler(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	database := s.DB.WithContext(r.Context())
+	author, err := database.GetAuthor(username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	published, err := database.ListAuthorPackages(author.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list packages")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, authorPage{Author: *author, Packages: latestResults(published)})
+}
+
+// latestResults keeps the latest version of each package in results, in the
+// order the packages first appear
+func latestResults(results []db.SearchResult) []db.SearchResult {
+	versions := map[string][]string{}
+	var names []string
+	for _, result := range results {
+		if _, seen := versions[result.Name]; !seen {
+			names = append(names, result.Name)
+		}
+		versions[result.Name] = append(versions[result.Name], result.Version)
+	}
+
+	latest := make([]db.SearchResult, 0, len(names))
+	for _, name := range names {
+		want := version.Latest(versions[name])
+		for _, result := range results {
+			if result.Name == name && result.Version == want {
+				latest = append(latest, result)
+				break
+			}
+		}
+	}
+	return latest
+}
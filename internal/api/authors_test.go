@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+)
+
+func TestAuthorProfiles(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	alice, err := database.CreateUser(db.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "secret123", Role: db.RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	publish := func(name, version, status string) {
+		if _, err := database.PublishPackageVersion(name, db.PackageVersion{Version: version, Status: status, PublishedBy: &alice.ID}, nil, func() error { return nil }); err != nil {
+			t.Fatalf("PublishPackageVersion failed: %v", err)
+		}
+	}
+	publish("security-rules", "1.0.0", db.VersionStatusPublished)
+	publish("security-rules", "1.2.0", db.VersionStatusPublished)
+	publish("security-rules", "2.0.0", db.VersionStatusAwaitingApproval)
+	publish("style-rules", "0.1.0", db.VersionStatusPublished)
+	s := &Server{DB: database}
+
+	updateProfile := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("PUT", "/v1/auth/profile", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), userContextKey, alice))
+		w := httptest.NewRecorder()
+		s.updateProfileHandler(w, r)
+		return w
+	}
+	authorPage := func(username string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/v1/users/"+username, nil)
+		r = mux.SetURLVars(r, map[string]string{"username": username})
+		w := httptest.NewRecorder()
+		s.authorHandler(w, r)
+		return w
+	}
+
+	if w := updateProfile(`{"display_name": " Alice Liddell ", "url": "https://alice.example.com", "bio": "Writes security rules"}`); w.Code != http.StatusOK {
+		t.Fatalf("update profile: status %d: %s", w.Code, w.Body.String())
+	}
+	// Fields left out keep their value, empty ones are cleared
+	w := updateProfile(`{"bio": ""}`)
+	var profile db.UserProfile
+	json.NewDecoder(w.Body).Decode(&profile)
+	if w.Code != http.StatusOK || profile.DisplayName == nil || *profile.DisplayName != "Alice Liddell" || profile.URL == nil || profile.Bio != nil {
+		t.Errorf("unexpected profile after a partial update: %d %+v", w.Code, profile)
+	}
+	if w := updateProfile(`{"avatar_url": "javascript:alert(1)"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("non-web avatar URL: status %d, want 400", w.Code)
+	}
+	if w := updateProfile(`{"display_name": "` + strings.Repeat("a", 101) + `"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("overlong display name: status %d, want 400", w.Code)
+	}
+
+	w = authorPage("alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("author page: status %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "alice@example.com") {
+		t.Errorf("author page exposes the email address: %s", w.Body.String())
+	}
+	var page struct {
+		Username    string            `json:"username"`
+		DisplayName string            `json:"display_name"`
+		Packages    []db.SearchResult `json:"packages"`
+	}
+	json.NewDecoder(w.Body).Decode(&page)
+	if page.Username != "alice" || page.DisplayName != "Alice Liddell" || len(page.Packages) != 2 {
+		t.Fatalf("unexpected author page %+v", page)
+	}
+	versions := map[string]string{}
+	for _, pkg := range page.Packages {
+		versions[pkg.Name] = pkg.Version
+	}
+	if versions["security-rules"] != "1.2.0" || versions["style-rules"] != "0.1.0" {
+		t.Errorf("expected the latest published version of each package, got %v", versions)
+	}
+
+	if w := authorPage("nobody"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status %d, want 404", w.Code)
+	}
+	if err := database.DeleteUser(alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if w := authorPage("alice"); w.Code != http.StatusNotFound {
+		t.Errorf("deactivated user: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"rulestack/internal/db"
+	"rulestack/internal/version"
+)
+
+// maxBulkPackages bounds how many versions one bulk request may look up
+const maxBulkPackages = 200
+
+// bulkVersion is the metadata of one requested package version
+type bulkVersion struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Found       bool   `json:"found"`
+	SHA256      string `json:"sha256,omitempty"`
+	Integrity   string `json:"integrity,omitempty"`
+	Size        int    `json:"size,omitempty"`
+	Deprecated  string `json:"deprecated,omitempty"`
+	Replacement string `json:"replacement,omitempty"` // What to use instead of a deprecated version
+	Latest      string `json:"latest,omitempty"`      // Newest published version of the package
+}
+
+// bulkPackageVersionsHandler returns metadata for many name@version pairs in one round trip
+func (s *Server) bulkPackageVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Packages) == 0 || len(req.Packages) > maxBulkPackages {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Request between 1 and %d packages", maxBulkPackages))
+		return
+	}
+
+	refs := make([]bulkVersion, len(req.Packages))
+	var names []string
+	seen := make(map[string]bool)
+	for i, spec := range req.Packages {
+		name, v, ok := strings.Cut(spec, "@")
+		if !ok || name == "" || v == "" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid package %q: use name@version", spec))
+			return
+		}
+		refs[i] = bulkVersion{Name: name, Version: v}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	versions, err := s.DB.WithContext(r.Context()).ListPublishedVersionsOf(names)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to look up package versions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"packages": resolveBulkVersions(refs, versions),
+	})
+}
+
+// resolveBulkVersions fills in each requested version from the published versions of its package
+func resolveBulkVersions(refs []bulkVersion, published []db.NamedPackageVersion) []bulkVersion {
+	byPackage := make(map[string][]db.NamedPackageVersion)
+	for _, v := range published {
+		byPackage[v.PackageName] = append(byPackage[v.PackageName], v)
+	}
+
+	results := make([]bulkVersion, len(refs))
+	for i, ref := range refs {
+		result := ref
+		var available []string
+		for _, v := range byPackage[ref.Name] {
+			available = append(available, v.Version)
+			if v.Version != ref.Version {
+				continue
+			}
+
+			result.Found = true
+			if v.SHA256 != nil {
+				result.SHA256 = *v.SHA256
+			}
+			if v.Integrity != nil {
+				result.Integrity = *v.Integrity
+			}
+			if v.SizeBytes != nil {
+				result.Size = *v.SizeBytes
+			}
+			if v.Deprecated != nil {
+				result.Deprecated = *v.Deprecated
+			}
+			if v.Replacement != nil {
+				result.Replacement = *v.Replacement
+			}
+		}
+		result.Latest = version.Latest(available)
+		results[i] = result
+	}
+
+	return results
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+
+	"rulestack/internal/db"
+)
+
+func TestResolveBulkVersions(t *testing.T) {
+	published := func(name, v, sha string, size int, deprecated string) db.NamedPackageVersion {
+		pv := db.NamedPackageVersion{
+			PackageName:    name,
+			PackageVersion: db.PackageVersion{Version: v, SHA256: &sha, SizeBytes: &size},
+		}
+		if deprecated != "" {
+			pv.Deprecated = &deprecated
+		}
+		return pv
+	}
+
+	versions := []db.NamedPackageVersion{
+		published("rules", "1.0.0", "aaa", 100, "Use 2.x"),
+		published("rules", "2.0.0", "bbb", 200, ""),
+		published("rules", "2.1.0-beta.1", "ccc", 300, ""),
+		published("other", "0.1.0", "ddd", 400, ""),
+	}
+
+	results := resolveBulkVersions([]bulkVersion{
+		{Name: "rules", Version: "1.0.0"},
+		{Name: "other", Version: "0.1.0"},
+		{Name: "rules", Version: "9.9.9"},
+		{Name: "missing", Version: "1.0.0"},
+	}, versions)
+
+	expected := []bulkVersion{
+		{Name: "rules", Version: "1.0.0", Found: true, SHA256: "aaa", Size: 100, Deprecated: "Use 2.x", Latest: "2.0.0"},
+		{Name: "other", Version: "0.1.0", Found: true, SHA256: "ddd", Size: 400, Latest: "0.1.0"},
+		{Name: "rules", Version: "9.9.9", Latest: "2.0.0"},
+		{Name: "missing", Version: "1.0.0"},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("result %d: expected %+v, got %+v", i, expected[i], results[i])
+		}
+	}
+}
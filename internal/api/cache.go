@@ -0,0 +1,153 @@
+package api
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// responseCache is an in-process LRU cache for hot read queries such as search and
+// package metadata. Any write that changes what clients can see invalidates the
+// whole cache; the TTL bounds staleness when several registry instances share a
+// database. A nil cache is valid and never hits.
+type responseCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used at the front
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// CacheStats reports cache effectiveness
+type CacheStats struct {
+	Entries       int     `json:"entries"`
+	Capacity      int     `json:"capacity"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Invalidations int64   `json:"invalidations"`
+}
+
+// newResponseCache returns a cache holding up to capacity entries, or nil if capacity is 0
+func newResponseCache(capacity int, ttl time.Duration) *responseCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &responseCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached value for key. Cached values are shared and must not be modified.
+func (c *responseCache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok || time.Now().After(element.Value.(*cacheEntry).expires) {
+		if ok {
+			c.remove(element)
+		}
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits.Add(1)
+	return element.Value.(*cacheEntry).value, true
+}
+
+// Set caches value under key, evicting the least recently used entry when full
+func (c *responseCache) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	for c.order.Len() >= c.capacity {
+		c.remove(c.order.Back())
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: time.Now().Add(c.ttl)})
+}
+
+// Invalidate drops every entry
+func (c *responseCache) Invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.invalidations.Add(1)
+}
+
+// Stats returns the current hit and miss counters
+func (c *responseCache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:       entries,
+		Capacity:      c.capacity,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+func (c *responseCache) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*cacheEntry).key)
+	c.order.Remove(element)
+}
+
+// writeCached writes a cached or freshly loaded value, marking which in X-Cache
+func writeCached(w http.ResponseWriter, value interface{}, hit bool) {
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	writeJSON(w, http.StatusOK, value)
+}
+
+// cacheStatsHandler reports cache hit metrics
+func (s *Server) cacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Cache.Stats())
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	t.Run("hits and misses", func(t *testing.T) {
+		c := newResponseCache(10, time.Minute)
+		if _, ok := c.Get("search"); ok {
+			t.Fatal("expected miss on empty cache")
+		}
+		c.Set("search", []string{"rules"})
+		if value, ok := c.Get("search"); !ok || value.([]string)[0] != "rules" {
+			t.Fatalf("expected hit, got %v %v", value, ok)
+		}
+
+		stats := c.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 || stats.HitRatio != 0.5 || stats.Entries != 1 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		c := newResponseCache(2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		if _, ok := c.Get("b"); ok {
+			t.Error("expected least recently used entry to be evicted")
+		}
+		if _, ok := c.Get("a"); !ok {
+			t.Error("expected recently used entry to be kept")
+		}
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		c := newResponseCache(10, time.Millisecond)
+		c.Set("a", 1)
+		time.Sleep(5 * time.Millisecond)
+		if _, ok := c.Get("a"); ok {
+			t.Error("expected expired entry to miss")
+		}
+		if c.Stats().Entries != 0 {
+			t.Error("expected expired entry to be removed")
+		}
+	})
+
+	t.Run("invalidate drops everything", func(t *testing.T) {
+		c := newResponseCache(10, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Invalidate()
+		if _, ok := c.Get("a"); ok {
+			t.Error("expected invalidated entry to miss")
+		}
+		if stats := c.Stats(); stats.Entries != 0 || stats.Invalidations != 1 {
+			t.Errorf("unexpected stats after invalidation: %+v", stats)
+		}
+	})
+
+	t.Run("disabled cache never hits", func(t *testing.T) {
+		c := newResponseCache(0, time.Minute)
+		c.Set("a", 1)
+		c.Invalidate()
+		if _, ok := c.Get("a"); ok {
+			t.Error("expected disabled cache to miss")
+		}
+	})
+}
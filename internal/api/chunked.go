@@ -0,0 +1,297 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/integrity"
+)
+
+// Chunked uploads let large archives survive flaky connections. The client opens
+// an upload session, appends the archive in chunks at the offset the registry last
+// acknowledged, and completes the publish once the whole archive has arrived:
+//
+//	POST   /v1/uploads                {"size": n}             -> session
+//	GET    /v1/uploads/{id}                                   -> session, with the acknowledged offset
+//	PATCH  /v1/uploads/{id}           Upload-Offset: n, bytes -> new offset
+//	POST   /v1/uploads/{id}/complete  manifest, sha256[, integrity] -> the publish response
+//	DELETE /v1/uploads/{id}                                   -> aborts the session
+//
+// The partial archive and the session live next to each other in storage, as
+// .upload-<id>.tgz and .upload-<id>.json, so a session survives a restart and
+// is swept like any other abandoned upload once idle for orphanGracePeriod.
+
+// uploadChunkSize is the largest chunk accepted by one PATCH
+const uploadChunkSize = 4 << 20
+
+// uploadOffsetHeader carries the offset a chunk starts at
+const uploadOffsetHeader = "Upload-Offset"
+
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// uploadSession is the stored state of a chunked upload
+type uploadSession struct {
+	ID     string `json:"id"`
+	UserID int    `json:"user_id"`
+	Size   int64  `json:"size"` // Declared archive size
+}
+
+// uploadStatus is the response describing a session
+type uploadStatus struct {
+	ID        string `json:"id"`
+	Size      int64  `json:"size"`
+	Offset    int64  `json:"offset"` // Bytes received and stored so far
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+// uploadLocks serializes the requests of each session, keyed by upload ID
+var uploadLocks sync.Map
+
+func lockUpload(id string) func() {
+	value, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *Server) uploadPaths(id string) (data, session string) {
+	base := filepath.Join(s.Config.StoragePath, ".upload-"+id)
+	return base + ".tgz", base + ".json"
+}
+
+// createUploadHandler opens a chunked upload session
+func (s *Server) createUploadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req struct {
+		Size int64 `json:"size"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxManifestSize)).Decode(&req); err != nil || req.Size <= 0 {
+		writeError(w, http.StatusBadRequest, "Request must give the archive size")
+		return
+	}
+	if req.Size > s.Config.MaxArchiveSize {
+		writeError(w, http.StatusRequestEntityTooLarge, archiveTooLarge(s.Config.MaxArchiveSize).Error())
+		return
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create upload")
+		return
+	}
+	session := uploadSession{ID: hex.EncodeToString(idBytes), UserID: user.ID, Size: req.Size}
+
+	dataPath, sessionPath := s.uploadPaths(session.ID)
+	if err := os.MkdirAll(s.Config.StoragePath, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create upload")
+		return
+	}
+	if err := os.WriteFile(dataPath, nil, 0o644); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create upload")
+		return
+	}
+	data, _ := json.Marshal(session)
+	if err := os.WriteFile(sessionPath, data, 0o644); err != nil {
+		os.Remove(dataPath)
+		writeError(w, http.StatusInternalServerError, "Failed to create upload")
+		return
+	}
+
+	w.Header().Set("Location", "/v1/uploads/"+session.ID)
+	writeJSON(w, http.StatusCreated, uploadStatus{ID: session.ID, Size: session.Size, ChunkSize: uploadChunkSize})
+}
+
+// loadUpload finds the caller's session named in the request; other users'
+// sessions are reported as missing
+func (s *Server) loadUpload(w http.ResponseWriter, r *http.Request) (*uploadSession, int64, bool) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return nil, 0, false
+	}
+
+	id := mux.Vars(r)["id"]
+	if !uploadIDPattern.MatchString(id) {
+		writeError(w, http.StatusNotFound, "Upload not found")
+		return nil, 0, false
+	}
+
+	dataPath, sessionPath := s.uploadPaths(id)
+	var session uploadSession
+	data, err := os.ReadFile(sessionPath)
+	if err == nil {
+		err = json.Unmarshal(data, &session)
+	}
+	info, statErr := os.Stat(dataPath)
+	if err != nil || statErr != nil || session.UserID != user.ID {
+		writeError(w, http.StatusNotFound, "Upload not found")
+		return nil, 0, false
+	}
+	return &session, info.Size(), true
+}
+
+// getUploadHandler reports how much of an upload the registry has stored
+func (s *Server) getUploadHandler(w http.ResponseWriter, r *http.Request) {
+	unlock := lockUpload(mux.Vars(r)["id"])
+	defer unlock()
+
+	session, offset, ok := s.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, uploadStatus{ID: session.ID, Size: session.Size, Offset: offset, ChunkSize: uploadChunkSize})
+}
+
+// patchUploadHandler appends a chunk at the offset the registry acknowledged.
+// Whatever part of a chunk arrives before a dropped connection is kept, so the
+// client resumes from the offset the session reports.
+func (s *Server) patchUploadHandler(w http.ResponseWriter, r *http.Request) {
+	unlock := lockUpload(mux.Vars(r)["id"])
+	defer unlock()
+
+	session, offset, ok := s.loadUpload(w, r)
+	if !ok {
+		return
+	}
+
+	start, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, uploadOffsetHeader+" header required")
+		return
+	}
+	if start != offset {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":      fmt.Sprintf("Chunk starts at %d, but the upload is at %d", start, offset),
+			"code":       CodeConflict,
+			"offset":     offset, // Where the client resumes
+			"request_id": w.Header().Get(RequestIDHeader),
+		})
+		return
+	}
+
+	limit := min(int64(uploadChunkSize), session.Size-offset)
+	dataPath, _ := s.uploadPaths(session.ID)
+	file, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to store chunk")
+		return
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, io.LimitReader(r.Body, limit))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read chunk")
+		return
+	}
+
+	// Bytes beyond the chunk size or the declared archive size are refused
+	if n, _ := r.Body.Read(make([]byte, 1)); n > 0 {
+		file.Truncate(offset)
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Chunk exceeds %d bytes or the declared archive size", limit))
+		return
+	}
+
+	// Keep the session as fresh as its data for the orphan sweep
+	_, sessionPath := s.uploadPaths(session.ID)
+	now := time.Now()
+	os.Chtimes(sessionPath, now, now)
+
+	writeJSON(w, http.StatusOK, uploadStatus{ID: session.ID, Size: session.Size, Offset: offset + written, ChunkSize: uploadChunkSize})
+}
+
+// completeUploadHandler publishes a fully uploaded archive. The request is a
+// multipart form with the manifest, as for a single-request publish, and the
+// archive's sha256.
+func (s *Server) completeUploadHandler(w http.ResponseWriter, r *http.Request) {
+	unlock := lockUpload(mux.Vars(r)["id"])
+	defer unlock()
+
+	session, offset, ok := s.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	if offset != session.Size {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":      fmt.Sprintf("Upload is incomplete: %d of %d bytes received", offset, session.Size),
+			"code":       CodeConflict,
+			"offset":     offset, // Where the client resumes
+			"request_id": w.Header().Get(RequestIDHeader),
+		})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxManifestSize)
+	if err := r.ParseMultipartForm(2 * maxManifestSize); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to parse form")
+		return
+	}
+	manifestFile, _, err := r.FormFile("manifest")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Manifest file required")
+		return
+	}
+	manifest, err := io.ReadAll(io.LimitReader(manifestFile, maxManifestSize))
+	manifestFile.Close()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read manifest")
+		return
+	}
+
+	dataPath, sessionPath := s.uploadPaths(session.ID)
+	digests, err := integrity.File(dataPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read upload")
+		return
+	}
+	sha256Digest, _ := digests.Get(integrity.SHA256)
+	sum := sha256Digest.Hex()
+
+	// The session is finished either way: published, or corrupt and restarted
+	defer uploadLocks.Delete(session.ID)
+	defer os.Remove(sessionPath)
+	upload := &upload{Manifest: manifest, TempPath: dataPath, SHA256: sum, Size: offset, Integrity: digests, Expected: r.FormValue("integrity")}
+	defer upload.Remove()
+
+	if expected := r.FormValue("sha256"); expected != sum {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Archive SHA256 %s does not match the expected %s; upload it again", sum, expected))
+		return
+	}
+
+	s.publishUpload(w, r, getUserFromContext(r.Context()), upload)
+}
+
+// deleteUploadHandler abandons an upload session
+func (s *Server) deleteUploadHandler(w http.ResponseWriter, r *http.Request) {
+	unlock := lockUpload(mux.Vars(r)["id"])
+	defer unlock()
+
+	session, _, ok := s.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	dataPath, sessionPath := s.uploadPaths(session.ID)
+	os.Remove(dataPath)
+	os.Remove(sessionPath)
+	uploadLocks.Delete(session.ID)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Upload aborted"})
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+)
+
+func TestChunkedUpload(t *testing.T) {
+	s := &Server{Config: config.Config{StoragePath: t.TempDir(), MaxArchiveSize: 1024}}
+	alice := &db.User{ID: 1, Username: "alice"}
+	bob := &db.User{ID: 2, Username: "bob"}
+	archive := bytes.Repeat([]byte("a"), 600)
+
+	request := func(user *db.User, method, id string, body []byte, offset int64) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/v1/uploads/"+id, bytes.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+		r = mux.SetURLVars(r, map[string]string{"id": id})
+		if offset >= 0 {
+			r.Header.Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+		}
+		w := httptest.NewRecorder()
+		switch method {
+		case "POST":
+			s.createUploadHandler(w, r)
+		case "GET":
+			s.getUploadHandler(w, r)
+		case "PATCH":
+			s.patchUploadHandler(w, r)
+		case "DELETE":
+			s.deleteUploadHandler(w, r)
+		}
+		return w
+	}
+	complete := func(id, sum string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("manifest", "manifest.json")
+		part.Write([]byte(`{"name":"rules","version":"1.0.0"}`))
+		writer.WriteField("sha256", sum)
+		writer.Close()
+
+		r := httptest.NewRequest("POST", "/v1/uploads/"+id+"/complete", &body)
+		r.Header.Set("Content-Type", writer.FormDataContentType())
+		r = r.WithContext(context.WithValue(r.Context(), userContextKey, alice))
+		r = mux.SetURLVars(r, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		s.completeUploadHandler(w, r)
+		return w
+	}
+	offsetOf := func(w *httptest.ResponseRecorder) int64 {
+		var status uploadStatus
+		json.Unmarshal(w.Body.Bytes(), &status)
+		return status.Offset
+	}
+
+	if w := request(alice, "POST", "", []byte(`{"size": 2048}`), -1); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: status %d, want 413", w.Code)
+	}
+
+	w := request(alice, "POST", "", []byte(fmt.Sprintf(`{"size": %d}`, len(archive))), -1)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body.String())
+	}
+	var session uploadStatus
+	json.Unmarshal(w.Body.Bytes(), &session)
+	if w.Header().Get("Location") != "/v1/uploads/"+session.ID || session.ChunkSize != uploadChunkSize {
+		t.Errorf("create returned %+v at %q", session, w.Header().Get("Location"))
+	}
+	id := session.ID
+
+	if w := request(alice, "PATCH", id, archive[:200], 0); w.Code != http.StatusOK || offsetOf(w) != 200 {
+		t.Fatalf("first chunk: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(alice, "PATCH", id, archive[:200], 0); w.Code != http.StatusConflict || offsetOf(w) != 200 {
+		t.Errorf("replayed chunk: status %d, want 409 at offset 200: %s", w.Code, w.Body.String())
+	}
+	if w := request(alice, "PATCH", id, archive[200:], -1); w.Code != http.StatusBadRequest {
+		t.Errorf("chunk without offset: status %d, want 400", w.Code)
+	}
+	if w := request(bob, "GET", id, nil, -1); w.Code != http.StatusNotFound {
+		t.Errorf("another user's upload: status %d, want 404", w.Code)
+	}
+	if w := complete(id, ""); w.Code != http.StatusConflict || offsetOf(w) != 200 {
+		t.Errorf("incomplete upload: status %d, want 409: %s", w.Code, w.Body.String())
+	}
+	if w := request(alice, "PATCH", id, append(archive[200:], 'x'), 200); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunk past the declared size: status %d, want 413", w.Code)
+	}
+	if w := request(alice, "GET", id, nil, -1); w.Code != http.StatusOK || offsetOf(w) != 200 {
+		t.Errorf("refused chunk was kept: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(alice, "PATCH", id, archive[200:], 200); w.Code != http.StatusOK || offsetOf(w) != int64(len(archive)) {
+		t.Fatalf("last chunk: status %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := complete(id, fmt.Sprintf("%x", sha256.Sum256([]byte("other")))); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("wrong sha256: status %d, want 422: %s", w.Code, w.Body.String())
+	}
+	dataPath, sessionPath := s.uploadPaths(id)
+	for _, path := range []string{dataPath, sessionPath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s removed after a failed completion", path)
+		}
+	}
+
+	t.Run("abort", func(t *testing.T) {
+		w := request(alice, "POST", "", []byte(`{"size": 10}`), -1)
+		json.Unmarshal(w.Body.Bytes(), &session)
+		if w := request(alice, "DELETE", session.ID, nil, -1); w.Code != http.StatusOK {
+			t.Fatalf("delete: status %d", w.Code)
+		}
+		if w := request(alice, "GET", session.ID, nil, -1); w.Code != http.StatusNotFound {
+			t.Errorf("aborted upload: status %d, want 404", w.Code)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings the API compresses JSON responses with and accepts on
+// request bodies, in order of preference when a client weighs them equally
+var contentCodings = []string{"gzip", "br", "zstd"}
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriter(io.Discard) }}
+	zstdWriters   = sync.Pool{New: func() interface{} {
+		encoder, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return encoder
+	}}
+)
+
+// compressionMiddleware negotiates compression through the standard headers.
+// JSON responses are compressed in the best coding the client's Accept-Encoding
+// allows, and request bodies sent with a Content-Encoding are decompressed, up
+// to maxBytes, before handlers read them.
+func compressionMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if coding := r.Header.Get("Content-Encoding"); coding != "" && coding != "identity" {
+				body, err := decompressBody(r.Body, coding)
+				if err == http.ErrNotSupported {
+					writeErrorCode(w, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+						"Unsupported request Content-Encoding "+coding, map[string]interface{}{"accepted": contentCodings})
+					return
+				}
+				if err != nil {
+					writeError(w, http.StatusBadRequest, "Request body is not valid "+coding)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, body, maxBytes)
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			}
+
+			coding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if coding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, coding: coding}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// decompressBody returns a reader decompressing a request body in a coding
+func decompressBody(body io.ReadCloser, coding string) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(coding)) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "br":
+		return io.NopCloser(brotli.NewReader(body)), nil
+	case "zstd":
+		decoder, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, http.ErrNotSupported
+	}
+}
+
+// negotiateEncoding picks the content coding to compress a response with from
+// an Accept-Encoding header, or "" to send it uncompressed
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	weights := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if coding == "*" {
+			wildcard = q
+			continue
+		}
+		weights[coding] = q
+	}
+
+	for _, coding := range contentCodings {
+		q, ok := weights[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter compresses a response once its headers show it is JSON that
+// no handler has already encoded
+type compressWriter struct {
+	http.ResponseWriter
+	coding  string
+	encoder io.WriteCloser
+	decided bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.decided = true
+		header := cw.Header()
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		if mediaType == "application/json" {
+			header.Add("Vary", "Accept-Encoding")
+			if header.Get("Content-Encoding") == "" && code != http.StatusNoContent && code != http.StatusNotModified {
+				header.Set("Content-Encoding", cw.coding)
+				header.Del("Content-Length")
+				cw.encoder = newEncoder(cw.coding, cw.ResponseWriter)
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Close flushes the compressed stream and returns the encoder to its pool
+func (cw *compressWriter) Close() error {
+	if cw.encoder == nil {
+		return nil
+	}
+	err := cw.encoder.Close()
+	switch encoder := cw.encoder.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(encoder)
+	case *brotli.Writer:
+		brotliWriters.Put(encoder)
+	case *zstd.Encoder:
+		zstdWriters.Put(encoder)
+	}
+	cw.encoder = nil
+	return err
+}
+
+// newEncoder takes a pooled encoder of a coding and points it at w
+func newEncoder(coding string, w io.Writer) io.WriteCloser {
+	switch coding {
+	case "zstd":
+		encoder := zstdWriters.Get().(*zstd.Encoder)
+		encoder.Reset(w)
+		return encoder
+	case "br":
+		encoder := brotliWriters.Get().(*brotli.Writer)
+		encoder.Reset(w)
+		return encoder
+	}
+	encoder := gzipWriters.Get().(*gzip.Writer)
+	encoder.Reset(w)
+	return encoder
+}
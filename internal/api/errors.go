@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Error codes of API error responses. Clients tell errors apart by code; the
+// message is for people and may change between releases.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeGone                 = "gone"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeUnsupportedMediaType = "unsupported_media_type" // The request body is in an encoding the registry cannot read
+	CodeValidation           = "validation_failed"      // The archive failed a check, such as its digests or rule tests
+	CodeRateLimited          = "rate_limited"
+	CodeUnavailable          = "unavailable"
+	CodeInternal             = "internal_error"
+	CodeVersionExists        = "version_exists"   // The package version is already published
+	CodeQuotaExceeded        = "quota_exceeded"   // An archive or upload exceeds the registry's size limit
+	CodeNameInvalid          = "name_invalid"     // The package name does not follow the naming rules
+	CodeNameReserved         = "name_reserved"    // Another user holds the package name
+	CodeInvalidManifest      = "invalid_manifest" // The manifest is not valid JSON or has invalid fields
+)
+
+// RequestIDHeader carries the ID of a request, from the client or assigned by
+// the registry, in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// ErrorResponse is the body of every API error response
+type ErrorResponse struct {
+	Error     string                 `json:"error"` // Human-readable message
+	Code      string                 `json:"code"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// statusCodes gives the error code of responses written without a more specific one
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusNotAcceptable:         CodeNotAcceptable,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusConflict:              CodeConflict,
+	http.StatusGone:                  CodeGone,
+	http.StatusRequestEntityTooLarge: CodeQuotaExceeded,
+	http.StatusUnprocessableEntity:   CodeValidation,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// writeErrorCode writes a JSON error response with a specific code and optional details
+func writeErrorCode(w http.ResponseWriter, status int, code, message string, details map[string]interface{}) {
+	writeJSON(w, status, ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Details:   details,
+		RequestID: w.Header().Get(RequestIDHeader),
+	})
+}
+
+// errorCode returns the error code of a status with no more specific code
+func errorCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// validRequestID matches request IDs a client may choose itself
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestIDMiddleware gives every request an ID, echoed in the X-Request-ID
+// response header and in error responses, so a failure reported by a user can
+// be found in the registry's logs. A well-formed ID sent by the client is kept.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponses(t *testing.T) {
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/exists":
+			writeErrorCode(w, http.StatusConflict, CodeVersionExists, "Package version already exists", map[string]interface{}{"version": "1.0.0"})
+		default:
+			writeError(w, http.StatusRequestEntityTooLarge, "Archive exceeds the maximum size")
+		}
+	}))
+
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		wantCode  string
+		wantID    string
+	}{
+		{name: "specific code", path: "/exists", wantCode: CodeVersionExists},
+		{name: "code from status", path: "/large", wantCode: CodeQuotaExceeded},
+		{name: "client request ID kept", path: "/exists", requestID: "ci-run-42", wantCode: CodeVersionExists, wantID: "ci-run-42"},
+		{name: "malformed request ID replaced", path: "/exists", requestID: "bad id\n", wantCode: CodeVersionExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var body ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Error == "" {
+				t.Errorf("Expected code %s with a message, got %+v", tt.wantCode, body)
+			}
+			if body.RequestID == "" || body.RequestID != rec.Header().Get(RequestIDHeader) {
+				t.Errorf("Expected the response's request ID %q in the body, got %q", rec.Header().Get(RequestIDHeader), body.RequestID)
+			}
+			if tt.wantID != "" && body.RequestID != tt.wantID {
+				t.Errorf("Expected request ID %q, got %q", tt.wantID, body.RequestID)
+			}
+			if tt.requestID != "" && tt.wantID == "" && body.RequestID == tt.requestID {
+				t.Errorf("Expected a malformed request ID to be replaced")
+			}
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package api
 
 import (
-	"crypto/sha256"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,21 +13,30 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
 
+	"rulestack/internal/client"
+	"rulestack/internal/compression"
 	"rulestack/internal/db"
+	"rulestack/internal/integrity"
+	rfhmanifest "rulestack/internal/manifest"
+	"rulestack/internal/ruletest"
+	"rulestack/internal/version"
 )
 
 // healthHandler returns API health status
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	if err := s.DB.Health(); err != nil {
+	if err := s.DB.WithContext(r.Context()).Health(); err != nil {
 		writeError(w, http.StatusServiceUnavailable, "Database connection failed")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"status":  "ok",
-		"service": "rulestack-api",
-		"version": "1.0.0",
+		"status":               "ok",
+		"service":              "rulestack-api",
+		"version":              "1.0.0",
+		"archive_formats":      compression.Formats,
+		"integrity_algorithms": integrity.Algorithms,
 	})
 }
 
@@ -35,6 +45,7 @@ func (s *Server) searchPackagesHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	tag := r.URL.Query().Get("tag")
 	target := r.URL.Query().Get("target")
+	official := r.URL.Query().Get("official") == "true"
 
 	// Parse limit parameter
 	limit := 50 // default
@@ -44,13 +55,30 @@ func (s *Server) searchPackagesHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	results, err := s.DB.SearchPackages(query, tag, target, limit)
+	cacheKey := fmt.Sprintf("search\x00%s\x00%s\x00%s\x00%t\x00%d", query, tag, target, official, limit)
+	if cached, ok := s.Cache.Get(cacheKey); ok {
+		writeCached(w, cached, true)
+		return
+	}
+
+	results, err := s.DB.WithContext(r.Context()).SearchPackages(query, tag, target, official, limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Search failed")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, results)
+	// Supplement local results with upstream packages in pull-through mode
+	if s.Upstream != nil && len(results) < limit {
+		upstream, err := s.Upstream.SearchPackages(r.Context(), client.SearchOptions{Query: query, Tag: tag, Target: target, Official: official, Limit: limit})
+		if err != nil {
+			log.Printf("upstream: search failed: %v", err)
+		} else {
+			results = mergeUpstreamResults(results, upstream, limit)
+		}
+	}
+
+	s.Cache.Set(cacheKey, results)
+	writeCached(w, results, false)
 }
 
 // getPackageHandler gets package information
@@ -58,13 +86,41 @@ func (s *Server) getPackageHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 
-	pkg, err := s.DB.GetPackage(name)
+	cacheKey := "package\x00" + name
+	if cached, ok := s.Cache.Get(cacheKey); ok {
+		writeCached(w, cached, true)
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	pkg, err := database.GetPackage(name)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "Package not found")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, pkg)
+	// Published versions let clients resolve version ranges such as "^2"
+	published, err := database.ListPublishedVersionsOf([]string{name})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list package versions")
+		return
+	}
+
+	details := packageDetails{Package: *pkg, Versions: []string{}}
+	for _, v := range published {
+		details.Versions = append(details.Versions, v.Version)
+	}
+	details.Latest = version.Latest(details.Versions)
+
+	s.Cache.Set(cacheKey, details)
+	writeCached(w, details, false)
+}
+
+// packageDetails is a package with its published versions
+type packageDetails struct {
+	db.Package
+	Latest   string   `json:"latest,omitempty"`
+	Versions []string `json:"versions"`
 }
 
 // getPackageVersionHandler gets specific package version
@@ -75,15 +131,35 @@ func (s *Server) getPackageVersionHandler(w http.ResponseWriter, r *http.Request
 
 	fmt.Printf("[DEBUG] getPackageVersionHandler called with name='%s', version='%s'\n", name, version)
 
-	pkgVersion, err := s.DB.GetPackageVersion(name, version)
+	cacheKey := "version\x00" + name + "\x00" + version
+	if cached, ok := s.Cache.Get(cacheKey); ok {
+		writeCached(w, cached, true)
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	pkgVersion, err := database.GetPackageVersion(name, version)
+	if errors.Is(err, sql.ErrNoRows) && s.Upstream != nil {
+		pkgVersion, err = s.pullThrough(r.Context(), database, name, version)
+		if err != nil && !errors.Is(err, client.ErrVersionNotFound) {
+			log.Printf("upstream: failed to pull %s@%s through: %v", name, version, err)
+		}
+	}
 	if err != nil {
 		fmt.Printf("[ERROR] GetPackageVersion failed: %v\n", err)
 		writeError(w, http.StatusNotFound, "Package version not found")
 		return
 	}
 
+	// Versions still being validated (or rejected) are not visible to clients
+	if pkgVersion.Status != db.VersionStatusPublished {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
 	fmt.Printf("[DEBUG] Found package version: %+v\n", pkgVersion)
-	writeJSON(w, http.StatusOK, pkgVersion)
+	s.Cache.Set(cacheKey, pkgVersion)
+	writeCached(w, pkgVersion, false)
 }
 
 // publishPackageHandler handles package publishing
@@ -99,101 +175,276 @@ func (s *Server) publishPackageHandler(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprintf(os.Stderr, "DEBUG PUBLISH: User authenticated: %s (ID: %d, Role: %s)\n", user.Username, user.ID, user.Role)
 
-	// Parse multipart form
-	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB limit
-		writeError(w, http.StatusBadRequest, "Failed to parse form")
-		return
-	}
-
-	// Get manifest file
-	manifestFile, _, err := r.FormFile("manifest")
+	// Stream the upload to a temp file in storage so large archives never sit in memory
+	upload, err := receiveUpload(w, r, s.Config.StoragePath, s.Config.MaxArchiveSize)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "Manifest file required")
+		var uploadErr *uploadError
+		if errors.As(err, &uploadErr) {
+			writeError(w, uploadErr.status, uploadErr.message)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to save archive")
 		return
 	}
-	defer manifestFile.Close()
+	defer upload.Remove()
 
+	s.publishUpload(w, r, user, upload)
+}
+
+// publishUpload validates an uploaded archive and records it as a new package
+// version, whether it arrived in one request or in chunks
+func (s *Server) publishUpload(w http.ResponseWriter, r *http.Request, user *db.User, upload *upload) {
 	// Parse manifest
 	var manifest struct {
-		Name        string   `json:"name"`
-		Version     string   `json:"version"`
-		Description string   `json:"description"`
-		Targets     []string `json:"targets"`
-		Tags        []string `json:"tags"`
+		Name         string            `json:"name"`
+		Version      string            `json:"version"`
+		Description  string            `json:"description"`
+		Targets      []string          `json:"targets"`
+		Tags         []string          `json:"tags"`
+		Tests        string            `json:"tests"`
+		License      string            `json:"license"`
+		Dependencies map[string]string `json:"dependencies"`
 	}
 
-	if err := json.NewDecoder(manifestFile).Decode(&manifest); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid manifest JSON")
+	if err := json.Unmarshal(upload.Manifest, &manifest); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeInvalidManifest, "Invalid manifest JSON", nil)
 		return
 	}
 
-	// Get archive file
-	archiveFile, _, err := r.FormFile("archive")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "Archive file required")
+	if err := rfhmanifest.ValidateName(manifest.Name); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeNameInvalid, err.Error(), map[string]interface{}{"name": manifest.Name})
+		return
+	}
+
+	if manifest.License != "" {
+		if err := rfhmanifest.ValidateLicense(manifest.License); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeInvalidManifest, err.Error(), nil)
+			return
+		}
+	}
+
+	if err := rfhmanifest.ValidateDependencies(manifest.Dependencies); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeInvalidManifest, err.Error(), nil)
+		return
+	}
+
+	if err := upload.verify(); err != nil {
+		var uploadErr *uploadError
+		errors.As(err, &uploadErr)
+		writeError(w, uploadErr.status, uploadErr.message)
 		return
 	}
-	defer archiveFile.Close()
 
-	// Calculate SHA256 and save archive
-	hasher := sha256.New()
+	// Check the archive is safe to extract before it is committed to storage
+	if err := validateArchive(upload.TempPath); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Invalid archive: %v", err))
+		return
+	}
+
+	// Packages that ship rule tests (or registries that require them) must pass before being accepted
+	if manifest.Tests != "" || s.Config.RequireRuleTests {
+		if err := checkRuleTests(upload.TempPath, manifest.Tests); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+
 	// Sanitize filename by replacing invalid characters
 	safeName := strings.ReplaceAll(manifest.Name, "/", "-")
 	safeName = strings.ReplaceAll(safeName, "@", "")
-	archivePath := filepath.Join(s.Config.StoragePath, fmt.Sprintf("%s-%s.tgz", safeName, manifest.Version))
+	format, _ := compression.DetectFile(upload.TempPath)
+	archivePath := filepath.Join(s.Config.StoragePath, fmt.Sprintf("%s-%s%s", safeName, manifest.Version, compression.Extension(format)))
+
+	sha256Hash := upload.SHA256
+	integrityString := upload.Integrity.String()
+	size := upload.Size
+
+	// Create package version
+	version := db.PackageVersion{
+		Version:      manifest.Version,
+		Description:  &manifest.Description,
+		Targets:      manifest.Targets,
+		Tags:         manifest.Tags,
+		SHA256:       &sha256Hash,
+		Integrity:    &integrityString,
+		SizeBytes:    &[]int{int(size)}[0],
+		BlobPath:     &archivePath,
+		Status:       publishedStatus(s.Config),
+		PublishedBy:  &user.ID,
+		Dependencies: manifest.Dependencies,
+	}
+	if manifest.License != "" {
+		version.License = &manifest.License
+	}
 
-	outFile, err := os.Create(archivePath)
+	gated := s.Pipeline.Enabled()
+	var checks []string
+	if gated {
+		version.Status = db.VersionStatusPending
+		checks = s.Pipeline.CheckNames()
+	}
+
+	// Rows and archive land together: the archive is moved into place inside the
+	// transaction and removed again if the commit fails
+	blobMoved := false
+	createdVersion, err := s.DB.WithContext(r.Context()).PublishPackageVersion(manifest.Name, version, checks, func() error {
+		if err := os.Rename(upload.TempPath, archivePath); err != nil {
+			return err
+		}
+		blobMoved = true
+		return nil
+	})
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to save archive")
+		if blobMoved {
+			os.Remove(archivePath)
+		}
+		if errors.Is(err, db.ErrVersionExists) {
+			writeErrorCode(w, http.StatusConflict, CodeVersionExists, "Package version already exists",
+				map[string]interface{}{"name": manifest.Name, "version": manifest.Version})
+			return
+		}
+		if errors.Is(err, db.ErrNameReserved) {
+			writeErrorCode(w, http.StatusForbidden, CodeNameReserved, reservedMessage(s.DB.WithContext(r.Context()), manifest.Name), nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to publish package version")
 		return
 	}
-	defer outFile.Close()
 
-	// Copy with hashing
-	teeReader := io.TeeReader(archiveFile, hasher)
-	size, err := io.Copy(outFile, teeReader)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to save archive")
+	// A new package or version may now be visible
+	s.Cache.Invalidate()
+
+	response := map[string]interface{}{
+		"name":      manifest.Name,
+		"version":   manifest.Version,
+		"sha256":    sha256Hash,
+		"integrity": integrityString,
+		"size":      size,
+		"id":        createdVersion.ID,
+		"status":    createdVersion.Status,
+	}
+
+	if !gated {
+		if createdVersion.Status == db.VersionStatusAwaitingApproval {
+			writeJSON(w, http.StatusAccepted, response)
+			return
+		}
+		writeJSON(w, http.StatusCreated, response)
 		return
 	}
 
-	sha256Hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	s.Pipeline.Enqueue(ValidationJob{
+		VersionID:   createdVersion.ID,
+		Name:        manifest.Name,
+		Version:     manifest.Version,
+		SHA256:      sha256Hash,
+		ArchivePath: archivePath,
+		Trace:       trace.SpanContextFromContext(r.Context()),
+	})
+
+	// Accepted for validation; clients poll the checks endpoint for the outcome
+	response["checks_url"] = fmt.Sprintf("/v1/packages/%s/versions/%s/checks", manifest.Name, manifest.Version)
+	writeJSON(w, http.StatusAccepted, response)
+}
 
-	// Use package name directly (no scope support)
-	packageName := manifest.Name
+// getVersionChecksHandler reports the validation status of a package version
+func (s *Server) getVersionChecksHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
 
-	// Create or get package
-	pkg, err := s.DB.GetOrCreatePackage(packageName)
+	pkgVersion, err := s.DB.WithContext(r.Context()).GetPackageVersion(name, version)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to create package")
+		writeError(w, http.StatusNotFound, "Package version not found")
 		return
 	}
 
-	// Create package version
-	version := db.PackageVersion{
-		PackageID:   pkg.ID,
-		Version:     manifest.Version,
-		Description: &manifest.Description,
-		Targets:     manifest.Targets,
-		Tags:        manifest.Tags,
-		SHA256:      &sha256Hash,
-		SizeBytes:   &[]int{int(size)}[0],
-		BlobPath:    &archivePath,
+	checks, err := s.DB.WithContext(r.Context()).GetVersionChecks(pkgVersion.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load validation checks")
+		return
 	}
 
-	createdVersion, err := s.DB.CreatePackageVersion(version)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":    name,
+		"version": version,
+		"status":  pkgVersion.Status,
+		"checks":  checks,
+	})
+}
+
+// checkRuleTests runs the rule test fixtures shipped in a package archive
+func checkRuleTests(archivePath, testsDir string) error {
+	report, err := ruletest.RunArchive(archivePath, testsDir)
+	if errors.Is(err, ruletest.ErrNoFixtures) {
+		return fmt.Errorf("registry requires rule tests: no fixtures found in package")
+	}
 	if err != nil {
-		writeError(w, http.StatusConflict, "Package version already exists or creation failed")
+		return fmt.Errorf("rule tests could not run: %v", err)
+	}
+
+	var failed []string
+	for _, result := range report.Results {
+		if !result.Passed() {
+			failed = append(failed, result.Fixture.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("rule tests failed: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// approvePackageVersionHandler lets a second reviewer publish a version awaiting approval
+func (s *Server) approvePackageVersionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
+
+	pkgVersion, err := s.DB.WithContext(r.Context()).GetPackageVersion(name, version)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
+	if pkgVersion.Status != db.VersionStatusAwaitingApproval {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Package version is %s, not awaiting approval", pkgVersion.Status))
+		return
+	}
+
+	// The whole point of the second review is that it is someone else, which
+	// cannot be shown when the publisher is unknown
+	if pkgVersion.PublishedBy == nil {
+		writeError(w, http.StatusForbidden, "Versions with an unknown publisher cannot be approved")
+		return
+	}
+	if *pkgVersion.PublishedBy == user.ID {
+		writeError(w, http.StatusForbidden, "Publishers cannot approve their own versions")
+		return
+	}
+
+	if err := s.DB.WithContext(r.Context()).ApprovePackageVersion(pkgVersion.ID, user.ID); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusConflict, "Package version is no longer awaiting approval")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to approve package version")
 		return
 	}
+	s.Cache.Invalidate()
 
-	// Return success response
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"name":    manifest.Name,
-		"version": manifest.Version,
-		"sha256":  sha256Hash,
-		"size":    size,
-		"id":      createdVersion.ID,
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":        name,
+		"version":     version,
+		"status":      db.VersionStatusPublished,
+		"approved_by": user.Username,
 	})
 }
 
@@ -209,12 +460,17 @@ func (s *Server) downloadBlobHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Find package version by SHA256
 	var blobPath string
-	err := s.DB.Get(&blobPath, "SELECT blob_path FROM package_versions WHERE sha256 = $1", sha256)
+	err := s.DB.GetContext(r.Context(), &blobPath, "SELECT blob_path FROM package_versions WHERE sha256 = $1 AND status = 'published'", sha256)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "Blob not found")
 		return
 	}
 
+	s.serveBlob(w, r, blobPath, sha256[:8])
+}
+
+// serveBlob streams a stored archive, as filename plus the extension of its format
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, blobPath, filename string) {
 	// Open file
 	file, err := os.Open(blobPath)
 	if err != nil {
@@ -230,11 +486,218 @@ func (s *Server) downloadBlobHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Clients that predate zstd send no Accept header and can only read gzip
+	format, _ := compression.DetectFile(blobPath)
+	if format == compression.Zstd && !acceptsMediaType(r.Header.Get("Accept"), compression.ContentType(format)) {
+		writeError(w, http.StatusNotAcceptable, "Package archive is zstd-compressed; upgrade rfh to download it")
+		return
+	}
+
 	// Set headers
-	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Type", compression.ContentType(format))
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.tgz\"", sha256[:8]))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s%s\"", filename, compression.Extension(format)))
 
 	// Stream file
 	http.ServeContent(w, r, "", info.ModTime(), file)
 }
+
+// acceptsMediaType reports whether an Accept header admits a media type
+func acceptsMediaType(accept, mediaType string) bool {
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, part := range strings.Split(accept, ",") {
+		accepted, _, _ := strings.Cut(part, ";")
+		switch strings.TrimSpace(accepted) {
+		case mediaType, major + "/*", "*/*":
+			return true
+		}
+	}
+	return false
+}
+
+// deprecationRequest is the body of a deprecation: a message, and optionally the
+// package and version range to use instead
+type deprecationRequest struct {
+	Message     string `json:"message"`
+	Replacement string `json:"replacement"`
+}
+
+// parse validates a deprecation request and returns the values to store; both
+// are nil when the deprecation is being cleared. A replacement without a message
+// gets a message pointing to it.
+func (req deprecationRequest) parse() (message, replacement *string, err error) {
+	if req.Replacement != "" {
+		if _, _, err := rfhmanifest.ParseReplacement(req.Replacement); err != nil {
+			return nil, nil, err
+		}
+		replacement = &req.Replacement
+		if strings.TrimSpace(req.Message) == "" {
+			req.Message = "Use " + req.Replacement + " instead"
+		}
+	}
+	if strings.TrimSpace(req.Message) != "" {
+		message = &req.Message
+	}
+	return message, replacement, nil
+}
+
+// deprecatePackageVersionHandler marks a published version deprecated, or clears the
+// deprecation when the message and replacement are empty
+func (s *Server) deprecatePackageVersionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
+
+	var req deprecationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	message, replacement, err := req.parse()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	pkgVersion, err := database.GetPackageVersion(name, version)
+	if err != nil || pkgVersion.Status != db.VersionStatusPublished {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
+	// Only whoever published the version (or an admin) may deprecate it
+	if !user.Role.HasPermission(db.PermissionAdmin) && (pkgVersion.PublishedBy == nil || *pkgVersion.PublishedBy != user.ID) {
+		writeError(w, http.StatusForbidden, "Only the publisher of this version can deprecate it")
+		return
+	}
+
+	if err := database.SetVersionDeprecation(pkgVersion.ID, message, replacement); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to deprecate package version")
+		return
+	}
+	s.Cache.Invalidate()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":        name,
+		"version":     version,
+		"deprecated":  stringValue(message),
+		"replacement": stringValue(replacement),
+	})
+}
+
+// deprecatePackageHandler deprecates, or clears the deprecation of, every
+// published version of a package
+func (s *Server) deprecatePackageHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+
+	var req deprecationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	message, replacement, err := req.parse()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	pkg, err := database.GetPackage(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+	versions, err := database.ListPublishedVersionsOf([]string{name})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list package versions")
+		return
+	}
+
+	// Only someone who published a version of the package (or an admin) may deprecate it
+	allowed := user.Role.HasPermission(db.PermissionAdmin)
+	for _, v := range versions {
+		if v.PublishedBy != nil && *v.PublishedBy == user.ID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		writeError(w, http.StatusForbidden, "Only a publisher of this package can deprecate it")
+		return
+	}
+
+	count, err := database.SetPackageDeprecation(pkg.ID, message, replacement)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to deprecate package")
+		return
+	}
+	s.Cache.Invalidate()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":        name,
+		"versions":    count,
+		"deprecated":  stringValue(message),
+		"replacement": stringValue(replacement),
+	})
+}
+
+// badgesRequest sets the badges of a package. An empty verified_org clears it.
+type badgesRequest struct {
+	Official    bool   `json:"official"`
+	VerifiedOrg string `json:"verified_org"`
+}
+
+// setPackageBadgesHandler lets admins mark a package as official and record the
+// organization they verified publishes it, so users can tell it from impostors
+func (s *Server) setPackageBadgesHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req badgesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var verifiedOrg *string
+	if org := strings.TrimSpace(req.VerifiedOrg); org != "" {
+		if len(org) > 100 {
+			writeError(w, http.StatusBadRequest, "verified_org must be at most 100 characters")
+			return
+		}
+		verifiedOrg = &org
+	}
+
+	database := s.DB.WithContext(r.Context())
+	pkg, err := database.GetPackage(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+	if err := database.SetPackageBadges(pkg.ID, req.Official, verifiedOrg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set package badges")
+		return
+	}
+	s.Cache.Invalidate()
+
+	pkg.Official, pkg.VerifiedOrg = req.Official, verifiedOrg
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+// stringValue returns the string s points to, or an empty string for nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -1,10 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
 )
 
 // Skip testing healthHandler since it requires real DB connection
@@ -113,5 +122,257 @@ func TestWriteError(t *testing.T) {
 	}
 }
 
+func TestAcceptsMediaType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/zstd, application/gzip", true},
+		{"application/gzip", false},
+		{"application/*;q=0.8", true},
+		{"*/*", true},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsMediaType(tt.accept, "application/zstd"); got != tt.want {
+			t.Errorf("acceptsMediaType(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRuleTests(t *testing.T) {
+	rule := "---\nid: api-style\nglobs: api/**\n---\n# API\n"
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		expectErr string
+	}{
+		{
+			name: "passing fixtures",
+			files: map[string]string{
+				"api.mdc":        rule,
+				"tests/api.json": `{"files": ["api/x.go"], "expect": ["api-style"]}`,
+			},
+		},
+		{
+			name: "failing fixtures",
+			files: map[string]string{
+				"api.mdc":        rule,
+				"tests/api.json": `{"name": "web edit", "files": ["web/x.go"], "expect": ["api-style"]}`,
+			},
+			expectErr: "rule tests failed: web edit",
+		},
+		{
+			name:      "no fixtures",
+			files:     map[string]string{"api.mdc": rule},
+			expectErr: "no fixtures found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRuleTests(buildTestArchive(t, tt.files), "")
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
 // Skip handler tests that require database connections
 // These would need proper integration tests with a test database
+
+func TestDeprecationRequestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		req             deprecationRequest
+		wantMessage     string
+		wantReplacement string
+		wantErr         bool
+	}{
+		{name: "message only", req: deprecationRequest{Message: "Use 2.x"}, wantMessage: "Use 2.x"},
+		{name: "replacement only", req: deprecationRequest{Replacement: "other-rules@^2"}, wantMessage: "Use other-rules@^2 instead", wantReplacement: "other-rules@^2"},
+		{name: "both", req: deprecationRequest{Message: "Renamed", Replacement: "other-rules@2.1.0"}, wantMessage: "Renamed", wantReplacement: "other-rules@2.1.0"},
+		{name: "cleared", req: deprecationRequest{Message: "  "}},
+		{name: "replacement without version", req: deprecationRequest{Replacement: "other-rules"}, wantErr: true},
+		{name: "invalid range", req: deprecationRequest{Replacement: "other-rules@>=2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, replacement, err := tt.req.parse()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stringValue(message) != tt.wantMessage || stringValue(replacement) != tt.wantReplacement {
+				t.Errorf("got %q, %q; want %q, %q", stringValue(message), stringValue(replacement), tt.wantMessage, tt.wantReplacement)
+			}
+			if tt.wantMessage == "" && message != nil {
+				t.Error("expected a nil message to clear the deprecation")
+			}
+		})
+	}
+}
+
+func TestPackageBadges(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	for _, name := range []string{"security-rules", "security-rules-official"} {
+		if _, err := database.PublishPackageVersion(name, db.PackageVersion{Version: "1.0.0"}, nil, func() error { return nil }); err != nil {
+			t.Fatalf("PublishPackageVersion failed: %v", err)
+		}
+	}
+	s := &Server{DB: database, Cache: newResponseCache(100, time.Minute)}
+
+	search := func(path string) []db.SearchResult {
+		w := httptest.NewRecorder()
+		s.searchPackagesHandler(w, httptest.NewRequest("GET", path, nil))
+		var results []db.SearchResult
+		if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+			t.Fatalf("invalid search response: %v", err)
+		}
+		return results
+	}
+	setBadges := func(name, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("PUT", "/v1/admin/packages/"+name+"/badges", strings.NewReader(body))
+		r = mux.SetURLVars(r, map[string]string{"name": name})
+		w := httptest.NewRecorder()
+		s.setPackageBadgesHandler(w, r)
+		return w
+	}
+
+	// Cached before the badge is set, so setting it must invalidate the cache
+	if results := search("/v1/packages?official=true"); len(results) != 0 {
+		t.Fatalf("expected no official packages yet, got %+v", results)
+	}
+
+	w := setBadges("security-rules-official", `{"official": true, "verified_org": " acme "}`)
+	var pkg db.Package
+	if err := json.NewDecoder(w.Body).Decode(&pkg); err != nil || w.Code != http.StatusOK || !pkg.Official || pkg.VerifiedOrg == nil || *pkg.VerifiedOrg != "acme" {
+		t.Fatalf("expected the badges set, got %d: %+v (%v)", w.Code, pkg, err)
+	}
+
+	results := search("/v1/packages?official=true")
+	if len(results) != 1 || results[0].Name != "security-rules-official" || *results[0].VerifiedOrg != "acme" {
+		t.Errorf("expected only the official package, got %+v", results)
+	}
+	if results := search("/v1/packages?q=security"); len(results) != 2 {
+		t.Errorf("expected both packages without the filter, got %+v", results)
+	}
+
+	if w := setBadges("missing-rules", `{"official": true}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing package, got %d", w.Code)
+	}
+	if w := setBadges("security-rules", `{"verified_org": "`+strings.Repeat("a", 101)+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an overlong organization, got %d", w.Code)
+	}
+}
+
+func TestApprovePackageVersionRequiresAnotherUser(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	publisher, err := database.CreateUser(db.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "secret123", Role: db.RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	reviewer, err := database.CreateUser(db.CreateUserRequest{Username: "bob", Email: "bob@example.com", Password: "secret123", Role: db.RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	pkg, err := database.GetOrCreatePackage("rules")
+	if err != nil {
+		t.Fatalf("GetOrCreatePackage failed: %v", err)
+	}
+	s := &Server{DB: database, Cache: newResponseCache(100, time.Minute)}
+
+	tests := []struct {
+		name         string
+		version      string
+		publishedBy  *int
+		approver     *db.User
+		expectStatus int
+	}{
+		{name: "own version", version: "1.0.0", publishedBy: &publisher.ID, approver: publisher, expectStatus: http.StatusForbidden},
+		{name: "unknown publisher", version: "1.1.0", publishedBy: nil, approver: reviewer, expectStatus: http.StatusForbidden},
+		{name: "another user", version: "1.2.0", publishedBy: &publisher.ID, approver: reviewer, expectStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := database.CreatePackageVersion(db.PackageVersion{PackageID: pkg.ID, Version: tt.version, Status: db.VersionStatusAwaitingApproval, PublishedBy: tt.publishedBy}); err != nil {
+				t.Fatalf("CreatePackageVersion failed: %v", err)
+			}
+			r := httptest.NewRequest("POST", "/v1/packages/rules/versions/"+tt.version+"/approve", nil)
+			r = mux.SetURLVars(r.WithContext(context.WithValue(r.Context(), userContextKey, tt.approver)), map[string]string{"name": "rules", "version": tt.version})
+			w := httptest.NewRecorder()
+			s.approvePackageVersionHandler(w, r)
+			if w.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func BenchmarkSearchPackagesHandler(b *testing.B) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(b.TempDir(), "registry.db"))
+	if err != nil {
+		b.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	for i := 0; i < 500; i++ {
+		description := fmt.Sprintf("Security rules for service %d", i)
+		_, err := database.PublishPackageVersion(fmt.Sprintf("rules-%03d", i), db.PackageVersion{
+			Version:     "1.0.0",
+			Description: &description,
+			Tags:        []string{"security", fmt.Sprintf("team-%d", i%10)},
+			Targets:     []string{"claude-code"},
+		}, nil, func() error { return nil })
+		if err != nil {
+			b.Fatalf("PublishPackageVersion failed: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		cache *responseCache
+	}{
+		{"uncached", nil},
+		{"cached", newResponseCache(100, time.Minute)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			s := &Server{DB: database, Cache: tc.cache}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest("GET", "/v1/packages?q=service&tag=team-3&limit=20", nil)
+				w := httptest.NewRecorder()
+				s.searchPackagesHandler(w, req)
+				if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "rules-493") {
+					b.Fatalf("expected rules-493 in the results, got %d: %s", w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"rulestack/internal/db"
+	"rulestack/internal/version"
+)
+
+// indexSyncOverlap widens each delta so a publish that committed after the client's
+// last sync, but started before it, is still picked up. Clients replace whole package
+// entries, so seeing a package twice is harmless.
+const indexSyncOverlap = time.Minute
+
+// indexVersion is one published version in an index entry
+type indexVersion struct {
+	Version     string    `json:"version"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Integrity   string    `json:"integrity,omitempty"`
+	Size        int       `json:"size,omitempty"`
+	Deprecated  string    `json:"deprecated,omitempty"`
+	Replacement string    `json:"replacement,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// indexPackage is the full index entry of a package. Entries without versions tell
+// clients to drop the package from their snapshot.
+type indexPackage struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Latest      string         `json:"latest,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Targets     []string       `json:"targets,omitempty"`
+	Versions    []indexVersion `json:"versions"`
+}
+
+// indexHandler returns the packages changed since the client's cursor, or every
+// package when no cursor is given. The response cursor is passed back on the next sync.
+func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
+	since := time.Time{}
+	if cursor := r.URL.Query().Get("since"); cursor != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid since cursor: use the cursor of a previous sync or an RFC 3339 timestamp")
+			return
+		}
+		since = parsed.Add(-indexSyncOverlap)
+	}
+
+	database := s.DB.WithContext(r.Context())
+	names, now, err := database.ListChangedPackages(since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list changed packages")
+		return
+	}
+
+	var versions []db.NamedPackageVersion
+	if len(names) > 0 {
+		versions, err = database.ListPublishedVersionsOf(names)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to list package versions")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"cursor":   now.UTC().Format(time.RFC3339Nano),
+		"full":     since.IsZero(),
+		"packages": buildIndexPackages(names, versions),
+	})
+}
+
+// buildIndexPackages groups published versions into one entry per package, taking the
+// description, tags and targets from the latest version
+func buildIndexPackages(names []string, versions []db.NamedPackageVersion) []indexPackage {
+	byPackage := make(map[string][]db.NamedPackageVersion, len(names))
+	for _, v := range versions {
+		byPackage[v.PackageName] = append(byPackage[v.PackageName], v)
+	}
+
+	packages := make([]indexPackage, 0, len(names))
+	for _, name := range names {
+		entry := indexPackage{Name: name, Versions: []indexVersion{}}
+
+		published := byPackage[name]
+		sort.Slice(published, func(i, j int) bool {
+			return published[i].CreatedAt.Before(published[j].CreatedAt)
+		})
+
+		var available []string
+		for _, v := range published {
+			available = append(available, v.Version)
+
+			iv := indexVersion{Version: v.Version, PublishedAt: v.CreatedAt}
+			if v.SHA256 != nil {
+				iv.SHA256 = *v.SHA256
+			}
+			if v.Integrity != nil {
+				iv.Integrity = *v.Integrity
+			}
+			if v.SizeBytes != nil {
+				iv.Size = *v.SizeBytes
+			}
+			if v.Deprecated != nil {
+				iv.Deprecated = *v.Deprecated
+			}
+			if v.Replacement != nil {
+				iv.Replacement = *v.Replacement
+			}
+			entry.Versions = append(entry.Versions, iv)
+		}
+
+		entry.Latest = version.Latest(available)
+		for _, v := range published {
+			if v.Version != entry.Latest {
+				continue
+			}
+			if v.Description != nil {
+				entry.Description = *v.Description
+			}
+			entry.Tags = v.Tags
+			entry.Targets = v.Targets
+		}
+
+		packages = append(packages, entry)
+	}
+
+	return packages
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"rulestack/internal/db"
+)
+
+func TestBuildIndexPackages(t *testing.T) {
+	now := time.Now()
+	published := func(name, v, description string, tags []string, age time.Duration) db.NamedPackageVersion {
+		return db.NamedPackageVersion{
+			PackageName: name,
+			PackageVersion: db.PackageVersion{
+				Version: v, Description: &description, Tags: pq.StringArray(tags), CreatedAt: now.Add(-age),
+			},
+		}
+	}
+
+	packages := buildIndexPackages([]string{"removed-rules", "security-rules"}, []db.NamedPackageVersion{
+		published("security-rules", "1.1.0", "Current description", []string{"security"}, time.Hour),
+		published("security-rules", "1.0.0", "Old description", nil, 48*time.Hour),
+		published("security-rules", "2.0.0-beta.1", "Beta description", nil, time.Minute),
+	})
+
+	if len(packages) != 2 {
+		t.Fatalf("expected an entry per changed package, got %d", len(packages))
+	}
+
+	removed := packages[0]
+	if removed.Name != "removed-rules" || removed.Versions == nil || len(removed.Versions) != 0 {
+		t.Errorf("expected removed-rules with an empty version list, got %+v", removed)
+	}
+
+	security := packages[1]
+	if security.Latest != "1.1.0" || security.Description != "Current description" || len(security.Tags) != 1 {
+		t.Errorf("expected entry details from the latest stable version, got %+v", security)
+	}
+	var versions []string
+	for _, v := range security.Versions {
+		versions = append(versions, v.Version)
+	}
+	if len(versions) != 3 || versions[0] != "1.0.0" || versions[2] != "2.0.0-beta.1" {
+		t.Errorf("expected versions in publish order, got %v", versions)
+	}
+}
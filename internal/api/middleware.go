@@ -18,9 +18,9 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeError writes JSON error response
+// writeError writes a JSON error response with the code of its status
 func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
+	writeErrorCode(w, status, errorCode(status), message, nil)
 }
 
 // panicRecoveryMiddleware recovers from panics and returns a 500 error
@@ -33,9 +33,7 @@ func panicRecoveryMiddleware(next http.Handler) http.Handler {
 				fmt.Fprintf(os.Stderr, "PANIC in %s %s: %v\n", r.Method, r.URL.Path, err)
 
 				// Return 500 error
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
+				writeError(w, http.StatusInternalServerError, "Internal server error")
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+	"rulestack/internal/security"
+	"rulestack/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// checkTimeout bounds how long a single validation check may run
+const checkTimeout = 2 * time.Minute
+
+// ValidationJob identifies an uploaded package version awaiting validation
+type ValidationJob struct {
+	VersionID   int
+	Name        string
+	Version     string
+	SHA256      string
+	ArchivePath string
+	Trace       trace.SpanContext // Publish request span, linked from the validation trace
+}
+
+// ValidationCheck is one step of the publish validation pipeline. Run returns an
+// error describing why the package was rejected.
+type ValidationCheck struct {
+	Name string
+	Run  func(ctx context.Context, job ValidationJob) error
+}
+
+// checkStore is the subset of the database the pipeline writes results to
+type checkStore interface {
+	StartVersionCheck(versionID int, name string) error
+	FinishVersionCheck(versionID int, name string, passed bool, message string) error
+	SetPackageVersionStatus(versionID int, status string) error
+}
+
+// ValidationPipeline runs validation checks on uploaded packages in the background
+// and publishes or rejects each version once all of its checks have finished
+type ValidationPipeline struct {
+	store        checkStore
+	checks       []ValidationCheck
+	jobs         chan ValidationJob
+	passedStatus string // Status given to versions that pass every check
+
+	statusChanged func() // Called after a version is published or rejected
+}
+
+// NewValidationPipeline creates a pipeline running the configured checks in order
+func NewValidationPipeline(store checkStore, cfg config.Config) *ValidationPipeline {
+	webhookURL := cfg.ValidationWebhookURL
+
+	var checks []ValidationCheck
+	for _, name := range cfg.ValidationChecks {
+		switch name {
+		case "security":
+			checks = append(checks, ValidationCheck{Name: name, Run: securityCheck})
+		case "lint":
+			checks = append(checks, ValidationCheck{Name: name, Run: lintCheck})
+		case "secrets":
+			checks = append(checks, ValidationCheck{Name: name, Run: secretScanCheck})
+		case "webhook":
+			checks = append(checks, ValidationCheck{Name: name, Run: webhookCheck(webhookURL)})
+		}
+	}
+
+	return &ValidationPipeline{
+		store:        store,
+		checks:       checks,
+		jobs:         make(chan ValidationJob, 64),
+		passedStatus: publishedStatus(cfg),
+	}
+}
+
+// publishedStatus is the status an accepted upload moves to: live, or held for a
+// second reviewer on registries that require approval
+func publishedStatus(cfg config.Config) string {
+	if cfg.RequireApproval {
+		return db.VersionStatusAwaitingApproval
+	}
+	return db.VersionStatusPublished
+}
+
+// Enabled reports whether uploads are gated by any checks
+func (p *ValidationPipeline) Enabled() bool {
+	return p != nil && len(p.checks) > 0
+}
+
+// CheckNames returns the names of the configured checks
+func (p *ValidationPipeline) CheckNames() []string {
+	names := make([]string, len(p.checks))
+	for i, check := range p.checks {
+		names[i] = check.Name
+	}
+	return names
+}
+
+// Start launches the worker goroutines
+func (p *ValidationPipeline) Start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-p.jobs:
+					p.Process(ctx, job)
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue schedules a job without blocking the request that uploaded it
+func (p *ValidationPipeline) Enqueue(job ValidationJob) {
+	select {
+	case p.jobs <- job:
+	default:
+		go func() { p.jobs <- job }()
+	}
+}
+
+// Process runs every check for a job and records the final version status.
+// All checks run even after a failure so publishers see every problem at once.
+func (p *ValidationPipeline) Process(ctx context.Context, job ValidationJob) {
+	ctx, span := tracing.Start(ctx, "validate "+job.Name+"@"+job.Version,
+		trace.WithLinks(trace.Link{SpanContext: job.Trace}),
+		trace.WithAttributes(attribute.String("package.name", job.Name), attribute.String("package.version", job.Version)),
+	)
+	defer span.End()
+
+	passed := true
+
+	for _, check := range p.checks {
+		if err := p.store.StartVersionCheck(job.VersionID, check.Name); err != nil {
+			log.Printf("validation: failed to start %s for %s@%s: %v", check.Name, job.Name, job.Version, err)
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		checkCtx, checkSpan := tracing.Start(checkCtx, "check "+check.Name)
+		err := check.Run(checkCtx, job)
+		tracing.End(checkSpan, err)
+		cancel()
+
+		message := ""
+		if err != nil {
+			passed = false
+			message = err.Error()
+		}
+
+		if err := p.store.FinishVersionCheck(job.VersionID, check.Name, message == "", message); err != nil {
+			log.Printf("validation: failed to record %s for %s@%s: %v", check.Name, job.Name, job.Version, err)
+		}
+	}
+
+	status := p.passedStatus
+	if !passed {
+		status = db.VersionStatusRejected
+	}
+	span.SetAttributes(attribute.String("package.status", status))
+
+	if err := p.store.SetPackageVersionStatus(job.VersionID, status); err != nil {
+		log.Printf("validation: failed to set status of %s@%s: %v", job.Name, job.Version, err)
+		return
+	}
+
+	if p.statusChanged != nil {
+		p.statusChanged()
+	}
+
+	log.Printf("validation: %s@%s %s", job.Name, job.Version, status)
+}
+
+// resumePendingValidations requeues versions left pending by a restart
+func (s *Server) resumePendingValidations() {
+	pending, err := s.DB.ListPendingVersions()
+	if err != nil {
+		log.Printf("validation: failed to list pending versions: %v", err)
+		return
+	}
+
+	for _, version := range pending {
+		if err := s.DB.CreateVersionChecks(version.ID, s.Pipeline.CheckNames()); err != nil {
+			log.Printf("validation: failed to reset checks for %s@%s: %v", version.PackageName, version.Version, err)
+			continue
+		}
+
+		job := ValidationJob{VersionID: version.ID, Name: version.PackageName, Version: version.Version}
+		if version.SHA256 != nil {
+			job.SHA256 = *version.SHA256
+		}
+		if version.BlobPath != nil {
+			job.ArchivePath = *version.BlobPath
+		}
+		s.Pipeline.Enqueue(job)
+	}
+}
+
+// securityCheck runs the archive security validator used by clients at install time
+func securityCheck(ctx context.Context, job ValidationJob) error {
+	return validateArchive(job.ArchivePath)
+}
+
+// validateArchive runs the package security validator against an archive on disk
+func validateArchive(archivePath string) error {
+	dir, err := os.MkdirTemp("", "rulestack-validate-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	return security.NewPackageValidator(nil).ValidateArchive(archivePath, dir)
+}
+
+// lintCheck verifies the embedded manifest and basic rule file structure
+func lintCheck(ctx context.Context, job ValidationJob) error {
+	return withExtractedArchive(job.ArchivePath, func(dir string) error {
+		data, err := os.ReadFile(filepath.Join(dir, "rulestack.json"))
+		if err != nil {
+			return fmt.Errorf("archive has no rulestack.json")
+		}
+
+		var packageManifest manifest.PackageManifest
+		if err := json.Unmarshal(data, &packageManifest); err != nil {
+			return fmt.Errorf("invalid rulestack.json: %v", err)
+		}
+		if err := packageManifest.Validate(); err != nil {
+			return err
+		}
+		if packageManifest.Name != job.Name || packageManifest.Version != job.Version {
+			return fmt.Errorf("archive manifest is %s@%s but was published as %s@%s",
+				packageManifest.Name, packageManifest.Version, job.Name, job.Version)
+		}
+
+		var problems []string
+		rules := 0
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext != ".md" && ext != ".mdc" {
+				return nil
+			}
+			rules++
+
+			relPath, _ := filepath.Rel(dir, path)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			text := strings.ReplaceAll(string(content), "\r\n", "\n")
+			if strings.TrimSpace(text) == "" {
+				problems = append(problems, relPath+": empty rule file")
+			} else if strings.HasPrefix(text, "---\n") && !strings.Contains(text[4:], "\n---") {
+				problems = append(problems, relPath+": unterminated frontmatter")
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case packageManifest.IsMeta() && rules > 0:
+			problems = append(problems, "meta-package contains rule files")
+		case !packageManifest.IsMeta() && rules == 0:
+			problems = append(problems, "package contains no rule files")
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%s", strings.Join(problems, "; "))
+		}
+		return nil
+	})
+}
+
+// secretPatterns match credentials that must never be published in rule content
+var secretPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"credential assignment", regexp.MustCompile(`(?i)\b(api[_-]?key|secret|password|token)\s*[:=]\s*["'][^"'\s]{16,}["']`)},
+}
+
+// secretScanCheck rejects packages containing likely credentials
+func secretScanCheck(ctx context.Context, job ValidationJob) error {
+	return withExtractedArchive(job.ArchivePath, func(dir string) error {
+		var findings []string
+
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			relPath, _ := filepath.Rel(dir, path)
+			scanner := bufio.NewScanner(file)
+			for line := 1; scanner.Scan(); line++ {
+				for _, secret := range secretPatterns {
+					if secret.pattern.MatchString(scanner.Text()) {
+						findings = append(findings, fmt.Sprintf("%s:%d: possible %s", filepath.ToSlash(relPath), line, secret.name))
+					}
+				}
+			}
+			return scanner.Err()
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(findings) > 0 {
+			return fmt.Errorf("%s", strings.Join(findings, "; "))
+		}
+		return nil
+	})
+}
+
+// webhookCheck delegates the decision to an external service. The webhook receives
+// the package identity and archive hash and must answer 2xx to accept the package.
+func webhookCheck(url string) func(ctx context.Context, job ValidationJob) error {
+	return func(ctx context.Context, job ValidationJob) error {
+		payload, err := json.Marshal(map[string]interface{}{
+			"name":    job.Name,
+			"version": job.Version,
+			"sha256":  job.SHA256,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("webhook request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("webhook rejected package (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return nil
+	}
+}
+
+// withExtractedArchive safely extracts an archive to a temporary directory for inspection
+func withExtractedArchive(archivePath string, fn func(dir string) error) error {
+	dir, err := os.MkdirTemp("", "rulestack-validate-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := pkg.Unpack(archivePath, dir); err != nil {
+		return fmt.Errorf("failed to extract archive: %v", err)
+	}
+
+	return fn(dir)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/client"
+	"rulestack/internal/db"
+	"rulestack/internal/version"
+)
+
+// packagePreviewHandler returns a version's manifest details and the first lines
+// of its rule files, so clients can judge a package without downloading it. The
+// latest published version is previewed unless ?version= is given.
+func (s *Server) packagePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	versionStr := r.URL.Query().Get("version")
+
+	database := s.DB.WithContext(r.Context())
+	if versionStr == "" {
+		published, err := database.ListPublishedVersionsOf([]string{name})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to list package versions")
+			return
+		}
+		var versions []string
+		for _, v := range published {
+			versions = append(versions, v.Version)
+		}
+		if versionStr = version.Latest(versions); versionStr == "" {
+			writeError(w, http.StatusNotFound, "Package not found")
+			return
+		}
+	}
+
+	cacheKey := "preview\x00" + name + "\x00" + versionStr
+	if cached, ok := s.Cache.Get(cacheKey); ok {
+		writeCached(w, cached, true)
+		return
+	}
+
+	pkgVersion, err := database.GetPackageVersion(name, versionStr)
+	if err != nil || pkgVersion.Status != db.VersionStatusPublished || pkgVersion.BlobPath == nil {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
+	preview, err := client.BuildPackagePreview(*pkgVersion.BlobPath, client.DefaultPreviewLines)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read package archive")
+		return
+	}
+	preview.Name = name
+	preview.Version = versionStr
+
+	s.Cache.Set(cacheKey, preview)
+	writeCached(w, preview, false)
+}
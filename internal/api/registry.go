@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
 )
 
 // RouteMetadata contains metadata for a route
@@ -12,7 +14,7 @@ type RouteMetadata struct {
 	Path                   string
 	Method                 string
 	RequiresAuthentication bool
-	RequiredRole           string // "user", "publisher", "admin", or "" for public
+	RequiredPermission     db.Permission // Permission the user's role must grant, or "" for public
 	Handler                http.HandlerFunc
 	Description            string
 	RateLimit              int // requests per minute, 0 = no limit
@@ -32,15 +34,15 @@ func NewRouteRegistry() *RouteRegistry {
 
 // RegisterRoute registers a route with metadata
 func (rr *RouteRegistry) RegisterRoute(path, method string, requiresAuth bool, handler http.HandlerFunc, description string) {
-	requiredRole := ""
+	var requiredPermission db.Permission
 	if requiresAuth {
-		requiredRole = "user" // Default to user level access
+		requiredPermission = db.PermissionRead // Default to user level access
 	}
 	route := RouteMetadata{
 		Path:                   path,
 		Method:                 method,
 		RequiresAuthentication: requiresAuth,
-		RequiredRole:           requiredRole,
+		RequiredPermission:     requiredPermission,
 		Handler:                handler,
 		Description:            description,
 		RateLimit:              0, // Default: no rate limit
@@ -48,14 +50,14 @@ func (rr *RouteRegistry) RegisterRoute(path, method string, requiresAuth bool, h
 	rr.routes = append(rr.routes, route)
 }
 
-// RegisterRouteWithRole registers a route with specific role requirement
-func (rr *RouteRegistry) RegisterRouteWithRole(path, method, requiredRole string, handler http.HandlerFunc, description string) {
-	requiresAuth := requiredRole != ""
+// RegisterRouteWithPermission registers a route that requires a permission
+func (rr *RouteRegistry) RegisterRouteWithPermission(path, method string, requiredPermission db.Permission, handler http.HandlerFunc, description string) {
+	requiresAuth := requiredPermission != ""
 	route := RouteMetadata{
 		Path:                   path,
 		Method:                 method,
 		RequiresAuthentication: requiresAuth,
-		RequiredRole:           requiredRole,
+		RequiredPermission:     requiredPermission,
 		Handler:                handler,
 		Description:            description,
 		RateLimit:              0, // Default: no rate limit
@@ -65,15 +67,15 @@ func (rr *RouteRegistry) RegisterRouteWithRole(path, method, requiredRole string
 
 // RegisterRouteWithRateLimit registers a route with rate limiting
 func (rr *RouteRegistry) RegisterRouteWithRateLimit(path, method string, requiresAuth bool, handler http.HandlerFunc, description string, rateLimit int) {
-	requiredRole := ""
+	var requiredPermission db.Permission
 	if requiresAuth {
-		requiredRole = "user" // Default to user level access
+		requiredPermission = db.PermissionRead // Default to user level access
 	}
 	route := RouteMetadata{
 		Path:                   path,
 		Method:                 method,
 		RequiresAuthentication: requiresAuth,
-		RequiredRole:           requiredRole,
+		RequiredPermission:     requiredPermission,
 		Handler:                handler,
 		Description:            description,
 		RateLimit:              rateLimit,
@@ -81,14 +83,14 @@ func (rr *RouteRegistry) RegisterRouteWithRateLimit(path, method string, require
 	rr.routes = append(rr.routes, route)
 }
 
-// RegisterRouteWithRoleAndRateLimit registers a route with role requirement and rate limiting
-func (rr *RouteRegistry) RegisterRouteWithRoleAndRateLimit(path, method, requiredRole string, handler http.HandlerFunc, description string, rateLimit int) {
-	requiresAuth := requiredRole != ""
+// RegisterRouteWithPermissionAndRateLimit registers a route with permission requirement and rate limiting
+func (rr *RouteRegistry) RegisterRouteWithPermissionAndRateLimit(path, method string, requiredPermission db.Permission, handler http.HandlerFunc, description string, rateLimit int) {
+	requiresAuth := requiredPermission != ""
 	route := RouteMetadata{
 		Path:                   path,
 		Method:                 method,
 		RequiresAuthentication: requiresAuth,
-		RequiredRole:           requiredRole,
+		RequiredPermission:     requiredPermission,
 		Handler:                handler,
 		Description:            description,
 		RateLimit:              rateLimit,
@@ -168,13 +170,75 @@ func (s *Server) SetupRoutes(router *mux.Router) *RouteRegistry {
 	registry.RegisterRouteWithRateLimit("/v1/packages/{name}/versions/{version}", "GET", false, s.getPackageVersionHandler, "Get package version", 6000)
 	api.HandleFunc("/packages/{name}/versions/{version}", s.getPackageVersionHandler).Methods("GET")
 
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/versions/{version}/checks", "GET", db.PermissionRead, s.getVersionChecksHandler, "Get package version validation checks", 1200)
+	api.HandleFunc("/packages/{name}/versions/{version}/checks", s.getVersionChecksHandler).Methods("GET")
+
+	// Second-reviewer approval - requires publisher role
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/versions/{version}/approve", "POST", db.PermissionPublish, s.approvePackageVersionHandler, "Approve package version", 300)
+	api.HandleFunc("/packages/{name}/versions/{version}/approve", s.approvePackageVersionHandler).Methods("POST")
+
+	// Time-limited download links - requires publisher role to create, none to use
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/versions/{version}/share", "POST", db.PermissionPublish, s.sharePackageVersionHandler, "Create package version share link", 300)
+	api.HandleFunc("/packages/{name}/versions/{version}/share", s.sharePackageVersionHandler).Methods("POST")
+	registry.RegisterRouteWithRateLimit("/v1/shared/{name}/{version}", "GET", false, s.sharedDownloadHandler, "Download shared package version", 600)
+	api.HandleFunc("/shared/{name}/{version}", s.sharedDownloadHandler).Methods("GET")
+
+	// Name reservations ahead of a first publish - requires publisher role
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/reservation", "POST", db.PermissionPublish, s.reservePackageNameHandler, "Reserve package name", 100)
+	api.HandleFunc("/packages/{name}/reservation", s.reservePackageNameHandler).Methods("POST")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/reservation", "DELETE", db.PermissionPublish, s.releasePackageNameHandler, "Release package name reservation", 100)
+	api.HandleFunc("/packages/{name}/reservation", s.releasePackageNameHandler).Methods("DELETE")
+
+	// Abuse reports - any signed-in user can flag a package for moderation
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/report", "POST", db.PermissionRead, s.reportPackageHandler, "Report package abuse", 20)
+	api.HandleFunc("/packages/{name}/report", s.reportPackageHandler).Methods("POST")
+
+	// Deprecation - requires publisher role; only the version's publisher or an admin may change it
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/versions/{version}/deprecate", "POST", db.PermissionPublish, s.deprecatePackageVersionHandler, "Deprecate package version", 300)
+	api.HandleFunc("/packages/{name}/versions/{version}/deprecate", s.deprecatePackageVersionHandler).Methods("POST")
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages/{name}/deprecate", "POST", db.PermissionPublish, s.deprecatePackageHandler, "Deprecate every package version", 300)
+	api.HandleFunc("/packages/{name}/deprecate", s.deprecatePackageHandler).Methods("POST")
+
+	// Bulk metadata lookup - public, replaces one request per dependency
+	registry.RegisterRouteWithRateLimit("/v1/packages/bulk", "POST", false, s.bulkPackageVersionsHandler, "Bulk package version metadata", 1500)
+	api.HandleFunc("/packages/bulk", s.bulkPackageVersionsHandler).Methods("POST")
+
+	// Delta index sync - public, clients keep a local snapshot up to date
+	registry.RegisterRouteWithRateLimit("/v1/index", "GET", false, s.indexHandler, "Packages changed since a sync cursor", 600)
+	api.HandleFunc("/index", s.indexHandler).Methods("GET")
+
+	// Package previews for search - public, reads the stored archive
+	registry.RegisterRouteWithRateLimit("/v1/packages/{name}/preview", "GET", false, s.packagePreviewHandler, "Preview package rule files", 1500)
+	api.HandleFunc("/packages/{name}/preview", s.packagePreviewHandler).Methods("GET")
+
 	registry.RegisterRouteWithRateLimit("/v1/packages/{name}", "GET", false, s.getPackageHandler, "Get package details", 6000)
 	api.HandleFunc("/packages/{name}", s.getPackageHandler).Methods("GET")
 
+	// Author pages - public profiles with the packages each user publishes
+	registry.RegisterRouteWithRateLimit("/v1/users/{username}", "GET", false, s.authorHandler, "Get author profile", 1000)
+	api.HandleFunc("/users/{username}", s.authorHandler).Methods("GET")
+
 	// Publishing - requires publisher role, with rate limiting
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/packages", "POST", "publisher", s.publishPackageHandler, "Publish package", 500)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/packages", "POST", db.PermissionPublish, s.publishPackageHandler, "Publish package", 500)
 	api.HandleFunc("/packages", s.publishPackageHandler).Methods("POST")
 
+	// Chunked publishing for large archives - requires publisher role; see chunked.go
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/uploads", "POST", db.PermissionPublish, s.createUploadHandler, "Start chunked upload", 500)
+	api.HandleFunc("/uploads", s.createUploadHandler).Methods("POST")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/uploads/{id}", "GET", db.PermissionPublish, s.getUploadHandler, "Get chunked upload offset", 6000)
+	api.HandleFunc("/uploads/{id}", s.getUploadHandler).Methods("GET")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/uploads/{id}", "PATCH", db.PermissionPublish, s.patchUploadHandler, "Upload archive chunk", 6000)
+	api.HandleFunc("/uploads/{id}", s.patchUploadHandler).Methods("PATCH")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/uploads/{id}", "DELETE", db.PermissionPublish, s.deleteUploadHandler, "Abort chunked upload", 500)
+	api.HandleFunc("/uploads/{id}", s.deleteUploadHandler).Methods("DELETE")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/uploads/{id}/complete", "POST", db.PermissionPublish, s.completeUploadHandler, "Complete chunked upload", 500)
+	api.HandleFunc("/uploads/{id}/complete", s.completeUploadHandler).Methods("POST")
+
 	// Authentication endpoints - public for registration and login
 	registry.RegisterRouteWithRateLimit("/v1/auth/register", "POST", false, s.registerHandler, "User registration", 500)
 	api.HandleFunc("/auth/register", s.registerHandler).Methods("POST")
@@ -183,24 +247,42 @@ func (s *Server) SetupRoutes(router *mux.Router) *RouteRegistry {
 	api.HandleFunc("/auth/login", s.loginHandler).Methods("POST")
 
 	// User management endpoints - require authentication
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/auth/logout", "POST", "user", s.logoutHandler, "User logout", 300)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/auth/logout", "POST", db.PermissionRead, s.logoutHandler, "User logout", 300)
 	api.HandleFunc("/auth/logout", s.logoutHandler).Methods("POST")
 
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/auth/profile", "GET", "user", s.profileHandler, "Get user profile", 600)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/auth/profile", "GET", db.PermissionRead, s.profileHandler, "Get user profile", 600)
 	api.HandleFunc("/auth/profile", s.profileHandler).Methods("GET")
 
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/auth/change-password", "POST", "user", s.changePasswordHandler, "Change password", 50)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/auth/profile", "PUT", db.PermissionRead, s.updateProfileHandler, "Update user profile", 100)
+	api.HandleFunc("/auth/profile", s.updateProfileHandler).Methods("PUT")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/auth/change-password", "POST", db.PermissionRead, s.changePasswordHandler, "Change password", 50)
 	api.HandleFunc("/auth/change-password", s.changePasswordHandler).Methods("POST")
 
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/auth/delete-account", "DELETE", "user", s.deleteAccountHandler, "Delete account", 20)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/auth/delete-account", "DELETE", db.PermissionRead, s.deleteAccountHandler, "Delete account", 20)
 	api.HandleFunc("/auth/delete-account", s.deleteAccountHandler).Methods("DELETE")
 
-	// Admin endpoints - require admin role
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/admin/users", "GET", "admin", s.listUsersHandler, "List all users", 300)
+	// Admin endpoints - each requires the permission it names
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/users", "GET", db.PermissionAdmin, s.listUsersHandler, "List all users", 300)
 	api.HandleFunc("/admin/users", s.listUsersHandler).Methods("GET")
 
-	registry.RegisterRouteWithRoleAndRateLimit("/v1/admin/users/{id}", "DELETE", "admin", s.adminDeleteUserHandler, "Admin delete user", 50)
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/users/{id}", "DELETE", db.PermissionAdmin, s.adminDeleteUserHandler, "Admin delete user", 50)
 	api.HandleFunc("/admin/users/{id}", s.adminDeleteUserHandler).Methods("DELETE")
 
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/cache", "GET", db.PermissionAdmin, s.cacheStatsHandler, "Cache hit metrics", 300)
+	api.HandleFunc("/admin/cache", s.cacheStatsHandler).Methods("GET")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/permissions", "GET", db.PermissionAdmin, s.permissionsHandler, "Role permission matrix", 300)
+	api.HandleFunc("/admin/permissions", s.permissionsHandler).Methods("GET")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/packages/{name}/badges", "PUT", db.PermissionManageOrg, s.setPackageBadgesHandler, "Set package badges", 100)
+	api.HandleFunc("/admin/packages/{name}/badges", s.setPackageBadgesHandler).Methods("PUT")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/reports", "GET", db.PermissionModerate, s.listReportsHandler, "List package abuse reports", 300)
+	api.HandleFunc("/admin/reports", s.listReportsHandler).Methods("GET")
+
+	registry.RegisterRouteWithPermissionAndRateLimit("/v1/admin/reports/{id}/resolve", "POST", db.PermissionModerate, s.resolveReportHandler, "Resolve package abuse report", 100)
+	api.HandleFunc("/admin/reports/{id}/resolve", s.resolveReportHandler).Methods("POST")
+
 	return registry
 }
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+)
+
+// maxReportMessage is the longest message a report or moderation note can carry
+const maxReportMessage = 2000
+
+// Moderation actions an admin can resolve a report with
+const (
+	moderationDismiss = "dismiss"
+	moderationYank    = "yank"
+	moderationBan     = "ban"
+)
+
+// reportRequest is the body of a package abuse report
+type reportRequest struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Version string `json:"version"` // Reported version, or empty for the whole package
+}
+
+// resolveReportRequest is the body of a moderation action on a report
+type resolveReportRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"` // Why, recorded on yanked versions
+}
+
+// reportPackageHandler lets users flag a malicious, spammy or impersonating
+// package for the admins' moderation queue
+func (s *Server) reportPackageHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req reportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !slices.Contains(db.ReportReasons, req.Reason) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("reason must be one of: %s", strings.Join(db.ReportReasons, ", ")))
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if len(message) > maxReportMessage {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("message must be at most %d characters", maxReportMessage))
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	database := s.DB.WithContext(r.Context())
+	pkg, err := database.GetPackage(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+
+	var version *string
+	if req.Version != "" {
+		if _, err := database.GetPackageVersion(name, req.Version); err != nil {
+			writeError(w, http.StatusNotFound, "Package version not found")
+			return
+		}
+		version = &req.Version
+	}
+
+	var messagePtr *string
+	if message != "" {
+		messagePtr = &message
+	}
+	report, err := database.CreatePackageReport(pkg.ID, version, user.ID, req.Reason, messagePtr)
+	if err != nil {
+		if errors.Is(err, db.ErrAlreadyReported) {
+			writeError(w, http.StatusConflict, "You already have an open report against this package")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to report package")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// listReportsHandler returns the moderation queue: open reports by default, or
+// those in the status given by ?status=, or all of them for ?status=all
+func (s *Server) listReportsHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = db.ReportStatusOpen
+	case "all":
+		status = ""
+	case db.ReportStatusOpen, db.ReportStatusDismissed, db.ReportStatusYanked, db.ReportStatusBanned:
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid report status")
+		return
+	}
+
+	reports, err := s.DB.WithContext(r.Context()).ListPackageReports(status)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list reports")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+// resolveReportHandler closes an open report with a moderation action. A yank
+// withdraws the reported version, or every published version of the package; a
+// ban also deactivates the accounts that published them.
+func (s *Server) resolveReportHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req resolveReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var status string
+	switch req.Action {
+	case moderationDismiss:
+		status = db.ReportStatusDismissed
+	case moderationYank:
+		status = db.ReportStatusYanked
+	case moderationBan:
+		status = db.ReportStatusBanned
+	default:
+		writeError(w, http.StatusBadRequest, "action must be one of: dismiss, yank, ban")
+		return
+	}
+	// Moderators can dismiss reports; withdrawing versions and banning accounts
+	// take the permissions of those actions too
+	if status != db.ReportStatusDismissed && !user.Role.HasPermission(db.PermissionYank) {
+		writeError(w, http.StatusForbidden, "Yanking versions requires the yank permission")
+		return
+	}
+	if status == db.ReportStatusBanned && !user.Role.HasPermission(db.PermissionAdmin) {
+		writeError(w, http.StatusForbidden, "Banning publishers requires the admin permission")
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxReportMessage {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("note must be at most %d characters", maxReportMessage))
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	report, err := database.GetPackageReport(reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Report not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get report")
+		return
+	}
+	if report.Status != db.ReportStatusOpen {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Report is already %s", report.Status))
+		return
+	}
+
+	var yanked []string
+	var banned []string
+	if status != db.ReportStatusDismissed {
+		if note == "" {
+			note = fmt.Sprintf("Withdrawn by moderators after a %s report", report.Reason)
+		}
+		versions, err := database.YankPackageVersions(report.PackageID, report.Version, note)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to yank package versions")
+			return
+		}
+		for _, v := range versions {
+			yanked = append(yanked, v.Version)
+		}
+
+		if status == db.ReportStatusBanned {
+			if banned, err = banPublishers(database, versions, user.ID); err != nil {
+				writeError(w, http.StatusInternalServerError, "Failed to ban publishers")
+				return
+			}
+		}
+		s.Cache.Invalidate()
+	}
+
+	resolved, err := database.ResolvePackageReports(report.ID, status, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to resolve report")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"report":          report.ID,
+		"package":         report.PackageName,
+		"status":          status,
+		"resolved":        resolved,
+		"yanked_versions": yanked,
+		"banned_users":    banned,
+	})
+}
+
+// banPublishers deactivates the accounts that published versions, except the
+// acting admin's and other admins', and returns their usernames
+func banPublishers(database *db.DB, versions []db.PackageVersion, adminID int) ([]string, error) {
+	seen := map[int]bool{adminID: true}
+	var banned []string
+	for _, v := range versions {
+		if v.PublishedBy == nil || seen[*v.PublishedBy] {
+			continue
+		}
+		seen[*v.PublishedBy] = true
+
+		// Deactivated accounts are not found, and need no ban
+		publisher, err := database.GetUserByID(*v.PublishedBy)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return banned, err
+		}
+		if publisher.Role.HasPermission(db.PermissionAdmin) {
+			continue
+		}
+		if err := database.DeleteUser(publisher.ID); err != nil {
+			return banned, err
+		}
+		banned = append(banned, publisher.Username)
+	}
+	return banned, nil
+}
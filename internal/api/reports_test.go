@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+)
+
+func TestPackageReports(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	createUser := func(name string, role db.UserRole) *db.User {
+		user, err := database.CreateUser(db.CreateUserRequest{Username: name, Email: name + "@example.com", Password: "secret123", Role: role})
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		return user
+	}
+	mallory := createUser("mallory", db.RolePublisher)
+	alice := createUser("alice", db.RoleUser)
+	bob := createUser("bob", db.RoleUser)
+	admin := createUser("admin", db.RoleAdmin)
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		if _, err := database.PublishPackageVersion("security-rulez", db.PackageVersion{Version: version, Status: db.VersionStatusPublished, PublishedBy: &mallory.ID}, nil, func() error { return nil }); err != nil {
+			t.Fatalf("PublishPackageVersion failed: %v", err)
+		}
+	}
+	s := &Server{DB: database, Cache: newResponseCache(100, time.Minute)}
+
+	request := func(user *db.User, handler http.HandlerFunc, method, path, body string, vars map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+		r = mux.SetURLVars(r, vars)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+	report := func(user *db.User, name, body string) *httptest.ResponseRecorder {
+		return request(user, s.reportPackageHandler, "POST", "/v1/packages/"+name+"/report", body, map[string]string{"name": name})
+	}
+	resolve := func(id, body string) *httptest.ResponseRecorder {
+		return request(admin, s.resolveReportHandler, "POST", "/v1/admin/reports/"+id+"/resolve", body, map[string]string{"id": id})
+	}
+	queue := func(status string) []db.PackageReport {
+		w := request(admin, s.listReportsHandler, "GET", "/v1/admin/reports?status="+status, "", nil)
+		var resp struct {
+			Reports []db.PackageReport `json:"reports"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("list reports: %d (%v)", w.Code, err)
+		}
+		return resp.Reports
+	}
+
+	w := report(alice, "security-rulez", `{"reason": "impersonation", "message": "Copies security-rules"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("report: status %d: %s", w.Code, w.Body.String())
+	}
+	var created db.PackageReport
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.PackageName != "security-rulez" || created.Reporter != "alice" || created.Status != db.ReportStatusOpen {
+		t.Errorf("unexpected report %+v", created)
+	}
+
+	if w := report(alice, "security-rulez", `{"reason": "spam"}`); w.Code != http.StatusConflict {
+		t.Errorf("second open report: status %d, want 409", w.Code)
+	}
+	if w := report(bob, "security-rulez", `{"reason": "rude"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown reason: status %d, want 400", w.Code)
+	}
+	if w := report(bob, "security-rulez", `{"reason": "malicious", "version": "9.9.9"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown version: status %d, want 404", w.Code)
+	}
+	if w := report(bob, "missing-rules", `{"reason": "spam"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown package: status %d, want 404", w.Code)
+	}
+	if w := report(bob, "security-rulez", `{"reason": "malicious", "version": "1.1.0"}`); w.Code != http.StatusCreated {
+		t.Fatalf("version report: status %d: %s", w.Code, w.Body.String())
+	}
+
+	open := queue("")
+	if len(open) != 2 || open[0].ID != created.ID || open[1].Version == nil || *open[1].Version != "1.1.0" {
+		t.Fatalf("expected both reports queued, got %+v", open)
+	}
+
+	if w := resolve("1", `{"action": "shrug"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown action: status %d, want 400", w.Code)
+	}
+
+	// A role that may only moderate cannot withdraw versions
+	db.SetPermissionMatrix(db.PermissionMatrix{db.RoleUser: {db.PermissionRead, db.PermissionModerate}})
+	w = request(bob, s.resolveReportHandler, "POST", "/v1/admin/reports/1/resolve", `{"action": "yank"}`, map[string]string{"id": "1"})
+	db.SetPermissionMatrix(db.DefaultPermissionMatrix)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("yank without the yank permission: status %d, want 403", w.Code)
+	}
+
+	// Banning yanks every published version and closes every open report on the package
+	w = resolve("1", `{"action": "ban", "note": "Typosquat of security-rules"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ban: status %d: %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		Resolved int      `json:"resolved"`
+		Yanked   []string `json:"yanked_versions"`
+		Banned   []string `json:"banned_users"`
+	}
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.Resolved != 2 || len(result.Yanked) != 2 || len(result.Banned) != 1 || result.Banned[0] != "mallory" {
+		t.Errorf("unexpected ban result %+v", result)
+	}
+
+	if len(queue("")) != 0 || len(queue("banned")) != 2 {
+		t.Errorf("expected the queue emptied into banned reports")
+	}
+	if v, err := database.GetPackageVersion("security-rulez", "1.0.0"); err != nil || v.Status != db.VersionStatusRejected {
+		t.Errorf("expected the version yanked, got %+v (%v)", v, err)
+	}
+	if _, err := database.GetUserByID(mallory.ID); err == nil {
+		t.Errorf("expected the publisher's account deactivated")
+	}
+	if w := resolve("1", `{"action": "dismiss"}`); w.Code != http.StatusConflict {
+		t.Errorf("resolving a closed report: status %d, want 409", w.Code)
+	}
+
+	// A yanked version number stays taken
+	if _, err := database.PublishPackageVersion("security-rulez", db.PackageVersion{Version: "1.0.0", PublishedBy: &admin.ID}, nil, func() error { return nil }); err != db.ErrVersionExists {
+		t.Errorf("republishing a yanked version: err = %v, want ErrVersionExists", err)
+	}
+}
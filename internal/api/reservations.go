@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+	"rulestack/internal/manifest"
+)
+
+// defaultReservationDays is how long a reservation lasts when no duration is given
+const defaultReservationDays = 30
+
+// reservePackageNameHandler holds a package name for the caller until its first publish
+func (s *Server) reservePackageNameHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	if s.Config.ReservationMaxDays == 0 {
+		writeError(w, http.StatusForbidden, "Name reservations are disabled on this registry")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := manifest.ValidateName(name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req struct {
+		Days int `json:"days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Days == 0 {
+		req.Days = min(defaultReservationDays, s.Config.ReservationMaxDays)
+	}
+	if req.Days < 1 || req.Days > s.Config.ReservationMaxDays {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Reservations last between 1 and %d days", s.Config.ReservationMaxDays))
+		return
+	}
+
+	database := s.DB.WithContext(r.Context())
+	if _, err := database.GetPackage(name); err == nil {
+		writeError(w, http.StatusConflict, "Package already exists")
+		return
+	} else if err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, "Failed to check package")
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.Days) * 24 * time.Hour)
+	reservation, err := database.ReservePackageName(name, user.ID, expiresAt)
+	if err != nil {
+		if errors.Is(err, db.ErrNameReserved) {
+			writeErrorCode(w, http.StatusConflict, CodeNameReserved, reservedMessage(database, name), nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to reserve package name")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+// releasePackageNameHandler gives up the caller's reservation of a package name
+func (s *Server) releasePackageNameHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := s.DB.WithContext(r.Context()).ReleasePackageName(name, user.ID); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "You do not hold a reservation for this name")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to release reservation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":     name,
+		"released": true,
+	})
+}
+
+// reservedMessage describes who holds a name and until when
+func reservedMessage(database *db.DB, name string) string {
+	reservation, err := database.GetActiveReservation(name)
+	if err != nil {
+		return "Package name is reserved by another user"
+	}
+	return fmt.Sprintf("Package name is reserved by %s until %s", reservation.Username, reservation.ExpiresAt.UTC().Format(time.DateOnly))
+}
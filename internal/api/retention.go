@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+	"rulestack/internal/retention"
+	"rulestack/internal/tracing"
+)
+
+// retentionStore is the subset of the database the retention job needs
+type retentionStore interface {
+	ListPublishedVersions() ([]db.NamedPackageVersion, error)
+	DeletePackageVersion(versionID int) error
+	CountVersionsWithBlobPath(blobPath string) (int, error)
+}
+
+// retentionPolicy builds the storage retention policy from the server config
+func retentionPolicy(cfg config.Config) retention.Policy {
+	return retention.Policy{
+		KeepVersions:     cfg.RetentionKeepVersions,
+		PrereleaseMaxAge: time.Duration(cfg.RetentionPrereleaseDays) * 24 * time.Hour,
+	}
+}
+
+// startRetention applies the retention policy now and then on every interval
+func (s *Server) startRetention(ctx context.Context, policy retention.Policy, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if removed, _ := applyRetention(ctx, s.DB, policy, s.Config.StoragePath, time.Now()); removed > 0 {
+				s.Cache.Invalidate()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// applyRetention removes expired versions and then any archive no longer referenced
+// by a version. Rows are deleted before blobs so a failure never leaves a version
+// pointing at a missing archive. Returns the number of versions removed and bytes freed.
+func applyRetention(ctx context.Context, store retentionStore, policy retention.Policy, storagePath string, now time.Time) (int, int64) {
+	_, span := tracing.Start(ctx, "retention")
+	defer span.End()
+
+	versions, err := store.ListPublishedVersions()
+	if err != nil {
+		log.Printf("retention: failed to list versions: %v", err)
+		return 0, 0
+	}
+
+	byPackage := make(map[string][]db.NamedPackageVersion)
+	for _, version := range versions {
+		byPackage[version.PackageName] = append(byPackage[version.PackageName], version)
+	}
+
+	removed := 0
+	var freed int64
+	for name, packageVersions := range byPackage {
+		candidates := make([]retention.Candidate, len(packageVersions))
+		byVersion := make(map[string]db.NamedPackageVersion, len(packageVersions))
+		for i, version := range packageVersions {
+			candidates[i] = retention.Candidate{Version: version.Version, PublishedAt: version.CreatedAt}
+			byVersion[version.Version] = version
+		}
+
+		for _, expired := range retention.Expired(candidates, policy, now) {
+			version := byVersion[expired.Version]
+			if err := store.DeletePackageVersion(version.ID); err != nil {
+				log.Printf("retention: failed to remove %s@%s: %v", name, version.Version, err)
+				continue
+			}
+			removed++
+			log.Printf("retention: removed %s@%s", name, version.Version)
+
+			freed += removeUnreferencedBlob(store, version.PackageVersion, storagePath)
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("retention: removed %d version(s), freed %d bytes", removed, freed)
+	}
+	return removed, freed
+}
+
+// removeUnreferencedBlob deletes a version's archive once no other version uses it.
+// Only files inside the storage directory are ever deleted.
+func removeUnreferencedBlob(store retentionStore, version db.PackageVersion, storagePath string) int64 {
+	if version.BlobPath == nil {
+		return 0
+	}
+
+	count, err := store.CountVersionsWithBlobPath(*version.BlobPath)
+	if err != nil || count > 0 {
+		return 0
+	}
+
+	blobPath, err := filepath.Abs(*version.BlobPath)
+	if err != nil {
+		return 0
+	}
+	storageRoot, err := filepath.Abs(storagePath)
+	if err != nil || !strings.HasPrefix(blobPath, storageRoot+string(filepath.Separator)) {
+		log.Printf("retention: not deleting %s outside storage path", *version.BlobPath)
+		return 0
+	}
+
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return 0
+	}
+	if err := os.Remove(blobPath); err != nil {
+		log.Printf("retention: failed to delete %s: %v", blobPath, err)
+		return 0
+	}
+
+	return info.Size()
+}
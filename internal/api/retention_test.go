@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rulestack/internal/db"
+	"rulestack/internal/retention"
+)
+
+type fakeRetentionStore struct {
+	versions []db.NamedPackageVersion
+	deleted  []int
+}
+
+func (f *fakeRetentionStore) ListPublishedVersions() ([]db.NamedPackageVersion, error) {
+	return f.versions, nil
+}
+
+func (f *fakeRetentionStore) DeletePackageVersion(versionID int) error {
+	f.deleted = append(f.deleted, versionID)
+	return nil
+}
+
+func (f *fakeRetentionStore) CountVersionsWithBlobPath(blobPath string) (int, error) {
+	count := 0
+	for _, version := range f.versions {
+		if *version.BlobPath != blobPath {
+			continue
+		}
+		deleted := false
+		for _, id := range f.deleted {
+			deleted = deleted || id == version.ID
+		}
+		if !deleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestApplyRetention(t *testing.T) {
+	storagePath := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.tgz")
+
+	writeBlob := func(path string) string {
+		if err := os.WriteFile(path, []byte("archive"), 0644); err != nil {
+			t.Fatalf("failed to write blob: %v", err)
+		}
+		return path
+	}
+	version := func(id int, name, v, sha, blobPath string, age time.Duration) db.NamedPackageVersion {
+		return db.NamedPackageVersion{
+			PackageName: name,
+			PackageVersion: db.PackageVersion{
+				ID: id, Version: v, SHA256: &sha, BlobPath: &blobPath,
+				CreatedAt: time.Now().Add(-age),
+			},
+		}
+	}
+
+	oldBlob := writeBlob(filepath.Join(storagePath, "old.tgz"))
+	sharedBlob := writeBlob(filepath.Join(storagePath, "shared.tgz"))
+	newBlob := writeBlob(filepath.Join(storagePath, "new.tgz"))
+	sameA := writeBlob(filepath.Join(storagePath, "same-1.0.0.tgz"))
+	sameB := writeBlob(filepath.Join(storagePath, "same-1.1.0.tgz"))
+	sameNewest := writeBlob(filepath.Join(storagePath, "same-1.2.0.tgz"))
+	writeBlob(outside)
+
+	store := &fakeRetentionStore{versions: []db.NamedPackageVersion{
+		version(1, "rules", "1.0.0", "old", oldBlob, 72*time.Hour),
+		version(2, "rules", "1.1.0", "shared", sharedBlob, 48*time.Hour),
+		version(3, "rules", "1.2.0", "new", newBlob, 24*time.Hour),
+		version(4, "other", "2.0.0", "shared", sharedBlob, time.Hour),
+		version(5, "escape", "0.1.0", "escape", outside, 72*time.Hour),
+		version(6, "escape", "0.2.0", "escape2", outside, time.Hour),
+		// Identical content stored in a file per version
+		version(7, "same", "1.0.0", "same", sameA, 72*time.Hour),
+		version(8, "same", "1.1.0", "same", sameB, 48*time.Hour),
+		version(9, "same", "1.2.0", "newest", sameNewest, time.Hour),
+	}}
+
+	removed, freed := applyRetention(context.Background(), store, retention.Policy{KeepVersions: 1}, storagePath, time.Now())
+
+	if removed != 5 {
+		t.Errorf("expected 5 versions removed, got %d (%v)", removed, store.deleted)
+	}
+	if freed != 3*int64(len("archive")) {
+		t.Errorf("expected only the unshared archives to be freed, got %d bytes", freed)
+	}
+	for _, path := range []string{sameA, sameB} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted although another version has the same content", filepath.Base(path))
+		}
+	}
+	if _, err := os.Stat(oldBlob); !os.IsNotExist(err) {
+		t.Error("expected unreferenced archive to be deleted")
+	}
+	if _, err := os.Stat(sharedBlob); err != nil {
+		t.Error("expected archive still used by another version to be kept")
+	}
+	if _, err := os.Stat(newBlob); err != nil {
+		t.Error("expected newest version's archive to be kept")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Error("expected archive outside the storage path to be left alone")
+	}
+}
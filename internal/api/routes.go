@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"time"
+
 	"github.com/gorilla/mux"
 
 	"rulestack/internal/config"
@@ -12,13 +15,34 @@ type Server struct {
 	DB       *db.DB
 	Config   config.Config
 	Registry *RouteRegistry
+	Pipeline *ValidationPipeline
+	Cache    *responseCache   // Hot search and metadata reads; nil when disabled
+	Upstream upstreamRegistry // Registry mirrored on a miss in pull-through mode; nil when off
 }
 
 // RegisterRoutes sets up all API routes with enhanced security
 func RegisterRoutes(r *mux.Router, database *db.DB, cfg config.Config) {
 	s := &Server{
-		DB:     database,
-		Config: cfg,
+		DB:       database,
+		Config:   cfg,
+		Pipeline: NewValidationPipeline(database, cfg),
+		Cache:    newResponseCache(cfg.CacheSize, cfg.CacheTTL),
+		Upstream: newUpstream(cfg.UpstreamURL, cfg.UpstreamToken),
+	}
+	s.Pipeline.statusChanged = s.Cache.Invalidate
+
+	// Clean up after publishes interrupted by a crash
+	removeOrphanedBlobs(database, cfg.StoragePath, time.Now())
+
+	// Uploads stay pending until the validation pipeline publishes or rejects them
+	if s.Pipeline.Enabled() {
+		s.Pipeline.Start(context.Background(), 2)
+		s.resumePendingValidations()
+	}
+
+	// Old versions expire under the storage retention policy
+	if policy := retentionPolicy(cfg); policy.Enabled() {
+		s.startRetention(context.Background(), policy, cfg.RetentionInterval)
 	}
 
 	// Create route registry
@@ -26,11 +50,14 @@ func RegisterRoutes(r *mux.Router, database *db.DB, cfg config.Config) {
 	s.Registry = registry
 
 	// Apply middleware in order (outermost to innermost)
-	r.Use(panicRecoveryMiddleware)                        // Panic recovery (outermost)
+	r.Use(requestIDMiddleware)                            // Request IDs (outermost, so every response has one)
+	r.Use(panicRecoveryMiddleware)                        // Panic recovery
+	r.Use(s.tracingMiddleware)                            // Request spans and trace context
 	r.Use(s.securityHeadersMiddleware)                    // Security headers
 	r.Use(s.corsMiddleware)                               // CORS
 	r.Use(s.loggingMiddleware)                            // Request logging
 	r.Use(s.requestSizeLimitMiddleware(50 * 1024 * 1024)) // 50MB max request size
+	r.Use(compressionMiddleware(50 * 1024 * 1024))        // Compressed JSON responses and request bodies
 	r.Use(s.rateLimitMiddleware(registry))                // Rate limiting
 	r.Use(s.jsonSanitizeMiddleware)                       // JSON sanitization
 	r.Use(s.enhancedAuthMiddleware(registry))             // Authentication
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -16,8 +18,13 @@ import (
 
 	"rulestack/internal/auth"
 	"rulestack/internal/db"
+	"rulestack/internal/tracing"
 
+	"github.com/gorilla/mux"
 	"github.com/microcosm-cc/bluemonday"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Context keys for user data
@@ -30,7 +37,7 @@ const (
 
 // Enhanced auth middleware with JWT and role-based access support
 func (s *Server) enhancedAuthMiddleware(registry *RouteRegistry) func(http.Handler) http.Handler {
-	jwtManager := auth.NewJWTManager(s.Config.JWTSecret, auth.DevelopmentTokenDuration)
+	jwtManager := auth.NewJWTManager(s.Config.JWTSecret, s.tokenLifetime())
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -56,7 +63,7 @@ func (s *Server) enhancedAuthMiddleware(registry *RouteRegistry) func(http.Handl
 			// If route not found in registry, assume it requires authentication
 			if !routeFound {
 				routeMetadata.RequiresAuthentication = true
-				routeMetadata.RequiredRole = "user"
+				routeMetadata.RequiredPermission = db.PermissionRead
 			}
 
 			// Extract Authorization header
@@ -99,12 +106,22 @@ func (s *Server) enhancedAuthMiddleware(registry *RouteRegistry) func(http.Handl
 
 				// JWT token is valid, get user and session from database
 				tokenHash := jwtManager.GetTokenHash(token)
-				if u, sess, err := s.DB.ValidateUserSession(tokenHash); err == nil {
+				if u, sess, err := s.DB.WithContext(r.Context()).ValidateUserSession(tokenHash); err == nil {
 					user = u
 					session = sess
 					fmt.Fprintf(os.Stderr, "DEBUG AUTH: Database session found for user ID %d, role: %s\n", user.ID, user.Role)
+					if reason := s.sessionExpiry(session, time.Now()); reason != "" {
+						log.Printf("session: ended session %d of %s: %s", session.ID, user.Username, reason)
+						s.DB.WithContext(r.Context()).DeleteUserSession(session.ID)
+						writeError(w, http.StatusUnauthorized, reason)
+						return
+					}
+					if !s.checkSessionBinding(r, user, session) {
+						writeError(w, http.StatusUnauthorized, "Session used from a new network or device; log in again")
+						return
+					}
 					// Update session last used time
-					s.DB.UpdateSessionLastUsed(session.ID)
+					s.DB.WithContext(r.Context()).UpdateSessionLastUsed(session.ID)
 				} else {
 					fmt.Fprintf(os.Stderr, "DEBUG AUTH: JWT valid but no database session found: %v\n", err)
 					writeError(w, http.StatusUnauthorized, "Invalid or expired session")
@@ -116,19 +133,11 @@ func (s *Server) enhancedAuthMiddleware(registry *RouteRegistry) func(http.Handl
 				return
 			}
 
-			// Check role-based access
-			if routeMetadata.RequiredRole != "" {
-				fmt.Fprintf(os.Stderr, "DEBUG AUTH: Route requires role: %s, user has role: %s\n", routeMetadata.RequiredRole, user.Role)
-
-				hasAccess := false
-				switch routeMetadata.RequiredRole {
-				case "user":
-					hasAccess = user.Role.HasPermission("read")
-				case "publisher":
-					hasAccess = user.Role.HasPermission("publish")
-				case "admin":
-					hasAccess = user.Role.HasPermission("admin")
-				}
+			// Check the route's permission against the user's role
+			if routeMetadata.RequiredPermission != "" {
+				fmt.Fprintf(os.Stderr, "DEBUG AUTH: Route requires permission: %s, user has role: %s\n", routeMetadata.RequiredPermission, user.Role)
+
+				hasAccess := user.Role.HasPermission(routeMetadata.RequiredPermission)
 
 				fmt.Fprintf(os.Stderr, "DEBUG AUTH: Permission check result: %t\n", hasAccess)
 
@@ -143,6 +152,7 @@ func (s *Server) enhancedAuthMiddleware(registry *RouteRegistry) func(http.Handl
 			ctx := r.Context()
 			if user != nil {
 				ctx = context.WithValue(ctx, userContextKey, user)
+				trace.SpanFromContext(ctx).SetAttributes(attribute.Int("enduser.id", user.ID))
 			}
 			if session != nil {
 				ctx = context.WithValue(ctx, sessionContextKey, session)
@@ -158,8 +168,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Upload-Offset, traceparent, tracestate, X-Request-ID")
 		w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 
 		// Handle preflight requests
@@ -297,13 +307,14 @@ func (s *Server) rateLimitMiddleware(registry *RouteRegistry) func(http.Handler)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get client IP
-			ip := getClientIP(r)
+			ip := s.getClientIP(r)
 
 			// Check if route has rate limit
 			if registry != nil {
 				if metadata, found := registry.GetRouteMetadata(r.URL.Path, r.Method); found {
 					if metadata.RateLimit > 0 {
 						if !limiter.allow(ip, metadata.RateLimit) {
+							trace.SpanFromContext(r.Context()).AddEvent("rate limit exceeded")
 							writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
 							return
 						}
@@ -316,6 +327,41 @@ func (s *Server) rateLimitMiddleware(registry *RouteRegistry) func(http.Handler)
 	}
 }
 
+// Tracing middleware starts the server span for each request, continuing the
+// trace of the caller when it sends a traceparent header
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Name spans after the route template so they group across packages
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", s.getClientIP(r)),
+				attribute.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+	})
+}
+
 // Security headers middleware
 func (s *Server) securityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -342,13 +388,21 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start)
-		log.Printf("[%s] %s %s - %d (%v) - %s",
-			getClientIP(r),
+		traceSuffix := ""
+		if requestID := w.Header().Get(RequestIDHeader); requestID != "" {
+			traceSuffix = " - request " + requestID
+		}
+		if traceID := tracing.TraceID(r.Context()); traceID != "" {
+			traceSuffix += " - trace " + traceID
+		}
+		log.Printf("[%s] %s %s - %d (%v) - %s%s",
+			s.getClientIP(r),
 			r.Method,
 			r.URL.Path,
 			wrapped.statusCode,
 			duration,
 			r.UserAgent(),
+			traceSuffix,
 		)
 	})
 }
@@ -384,26 +438,68 @@ func sanitizeData(v interface{}, policy *bluemonday.Policy) interface{} {
 	}
 }
 
-func getClientIP(r *http.Request) string {
-	// Check for X-Forwarded-For header (behind proxy)
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		ips := strings.Split(xff, ",")
-		return strings.TrimSpace(ips[0])
+// getClientIP returns the address of the client that made the request.
+// Forwarding headers are only believed when the direct peer is a trusted proxy.
+func (s *Server) getClientIP(r *http.Request) string {
+	return clientIP(r, s.Config.TrustedProxies)
+}
+
+// clientIP derives the client address from the connection and forwarding headers.
+// X-Forwarded-For is walked from the right: each hop was appended by the proxy
+// before it, so the first hop not added by a trusted proxy is the real client.
+// Anything further left was supplied by the client and can be spoofed.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			addr, err := netip.ParseAddr(hop)
+			if err != nil {
+				// A malformed hop cannot be attributed; stop at the last address we trust
+				return client
+			}
+
+			client = addr.Unmap().String()
+			if !isTrustedProxy(client, trustedProxies) {
+				return client
+			}
+		}
+		return client
 	}
 
-	// Check for X-Real-IP header
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return xri
+	// X-Real-IP is set by single-hop proxies such as nginx
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
 	}
 
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	if strings.Contains(ip, ":") {
-		ip, _, _ = strings.Cut(ip, ":")
+	return remote
+}
+
+// isTrustedProxy reports whether ip falls within one of the trusted proxy ranges
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
 	}
-	return ip
+	addr = addr.Unmap()
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 type responseWriter struct {
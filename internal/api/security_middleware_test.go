@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/auth"
+	"rulestack/internal/db"
+	"rulestack/internal/tracing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.5/32"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		xRealIP    string
+		expected   string
+	}{
+		{
+			name:       "direct client ignores forwarding headers",
+			remoteAddr: "203.0.113.7:5123",
+			xff:        []string{"1.2.3.4"},
+			xRealIP:    "5.6.7.8",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy forwards client",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"198.51.100.20"},
+			expected:   "198.51.100.20",
+		},
+		{
+			name:       "spoofed leftmost hop is ignored",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"1.1.1.1, 198.51.100.20, 192.168.1.5"},
+			expected:   "198.51.100.20",
+		},
+		{
+			name:       "multiple header lines are joined",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"1.1.1.1", "198.51.100.20"},
+			expected:   "198.51.100.20",
+		},
+		{
+			name:       "all hops trusted returns leftmost",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"10.1.1.1, 10.2.2.2"},
+			expected:   "10.1.1.1",
+		},
+		{
+			name:       "malformed hop stops at last trusted address",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"198.51.100.20, not-an-ip"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			remoteAddr: "10.0.0.2:443",
+			xRealIP:    "198.51.100.20",
+			expected:   "198.51.100.20",
+		},
+		{
+			name:       "IPv6 remote address",
+			remoteAddr: "[2001:db8::1]:8080",
+			xff:        []string{"1.2.3.4"},
+			expected:   "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/health", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.xff {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.xRealIP != "" {
+				r.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+
+			if got := clientIP(r, trusted); got != tt.expected {
+				t.Errorf("clientIP() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTracingMiddlewareContinuesCallerTrace(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if _, err := tracing.Setup(context.Background(), "test"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	var traceID string
+	s := &Server{}
+	r := mux.NewRouter()
+	r.Use(s.tracingMiddleware)
+	r.HandleFunc("/v1/packages/{name}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = tracing.TraceID(r.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/v1/packages/my-rules", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected handler to run in the caller's trace, got %q", traceID)
+	}
+}
+
+func TestSessionPolicy(t *testing.T) {
+	s := &Server{}
+	if lifetime := s.tokenLifetime(); lifetime != auth.DefaultTokenDuration {
+		t.Errorf("unconfigured token lifetime = %v", lifetime)
+	}
+
+	s.Config.TokenLifetime = 30 * 24 * time.Hour
+	s.Config.SessionMaxLifetime = 7 * 24 * time.Hour
+	s.Config.SessionIdleTimeout = time.Hour
+	if lifetime := s.tokenLifetime(); lifetime != 7*24*time.Hour {
+		t.Errorf("expected tokens capped at the session lifetime, got %v", lifetime)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		lastUsed  time.Time
+		expired   bool
+	}{
+		{"active", now.Add(-24 * time.Hour), now.Add(-time.Minute), false},
+		{"idle", now.Add(-24 * time.Hour), now.Add(-2 * time.Hour), true},
+		{"past the absolute lifetime", now.Add(-8 * 24 * time.Hour), now.Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := s.sessionExpiry(&db.UserSession{CreatedAt: tt.createdAt, LastUsed: tt.lastUsed}, now)
+			if (reason != "") != tt.expired {
+				t.Errorf("sessionExpiry = %q, expired %v", reason, tt.expired)
+			}
+		})
+	}
+
+	s.Config.SessionMaxLifetime, s.Config.SessionIdleTimeout = 0, 0
+	if reason := s.sessionExpiry(&db.UserSession{CreatedAt: now.AddDate(-1, 0, 0), LastUsed: now.AddDate(-1, 0, 0)}, now); reason != "" {
+		t.Errorf("expected no policy without limits, got %q", reason)
+	}
+}
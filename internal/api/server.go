@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+)
+
+// Serve creates the storage directory and serves the registry API on cfg.APIPort
+// until the server fails
+func Serve(database *db.DB, cfg config.Config) error {
+	// Create storage directory if it doesn't exist
+	if err := os.MkdirAll(cfg.StoragePath, 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	// Roles grant what the role_permissions table lists
+	permissions, err := database.LoadPermissionMatrix()
+	if err != nil {
+		return fmt.Errorf("failed to load role permissions: %w", err)
+	}
+	db.SetPermissionMatrix(permissions)
+
+	// Set up router
+	r := mux.NewRouter()
+
+	// Register API routes
+	RegisterRoutes(r, database, cfg)
+
+	log.Printf("API server starting on port %s", cfg.APIPort)
+	log.Printf("Storage path: %s", cfg.StoragePath)
+	return http.ListenAndServe(":"+cfg.APIPort, r)
+}
+
+// EnsureRootUser creates the root user with password if no root user exists,
+// and reports whether it did
+func EnsureRootUser(database *db.DB, password string) (bool, error) {
+	// Check if any root user exists
+	var exists bool
+	err := database.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM users WHERE role = 'root'
+		)
+	`).Scan(&exists)
+
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		log.Println("Root user already exists")
+		return false, nil
+	}
+
+	log.Println("Creating default root user...")
+
+	user := &db.CreateUserRequest{
+		Username: "root",
+		Email:    "root@rulestack.init",
+		Password: password,
+		Role:     db.RoleRoot,
+	}
+
+	// Use the existing CreateUser method to create the root user
+	_, err = database.CreateUser(*user)
+	if err != nil {
+		// Check if it's a duplicate error (race condition)
+		if err.Error() == "username or email already exists" {
+			log.Println("Root user was created by another process")
+			return false, nil
+		}
+		return false, err
+	}
+
+	log.Println("Root user created successfully")
+	return true, nil
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"rulestack/internal/db"
+)
+
+// Session binding compares each request with the network and device its session
+// logged in from. Networks are coarse (an IPv4 /16 or IPv6 /32) so that DHCP
+// renewals and mobile hand-offs stay inside them; a session that shows up in a
+// different one has most likely had its token copied elsewhere.
+const (
+	sessionNetworkBitsV4 = 16
+	sessionNetworkBitsV6 = 32
+)
+
+// sessionAlertTimeout bounds how long a session alert webhook may take
+const sessionAlertTimeout = 10 * time.Second
+
+// uaVersion matches the version numbers in a User-Agent, which change on upgrades
+var uaVersion = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// sessionAlert is posted to the session alert webhook. It carries the account's
+// email so the receiver can tell the user.
+type sessionAlert struct {
+	Event          string    `json:"event"`
+	Action         string    `json:"action"`
+	Reason         string    `json:"reason"`
+	UserID         int       `json:"user_id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	SessionID      int       `json:"session_id"`
+	IPAddress      string    `json:"ip_address"`
+	UserAgent      string    `json:"user_agent"`
+	LoginIPAddress string    `json:"login_ip_address"`
+	LoginUserAgent string    `json:"login_user_agent"`
+	Time           time.Time `json:"time"`
+}
+
+// checkSessionBinding flags or ends a session used outside its binding and
+// notifies the alert webhook. It reports whether the request may go on.
+func (s *Server) checkSessionBinding(r *http.Request, user *db.User, session *db.UserSession) bool {
+	ip, userAgent := s.getClientIP(r), r.UserAgent()
+	reason := sessionAnomaly(s.Config.SessionBinding, session, ip, userAgent)
+	if reason == "" {
+		return true
+	}
+
+	database := s.DB.WithContext(r.Context())
+	action := "flagged"
+	notify := true
+	if s.Config.SessionAnomalyAction == "revoke" {
+		action = "revoked"
+		if err := database.DeleteUserSession(session.ID); err != nil {
+			log.Printf("session: failed to end session %d: %v", session.ID, err)
+		}
+	} else {
+		// Only the first suspicious request of a session is reported
+		first, err := database.FlagUserSession(session.ID)
+		if err != nil {
+			log.Printf("session: failed to flag session %d: %v", session.ID, err)
+		}
+		notify = first
+	}
+	log.Printf("session: %s session %d of %s: %s", action, session.ID, user.Username, reason)
+
+	if notify && s.Config.SessionAlertWebhookURL != "" {
+		alert := sessionAlert{
+			Event:          "session.suspicious",
+			Action:         action,
+			Reason:         reason,
+			UserID:         user.ID,
+			Username:       user.Username,
+			Email:          user.Email,
+			SessionID:      session.ID,
+			IPAddress:      ip,
+			UserAgent:      userAgent,
+			LoginIPAddress: stringValue(session.IPAddress),
+			LoginUserAgent: stringValue(session.UserAgent),
+			Time:           time.Now().UTC(),
+		}
+		go func() {
+			if err := sendSessionAlert(s.Config.SessionAlertWebhookURL, alert); err != nil {
+				log.Printf("session: alert for session %d failed: %v", alert.SessionID, err)
+			}
+		}()
+	}
+
+	return action != "revoked"
+}
+
+// sessionAnomaly describes how a request falls outside the bindings of its
+// session, or returns "" when it does not. Sessions without a recorded address
+// or User-Agent cannot be compared on that binding.
+func sessionAnomaly(bindings []string, session *db.UserSession, ip, userAgent string) string {
+	if slices.Contains(bindings, "network") && session.IPAddress != nil {
+		login, loginOK := sessionNetwork(*session.IPAddress)
+		if current, ok := sessionNetwork(ip); ok && loginOK && current != login {
+			return fmt.Sprintf("used from %s, outside the %s network it logged in from", ip, login)
+		}
+	}
+	if slices.Contains(bindings, "device") && session.UserAgent != nil && *session.UserAgent != "" {
+		if deviceFingerprint(userAgent) != deviceFingerprint(*session.UserAgent) {
+			return fmt.Sprintf("used from a different device (%q, logged in with %q)", userAgent, *session.UserAgent)
+		}
+	}
+	return ""
+}
+
+// sessionNetwork returns the coarse network an address belongs to. Addresses
+// may carry a prefix length, as PostgreSQL's inet type prints them.
+func sessionNetwork(address string) (netip.Prefix, bool) {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		addr = prefix.Addr()
+	}
+	addr = addr.Unmap()
+
+	bits := sessionNetworkBitsV6
+	if addr.Is4() {
+		bits = sessionNetworkBitsV4
+	}
+	prefix, err := addr.Prefix(bits)
+	return prefix, err == nil
+}
+
+// deviceFingerprint reduces a User-Agent to the client and platform it names
+func deviceFingerprint(userAgent string) string {
+	return strings.ToLower(strings.TrimSpace(uaVersion.ReplaceAllString(userAgent, "")))
+}
+
+// sendSessionAlert posts an alert to the webhook, which must answer 2xx
+func sendSessionAlert(url string, alert sessionAlert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionAlertTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rulestack/internal/db"
+)
+
+func TestSessionAnomaly(t *testing.T) {
+	loginIP, loginAgent := "198.51.100.7", "rfh/1.4.0 (linux; amd64)"
+	session := &db.UserSession{IPAddress: &loginIP, UserAgent: &loginAgent}
+	both := []string{"network", "device"}
+
+	tests := []struct {
+		name      string
+		bindings  []string
+		ip        string
+		userAgent string
+		anomalous bool
+	}{
+		{"same network", both, "198.51.22.10", loginAgent, false},
+		{"upgraded client", both, loginIP, "rfh/1.5.2 (linux; amd64)", false},
+		{"different network", both, "203.0.113.9", loginAgent, true},
+		{"different device", both, loginIP, "curl/8.4.0", true},
+		{"network not bound", []string{"device"}, "203.0.113.9", loginAgent, false},
+		{"binding off", nil, "203.0.113.9", "curl/8.4.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := sessionAnomaly(tt.bindings, session, tt.ip, tt.userAgent)
+			if (reason != "") != tt.anomalous {
+				t.Errorf("sessionAnomaly = %q, anomalous %v", reason, tt.anomalous)
+			}
+		})
+	}
+
+	// PostgreSQL prints inet values with a prefix length
+	pgIP := "198.51.100.7/32"
+	if reason := sessionAnomaly(both, &db.UserSession{IPAddress: &pgIP}, "198.51.3.3", ""); reason != "" {
+		t.Errorf("expected an inet address to match its network, got %q", reason)
+	}
+	// Sessions from before binding was recorded are left alone
+	if reason := sessionAnomaly(both, &db.UserSession{}, "203.0.113.9", "curl/8.4.0"); reason != "" {
+		t.Errorf("expected no anomaly without login details, got %q", reason)
+	}
+}
+
+func TestCheckSessionBinding(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	user, err := database.CreateUser(db.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "secret123", Role: db.RoleUser})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	loginIP, loginAgent := "198.51.100.7", "rfh/1.4.0"
+	newSession := func(tokenHash string) *db.UserSession {
+		session, err := database.CreateUserSession(user.ID, tokenHash, time.Now().Add(time.Hour), &loginAgent, &loginIP)
+		if err != nil {
+			t.Fatalf("CreateUserSession failed: %v", err)
+		}
+		return session
+	}
+
+	alerts := make(chan sessionAlert, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert sessionAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		alerts <- alert
+	}))
+	defer webhook.Close()
+
+	s := &Server{DB: database}
+	s.Config.SessionBinding = []string{"network"}
+	s.Config.SessionAnomalyAction = "flag"
+	s.Config.SessionAlertWebhookURL = webhook.URL
+
+	request := func() *http.Request {
+		r := httptest.NewRequest("GET", "/v1/auth/profile", nil)
+		r.RemoteAddr = "203.0.113.9:52100"
+		r.Header.Set("User-Agent", loginAgent)
+		return r
+	}
+	nextAlert := func() sessionAlert {
+		select {
+		case alert := <-alerts:
+			return alert
+		case <-time.After(5 * time.Second):
+			t.Fatal("no session alert was sent")
+			return sessionAlert{}
+		}
+	}
+
+	// Flagged sessions keep working and only the first suspicious use is reported
+	session := newSession("flagged")
+	for i := 0; i < 2; i++ {
+		if !s.checkSessionBinding(request(), user, session) {
+			t.Fatal("expected a flagged session to stay usable")
+		}
+	}
+	if alert := nextAlert(); alert.Action != "flagged" || alert.Email != "alice@example.com" || alert.IPAddress != "203.0.113.9" || alert.LoginIPAddress != loginIP {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+	if _, flagged, err := database.ValidateUserSession("flagged"); err != nil || flagged.FlaggedAt == nil {
+		t.Errorf("expected the session to be flagged: %+v, %v", flagged, err)
+	}
+
+	s.Config.SessionAnomalyAction = "revoke"
+	session = newSession("revoked")
+	if s.checkSessionBinding(request(), user, session) {
+		t.Fatal("expected the session to be ended")
+	}
+	if alert := nextAlert(); alert.Action != "revoked" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+	if _, _, err := database.ValidateUserSession("revoked"); err == nil {
+		t.Error("expected the revoked session to be deleted")
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("unexpected extra alert %+v", alert)
+	default:
+	}
+}
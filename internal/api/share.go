@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/db"
+)
+
+// Share links let a publisher hand one version to someone without a registry
+// account. The link names the version and an expiry, signed with the registry's
+// JWT secret, so the registry keeps no record of the links it gives out.
+
+// defaultShareDuration is how long a share link works when no duration is given
+const defaultShareDuration = 24 * time.Hour
+
+// maxShareDuration caps how long a share link can work
+const maxShareDuration = 30 * 24 * time.Hour
+
+// shareLink is the response describing a share link
+type shareLink struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Path      string    `json:"path"` // Relative to the registry URL
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// shareSignature signs a version and expiry (Unix seconds) for a share link
+func (s *Server) shareSignature(name, version string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte("share:"+s.Config.JWTSecret))
+	fmt.Fprintf(mac, "%s\n%s\n%d", name, version, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sharePackageVersionHandler creates a time-limited download link for a
+// published version
+func (s *Server) sharePackageVersionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
+
+	var req struct {
+		ExpiresIn int64 `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	duration := time.Duration(req.ExpiresIn) * time.Second
+	if req.ExpiresIn == 0 {
+		duration = defaultShareDuration
+	}
+	if duration < time.Minute || duration > maxShareDuration {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Share links last between 1 minute and %d days", int(maxShareDuration.Hours()/24)))
+		return
+	}
+
+	pkgVersion, err := s.DB.WithContext(r.Context()).GetPackageVersion(name, version)
+	if err != nil || pkgVersion.Status != db.VersionStatusPublished {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
+	// Only whoever published the version (or an admin) may share it
+	if !user.Role.HasPermission(db.PermissionAdmin) && (pkgVersion.PublishedBy == nil || *pkgVersion.PublishedBy != user.ID) {
+		writeError(w, http.StatusForbidden, "Only the publisher of this version can share it")
+		return
+	}
+
+	expiresAt := time.Now().Add(duration).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.shareSignature(name, version, expires))
+
+	writeJSON(w, http.StatusCreated, shareLink{
+		Name:      name,
+		Version:   version,
+		Path:      fmt.Sprintf("/v1/shared/%s/%s?%s", url.PathEscape(name), url.PathEscape(version), query.Encode()),
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// sharedDownloadHandler serves the archive named by a share link, without
+// authentication. The version must still be published: links never reach
+// versions held back by validation or approval.
+func (s *Server) sharedDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
+
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	signature := r.URL.Query().Get("signature")
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.shareSignature(name, version, expires))) {
+		writeError(w, http.StatusForbidden, "Invalid share link")
+		return
+	}
+	if time.Now().Unix() > expires {
+		writeError(w, http.StatusGone, "Share link has expired")
+		return
+	}
+
+	pkgVersion, err := s.DB.WithContext(r.Context()).GetPackageVersion(name, version)
+	if err != nil || pkgVersion.Status != db.VersionStatusPublished || pkgVersion.BlobPath == nil {
+		writeError(w, http.StatusNotFound, "Package version not found")
+		return
+	}
+
+	s.serveBlob(w, r, *pkgVersion.BlobPath, name+"-"+version)
+}
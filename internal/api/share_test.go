@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+)
+
+func TestSharedDownloadSignature(t *testing.T) {
+	s := &Server{Config: config.Config{JWTSecret: "secret"}}
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name         string
+		version      string
+		expires      int64
+		signature    string
+		expectStatus int
+	}{
+		{
+			name:         "signature for another version",
+			version:      "2.0.0",
+			expires:      future,
+			signature:    s.shareSignature("rules", "1.0.0", future),
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name:         "extended expiry",
+			version:      "1.0.0",
+			expires:      future + 3600,
+			signature:    s.shareSignature("rules", "1.0.0", future),
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name:         "signed with another secret",
+			version:      "1.0.0",
+			expires:      future,
+			signature:    (&Server{Config: config.Config{JWTSecret: "other"}}).shareSignature("rules", "1.0.0", future),
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name:         "expired",
+			version:      "1.0.0",
+			expires:      past,
+			signature:    s.shareSignature("rules", "1.0.0", past),
+			expectStatus: http.StatusGone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/shared/rules/"+tt.version+"?expires="+strconv.FormatInt(tt.expires, 10)+"&signature="+tt.signature, nil)
+			r = mux.SetURLVars(r, map[string]string{"name": "rules", "version": tt.version})
+			w := httptest.NewRecorder()
+
+			s.sharedDownloadHandler(w, r)
+			if w.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestShareRequiresPublishedVersion(t *testing.T) {
+	database, err := db.Connect(db.SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	user, err := database.CreateUser(db.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "secret123", Role: db.RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	pkg, err := database.GetOrCreatePackage("rules")
+	if err != nil {
+		t.Fatalf("GetOrCreatePackage failed: %v", err)
+	}
+	blobPath := filepath.Join(t.TempDir(), "rules-1.0.0.tgz")
+	if err := os.WriteFile(blobPath, []byte("archive"), 0644); err != nil {
+		t.Fatalf("Failed to write blob: %v", err)
+	}
+
+	s := &Server{DB: database, Config: config.Config{JWTSecret: "secret"}}
+	future := time.Now().Add(time.Hour).Unix()
+
+	for _, status := range []string{db.VersionStatusPending, db.VersionStatusAwaitingApproval, db.VersionStatusPublished} {
+		t.Run(status, func(t *testing.T) {
+			version := "1.0.0-" + strings.ReplaceAll(status, "_", "-")
+			if _, err := database.CreatePackageVersion(db.PackageVersion{PackageID: pkg.ID, Version: version, BlobPath: &blobPath, Status: status, PublishedBy: &user.ID}); err != nil {
+				t.Fatalf("CreatePackageVersion failed: %v", err)
+			}
+			vars := map[string]string{"name": "rules", "version": version}
+			expectShare, expectDownload := http.StatusNotFound, http.StatusNotFound
+			if status == db.VersionStatusPublished {
+				expectShare, expectDownload = http.StatusCreated, http.StatusOK
+			}
+
+			r := httptest.NewRequest("POST", "/v1/packages/rules/versions/"+version+"/share", nil)
+			r = mux.SetURLVars(r.WithContext(context.WithValue(r.Context(), userContextKey, user)), vars)
+			w := httptest.NewRecorder()
+			s.sharePackageVersionHandler(w, r)
+			if w.Code != expectShare {
+				t.Errorf("share: expected status %d, got %d: %s", expectShare, w.Code, w.Body.String())
+			}
+
+			// A link signed before the version was held back does not reach it either
+			r = httptest.NewRequest("GET", "/v1/shared/rules/"+version+"?expires="+strconv.FormatInt(future, 10)+"&signature="+s.shareSignature("rules", version, future), nil)
+			r = mux.SetURLVars(r, vars)
+			w = httptest.NewRecorder()
+			s.sharedDownloadHandler(w, r)
+			if w.Code != expectDownload {
+				t.Errorf("download: expected status %d, got %d: %s", expectDownload, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/integrity"
+)
+
+// maxManifestSize bounds the manifest part of a publish upload
+const maxManifestSize = 1 << 20
+
+// orphanGracePeriod keeps files young enough to belong to an in-flight publish
+const orphanGracePeriod = time.Hour
+
+// uploadError is a publish upload rejected with a specific HTTP status
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string {
+	return e.message
+}
+
+// upload is a publish request whose archive has been streamed to a temp file
+type upload struct {
+	Manifest []byte
+	TempPath string // Archive on disk; the caller renames or removes it
+	SHA256   string
+	Size     int64
+
+	Integrity integrity.Integrity // Digests of the archive in every supported algorithm
+	Expected  string              // Digests the client computed before sending it, if any
+}
+
+// Remove deletes the temp archive if it has not been moved into storage
+func (u *upload) Remove() {
+	os.Remove(u.TempPath)
+}
+
+// receiveUpload streams a multipart publish request without buffering the archive
+// in memory. The archive is written to a temp file in tempDir and hashed on the
+// way; uploads larger than maxArchiveSize are rejected as soon as they exceed it.
+func receiveUpload(w http.ResponseWriter, r *http.Request, tempDir string, maxArchiveSize int64) (*upload, error) {
+	// Headroom for the manifest and multipart framing around the archive
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize+2*maxManifestSize)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, &uploadError{http.StatusBadRequest, "Failed to parse form"}
+	}
+
+	result := &upload{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.Remove()
+			return nil, readError(err, maxArchiveSize)
+		}
+
+		switch part.FormName() {
+		case "manifest":
+			data, err := io.ReadAll(io.LimitReader(part, maxManifestSize+1))
+			if err != nil {
+				result.Remove()
+				return nil, readError(err, maxArchiveSize)
+			}
+			if len(data) > maxManifestSize {
+				result.Remove()
+				return nil, &uploadError{http.StatusRequestEntityTooLarge, "Manifest too large"}
+			}
+			result.Manifest = data
+		case "integrity":
+			data, err := io.ReadAll(io.LimitReader(part, maxManifestSize))
+			if err != nil {
+				result.Remove()
+				return nil, readError(err, maxArchiveSize)
+			}
+			result.Expected = string(data)
+		case "archive":
+			if result.TempPath != "" {
+				result.Remove()
+				return nil, &uploadError{http.StatusBadRequest, "Only one archive may be uploaded"}
+			}
+			if err := result.writeArchive(part, tempDir, maxArchiveSize); err != nil {
+				result.Remove()
+				return nil, err
+			}
+		}
+		part.Close()
+	}
+
+	if result.Manifest == nil {
+		result.Remove()
+		return nil, &uploadError{http.StatusBadRequest, "Manifest file required"}
+	}
+	if result.TempPath == "" {
+		return nil, &uploadError{http.StatusBadRequest, "Archive file required"}
+	}
+
+	return result, nil
+}
+
+// writeArchive copies the archive part to a temp file, hashing it as it goes
+func (u *upload) writeArchive(part io.Reader, tempDir string, maxArchiveSize int64) error {
+	file, err := os.CreateTemp(tempDir, ".upload-*.tgz")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	u.TempPath = file.Name()
+	defer file.Close()
+
+	hasher := integrity.NewHasher()
+	size, err := io.Copy(io.MultiWriter(file, hasher), io.LimitReader(part, maxArchiveSize+1))
+	if err != nil {
+		return readError(err, maxArchiveSize)
+	}
+	if size > maxArchiveSize {
+		return archiveTooLarge(maxArchiveSize)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	u.SHA256 = hasher.SHA256()
+	u.Integrity = hasher.Integrity()
+	u.Size = size
+	return nil
+}
+
+// verify checks the archive against the digests the client sent with it.
+// Digests in algorithms the registry does not know are ignored, and clients that
+// predate integrity strings send none.
+func (u *upload) verify() error {
+	expected, err := integrity.Parse(u.Expected)
+	if err != nil {
+		return &uploadError{http.StatusBadRequest, err.Error()}
+	}
+	if len(expected) == 0 {
+		return nil
+	}
+	if err := expected.Check(u.Integrity); err != nil {
+		return &uploadError{http.StatusUnprocessableEntity, fmt.Sprintf("Archive does not match the integrity sent with it (%v); upload it again", err)}
+	}
+	return nil
+}
+
+// readError maps a failed body read to the response the client should see
+func readError(err error, maxArchiveSize int64) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return archiveTooLarge(maxArchiveSize)
+	}
+	return &uploadError{http.StatusBadRequest, "Failed to read upload"}
+}
+
+func archiveTooLarge(maxArchiveSize int64) error {
+	return &uploadError{http.StatusRequestEntityTooLarge, fmt.Sprintf("Archive exceeds the maximum size of %d bytes", maxArchiveSize)}
+}
+
+// blobStore is the subset of the database the orphan sweep needs
+type blobStore interface {
+	ListBlobPaths() ([]string, error)
+}
+
+// isArchiveName reports whether a file is named like a stored archive
+func isArchiveName(name string) bool {
+	for _, format := range compression.Formats {
+		if strings.HasSuffix(name, compression.Extension(format)) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadID returns the upload a temp upload file belongs to, as in
+// .upload-<id>.tgz and .upload-<id>.json
+func uploadID(name string) string {
+	id, _, _ := strings.Cut(strings.TrimPrefix(name, ".upload-"), ".")
+	return id
+}
+
+// removeOrphanedBlobs deletes leftovers of publishes that died part way: temp
+// uploads, and archives moved into storage whose transaction never committed.
+// Files younger than orphanGracePeriod are left for publishes still in flight;
+// the data and session files of an upload age together, by the newest of them,
+// so a session is kept as long as its upload is receiving data.
+func removeOrphanedBlobs(store blobStore, storagePath string, now time.Time) int {
+	entries, err := os.ReadDir(storagePath)
+	if err != nil {
+		return 0
+	}
+
+	uploadModTimes := make(map[string]time.Time)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".upload-") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			id := uploadID(entry.Name())
+			if info.ModTime().After(uploadModTimes[id]) {
+				uploadModTimes[id] = info.ModTime()
+			}
+		}
+	}
+
+	paths, err := store.ListBlobPaths()
+	if err != nil {
+		log.Printf("storage: failed to list archives: %v", err)
+		return 0
+	}
+	referenced := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			referenced[abs] = true
+		}
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		// Archives in any format, and the data and session files of uploads
+		if entry.IsDir() || (!isArchiveName(name) && !strings.HasPrefix(name, ".upload-")) {
+			continue
+		}
+
+		path, err := filepath.Abs(filepath.Join(storagePath, name))
+		if err != nil || (!strings.HasPrefix(name, ".upload-") && referenced[path]) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		modTime := info.ModTime()
+		if strings.HasPrefix(name, ".upload-") {
+			modTime = uploadModTimes[uploadID(name)]
+		}
+		if now.Sub(modTime) < orphanGracePeriod {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			log.Printf("storage: failed to remove orphaned %s: %v", name, err)
+			continue
+		}
+		removed++
+		log.Printf("storage: removed orphaned %s", name)
+	}
+
+	return removed
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rulestack/internal/integrity"
+)
+
+func TestReceiveUpload(t *testing.T) {
+	archive := bytes.Repeat([]byte("a"), 1024)
+	hasher := integrity.NewHasher()
+	hasher.Write(archive)
+	archiveIntegrity := hasher.Integrity()
+
+	newRequest := func(fields map[string][]byte) *http.Request {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for _, name := range []string{"manifest", "integrity", "archive"} {
+			if data, ok := fields[name]; ok {
+				part, _ := writer.CreateFormFile(name, name)
+				part.Write(data)
+			}
+		}
+		writer.Close()
+
+		r := httptest.NewRequest("POST", "/v1/packages", &body)
+		r.Header.Set("Content-Type", writer.FormDataContentType())
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		fields         map[string][]byte
+		maxArchiveSize int64
+		expectStatus   int
+	}{
+		{
+			name:           "streams archive to disk",
+			fields:         map[string][]byte{"manifest": []byte(`{"name":"rules"}`), "archive": archive},
+			maxArchiveSize: 2048,
+		},
+		{
+			name:           "archive at the limit is accepted",
+			fields:         map[string][]byte{"manifest": []byte(`{}`), "archive": archive},
+			maxArchiveSize: int64(len(archive)),
+		},
+		{
+			name:           "oversized archive",
+			fields:         map[string][]byte{"manifest": []byte(`{}`), "archive": archive},
+			maxArchiveSize: 512,
+			expectStatus:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "integrity sent by the client",
+			fields:         map[string][]byte{"manifest": []byte(`{}`), "integrity": []byte(archiveIntegrity.String()), "archive": archive},
+			maxArchiveSize: 2048,
+		},
+		{
+			name:           "missing manifest",
+			fields:         map[string][]byte{"archive": archive},
+			maxArchiveSize: 2048,
+			expectStatus:   http.StatusBadRequest,
+		},
+		{
+			name:           "missing archive",
+			fields:         map[string][]byte{"manifest": []byte(`{}`)},
+			maxArchiveSize: 2048,
+			expectStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			upload, err := receiveUpload(httptest.NewRecorder(), newRequest(tt.fields), dir, tt.maxArchiveSize)
+
+			if tt.expectStatus != 0 {
+				var uploadErr *uploadError
+				if !errors.As(err, &uploadErr) || uploadErr.status != tt.expectStatus {
+					t.Fatalf("expected upload error with status %d, got %v", tt.expectStatus, err)
+				}
+				if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+					t.Errorf("expected rejected upload to leave no temp files, found %d", len(entries))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("receiveUpload failed: %v", err)
+			}
+			defer upload.Remove()
+
+			if upload.Size != int64(len(archive)) {
+				t.Errorf("expected size %d, got %d", len(archive), upload.Size)
+			}
+			if expected := fmt.Sprintf("%x", sha256.Sum256(archive)); upload.SHA256 != expected {
+				t.Errorf("expected sha256 %s, got %s", expected, upload.SHA256)
+			}
+			if upload.Integrity.String() != archiveIntegrity.String() || upload.Expected != string(tt.fields["integrity"]) {
+				t.Errorf("expected digests %s (sent %q), got %s (sent %q)", archiveIntegrity, tt.fields["integrity"], upload.Integrity, upload.Expected)
+			}
+			if err := upload.verify(); err != nil {
+				t.Errorf("expected the archive to match the integrity sent with it, got %v", err)
+			}
+			if data, err := os.ReadFile(upload.TempPath); err != nil || !bytes.Equal(data, archive) {
+				t.Errorf("expected temp file to hold the archive, got %d bytes (%v)", len(data), err)
+			}
+			if !bytes.Equal(upload.Manifest, tt.fields["manifest"]) {
+				t.Errorf("expected manifest %q, got %q", tt.fields["manifest"], upload.Manifest)
+			}
+		})
+	}
+}
+
+func TestUploadVerify(t *testing.T) {
+	hasher := integrity.NewHasher()
+	hasher.Write([]byte("archive"))
+	digests := hasher.Integrity()
+	sha512Digest, _ := digests.Get(integrity.SHA512)
+
+	other := integrity.NewHasher(integrity.BLAKE3)
+	other.Write([]byte("something else"))
+
+	tests := []struct {
+		name         string
+		expected     string
+		expectStatus int
+	}{
+		{name: "no integrity from older clients", expected: ""},
+		{name: "matching digest", expected: sha512Digest.String()},
+		{name: "unknown algorithms are ignored", expected: "sha3-abc " + sha512Digest.String()},
+		{name: "only unknown algorithms", expected: "sha3-abc"},
+		{name: "mismatch", expected: other.Integrity().String(), expectStatus: http.StatusUnprocessableEntity},
+		{name: "malformed", expected: "sha512-short", expectStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&upload{Integrity: digests, Expected: tt.expected}).verify()
+			if tt.expectStatus == 0 {
+				if err != nil {
+					t.Errorf("expected the upload to pass, got %v", err)
+				}
+				return
+			}
+			var uploadErr *uploadError
+			if !errors.As(err, &uploadErr) || uploadErr.status != tt.expectStatus {
+				t.Errorf("expected upload error with status %d, got %v", tt.expectStatus, err)
+			}
+		})
+	}
+}
+
+type fakeBlobStore []string
+
+func (f fakeBlobStore) ListBlobPaths() ([]string, error) {
+	return f, nil
+}
+
+func TestRemoveOrphanedBlobs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * orphanGracePeriod)
+
+	write := func(name string, modTime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("archive"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		os.Chtimes(path, modTime, modTime)
+		return path
+	}
+
+	referenced := write("rules-1.0.0.tgz", old)
+	orphan := write("rules-1.1.0.tgz", old)
+	zstdOrphan := write("rules-1.2.0.tar.zst", old)
+	staleUpload := write(".upload-123.tgz", old)
+	inFlight := write(".upload-456.tgz", time.Now())
+	// A long-running chunked upload whose session was written when it started
+	activeSession := write(".upload-789.json", old)
+	activeData := write(".upload-789.tgz", time.Now())
+	other := write("README", old)
+
+	removed := removeOrphanedBlobs(fakeBlobStore{referenced}, dir, time.Now())
+	if removed != 3 {
+		t.Errorf("expected 3 orphans removed, got %d", removed)
+	}
+
+	for path, kept := range map[string]bool{
+		referenced:    true,
+		orphan:        false,
+		zstdOrphan:    false,
+		staleUpload:   false,
+		inFlight:      true,
+		activeSession: true,
+		activeData:    true,
+		other:         true,
+	} {
+		_, err := os.Stat(path)
+		if kept && err != nil {
+			t.Errorf("expected %s to be kept", filepath.Base(path))
+		}
+		if !kept && !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rulestack/internal/client"
+	"rulestack/internal/compression"
+	"rulestack/internal/db"
+	"rulestack/internal/integrity"
+)
+
+// upstreamRegistry is the part of a registry client pull-through mode uses
+type upstreamRegistry interface {
+	GetPackageVersion(ctx context.Context, name, version string) (*client.PackageVersion, error)
+	DownloadBlob(ctx context.Context, sha256, destPath string) error
+	SearchPackages(ctx context.Context, opts client.SearchOptions) ([]client.Package, error)
+}
+
+// newUpstream returns the upstream registry client, or nil when pull-through is off
+func newUpstream(url, token string) upstreamRegistry {
+	if url == "" {
+		return nil
+	}
+	return client.NewHTTPClient(url, token, false)
+}
+
+// upstreamStore is the subset of the database pulling a version through needs
+type upstreamStore interface {
+	PublishPackageVersion(name string, version db.PackageVersion, checks []string, commitBlob func() error) (*db.PackageVersion, error)
+	GetPackageVersion(name, version string) (*db.PackageVersion, error)
+}
+
+// pullThrough fetches a version missing from this registry from upstream, verifies
+// its archive and stores both as a published version, so later requests are served
+// locally. Locally published versions always win: this only runs on a miss.
+func (s *Server) pullThrough(ctx context.Context, store upstreamStore, name, version string) (*db.PackageVersion, error) {
+	remote, err := s.Upstream.GetPackageVersion(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if remote.SHA256 == "" {
+		return nil, fmt.Errorf("upstream version %s@%s has no sha256", name, version)
+	}
+	expected, err := integrity.Expect(remote.Integrity, remote.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("upstream version %s@%s: %w", name, version, err)
+	}
+
+	file, err := os.CreateTemp(s.Config.StoragePath, ".upload-*.tgz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := file.Name()
+	file.Close()
+	defer os.Remove(tempPath)
+
+	if err := s.Upstream.DownloadBlob(ctx, remote.SHA256, tempPath); err != nil {
+		return nil, fmt.Errorf("failed to download from upstream: %w", err)
+	}
+
+	size, digests, err := verifyUpstreamArchive(tempPath, expected, s.Config.MaxArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+	integrityString := digests.String()
+
+	format, _ := compression.DetectFile(tempPath)
+	archivePath := filepath.Join(s.Config.StoragePath, fmt.Sprintf("%s-%s%s", strings.ReplaceAll(name, "/", "-"), version, compression.Extension(format)))
+	upstream := s.Config.UpstreamURL
+	sizeBytes := int(size)
+	pv := db.PackageVersion{
+		Version:      version,
+		Description:  &remote.Description,
+		SHA256:       &remote.SHA256,
+		Integrity:    &integrityString,
+		SizeBytes:    &sizeBytes,
+		BlobPath:     &archivePath,
+		Status:       db.VersionStatusPublished,
+		Upstream:     &upstream,
+		Dependencies: remote.Dependencies,
+	}
+	if remote.License != "" {
+		pv.License = &remote.License
+	}
+
+	blobMoved := false
+	_, err = store.PublishPackageVersion(name, pv, nil, func() error {
+		if err := os.Rename(tempPath, archivePath); err != nil {
+			return err
+		}
+		blobMoved = true
+		return nil
+	})
+	if err != nil {
+		if blobMoved {
+			os.Remove(archivePath)
+		}
+		// A concurrent request cached it first
+		if errors.Is(err, db.ErrVersionExists) {
+			return store.GetPackageVersion(name, version)
+		}
+		return nil, err
+	}
+
+	log.Printf("upstream: cached %s@%s from %s", name, version, upstream)
+	s.Cache.Invalidate()
+
+	return store.GetPackageVersion(name, version)
+}
+
+// verifyUpstreamArchive checks a downloaded archive against the digests upstream
+// advertised and the local size limit, and that it is safe to extract. It
+// returns the archive's size and its digests in every supported algorithm.
+func verifyUpstreamArchive(path string, expected integrity.Integrity, maxArchiveSize int64) (int64, integrity.Integrity, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read upstream archive: %w", err)
+	}
+	defer file.Close()
+
+	hasher := integrity.NewHasher()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read upstream archive: %w", err)
+	}
+	if size > maxArchiveSize {
+		return 0, nil, fmt.Errorf("upstream archive exceeds the maximum size of %d bytes", maxArchiveSize)
+	}
+	digests := hasher.Integrity()
+	if err := expected.Check(digests); err != nil {
+		return 0, nil, fmt.Errorf("upstream archive: %w", err)
+	}
+	if err := validateArchive(path); err != nil {
+		return 0, nil, fmt.Errorf("invalid upstream archive: %w", err)
+	}
+
+	return size, digests, nil
+}
+
+// verifiedOrg returns an upstream package's verified organization as stored
+// locally, nil when it has none
+func verifiedOrg(org string) *string {
+	if org == "" {
+		return nil
+	}
+	return &org
+}
+
+// mergeUpstreamResults appends upstream search results for packages not published
+// locally, up to limit results in total
+func mergeUpstreamResults(local []db.SearchResult, upstream []client.Package, limit int) []db.SearchResult {
+	seen := make(map[string]bool, len(local))
+	for _, result := range local {
+		seen[result.Name] = true
+	}
+
+	merged := local
+	for _, pkg := range upstream {
+		if limit > 0 && len(merged) >= limit {
+			break
+		}
+		if seen[pkg.Name] {
+			continue
+		}
+		seen[pkg.Name] = true
+
+		description := pkg.Description
+		merged = append(merged, db.SearchResult{
+			Name:         pkg.Name,
+			Version:      pkg.Latest,
+			Description:  &description,
+			Tags:         pkg.Tags,
+			Dependencies: pkg.Dependencies,
+			Official:     pkg.Official,
+			VerifiedOrg:  verifiedOrg(pkg.VerifiedOrg),
+			CreatedAt:    pkg.UpdatedAt,
+		})
+	}
+
+	return merged
+}
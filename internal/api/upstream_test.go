@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+	"rulestack/internal/integrity"
+)
+
+type fakeUpstream struct {
+	versions map[string]*client.PackageVersion
+	blobs    map[string]string // sha256 to archive path
+}
+
+func (f *fakeUpstream) GetPackageVersion(ctx context.Context, name, version string) (*client.PackageVersion, error) {
+	pv, ok := f.versions[name+"@"+version]
+	if !ok {
+		return nil, client.NewRegistryError(client.ErrVersionNotFound, name+"@"+version)
+	}
+	return pv, nil
+}
+
+func (f *fakeUpstream) DownloadBlob(ctx context.Context, sha256, destPath string) error {
+	data, err := os.ReadFile(f.blobs[sha256])
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(destPath, data, 0644)
+}
+
+func (f *fakeUpstream) SearchPackages(ctx context.Context, opts client.SearchOptions) ([]client.Package, error) {
+	return nil, nil
+}
+
+type fakeUpstreamStore struct {
+	published map[string]db.PackageVersion
+}
+
+func (f *fakeUpstreamStore) PublishPackageVersion(name string, version db.PackageVersion, checks []string, commitBlob func() error) (*db.PackageVersion, error) {
+	if _, exists := f.published[name+"@"+version.Version]; exists {
+		return nil, db.ErrVersionExists
+	}
+	if err := commitBlob(); err != nil {
+		return nil, err
+	}
+	f.published[name+"@"+version.Version] = version
+	return &version, nil
+}
+
+func (f *fakeUpstreamStore) GetPackageVersion(name, version string) (*db.PackageVersion, error) {
+	pv, ok := f.published[name+"@"+version]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return &pv, nil
+}
+
+func TestPullThrough(t *testing.T) {
+	archive := buildTestArchive(t, map[string]string{"rules/secure.md": "# Secure coding\n"})
+	data, _ := os.ReadFile(archive)
+	sha := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	upstream := &fakeUpstream{
+		versions: map[string]*client.PackageVersion{
+			"security-rules@1.0.0": {Name: "security-rules", Version: "1.0.0", SHA256: sha, Description: "Secure coding"},
+			"tampered@1.0.0":       {Name: "tampered", Version: "1.0.0", SHA256: strings.Repeat("0", 64)},
+		},
+		blobs: map[string]string{sha: archive, strings.Repeat("0", 64): archive},
+	}
+
+	storagePath := t.TempDir()
+	s := &Server{
+		Config:   config.Config{StoragePath: storagePath, MaxArchiveSize: 1 << 20, UpstreamURL: "https://public.example.com"},
+		Upstream: upstream,
+	}
+	store := &fakeUpstreamStore{published: map[string]db.PackageVersion{}}
+
+	pv, err := s.pullThrough(context.Background(), store, "security-rules", "1.0.0")
+	if err != nil {
+		t.Fatalf("pullThrough failed: %v", err)
+	}
+	if pv.Status != db.VersionStatusPublished || *pv.SHA256 != sha || *pv.Upstream != "https://public.example.com" {
+		t.Errorf("expected a published copy marked with its upstream, got %+v", pv)
+	}
+	if cached, err := os.ReadFile(*pv.BlobPath); err != nil || string(cached) != string(data) {
+		t.Errorf("expected the archive to be cached in storage (%v)", err)
+	}
+
+	// A second pull of the same version is served from the store
+	if _, err := s.pullThrough(context.Background(), store, "security-rules", "1.0.0"); err != nil {
+		t.Errorf("expected an already cached version to be returned, got %v", err)
+	}
+
+	if pv.Integrity == nil || !strings.Contains(*pv.Integrity, "sha512-") {
+		t.Errorf("expected the cached copy to record its digests, got %v", pv.Integrity)
+	}
+
+	if _, err := s.pullThrough(context.Background(), store, "tampered", "1.0.0"); !errors.Is(err, integrity.ErrMismatch) {
+		t.Errorf("expected a hash mismatch to be rejected, got %v", err)
+	}
+	if _, err := s.pullThrough(context.Background(), store, "missing", "1.0.0"); err == nil {
+		t.Error("expected a version missing upstream to fail")
+	}
+
+	if entries, _ := filepath.Glob(filepath.Join(storagePath, ".upload-*")); len(entries) != 0 {
+		t.Errorf("expected no temp files left behind, found %v", entries)
+	}
+}
+
+func TestMergeUpstreamResults(t *testing.T) {
+	local := []db.SearchResult{{Name: "security-rules", Version: "2.0.0"}, {Name: "security-rules", Version: "1.0.0"}}
+	upstream := []client.Package{
+		{Name: "security-rules", Latest: "9.0.0"},
+		{Name: "logging-rules", Latest: "1.0.0"},
+		{Name: "api-rules", Latest: "0.1.0"},
+	}
+
+	merged := mergeUpstreamResults(local, upstream, 3)
+	if len(merged) != 3 {
+		t.Fatalf("expected results capped at the limit, got %d", len(merged))
+	}
+	if merged[0].Version != "2.0.0" || merged[2].Name != "logging-rules" {
+		t.Errorf("expected local results first and upstream copies of local packages skipped, got %+v", merged)
+	}
+}
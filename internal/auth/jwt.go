@@ -93,8 +93,5 @@ func (j *JWTManager) GetTokenHash(tokenString string) string {
 	return j.hashToken(tokenString)
 }
 
-// DefaultTokenDuration is the default token expiration time
-const DefaultTokenDuration = 24 * time.Hour
-
-// DevelopmentTokenDuration is used during development (effectively no expiration)
-const DevelopmentTokenDuration = 365 * 24 * time.Hour // 1 year
+// DefaultTokenDuration is the token expiration time when none is configured
+const DefaultTokenDuration = 30 * 24 * time.Hour
@@ -0,0 +1,248 @@
+// Package blobcache keeps downloaded package archives under ~/.rfh/cache/blobs,
+// keyed by SHA256, and records which packages are used most. Installs reuse a
+// cached archive instead of downloading it again, and 'rfh cache warm' fetches
+// the archives of frequently used packages ahead of time.
+package blobcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"rulestack/internal/config"
+	"rulestack/internal/integrity"
+)
+
+// Downloader fetches a blob from a registry
+type Downloader interface {
+	DownloadBlob(ctx context.Context, sha256, destPath string) error
+}
+
+// Use records how often a package has been installed from a registry
+type Use struct {
+	Registry string    `json:"registry"`
+	Name     string    `json:"name"`
+	Version  string    `json:"version"` // Last version installed
+	SHA256   string    `json:"sha256"`
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// usageMu serializes updates of the usage file within the process
+var usageMu sync.Mutex
+
+// Dir returns the directory blobs are cached in
+func Dir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", "blobs"), nil
+}
+
+// Has reports whether the blob is cached
+func Has(sha256 string) bool {
+	path, err := blobPath(sha256)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Fetch copies a blob to destPath, downloading it into the cache first unless
+// it is already there. Blobs are checked against their SHA256 before they are
+// cached, and a cached blob that no longer matches is downloaded again.
+func Fetch(ctx context.Context, d Downloader, sha256, destPath string) error {
+	path, err := blobPath(sha256)
+	if err != nil {
+		return err
+	}
+
+	if checkBlob(path, sha256) == nil {
+		return copyFile(path, destPath)
+	}
+
+	if err := Prefetch(ctx, d, sha256); err != nil {
+		return err
+	}
+	return copyFile(path, destPath)
+}
+
+// Prefetch downloads a blob into the cache unless it is already there
+func Prefetch(ctx context.Context, d Downloader, sha256 string) error {
+	path, err := blobPath(sha256)
+	if err != nil {
+		return err
+	}
+	if checkBlob(path, sha256) == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob cache: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	defer os.Remove(tmp)
+
+	if err := d.DownloadBlob(ctx, sha256, tmp); err != nil {
+		return err
+	}
+	if err := checkBlob(tmp, sha256); err != nil {
+		return fmt.Errorf("downloaded blob failed its integrity check: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to cache blob: %w", err)
+	}
+	return nil
+}
+
+// RecordUse counts an install of a package version from a registry
+func RecordUse(registry, name, version, sha256 string) error {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	uses, err := loadUsage()
+	if err != nil {
+		return err
+	}
+
+	key := registry + "/" + name
+	use := uses[key]
+	use.Registry = registry
+	use.Name = name
+	use.Version = version
+	use.SHA256 = sha256
+	use.Count++
+	use.LastUsed = time.Now().UTC()
+	uses[key] = use
+
+	return saveUsage(uses)
+}
+
+// Frequent returns up to n of the packages installed from a registry, most
+// used first. n <= 0 returns them all.
+func Frequent(registry string, n int) ([]Use, error) {
+	usageMu.Lock()
+	uses, err := loadUsage()
+	usageMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var frequent []Use
+	for _, use := range uses {
+		if use.Registry == registry {
+			frequent = append(frequent, use)
+		}
+	}
+	sort.Slice(frequent, func(i, j int) bool {
+		if frequent[i].Count != frequent[j].Count {
+			return frequent[i].Count > frequent[j].Count
+		}
+		return frequent[i].LastUsed.After(frequent[j].LastUsed)
+	})
+
+	if n > 0 && len(frequent) > n {
+		frequent = frequent[:n]
+	}
+	return frequent, nil
+}
+
+func blobPath(sha256 string) (string, error) {
+	if len(sha256) != 64 {
+		return "", fmt.Errorf("invalid blob sha256 %q", sha256)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sha256[:2], sha256+".tgz"), nil
+}
+
+func checkBlob(path, sha256 string) error {
+	expected, err := integrity.Expect("", sha256)
+	if err != nil {
+		return err
+	}
+	return integrity.CheckFile(path, expected)
+}
+
+func usagePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.json"), nil
+}
+
+func loadUsage() (map[string]Use, error) {
+	path, err := usagePath()
+	if err != nil {
+		return nil, err
+	}
+
+	uses := make(map[string]Use)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uses, nil
+		}
+		return nil, fmt.Errorf("failed to read blob usage: %w", err)
+	}
+	// A corrupt usage file only loses the counts
+	if json.Unmarshal(data, &uses) != nil {
+		return make(map[string]Use), nil
+	}
+	return uses, nil
+}
+
+func saveUsage(uses map[string]Use) error {
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob cache: %w", err)
+	}
+
+	data, err := json.MarshalIndent(uses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode blob usage: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write blob usage: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write blob usage: %w", err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open cached blob: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy cached blob: %w", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+package blobcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeDownloader serves one blob and counts downloads
+type fakeDownloader struct {
+	content   []byte
+	downloads int
+}
+
+func (f *fakeDownloader) DownloadBlob(ctx context.Context, sha256, destPath string) error {
+	f.downloads++
+	return os.WriteFile(destPath, f.content, 0644)
+}
+
+func TestFetch(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+
+	content := []byte("archive")
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+	downloader := &fakeDownloader{content: content}
+	dest := filepath.Join(t.TempDir(), "out.tgz")
+
+	for i := 0; i < 2; i++ {
+		if err := Fetch(context.Background(), downloader, sha, dest); err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+	}
+	if downloader.downloads != 1 {
+		t.Errorf("expected one download, got %d", downloader.downloads)
+	}
+
+	// A damaged cached blob is downloaded again
+	path, _ := blobPath(sha)
+	if err := os.WriteFile(path, []byte("damaged"), 0644); err != nil {
+		t.Fatalf("failed to damage blob: %v", err)
+	}
+	if err := Fetch(context.Background(), downloader, sha, dest); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "archive" || downloader.downloads != 2 {
+		t.Errorf("expected the blob to be downloaded again, got %q after %d downloads", data, downloader.downloads)
+	}
+
+	// A registry serving the wrong content is not cached
+	wrong := &fakeDownloader{content: []byte("tampered")}
+	other := sha256.Sum256([]byte("other"))
+	if err := Prefetch(context.Background(), wrong, hex.EncodeToString(other[:])); err == nil {
+		t.Error("expected a blob that does not match its sha256 to be rejected")
+	}
+	if Has(hex.EncodeToString(other[:])) {
+		t.Error("expected the rejected blob not to be cached")
+	}
+}
+
+func TestFrequent(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+
+	for _, use := range []struct{ registry, name string }{
+		{"corp", "logging-rules"},
+		{"corp", "security-rules"},
+		{"corp", "security-rules"},
+		{"public", "security-rules"},
+	} {
+		if err := RecordUse(use.registry, use.name, "1.0.0", "sha"); err != nil {
+			t.Fatalf("RecordUse failed: %v", err)
+		}
+	}
+
+	frequent, err := Frequent("corp", 0)
+	if err != nil {
+		t.Fatalf("Frequent failed: %v", err)
+	}
+	if len(frequent) != 2 || frequent[0].Name != "security-rules" || frequent[0].Count != 2 || frequent[1].Name != "logging-rules" {
+		t.Errorf("unexpected frequent packages %+v", frequent)
+	}
+
+	if top, _ := Frequent("corp", 1); len(top) != 1 || top[0].Name != "security-rules" {
+		t.Errorf("expected only the most used package, got %+v", top)
+	}
+}
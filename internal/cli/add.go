@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"bufio"
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,10 +9,11 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"rulestack/internal/client"
-	"rulestack/internal/config"
 	"rulestack/internal/manifest"
-	"rulestack/internal/pkg"
+	"rulestack/internal/output"
+	"rulestack/internal/tempdir"
+	"rulestack/internal/tracing"
+	"rulestack/internal/version"
 )
 
 // addCmd represents the add command
@@ -23,14 +22,66 @@ var addCmd = &cobra.Command{
 	Short: "Add (download) a ruleset package",
 	Long: `Download and add a ruleset package to the current workspace.
 
+Packages can also be built from a local directory or git repository
+containing a rulestack.pkg.json, or downloaded as an archive from a URL,
+without using a registry. An archive URL must be pinned with --sha256; the
+package is named by the archive's manifest.
+
+Use --as to install a version under an alias, next to the version already
+declared in dependencies. The alias is recorded under "aliases" in
+rulestack.json, which lets a project migrate between major versions gradually.
+
+Adding a meta-package, which ships no rules, installs the packages it lists.
+
 Examples:
-  rfh add mypackage@1.0.0`,
-	Args: cobra.ExactArgs(1),
+  rfh add mypackage@1.0.0
+  rfh add my-rules@file:../my-rules
+  rfh add team-rules@git+https://github.com/org/repo#v1.2.0
+  rfh add https://example.com/rules-1.0.0.tgz --sha256 3f2a...
+  rfh add security-rules@1.4.0 --as security-rules-v1
+  rfh add security-rules@1.5.0 --review`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePackageRefs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runAdd(args[0])
+		add := func() error {
+			if isURLSource(args[0]) {
+				return runAddURL(args[0], addSHA256, addAlias, addReview)
+			}
+			if addSHA256 != "" {
+				return fmt.Errorf("--sha256 is only used when adding an archive from a URL")
+			}
+			return runAdd(args[0], addAlias, addReview)
+		}
+		if !addDryRun {
+			return add()
+		}
+
+		projectRoot, err := findProjectRoot()
+		if err != nil {
+			return fmt.Errorf("failed to find project root: %w", err)
+		}
+		if err := previewInstall(projectRoot, add); err != nil {
+			return err
+		}
+		output.Printf("ℹ️  Dry run: nothing was added\n")
+		return nil
 	},
 }
 
+var (
+	addAlias  string
+	addReview bool
+	addSHA256 string
+	addDryRun bool
+)
+
+func init() {
+	addCmd.Flags().StringVar(&addAlias, "as", "", "install the package under this alias, alongside other versions")
+	addCmd.Flags().StringVar(&addSHA256, "sha256", "", "SHA256 the archive added from a URL must have")
+	addCmd.Flags().BoolVar(&addReview, "review", false, "page through the rule files (or a diff against the installed version) before adding")
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "print the CLAUDE.md changes as a unified diff without adding the package")
+}
+
 // PackageRef represents a parsed package reference
 type PackageRef struct {
 	Name    string
@@ -41,23 +92,47 @@ type PackageRef struct {
 type LockManifest struct {
 	Version  string                      `json:"version"`
 	Packages map[string]LockPackageEntry `json:"packages"`
+	Extends  *LockPackageEntry           `json:"extends,omitempty"` // Installed version of the base configuration package
 }
 
 type LockPackageEntry struct {
-	Version string `json:"version"`
-	SHA256  string `json:"sha256"`
+	Version        string `json:"version"`
+	SHA256         string `json:"sha256"`
+	Integrity      string `json:"integrity,omitempty"`       // Archive digest the install was checked against, as sha512-...
+	Package        string `json:"package,omitempty"`         // Replacement package installed in place of this dependency
+	OverriddenFrom string `json:"overridden_from,omitempty"` // Declared name@version that an override replaced
+	Source         string `json:"source,omitempty"`          // file:, git+ or archive URL spec for packages not from a registry
+	Commit         string `json:"commit,omitempty"`          // Resolved commit for git+ sources
+	Registry       string `json:"registry,omitempty"`        // Registry that served the package, which may be a mirror
+
+	// SHA-256 of each file as installed, relative to the package directory; checked by 'rfh verify'
+	Files map[string]string `json:"files,omitempty"`
+	// Rules are kept out of CLAUDE.md until the package is reviewed with 'rfh trust'
+	Quarantined bool `json:"quarantined,omitempty"`
 }
 
 // runAdd implements the add command logic
-func runAdd(packageSpec string) error {
+func runAdd(packageSpec, alias string, review bool) error {
 	// Parse package specification
 	pkgRef, err := parsePackageRef(packageSpec)
 	if err != nil {
 		return err
 	}
 
+	// The package is unpacked and referenced under its alias, if it has one
+	installName := pkgRef.Name
+	if alias != "" {
+		if isSourceSpec(pkgRef.Version) {
+			return fmt.Errorf("aliases are only supported for registry packages")
+		}
+		if err := manifest.ValidateName(alias); err != nil {
+			return fmt.Errorf("invalid alias: %w", err)
+		}
+		installName = alias
+	}
+
 	if verbose {
-		fmt.Printf("📦 Adding package: %s@%s\n", pkgRef.FullName(), pkgRef.Version)
+		output.Printf("📦 Adding package: %s@%s\n", pkgRef.FullName(), pkgRef.Version)
 	}
 
 	// Find project root
@@ -67,52 +142,88 @@ func runAdd(packageSpec string) error {
 	}
 
 	if verbose {
-		fmt.Printf("📁 Project root: %s\n", projectRoot)
+		output.Printf("📁 Project root: %s\n", projectRoot)
+	}
+
+	// Local path and git dependencies are packed from source, no registry involved
+	if isSourceSpec(pkgRef.Version) {
+		if review {
+			return fmt.Errorf("--review is only supported for registry packages")
+		}
+		return addSourcePackage(projectRoot, pkgRef)
 	}
 
 	// Check if package already exists
 	rulestackDir := filepath.Join(projectRoot, ".rulestack")
-	packageDir := filepath.Join(rulestackDir, fmt.Sprintf("%s.%s", pkgRef.Name, pkgRef.Version))
+	packageDir := filepath.Join(rulestackDir, packageDirName(installName, pkgRef.Version))
 
-	if _, err := os.Stat(packageDir); err == nil {
+	if _, err := os.Stat(packageDir); err == nil && !previewingInstall() {
 		// Package exists, prompt user
-		if !confirmOverwrite(pkgRef.FullName()) {
-			fmt.Printf("⏭️  Skipping %s\n", pkgRef.FullName())
+		if !confirmOverwrite(installName) {
+			output.Printf("⏭️  Skipping %s\n", installName)
 			return nil
 		}
 	}
 
 	// Get registry configuration (use default config only)
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Use current registry (no overrides)
+	projectManifest, err := loadOrCreateProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	// Use the project's registry, else the active one
+	if err := useProjectRegistry(&cfg, projectManifest); err != nil {
+		return err
+	}
 	registryName := cfg.Current
 	if registryName == "" {
 		return fmt.Errorf("no registry configured. Use 'rfh registry add' to add a registry")
 	}
 
-	if _, exists := cfg.Registries[registryName]; !exists {
+	registry, exists := cfg.Registries[registryName]
+	if !exists {
 		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
 	}
 
-	// Create client using new factory
-	c, err := client.GetClient(cfg, verbose)
+	// Enforce organization constraints before downloading anything
+
+	if _, exists := projectManifest.Dependencies[alias]; alias != "" && exists {
+		return fmt.Errorf("alias '%s' clashes with a dependency of the same name", alias)
+	}
+
+	constraints, err := loadProjectConstraints(projectRoot, projectManifest)
 	if err != nil {
 		return err
 	}
 
-	// Get package version info
-	if verbose {
-		fmt.Printf("🔍 Looking up package version...\n")
+	if constraints != nil {
+		if err := constraints.CheckRegistry(registryName, registry.URL); err != nil {
+			return err
+		}
+		if err := constraints.CheckPackage(pkgRef.Name, pkgRef.Version); err != nil {
+			return err
+		}
 	}
 
-	ctx, cancel := client.WithTimeout(context.Background())
-	defer cancel()
+	// Look the version up on the active registry, then on the project's mirrors
+	resolver, err := newPackageResolver(cfg, projectManifest.Mirrors)
+	if err != nil {
+		return err
+	}
+	if err := resolver.checkConstraints(constraints); err != nil {
+		return err
+	}
 
-	versionInfo, err := c.GetPackageVersion(ctx, pkgRef.Name, pkgRef.Version)
+	if verbose {
+		output.Printf("🔍 Looking up package version...\n")
+	}
+
+	source, versionInfo, err := resolver.resolve(pkgRef.Name, pkgRef.Version)
 	if err != nil {
 		return fmt.Errorf("failed to get package version: %w", err)
 	}
@@ -123,48 +234,150 @@ func runAdd(packageSpec string) error {
 		return fmt.Errorf("package version missing sha256 hash")
 	}
 
+	if versionInfo.Deprecated != "" {
+		printDeprecation(pkgRef.Name, pkgRef.Version, versionInfo.Deprecated, versionInfo.Replacement)
+	}
+
 	// Create .rulestack directory if it doesn't exist
 	if err := os.MkdirAll(rulestackDir, 0755); err != nil {
 		return fmt.Errorf("failed to create .rulestack directory: %w", err)
 	}
 
 	// Download package
-	tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s-%s.tgz", pkgRef.Name, pkgRef.Version))
+	tempFile, err := tempdir.Path(fmt.Sprintf("%s-%s-*.tgz", pkgRef.Name, pkgRef.Version))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
 
 	if verbose {
-		fmt.Printf("📥 Downloading package...\n")
+		output.Printf("📥 Downloading package...\n")
 	}
 
-	if err := c.DownloadBlob(ctx, sha256, tempFile); err != nil {
-		return fmt.Errorf("failed to download package: %w", err)
+	// As in install, the download is bounded by the client's stall timeout only
+	if err := source.downloadPackage(commandContext, pkgRef.Name, pkgRef.Version, sha256, versionInfo.Size, tempFile); err != nil {
+		return fmt.Errorf("failed to download package from %s: %w", source.Name, err)
+	}
+
+	verified, err := checkArchive(tempFile, versionInfo.Integrity, sha256)
+	if err != nil {
+		return fmt.Errorf("package downloaded from %s failed its integrity check: %w", source.Name, err)
+	}
+
+	// Refuse packages whose declared requirements this project does not meet, or
+	// whose license its policy does not allow
+	packageManifest, err := archiveManifest(tempFile)
+	if err != nil {
+		return err
+	}
+	if packageManifest.IsMeta() && alias != "" {
+		return fmt.Errorf("meta-package %s cannot be added under an alias", pkgRef.FullName())
+	}
+	if err := checkCompatibility(projectRoot, pkgRef.Name, pkgRef.Version, packageManifest.Requires); err != nil {
+		return err
+	}
+	if err := checkLicensePolicy(projectRoot, pkgRef.Name, pkgRef.Version, packageManifest.License); err != nil {
+		return err
+	}
+
+	// Let the user read the rules before anything is installed
+	if review {
+		previousDir := ""
+		if _, dir, err := findInstalledPackage(rulestackDir, installName); err == nil {
+			previousDir = dir
+		}
+		accepted, err := reviewPackage(tempFile, previousDir, pkgRef.FullName(), pkgRef.Version)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			output.Printf("⏭️  Not adding %s@%s\n", pkgRef.FullName(), pkgRef.Version)
+			return nil
+		}
 	}
-	defer os.Remove(tempFile) // Clean up temp file
 
 	// Extract package
 	if verbose {
-		fmt.Printf("📂 Extracting package...\n")
+		output.Printf("📂 Extracting package...\n")
 	}
 
-	if err := pkg.Unpack(tempFile, packageDir); err != nil {
+	extracted, err := unpackPackage(tempFile, packageInstallDir(projectRoot, packageDirName(installName, pkgRef.Version)))
+	if err != nil {
 		return fmt.Errorf("failed to extract package: %w", err)
 	}
+	files := extracted.Hashes()
+
+	lockName := pkgRef.FullName()
+	if alias != "" {
+		lockName = alias
+	}
+
+	// A dry run stops at showing the CLAUDE.md change of the staged package
+	if previewingInstall() {
+		installed := &PackageRef{Name: installName, Version: pkgRef.Version}
+		if review {
+			err = wireRules(projectRoot, lockName, installed, false)
+		} else {
+			err = wireStagedRules(projectRoot, lockName, installed, LockPackageEntry{Version: pkgRef.Version, SHA256: sha256})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update CLAUDE.md: %w", err)
+		}
+		if packageManifest.IsMeta() {
+			output.Printf("ℹ️  %s is a meta-package; its dependencies are not previewed\n", pkgRef.FullName())
+		}
+		return nil
+	}
 
 	// Update manifests
-	if err := updateManifests(projectRoot, pkgRef, sha256); err != nil {
+	if alias != "" {
+		err = updateAliasManifests(projectRoot, alias, pkgRef, sha256, verified, source.Name, files)
+	} else {
+		err = updateManifests(projectRoot, pkgRef, sha256, verified, source.Name, files)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to update manifests: %w", err)
 	}
 
+	// Reviewing the package before adding it is what quarantine waits for
+	if review {
+		if err := markReviewed(projectRoot, lockName); err != nil {
+			return err
+		}
+	}
+
 	// Update CLAUDE.md with new package rules
-	if err := updateClaudeFile(projectRoot, pkgRef); err != nil {
+	if err := wirePackageRules(projectRoot, lockName, &PackageRef{Name: installName, Version: pkgRef.Version}); err != nil {
 		// Don't fail the entire operation if CLAUDE.md update fails
 		if verbose {
-			fmt.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+			output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
 		}
 	} else if verbose {
-		fmt.Printf("📝 Updated CLAUDE.md with new package rules\n")
+		output.Printf("📝 Updated CLAUDE.md with new package rules\n")
+	}
+
+	// Keep installed packages where the project's "storage" setting wants them
+	if err := syncPackageStorage(projectRoot); err != nil {
+		return err
+	}
+
+	if alias != "" {
+		output.Printf("✅ Successfully added %s@%s as %s\n", pkgRef.FullName(), pkgRef.Version, alias)
+	} else {
+		output.Printf("✅ Successfully added %s@%s\n", pkgRef.FullName(), pkgRef.Version)
+	}
+
+	// A meta-package ships no rules; what it brings in are its dependencies
+	if packageManifest.IsMeta() {
+		output.Printf("📦 %s is a meta-package; installing its %d dependenc%s\n", pkgRef.FullName(), len(packageManifest.Dependencies), pluralY(len(packageManifest.Dependencies)))
+		return runInstall()
+	}
+
+	// Warn about rules that clash with other installed packages
+	if projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json")); err == nil {
+		checkRuleConflicts(projectRoot, projectManifest.ResolvedDependencies(), projectManifest.Priority)
 	}
 
-	fmt.Printf("✅ Successfully added %s@%s\n", pkgRef.FullName(), pkgRef.Version)
 	return nil
 }
 
@@ -184,15 +397,12 @@ func parsePackageRef(spec string) (*PackageRef, error) {
 		return nil, fmt.Errorf("version must be specified: use package@version format")
 	}
 
-	// Parse name@version
-	parts := strings.Split(spec, "@")
-	if len(parts) != 2 {
+	// Parse name@version (source specs such as git+ URLs may contain '@' themselves)
+	name, version, _ := strings.Cut(spec, "@")
+	if !isSourceSpec(version) && strings.Contains(version, "@") {
 		return nil, fmt.Errorf("invalid package format: use name@version")
 	}
 
-	name := parts[0]
-	version := parts[1]
-
 	if name == "" {
 		return nil, fmt.Errorf("package name cannot be empty")
 	}
@@ -203,10 +413,22 @@ func parsePackageRef(spec string) (*PackageRef, error) {
 
 	return &PackageRef{
 		Name:    name,
-		Version: version,
+		Version: canonicalVersionArg(version),
 	}, nil
 }
 
+// canonicalVersionArg canonicalizes a version typed on the command line, such
+// as "v1.2" for 1.2.0, and says so when it changes. Ranges, sources and
+// anything else that is not a version are returned as they are.
+func canonicalVersionArg(versionArg string) string {
+	canonical, err := version.Canonicalize(versionArg)
+	if err != nil || canonical == versionArg {
+		return versionArg
+	}
+	output.Fprintf(os.Stderr, "ℹ️  Reading version %s as %s\n", versionArg, canonical)
+	return canonical
+}
+
 // FullName returns the package name
 func (p *PackageRef) FullName() string {
 	return p.Name
@@ -235,21 +457,17 @@ func findProjectRoot() (string, error) {
 	}
 }
 
-// confirmOverwrite prompts the user to confirm overwriting an existing package
+// confirmOverwrite prompts the user to confirm overwriting an existing package.
+// Without --yes, non-interactive runs keep the installed copy.
 func confirmOverwrite(packageName string) bool {
-	fmt.Printf("⚠️  Package %s already exists. Do you want to reinstall it? (y/N): ", packageName)
-
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		response := strings.ToLower(strings.TrimSpace(scanner.Text()))
-		return response == "y" || response == "yes"
-	}
-
-	return false
+	return confirm(fmt.Sprintf("⚠️  Package %s already exists. Do you want to reinstall it?", packageName), false)
 }
 
 // updateManifests updates both rulestack.json and rulestack.lock.json
-func updateManifests(projectRoot string, pkgRef *PackageRef, sha256 string) error {
+func updateManifests(projectRoot string, pkgRef *PackageRef, sha256, integrity, registryName string, files map[string]string) error {
+	_, span := tracing.Start(commandContext, "write manifests")
+	defer span.End()
+
 	// Update rulestack.json
 	manifestPath := filepath.Join(projectRoot, "rulestack.json")
 	projectManifest, err := loadOrCreateProjectManifest(manifestPath, projectRoot)
@@ -264,16 +482,60 @@ func updateManifests(projectRoot string, pkgRef *PackageRef, sha256 string) erro
 	}
 
 	// Update rulestack.lock.json
+	return updateLockEntry(projectRoot, pkgRef.FullName(), LockPackageEntry{
+		Version:   pkgRef.Version,
+		SHA256:    sha256,
+		Integrity: integrity,
+		Registry:  registryName,
+		Files:     files,
+	})
+}
+
+// updateAliasManifests records an aliased package in rulestack.json "aliases"
+// and rulestack.lock.json
+func updateAliasManifests(projectRoot, alias string, pkgRef *PackageRef, sha256, integrity, registryName string, files map[string]string) error {
+	_, span := tracing.Start(commandContext, "write manifests")
+	defer span.End()
+
+	manifestPath := filepath.Join(projectRoot, "rulestack.json")
+	projectManifest, err := loadOrCreateProjectManifest(manifestPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	if projectManifest.Aliases == nil {
+		projectManifest.Aliases = make(map[string]string)
+	}
+	projectManifest.Aliases[alias] = fmt.Sprintf("%s@%s", pkgRef.FullName(), pkgRef.Version)
+
+	if err := manifest.SaveProjectManifest(manifestPath, projectManifest); err != nil {
+		return fmt.Errorf("failed to save project manifest: %w", err)
+	}
+
+	return updateLockEntry(projectRoot, alias, LockPackageEntry{
+		Version:   pkgRef.Version,
+		SHA256:    sha256,
+		Integrity: integrity,
+		Package:   pkgRef.FullName(),
+		Registry:  registryName,
+		Files:     files,
+	})
+}
+
+// updateLockEntry records a single dependency entry in rulestack.lock.json
+func updateLockEntry(projectRoot, name string, entry LockPackageEntry) error {
 	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
 	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
 	if err != nil {
 		return fmt.Errorf("failed to load lock manifest: %w", err)
 	}
 
-	lockManifest.Packages[pkgRef.FullName()] = LockPackageEntry{
-		Version: pkgRef.Version,
-		SHA256:  sha256,
+	if previous, exists := lockManifest.Packages[name]; exists {
+		quarantineEntry(projectRoot, &previous, &entry)
+	} else {
+		quarantineEntry(projectRoot, nil, &entry)
 	}
+	lockManifest.Packages[name] = entry
 
 	if err := saveLockManifest(lockPath, lockManifest); err != nil {
 		return fmt.Errorf("failed to save lock manifest: %w", err)
@@ -327,14 +589,25 @@ func loadOrCreateLockManifest(path, projectRoot string) (*LockManifest, error) {
 	return &lockManifest, nil
 }
 
-// saveLockManifest saves the lock manifest
+// saveLockManifest saves the lock manifest, and records the packages it
+// installs, updates or removes as install events
 func saveLockManifest(path string, lockManifest *LockManifest) error {
+	_, span := tracing.Start(commandContext, "write manifests")
+	defer span.End()
+
 	data, err := json.MarshalIndent(lockManifest, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	projectRoot := filepath.Dir(path)
+	previous, _ := loadOrCreateLockManifest(path, projectRoot)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	recordInstallEvents(projectRoot, lockEvents(previous, lockManifest))
+	return nil
 }
 
 // updateClaudeFile adds the newly installed package to CLAUDE.md
@@ -343,14 +616,14 @@ func updateClaudeFile(projectRoot string, pkgRef *PackageRef) error {
 	templatePath := filepath.Join(projectRoot, "CLAUDE.TEMPLATE.md")
 
 	// If CLAUDE.md doesn't exist, copy from template
-	if _, err := os.Stat(claudePath); os.IsNotExist(err) {
+	if _, err := readEditorFile(claudePath); os.IsNotExist(err) {
 		if _, err := os.Stat(templatePath); err == nil {
 			// Copy template to CLAUDE.md
 			templateData, err := os.ReadFile(templatePath)
 			if err != nil {
 				return fmt.Errorf("failed to read CLAUDE template: %w", err)
 			}
-			if err := os.WriteFile(claudePath, templateData, 0644); err != nil {
+			if err := writeEditorFile(claudePath, templateData); err != nil {
 				return fmt.Errorf("failed to create CLAUDE.md from template: %w", err)
 			}
 		} else {
@@ -360,16 +633,15 @@ func updateClaudeFile(projectRoot string, pkgRef *PackageRef) error {
 This file provides guidance to Claude Code (claude.ai/code) when working with code in this repository.
 
 ## Active Rules (Rulestack core)
-- @.rulestack/core.v1.0.0/core_rules.md
-`
-			if err := os.WriteFile(claudePath, []byte(basicContent), 0644); err != nil {
+` + coreRulesImport(projectTemplateVersion) + "\n"
+			if err := writeEditorFile(claudePath, []byte(basicContent)); err != nil {
 				return fmt.Errorf("failed to create basic CLAUDE.md: %w", err)
 			}
 		}
 	}
 
 	// Read current CLAUDE.md content
-	content, err := os.ReadFile(claudePath)
+	content, err := readEditorFile(claudePath)
 	if err != nil {
 		return fmt.Errorf("failed to read CLAUDE.md: %w", err)
 	}
@@ -377,7 +649,7 @@ This file provides guidance to Claude Code (claude.ai/code) when working with co
 	lines := strings.Split(string(content), "\n")
 
 	// Find actual rule files in the package directory
-	packageDir := filepath.Join(projectRoot, ".rulestack", fmt.Sprintf("%s.%s", pkgRef.Name, pkgRef.Version))
+	packageDir := packageRulesDir(projectRoot, packageDirName(pkgRef.Name, pkgRef.Version))
 	ruleFiles, err := findRuleFiles(packageDir)
 	if err != nil {
 		return fmt.Errorf("failed to find rule files in package: %w", err)
@@ -392,7 +664,7 @@ This file provides guidance to Claude Code (claude.ai/code) when working with co
 	var newRuleLines []string
 	for _, ruleFile := range ruleFiles {
 		// Make path relative to .rulestack directory
-		relPath := filepath.Join(fmt.Sprintf("%s.%s", pkgRef.Name, pkgRef.Version), ruleFile)
+		relPath := filepath.Join(packageDirName(pkgRef.Name, pkgRef.Version), ruleFile)
 		newRuleLines = append(newRuleLines, fmt.Sprintf("- @.rulestack/%s", strings.ReplaceAll(relPath, "\\", "/")))
 	}
 
@@ -475,7 +747,7 @@ This file provides guidance to Claude Code (claude.ai/code) when working with co
 
 	// Write updated content back to file
 	updatedContent := strings.Join(updatedLines, "\n")
-	if err := os.WriteFile(claudePath, []byte(updatedContent), 0644); err != nil {
+	if err := writeEditorFile(claudePath, []byte(updatedContent)); err != nil {
 		return fmt.Errorf("failed to update CLAUDE.md: %w", err)
 	}
 
@@ -486,7 +758,13 @@ This file provides guidance to Claude Code (claude.ai/code) when working with co
 func findRuleFiles(packageDir string) ([]string, error) {
 	var ruleFiles []string
 
-	err := filepath.Walk(packageDir, func(path string, info os.FileInfo, err error) error {
+	// Resolve linked package directories (see 'rfh link') so the walk descends into them
+	packageDir, err := filepath.EvalSymlinks(packageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(packageDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
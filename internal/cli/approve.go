@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+// approveCmd represents the approve command
+var approveCmd = &cobra.Command{
+	Use:   "approve <package>@<version>",
+	Short: "Approve a package version awaiting a second reviewer",
+	Long: `Approve a package version published to a registry that requires two-person review.
+
+On HTTP registries started with REQUIRE_APPROVAL=true, versions that pass
+validation wait in the awaiting_approval state until a second publisher approves
+them. On git registries configured with require_approval, this submits an
+approving review on the version's publish pull request.
+
+Publishers cannot approve their own versions.
+
+Examples:
+  rfh approve security-rules@1.2.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApprove(args[0])
+	},
+}
+
+// runApprove implements the approve command logic
+func runApprove(spec string) error {
+	pkgRef, err := parsePackageRef(spec)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("👥 Approving %s@%s\n", pkgRef.Name, pkgRef.Version)
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	if err := c.ApprovePackage(ctx, pkgRef.Name, pkgRef.Version); err != nil {
+		return fmt.Errorf("failed to approve %s@%s: %w", pkgRef.Name, pkgRef.Version, err)
+	}
+
+	output.Printf("✅ Approved %s@%s\n", pkgRef.Name, pkgRef.Version)
+	return nil
+}
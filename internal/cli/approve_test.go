@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunApprove(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		expectErr string
+	}{
+		{
+			name:   "approved",
+			status: http.StatusOK,
+			body:   `{"name": "my-rules", "version": "1.0.0", "status": "published", "approved_by": "reviewer"}`,
+		},
+		{
+			name:      "own version",
+			status:    http.StatusForbidden,
+			body:      `{"error": "Publishers cannot approve their own versions"}`,
+			expectErr: "cannot approve their own versions",
+		},
+		{
+			name:      "not awaiting approval",
+			status:    http.StatusConflict,
+			body:      `{"error": "Package version is published, not awaiting approval"}`,
+			expectErr: "not awaiting approval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != "/v1/packages/my-rules/versions/1.0.0/approve" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			configDir := t.TempDir()
+			t.Setenv("RFH_CONFIG", configDir)
+			configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+			if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			err := runApprove("my-rules@1.0.0")
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check project dependencies against organization constraints",
+	Long: `Check the project's dependencies and active registry against the constraints
+file referenced by the "constraints" field in rulestack.json.
+
+Reports:
+- Registries not in the allowed list
+- Blocked packages or versions
+- Required packages missing from dependencies
+- Packages below their minimum version
+
+Exits with an error when any violation is found, so it can be used in CI.
+
+Examples:
+  rfh audit`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAudit()
+	},
+}
+
+// runAudit implements the audit command logic
+func runAudit() error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+	if err := applyInherited(projectRoot, projectManifest); err != nil {
+		return err
+	}
+
+	constraints, err := loadProjectConstraints(projectRoot, projectManifest)
+	if err != nil {
+		return err
+	}
+
+	if constraints == nil {
+		output.Printf("ℹ️  No constraints file configured in rulestack.json\n")
+		return nil
+	}
+
+	if verbose {
+		output.Printf("📋 Constraints: %s\n", projectManifest.Constraints)
+	}
+
+	var violations []error
+
+	// The registry check only applies when a registry is configured
+	if cfg, err := loadConfig(); err == nil && cfg.Current != "" {
+		if registry, exists := cfg.Registries[cfg.Current]; exists {
+			if err := constraints.CheckRegistry(cfg.Current, registry.URL); err != nil {
+				violations = append(violations, err)
+			}
+		}
+	}
+
+	violations = append(violations, checkDependencyConstraints(constraints, projectManifest)...)
+
+	if len(violations) == 0 {
+		output.Printf("✅ No constraint violations found (%d dependencies checked)\n", len(projectManifest.Dependencies))
+		return nil
+	}
+
+	for _, violation := range violations {
+		output.Printf("❌ %v\n", violation)
+	}
+
+	return fmt.Errorf("found %d constraint violation(s)", len(violations))
+}
+
+// loadProjectConstraints loads the constraints file referenced by the project manifest,
+// returning nil when the project has none configured
+func loadProjectConstraints(projectRoot string, projectManifest *manifest.ProjectManifest) (*manifest.Constraints, error) {
+	if projectManifest.Constraints == "" {
+		return nil, nil
+	}
+
+	path := projectManifest.Constraints
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectRoot, path)
+	}
+
+	constraints, err := manifest.LoadConstraints(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load constraints: %w", err)
+	}
+
+	return constraints, nil
+}
+
+// enforceConstraints checks the registry and dependencies against project constraints,
+// printing each violation and returning an error if any were found
+func enforceConstraints(constraints *manifest.Constraints, registryName string, registry config.Registry, projectManifest *manifest.ProjectManifest) error {
+	if constraints == nil {
+		return nil
+	}
+
+	var violations []error
+	if registryName != "" {
+		if err := constraints.CheckRegistry(registryName, registry.URL); err != nil {
+			violations = append(violations, err)
+		}
+	}
+	violations = append(violations, checkDependencyConstraints(constraints, projectManifest)...)
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, violation := range violations {
+		output.Printf("❌ %v\n", violation)
+	}
+
+	return fmt.Errorf("project constraints violated (%d). Run 'rfh audit' for details", len(violations))
+}
+
+// checkDependencyConstraints checks declared dependencies, any packages that
+// overrides substitute for them and aliased packages
+func checkDependencyConstraints(constraints *manifest.Constraints, projectManifest *manifest.ProjectManifest) []error {
+	violations := constraints.CheckDependencies(projectManifest.Dependencies)
+
+	for name, declaredVersion := range projectManifest.Dependencies {
+		resolvedName, resolvedVersion, overridden := projectManifest.ResolveDependency(name, declaredVersion)
+		if !overridden {
+			continue
+		}
+		if err := constraints.CheckPackage(resolvedName, resolvedVersion); err != nil {
+			violations = append(violations, err)
+		}
+	}
+
+	for alias := range projectManifest.Aliases {
+		name, version, _ := projectManifest.ResolveAlias(alias)
+		if err := constraints.CheckPackage(name, version); err != nil {
+			violations = append(violations, err)
+		}
+	}
+
+	return violations
+}
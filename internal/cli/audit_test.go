@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupConstrainedProject creates a project with a constraints file and a configured registry
+func setupConstrainedProject(t *testing.T, dependencies string) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(tempDir)
+
+	t.Setenv("RFH_CONFIG", filepath.Join(tempDir, ".rfh"))
+	if err := os.MkdirAll(filepath.Join(tempDir, ".rfh"), 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	configContent := `current = "corp"
+
+[registries.corp]
+url = "https://rules.example.com"
+type = "remote-http"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, ".rfh", "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	constraintsContent := `{
+		"allowed_registries": ["corp"],
+		"blocked": ["evil-rules"],
+		"required": ["security-rules"]
+	}`
+	if err := os.WriteFile(filepath.Join(tempDir, "constraints.json"), []byte(constraintsContent), 0644); err != nil {
+		t.Fatalf("Failed to create constraints: %v", err)
+	}
+
+	manifestContent := `{
+		"version": "1.0.0",
+		"constraints": "constraints.json",
+		"dependencies": ` + dependencies + `
+	}`
+	if err := os.WriteFile(filepath.Join(tempDir, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	return tempDir
+}
+
+func TestRunAudit_Violations(t *testing.T) {
+	setupConstrainedProject(t, `{"evil-rules": "1.0.0"}`)
+
+	err := runAudit()
+	if err == nil {
+		t.Fatal("Expected audit to report violations")
+	}
+
+	expectedError := "found 2 constraint violation(s)"
+	if err.Error() != expectedError {
+		t.Errorf("Expected error '%s', got '%s'", expectedError, err.Error())
+	}
+}
+
+func TestRunAudit_Clean(t *testing.T) {
+	setupConstrainedProject(t, `{"security-rules": "1.0.0"}`)
+
+	if err := runAudit(); err != nil {
+		t.Fatalf("Expected audit to pass, got: %v", err)
+	}
+}
+
+func TestRunInstall_ConstraintsViolated(t *testing.T) {
+	setupConstrainedProject(t, `{"evil-rules": "1.0.0", "security-rules": "1.0.0"}`)
+
+	err := runInstall()
+	if err == nil {
+		t.Fatal("Expected install to fail on blocked package")
+	}
+
+	if !strings.Contains(err.Error(), "project constraints violated") {
+		t.Errorf("Expected constraints error, got '%s'", err.Error())
+	}
+}
@@ -1,17 +1,19 @@
 package cli
 
 import (
-	"bufio"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
-	"syscall"
+	"time"
 
 	"rulestack/internal/client"
 	"rulestack/internal/config"
+	"rulestack/internal/output"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
 // Command line flags for non-interactive auth
@@ -19,6 +21,8 @@ var (
 	authUsername string
 	authPassword string
 	authEmail    string
+
+	authPasswordStdin bool
 )
 
 // authCmd represents the auth command group
@@ -48,7 +52,16 @@ var loginCmd = &cobra.Command{
 	Short: "Login to your user account",
 	Long: `Login to your user account with username and password.
 	
-Your JWT token will be saved locally for future API calls.`,
+Your JWT token will be saved locally for future API calls.
+
+For automation, pipe the password in with --password-stdin or set RFH_USERNAME
+and RFH_PASSWORD; unlike --password, neither leaves it in the shell history or
+process list.
+
+Examples:
+  rfh auth login
+  echo "$REGISTRY_PASSWORD" | rfh auth login --username ci-bot --password-stdin
+  RFH_USERNAME=ci-bot RFH_PASSWORD=... rfh auth login`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runLogin()
 	},
@@ -78,7 +91,7 @@ var whoamiCmd = &cobra.Command{
 
 func runRegister() error {
 	// Get current registry
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -92,53 +105,41 @@ func runRegister() error {
 		return fmt.Errorf("active registry '%s' not found", cfg.Current)
 	}
 
-	fmt.Printf("📝 Registering new account at %s\n", registry.URL)
-
-	var username, email, password string
+	output.Printf("📝 Registering new account at %s\n", registry.URL)
 
-	// Check if non-interactive flags are provided
-	if authUsername != "" && authEmail != "" && authPassword != "" {
-		username = authUsername
-		email = authEmail
-		password = authPassword
-		fmt.Printf("Using provided credentials for %s (%s)\n", username, email)
-	} else {
-		// Interactive mode
-		fmt.Println()
-		reader := bufio.NewReader(os.Stdin)
-
-		fmt.Print("Username: ")
-		usernameInput, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("failed to read username: %w", err)
-		}
-		username = strings.TrimSpace(usernameInput)
+	password, err := passwordFromFlags()
+	if err != nil {
+		return err
+	}
+	username, email := authUsername, authEmail
 
-		fmt.Print("Email: ")
-		emailInput, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("failed to read email: %w", err)
+	if username == "" || email == "" || password == "" {
+		output.Println()
+	}
+	if username == "" {
+		if username, err = ask(prompt{Question: "Username", Env: "RFH_USERNAME", Flag: "--username"}); err != nil {
+			return err
 		}
-		email = strings.TrimSpace(emailInput)
-
-		fmt.Print("Password: ")
-		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password: %w", err)
+	}
+	if email == "" {
+		if email, err = ask(prompt{Question: "Email", Env: "RFH_EMAIL", Flag: "--email"}); err != nil {
+			return err
 		}
-		password = string(passwordBytes)
-		fmt.Println() // New line after password input
-
-		fmt.Print("Confirm password: ")
-		confirmBytes, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password confirmation: %w", err)
+	}
+	if password == "" {
+		// A password from the environment is not confirmed; a typed one is
+		typed := os.Getenv("RFH_PASSWORD") == ""
+		if password, err = ask(prompt{Question: "Password", Env: "RFH_PASSWORD", Flag: "--password", Secret: true}); err != nil {
+			return err
 		}
-		confirm := string(confirmBytes)
-		fmt.Println() // New line after password input
-
-		if password != confirm {
-			return fmt.Errorf("passwords do not match")
+		if typed {
+			confirmation, err := ask(prompt{Question: "Confirm password", Secret: true})
+			if err != nil {
+				return err
+			}
+			if password != confirmation {
+				return fmt.Errorf("passwords do not match")
+			}
 		}
 	}
 
@@ -154,7 +155,7 @@ func runRegister() error {
 	}
 
 	// Create auth client and register
-	authClient := client.NewAuthClient(registry.URL)
+	authClient := client.NewRegistryAuthClient(registry)
 	authResp, err := authClient.Register(client.RegisterRequest{
 		Username: username,
 		Email:    email,
@@ -168,22 +169,23 @@ func runRegister() error {
 	registryConfig := cfg.Registries[cfg.Current]
 	registryConfig.Username = authResp.User.Username
 	registryConfig.JWTToken = authResp.Token
+	registryConfig.TokenURL = registryConfig.URL
 	cfg.Registries[cfg.Current] = registryConfig
 
 	if err := config.SaveCLI(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Successfully registered and logged in as %s\n", authResp.User.Username)
-	fmt.Printf("👤 Role: %s\n", authResp.User.Role)
-	fmt.Printf("🔑 Authentication token saved\n")
+	output.Printf("✅ Successfully registered and logged in as %s\n", authResp.User.Username)
+	output.Printf("👤 Role: %s\n", authResp.User.Role)
+	output.Printf("🔑 Authentication token saved\n")
 
 	return nil
 }
 
 func runLogin() error {
 	// Get current registry
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -197,34 +199,26 @@ func runLogin() error {
 		return fmt.Errorf("active registry '%s' not found", cfg.Current)
 	}
 
-	fmt.Printf("🔑 Logging in to %s\n", registry.URL)
+	output.Printf("🔑 Logging in to %s\n", registry.URL)
 
-	var username, password string
-
-	// Check if non-interactive flags are provided
-	if authUsername != "" && authPassword != "" {
-		username = authUsername
-		password = authPassword
-		fmt.Printf("Using provided credentials for %s\n", username)
-	} else {
-		// Interactive mode
-		fmt.Println()
-		reader := bufio.NewReader(os.Stdin)
+	password, err := passwordFromFlags()
+	if err != nil {
+		return err
+	}
+	username := authUsername
 
-		fmt.Print("Username: ")
-		usernameInput, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("failed to read username: %w", err)
+	if username == "" || password == "" {
+		output.Println()
+	}
+	if username == "" {
+		if username, err = ask(prompt{Question: "Username", Env: "RFH_USERNAME", Flag: "--username"}); err != nil {
+			return err
 		}
-		username = strings.TrimSpace(usernameInput)
-
-		fmt.Print("Password: ")
-		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password: %w", err)
+	}
+	if password == "" {
+		if password, err = ask(prompt{Question: "Password", Env: "RFH_PASSWORD", Flag: "--password", Secret: true}); err != nil {
+			return err
 		}
-		password = string(passwordBytes)
-		fmt.Println() // New line after password input
 	}
 
 	// Validate input
@@ -236,7 +230,7 @@ func runLogin() error {
 	}
 
 	// Create auth client and login
-	authClient := client.NewAuthClient(registry.URL)
+	authClient := client.NewRegistryAuthClient(registry)
 	authResp, err := authClient.Login(client.LoginRequest{
 		Username: username,
 		Password: password,
@@ -249,21 +243,56 @@ func runLogin() error {
 	registryConfig := cfg.Registries[cfg.Current]
 	registryConfig.Username = authResp.User.Username
 	registryConfig.JWTToken = authResp.Token
+	registryConfig.TokenURL = registryConfig.URL
 	cfg.Registries[cfg.Current] = registryConfig
 
 	if err := config.SaveCLI(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Successfully logged in as %s\n", authResp.User.Username)
-	fmt.Printf("👤 Role: %s\n", authResp.User.Role)
-	fmt.Printf("🔑 Authentication token saved\n")
+	output.Printf("✅ Successfully logged in as %s\n", authResp.User.Username)
+	output.Printf("👤 Role: %s\n", authResp.User.Role)
+	output.Printf("🔑 Authentication token saved\n")
+	if !authResp.ExpiresAt.IsZero() {
+		output.Printf("⏳ Token expires: %s\n", authResp.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	if authResp.IdleTimeout > 0 {
+		output.Printf("💤 The session ends after %s without use\n", time.Duration(authResp.IdleTimeout)*time.Second)
+	}
 
 	return nil
 }
 
+// passwordFromFlags returns the password given by --password or piped in with
+// --password-stdin, or an empty string if neither is used
+func passwordFromFlags() (string, error) {
+	if !authPasswordStdin {
+		if authPassword != "" {
+			output.Fprintf(os.Stderr, "%s\n", "⚠️  Using --password on the command line is insecure; use --password-stdin or RFH_PASSWORD")
+		}
+		return authPassword, nil
+	}
+
+	if authPassword != "" {
+		return "", fmt.Errorf("--password and --password-stdin cannot be used together")
+	}
+	if authUsername == "" && os.Getenv("RFH_USERNAME") == "" {
+		return "", fmt.Errorf("--password-stdin requires --username or RFH_USERNAME")
+	}
+
+	data, err := io.ReadAll(promptIn)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	password := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if password == "" {
+		return "", fmt.Errorf("no password on stdin")
+	}
+	return password, nil
+}
+
 func runLogout() error {
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -275,22 +304,22 @@ func runLogout() error {
 	if cfg.Current != "" {
 		if registry, exists := cfg.Registries[cfg.Current]; exists && registry.Username != "" {
 			username = registry.Username
-			tokenToLogout = registry.JWTToken
+			tokenToLogout = registry.AuthToken()
 		}
 	}
 
 	if username == "" {
-		fmt.Println("ℹ️  You are not currently logged in")
+		output.Println("ℹ️  You are not currently logged in")
 		return nil
 	}
 
 	// Try to logout from server (invalidate session)
 	if cfg.Current != "" && tokenToLogout != "" {
 		if registry, exists := cfg.Registries[cfg.Current]; exists {
-			authClient := client.NewAuthClient(registry.URL)
+			authClient := client.NewRegistryAuthClient(registry)
 			if err := authClient.Logout(tokenToLogout); err != nil {
 				// Don't fail if server logout fails - we'll clear local credentials anyway
-				fmt.Printf("⚠️  Warning: Failed to logout from server: %v\n", err)
+				output.Printf("⚠️  Warning: Failed to logout from server: %v\n", err)
 			}
 		}
 	}
@@ -300,6 +329,7 @@ func runLogout() error {
 		if registryConfig, exists := cfg.Registries[cfg.Current]; exists {
 			registryConfig.Username = ""
 			registryConfig.JWTToken = ""
+			registryConfig.TokenURL = ""
 			cfg.Registries[cfg.Current] = registryConfig
 		}
 	}
@@ -308,14 +338,14 @@ func runLogout() error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Successfully logged out %s\n", username)
-	fmt.Printf("🗑️  Local credentials removed\n")
+	output.Printf("✅ Successfully logged out %s\n", username)
+	output.Printf("🗑️  Local credentials removed\n")
 
 	return nil
 }
 
 func runWhoami() error {
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -327,40 +357,96 @@ func runWhoami() error {
 	if cfg.Current != "" {
 		if registry, exists := cfg.Registries[cfg.Current]; exists && registry.Username != "" {
 			username = registry.Username
-			token = registry.JWTToken
+			token = registry.AuthToken()
 		}
 	}
 
 	if username == "" {
-		fmt.Println("❌ You are not currently logged in")
-		fmt.Println("Use 'rfh auth login' to authenticate or 'rfh auth register' to create an account")
+		output.Println("❌ You are not currently logged in")
+		output.Println("Use 'rfh auth login' to authenticate or 'rfh auth register' to create an account")
 		return nil
 	}
 
-	fmt.Printf("👤 Logged in as: %s\n", username)
+	output.Printf("👤 Logged in as: %s\n", username)
 
 	// Try to get detailed profile from server
 	if cfg.Current != "" && token != "" {
 		if registry, exists := cfg.Registries[cfg.Current]; exists {
-			authClient := client.NewAuthClient(registry.URL)
+			authClient := client.NewRegistryAuthClient(registry)
 			if profile, err := authClient.GetProfile(token); err == nil {
-				fmt.Printf("📧 Email: %s\n", profile.Email)
-				fmt.Printf("🎭 Role: %s\n", profile.Role)
+				output.Printf("📧 Email: %s\n", profile.Email)
+				output.Printf("🎭 Role: %s\n", profile.Role)
 				if profile.LastLogin != nil {
-					fmt.Printf("🕐 Last login: %s\n", profile.LastLogin.Format("2006-01-02 15:04:05"))
+					output.Printf("🕐 Last login: %s\n", profile.LastLogin.Format("2006-01-02 15:04:05"))
 				}
-				fmt.Printf("📅 Account created: %s\n", profile.CreatedAt.Format("2006-01-02"))
+				output.Printf("📅 Account created: %s\n", profile.CreatedAt.Format("2006-01-02"))
 			} else {
-				fmt.Printf("⚠️  Could not fetch profile details: %v\n", err)
+				output.Printf("⚠️  Could not fetch profile details: %v\n", err)
 			}
 		}
 	}
 
-	fmt.Printf("🔑 Token: [saved]\n")
+	output.Printf("🔑 Token: [saved]\n")
+	if _, expiresAt, ok := tokenLifetime(token); ok {
+		output.Printf("⏳ Token expires: %s\n", expiresAt.Local().Format("2006-01-02 15:04"))
+	}
 
 	return nil
 }
 
+// tokenExpiryWarning is how close to expiring a stored token must be for
+// commands to warn about it. Tokens with short lifetimes are warned about in
+// their last quarter instead.
+const tokenExpiryWarning = 72 * time.Hour
+
+// tokenLifetime reads when a registry token was issued and when it expires from
+// its JWT claims. ok is false for tokens that are not JWTs or do not expire.
+func tokenLifetime(token string) (issuedAt, expiresAt time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	var claims struct {
+		IssuedAt  int64 `json:"iat"`
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	if claims.IssuedAt != 0 {
+		issuedAt = time.Unix(claims.IssuedAt, 0)
+	}
+	return issuedAt, time.Unix(claims.ExpiresAt, 0), true
+}
+
+// warnTokenExpiry warns on stderr when the active registry's login has expired
+// or is about to, so it can be renewed before a command fails on it
+func warnTokenExpiry(cfg config.CLIConfig, commandName string, now time.Time) {
+	if isAuthCommand(commandName) || cfg.Current == "" {
+		return
+	}
+	registry, exists := cfg.Registries[cfg.Current]
+	if !exists || registry.GetEffectiveType() == config.RegistryTypeGit {
+		return
+	}
+	issuedAt, expiresAt, ok := tokenLifetime(registry.AuthToken())
+	if !ok {
+		return
+	}
+
+	remaining := expiresAt.Sub(now)
+	switch {
+	case remaining <= 0:
+		output.Fprintf(os.Stderr, "⚠️  Your login to %s expired on %s; run 'rfh auth login'\n", cfg.Current, expiresAt.Local().Format("2006-01-02 15:04"))
+	case remaining < tokenExpiryWarning && (issuedAt.IsZero() || remaining < expiresAt.Sub(issuedAt)/4):
+		output.Fprintf(os.Stderr, "⚠️  Your login to %s expires in %s; run 'rfh auth login' to renew it\n", cfg.Current, remaining.Round(time.Minute))
+	}
+}
+
 func init() {
 	authCmd.AddCommand(registerCmd)
 	authCmd.AddCommand(loginCmd)
@@ -371,7 +457,9 @@ func init() {
 	registerCmd.Flags().StringVar(&authUsername, "username", "", "username for registration (non-interactive)")
 	registerCmd.Flags().StringVar(&authEmail, "email", "", "email for registration (non-interactive)")
 	registerCmd.Flags().StringVar(&authPassword, "password", "", "password for registration (non-interactive)")
+	registerCmd.Flags().BoolVar(&authPasswordStdin, "password-stdin", false, "read the password from stdin")
 
 	loginCmd.Flags().StringVar(&authUsername, "username", "", "username for login (non-interactive)")
 	loginCmd.Flags().StringVar(&authPassword, "password", "", "password for login (non-interactive)")
+	loginCmd.Flags().BoolVar(&authPasswordStdin, "password-stdin", false, "read the password from stdin")
 }
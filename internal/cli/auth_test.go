@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+)
+
+func TestRunLoginPasswordStdin(t *testing.T) {
+	var received client.LoginRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/login" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		resp := client.AuthResponse{Token: "jwt"}
+		resp.User.Username = received.Username
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	t.Setenv("RFH_USERNAME", "")
+	t.Setenv("RFH_PASSWORD", "")
+	configTOML := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configTOML), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	login := func(input, username, password string, stdin bool) error {
+		withPromptInput(t, input)
+		authUsername, authPassword, authPasswordStdin = username, password, stdin
+		defer func() { authUsername, authPassword, authPasswordStdin = "", "", false }()
+		return runLogin()
+	}
+
+	if err := login("s3cret pass\r\n", "ci-bot", "", true); err != nil {
+		t.Fatalf("runLogin failed: %v", err)
+	}
+	if received.Username != "ci-bot" || received.Password != "s3cret pass" {
+		t.Errorf("expected ci-bot with the piped password, got %+v", received)
+	}
+	cfg, err := config.LoadCLI()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Registries["corp"].JWTToken != "jwt" {
+		t.Errorf("expected the token saved, got %q", cfg.Registries["corp"].JWTToken)
+	}
+
+	t.Setenv("RFH_USERNAME", "env-bot")
+	t.Setenv("RFH_PASSWORD", "from-env")
+	if err := login("", "", "", false); err != nil {
+		t.Fatalf("runLogin from environment failed: %v", err)
+	}
+	if received.Username != "env-bot" || received.Password != "from-env" {
+		t.Errorf("expected credentials from the environment, got %+v", received)
+	}
+	t.Setenv("RFH_USERNAME", "")
+
+	for _, tt := range []struct {
+		name, input, username, password, wantErr string
+	}{
+		{"with --password", "x\n", "ci-bot", "other", "cannot be used together"},
+		{"without a username", "x\n", "", "", "requires --username"},
+		{"empty stdin", "\n", "ci-bot", "", "no password on stdin"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := login(tt.input, tt.username, tt.password, true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTokenLifetime(t *testing.T) {
+	jwt := func(claims string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2ln"
+	}
+
+	issuedAt, expiresAt, ok := tokenLifetime(jwt(`{"iat": 1700000000, "exp": 1702592000, "user_id": 1}`))
+	if !ok || !issuedAt.Equal(time.Unix(1700000000, 0)) || !expiresAt.Equal(time.Unix(1702592000, 0)) {
+		t.Errorf("got %v, %v, %v", issuedAt, expiresAt, ok)
+	}
+
+	// Tokens without an issue time are still read, and their lifetime is unknown
+	if issuedAt, _, ok := tokenLifetime(jwt(`{"exp": 1702592000}`)); !ok || !issuedAt.IsZero() {
+		t.Errorf("without iat: got %v, %v", issuedAt, ok)
+	}
+
+	for _, token := range []string{"", "opaque-api-token", jwt(`{"user_id": 1}`), "a.!!!.c"} {
+		if _, _, ok := tokenLifetime(token); ok {
+			t.Errorf("expected no lifetime for %q", token)
+		}
+	}
+}
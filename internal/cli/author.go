@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+// authorCmd represents the author command
+var authorCmd = &cobra.Command{
+	Use:   "author <username>",
+	Short: "Show an author's profile and published rulesets",
+	Long: `Show the public profile of a user on the active registry and the latest
+version of each ruleset they have published.
+
+Users fill in their display name, website, avatar and bio with
+PUT /v1/auth/profile. Author pages are only available on HTTP registries.
+
+Examples:
+  rfh author alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthor(args[0])
+	},
+}
+
+// runAuthor implements the author command logic
+func runAuthor(username string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	author, err := c.GetAuthor(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get author %s: %w", username, err)
+	}
+
+	if author.DisplayName != "" {
+		output.Printf("👤 %s (%s)\n", author.DisplayName, author.Username)
+	} else {
+		output.Printf("👤 %s\n", author.Username)
+	}
+	if author.Bio != "" {
+		output.Printf("   %s\n", author.Bio)
+	}
+	if author.URL != "" {
+		output.Printf("   🔗 %s\n", author.URL)
+	}
+	if author.AvatarURL != "" {
+		output.Printf("   🖼️  %s\n", author.AvatarURL)
+	}
+	output.Printf("   📅 Member since %s\n\n", author.MemberSince.Local().Format("2006-01-02"))
+
+	if len(author.Packages) == 0 {
+		output.Printf("No published rulesets\n")
+		return nil
+	}
+
+	output.Printf("📋 %d published ruleset(s):\n\n", len(author.Packages))
+	for _, pkg := range author.Packages {
+		if badges := packageBadges(pkg); badges != "" {
+			output.Printf("📦 %s@%s %s\n", pkg.Name, pkg.Latest, badges)
+		} else {
+			output.Printf("📦 %s@%s\n", pkg.Name, pkg.Latest)
+		}
+		if pkg.Description != "" {
+			output.Printf("   %s\n", pkg.Description)
+		}
+	}
+	return nil
+}
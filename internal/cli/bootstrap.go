@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"rulestack/internal/config"
+	"rulestack/internal/output"
+)
+
+// noBootstrapCommands never offer the default registry: they manage registries
+// themselves, or speak a protocol on stdout that a prompt would break
+var noBootstrapCommands = map[string]bool{
+	"registry":   true,
+	"mcp":        true,
+	"serve":      true,
+	"help":       true,
+	"completion": true,
+}
+
+// stdinIsTerminal reports whether a person can answer prompts; replaced in tests
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// offerDefaultRegistry offers to add the default public registry the first time
+// rfh runs, so searching and installing work without setting up a registry
+// first. The offer is made once: declining it still writes the config file.
+// Scripts and CI, without a terminal or in non-interactive mode, only get the
+// registry with --yes.
+func offerDefaultRegistry(commandName string) {
+	fields := strings.Fields(commandName)
+	if len(fields) < 2 || noBootstrapCommands[fields[1]] || config.ConfigExists() {
+		return
+	}
+
+	registryURL := config.DefaultRegistry()
+	if registryURL == "" || (!assumeYes && (!isInteractive() || !stdinIsTerminal())) {
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil || len(cfg.Registries) > 0 {
+		return
+	}
+
+	output.Printf("👋 No registry is configured yet.\n")
+	if confirm(fmt.Sprintf("Add the public registry %s as '%s'?", registryURL, config.DefaultRegistryName), true) {
+		cfg.Registries[config.DefaultRegistryName] = config.Registry{
+			URL:  registryURL,
+			Type: config.RegistryTypeHTTP,
+		}
+		cfg.Current = config.DefaultRegistryName
+	}
+
+	if err := config.SaveCLI(cfg); err != nil {
+		output.Printf("⚠️  Failed to save config: %v\n", err)
+		return
+	}
+
+	if cfg.Current == config.DefaultRegistryName {
+		output.Printf("✅ Added registry '%s' (%s) and set it as active\n\n", config.DefaultRegistryName, registryURL)
+	} else {
+		output.Printf("💡 Use 'rfh registry add' to add a registry later\n\n")
+	}
+}
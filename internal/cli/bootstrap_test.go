@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"testing"
+
+	"rulestack/internal/config"
+)
+
+func TestOfferDefaultRegistry(t *testing.T) {
+	terminal := true
+	oldTerminal := stdinIsTerminal
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdinIsTerminal = oldTerminal })
+
+	t.Run("added on first run", func(t *testing.T) {
+		t.Setenv("RFH_CONFIG", t.TempDir())
+		t.Setenv("RFH_DEFAULT_REGISTRY", "https://rules.example.com")
+		withPromptInput(t, "y\n")
+
+		offerDefaultRegistry("rfh search")
+
+		cfg, err := config.LoadCLI()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Current != config.DefaultRegistryName || cfg.Registries[config.DefaultRegistryName].URL != "https://rules.example.com" {
+			t.Errorf("config = %+v, want the default registry active", cfg)
+		}
+	})
+
+	t.Run("declining is remembered", func(t *testing.T) {
+		t.Setenv("RFH_CONFIG", t.TempDir())
+		withPromptInput(t, "n\n")
+
+		offerDefaultRegistry("rfh search")
+
+		if !config.ConfigExists() {
+			t.Fatal("declining should write the config so the offer is not repeated")
+		}
+		cfg, _ := config.LoadCLI()
+		if len(cfg.Registries) != 0 {
+			t.Errorf("registries = %+v, want none", cfg.Registries)
+		}
+	})
+
+	t.Run("not offered", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			command  string
+			override string
+			terminal bool
+		}{
+			{"registry commands", "rfh registry add", "", true},
+			{"MCP server", "rfh mcp", "", true},
+			{"turned off", "rfh search", "none", true},
+			{"without a terminal", "rfh search", "", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv("RFH_CONFIG", t.TempDir())
+				t.Setenv("RFH_DEFAULT_REGISTRY", tt.override)
+				withPromptInput(t, "y\n")
+				terminal = tt.terminal
+				defer func() { terminal = true }()
+
+				offerDefaultRegistry(tt.command)
+
+				if config.ConfigExists() {
+					t.Error("config written without an offer")
+				}
+			})
+		}
+	})
+}
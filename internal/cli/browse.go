@@ -0,0 +1,428 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+var (
+	browseTag    string
+	browseTarget string
+)
+
+// browseCmd represents the browse command
+var browseCmd = &cobra.Command{
+	Use:   "browse [query]",
+	Short: "Interactively search the registry and pick packages to add",
+	Long: `Open an interactive view of the active registry: a list of packages on the
+left and the selected package's details and rule file excerpts on the right.
+Mark packages with space and press enter to add them to the project.
+
+Keys:
+  /          edit the search query (enter to search)
+  ↑/↓, k/j   move through the results
+  space      mark or unmark a package for adding
+  enter      add the marked packages, or the highlighted one
+  q, esc     quit without adding anything
+
+Examples:
+  rfh browse
+  rfh browse security
+  rfh browse --target=cursor`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		return runBrowse(query)
+	},
+}
+
+func runBrowse(query string) error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("rfh browse needs an interactive terminal; use 'rfh search' instead")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	registryName, registry, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	model := newBrowseModel(c, registryName, query)
+	if model.target == "" {
+		model.target = registry.Defaults.Target
+	}
+	final, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("browse failed: %w", err)
+	}
+
+	chosen := final.(*browseModel).chosen
+	if len(chosen) == 0 {
+		return nil
+	}
+
+	var failed []string
+	for _, spec := range chosen {
+		if err := runAdd(spec, "", false); err != nil {
+			output.Printf("❌ %s: %v\n", spec, err)
+			failed = append(failed, spec)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to add %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// browseResultsMsg carries the results of a registry search
+type browseResultsMsg struct {
+	packages []client.Package
+	err      error
+}
+
+// browsePreviewMsg carries a fetched package preview
+type browsePreviewMsg struct {
+	name    string
+	preview *client.PackagePreview
+	err     error
+}
+
+// browseModel is the state of 'rfh browse'
+type browseModel struct {
+	client   client.RegistryClient
+	registry string
+	target   string // Target filter for every search
+
+	input     textinput.Model
+	searching bool // The query input has focus
+	loading   bool
+	err       error
+
+	packages []client.Package
+	cursor   int
+	marked   map[string]bool
+	previews map[string]browsePreviewMsg
+
+	width, height int
+
+	chosen []string // name@version specs to add once the program exits
+}
+
+func newBrowseModel(c client.RegistryClient, registryName, query string) *browseModel {
+	input := textinput.New()
+	input.Placeholder = "search packages"
+	input.Prompt = "🔍 "
+	input.SetValue(query)
+
+	return &browseModel{
+		client:   c,
+		registry: registryName,
+		target:   browseTarget,
+		input:    input,
+		loading:  true,
+		marked:   make(map[string]bool),
+		previews: make(map[string]browsePreviewMsg),
+		width:    100,
+		height:   30,
+	}
+}
+
+func (m *browseModel) Init() tea.Cmd {
+	return m.search()
+}
+
+// search runs the current query against the registry
+func (m *browseModel) search() tea.Cmd {
+	opts := client.SearchOptions{
+		Query:  strings.TrimSpace(m.input.Value()),
+		Tag:    browseTag,
+		Target: m.target,
+		Limit:  100,
+	}
+	return func() tea.Msg {
+		ctx, cancel := client.WithTimeout(commandContext)
+		defer cancel()
+
+		packages, err := m.client.SearchPackages(ctx, opts)
+		return browseResultsMsg{packages: packages, err: err}
+	}
+}
+
+// fetchPreview loads the highlighted package's preview unless it is already cached
+func (m *browseModel) fetchPreview() tea.Cmd {
+	if m.cursor >= len(m.packages) {
+		return nil
+	}
+	result := m.packages[m.cursor]
+	if _, ok := m.previews[result.Name]; ok {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := client.WithTimeout(commandContext)
+		defer cancel()
+
+		preview, err := m.client.GetPackagePreview(ctx, result.Name, result.Latest)
+		return browsePreviewMsg{name: result.Name, preview: preview, err: err}
+	}
+}
+
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case browseResultsMsg:
+		m.loading = false
+		m.err = msg.err
+		m.packages = msg.packages
+		m.cursor = 0
+		return m, m.fetchPreview()
+
+	case browsePreviewMsg:
+		m.previews[msg.name] = msg
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.chosen = nil
+			return m, tea.Quit
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.updateList(msg)
+	}
+
+	return m, nil
+}
+
+// updateSearch handles keys while the query input has focus
+func (m *browseModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searching = false
+		m.input.Blur()
+		m.loading = true
+		return m, m.search()
+	case tea.KeyEsc:
+		m.searching = false
+		m.input.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateList handles keys while the result list has focus
+func (m *browseModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.chosen = nil
+		return m, tea.Quit
+	case "/":
+		m.searching = true
+		return m, m.input.Focus()
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, m.fetchPreview()
+	case "down", "j":
+		if m.cursor < len(m.packages)-1 {
+			m.cursor++
+		}
+		return m, m.fetchPreview()
+	case " ":
+		if m.cursor < len(m.packages) {
+			name := m.packages[m.cursor].Name
+			m.marked[name] = !m.marked[name]
+		}
+		return m, nil
+	case "enter":
+		m.chosen = m.selection()
+		if len(m.chosen) == 0 {
+			return m, nil
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// selection returns the marked packages at their latest versions, in list order,
+// or the highlighted package if none are marked
+func (m *browseModel) selection() []string {
+	var specs []string
+	for _, result := range m.packages {
+		if m.marked[result.Name] {
+			specs = append(specs, fmt.Sprintf("%s@%s", result.Name, result.Latest))
+		}
+	}
+	if len(specs) == 0 && m.cursor < len(m.packages) {
+		result := m.packages[m.cursor]
+		specs = append(specs, fmt.Sprintf("%s@%s", result.Name, result.Latest))
+	}
+	return specs
+}
+
+var (
+	browseTitleStyle  = lipgloss.NewStyle().Bold(true)
+	browseCursorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	browseDimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	browsePaneStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	browseErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// browseListWidthPart gives the result list a third of the window width
+const browseListWidthPart = 3
+
+func (m *browseModel) View() string {
+	header := browseTitleStyle.Render(fmt.Sprintf("rfh browse — %s", m.registry))
+	footer := browseDimStyle.Render("/ search • ↑/↓ move • space mark • enter add • q quit")
+
+	// Header, input and footer lines plus the pane borders
+	paneHeight := m.height - 7
+	if paneHeight < 5 {
+		paneHeight = 5
+	}
+	listWidth := m.width/browseListWidthPart - 4
+	detailWidth := m.width - listWidth - 8
+	if listWidth < 20 {
+		listWidth = 20
+	}
+	if detailWidth < 20 {
+		detailWidth = 20
+	}
+
+	list := browsePaneStyle.Width(listWidth).Height(paneHeight).Render(m.renderList(paneHeight))
+	detail := browsePaneStyle.Width(detailWidth).Height(paneHeight).Render(m.renderDetail(detailWidth, paneHeight))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		m.input.View(),
+		lipgloss.JoinHorizontal(lipgloss.Top, list, detail),
+		footer,
+	)
+}
+
+// renderList renders the results, scrolled so the cursor stays visible
+func (m *browseModel) renderList(height int) string {
+	switch {
+	case m.loading:
+		return browseDimStyle.Render("Searching…")
+	case m.err != nil:
+		return browseErrorStyle.Render(fmt.Sprintf("Search failed: %v", m.err))
+	case len(m.packages) == 0:
+		return browseDimStyle.Render("No packages found")
+	}
+
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+
+	var lines []string
+	for i := start; i < len(m.packages) && i < start+height; i++ {
+		result := m.packages[i]
+		mark := "[ ]"
+		if m.marked[result.Name] {
+			mark = "[x]"
+		}
+		line := fmt.Sprintf("%s %s@%s", mark, result.Name, result.Latest)
+		if badges := packageBadges(result); badges != "" {
+			line += " " + badges
+		}
+		if i == m.cursor {
+			line = browseCursorStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDetail renders the highlighted package's details and rule file excerpts
+func (m *browseModel) renderDetail(width, height int) string {
+	if m.loading || m.cursor >= len(m.packages) {
+		return ""
+	}
+	result := m.packages[m.cursor]
+
+	var b strings.Builder
+	b.WriteString(browseTitleStyle.Render(fmt.Sprintf("%s@%s", result.Name, result.Latest)) + "\n")
+	if result.Description != "" {
+		b.WriteString(result.Description + "\n")
+	}
+	if badges := packageBadges(result); badges != "" {
+		b.WriteString(badges + "\n")
+	}
+	if len(result.Versions) > 1 {
+		b.WriteString(fmt.Sprintf("Versions: %s\n", strings.Join(result.Versions, ", ")))
+	}
+	if len(result.Tags) > 0 {
+		b.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(result.Tags, ", ")))
+	}
+	if result.License != "" {
+		b.WriteString(fmt.Sprintf("License: %s\n", result.License))
+	}
+	if len(result.Dependencies) > 0 {
+		b.WriteString(fmt.Sprintf("Depends on: %s\n", formatDependencies(result.Dependencies)))
+	}
+	b.WriteString("\n")
+
+	preview, ok := m.previews[result.Name]
+	switch {
+	case !ok:
+		b.WriteString(browseDimStyle.Render("Loading preview…"))
+	case preview.err != nil:
+		b.WriteString(browseDimStyle.Render(fmt.Sprintf("Preview unavailable: %v", preview.err)))
+	default:
+		if len(preview.preview.Targets) > 0 {
+			b.WriteString(fmt.Sprintf("Targets: %s\n\n", strings.Join(preview.preview.Targets, ", ")))
+		}
+		for _, file := range preview.preview.Files {
+			b.WriteString(browseCursorStyle.Render(file.Path) + "\n")
+			b.WriteString(file.Excerpt + "\n")
+			if file.Truncated {
+				b.WriteString(browseDimStyle.Render("…") + "\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	// Keep the pane within the window; lipgloss wraps long lines to the width
+	lines := strings.Split(lipgloss.NewStyle().Width(width).Render(b.String()), "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	browseCmd.Flags().StringVar(&browseTag, "tag", "", "filter by tag")
+	browseCmd.Flags().StringVar(&browseTarget, "target", "", "filter by target (cursor, claude-code, etc.)")
+}
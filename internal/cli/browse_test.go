@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"rulestack/internal/client"
+)
+
+func TestBrowseModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/packages":
+			json.NewEncoder(w).Encode([]client.Package{
+				{Name: "security-rules", Latest: "1.2.0", Description: "Secure coding"},
+				{Name: "api-rules", Latest: "0.3.0"},
+				{Name: "style-rules", Latest: "2.0.0"},
+			})
+		case strings.HasSuffix(r.URL.Path, "/preview"):
+			json.NewEncoder(w).Encode(client.PackagePreview{
+				Name:    "security-rules",
+				Version: r.URL.Query().Get("version"),
+				Files:   []client.FilePreview{{Path: "rules/secrets.mdc", Excerpt: "Never commit secrets."}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := newBrowseModel(client.NewHTTPClient(server.URL, "token", false), "corp", "rules")
+
+	// Drive a command to completion and feed its message back, as the program would
+	run := func(cmd tea.Cmd) {
+		t.Helper()
+		if cmd == nil {
+			t.Fatal("expected a command")
+		}
+		m.Update(cmd())
+	}
+	key := func(k string) tea.Cmd {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		}
+		_, cmd := m.Update(msg)
+		return cmd
+	}
+
+	run(m.Init())
+	if len(m.packages) != 3 || m.loading {
+		t.Fatalf("expected 3 results, got %+v", m.packages)
+	}
+
+	// The first result's preview is fetched when results arrive
+	run(m.fetchPreview())
+	if !strings.Contains(m.View(), "Never commit secrets.") {
+		t.Errorf("expected the preview excerpt in the detail pane, got:\n%s", m.View())
+	}
+
+	key(" ")
+	key("down")
+	key("down")
+	key(" ")
+	if cmd := key("enter"); cmd == nil {
+		t.Fatal("expected enter to quit with marked packages")
+	}
+
+	expected := []string{"security-rules@1.2.0", "style-rules@2.0.0"}
+	if !reflect.DeepEqual(m.chosen, expected) {
+		t.Errorf("chosen = %v, want %v", m.chosen, expected)
+	}
+
+	// Unmarking everything falls back to the highlighted package
+	m.marked = map[string]bool{}
+	if got := m.selection(); !reflect.DeepEqual(got, []string{"style-rules@2.0.0"}) {
+		t.Errorf("selection() = %v, want the highlighted package", got)
+	}
+
+	// Quitting discards the selection
+	key("q")
+	if m.chosen != nil {
+		t.Errorf("expected quitting to add nothing, got %v", m.chosen)
+	}
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/blobcache"
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/index"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+)
+
+var (
+	cacheWarmRegistry string        // Warm only this registry
+	cacheWarmTop      int           // Number of most used packages whose archives are fetched
+	cacheWarmDaemon   bool          // Keep warming until interrupted
+	cacheWarmInterval time.Duration // Time between warm passes in daemon mode
+)
+
+// cacheCmd manages rfh's local caches
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local registry cache",
+	Long: `Manage the clones of Git registries cached under ~/.rfh/cache/git.
+
+Installed package archives are also kept under ~/.rfh/cache/blobs, so that
+reinstalling them, or installing what 'rfh cache warm' fetched, needs no
+download.
+
+The Git registry cache is capped at 1 GiB by default. Once a registry has synced, the least
+recently used registries are evicted until the cache fits the cap again. Set
+the cap in bytes in ~/.rfh/config.toml, or a negative size to never evict:
+
+  [cache]
+  max_size = 2147483648`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show disk usage of cached registries",
+	Long: `Show how much disk each cached Git registry uses and when it was last used,
+most recently used first, against the cache's size cap.
+
+Examples:
+  rfh cache stats`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheStats()
+	},
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Refresh index snapshots and prefetch frequently used packages",
+	Long: `Sync the local index snapshot of each configured registry and download the
+archives of the most frequently installed packages, at the version last
+installed and the latest one, so later commands find them locally.
+
+Run it once, e.g. from cron, or with --daemon to keep refreshing every
+--interval until interrupted.
+
+Examples:
+  rfh cache warm
+  rfh cache warm --registry corp --top 50
+  rfh cache warm --daemon --interval 30m
+
+  # crontab: refresh every 15 minutes
+  */15 * * * * rfh cache warm --plain >/dev/null 2>&1`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cacheWarmDaemon {
+			return runCacheWarm()
+		}
+		if cacheWarmInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(commandContext, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		output.Printf("🔥 Warming the cache every %s (Ctrl+C to stop)\n", cacheWarmInterval)
+		for {
+			// A failed pass is reported and retried on the next one
+			if err := runCacheWarm(); err != nil {
+				output.Fprintf(os.Stderr, "❌ %v\n", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(cacheWarmInterval):
+			}
+		}
+	},
+}
+
+// runCacheWarm makes one pass over the registries to warm
+func runCacheWarm() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var names []string
+	if cacheWarmRegistry != "" {
+		if _, exists := cfg.Registries[cacheWarmRegistry]; !exists {
+			return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", cacheWarmRegistry)
+		}
+		names = append(names, cacheWarmRegistry)
+	} else {
+		for name := range cfg.Registries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no registries configured. Use 'rfh registry add' first")
+	}
+
+	var failed []error
+	for _, name := range names {
+		if err := warmRegistry(cfg, name); err != nil {
+			output.Printf("❌ %s: %v\n", name, err)
+			failed = append(failed, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to warm %d registr%s: %w", len(failed), pluralY(len(failed)), errors.Join(failed...))
+	}
+	return nil
+}
+
+// warmRegistry syncs a registry's index snapshot and prefetches the archives of
+// its most used packages
+func warmRegistry(cfg config.CLIConfig, name string) error {
+	registry := cfg.Registries[name]
+
+	c, err := client.GetClientForRegistry(cfg, name, verbose)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := index.Load(name, registry.URL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+	defer cancel()
+
+	changed, err := index.Sync(ctx, c, snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to sync index: %w", err)
+	}
+
+	frequent, err := blobcache.Frequent(name, cacheWarmTop)
+	if err != nil {
+		return err
+	}
+
+	fetched := 0
+	for _, use := range frequent {
+		for _, sha256 := range warmBlobs(snapshot, use) {
+			if blobcache.Has(sha256) {
+				continue
+			}
+			if verbose {
+				output.Printf("📥 Fetching %s@%s archive %s\n", use.Name, use.Version, sha256[:12])
+			}
+			if err := blobcache.Prefetch(ctx, c, sha256); err != nil {
+				return fmt.Errorf("failed to fetch %s: %w", use.Name, err)
+			}
+			fetched++
+		}
+	}
+
+	output.Printf("✅ %s: index synced (%d package(s) updated), %d archive(s) fetched\n", name, changed, fetched)
+	return nil
+}
+
+// warmBlobs returns the archives to keep cached for a frequently used package:
+// the version last installed and, if newer, the latest in the index
+func warmBlobs(snapshot *index.Snapshot, use blobcache.Use) []string {
+	blobs := []string{use.SHA256}
+
+	latest := snapshot.Packages[use.Name].Latest
+	if latest != "" && latest != use.Version {
+		if metadata := snapshot.Lookup(client.VersionRef{Name: use.Name, Version: latest}); metadata.Found && metadata.SHA256 != "" {
+			blobs = append(blobs, metadata.SHA256)
+		}
+	}
+	return blobs
+}
+
+func runCacheStats() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	root, err := client.GitCacheRoot()
+	if err != nil {
+		return fmt.Errorf("failed to locate registry cache: %w", err)
+	}
+
+	entries, err := client.ListGitCache()
+	if err != nil {
+		return err
+	}
+
+	output.Printf("🗄️  Registry cache: %s\n", root)
+	if len(entries) == 0 {
+		output.Printf("No registries cached.\n")
+		return nil
+	}
+
+	names := cachedRegistryNames(cfg)
+	var total int64
+	for _, entry := range entries {
+		name := names[filepath.Clean(entry.Dir)]
+		if name == "" {
+			name = "(not configured)"
+		}
+		total += entry.Size
+
+		output.Printf("   %-20s %10s   used %s ago\n", name, pkg.FormatSize(entry.Size), formatAge(time.Since(entry.LastUsed)))
+		if entry.URL != "" {
+			output.Printf("   %-20s %s\n", "", entry.URL)
+		}
+	}
+
+	maxSize := cfg.Cache.MaxSize
+	if maxSize == 0 {
+		maxSize = client.DefaultGitCacheMaxSize
+	}
+	if maxSize < 0 {
+		output.Printf("\nTotal: %s in %d registr%s, no size cap\n", pkg.FormatSize(total), len(entries), pluralY(len(entries)))
+	} else {
+		output.Printf("\nTotal: %s in %d registr%s, capped at %s\n", pkg.FormatSize(total), len(entries), pluralY(len(entries)), pkg.FormatSize(maxSize))
+	}
+	return nil
+}
+
+// cachedRegistryNames maps the cache directory of each configured Git registry
+// to its name
+func cachedRegistryNames(cfg config.CLIConfig) map[string]string {
+	names := make(map[string]string)
+	for name, registry := range cfg.Registries {
+		if registry.GetEffectiveType() != config.RegistryTypeGit {
+			continue
+		}
+		if dir, err := client.GitCacheDir(registry.URL); err == nil {
+			names[filepath.Clean(dir)] = name
+		}
+	}
+	return names
+}
+
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+func init() {
+	cacheWarmCmd.Flags().StringVar(&cacheWarmRegistry, "registry", "", "warm only this registry instead of all configured ones")
+	cacheWarmCmd.Flags().IntVar(&cacheWarmTop, "top", 20, "number of most frequently installed packages to prefetch")
+	cacheWarmCmd.Flags().BoolVar(&cacheWarmDaemon, "daemon", false, "keep warming every --interval until interrupted")
+	cacheWarmCmd.Flags().DurationVar(&cacheWarmInterval, "interval", 15*time.Minute, "time between refreshes with --daemon")
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+}
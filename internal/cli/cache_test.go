@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/blobcache"
+)
+
+func TestRunCacheWarm(t *testing.T) {
+	blobs := map[string][]byte{}
+	blobSHA := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		sha := hex.EncodeToString(sum[:])
+		blobs[sha] = []byte(content)
+		return sha
+	}
+	installed := blobSHA("archive 1.0.0")
+	latest := blobSHA("archive 1.1.0")
+
+	var downloads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/index":
+			fmt.Fprintf(w, `{"cursor": "c1", "full": true, "packages": [
+				{"name": "security-rules", "latest": "1.1.0", "versions": [
+					{"version": "1.0.0", "sha256": %q}, {"version": "1.1.0", "sha256": %q}]}]}`, installed, latest)
+		case strings.HasPrefix(r.URL.Path, "/v1/blobs/"):
+			sha := strings.TrimPrefix(r.URL.Path, "/v1/blobs/")
+			downloads = append(downloads, sha)
+			w.Write(blobs[sha])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	t.Setenv("HOME", t.TempDir())
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	// An earlier install of 1.0.0 makes security-rules frequently used
+	if err := blobcache.RecordUse("corp", "security-rules", "1.0.0", installed); err != nil {
+		t.Fatalf("failed to record use: %v", err)
+	}
+
+	cacheWarmTop = 20
+	if err := runCacheWarm(); err != nil {
+		t.Fatalf("warm failed: %v", err)
+	}
+	if len(downloads) != 2 || !blobcache.Has(installed) || !blobcache.Has(latest) {
+		t.Fatalf("expected the installed and latest archives to be fetched, got %v", downloads)
+	}
+
+	if err := runCacheWarm(); err != nil {
+		t.Fatalf("second warm failed: %v", err)
+	}
+	if len(downloads) != 2 {
+		t.Errorf("expected cached archives not to be fetched again, got %v", downloads)
+	}
+
+	// Installs take warmed archives from the cache
+	source := registrySource{Name: "corp"}
+	dest := filepath.Join(t.TempDir(), "security-rules.tgz")
+	if err := source.downloadPackage(commandContext, "security-rules", "1.1.0", latest, 0, dest); err != nil {
+		t.Fatalf("download from cache failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "archive 1.1.0" {
+		t.Errorf("unexpected archive %q", data)
+	}
+}
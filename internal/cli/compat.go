@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+	"rulestack/internal/version"
+)
+
+// coreRulesPrefix is the directory prefix of the core rules installed by 'rfh init'
+const coreRulesPrefix = "core.v"
+
+// loadProjectEnvironment describes the project for checking package requirements:
+// its targets, installed core rules and the dependency versions it declares,
+// including those from its base configuration
+func loadProjectEnvironment(projectRoot string) (manifest.Environment, error) {
+	projectManifest, err := loadOrCreateProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectRoot)
+	if err != nil {
+		return manifest.Environment{}, fmt.Errorf("failed to load project manifest: %w", err)
+	}
+	if err := applyInherited(projectRoot, projectManifest); err != nil {
+		return manifest.Environment{}, err
+	}
+
+	return manifest.Environment{
+		Targets:  projectManifest.Targets,
+		Core:     installedCoreVersion(filepath.Join(projectRoot, ".rulestack")),
+		Packages: projectManifest.ResolvedDependencies(),
+	}, nil
+}
+
+// installedCoreVersion returns the highest core rules version in .rulestack/, or
+// an empty string when none is installed
+func installedCoreVersion(rulestackDir string) string {
+	entries, err := os.ReadDir(rulestackDir)
+	if err != nil {
+		return ""
+	}
+
+	highest := ""
+	for _, entry := range entries {
+		candidate, ok := strings.CutPrefix(entry.Name(), coreRulesPrefix)
+		if !isPackageDir(rulestackDir, entry) || !ok || !version.IsValidVersion(candidate) {
+			continue
+		}
+		if cmp, err := version.CompareVersions(candidate, highest); highest == "" || (err == nil && cmp > 0) {
+			highest = candidate
+		}
+	}
+
+	return highest
+}
+
+// checkCompatibility verifies a package's declared requirements against the project
+func checkCompatibility(projectRoot, name, ver string, requires *manifest.Requirements) error {
+	if requires == nil {
+		return nil
+	}
+
+	env, err := loadProjectEnvironment(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	if problems := requires.Check(env); len(problems) > 0 {
+		return fmt.Errorf("%s@%s: %w", name, ver, errors.Join(problems...))
+	}
+
+	return nil
+}
+
+// checkLicensePolicy verifies a package's license against the licenses the
+// project's constraints allow
+func checkLicensePolicy(projectRoot, name, ver, license string) error {
+	projectManifest, err := loadOrCreateProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	constraints, err := loadProjectConstraints(projectRoot, projectManifest)
+	if err != nil || constraints == nil {
+		return err
+	}
+
+	return constraints.CheckLicense(name, ver, license)
+}
+
+// archiveManifest reads the manifest inside a package archive. Archives without
+// a manifest get an empty one, declaring no requirements and no license.
+func archiveManifest(archivePath string) (*manifest.PackageManifest, error) {
+	data, err := pkg.ExtractManifest(archivePath)
+	if err != nil {
+		return &manifest.PackageManifest{}, nil
+	}
+
+	var packageManifest manifest.PackageManifest
+	if err := json.Unmarshal(data, &packageManifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package manifest: %w", err)
+	}
+
+	if packageManifest.Requires != nil {
+		if err := packageManifest.Requires.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid package requirements: %w", err)
+		}
+	}
+
+	return &packageManifest, nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	projectRoot := t.TempDir()
+	for _, dir := range []string{"core.v1.0.0", "core.v1.2.0", "security-rules.1.0.0"} {
+		if err := os.MkdirAll(filepath.Join(projectRoot, ".rulestack", dir), 0755); err != nil {
+			t.Fatalf("Failed to create package dir: %v", err)
+		}
+	}
+	manifestContent := `{"version": "1.0.0", "dependencies": {"security-rules": "1.0.0"}, "targets": ["cursor"]}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	if got := installedCoreVersion(filepath.Join(projectRoot, ".rulestack")); got != "1.2.0" {
+		t.Errorf("expected the highest core version 1.2.0, got %q", got)
+	}
+
+	compatible := &manifest.Requirements{Targets: []string{"cursor"}, Core: "1.1.0", Peers: map[string]string{"security-rules": "1.0.0"}}
+	if err := checkCompatibility(projectRoot, "api-rules", "1.0.0", compatible); err != nil {
+		t.Errorf("expected compatible requirements to pass, got %v", err)
+	}
+
+	incompatible := &manifest.Requirements{Targets: []string{"claude-code"}, Core: "2.0.0"}
+	err := checkCompatibility(projectRoot, "api-rules", "2.0.0", incompatible)
+	if err == nil || !strings.HasPrefix(err.Error(), "api-rules@2.0.0: ") || strings.Count(err.Error(), "\n") != 1 {
+		t.Errorf("expected both unmet requirements reported for api-rules@2.0.0, got %v", err)
+	}
+}
+
+func TestCheckLicensePolicy(t *testing.T) {
+	projectRoot := t.TempDir()
+	manifestContent := `{"version": "1.0.0", "dependencies": {}, "constraints": "constraints.json"}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, "constraints.json"), []byte(`{"allowed_licenses": ["MIT", "Apache-2.0"]}`), 0644); err != nil {
+		t.Fatalf("Failed to write constraints: %v", err)
+	}
+
+	if err := checkLicensePolicy(projectRoot, "api-rules", "1.0.0", "MIT OR GPL-3.0-only"); err != nil {
+		t.Errorf("expected an allowed license to pass, got %v", err)
+	}
+
+	err := checkLicensePolicy(projectRoot, "api-rules", "2.0.0", "GPL-3.0-only")
+	if !errors.Is(err, manifest.ErrConstraintViolation) {
+		t.Errorf("expected a constraint violation for GPL-3.0-only, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rulestack/internal/output"
+)
+
+// RuleConflict describes rules from different installed packages that clash
+type RuleConflict struct {
+	Kind     string   // "duplicate-id" or "duplicate-content"
+	Key      string   // Rule ID or content hash the conflict was detected on
+	Packages []string // Packages defining the rule, sorted by name
+	Files    []string // Rule files involved, relative to .rulestack
+	Winner   string   // Package that takes precedence per manifest priority ("" if unresolved)
+}
+
+// installedRule is a single rule file discovered in an installed package
+type installedRule struct {
+	Package string
+	File    string
+	ID      string
+	Hash    string
+}
+
+// detectRuleConflicts scans installed dependencies for rules that share a
+// frontmatter ID or have the same normalized content
+func detectRuleConflicts(projectRoot string, dependencies map[string]string, priority []string) ([]RuleConflict, error) {
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+
+	var rules []installedRule
+	for packageName := range dependencies {
+		_, packageDir, err := findInstalledPackage(rulestackDir, packageName)
+		if err != nil {
+			continue // Not installed, nothing to compare
+		}
+
+		ruleFiles, err := findRuleFiles(packageDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find rule files in %s: %w", packageName, err)
+		}
+
+		for _, ruleFile := range ruleFiles {
+			content, err := os.ReadFile(filepath.Join(packageDir, ruleFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rule file %s: %w", ruleFile, err)
+			}
+
+			rules = append(rules, installedRule{
+				Package: packageName,
+				File:    filepath.ToSlash(filepath.Join(filepath.Base(packageDir), ruleFile)),
+				ID:      parseRuleID(string(content)),
+				Hash:    hashRuleContent(string(content)),
+			})
+		}
+	}
+
+	byID := make(map[string][]installedRule)
+	byHash := make(map[string][]installedRule)
+	for _, rule := range rules {
+		if rule.ID != "" {
+			byID[rule.ID] = append(byID[rule.ID], rule)
+		}
+		if rule.Hash != "" {
+			byHash[rule.Hash] = append(byHash[rule.Hash], rule)
+		}
+	}
+
+	var conflicts []RuleConflict
+	conflicts = append(conflicts, collectConflicts("duplicate-id", byID, priority)...)
+	conflicts = append(conflicts, collectConflicts("duplicate-content", byHash, priority)...)
+
+	return conflicts, nil
+}
+
+// collectConflicts turns grouped rules into conflicts when more than one package is involved
+func collectConflicts(kind string, groups map[string][]installedRule, priority []string) []RuleConflict {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var conflicts []RuleConflict
+	for _, key := range keys {
+		group := groups[key]
+
+		packageSet := make(map[string]bool)
+		var files []string
+		for _, rule := range group {
+			packageSet[rule.Package] = true
+			files = append(files, rule.File)
+		}
+
+		// Rules repeated within a single package are the author's business
+		if len(packageSet) < 2 {
+			continue
+		}
+
+		packages := make([]string, 0, len(packageSet))
+		for name := range packageSet {
+			packages = append(packages, name)
+		}
+		sort.Strings(packages)
+		sort.Strings(files)
+
+		conflicts = append(conflicts, RuleConflict{
+			Kind:     kind,
+			Key:      key,
+			Packages: packages,
+			Files:    files,
+			Winner:   resolveConflictWinner(packages, priority),
+		})
+	}
+
+	return conflicts
+}
+
+// resolveConflictWinner returns the first package in priority order that is part of the conflict
+func resolveConflictWinner(packages []string, priority []string) string {
+	for _, name := range priority {
+		for _, pkgName := range packages {
+			if pkgName == name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// parseRuleID extracts the id field from a rule file's YAML frontmatter
+func parseRuleID(content string) string {
+	frontmatter, _ := splitFrontmatter(content)
+	for _, line := range strings.Split(frontmatter, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) != "id" {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return ""
+}
+
+// hashRuleContent hashes the rule body with frontmatter removed and whitespace and
+// case normalized, so trivially reformatted copies of a rule hash the same
+func hashRuleContent(content string) string {
+	_, body := splitFrontmatter(content)
+	normalized := strings.ToLower(strings.Join(strings.Fields(body), " "))
+	if normalized == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitFrontmatter separates a leading "---" delimited frontmatter block from the body
+func splitFrontmatter(content string) (string, string) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end == -1 {
+		return "", content
+	}
+
+	body := rest[end+len("\n---"):]
+	return rest[:end], strings.TrimPrefix(body, "\n")
+}
+
+// reportRuleConflicts prints detected rule conflicts and how they are resolved
+func reportRuleConflicts(conflicts []RuleConflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	output.Printf("\n⚠️  Rule conflicts detected (%d):\n", len(conflicts))
+
+	unresolved := 0
+	for _, conflict := range conflicts {
+		switch conflict.Kind {
+		case "duplicate-id":
+			output.Printf("⚠️  Rule ID '%s' is defined by: %s\n", conflict.Key, strings.Join(conflict.Packages, ", "))
+		default:
+			output.Printf("⚠️  Duplicate rule content in: %s\n", strings.Join(conflict.Packages, ", "))
+		}
+
+		for _, file := range conflict.Files {
+			output.Printf("   - %s\n", file)
+		}
+
+		if conflict.Winner != "" {
+			output.Printf("   ✅ Resolved by priority: %s takes precedence\n", conflict.Winner)
+		} else {
+			unresolved++
+		}
+	}
+
+	if unresolved > 0 {
+		output.Printf("💡 Add a \"priority\" list to rulestack.json to choose which package wins, e.g. \"priority\": [\"%s\"]\n", conflicts[0].Packages[0])
+	}
+}
+
+// checkRuleConflicts detects and reports rule conflicts for the project's dependencies
+func checkRuleConflicts(projectRoot string, dependencies map[string]string, priority []string) {
+	conflicts, err := detectRuleConflicts(projectRoot, dependencies, priority)
+	if err != nil {
+		if verbose {
+			output.Printf("⚠️ Warning: Failed to check for rule conflicts: %v\n", err)
+		}
+		return
+	}
+
+	reportRuleConflicts(conflicts)
+}
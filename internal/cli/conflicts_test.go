@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeInstalledRule creates a rule file inside an installed package directory
+func writeInstalledRule(t *testing.T, projectRoot, packageDir, fileName, content string) {
+	t.Helper()
+
+	dir := filepath.Join(projectRoot, ".rulestack", packageDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create package dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule file: %v", err)
+	}
+}
+
+func TestParseRuleID(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"frontmatter id", "---\nid: no-secrets\ntitle: x\n---\n# Rule", "no-secrets"},
+		{"quoted id", "---\nid: \"no-secrets\"\n---\nbody", "no-secrets"},
+		{"no frontmatter", "# Rule\nid: not-frontmatter", ""},
+		{"frontmatter without id", "---\ntitle: x\n---\nbody", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRuleID(tt.content); got != tt.expected {
+				t.Errorf("parseRuleID() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHashRuleContent_IgnoresFormatting(t *testing.T) {
+	a := hashRuleContent("---\nid: a\n---\n# Never   commit secrets\n")
+	b := hashRuleContent("# never commit\r\nsecrets")
+	if a != b {
+		t.Errorf("Expected near-duplicate content to hash the same, got %s and %s", a, b)
+	}
+
+	if hashRuleContent("# Something else") == a {
+		t.Error("Expected different content to hash differently")
+	}
+}
+
+func TestDetectRuleConflicts(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	writeInstalledRule(t, projectRoot, "alpha.1.0.0", "secrets.md", "---\nid: no-secrets\n---\nNever commit secrets.")
+	writeInstalledRule(t, projectRoot, "beta.2.0.0", "secrets.md", "---\nid: no-secrets\n---\nSecrets are fine in tests.")
+	writeInstalledRule(t, projectRoot, "beta.2.0.0", "style.md", "Use tabs.")
+	writeInstalledRule(t, projectRoot, "gamma.1.0.0", "style.md", "use   TABS.")
+	writeInstalledRule(t, projectRoot, "unlisted.1.0.0", "secrets.md", "---\nid: no-secrets\n---\nIgnored.")
+
+	dependencies := map[string]string{
+		"alpha": "1.0.0",
+		"beta":  "2.0.0",
+		"gamma": "1.0.0",
+	}
+
+	conflicts, err := detectRuleConflicts(projectRoot, dependencies, []string{"beta"})
+	if err != nil {
+		t.Fatalf("detectRuleConflicts failed: %v", err)
+	}
+
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %d: %+v", len(conflicts), conflicts)
+	}
+
+	idConflict := conflicts[0]
+	if idConflict.Kind != "duplicate-id" || idConflict.Key != "no-secrets" {
+		t.Errorf("Expected duplicate-id conflict on 'no-secrets', got %+v", idConflict)
+	}
+	if len(idConflict.Packages) != 2 || idConflict.Packages[0] != "alpha" || idConflict.Packages[1] != "beta" {
+		t.Errorf("Expected packages [alpha beta], got %v", idConflict.Packages)
+	}
+	if idConflict.Winner != "beta" {
+		t.Errorf("Expected beta to win by priority, got %q", idConflict.Winner)
+	}
+
+	contentConflict := conflicts[1]
+	if contentConflict.Kind != "duplicate-content" {
+		t.Errorf("Expected duplicate-content conflict, got %+v", contentConflict)
+	}
+	if contentConflict.Winner != "beta" {
+		t.Errorf("Expected beta to win by priority, got %q", contentConflict.Winner)
+	}
+}
+
+func TestDetectRuleConflicts_Unresolved(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	writeInstalledRule(t, projectRoot, "alpha.1.0.0", "a.md", "---\nid: shared\n---\nOne.")
+	writeInstalledRule(t, projectRoot, "beta.1.0.0", "b.md", "---\nid: shared\n---\nTwo.")
+
+	conflicts, err := detectRuleConflicts(projectRoot, map[string]string{"alpha": "1.0.0", "beta": "1.0.0"}, nil)
+	if err != nil {
+		t.Fatalf("detectRuleConflicts failed: %v", err)
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %d", len(conflicts))
+	}
+	if conflicts[0].Winner != "" {
+		t.Errorf("Expected unresolved conflict without priority, got winner %q", conflicts[0].Winner)
+	}
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// corePackageName is the registry package the core rules are published as. The
+// core/ directory is its package source, published with 'rfh publish' for every
+// template version.
+const corePackageName = "core"
+
+// bundledCore is the core package built into rfh, installed by 'rfh init' and by
+// 'rfh install' when no registry serves the template's core version
+//
+//go:embed core
+var bundledCore embed.FS
+
+// coreRules is the core_rules.md of the current template version
+//
+//go:embed core/core_rules.md
+var coreRules string
+
+// packageDirName returns the .rulestack/ directory a package version is installed
+// in. The core rules keep the core.v<version> name they have always had.
+func packageDirName(name, version string) string {
+	if name == corePackageName {
+		return coreRulesPrefix + version
+	}
+	return fmt.Sprintf("%s.%s", name, version)
+}
+
+// isBundledCore reports whether a dependency can be installed from the core
+// package built into rfh
+func isBundledCore(name, version string) bool {
+	return name == corePackageName && version == projectTemplateVersion
+}
+
+// installBundledCore writes the core package built into rfh to .rulestack/ and
+// records it in rulestack.lock.json. The bundled copy ships with rfh itself, so
+// it is never quarantined. A dry run only stages it.
+func installBundledCore(projectRoot string) error {
+	packageDir := packageInstallDir(projectRoot, packageDirName(corePackageName, projectTemplateVersion))
+	if err := os.RemoveAll(packageDir); err != nil {
+		return fmt.Errorf("failed to clear previous core rules: %w", err)
+	}
+
+	files := make(map[string]string)
+	err := fs.WalkDir(bundledCore, "core", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := bundledCore.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		relPath := strings.TrimPrefix(name, "core/")
+		target := filepath.Join(packageDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+		files[path.Clean(relPath)] = fmt.Sprintf("%x", sha256.Sum256(data))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write core rules: %w", err)
+	}
+
+	if previewingInstall() {
+		return switchCoreRules(projectRoot, projectTemplateVersion)
+	}
+
+	contentHash, err := hashDirectoryContents(packageDir)
+	if err != nil {
+		return fmt.Errorf("failed to hash core rules: %w", err)
+	}
+
+	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
+	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+	lockManifest.Packages[corePackageName] = LockPackageEntry{
+		Version: projectTemplateVersion,
+		SHA256:  contentHash,
+		Files:   files,
+	}
+	if err := saveLockManifest(lockPath, lockManifest); err != nil {
+		return fmt.Errorf("failed to save lock manifest: %w", err)
+	}
+
+	return switchCoreRules(projectRoot, projectTemplateVersion)
+}
+
+// switchCoreRules points CLAUDE.md's core rules import at a newly installed core
+// version and removes every other one, so only one set of core rules is active
+func switchCoreRules(projectRoot, version string) error {
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	entries, err := os.ReadDir(rulestackDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read .rulestack directory: %w", err)
+	}
+
+	oldImports := make(map[string]bool)
+	for _, entry := range entries {
+		other, ok := strings.CutPrefix(entry.Name(), coreRulesPrefix)
+		if !isPackageDir(rulestackDir, entry) || !ok || other == version {
+			continue
+		}
+		oldImports[coreRulesImport(other)] = true
+		// A preview only shows the CLAUDE.md change
+		if editorPreview != nil {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(rulestackDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove core rules %s: %w", other, err)
+		}
+	}
+	if len(oldImports) == 0 {
+		return nil
+	}
+
+	claudePath := filepath.Join(projectRoot, "CLAUDE.md")
+	content, err := readEditorFile(claudePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	}
+
+	newImport := coreRulesImport(version)
+	imported := strings.Contains(string(content), newImport)
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if oldImports[strings.TrimSpace(line)] {
+			if imported {
+				continue
+			}
+			line = newImport
+			imported = true
+		}
+		lines = append(lines, line)
+	}
+
+	if err := writeEditorFile(claudePath, []byte(strings.Join(lines, "\n"))); err != nil {
+		return fmt.Errorf("failed to update CLAUDE.md: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestBundledCoreManifest(t *testing.T) {
+	data, err := bundledCore.ReadFile("core/rulestack.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "rulestack.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := manifest.LoadPackageManifests(path)
+	if err != nil {
+		t.Fatalf("bundled core manifest is invalid: %v", err)
+	}
+	if manifests[0].Name != corePackageName || manifests[0].Version != projectTemplateVersion {
+		t.Errorf("bundled core is %s@%s, want %s@%s", manifests[0].Name, manifests[0].Version, corePackageName, projectTemplateVersion)
+	}
+}
+
+func TestInitInstallsBundledCore(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	projectRoot := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+
+	if err := runInit(false, false); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectManifest.Dependencies[corePackageName] != projectTemplateVersion {
+		t.Errorf("core dependency = %q, want %q", projectManifest.Dependencies[corePackageName], projectTemplateVersion)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := lockManifest.Packages[corePackageName]; entry.Version != projectTemplateVersion || entry.Files["core_rules.md"] != knownCoreRules[projectTemplateVersion] {
+		t.Errorf("core lock entry = %+v", entry)
+	}
+
+	// The core rules are verified like any other package
+	if err := runVerify(); err != nil {
+		t.Errorf("verify failed on a fresh project: %v", err)
+	}
+
+	// and are already installed as far as 'rfh install' is concerned
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requirements) != 1 || requirements[0].Action != "skip" {
+		t.Errorf("requirements = %+v, want core skipped", requirements)
+	}
+}
+
+func TestInstallBundledCoreWithoutRegistry(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	projectRoot := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+
+	projectManifest := manifest.CreateProjectManifest()
+	projectManifest.Dependencies[corePackageName] = projectTemplateVersion
+	if err := manifest.SaveProjectManifest("rulestack.json", projectManifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("CLAUDE.md", []byte(claudeTemplate()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runInstall(); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectRoot, coreRulesDir(projectTemplateVersion), "core_rules.md"))
+	if err != nil || string(data) != coreRules {
+		t.Errorf("core rules not installed: %v", err)
+	}
+}
+
+func TestSwitchCoreRules(t *testing.T) {
+	projectRoot := t.TempDir()
+	for _, dir := range []string{coreRulesDir("1.0.0"), coreRulesDir("1.2.0"), filepath.Join(".rulestack", "security-rules.1.0.0")} {
+		if err := os.MkdirAll(filepath.Join(projectRoot, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	claude := "# CLAUDE.md\n\n### Active Rules (Rulestack core)\n" + coreRulesImport("1.0.0") + "\n- @.rulestack/security-rules.1.0.0/secrets.md\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, "CLAUDE.md"), []byte(claude), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := switchCoreRules(projectRoot, "1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(projectRoot, coreRulesDir("1.0.0"))); !os.IsNotExist(err) {
+		t.Error("old core rules not removed")
+	}
+	for _, dir := range []string{coreRulesDir("1.2.0"), filepath.Join(".rulestack", "security-rules.1.0.0")} {
+		if _, err := os.Stat(filepath.Join(projectRoot, dir)); err != nil {
+			t.Errorf("%s removed: %v", dir, err)
+		}
+	}
+
+	updated, _ := os.ReadFile(filepath.Join(projectRoot, "CLAUDE.md"))
+	expected := strings.Replace(claude, coreRulesImport("1.0.0"), coreRulesImport("1.2.0"), 1)
+	if string(updated) != expected {
+		t.Errorf("CLAUDE.md =\n%s\nwant\n%s", updated, expected)
+	}
+}
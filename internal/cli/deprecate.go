@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// deprecateCmd represents the deprecate command
+var deprecateCmd = &cobra.Command{
+	Use:   "deprecate <package>[@<version>]",
+	Short: "Mark a published package version as deprecated",
+	Long: `Mark a published package version, or every published version of a package,
+as deprecated on the active registry.
+
+Deprecated versions can still be installed, but rfh add, rfh install and
+rfh outdated show the message to everyone who depends on them. With
+--replacement, the deprecation also names the package and version range to use
+instead, which 'rfh migrate-deps' applies to projects. Only the package's
+publisher or an admin can deprecate a version. Deprecation is only available on
+HTTP registries.
+
+Examples:
+  rfh deprecate security-rules@1.0.0 --message "Use 2.x, 1.x misses the new checks"
+  rfh deprecate security-rules --replacement secure-coding-rules@^2
+  rfh deprecate security-rules@1.0.0 --undo`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePackageRefs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		message, _ := cmd.Flags().GetString("message")
+		replacement, _ := cmd.Flags().GetString("replacement")
+		undo, _ := cmd.Flags().GetBool("undo")
+		return runDeprecate(args[0], message, replacement, undo)
+	},
+}
+
+// runDeprecate implements the deprecate command logic
+func runDeprecate(spec, message, replacement string, undo bool) error {
+	name, version := spec, ""
+	if strings.Contains(spec, "@") {
+		pkgRef, err := parsePackageRef(spec)
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+		name, version = pkgRef.Name, pkgRef.Version
+	} else if err := manifest.ValidateName(spec); err != nil {
+		return fmt.Errorf("invalid package reference: %w", err)
+	}
+	target := spec
+	if version == "" {
+		target = "every version of " + name
+	}
+
+	if undo {
+		message, replacement = "", ""
+	} else if message == "" && replacement == "" {
+		return fmt.Errorf("--message or --replacement is required (or use --undo to remove a deprecation)")
+	}
+	if replacement != "" {
+		if _, _, err := manifest.ParseReplacement(replacement); err != nil {
+			return err
+		}
+		if message == "" {
+			message = "Use " + replacement + " instead"
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	if err := c.DeprecatePackage(ctx, name, version, message, replacement); err != nil {
+		return fmt.Errorf("failed to deprecate %s: %w", spec, err)
+	}
+
+	if undo {
+		output.Printf("✅ Removed deprecation of %s\n", target)
+	} else {
+		output.Printf("⚠️  Deprecated %s: %s\n", target, message)
+		if replacement != "" {
+			output.Printf("💡 Replacement: %s\n", replacement)
+		}
+	}
+	return nil
+}
+
+// printDeprecation warns that a version being installed is deprecated, pointing
+// to its replacement if the publisher named one
+func printDeprecation(name, version, message, replacement string) {
+	output.Printf("⚠️  %s@%s is deprecated: %s\n", name, version, message)
+	if replacement != "" {
+		output.Printf("   💡 Replace it with %s by running 'rfh migrate-deps'\n", replacement)
+	}
+}
+
+func init() {
+	deprecateCmd.Flags().String("message", "", "Why the version is deprecated and what to use instead")
+	deprecateCmd.Flags().String("replacement", "", "Package and version range to use instead, as name@range (e.g. other-rules@^2)")
+	deprecateCmd.Flags().Bool("undo", false, "Remove the deprecation")
+}
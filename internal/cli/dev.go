@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/security"
+	"rulestack/internal/tempdir"
+)
+
+var (
+	devProjects     []string
+	devPackageName  string
+	devPollInterval time.Duration
+)
+
+// devCmd represents the dev command
+var devCmd = &cobra.Command{
+	Use:   "dev [path]",
+	Short: "Watch a package source and rebuild on change",
+	Long: `Watch a package source directory (default: current directory) and, whenever a
+file changes:
+
+- Re-validates the package manifest and rule files
+- Re-packs the package into .rulestack/dev/ in the source directory
+- Refreshes CLAUDE.md in each consuming project linked with 'rfh link'
+
+Press Ctrl+C to stop.
+
+Examples:
+  rfh dev
+  rfh dev ../my-rules --project ../my-app
+  rfh dev --project ../app-one --project ../app-two --interval 2s`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourcePath := "."
+		if len(args) == 1 {
+			sourcePath = args[0]
+		}
+		return runDev(sourcePath)
+	},
+}
+
+// runDev implements the dev command logic
+func runDev(sourcePath string) error {
+	sourceDir, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source path: %w", err)
+	}
+
+	projects := make([]string, 0, len(devProjects))
+	for _, project := range devProjects {
+		projectRoot, err := filepath.Abs(project)
+		if err != nil {
+			return fmt.Errorf("failed to resolve project path: %w", err)
+		}
+		projects = append(projects, projectRoot)
+	}
+
+	ctx, stop := signal.NotifyContext(commandContext, os.Interrupt)
+	defer stop()
+
+	output.Printf("👀 Watching %s (Ctrl+C to stop)\n", sourceDir)
+
+	snapshot, err := snapshotSourceDir(sourceDir)
+	if err != nil {
+		return err
+	}
+	devRebuild(sourceDir, projects)
+
+	ticker := time.NewTicker(devPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			output.Printf("\n👋 Stopped watching\n")
+			return nil
+		case <-ticker.C:
+			current, err := snapshotSourceDir(sourceDir)
+			if err != nil {
+				output.Printf("❌ %v\n", err)
+				continue
+			}
+			if snapshotsEqual(snapshot, current) {
+				continue
+			}
+			snapshot = current
+
+			output.Printf("\n🔄 Change detected at %s\n", time.Now().Format("15:04:05"))
+			devRebuild(sourceDir, projects)
+		}
+	}
+}
+
+// devRebuild runs one build cycle and reports the outcome without stopping the watch loop
+func devRebuild(sourceDir string, projects []string) {
+	packageManifest, err := buildDevPackage(sourceDir, devPackageName)
+	if err != nil {
+		output.Printf("❌ %v\n", err)
+		return
+	}
+
+	for _, projectRoot := range projects {
+		if err := refreshLinkedProject(projectRoot, sourceDir, packageManifest); err != nil {
+			output.Printf("⚠️  %s: %v\n", projectRoot, err)
+			continue
+		}
+		output.Printf("📝 Refreshed %s\n", projectRoot)
+	}
+}
+
+// buildDevPackage validates and packs the package source, returning its manifest
+func buildDevPackage(sourceDir, packageName string) (*manifest.PackageManifest, error) {
+	packageManifest, err := selectLinkManifest(sourceDir, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	stageDir, err := tempdir.MkdirTemp("dev-stage-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	if err := stageSourceFiles(sourceDir, stageDir, packageManifest); err != nil {
+		return nil, err
+	}
+
+	devDir := filepath.Join(sourceDir, ".rulestack", "dev")
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dev directory: %w", err)
+	}
+
+	archivePath := filepath.Join(devDir, fmt.Sprintf("%s-%s.tgz", packageManifest.Name, packageManifest.Version))
+	info, err := pkg.PackFromDirectory(stageDir, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack: %w", err)
+	}
+
+	if err := security.NewPackageValidator(nil).ValidateArchive(archivePath, stageDir); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	output.Printf("✅ %s@%s packed (%d bytes)\n", packageManifest.Name, packageManifest.Version, info.SizeBytes)
+	return packageManifest, nil
+}
+
+// refreshLinkedProject re-points a consuming project's link at the current source
+// version and rewrites its CLAUDE.md rule references
+func refreshLinkedProject(projectRoot, sourceDir string, packageManifest *manifest.PackageManifest) error {
+	links, err := loadLinks(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	linked, ok := links[packageManifest.Name]
+	if !ok || linked.Path != sourceDir {
+		return fmt.Errorf("%s is not linked to this source. Run 'rfh link %s' in the project", packageManifest.Name, sourceDir)
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	oldDirName := fmt.Sprintf("%s.%s", packageManifest.Name, linked.Version)
+	newDirName := fmt.Sprintf("%s.%s", packageManifest.Name, packageManifest.Version)
+
+	// Version bumps change the directory name, so move the link along with it
+	if oldDirName != newDirName {
+		if err := os.Remove(filepath.Join(rulestackDir, oldDirName)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old link: %w", err)
+		}
+		if err := createDirLink(sourceDir, filepath.Join(rulestackDir, newDirName)); err != nil {
+			return fmt.Errorf("failed to relink: %w", err)
+		}
+
+		linked.Version = packageManifest.Version
+		links[packageManifest.Name] = linked
+		if err := saveLinks(projectRoot, links); err != nil {
+			return err
+		}
+	}
+
+	// Drop stale references (renamed or deleted files) before re-adding current ones
+	if err := removeClaudeRules(projectRoot, oldDirName); err != nil {
+		return err
+	}
+
+	return updateClaudeFile(projectRoot, &PackageRef{Name: packageManifest.Name, Version: packageManifest.Version})
+}
+
+// sourceFileState captures what the watcher compares between polls
+type sourceFileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// snapshotSourceDir records the state of every file in a package source,
+// skipping version control and rulestack working directories
+func snapshotSourceDir(sourceDir string) (map[string]sourceFileState, error) {
+	snapshot := make(map[string]sourceFileState)
+
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if name := info.Name(); path != sourceDir && (name == ".git" || name == ".rulestack") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		snapshot[path] = sourceFileState{ModTime: info.ModTime(), Size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", sourceDir, err)
+	}
+
+	return snapshot, nil
+}
+
+// snapshotsEqual reports whether two source snapshots describe the same files
+func snapshotsEqual(a, b map[string]sourceFileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		other, ok := b[path]
+		if !ok || !other.ModTime.Equal(state.ModTime) || other.Size != state.Size {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	devCmd.Flags().StringArrayVar(&devProjects, "project", nil, "consuming project to refresh (repeatable)")
+	devCmd.Flags().StringVar(&devPackageName, "package", "", "package to build when the source defines several")
+	devCmd.Flags().DurationVar(&devPollInterval, "interval", time.Second, "how often to check for changes")
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSourceDir(t *testing.T) {
+	sourceDir := t.TempDir()
+	rulePath := filepath.Join(sourceDir, "style.md")
+	if err := os.WriteFile(rulePath, []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	// Working directories must not trigger rebuilds
+	if err := os.MkdirAll(filepath.Join(sourceDir, ".rulestack", "dev"), 0755); err != nil {
+		t.Fatalf("Failed to create dev dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, ".rulestack", "dev", "x.tgz"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	before, err := snapshotSourceDir(sourceDir)
+	if err != nil {
+		t.Fatalf("snapshotSourceDir failed: %v", err)
+	}
+	if len(before) != 1 {
+		t.Errorf("Expected 1 tracked file, got %d", len(before))
+	}
+
+	same, _ := snapshotSourceDir(sourceDir)
+	if !snapshotsEqual(before, same) {
+		t.Error("Expected unchanged directory to produce equal snapshots")
+	}
+
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(rulePath, later, later); err != nil {
+		t.Fatalf("Failed to touch rule: %v", err)
+	}
+	after, _ := snapshotSourceDir(sourceDir)
+	if snapshotsEqual(before, after) {
+		t.Error("Expected modified file to change the snapshot")
+	}
+}
+
+func TestBuildDevPackageAndRefresh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("junction creation requires cmd.exe")
+	}
+
+	tempDir := t.TempDir()
+
+	sourceDir := filepath.Join(tempDir, "my-rules")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+	writeSourceManifest := func(version string) {
+		content := `{"name": "my-rules", "version": "` + version + `", "files": ["*.md"]}`
+		if err := os.WriteFile(filepath.Join(sourceDir, "rulestack.pkg.json"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write source manifest: %v", err)
+		}
+	}
+	writeSourceManifest("1.0.0")
+	if err := os.WriteFile(filepath.Join(sourceDir, "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	projectDir := filepath.Join(tempDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(`{"version": "1.0.0", "dependencies": {}}`), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectDir)
+
+	if err := runLink(sourceDir); err != nil {
+		t.Fatalf("runLink failed: %v", err)
+	}
+
+	// Bump the version and rename the rule, as an author would mid-session
+	writeSourceManifest("1.1.0")
+	if err := os.Rename(filepath.Join(sourceDir, "style.md"), filepath.Join(sourceDir, "format.md")); err != nil {
+		t.Fatalf("Failed to rename rule: %v", err)
+	}
+
+	packageManifest, err := buildDevPackage(sourceDir, "")
+	if err != nil {
+		t.Fatalf("buildDevPackage failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sourceDir, ".rulestack", "dev", "my-rules-1.1.0.tgz")); err != nil {
+		t.Errorf("Expected dev archive to be written: %v", err)
+	}
+
+	if err := refreshLinkedProject(projectDir, sourceDir, packageManifest); err != nil {
+		t.Fatalf("refreshLinkedProject failed: %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(projectDir, ".rulestack", "my-rules.1.0.0")); !os.IsNotExist(err) {
+		t.Error("Expected old version link to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".rulestack", "my-rules.1.1.0", "format.md")); err != nil {
+		t.Errorf("Expected new version link to serve source files: %v", err)
+	}
+
+	claude, _ := os.ReadFile(filepath.Join(projectDir, "CLAUDE.md"))
+	if strings.Contains(string(claude), "my-rules.1.0.0/style.md") {
+		t.Errorf("Expected stale rule reference to be removed, got:\n%s", claude)
+	}
+	if !strings.Contains(string(claude), "- @.rulestack/my-rules.1.1.0/format.md") {
+		t.Errorf("Expected current rule reference, got:\n%s", claude)
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rulestack/internal/output"
+)
+
+// eventsLogName is the append-only log of install events under .rulestack/
+const eventsLogName = "events.log"
+
+// Install event actions
+const (
+	eventInstall = "install"
+	eventUpdate  = "update"
+	eventRemove  = "remove"
+)
+
+// installEvent is one line of .rulestack/events.log: a package the project
+// installed, updated to another version or removed
+type installEvent struct {
+	Time            time.Time `json:"time"`
+	Action          string    `json:"action"`
+	Package         string    `json:"package"`
+	Version         string    `json:"version"`
+	SHA256          string    `json:"sha256"`
+	PreviousVersion string    `json:"previous_version,omitempty"` // Version an update replaced
+	PreviousSHA256  string    `json:"previous_sha256,omitempty"`
+	Replacement     string    `json:"replacement,omitempty"` // Package an override installed in place of this dependency
+	Base            bool      `json:"base,omitempty"`        // The project's base configuration, see "extends"
+	Registry        string    `json:"registry,omitempty"`
+	Source          string    `json:"source,omitempty"`
+	Actor           string    `json:"actor"`
+	Command         string    `json:"command,omitempty"`
+}
+
+// lockEvents lists the installs, updates and removals that turn one lock file
+// into the next. previous is nil when there was no lock file.
+func lockEvents(previous, next *LockManifest) []installEvent {
+	before, beforeBase := map[string]LockPackageEntry{}, map[string]LockPackageEntry{}
+	if previous != nil {
+		before, beforeBase = previous.Packages, baseEntries(previous)
+	}
+
+	events := diffLockEntries(before, next.Packages)
+	for _, event := range diffLockEntries(beforeBase, baseEntries(next)) {
+		event.Base = true
+		events = append(events, event)
+	}
+	return events
+}
+
+// baseEntries returns a lock file's base configuration keyed by package name
+func baseEntries(lockManifest *LockManifest) map[string]LockPackageEntry {
+	if lockManifest.Extends == nil {
+		return map[string]LockPackageEntry{}
+	}
+	return map[string]LockPackageEntry{lockManifest.Extends.Package: *lockManifest.Extends}
+}
+
+func diffLockEntries(before, after map[string]LockPackageEntry) []installEvent {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var events []installEvent
+	for _, name := range names {
+		old, hadOld := before[name]
+		entry, hasNew := after[name]
+		switch {
+		case !hasNew:
+			events = append(events, newInstallEvent(eventRemove, name, old))
+		case !hadOld:
+			events = append(events, newInstallEvent(eventInstall, name, entry))
+		case old.Version != entry.Version || old.SHA256 != entry.SHA256 || old.Package != entry.Package:
+			event := newInstallEvent(eventUpdate, name, entry)
+			event.PreviousVersion, event.PreviousSHA256 = old.Version, old.SHA256
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func newInstallEvent(action, name string, entry LockPackageEntry) installEvent {
+	event := installEvent{
+		Action:   action,
+		Package:  name,
+		Version:  entry.Version,
+		SHA256:   entry.SHA256,
+		Registry: entry.Registry,
+		Source:   entry.Source,
+	}
+	if entry.Package != "" && entry.Package != name {
+		event.Replacement = entry.Package
+	}
+	return event
+}
+
+// eventActor names who changed the project's packages: RFH_ACTOR when set, as
+// CI can do for the user who triggered the run, or else the local user
+func eventActor() string {
+	if actor := os.Getenv("RFH_ACTOR"); actor != "" {
+		return actor
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// recordInstallEvents appends events to the project's .rulestack/events.log and
+// runs the configured events hook for each. The packages have changed by then,
+// so failures are warnings rather than errors.
+func recordInstallEvents(projectRoot string, events []installEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	now, actor := time.Now().UTC(), eventActor()
+	lines := make([][]byte, len(events))
+	for i := range events {
+		events[i].Time, events[i].Actor, events[i].Command = now, actor, commandName
+		line, err := json.Marshal(events[i])
+		if err != nil {
+			output.Fprintf(os.Stderr, "⚠️  Failed to record install events: %v\n", err)
+			return
+		}
+		lines[i] = append(line, '\n')
+	}
+
+	if err := appendEventsLog(projectRoot, bytes.Join(lines, nil)); err != nil {
+		output.Fprintf(os.Stderr, "⚠️  Failed to record install events: %v\n", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil || strings.TrimSpace(cfg.Events.Hook) == "" {
+		return
+	}
+	for i, line := range lines {
+		if err := runEventsHook(cfg.Events.Hook, projectRoot, line); err != nil {
+			output.Fprintf(os.Stderr, "⚠️  Events hook failed for %s %s: %v\n", events[i].Action, events[i].Package, err)
+		}
+	}
+}
+
+func appendEventsLog(projectRoot string, data []byte) error {
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	if err := os.MkdirAll(rulestackDir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(rulestackDir, eventsLogName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runEventsHook runs the hook command in the project root with one event on
+// stdin. Like $PAGER, the command is split on spaces rather than run by a shell.
+func runEventsHook(hook, projectRoot string, event []byte) error {
+	args := strings.Fields(hook)
+	cmd := exec.CommandContext(commandContext, args[0], args[1:]...)
+	cmd.Dir = projectRoot
+	cmd.Stdin = bytes.NewReader(event)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
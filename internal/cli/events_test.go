@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func readEventsLog(t *testing.T, projectRoot string) []installEvent {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join(projectRoot, ".rulestack", eventsLogName))
+	if err != nil {
+		t.Fatalf("Failed to open events log: %v", err)
+	}
+	defer file.Close()
+
+	var events []installEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event installEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestLockEvents(t *testing.T) {
+	previous := &LockManifest{
+		Packages: map[string]LockPackageEntry{
+			"security-rules": {Version: "1.0.0", SHA256: "aaa"},
+			"style-rules":    {Version: "2.0.0", SHA256: "bbb"},
+			"go-rules":       {Version: "1.1.0", SHA256: "ccc", Quarantined: true},
+		},
+		Extends: &LockPackageEntry{Version: "1.0.0", SHA256: "ddd", Package: "acme-base"},
+	}
+	next := &LockManifest{
+		Packages: map[string]LockPackageEntry{
+			"security-rules": {Version: "1.1.0", SHA256: "eee", Registry: "corp"},
+			"go-rules":       {Version: "1.1.0", SHA256: "ccc"},
+			"legacy-rules":   {Version: "1.0.0", SHA256: "fff", Package: "modern-rules"},
+		},
+		Extends: &LockPackageEntry{Version: "1.0.0", SHA256: "ddd", Package: "acme-base"},
+	}
+
+	events := lockEvents(previous, next)
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Action+" "+event.Package+"@"+event.Version)
+	}
+	want := []string{"install legacy-rules@1.0.0", "update security-rules@1.1.0", "remove style-rules@2.0.0"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if events[0].Replacement != "modern-rules" {
+		t.Errorf("expected the override replacement recorded, got %+v", events[0])
+	}
+	if events[1].PreviousVersion != "1.0.0" || events[1].PreviousSHA256 != "aaa" || events[1].Registry != "corp" {
+		t.Errorf("expected the update to record what it replaced, got %+v", events[1])
+	}
+
+	// A new base configuration is an update of the base
+	next.Extends = &LockPackageEntry{Version: "2.0.0", SHA256: "ggg", Package: "acme-base"}
+	events = lockEvents(previous, next)
+	if last := events[len(events)-1]; !last.Base || last.Action != eventUpdate || last.Version != "2.0.0" {
+		t.Errorf("expected a base configuration update, got %+v", last)
+	}
+
+	// Without a previous lock file everything is installed
+	if events := lockEvents(nil, next); len(events) != 4 || events[0].Action != eventInstall {
+		t.Errorf("expected 4 installs, got %+v", events)
+	}
+}
+
+func TestInstallEventsLog(t *testing.T) {
+	tempDir := setupQuarantineProject(t, false)
+	t.Setenv("RFH_CONFIG", filepath.Join(tempDir, ".rfh"))
+	t.Setenv("RFH_ACTOR", "alice")
+	commandName = "rfh add"
+	t.Cleanup(func() { commandName = "" })
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	// Reinstalling the same archive changes nothing
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.1.0", SHA256: "def"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+
+	events := readEventsLog(t, tempDir)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if e := events[0]; e.Action != eventInstall || e.Package != "security-rules" || e.SHA256 != "abc" || e.Actor != "alice" || e.Command != "rfh add" || e.Time.IsZero() {
+		t.Errorf("unexpected install event %+v", e)
+	}
+	if e := events[1]; e.Action != eventUpdate || e.Version != "1.1.0" || e.PreviousVersion != "1.0.0" {
+		t.Errorf("unexpected update event %+v", e)
+	}
+}
+
+func TestInstallEventsHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook is a shell script")
+	}
+
+	tempDir := setupQuarantineProject(t, false)
+	configDir := filepath.Join(tempDir, ".rfh")
+	t.Setenv("RFH_CONFIG", configDir)
+	os.MkdirAll(configDir, 0755)
+
+	hook := filepath.Join(tempDir, "hook.sh")
+	received := filepath.Join(tempDir, "received.jsonl")
+	os.WriteFile(hook, []byte("#!/bin/sh\ncat >> "+received+"\n"), 0755)
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("[events]\nhook = \""+hook+"\"\n"), 0644)
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+
+	data, err := os.ReadFile(received)
+	if err != nil {
+		t.Fatalf("expected the hook to run: %v", err)
+	}
+	var event installEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Action != eventInstall || event.Package != "security-rules" {
+		t.Errorf("expected the install event on the hook's stdin, got %q (%v)", data, err)
+	}
+}
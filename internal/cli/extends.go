@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/tempdir"
+	"rulestack/internal/version"
+)
+
+// installBaseConfig makes sure the base configuration package named by "extends"
+// is installed. The version recorded in rulestack.lock.json is kept while it still
+// satisfies the range; otherwise the highest matching version is installed.
+func installBaseConfig(projectRoot string, projectManifest *manifest.ProjectManifest, resolver *packageResolver) error {
+	name, versionRange, err := manifest.ParseExtends(projectManifest.Extends)
+	if err != nil {
+		return err
+	}
+
+	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
+	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	if locked := lockManifest.Extends; locked != nil && locked.Package == name {
+		if v, err := version.Parse(locked.Version); err == nil && versionRange.Contains(v) {
+			if _, err := os.Stat(baseConfigPath(projectRoot, name, locked.Version)); err == nil {
+				return nil
+			}
+		}
+	}
+
+	_, baseVersion, _ := strings.Cut(projectManifest.Extends, "@")
+	if !versionRange.IsExact() {
+		baseVersion, err = resolveRange(resolver, name, versionRange)
+		if err != nil {
+			return err
+		}
+	}
+
+	source, metadata, err := resolver.resolve(name, baseVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get %s@%s: %w", name, baseVersion, err)
+	}
+	if metadata.SHA256 == "" {
+		return fmt.Errorf("package version missing sha256 hash")
+	}
+
+	tempFile, err := tempdir.Path(fmt.Sprintf("%s-%s-*.tgz", name, baseVersion))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+
+	if err := source.downloadPackage(commandContext, name, baseVersion, metadata.SHA256, metadata.Size, tempFile); err != nil {
+		return fmt.Errorf("failed to download %s@%s from %s: %w", name, baseVersion, source.Name, err)
+	}
+
+	verified, err := checkArchive(tempFile, metadata.Integrity, metadata.SHA256)
+	if err != nil {
+		return fmt.Errorf("%s@%s downloaded from %s failed its integrity check: %w", name, baseVersion, source.Name, err)
+	}
+
+	packageDir := filepath.Join(projectRoot, ".rulestack", fmt.Sprintf("%s.%s", name, baseVersion))
+	extracted, err := unpackPackage(tempFile, packageDir)
+	if err != nil {
+		return fmt.Errorf("failed to extract package: %w", err)
+	}
+	files := extracted.Hashes()
+
+	// Fail now rather than on every later command if it is not a base configuration
+	if _, err := manifest.LoadBaseConfig(baseConfigPath(projectRoot, name, baseVersion)); err != nil {
+		os.RemoveAll(packageDir)
+		return fmt.Errorf("%s@%s is not a usable base configuration: %w", name, baseVersion, err)
+	}
+
+	// Drop the version this one replaces
+	if locked := lockManifest.Extends; locked != nil && (locked.Package != name || locked.Version != baseVersion) {
+		os.RemoveAll(filepath.Join(projectRoot, ".rulestack", fmt.Sprintf("%s.%s", locked.Package, locked.Version)))
+	}
+
+	lockManifest.Extends = &LockPackageEntry{
+		Version:   baseVersion,
+		SHA256:    metadata.SHA256,
+		Integrity: verified,
+		Package:   name,
+		Registry:  source.Name,
+		Files:     files,
+	}
+	if err := saveLockManifest(lockPath, lockManifest); err != nil {
+		return fmt.Errorf("failed to save lock manifest: %w", err)
+	}
+
+	output.Printf("🧱 Using base configuration %s@%s\n", name, baseVersion)
+	return nil
+}
+
+// resolveRange finds the highest version of name within versionRange on the active
+// registry, or on the first mirror that can answer
+func resolveRange(resolver *packageResolver, name string, versionRange *version.Range) (string, error) {
+	var firstErr error
+	for _, source := range resolver.sources {
+		ctx, cancel := client.WithTimeout(commandContext)
+		packageInfo, err := source.Client.GetPackage(ctx, name)
+		cancel()
+
+		if err == nil {
+			if match := versionRange.Highest(packageInfo.Versions); match != "" {
+				return match, nil
+			}
+			err = fmt.Errorf("no version of %s matches the range", name)
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+		if !canFallBack(err) {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("failed to resolve base configuration %s: %w", name, firstErr)
+}
+
+// applyInherited merges the dependencies the project inherits into its manifest:
+// those of the installed base configuration, then those of installed
+// meta-packages. Run 'rfh install .' to install or update them first.
+func applyInherited(projectRoot string, projectManifest *manifest.ProjectManifest) error {
+	if err := applyInstalledBase(projectRoot, projectManifest); err != nil {
+		return err
+	}
+	applyMetaPackages(projectRoot, projectManifest)
+	return nil
+}
+
+// applyInstalledBase merges the installed base configuration into the project manifest
+func applyInstalledBase(projectRoot string, projectManifest *manifest.ProjectManifest) error {
+	if projectManifest.Extends == "" {
+		return nil
+	}
+
+	name, _, err := manifest.ParseExtends(projectManifest.Extends)
+	if err != nil {
+		return err
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+	if lockManifest.Extends == nil || lockManifest.Extends.Package != name {
+		return fmt.Errorf("base configuration %s is not installed. Run 'rfh install .' first", projectManifest.Extends)
+	}
+
+	base, err := manifest.LoadBaseConfig(baseConfigPath(projectRoot, name, lockManifest.Extends.Version))
+	if err != nil {
+		return fmt.Errorf("failed to load base configuration %s: %w", projectManifest.Extends, err)
+	}
+
+	projectManifest.Merge(base)
+	return nil
+}
+
+// applyMetaPackages merges the dependencies of installed meta-packages into the
+// project manifest, following meta-packages among them that are installed too.
+// It returns the names of the dependencies added.
+func applyMetaPackages(projectRoot string, projectManifest *manifest.ProjectManifest) []string {
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	expanded := make(map[string]bool)
+
+	var added []string
+	for {
+		dependencies := projectManifest.ResolvedDependencies()
+		names := make([]string, 0, len(dependencies))
+		for name := range dependencies {
+			if !expanded[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		progressed := false
+		for _, name := range names {
+			expanded[name] = true
+
+			packageManifest := installedPackageManifest(rulestackDir, name, dependencies[name])
+			if packageManifest == nil || !packageManifest.IsMeta() {
+				continue
+			}
+			added = append(added, projectManifest.Inherit(name, packageManifest.Dependencies)...)
+			progressed = true
+		}
+		if !progressed {
+			return added
+		}
+	}
+}
+
+// installedPackageManifest reads the manifest of an installed package at the
+// required version, or of whatever version a source dependency installed. It is
+// nil when the package is not installed.
+func installedPackageManifest(rulestackDir, name, requiredVersion string) *manifest.PackageManifest {
+	packageDir := filepath.Join(rulestackDir, packageDirName(name, requiredVersion))
+	if isSourceSpec(requiredVersion) {
+		_, dir, err := findInstalledPackage(rulestackDir, name)
+		if err != nil {
+			return nil
+		}
+		packageDir = dir
+	}
+
+	data, err := os.ReadFile(filepath.Join(packageDir, "rulestack.json"))
+	if err != nil {
+		return nil
+	}
+	var packageManifest manifest.PackageManifest
+	if err := json.Unmarshal(data, &packageManifest); err != nil {
+		return nil
+	}
+	return &packageManifest
+}
+
+// baseConfigPath returns where an installed base configuration keeps its settings
+func baseConfigPath(projectRoot, name, ver string) string {
+	return filepath.Join(projectRoot, ".rulestack", fmt.Sprintf("%s.%s", name, ver), manifest.BaseConfigFile)
+}
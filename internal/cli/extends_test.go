@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestInstallBaseConfig(t *testing.T) {
+	// Base configuration package archive
+	stageDir := t.TempDir()
+	baseContent := `{"dependencies": {"security-rules": "0.9.0", "logging-rules": "2.0.0"}, "targets": ["claude-code"]}`
+	if err := os.WriteFile(filepath.Join(stageDir, manifest.BaseConfigFile), []byte(baseContent), 0644); err != nil {
+		t.Fatalf("Failed to write base config: %v", err)
+	}
+	archive, err := pkg.PackFromDirectory(stageDir, filepath.Join(t.TempDir(), "base.tgz"))
+	if err != nil {
+		t.Fatalf("Failed to pack base config: %v", err)
+	}
+	archiveData, _ := os.ReadFile(archive.Path)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/v1/packages/org-base-config":
+			w.Write([]byte(`{"name": "org-base-config", "versions": ["1.0.0", "2.1.0", "2.3.0", "3.0.0"]}`))
+		case "/v1/packages/org-base-config/versions/2.3.0":
+			w.Write([]byte(`{"name": "org-base-config", "version": "2.3.0", "sha256": "` + archive.SHA256 + `"}`))
+		case "/v1/blobs/" + archive.SHA256:
+			w.Write(archiveData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.CLIConfig{
+		Current:    "corp",
+		Registries: map[string]config.Registry{"corp": {URL: server.URL, Type: config.RegistryTypeHTTP}},
+	}
+	resolver, err := newPackageResolver(cfg, nil)
+	if err != nil {
+		t.Fatalf("newPackageResolver failed: %v", err)
+	}
+
+	projectRoot := t.TempDir()
+	projectManifest := &manifest.ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"security-rules": "1.0.0"},
+		Extends:      "org-base-config@^2",
+	}
+
+	if err := applyInherited(projectRoot, projectManifest); err == nil {
+		t.Error("expected using the base before installing it to fail")
+	}
+
+	if err := installBaseConfig(projectRoot, projectManifest, resolver); err != nil {
+		t.Fatalf("installBaseConfig failed: %v", err)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		t.Fatalf("Failed to load lock manifest: %v", err)
+	}
+	if lockManifest.Extends == nil || lockManifest.Extends.Package != "org-base-config" || lockManifest.Extends.Version != "2.3.0" {
+		t.Fatalf("expected the highest 2.x version to be locked, got %+v", lockManifest.Extends)
+	}
+
+	if err := applyInherited(projectRoot, projectManifest); err != nil {
+		t.Fatalf("applyInherited failed: %v", err)
+	}
+	if projectManifest.Dependencies["security-rules"] != "1.0.0" || projectManifest.Dependencies["logging-rules"] != "2.0.0" {
+		t.Errorf("expected base dependencies merged under the project's own, got %v", projectManifest.Dependencies)
+	}
+	if len(projectManifest.Targets) != 1 || projectManifest.Targets[0] != "claude-code" {
+		t.Errorf("expected base targets merged, got %v", projectManifest.Targets)
+	}
+
+	// The locked version is reused while it satisfies the range
+	requests = 0
+	if err := installBaseConfig(projectRoot, projectManifest, resolver); err != nil {
+		t.Fatalf("second installBaseConfig failed: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected the locked base to be reused without contacting the registry, got %d requests", requests)
+	}
+}
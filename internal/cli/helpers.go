@@ -3,17 +3,18 @@ package cli
 import (
 	"fmt"
 	"rulestack/internal/config"
+	"rulestack/internal/output"
 	"strings"
 )
 
 // getEffectiveToken returns the token to use for API calls
 func getEffectiveToken(cfg config.CLIConfig, registry config.Registry) (string, error) {
-	// Check registry-specific JWT token
-	if registry.JWTToken != "" {
+	// Check registry-specific JWT token, unless it was issued for another URL
+	if token := registry.AuthToken(); token != "" {
 		if verbose {
-			fmt.Printf("🔍 Using JWT token from registry config (length: %d chars)\n", len(registry.JWTToken))
+			output.Printf("🔍 Using JWT token from registry config (length: %d chars)\n", len(token))
 		}
-		return registry.JWTToken, nil
+		return token, nil
 	}
 
 	return "", fmt.Errorf("no authentication token available. Use 'rfh auth login' to authenticate or configure a registry JWT token")
@@ -37,12 +38,12 @@ func getCurrentRegistry(cfg config.CLIConfig) (string, config.Registry, error) {
 
 // getDefaultToken returns the default token for a registry (no command line overrides)
 func getDefaultToken(registry config.Registry) string {
-	// Use registry-specific JWT token
-	if registry.JWTToken != "" {
+	// Use registry-specific JWT token, unless it was issued for another URL
+	if token := registry.AuthToken(); token != "" {
 		if verbose {
-			fmt.Printf("🔍 Using JWT token from registry config (length: %d chars)\n", len(registry.JWTToken))
+			output.Printf("🔍 Using JWT token from registry config (length: %d chars)\n", len(token))
 		}
-		return registry.JWTToken
+		return token
 	}
 
 	// No token available - return empty string (will cause auth error)
@@ -59,22 +60,23 @@ func checkAndWarnRootUser(cfg config.CLIConfig, commandName string) {
 	// Check if user is logged in as root in the current registry
 	if cfg.Current != "" {
 		if registry, exists := cfg.Registries[cfg.Current]; exists && strings.ToLower(registry.Username) == "root" {
-			fmt.Printf("\n⚠️  🚨 SECURITY WARNING 🚨 ⚠️\n")
-			fmt.Printf("YOU ARE LOGGED IN AS ROOT USER!\n\n")
-			fmt.Printf("This is a high-privilege administrative account that should NOT be used for regular operations.\n\n")
-			fmt.Printf("RECOMMENDED ACTIONS:\n")
-			fmt.Printf("1. Create a regular user account: rfh auth register\n")
-			fmt.Printf("2. Grant admin privileges to your user account\n")
-			fmt.Printf("3. Disable or change the root account password\n")
-			fmt.Printf("4. Use your regular account for daily operations\n\n")
-			fmt.Printf("This warning appears for all commands when logged in as 'root'.\n")
-			fmt.Printf("═════════════════════════════════════════════════════════════════\n\n")
+			output.Printf("\n⚠️  🚨 SECURITY WARNING 🚨 ⚠️\n")
+			output.Printf("YOU ARE LOGGED IN AS ROOT USER!\n\n")
+			output.Printf("This is a high-privilege administrative account that should NOT be used for regular operations.\n\n")
+			output.Printf("RECOMMENDED ACTIONS:\n")
+			output.Printf("1. Create a regular user account: rfh auth register\n")
+			output.Printf("2. Grant admin privileges to your user account\n")
+			output.Printf("3. Disable or change the root account password\n")
+			output.Printf("4. Use your regular account for daily operations\n\n")
+			output.Printf("This warning appears for all commands when logged in as 'root'.\n")
+			output.Printf("═════════════════════════════════════════════════════════════════\n\n")
 		}
 	}
 }
 
 // isAuthCommand checks if the command is related to authentication (to skip warnings)
 func isAuthCommand(commandName string) bool {
+	commandName = strings.TrimPrefix(commandName, "rfh ")
 	authCommands := []string{
 		"auth",
 		"auth login",
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/output"
+)
+
+var (
+	hooksNames []string
+	hooksTest  bool
+	hooksForce bool
+)
+
+// hookMarker identifies hooks written by rfh, so they are never confused with
+// hooks written by hand or by other tools
+const hookMarker = "# rfh-managed hook"
+
+// supportedHooks are the git hooks rfh can install
+var supportedHooks = []string{"pre-commit", "pre-push"}
+
+// hooksCmd represents the hooks command
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that check rule dependencies",
+	Long: `Install git hooks that stop commits and pushes when the project's rule
+dependencies have been tampered with or break organization constraints.`,
+}
+
+// hooksInstallCmd represents the hooks install command
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install pre-commit and pre-push hooks",
+	Long: `Write git hooks that run 'rfh verify' and 'rfh audit' for this project, and
+with --test 'rfh test' for a package source in it, before each commit and push.
+
+Existing hooks not written by rfh are left alone unless --force is given, in
+which case they are kept with a .pre-rfh suffix. The hooks skip their checks
+when rfh is not on PATH or RFH_SKIP_HOOKS=1 is set; 'git commit --no-verify'
+skips them as well.
+
+Examples:
+  rfh hooks install
+  rfh hooks install --hook pre-push
+  rfh hooks install --test --force`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHooksInstall(hooksNames, hooksTest, hooksForce)
+	},
+}
+
+// hooksUninstallCmd represents the hooks uninstall command
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the hooks installed by rfh",
+	Long: `Remove the git hooks written by 'rfh hooks install', restoring any hook
+they replaced with --force. Hooks not written by rfh are left alone.
+
+Examples:
+  rfh hooks uninstall`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHooksUninstall(hooksNames)
+	},
+}
+
+func runHooksInstall(hooks []string, withTest, force bool) error {
+	if err := validateHookNames(hooks); err != nil {
+		return err
+	}
+
+	projectRoot, repoRoot, hooksDir, err := findHooksDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	script, err := hookScript(repoRoot, projectRoot, withTest)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		hookPath := filepath.Join(hooksDir, hook)
+
+		if existing, err := os.ReadFile(hookPath); err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+			if !force {
+				return fmt.Errorf("%s already has a %s hook not written by rfh; use --force to replace it (it is kept as %s.pre-rfh)", hooksDir, hook, hook)
+			}
+			if err := os.Rename(hookPath, hookPath+".pre-rfh"); err != nil {
+				return fmt.Errorf("failed to keep existing %s hook: %w", hook, err)
+			}
+			output.Printf("📦 Kept the existing %s hook as %s.pre-rfh\n", hook, hook)
+		}
+
+		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write %s hook: %w", hook, err)
+		}
+		output.Printf("✅ Installed %s hook\n", hook)
+	}
+
+	checks := "rfh verify, rfh audit"
+	if withTest {
+		checks += ", rfh test"
+	}
+	output.Printf("🔒 Commits and pushes now run: %s\n", checks)
+	output.Printf("💡 Skip once with 'git commit --no-verify' or RFH_SKIP_HOOKS=1\n")
+	return nil
+}
+
+func runHooksUninstall(hooks []string) error {
+	if err := validateHookNames(hooks); err != nil {
+		return err
+	}
+
+	_, _, hooksDir, err := findHooksDir()
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, hook := range hooks {
+		hookPath := filepath.Join(hooksDir, hook)
+		existing, err := os.ReadFile(hookPath)
+		if err != nil {
+			continue
+		}
+		if !bytes.Contains(existing, []byte(hookMarker)) {
+			output.Printf("ℹ️  %s hook was not written by rfh, leaving it\n", hook)
+			continue
+		}
+
+		if err := os.Remove(hookPath); err != nil {
+			return fmt.Errorf("failed to remove %s hook: %w", hook, err)
+		}
+		removed++
+		output.Printf("🗑️  Removed %s hook\n", hook)
+
+		if _, err := os.Stat(hookPath + ".pre-rfh"); err == nil {
+			if err := os.Rename(hookPath+".pre-rfh", hookPath); err != nil {
+				return fmt.Errorf("failed to restore previous %s hook: %w", hook, err)
+			}
+			output.Printf("♻️  Restored the previous %s hook\n", hook)
+		}
+	}
+
+	if removed == 0 {
+		output.Printf("ℹ️  No rfh hooks installed\n")
+	}
+	return nil
+}
+
+func validateHookNames(hooks []string) error {
+	if len(hooks) == 0 {
+		return fmt.Errorf("no hooks given; use --hook %s", strings.Join(supportedHooks, ","))
+	}
+	for _, hook := range hooks {
+		if !slices.Contains(supportedHooks, hook) {
+			return fmt.Errorf("unsupported hook '%s': use %s", hook, strings.Join(supportedHooks, " or "))
+		}
+	}
+	return nil
+}
+
+// findHooksDir returns the project root, the root of the git working tree
+// containing it, and the repository's hooks directory
+func findHooksDir() (string, string, string, error) {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	repoRoot, gitDir, err := findGitDir(projectRoot)
+	if err != nil {
+		return "", "", "", err
+	}
+	return projectRoot, repoRoot, filepath.Join(gitDir, "hooks"), nil
+}
+
+// findGitDir walks up from dir to the root of its git working tree, returning
+// that and the git directory. In a linked worktree, .git is a file pointing at
+// the worktree's git directory, whose hooks are shared from the main repository's.
+func findGitDir(dir string) (string, string, error) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			if info.IsDir() {
+				return dir, dotGit, nil
+			}
+			gitDir, err := gitDirFromFile(dotGit)
+			return dir, gitDir, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("not inside a git repository")
+		}
+		dir = parent
+	}
+}
+
+// gitDirFromFile resolves a worktree's .git file to the main repository's git directory
+func gitDirFromFile(dotGit string) (string, error) {
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dotGit, err)
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("%s is not a valid git file", dotGit)
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+
+	// Worktree git directories name the shared one in commondir
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		return filepath.Clean(commonDir), nil
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// hookScript is the shell script run by each hook, from the project directory
+// relative to the repository root
+func hookScript(repoRoot, projectRoot string, withTest bool) (string, error) {
+	rel, err := filepath.Rel(repoRoot, projectRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate project in repository: %w", err)
+	}
+	rel = filepath.ToSlash(rel)
+
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString(hookMarker + "; remove with 'rfh hooks uninstall'\n")
+	script.WriteString(`
+if [ "$RFH_SKIP_HOOKS" = "1" ]; then
+	exit 0
+fi
+if ! command -v rfh >/dev/null 2>&1; then
+	echo "rfh not found on PATH; skipping rule dependency checks" >&2
+	exit 0
+fi
+
+`)
+	fmt.Fprintf(&script, "cd \"$(git rev-parse --show-toplevel)/%s\" || exit 1\n\n", rel)
+	script.WriteString("rfh --non-interactive verify || exit 1\n")
+	script.WriteString("rfh --non-interactive audit || exit 1\n")
+	if withTest {
+		script.WriteString("rfh --non-interactive test || exit 1\n")
+	}
+	return script.String(), nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{hooksInstallCmd, hooksUninstallCmd} {
+		cmd.Flags().StringSliceVar(&hooksNames, "hook", supportedHooks, "hooks to manage (pre-commit, pre-push)")
+	}
+	hooksInstallCmd.Flags().BoolVar(&hooksTest, "test", false, "also run 'rfh test' for a package source in the project")
+	hooksInstallCmd.Flags().BoolVar(&hooksForce, "force", false, "replace existing hooks not written by rfh, keeping them with a .pre-rfh suffix")
+
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupHookRepo creates a git repository with a project in a subdirectory and
+// changes into the project
+func setupHookRepo(t *testing.T) (string, string) {
+	t.Helper()
+	repoRoot := t.TempDir()
+	projectRoot := filepath.Join(repoRoot, "app")
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".git", "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(projectRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.json"), []byte(`{"dependencies": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+	return repoRoot, projectRoot
+}
+
+func TestHooksInstall(t *testing.T) {
+	repoRoot, _ := setupHookRepo(t)
+	hooksDir := filepath.Join(repoRoot, ".git", "hooks")
+
+	if err := runHooksInstall(supportedHooks, true, false); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	for _, hook := range supportedHooks {
+		info, err := os.Stat(filepath.Join(hooksDir, hook))
+		if err != nil {
+			t.Fatalf("%s hook not written: %v", hook, err)
+		}
+		if info.Mode().Perm()&0100 == 0 {
+			t.Errorf("%s hook is not executable", hook)
+		}
+
+		script, _ := os.ReadFile(filepath.Join(hooksDir, hook))
+		for _, want := range []string{hookMarker, `/app"`, "rfh --non-interactive verify", "rfh --non-interactive audit", "rfh --non-interactive test", "RFH_SKIP_HOOKS"} {
+			if !strings.Contains(string(script), want) {
+				t.Errorf("%s hook missing %q:\n%s", hook, want, script)
+			}
+		}
+	}
+
+	// Reinstalling replaces rfh's own hooks without --force
+	if err := runHooksInstall([]string{"pre-commit"}, false, false); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+	script, _ := os.ReadFile(filepath.Join(hooksDir, "pre-commit"))
+	if strings.Contains(string(script), "rfh --non-interactive test") {
+		t.Error("reinstall without --test should drop rfh test")
+	}
+}
+
+func TestHooksInstallExistingHook(t *testing.T) {
+	repoRoot, _ := setupHookRepo(t)
+	hookPath := filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runHooksInstall([]string{"pre-commit"}, false, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected an error suggesting --force, got %v", err)
+	}
+
+	if err := runHooksInstall([]string{"pre-commit"}, false, true); err != nil {
+		t.Fatalf("forced install failed: %v", err)
+	}
+	if kept, err := os.ReadFile(hookPath + ".pre-rfh"); err != nil || !strings.Contains(string(kept), "make lint") {
+		t.Errorf("existing hook not kept: %v", err)
+	}
+
+	if err := runHooksUninstall([]string{"pre-commit"}); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	restored, err := os.ReadFile(hookPath)
+	if err != nil || !strings.Contains(string(restored), "make lint") {
+		t.Errorf("previous hook not restored: %v", err)
+	}
+	if _, err := os.Stat(hookPath + ".pre-rfh"); !os.IsNotExist(err) {
+		t.Error("backup should be gone after restoring it")
+	}
+
+	// Hooks rfh did not write are never removed
+	if err := runHooksUninstall([]string{"pre-commit"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(hookPath); err != nil {
+		t.Error("uninstall removed a hook rfh did not write")
+	}
+}
+
+func TestFindGitDirWorktree(t *testing.T) {
+	mainRepo := t.TempDir()
+	worktreeGitDir := filepath.Join(mainRepo, ".git", "worktrees", "feature")
+	if err := os.MkdirAll(worktreeGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(worktree, "app")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root, gitDir, err := findGitDir(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != worktree {
+		t.Errorf("working tree root = %s, want %s", root, worktree)
+	}
+	if gitDir != filepath.Join(mainRepo, ".git") {
+		t.Errorf("git dir = %s, want the main repository's", gitDir)
+	}
+
+	if _, _, err := findGitDir(t.TempDir()); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/index"
+	"rulestack/internal/output"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the local registry index",
+	Long: `Manage the local snapshot of the active registry's package index.
+
+The snapshot powers rfh search --offline, rfh outdated --offline and shell
+completion of package names. Syncing only downloads packages changed since
+the previous sync.`,
+}
+
+// indexSyncCmd represents the index sync command
+var indexSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Update the local index from the active registry",
+	Long: `Download the packages changed on the active registry since the last sync
+and merge them into the local index snapshot.
+
+Examples:
+  rfh index sync
+  rfh index sync --full`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		full, _ := cmd.Flags().GetBool("full")
+		return runIndexSync(full)
+	},
+}
+
+// runIndexSync implements the index sync command logic
+func runIndexSync(full bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := index.Load(registryName, reg.URL)
+	if err != nil {
+		return err
+	}
+	if full {
+		snapshot.Cursor = ""
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+	defer cancel()
+
+	if verbose && snapshot.Cursor != "" {
+		output.Printf("🔄 Syncing changes since %s\n", snapshot.Cursor)
+	}
+
+	changed, err := index.Sync(ctx, c, snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to sync index: %w", err)
+	}
+
+	output.Printf("✅ Index of %s synced: %d package(s) updated, %d total\n", registryName, changed, len(snapshot.Packages))
+	return nil
+}
+
+// loadSyncedSnapshot loads the active registry's snapshot for offline use
+func loadSyncedSnapshot() (*index.Snapshot, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := index.Load(registryName, reg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !snapshot.Synced() {
+		return nil, fmt.Errorf("no local index for %s: run 'rfh index sync' first", registryName)
+	}
+
+	if verbose {
+		output.Printf("📇 Using local index of %s from %s\n", registryName, snapshot.SyncedAt.Local().Format(time.DateTime))
+	}
+
+	return snapshot, nil
+}
+
+// completePackageRefs completes package names, and name@version once an @ is typed,
+// from the local index. Completion never touches the network.
+func completePackageRefs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshot, err := index.Load(registryName, reg.URL)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	if name, _, ok := strings.Cut(toComplete, "@"); ok {
+		for _, v := range snapshot.Packages[name].Versions {
+			completions = append(completions, name+"@"+v.Version)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, name := range snapshot.Names() {
+		if strings.HasPrefix(name, toComplete) {
+			completions = append(completions, name+"@")
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func init() {
+	indexSyncCmd.Flags().Bool("full", false, "Download the whole index instead of changes since the last sync")
+
+	indexCmd.AddCommand(indexSyncCmd)
+}
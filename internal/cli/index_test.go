@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rulestack/internal/index"
+)
+
+func TestRunIndexSync(t *testing.T) {
+	var sinceParams []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/index" {
+			http.NotFound(w, r)
+			return
+		}
+		since := r.URL.Query().Get("since")
+		sinceParams = append(sinceParams, since)
+
+		if since == "" {
+			w.Write([]byte(`{"cursor": "2030-01-01T00:00:00Z", "full": true, "packages": [
+				{"name": "security-rules", "latest": "1.0.0", "versions": [{"version": "1.0.0"}]},
+				{"name": "logging-rules", "latest": "2.0.0", "versions": [{"version": "2.0.0"}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"cursor": "2030-01-02T00:00:00Z", "full": false, "packages": [
+			{"name": "logging-rules", "versions": []}]}`))
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	if _, err := loadSyncedSnapshot(); err == nil {
+		t.Error("expected offline use to fail before the first sync")
+	}
+
+	if err := runIndexSync(false); err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+	if err := runIndexSync(false); err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+
+	if len(sinceParams) != 2 || sinceParams[0] != "" || sinceParams[1] != "2030-01-01T00:00:00Z" {
+		t.Errorf("expected a full sync followed by a delta from the first cursor, got %q", sinceParams)
+	}
+
+	snapshot, err := index.Load("corp", server.URL)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	if names := snapshot.Names(); len(names) != 1 || names[0] != "security-rules" {
+		t.Errorf("expected the delta to remove logging-rules, got %v", names)
+	}
+	if snapshot.Cursor != "2030-01-02T00:00:00Z" {
+		t.Errorf("expected the cursor to advance, got %q", snapshot.Cursor)
+	}
+
+	completions, _ := completePackageRefs(addCmd, nil, "sec")
+	if len(completions) != 1 || completions[0] != "security-rules@" {
+		t.Errorf("expected package name completion, got %v", completions)
+	}
+	completions, _ = completePackageRefs(addCmd, nil, "security-rules@")
+	if len(completions) != 1 || completions[0] != "security-rules@1.0.0" {
+		t.Errorf("expected version completion, got %v", completions)
+	}
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/security"
+	"rulestack/internal/tempdir"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <archive|package@version>",
+	Short: "Show the contents of a package archive",
+	Long: `Show a package archive's embedded manifest, every file with its size and
+SHA256, and the result of the security checks run before installation.
+
+The archive can be a local .tgz or .tar.zst file, or a package version on the
+active registry, which is downloaded for inspection without being installed.
+Exits with an error when the archive fails the security checks.
+
+Examples:
+  rfh inspect .rulestack/staged/security-rules-1.2.0.tgz
+  rfh inspect security-rules@1.2.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInspect(args[0])
+	},
+}
+
+// inspectedFile is a file in an inspected archive
+type inspectedFile struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+// archiveReport is what 'rfh inspect' shows about an archive
+type archiveReport struct {
+	SHA256   string
+	Size     int64
+	Manifest *manifest.PackageManifest // Nil if the archive has no readable manifest
+	Files    []inspectedFile
+	Security error // Why the archive failed the security checks, if it did
+}
+
+func runInspect(ref string) error {
+	archivePath := ref
+	source := ref
+
+	if _, err := os.Stat(ref); err != nil {
+		downloaded, registryName, err := downloadForInspection(ref)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(downloaded)
+		archivePath = downloaded
+		source = fmt.Sprintf("%s (registry %s)", ref, registryName)
+	}
+
+	report, err := inspectArchive(archivePath)
+	if err != nil {
+		return err
+	}
+
+	printArchiveReport(source, report)
+
+	if report.Security != nil {
+		return fmt.Errorf("archive failed security checks: %w", report.Security)
+	}
+	return nil
+}
+
+// downloadForInspection downloads a package version from the active registry to a
+// temp file, returning its path and the registry name
+func downloadForInspection(spec string) (string, string, error) {
+	pkgRef, err := parsePackageRef(spec)
+	if err != nil {
+		return "", "", fmt.Errorf("'%s' is neither an archive file nor a package reference: %w", spec, err)
+	}
+	if isSourceSpec(pkgRef.Version) {
+		return "", "", fmt.Errorf("only registry packages can be inspected by reference")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load config: %w", err)
+	}
+	registryName, _, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return "", "", err
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	versionInfo, err := c.GetPackageVersion(ctx, pkgRef.Name, pkgRef.Version)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get package version: %w", err)
+	}
+	if versionInfo.SHA256 == "" {
+		return "", "", fmt.Errorf("package version missing sha256 hash")
+	}
+
+	tempFile, err := tempdir.CreateTemp("inspect-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFile.Close()
+
+	// Not under ctx's deadline, which would cut off large archives
+	if err := c.DownloadBlob(commandContext, versionInfo.SHA256, tempFile.Name()); err != nil {
+		os.Remove(tempFile.Name())
+		return "", "", fmt.Errorf("failed to download package: %w", err)
+	}
+
+	if _, err := checkArchive(tempFile.Name(), versionInfo.Integrity, versionInfo.SHA256); err != nil {
+		os.Remove(tempFile.Name())
+		return "", "", fmt.Errorf("downloaded archive does not match the registry's digests: %w", err)
+	}
+
+	return tempFile.Name(), registryName, nil
+}
+
+// inspectArchive reads an archive's manifest and files and runs the security
+// validator over it
+func inspectArchive(archivePath string) (*archiveReport, error) {
+	stat, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("archive not found: %s", archivePath)
+	}
+
+	sha, err := pkg.CalculateSHA256(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash archive: %w", err)
+	}
+	report := &archiveReport{SHA256: sha, Size: stat.Size()}
+
+	err = pkg.WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		hasher := sha256.New()
+		size, err := io.Copy(hasher, content)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		report.Files = append(report.Files, inspectedFile{
+			Path:   filepath.ToSlash(filepath.Clean(header.Name)),
+			Size:   size,
+			SHA256: fmt.Sprintf("%x", hasher.Sum(nil)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if packageManifest, err := archiveManifest(archivePath); err == nil && packageManifest.Name != "" {
+		report.Manifest = packageManifest
+	}
+
+	// The validator checks paths against a destination; none is written to
+	report.Security = security.NewPackageValidator(nil).ValidateArchive(archivePath, filepath.Join(os.TempDir(), "rfh-inspect"))
+
+	return report, nil
+}
+
+func printArchiveReport(source string, report *archiveReport) {
+	output.Printf("📦 %s\n", source)
+	output.Printf("🔒 SHA256: %s\n", report.SHA256)
+	output.Printf("📏 Size: %s\n", pkg.FormatSize(report.Size))
+
+	if m := report.Manifest; m != nil {
+		output.Printf("\n📄 Manifest:\n")
+		output.Printf("   Name:        %s\n", m.Name)
+		output.Printf("   Version:     %s\n", m.Version)
+		if m.Description != "" {
+			output.Printf("   Description: %s\n", m.Description)
+		}
+		if m.License != "" {
+			output.Printf("   License:     %s\n", m.License)
+		}
+		if len(m.Targets) > 0 {
+			output.Printf("   Targets:     %s\n", strings.Join(m.Targets, ", "))
+		}
+		if len(m.Tags) > 0 {
+			output.Printf("   Tags:        %s\n", strings.Join(m.Tags, ", "))
+		}
+		if len(m.Dependencies) > 0 {
+			output.Printf("   Depends on:  %s\n", formatDependencies(m.Dependencies))
+		}
+	} else {
+		output.Printf("\n⚠️  No rulestack.json manifest in archive\n")
+	}
+
+	var total int64
+	output.Printf("\n📋 Files:\n")
+	for _, file := range report.Files {
+		output.Printf("   %10s  %s  %s\n", pkg.FormatSize(file.Size), shortHash(file.SHA256), file.Path)
+		total += file.Size
+	}
+	output.Printf("   %d file(s), %s uncompressed\n", len(report.Files), pkg.FormatSize(total))
+
+	if report.Security != nil {
+		output.Printf("\n🛡️  Security: ❌ %v\n", report.Security)
+	} else {
+		output.Printf("\n🛡️  Security: ✅ passed\n")
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestInspectArchive(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "secrets.mdc"), []byte("# Never commit secrets\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	packageManifest := &manifest.PackageManifest{Name: "security-rules", Version: "1.2.0", Files: []string{"*.mdc"}, License: "MIT", Dependencies: map[string]string{"base-rules": "^1.2"}}
+	archivePath := filepath.Join(t.TempDir(), "security-rules-1.2.0.tgz")
+	info, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{})
+	if err != nil {
+		t.Fatalf("packManifestPackage failed: %v", err)
+	}
+
+	report, err := inspectArchive(archivePath)
+	if err != nil {
+		t.Fatalf("inspectArchive failed: %v", err)
+	}
+	if report.SHA256 != info.SHA256 || report.Security != nil {
+		t.Errorf("Expected hash %s and passing security checks, got %+v", info.SHA256, report)
+	}
+	if report.Manifest == nil || report.Manifest.License != "MIT" || report.Manifest.Dependencies["base-rules"] != "^1.2" {
+		t.Errorf("Expected the embedded manifest, got %+v", report.Manifest)
+	}
+	if len(report.Files) != 2 || report.Files[0].Path != "rulestack.json" || report.Files[1].Path != "secrets.mdc" || report.Files[1].Size != 23 {
+		t.Errorf("Expected rulestack.json and secrets.mdc (23 bytes), got %+v", report.Files)
+	}
+
+	t.Run("registry version", func(t *testing.T) {
+		archiveData, _ := os.ReadFile(archivePath)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/packages/security-rules/versions/1.2.0":
+				json.NewEncoder(w).Encode(map[string]string{"name": "security-rules", "version": "1.2.0", "sha256": info.SHA256, "integrity": info.Integrity})
+			case "/v1/packages/security-rules/versions/1.3.0":
+				// Same archive, but the registry recorded a different SHA-512
+				tampered := "sha512-" + base64.StdEncoding.EncodeToString(make([]byte, 64))
+				json.NewEncoder(w).Encode(map[string]string{"name": "security-rules", "version": "1.3.0", "sha256": info.SHA256, "integrity": tampered})
+			case "/v1/blobs/" + info.SHA256:
+				w.Write(archiveData)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		configDir := t.TempDir()
+		t.Setenv("RFH_CONFIG", configDir)
+		configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\n"
+		if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+
+		if err := runInspect("security-rules@1.2.0"); err != nil {
+			t.Errorf("Expected the registry version to be inspected, got %v", err)
+		}
+		if err := runInspect("security-rules@1.3.0"); err == nil || !strings.Contains(err.Error(), "integrity mismatch") {
+			t.Errorf("Expected an archive not matching its SHA-512 to be refused, got %v", err)
+		}
+		if err := runInspect("security-rules@9.9.9"); err == nil {
+			t.Error("Expected an unknown version to fail")
+		}
+	})
+}
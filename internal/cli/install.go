@@ -1,10 +1,13 @@
 package cli
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,7 +15,9 @@ import (
 	"rulestack/internal/client"
 	"rulestack/internal/config"
 	"rulestack/internal/manifest"
-	"rulestack/internal/pkg"
+	"rulestack/internal/output"
+	"rulestack/internal/progress"
+	"rulestack/internal/tempdir"
 	"rulestack/internal/version"
 )
 
@@ -30,18 +35,55 @@ Operations:
 - Updates packages to higher versions specified in manifest
 - Skips packages that are already up-to-date
 - Reports failures but continues processing other packages
+- Applies "overrides" from rulestack.json (forced versions or replacement
+  packages), recording them in rulestack.lock.json
+- Packs "file:" and "git+" dependencies from source without a registry
+- Reports rules that conflict across installed packages (use "priority" in
+  rulestack.json to choose which package takes precedence)
+- Installs "aliases" from rulestack.json alongside the dependencies, so two
+  major versions of a package can be used side by side
+- Installs the base configuration named by "extends" and merges its
+  dependencies and targets into the project (the project's own entries win)
+- Installs the dependencies of meta-packages, which ship no rules of their
+  own (the project's own entries win)
+
+Use --plan to print the computed plan as JSON before installing, or
+--plan-only to print it and stop without changing anything, so CI can review
+or diff it.
+
+With --recursive, every project under the current directory is installed in
+turn, each with its own rulestack.json, registry and mirrors, and the results
+are summarized at the end.
 
 Examples:
-  rfh install .`,
+  rfh install .
+  rfh install . --plan-only > install-plan.json
+  rfh install . --recursive`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if args[0] != "." {
 			return fmt.Errorf("only '.' is supported (current directory)")
 		}
+		if recursive {
+			return runInProjects("install", runInstall)
+		}
 		return runInstall()
 	},
 }
 
+var (
+	installPlan     bool
+	installPlanOnly bool
+	installDryRun   bool
+)
+
+func init() {
+	installCmd.Flags().BoolVar(&installPlan, "plan", false, "print the install plan as JSON before installing")
+	installCmd.Flags().BoolVar(&installPlanOnly, "plan-only", false, "print the install plan as JSON and exit without installing")
+	installCmd.Flags().BoolVar(&installDryRun, "dry-run", false, "print the CLAUDE.md changes as a unified diff without installing")
+	installCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "install every project under the current directory")
+}
+
 // InstallResult represents the result of installing a single package
 type InstallResult struct {
 	Package string
@@ -53,18 +95,43 @@ type InstallResult struct {
 
 // PackageRequirement represents a package that needs to be processed
 type PackageRequirement struct {
-	Name             string
-	RequiredVersion  string
-	InstalledVersion string
-	Action           string // "install", "update", "skip"
-	PackageDir       string // Path to installed package directory
-	Details          string // Additional details about the operation
+	Name             string `json:"name"`                      // Dependency name as declared in rulestack.json
+	Package          string `json:"package"`                   // Package actually installed (differs from Name when overridden)
+	RequiredVersion  string `json:"version"`                   // Registry version, or file:/git+ source spec
+	OverriddenFrom   string `json:"overridden_from,omitempty"` // Original name@version when an override applies
+	Alias            string `json:"alias,omitempty"`           // Name the package is installed under when it comes from "aliases"
+	Inherited        bool   `json:"inherited,omitempty"`       // Dependency comes from the base configuration or a meta-package, not rulestack.json
+	RequiredBy       string `json:"required_by,omitempty"`     // Meta-package whose dependencies include this one
+	Condition        string `json:"condition,omitempty"`       // Unmet condition that keeps the dependency from being installed
+	InstalledVersion string `json:"installed_version,omitempty"`
+	Action           string `json:"action"`            // "install", "update", "skip"
+	PackageDir       string `json:"-"`                 // Path to installed package directory
+	Details          string `json:"details,omitempty"` // Additional details about the operation
+
+	// Set by planInstall for registry packages that will be downloaded
+	Registry    string          `json:"registry,omitempty"`
+	SHA256      string          `json:"sha256,omitempty"`
+	Integrity   string          `json:"integrity,omitempty"` // Archive digests, from registries that record them
+	SizeBytes   int64           `json:"size_bytes,omitempty"`
+	Deprecated  string          `json:"deprecated,omitempty"`
+	Replacement string          `json:"replacement,omitempty"` // What to use instead of a deprecated version
+	Error       string          `json:"error,omitempty"`       // Why the version could not be resolved
+	Bundled     bool            `json:"bundled,omitempty"`     // Core rules installed from the copy built into rfh
+	source      *registrySource // Registry the download comes from
+}
+
+// InstallPlan is everything 'rfh install .' will do, computed before anything
+// is changed
+type InstallPlan struct {
+	Packages   []PackageRequirement `json:"packages"`
+	Downloads  int                  `json:"downloads"`
+	TotalBytes int64                `json:"total_bytes"`
 }
 
 // runInstall implements the install command logic
 func runInstall() error {
 	if verbose {
-		fmt.Printf("📦 Installing packages from project manifest...\n")
+		output.Printf("📦 Installing packages from project manifest...\n")
 	}
 
 	// Find project root
@@ -74,7 +141,7 @@ func runInstall() error {
 	}
 
 	if verbose {
-		fmt.Printf("📁 Project root: %s\n", projectRoot)
+		output.Printf("📁 Project root: %s\n", projectRoot)
 	}
 
 	// Load project manifest
@@ -84,86 +151,301 @@ func runInstall() error {
 		return fmt.Errorf("failed to load project manifest: %w", err)
 	}
 
-	if len(projectManifest.Dependencies) == 0 {
-		fmt.Printf("ℹ️  No dependencies found in rulestack.json\n")
+	if len(projectManifest.Dependencies) == 0 && len(projectManifest.Aliases) == 0 && projectManifest.Extends == "" {
+		output.Printf("ℹ️  No dependencies found in rulestack.json\n")
 		return nil
 	}
 
-	// Validate registry configuration before proceeding
-	cfg, err := config.LoadCLI()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Validate registry configuration before proceeding (not needed when every
+	// dependency is a local path or git source)
+	var registryName string
+	var registry config.Registry
+	var resolver *packageResolver
+	if needsRegistry(projectManifest.ResolvedDependencies()) || len(projectManifest.Aliases) > 0 || projectManifest.Extends != "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := useProjectRegistry(&cfg, projectManifest); err != nil {
+			return err
+		}
+
+		registryName = cfg.Current
+		if registryName == "" {
+			return fmt.Errorf("no registry configured. Use 'rfh registry add' to add a registry")
+		}
+
+		var exists bool
+		registry, exists = cfg.Registries[registryName]
+		if !exists {
+			return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
+		}
+
+		resolver, err = newPackageResolver(cfg, projectManifest.Mirrors)
+		if err != nil {
+			return err
+		}
 	}
 
-	registryName := cfg.Current
-	if registryName == "" {
-		return fmt.Errorf("no registry configured. Use 'rfh registry add' to add a registry")
+	// Merge in the base configuration, so its dependencies are installed and
+	// checked like the project's own. A plan or dry run only uses the installed base.
+	if projectManifest.Extends != "" {
+		if !installPlanOnly && !installDryRun {
+			if err := installBaseConfig(projectRoot, projectManifest, resolver); err != nil {
+				return fmt.Errorf("failed to install base configuration: %w", err)
+			}
+		}
+		if err := applyInherited(projectRoot, projectManifest); err != nil {
+			return err
+		}
 	}
 
-	if _, exists := cfg.Registries[registryName]; !exists {
-		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
+	// Enforce organization constraints before installing anything
+	constraints, err := loadProjectConstraints(projectRoot, projectManifest)
+	if err != nil {
+		return err
+	}
+
+	if err := enforceConstraints(constraints, registryName, registry, projectManifest); err != nil {
+		return err
+	}
+	if resolver != nil {
+		if err := resolver.checkConstraints(constraints); err != nil {
+			return err
+		}
 	}
 
 	// Analyze package requirements
-	requirements, err := analyzePackageRequirements(projectRoot, projectManifest.Dependencies)
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
 	if err != nil {
 		return fmt.Errorf("failed to analyze package requirements: %w", err)
 	}
 
+	// Resolve every registry package in one round trip instead of one request each
+	if resolver != nil {
+		resolver.prefetch(registryRefs(requirements))
+	}
+
+	plan := planInstall(requirements, resolver)
+
+	if installPlan || installPlanOnly {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode install plan: %w", err)
+		}
+		output.Data(data)
+
+		if installPlanOnly {
+			return nil
+		}
+	}
+
+	// A dry run downloads and stages the packages to show how CLAUDE.md changes,
+	// reporting only the ones that could not be staged
+	if installDryRun {
+		err := previewInstall(projectRoot, func() error {
+			for _, result := range processPackages(projectRoot, plan) {
+				if result.Status == "failed" {
+					output.Printf("❌ %s@%s → failed (%s)\n", result.Package, result.Version, result.Details)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		output.Printf("ℹ️  Dry run: nothing was installed\n")
+		return nil
+	}
+
 	// Process all packages
-	results := processPackages(projectRoot, requirements)
+	results := processPackages(projectRoot, plan)
+
+	// Meta-packages installed just now bring in dependencies of their own
+	for {
+		added := applyMetaPackages(projectRoot, projectManifest)
+		if len(added) == 0 {
+			break
+		}
+		if resolver == nil && needsRegistry(projectManifest.ResolvedDependencies()) {
+			return fmt.Errorf("dependencies of meta-packages need a registry. Use 'rfh registry add' to add a registry")
+		}
+		if err := enforceConstraints(constraints, registryName, registry, projectManifest); err != nil {
+			return err
+		}
+
+		metaPlan, err := planMetaDependencies(projectRoot, projectManifest, added, resolver)
+		if err != nil {
+			return err
+		}
+		results = append(results, processPackages(projectRoot, metaPlan)...)
+	}
 
 	// Report results
 	reportInstallResults(results)
 
+	// Keep installed packages where the project's "storage" setting wants them
+	if err := syncPackageStorage(projectRoot); err != nil {
+		return err
+	}
+
+	// Warn about rules that clash across installed packages
+	checkRuleConflicts(projectRoot, projectManifest.ResolvedDependencies(), projectManifest.Priority)
+
 	return nil
 }
 
 // analyzePackageRequirements compares manifest dependencies with installed packages
-func analyzePackageRequirements(projectRoot string, dependencies map[string]string) ([]PackageRequirement, error) {
+func analyzePackageRequirements(projectRoot string, projectManifest *manifest.ProjectManifest) ([]PackageRequirement, error) {
 	requirements := []PackageRequirement{}
 	rulestackDir := filepath.Join(projectRoot, ".rulestack")
 
-	for packageName, requiredVersion := range dependencies {
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	for dependencyName, declaredVersion := range projectManifest.Dependencies {
+		// Apply overrides from the project manifest
+		packageName, requiredVersion, overridden := projectManifest.ResolveDependency(dependencyName, declaredVersion)
+
 		req := PackageRequirement{
-			Name:            packageName,
+			Name:            dependencyName,
+			Package:         packageName,
 			RequiredVersion: requiredVersion,
+			Inherited:       projectManifest.IsInherited(dependencyName),
+			RequiredBy:      projectManifest.RequiredBy(dependencyName),
+		}
+		if overridden {
+			req.OverriddenFrom = fmt.Sprintf("%s@%s", dependencyName, declaredVersion)
+		}
+
+		// Dependencies for other targets or operating systems are left out
+		if !projectManifest.Applies(dependencyName) {
+			condition := projectManifest.Conditions[dependencyName]
+			req.Condition = condition.String()
+			req.Action = "skip"
+			req.Details = fmt.Sprintf("Not needed here (only for %s)", req.Condition)
+			requirements = append(requirements, req)
+			continue
 		}
 
 		// Check if package is already installed
 		installedVersion, packageDir, err := findInstalledPackage(rulestackDir, packageName)
-		if err != nil {
-			req.Action = "install"
-			req.Details = "Package not installed"
-		} else {
+
+		if isSourceSpec(requiredVersion) {
 			req.InstalledVersion = installedVersion
 			req.PackageDir = packageDir
 
-			// Compare versions
-			comparison, err := version.CompareVersions(installedVersion, requiredVersion)
-			if err != nil {
+			// Local paths are always re-packed; git sources and archive URLs only when the spec changed
+			locked, isLocked := lockManifest.Packages[dependencyName]
+			switch {
+			case strings.HasPrefix(requiredVersion, fileSourcePrefix):
 				req.Action = "install"
-				req.Details = fmt.Sprintf("Version comparison failed: %v", err)
-			} else if comparison < 0 {
-				req.Action = "update"
-				req.Details = fmt.Sprintf("Installed: %s → Required: %s", installedVersion, requiredVersion)
-			} else if comparison == 0 {
+				req.Details = "Re-packing local source"
+			case err == nil && isLocked && locked.Source == requiredVersion && locked.Version == installedVersion:
 				req.Action = "skip"
-				req.Details = "Already up-to-date"
-			} else {
-				req.Action = "skip"
-				req.Details = fmt.Sprintf("Installed version %s is newer than required %s", installedVersion, requiredVersion)
+				req.Details = "Already installed from source"
+			case isURLSource(requiredVersion):
+				req.Action = "install"
+				req.Details = "Downloading archive"
+			default:
+				req.Action = "install"
+				req.Details = "Installing from git source"
 			}
+
+			requirements = append(requirements, req)
+			continue
 		}
 
+		setRegistryAction(&req, installedVersion, packageDir, err)
 		requirements = append(requirements, req)
 	}
 
+	// Aliased installs live in their own directory, named after the alias
+	for alias := range projectManifest.Aliases {
+		packageName, requiredVersion, _ := projectManifest.ResolveAlias(alias)
+
+		req := PackageRequirement{
+			Name:            alias,
+			Package:         packageName,
+			RequiredVersion: requiredVersion,
+			Alias:           alias,
+		}
+
+		installedVersion, packageDir, err := findInstalledPackage(rulestackDir, alias)
+		setRegistryAction(&req, installedVersion, packageDir, err)
+		requirements = append(requirements, req)
+	}
+
+	// Keep plans and reports stable between runs
+	sort.Slice(requirements, func(i, j int) bool {
+		return requirements[i].Name < requirements[j].Name
+	})
+
 	return requirements, nil
 }
 
+// planMetaDependencies plans the install of the dependencies a meta-package
+// added to the project manifest
+func planMetaDependencies(projectRoot string, projectManifest *manifest.ProjectManifest, added []string, resolver *packageResolver) (*InstallPlan, error) {
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze package requirements: %w", err)
+	}
+
+	var metaRequirements []PackageRequirement
+	for _, req := range requirements {
+		if slices.Contains(added, req.Name) {
+			metaRequirements = append(metaRequirements, req)
+		}
+	}
+
+	if resolver != nil {
+		resolver.prefetch(registryRefs(metaRequirements))
+	}
+	return planInstall(metaRequirements, resolver), nil
+}
+
+// setRegistryAction decides whether a registry package needs installing or updating
+// by comparing its installed version (if found) with the required one
+func setRegistryAction(req *PackageRequirement, installedVersion, packageDir string, findErr error) {
+	if findErr != nil {
+		req.Action = "install"
+		req.Details = "Package not installed"
+		return
+	}
+
+	req.InstalledVersion = installedVersion
+	req.PackageDir = packageDir
+
+	// Compare versions
+	comparison, err := version.CompareVersions(installedVersion, req.RequiredVersion)
+	if err != nil {
+		req.Action = "install"
+		req.Details = fmt.Sprintf("Version comparison failed: %v", err)
+	} else if comparison < 0 {
+		req.Action = "update"
+		req.Details = fmt.Sprintf("Installed: %s → Required: %s", installedVersion, req.RequiredVersion)
+	} else if comparison == 0 {
+		req.Action = "skip"
+		req.Details = "Already up-to-date"
+	} else {
+		req.Action = "skip"
+		req.Details = fmt.Sprintf("Installed version %s is newer than required %s", installedVersion, req.RequiredVersion)
+	}
+}
+
 // findInstalledPackage finds if a package is installed and returns its version and directory
 func findInstalledPackage(rulestackDir, packageName string) (string, string, error) {
+	if packageName == corePackageName {
+		coreVersion := installedCoreVersion(rulestackDir)
+		if coreVersion == "" {
+			return "", "", fmt.Errorf("package not installed")
+		}
+		return coreVersion, filepath.Join(rulestackDir, packageDirName(corePackageName, coreVersion)), nil
+	}
+
 	// Look for directories matching pattern: packagename.version
 	entries, err := os.ReadDir(rulestackDir)
 	if err != nil {
@@ -174,7 +456,7 @@ func findInstalledPackage(rulestackDir, packageName string) (string, string, err
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !isPackageDir(rulestackDir, entry) {
 			continue
 		}
 
@@ -201,13 +483,71 @@ func findInstalledPackage(rulestackDir, packageName string) (string, string, err
 	return "", "", fmt.Errorf("package not installed")
 }
 
-// processPackages processes all package requirements and returns results
-func processPackages(projectRoot string, requirements []PackageRequirement) []InstallResult {
+// registryRefs returns the registry packages that need installing
+func registryRefs(requirements []PackageRequirement) []client.VersionRef {
+	var refs []client.VersionRef
+	for _, req := range requirements {
+		if (req.Action == "install" || req.Action == "update") && !isSourceSpec(req.RequiredVersion) {
+			refs = append(refs, client.VersionRef{Name: req.Package, Version: req.RequiredVersion})
+		}
+	}
+	return refs
+}
+
+// planInstall finds the registry, hash and size of every registry package that
+// needs downloading. Versions that cannot be resolved are kept in the plan with
+// their error, and fail when the plan is processed.
+func planInstall(requirements []PackageRequirement, resolver *packageResolver) *InstallPlan {
+	plan := &InstallPlan{Packages: requirements}
+
+	for i := range plan.Packages {
+		req := &plan.Packages[i]
+		if (req.Action != "install" && req.Action != "update") || isSourceSpec(req.RequiredVersion) {
+			continue
+		}
+
+		// The core rules of the current template are built in, for projects
+		// without a registry or whose registry does not serve them
+		bundled := req.Alias == "" && isBundledCore(req.Package, req.RequiredVersion)
+		if bundled && resolver == nil {
+			req.Bundled = true
+			continue
+		}
+
+		source, metadata, err := resolver.resolve(req.Package, req.RequiredVersion)
+		if err != nil {
+			if bundled {
+				req.Bundled = true
+				continue
+			}
+			req.Error = fmt.Sprintf("failed to get package version: %v", err)
+			continue
+		}
+
+		req.source = source
+		req.Registry = source.Name
+		req.SHA256 = metadata.SHA256
+		req.Integrity = metadata.Integrity
+		req.SizeBytes = metadata.Size
+		req.Deprecated = metadata.Deprecated
+		req.Replacement = metadata.Replacement
+
+		plan.Downloads++
+		plan.TotalBytes += metadata.Size
+	}
+
+	return plan
+}
+
+// processPackages carries out an install plan and returns the result of each package
+func processPackages(projectRoot string, plan *InstallPlan) []InstallResult {
 	results := []InstallResult{}
 
-	for _, req := range requirements {
+	progress.Start("install", "")
+	for i, req := range plan.Packages {
+		progress.Step("install", "package", req.Package, i, len(plan.Packages))
 		result := InstallResult{
-			Package: req.Name,
+			Package: req.Package,
 			Version: req.RequiredVersion,
 		}
 
@@ -216,7 +556,7 @@ func processPackages(projectRoot string, requirements []PackageRequirement) []In
 			result.Status = "skipped"
 			result.Details = req.Details
 		case "install", "update":
-			err := installSinglePackage(projectRoot, req.Name, req.RequiredVersion)
+			err := installSinglePackage(projectRoot, req)
 			if err != nil {
 				result.Status = "failed"
 				result.Error = err
@@ -232,90 +572,207 @@ func processPackages(projectRoot string, requirements []PackageRequirement) []In
 			}
 		}
 
+		if req.OverriddenFrom != "" && result.Status != "failed" {
+			result.Details = fmt.Sprintf("%s (override of %s)", result.Details, req.OverriddenFrom)
+		}
+		if req.Alias != "" && result.Status != "failed" {
+			result.Details = fmt.Sprintf("%s (as %s)", result.Details, req.Alias)
+		}
+		if req.RequiredBy != "" && result.Status != "failed" {
+			result.Details += fmt.Sprintf(" (from %s)", req.RequiredBy)
+		} else if req.Inherited && result.Status != "failed" {
+			result.Details += " (from base configuration)"
+		}
+
 		results = append(results, result)
 	}
+	progress.Done("install", "", nil)
 
 	return results
 }
 
-// installSinglePackage installs a single package (extracted from add command logic)
-func installSinglePackage(projectRoot, packageName, packageVersion string) error {
+// installSinglePackage installs a single planned package (extracted from add command logic)
+func installSinglePackage(projectRoot string, req PackageRequirement) error {
+	if isSourceSpec(req.RequiredVersion) {
+		return installSourceRequirement(projectRoot, req)
+	}
+
+	if req.Error != "" {
+		return errors.New(req.Error)
+	}
+
+	if req.Bundled {
+		if verbose {
+			output.Printf("📦 Installing %s@%s built into rfh...\n", req.Package, req.RequiredVersion)
+		}
+		if err := installBundledCore(projectRoot); err != nil {
+			return err
+		}
+		// The bundled copy is never quarantined
+		return wireRules(projectRoot, req.Name, &PackageRef{Name: req.Package, Version: req.RequiredVersion}, false)
+	}
+
 	// Create package reference
 	pkgRef := &PackageRef{
-		Name:    packageName,
-		Version: packageVersion,
+		Name:    req.Package,
+		Version: req.RequiredVersion,
 	}
 
 	if verbose {
-		fmt.Printf("📦 Installing %s@%s...\n", pkgRef.FullName(), pkgRef.Version)
+		output.Printf("📦 Installing %s@%s...\n", pkgRef.FullName(), pkgRef.Version)
 	}
 
-	// Get registry configuration
-	cfg, err := config.LoadCLI()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// The plan found the registry serving this version, falling back to mirrors
+	source := req.source
+	sha256 := req.SHA256
+	if sha256 == "" {
+		return fmt.Errorf("package version missing sha256 hash")
 	}
 
-	registryName := cfg.Current
-	if registryName == "" {
-		return fmt.Errorf("no registry configured. Use 'rfh registry add' to add a registry")
+	if req.Deprecated != "" {
+		printDeprecation(pkgRef.Name, pkgRef.Version, req.Deprecated, req.Replacement)
 	}
 
-	if _, exists := cfg.Registries[registryName]; !exists {
-		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
+	// Create .rulestack directory if it doesn't exist
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	if err := os.MkdirAll(rulestackDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .rulestack directory: %w", err)
 	}
 
-	// Create client using new factory
-	c, err := client.GetClient(cfg, verbose)
+	// Download package
+	tempFile, err := tempdir.Path(fmt.Sprintf("%s-%s-*.tgz", pkgRef.Name, pkgRef.Version))
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tempFile)
 
-	ctx, cancel := client.WithTimeout(context.Background())
-	defer cancel()
+	// Downloads have no overall deadline, so large archives are not cut off; the
+	// client fails transfers that stall
+	if err := source.downloadPackage(commandContext, pkgRef.Name, pkgRef.Version, sha256, req.SizeBytes, tempFile); err != nil {
+		return fmt.Errorf("failed to download package from %s: %w", source.Name, err)
+	}
 
-	// Get package version info
-	versionInfo, err := c.GetPackageVersion(ctx, pkgRef.Name, pkgRef.Version)
+	verified, err := checkArchive(tempFile, req.Integrity, sha256)
 	if err != nil {
-		return fmt.Errorf("failed to get package version: %w", err)
+		return fmt.Errorf("package downloaded from %s failed its integrity check: %w", source.Name, err)
 	}
 
-	// Extract SHA256 from version info
-	sha256 := versionInfo.SHA256
-	if sha256 == "" {
-		return fmt.Errorf("package version missing sha256 hash")
+	// Refuse packages whose declared requirements this project does not meet, or
+	// whose license its policy does not allow
+	packageManifest, err := archiveManifest(tempFile)
+	if err != nil {
+		return err
 	}
-
-	// Create .rulestack directory if it doesn't exist
-	rulestackDir := filepath.Join(projectRoot, ".rulestack")
-	if err := os.MkdirAll(rulestackDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .rulestack directory: %w", err)
+	if err := checkCompatibility(projectRoot, pkgRef.Name, pkgRef.Version, packageManifest.Requires); err != nil {
+		return err
 	}
-
-	// Download package
-	tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s-%s.tgz", pkgRef.Name, pkgRef.Version))
-
-	if err := c.DownloadBlob(ctx, sha256, tempFile); err != nil {
-		return fmt.Errorf("failed to download package: %w", err)
+	if err := checkLicensePolicy(projectRoot, pkgRef.Name, pkgRef.Version, packageManifest.License); err != nil {
+		return err
 	}
-	defer os.Remove(tempFile) // Clean up temp file
 
-	// Extract package
-	packageDir := filepath.Join(rulestackDir, fmt.Sprintf("%s.%s", pkgRef.Name, pkgRef.Version))
-	if err := pkg.Unpack(tempFile, packageDir); err != nil {
+	// Extract package. Aliases get a directory of their own so that another
+	// version of the same package can be installed next to it.
+	installed := &PackageRef{Name: pkgRef.Name, Version: pkgRef.Version}
+	if req.Alias != "" {
+		installed.Name = req.Alias
+	}
+	packageDir := packageInstallDir(projectRoot, packageDirName(installed.Name, installed.Version))
+	extracted, err := unpackPackage(tempFile, packageDir)
+	if err != nil {
 		return fmt.Errorf("failed to extract package: %w", err)
 	}
+	files := extracted.Hashes()
+
+	lockName := req.Name
+	if req.Alias != "" {
+		lockName = req.Alias
+	}
+	if previewingInstall() {
+		return wireStagedRules(projectRoot, lockName, installed, LockPackageEntry{Version: pkgRef.Version, SHA256: sha256})
+	}
 
-	// Update manifests
-	if err := updateManifests(projectRoot, pkgRef, sha256); err != nil {
+	// Update manifests. Overridden dependencies keep their declared version in
+	// rulestack.json and record the override in the lock file instead; aliases
+	// and dependencies from the base configuration only need a lock entry.
+	if req.Inherited && req.OverriddenFrom == "" {
+		entry := LockPackageEntry{
+			Version:   pkgRef.Version,
+			SHA256:    sha256,
+			Integrity: verified,
+			Registry:  source.Name,
+			Files:     files,
+		}
+		if err := updateLockEntry(projectRoot, req.Name, entry); err != nil {
+			return fmt.Errorf("failed to update lock manifest: %w", err)
+		}
+	} else if req.Alias != "" {
+		entry := LockPackageEntry{
+			Version:   pkgRef.Version,
+			SHA256:    sha256,
+			Integrity: verified,
+			Package:   pkgRef.Name,
+			Registry:  source.Name,
+			Files:     files,
+		}
+		if err := updateLockEntry(projectRoot, req.Alias, entry); err != nil {
+			return fmt.Errorf("failed to update lock manifest: %w", err)
+		}
+	} else if req.OverriddenFrom != "" {
+		entry := LockPackageEntry{
+			Version:        pkgRef.Version,
+			SHA256:         sha256,
+			Integrity:      verified,
+			OverriddenFrom: req.OverriddenFrom,
+			Registry:       source.Name,
+			Files:          files,
+		}
+		if pkgRef.Name != req.Name {
+			entry.Package = pkgRef.Name
+		}
+		if err := updateLockEntry(projectRoot, req.Name, entry); err != nil {
+			return fmt.Errorf("failed to update lock manifest: %w", err)
+		}
+	} else if err := updateManifests(projectRoot, pkgRef, sha256, verified, source.Name, files); err != nil {
 		return fmt.Errorf("failed to update manifests: %w", err)
 	}
 
 	// Update CLAUDE.md with new package rules
-	if err := updateClaudeFile(projectRoot, pkgRef); err != nil {
+	if err := wirePackageRules(projectRoot, lockName, installed); err != nil {
 		// Don't fail the entire operation if CLAUDE.md update fails
 		if verbose {
-			fmt.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+			output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// installSourceRequirement installs a file:, git+ or archive URL dependency and records it in the lock file
+func installSourceRequirement(projectRoot string, req PackageRequirement) error {
+	if verbose {
+		output.Printf("📂 Packing %s from %s...\n", req.Package, req.RequiredVersion)
+	}
+
+	entry, err := installSourcePackage(projectRoot, req.Package, req.RequiredVersion)
+	if err != nil {
+		return err
+	}
+	if previewingInstall() {
+		return wireStagedRules(projectRoot, req.Name, &PackageRef{Name: req.Package, Version: entry.Version}, *entry)
+	}
+
+	entry.OverriddenFrom = req.OverriddenFrom
+	if req.Package != req.Name {
+		entry.Package = req.Package
+	}
+
+	if err := updateLockEntry(projectRoot, req.Name, *entry); err != nil {
+		return fmt.Errorf("failed to update lock manifest: %w", err)
+	}
+
+	if err := wirePackageRules(projectRoot, req.Name, &PackageRef{Name: req.Package, Version: entry.Version}); err != nil {
+		if verbose {
+			output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
 		}
 	}
 
@@ -324,7 +781,7 @@ func installSinglePackage(projectRoot, packageName, packageVersion string) error
 
 // reportInstallResults prints a comprehensive report of installation results
 func reportInstallResults(results []InstallResult) {
-	fmt.Printf("\n📦 Installation Summary:\n")
+	output.Printf("\n📦 Installation Summary:\n")
 
 	installed := 0
 	updated := 0
@@ -334,23 +791,23 @@ func reportInstallResults(results []InstallResult) {
 	for _, result := range results {
 		switch result.Status {
 		case "installed":
-			fmt.Printf("✅ %s@%s → installed successfully\n", result.Package, result.Version)
+			output.Printf("✅ %s@%s → installed successfully\n", result.Package, result.Version)
 			installed++
 		case "updated":
-			fmt.Printf("✅ %s@%s → %s\n", result.Package, result.Version, result.Details)
+			output.Printf("✅ %s@%s → %s\n", result.Package, result.Version, result.Details)
 			updated++
 		case "skipped":
-			fmt.Printf("⏭️ %s@%s → %s\n", result.Package, result.Version, result.Details)
+			output.Printf("⏭️ %s@%s → %s\n", result.Package, result.Version, result.Details)
 			skipped++
 		case "failed":
-			fmt.Printf("❌ %s@%s → failed (%s)\n", result.Package, result.Version, result.Details)
+			output.Printf("❌ %s@%s → failed (%s)\n", result.Package, result.Version, result.Details)
 			failed++
 		}
 	}
 
-	fmt.Printf("\nSummary: %d installed, %d updated, %d skipped, %d failed\n", installed, updated, skipped, failed)
+	output.Printf("\nSummary: %d installed, %d updated, %d skipped, %d failed\n", installed, updated, skipped, failed)
 
 	if failed > 0 {
-		fmt.Printf("⚠️  Some packages failed to install. Check network connectivity and registry access.\n")
+		output.Printf("⚠️  Some packages failed to install. Check network connectivity and registry access.\n")
 	}
 }
@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
 )
 
 func TestRunInstall_NoConfigFile(t *testing.T) {
@@ -130,4 +136,278 @@ func TestRunInstall_NoDependencies(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Expected install to succeed with no dependencies, but got error: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestAnalyzePackageRequirements_Overrides(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	// Fork already installed at the overridden version
+	if err := os.MkdirAll(filepath.Join(projectRoot, ".rulestack", "style-rules-fork.2.0.3"), 0755); err != nil {
+		t.Fatalf("Failed to create package dir: %v", err)
+	}
+
+	projectManifest := &manifest.ProjectManifest{
+		Version: "1.0.0",
+		Dependencies: map[string]string{
+			"style-rules": "2.0.0",
+		},
+		Overrides: map[string]string{
+			"style-rules": "style-rules-fork@2.0.3",
+		},
+	}
+
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		t.Fatalf("analyzePackageRequirements failed: %v", err)
+	}
+
+	if len(requirements) != 1 {
+		t.Fatalf("Expected 1 requirement, got %d", len(requirements))
+	}
+
+	req := requirements[0]
+	if req.Name != "style-rules" || req.Package != "style-rules-fork" || req.RequiredVersion != "2.0.3" {
+		t.Errorf("Override not applied: %+v", req)
+	}
+	if req.OverriddenFrom != "style-rules@2.0.0" {
+		t.Errorf("Expected provenance 'style-rules@2.0.0', got '%s'", req.OverriddenFrom)
+	}
+	if req.Action != "skip" {
+		t.Errorf("Expected installed fork to be skipped, got action '%s'", req.Action)
+	}
+}
+
+func TestAnalyzePackageRequirements_Aliases(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	// The current major is installed; the aliased older major is not yet
+	if err := os.MkdirAll(filepath.Join(projectRoot, ".rulestack", "security-rules.2.0.0"), 0755); err != nil {
+		t.Fatalf("Failed to create package dir: %v", err)
+	}
+
+	projectManifest := &manifest.ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"security-rules": "2.0.0"},
+		Aliases:      map[string]string{"security-rules-v1": "security-rules@1.4.0"},
+	}
+
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		t.Fatalf("analyzePackageRequirements failed: %v", err)
+	}
+
+	if len(requirements) != 2 {
+		t.Fatalf("Expected 2 requirements, got %d", len(requirements))
+	}
+
+	dependency, alias := requirements[0], requirements[1]
+	if dependency.Alias != "" || dependency.Action != "skip" {
+		t.Errorf("Expected the installed dependency to be skipped, got %+v", dependency)
+	}
+	if alias.Alias != "security-rules-v1" || alias.Package != "security-rules" || alias.RequiredVersion != "1.4.0" {
+		t.Errorf("Alias not resolved: %+v", alias)
+	}
+	if alias.Action != "install" {
+		t.Errorf("Expected the alias to be installed separately, got action '%s'", alias.Action)
+	}
+}
+
+func TestAnalyzePackageRequirements_Conditions(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	projectManifest := &manifest.ProjectManifest{
+		Version: "1.0.0",
+		Dependencies: map[string]string{
+			"cursor-rules": "1.0.0",
+			"claude-rules": "1.0.0",
+		},
+		Targets: []string{"claude-code"},
+		Conditions: map[string]manifest.Condition{
+			"cursor-rules": {Targets: []string{"cursor"}},
+			"claude-rules": {Targets: []string{"claude-code"}},
+		},
+	}
+
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		t.Fatalf("analyzePackageRequirements failed: %v", err)
+	}
+
+	if len(requirements) != 2 {
+		t.Fatalf("Expected 2 requirements, got %d", len(requirements))
+	}
+
+	claude, cursor := requirements[0], requirements[1]
+	if claude.Condition != "" || claude.Action != "install" {
+		t.Errorf("Expected the dependency for a project target to be installed, got %+v", claude)
+	}
+	if cursor.Condition != "targets cursor" || cursor.Action != "skip" {
+		t.Errorf("Expected the dependency for another target to be skipped, got %+v", cursor)
+	}
+	if _, ok := projectManifest.ResolvedDependencies()["cursor-rules"]; ok {
+		t.Error("Expected the dependency for another target to be left out of resolved dependencies")
+	}
+}
+
+func TestRunInstall_PlanOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages/security-rules/versions/1.0.0":
+			w.Write([]byte(`{"name": "security-rules", "version": "1.0.0", "sha256": "abc123", "size_bytes": 2048}`))
+		case "/v1/packages/api-rules/versions/3.0.0":
+			w.Write([]byte(`{"name": "api-rules", "version": "3.0.0", "sha256": "def456", "size_bytes": 1024, "deprecated": "Use 3.1.0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	projectRoot := t.TempDir()
+	manifestContent := `{"version": "1.0.0", "dependencies": {"security-rules": "1.0.0", "api-rules": "3.0.0", "logging-rules": "2.0.0", "missing-rules": "1.0.0"}}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(projectRoot, ".rulestack", "logging-rules.2.0.0"), 0755); err != nil {
+		t.Fatalf("Failed to create package dir: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectRoot)
+
+	installPlanOnly = true
+	defer func() { installPlanOnly = false }()
+
+	if err := runInstall(); err != nil {
+		t.Fatalf("runInstall --plan-only failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(projectRoot, "rulestack.lock.json")); !os.IsNotExist(err) {
+		t.Error("expected --plan-only not to write the lock file")
+	}
+	if _, _, err := findInstalledPackage(filepath.Join(projectRoot, ".rulestack"), "security-rules"); err == nil {
+		t.Error("expected --plan-only not to install anything")
+	}
+
+	projectManifest, _ := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		t.Fatalf("analyzePackageRequirements failed: %v", err)
+	}
+	cfg, err := config.LoadCLI()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	resolver, err := newPackageResolver(cfg, nil)
+	if err != nil {
+		t.Fatalf("newPackageResolver failed: %v", err)
+	}
+
+	plan := planInstall(requirements, resolver)
+	if plan.Downloads != 2 || plan.TotalBytes != 3072 {
+		t.Errorf("expected 2 downloads totalling 3072 bytes, got %d and %d", plan.Downloads, plan.TotalBytes)
+	}
+
+	byName := make(map[string]PackageRequirement)
+	for _, req := range plan.Packages {
+		byName[req.Name] = req
+	}
+	if req := byName["api-rules"]; req.Registry != "corp" || req.SHA256 != "def456" || req.Deprecated != "Use 3.1.0" {
+		t.Errorf("expected api-rules to be resolved with its metadata, got %+v", req)
+	}
+	if req := byName["logging-rules"]; req.Action != "skip" || req.SHA256 != "" {
+		t.Errorf("expected the installed package to be skipped without a download, got %+v", req)
+	}
+	if req := byName["missing-rules"]; req.Error == "" {
+		t.Errorf("expected an unresolvable version to carry its error, got %+v", req)
+	}
+}
+
+func TestRunInstall_MetaPackage(t *testing.T) {
+	packDir := func(files map[string]string) *pkg.ArchiveInfo {
+		stageDir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(stageDir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		archive, err := pkg.PackFromDirectory(stageDir, filepath.Join(t.TempDir(), "package.tgz"))
+		if err != nil {
+			t.Fatalf("Failed to pack archive: %v", err)
+		}
+		return archive
+	}
+	meta := packDir(map[string]string{
+		"rulestack.json": `{"name": "backend-profile", "version": "1.0.0", "type": "meta", "dependencies": {"security-rules": "1.0.0", "logging-rules": "2.0.0"}}`,
+	})
+	security := packDir(map[string]string{
+		"rulestack.json":    `{"name": "security-rules", "version": "1.0.0", "files": ["rules/*.md"]}`,
+		"rules/security.md": "# Security\n",
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages/backend-profile/versions/1.0.0":
+			w.Write([]byte(`{"name": "backend-profile", "version": "1.0.0", "sha256": "` + meta.SHA256 + `"}`))
+		case "/v1/packages/security-rules/versions/1.0.0":
+			w.Write([]byte(`{"name": "security-rules", "version": "1.0.0", "sha256": "` + security.SHA256 + `"}`))
+		case "/v1/blobs/" + meta.SHA256:
+			data, _ := os.ReadFile(meta.Path)
+			w.Write(data)
+		case "/v1/blobs/" + security.SHA256:
+			data, _ := os.ReadFile(security.Path)
+			w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	// The project pins logging-rules itself, so the meta-package's version is not used
+	projectRoot := t.TempDir()
+	manifestContent := `{"version": "1.0.0", "dependencies": {"backend-profile": "1.0.0", "logging-rules": "1.5.0"}}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(projectRoot, ".rulestack", "logging-rules.1.5.0"), 0755); err != nil {
+		t.Fatalf("Failed to create package dir: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectRoot)
+
+	if err := runInstall(); err != nil {
+		t.Fatalf("runInstall failed: %v", err)
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	if _, err := os.Stat(filepath.Join(rulestackDir, "security-rules.1.0.0", "rules", "security.md")); err != nil {
+		t.Errorf("expected the meta-package's dependency to be installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rulestackDir, "logging-rules.2.0.0")); !os.IsNotExist(err) {
+		t.Error("expected the project's own logging-rules version to win over the meta-package's")
+	}
+
+	projectManifest, _ := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if _, exists := projectManifest.Dependencies["security-rules"]; exists {
+		t.Errorf("expected rulestack.json to keep only the project's own dependencies, got %v", projectManifest.Dependencies)
+	}
+}
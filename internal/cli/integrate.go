@@ -0,0 +1,343 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/output"
+	"rulestack/internal/tempdir"
+)
+
+var (
+	integrateDryRun bool
+	integrateCheck  bool
+)
+
+// integrateCmd represents the integrate command
+var integrateCmd = &cobra.Command{
+	Use:   "integrate",
+	Short: "Bring CLAUDE.md in line with rulestack.lock.json",
+	Long: `Reference the rules of every installed, trusted package in rulestack.lock.json
+from CLAUDE.md, and remove references to package directories that are no
+longer installed or are quarantined. The rest of CLAUDE.md is left as it is.
+
+With --dry-run, the changes are printed as a unified diff and nothing is
+written. With --check, the command also exits with an error when CLAUDE.md is
+out of sync, so CI can catch a lock file committed without its editor changes.
+
+Examples:
+  rfh integrate
+  rfh integrate --dry-run
+  rfh integrate --check`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if recursive {
+			return runInProjects("integrate", runIntegrate)
+		}
+		return runIntegrate()
+	},
+}
+
+// errIntegrationOutOfSync is returned by 'rfh integrate --check'
+var errIntegrationOutOfSync = errors.New("CLAUDE.md is out of sync with rulestack.lock.json; run 'rfh integrate'")
+
+func runIntegrate() error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	updates, err := planIntegration(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		output.Printf("✅ CLAUDE.md is in sync with rulestack.lock.json\n")
+		return nil
+	}
+
+	if integrateDryRun || integrateCheck {
+		printEditorDiffs(updates)
+		if integrateCheck {
+			return errIntegrationOutOfSync
+		}
+		return nil
+	}
+
+	for _, update := range updates {
+		if err := applyFileUpdate(projectRoot, update); err != nil {
+			return err
+		}
+	}
+	output.Printf("✅ Updated CLAUDE.md from rulestack.lock.json\n")
+	return nil
+}
+
+// planIntegration works out the editor file changes that reference every
+// trusted package in the lock file and drop references to the rest
+func planIntegration(projectRoot string) ([]fileUpdate, error) {
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	names := make([]string, 0, len(lockManifest.Packages))
+	for name := range lockManifest.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return previewEditorChanges(projectRoot, func() error {
+		// References to quarantined or missing package directories go
+		quarantined := make(map[string]bool)
+		for _, name := range names {
+			if entry := lockManifest.Packages[name]; entry.Quarantined {
+				quarantined[filepath.Base(installedPackageDir(rulestackDir, name, entry))] = true
+			}
+		}
+		for _, dirName := range referencedPackageDirs(projectRoot) {
+			if _, err := os.Stat(filepath.Join(rulestackDir, dirName)); err == nil && !quarantined[dirName] {
+				continue
+			}
+			if err := removeClaudeRules(projectRoot, dirName); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range names {
+			entry := lockManifest.Packages[name]
+			if entry.Quarantined {
+				continue
+			}
+			if _, err := os.Stat(installedPackageDir(rulestackDir, name, entry)); err != nil {
+				continue
+			}
+			if err := updateClaudeFile(projectRoot, installedPackageRef(name, entry)); err != nil {
+				return fmt.Errorf("failed to update CLAUDE.md for %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// referencedPackageDirs lists the .rulestack/ directories CLAUDE.md references
+func referencedPackageDirs(projectRoot string) []string {
+	content, err := readEditorFile(filepath.Join(projectRoot, "CLAUDE.md"))
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, line := range strings.Split(string(content), "\n") {
+		ref, ok := strings.CutPrefix(strings.TrimSpace(line), "- @.rulestack/")
+		if !ok {
+			continue
+		}
+		dirName, _, found := strings.Cut(ref, "/")
+		if found && !seen[dirName] {
+			seen[dirName] = true
+			dirs = append(dirs, dirName)
+		}
+	}
+	return dirs
+}
+
+// editorPreview collects editor file writes instead of making them while a
+// preview is running: the content each file would get, by path
+var editorPreview map[string][]byte
+
+// readEditorFile reads an editor integration file such as CLAUDE.md, as
+// changed so far by a running preview
+func readEditorFile(path string) ([]byte, error) {
+	if content, ok := editorPreview[path]; ok {
+		return content, nil
+	}
+	return os.ReadFile(path)
+}
+
+// writeEditorFile writes an editor integration file, or records the write when
+// a preview is running
+func writeEditorFile(path string, content []byte) error {
+	if editorPreview != nil {
+		editorPreview[path] = content
+		return nil
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// previewEditorChanges runs change without writing editor files and returns the
+// changes it would have made, relative to the project root
+func previewEditorChanges(projectRoot string, change func() error) ([]fileUpdate, error) {
+	editorPreview = make(map[string][]byte)
+	defer func() { editorPreview = nil }()
+
+	if err := change(); err != nil {
+		return nil, err
+	}
+
+	var updates []fileUpdate
+	for path, content := range editorPreview {
+		old, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if string(old) == string(content) {
+			continue
+		}
+		rel, err := filepath.Rel(projectRoot, path)
+		if err != nil {
+			rel = path
+		}
+		updates = append(updates, fileUpdate{Path: filepath.ToSlash(rel), Old: string(old), New: string(content)})
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Path < updates[j].Path })
+	return updates, nil
+}
+
+// printEditorDiffs prints editor file changes as a unified diff
+func printEditorDiffs(updates []fileUpdate) {
+	for _, update := range updates {
+		output.Data([]byte(strings.TrimSuffix(unifiedDiff(update.Path, update.Old, update.New), "\n")))
+	}
+}
+
+// previewStageDir is where packages are extracted instead of .rulestack/ while
+// a dry run previews installing them
+var previewStageDir string
+
+// previewingInstall reports whether a dry run is previewing an install, so
+// nothing may be recorded in the manifests or lock file
+func previewingInstall() bool {
+	return previewStageDir != ""
+}
+
+// packageInstallDir returns the directory a package version is extracted to
+func packageInstallDir(projectRoot, dirName string) string {
+	if previewingInstall() {
+		return filepath.Join(previewStageDir, dirName)
+	}
+	return filepath.Join(projectRoot, ".rulestack", dirName)
+}
+
+// packageRulesDir returns the directory a package's rule files are read from:
+// the copy a running dry run staged, else the installed one
+func packageRulesDir(projectRoot, dirName string) string {
+	if previewingInstall() {
+		staged := filepath.Join(previewStageDir, dirName)
+		if info, err := os.Stat(staged); err == nil && info.IsDir() {
+			return staged
+		}
+	}
+	return filepath.Join(projectRoot, ".rulestack", dirName)
+}
+
+// previewInstall runs install as a dry run and prints the editor file changes
+// it would make as a unified diff
+func previewInstall(projectRoot string, install func() error) error {
+	updates, err := stageInstall(projectRoot, install)
+	if err != nil {
+		return err
+	}
+	printEditorDiffs(updates)
+	return nil
+}
+
+// stageInstall runs install with packages extracted to a temp directory and the
+// manifests and lock file left alone, and returns the editor file changes it
+// would have made
+func stageInstall(projectRoot string, install func() error) ([]fileUpdate, error) {
+	stageDir, err := tempdir.MkdirTemp("dry-run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	previewStageDir = stageDir
+	defer func() {
+		previewStageDir = ""
+		os.RemoveAll(stageDir)
+	}()
+
+	return previewEditorChanges(projectRoot, install)
+}
+
+// unifiedDiff formats the change from oldText to newText as a unified diff of
+// path with three lines of context, as 'diff -u' and 'git apply' expect
+func unifiedDiff(path, oldText, newText string) string {
+	const context = 3
+	lines := diffLines(oldText, newText)
+
+	var b strings.Builder
+	if oldText == "" {
+		b.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "--- a/%s\n", path)
+	}
+	fmt.Fprintf(&b, "+++ b/%s\n", path)
+
+	for start := 0; start < len(lines); {
+		// Find the next change and extend the hunk while changes are close
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for n := first; n < len(lines) && n <= last+2*context; n++ {
+			if lines[n].op != ' ' {
+				last = n
+			}
+		}
+		from := max(start, first-context)
+		to := min(len(lines), last+context+1)
+
+		oldLine, newLine := 1, 1
+		for _, line := range lines[:from] {
+			if line.op != '+' {
+				oldLine++
+			}
+			if line.op != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldLine--
+		}
+		if newCount == 0 {
+			newLine--
+		}
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, line := range lines[from:to] {
+			b.WriteByte(line.op)
+			b.WriteString(line.text)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return b.String()
+}
+
+func init() {
+	integrateCmd.Flags().BoolVar(&integrateDryRun, "dry-run", false, "print the changes as a unified diff without writing them")
+	integrateCmd.Flags().BoolVar(&integrateCheck, "check", false, "exit with an error when CLAUDE.md is out of sync with rulestack.lock.json")
+	integrateCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "integrate every project under the current directory")
+}
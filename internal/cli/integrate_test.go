@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunIntegrate(t *testing.T) {
+	tempDir := setupQuarantineProject(t, false)
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+
+	// CLAUDE.md still references a version that is no longer installed
+	claudePath := filepath.Join(tempDir, "CLAUDE.md")
+	stale := "# CLAUDE.md\n\n## Active Rules (Rulestack core)\n- @.rulestack/security-rules.0.9.0/secure.md\n\n## Notes\nKeep this.\n"
+	if err := os.WriteFile(claudePath, []byte(stale), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+
+	integrateCheck = true
+	err := runIntegrate()
+	integrateCheck = false
+	if !errors.Is(err, errIntegrationOutOfSync) {
+		t.Fatalf("expected --check to fail for an out of sync CLAUDE.md, got %v", err)
+	}
+
+	integrateDryRun = true
+	err = runIntegrate()
+	integrateDryRun = false
+	if err != nil {
+		t.Fatalf("runIntegrate --dry-run failed: %v", err)
+	}
+	if claude, _ := os.ReadFile(claudePath); string(claude) != stale {
+		t.Errorf("expected --dry-run to leave CLAUDE.md alone, got %q", claude)
+	}
+
+	if err := runIntegrate(); err != nil {
+		t.Fatalf("runIntegrate failed: %v", err)
+	}
+	claude, _ := os.ReadFile(claudePath)
+	if strings.Contains(string(claude), "security-rules.0.9.0") || !strings.Contains(string(claude), "- @.rulestack/security-rules.1.0.0/secure.md") {
+		t.Errorf("expected the stale reference replaced by the installed version, got %q", claude)
+	}
+	if !strings.Contains(string(claude), "Keep this.") {
+		t.Errorf("expected the rest of CLAUDE.md to be kept, got %q", claude)
+	}
+
+	integrateCheck = true
+	defer func() { integrateCheck = false }()
+	if err := runIntegrate(); err != nil {
+		t.Errorf("expected --check to pass once integrated, got %v", err)
+	}
+}
+
+func TestRunTrustDryRun(t *testing.T) {
+	tempDir := setupQuarantineProject(t, true)
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+
+	trustDryRun = true
+	defer func() { trustDryRun = false }()
+	if err := runTrust("security-rules"); err != nil {
+		t.Fatalf("runTrust --dry-run failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to write CLAUDE.md")
+	}
+	lockManifest, _ := loadOrCreateLockManifest(filepath.Join(tempDir, "rulestack.lock.json"), tempDir)
+	if !lockManifest.Packages["security-rules"].Quarantined {
+		t.Error("expected --dry-run to keep the package quarantined")
+	}
+}
+
+// setupSourceProject creates a my-rules@0.2.0 package source and a project next
+// to it with the given rulestack.json, and changes into the project
+func setupSourceProject(t *testing.T, manifestContent string) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "my-rules")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "rules"), 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+	sourceManifest := `{"name": "my-rules", "version": "0.2.0", "files": ["rules/*.md"]}`
+	if err := os.WriteFile(filepath.Join(sourceDir, "rulestack.pkg.json"), []byte(sourceManifest), 0644); err != nil {
+		t.Fatalf("Failed to write source manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "rules", "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	projectDir := filepath.Join(tempDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectDir)
+	t.Setenv("RFH_CONFIG", filepath.Join(tempDir, ".rfh"))
+
+	return projectDir
+}
+
+func TestRunInstallDryRun(t *testing.T) {
+	projectDir := setupSourceProject(t, `{"version": "1.0.0", "dependencies": {"my-rules": "file:../my-rules"}}`)
+
+	installDryRun = true
+	err := runInstall()
+	installDryRun = false
+	if err != nil {
+		t.Fatalf("runInstall --dry-run failed: %v", err)
+	}
+	for _, path := range []string{".rulestack/my-rules.0.2.0", "rulestack.lock.json", "CLAUDE.md"} {
+		if _, err := os.Stat(filepath.Join(projectDir, path)); !os.IsNotExist(err) {
+			t.Errorf("expected --dry-run not to create %s", path)
+		}
+	}
+
+	// The staged package is what the CLAUDE.md diff references
+	req := PackageRequirement{Name: "my-rules", Package: "my-rules", RequiredVersion: "file:../my-rules"}
+	updates, err := stageInstall(projectDir, func() error { return installSourceRequirement(projectDir, req) })
+	if err != nil {
+		t.Fatalf("stageInstall failed: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Path != "CLAUDE.md" || !strings.Contains(updates[0].New, "- @.rulestack/my-rules.0.2.0/rules/style.md") {
+		t.Errorf("expected CLAUDE.md to gain the staged rule, got %+v", updates)
+	}
+
+	// A package that would be quarantined leaves CLAUDE.md alone
+	quarantined := `{"version": "1.0.0", "dependencies": {"my-rules": "file:../my-rules"}, "quarantine": true}`
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(quarantined), 0644); err != nil {
+		t.Fatalf("Failed to update manifest: %v", err)
+	}
+	updates, err = stageInstall(projectDir, func() error { return installSourceRequirement(projectDir, req) })
+	if err != nil {
+		t.Fatalf("stageInstall failed: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected no changes for a quarantined package, got %+v", updates)
+	}
+}
+
+func TestRunAddDryRun(t *testing.T) {
+	manifestContent := `{"version": "1.0.0", "dependencies": {}}`
+	projectDir := setupSourceProject(t, manifestContent)
+
+	updates, err := stageInstall(projectDir, func() error { return runAdd("my-rules@file:../my-rules", "", false) })
+	if err != nil {
+		t.Fatalf("runAdd --dry-run failed: %v", err)
+	}
+	if len(updates) != 1 || !strings.Contains(updates[0].New, "- @.rulestack/my-rules.0.2.0/rules/style.md") {
+		t.Errorf("expected CLAUDE.md to gain the staged rule, got %+v", updates)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(projectDir, "rulestack.json")); string(data) != manifestContent {
+		t.Errorf("expected --dry-run to leave rulestack.json alone, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".rulestack", "my-rules.0.2.0")); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to install the package")
+	}
+}
+
+func TestRunInitDryRun(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	projectRoot := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+
+	if err := runInit(false, true); err != nil {
+		t.Fatalf("runInit --dry-run failed: %v", err)
+	}
+
+	updates, err := stageInstall(projectRoot, func() error { return writeProjectRules(projectRoot) })
+	if err != nil {
+		t.Fatalf("stageInstall failed: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Path != "CLAUDE.md" || updates[0].New != claudeTemplate() {
+		t.Errorf("expected CLAUDE.md to be created from the template, got %+v", updates)
+	}
+
+	entries, _ := os.ReadDir(projectRoot)
+	if len(entries) != 0 {
+		t.Errorf("expected --dry-run to create nothing, found %d entries", len(entries))
+	}
+}
+
+func TestRunLinkDryRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("junction creation requires cmd.exe")
+	}
+
+	projectDir := setupSourceProject(t, `{"version": "1.0.0", "dependencies": {}}`)
+	claudePath := filepath.Join(projectDir, "CLAUDE.md")
+	if err := os.WriteFile(claudePath, []byte(claudeTemplate()), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+
+	linkDryRun = true
+	defer func() { linkDryRun = false }()
+	if err := runLink(filepath.Join(projectDir, "..", "my-rules")); err != nil {
+		t.Fatalf("runLink --dry-run failed: %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(projectDir, ".rulestack", "my-rules.0.2.0")); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to create the link")
+	}
+	if _, err := os.Stat(linksPath(projectDir)); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to record the link")
+	}
+	if claude, _ := os.ReadFile(claudePath); string(claude) != claudeTemplate() {
+		t.Errorf("expected --dry-run to leave CLAUDE.md alone, got %q", claude)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newText := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\n"
+
+	want := `--- a/CLAUDE.md
++++ b/CLAUDE.md
+@@ -2,9 +2,10 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+ i
+ j
++k
+`
+	if got := unifiedDiff("CLAUDE.md", oldText, newText); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := unifiedDiff("CLAUDE.md", "", "a\n"); got != "--- /dev/null\n+++ b/CLAUDE.md\n@@ -0,0 +1,1 @@\n+a\n" {
+		t.Errorf("unexpected diff for a new file:\n%s", got)
+	}
+}
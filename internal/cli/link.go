@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+var (
+	linkPackageName string
+	linkDryRun      bool
+)
+
+// LinkedPackage records a package source linked into the project
+type LinkedPackage struct {
+	Path    string `json:"path"`    // Absolute path to the package source directory
+	Version string `json:"version"` // Version from the source manifest at link time
+}
+
+// linkCmd represents the link command
+var linkCmd = &cobra.Command{
+	Use:   "link <path>",
+	Short: "Link a local package source into this project",
+	Long: `Link a local package source directory into .rulestack/ of the current project
+so rule changes are picked up immediately, without packing or publishing.
+
+The source directory must contain a rulestack.pkg.json. The link is a
+symlink (a directory junction on Windows) and is recorded in .rulestack/links.json.
+Linked packages cannot be packed or published from this project until unlinked.
+
+Examples:
+  rfh link ../my-rules
+  rfh link ../rules-monorepo --package security-rules`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLink(args[0])
+	},
+}
+
+// unlinkCmd represents the unlink command
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <package>",
+	Short: "Remove a linked package source from this project",
+	Long: `Remove a package link created by 'rfh link', restoring any installed copy
+of the same version that the link replaced.
+
+Examples:
+  rfh unlink my-rules`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUnlink(args[0])
+	},
+}
+
+// runLink implements the link command logic
+func runLink(sourcePath string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	sourceDir, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source path: %w", err)
+	}
+
+	packageManifest, err := selectLinkManifest(sourceDir, linkPackageName)
+	if err != nil {
+		return err
+	}
+
+	links, err := loadLinks(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	if existing, ok := links[packageManifest.Name]; ok {
+		return fmt.Errorf("package %s is already linked to %s. Run 'rfh unlink %s' first", packageManifest.Name, existing.Path, packageManifest.Name)
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	dirName := fmt.Sprintf("%s.%s", packageManifest.Name, packageManifest.Version)
+	linkPath := filepath.Join(rulestackDir, dirName)
+	pkgRef := &PackageRef{Name: packageManifest.Name, Version: packageManifest.Version}
+
+	// A dry run links the source into a staging directory to show the CLAUDE.md change
+	if linkDryRun {
+		err := previewInstall(projectRoot, func() error {
+			if err := createDirLink(sourceDir, packageInstallDir(projectRoot, dirName)); err != nil {
+				return fmt.Errorf("failed to link %s: %w", sourceDir, err)
+			}
+			return updateClaudeFile(projectRoot, pkgRef)
+		})
+		if err != nil {
+			return err
+		}
+		output.Printf("ℹ️  Dry run: %s@%s was not linked\n", packageManifest.Name, packageManifest.Version)
+		return nil
+	}
+
+	// Keep an installed copy of the same version aside so unlink can restore it
+	if _, err := os.Lstat(linkPath); err == nil {
+		backupDir := filepath.Join(rulestackDir, ".linked-backup")
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		if err := os.Rename(linkPath, filepath.Join(backupDir, dirName)); err != nil {
+			return fmt.Errorf("failed to move installed %s aside: %w", dirName, err)
+		}
+		if verbose {
+			output.Printf("📁 Moved installed %s to .rulestack/.linked-backup\n", dirName)
+		}
+	}
+
+	if err := os.MkdirAll(rulestackDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .rulestack directory: %w", err)
+	}
+
+	if err := createDirLink(sourceDir, linkPath); err != nil {
+		return fmt.Errorf("failed to link %s: %w", sourceDir, err)
+	}
+
+	links[packageManifest.Name] = LinkedPackage{Path: sourceDir, Version: packageManifest.Version}
+	if err := saveLinks(projectRoot, links); err != nil {
+		return err
+	}
+
+	if err := updateClaudeFile(projectRoot, pkgRef); err != nil {
+		if verbose {
+			output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+		}
+	}
+
+	output.Printf("🔗 Linked %s@%s → %s\n", packageManifest.Name, packageManifest.Version, sourceDir)
+	return nil
+}
+
+// runUnlink implements the unlink command logic
+func runUnlink(packageName string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	links, err := loadLinks(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	linked, ok := links[packageName]
+	if !ok {
+		return fmt.Errorf("package %s is not linked", packageName)
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	dirName := fmt.Sprintf("%s.%s", packageName, linked.Version)
+	linkPath := filepath.Join(rulestackDir, dirName)
+
+	// os.Remove deletes the link itself, never the source directory behind it
+	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove link %s: %w", linkPath, err)
+	}
+
+	restored := false
+	backupPath := filepath.Join(rulestackDir, ".linked-backup", dirName)
+	if _, err := os.Stat(backupPath); err == nil {
+		if err := os.Rename(backupPath, linkPath); err != nil {
+			return fmt.Errorf("failed to restore installed %s: %w", dirName, err)
+		}
+		restored = true
+	} else if err := removeClaudeRules(projectRoot, dirName); err != nil && verbose {
+		output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+	}
+
+	delete(links, packageName)
+	if err := saveLinks(projectRoot, links); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Unlinked %s\n", packageName)
+	if restored {
+		output.Printf("📦 Restored installed %s@%s\n", packageName, linked.Version)
+	} else if projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json")); err == nil {
+		if _, isDependency := projectManifest.Dependencies[packageName]; isDependency {
+			output.Printf("💡 Run 'rfh install .' to reinstall %s from the registry\n", packageName)
+		}
+	}
+
+	return nil
+}
+
+// selectLinkManifest picks the package manifest to link from a source directory
+func selectLinkManifest(sourceDir, packageName string) (*manifest.PackageManifest, error) {
+	manifests, err := manifest.LoadPackageManifests(packageManifestPath(sourceDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package manifest from %s: %w", sourceDir, err)
+	}
+
+	if packageName == "" {
+		if len(manifests) > 1 {
+			return nil, fmt.Errorf("%s defines %d packages. Use --package to choose one", sourceDir, len(manifests))
+		}
+		return &manifests[0], nil
+	}
+
+	for i := range manifests {
+		if manifests[i].Name == packageName {
+			return &manifests[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("package '%s' not found in %s", packageName, sourceDir)
+}
+
+// createDirLink links linkPath to targetDir, using a junction on Windows where
+// directory symlinks require elevated privileges
+func createDirLink(targetDir, linkPath string) error {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("cmd", "/c", "mklink", "/J", linkPath, targetDir).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("mklink failed: %s", strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	return os.Symlink(targetDir, linkPath)
+}
+
+// linksPath returns the path of the file recording linked packages
+func linksPath(projectRoot string) string {
+	return filepath.Join(projectRoot, ".rulestack", "links.json")
+}
+
+// loadLinks reads the linked packages for a project
+func loadLinks(projectRoot string) (map[string]LinkedPackage, error) {
+	links := make(map[string]LinkedPackage)
+
+	data, err := os.ReadFile(linksPath(projectRoot))
+	if os.IsNotExist(err) {
+		return links, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %w", linksPath(projectRoot), err)
+	}
+
+	return links, nil
+}
+
+// saveLinks writes the linked packages for a project, removing the file when empty
+func saveLinks(projectRoot string, links map[string]LinkedPackage) error {
+	if len(links) == 0 {
+		if err := os.Remove(linksPath(projectRoot)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove links file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal links: %w", err)
+	}
+
+	return os.WriteFile(linksPath(projectRoot), data, 0644)
+}
+
+// ensureNotLinked refuses to pack or publish packages that are currently linked,
+// so local development state never leaks into a published archive
+func ensureNotLinked(projectRoot string, packageNames ...string) error {
+	links, err := loadLinks(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	var linked []string
+	for _, name := range packageNames {
+		if _, ok := links[name]; ok {
+			linked = append(linked, name)
+		}
+	}
+
+	if len(linked) == 0 {
+		return nil
+	}
+
+	sort.Strings(linked)
+	return fmt.Errorf("package(s) %s are linked to local sources. Run 'rfh unlink' before packing or publishing", strings.Join(linked, ", "))
+}
+
+// removeClaudeRules removes CLAUDE.md rule references for a package directory
+func removeClaudeRules(projectRoot, dirName string) error {
+	claudePath := filepath.Join(projectRoot, "CLAUDE.md")
+	content, err := readEditorFile(claudePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	}
+
+	prefix := fmt.Sprintf("- @.rulestack/%s/", dirName)
+	var kept []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return writeEditorFile(claudePath, []byte(strings.Join(kept, "\n")))
+}
+
+func init() {
+	linkCmd.Flags().StringVar(&linkPackageName, "package", "", "package to link when the source defines several")
+	linkCmd.Flags().BoolVar(&linkDryRun, "dry-run", false, "print the CLAUDE.md changes as a unified diff without linking the package")
+}
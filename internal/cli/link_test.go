@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLinkAndUnlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("junction creation requires cmd.exe")
+	}
+
+	tempDir := t.TempDir()
+
+	sourceDir := filepath.Join(tempDir, "my-rules")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+	sourceManifest := `{"name": "my-rules", "version": "1.0.0", "files": ["*.md"]}`
+	if err := os.WriteFile(filepath.Join(sourceDir, "rulestack.pkg.json"), []byte(sourceManifest), 0644); err != nil {
+		t.Fatalf("Failed to write source manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	projectDir := filepath.Join(tempDir, "project")
+	installedDir := filepath.Join(projectDir, ".rulestack", "my-rules.1.0.0")
+	if err := os.MkdirAll(installedDir, 0755); err != nil {
+		t.Fatalf("Failed to create installed dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(installedDir, "installed.md"), []byte("# Installed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write installed rule: %v", err)
+	}
+	manifestContent := `{"version": "1.0.0", "dependencies": {"my-rules": "1.0.0"}}`
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectDir)
+
+	if err := runLink(sourceDir); err != nil {
+		t.Fatalf("runLink failed: %v", err)
+	}
+
+	// Linked directory now serves the source files
+	if _, err := os.Stat(filepath.Join(installedDir, "style.md")); err != nil {
+		t.Errorf("Expected linked source file to be visible: %v", err)
+	}
+
+	claude, _ := os.ReadFile(filepath.Join(projectDir, "CLAUDE.md"))
+	if !strings.Contains(string(claude), "- @.rulestack/my-rules.1.0.0/style.md") {
+		t.Errorf("Expected CLAUDE.md to reference linked rule, got:\n%s", claude)
+	}
+
+	if err := ensureNotLinked(projectDir, "my-rules"); err == nil {
+		t.Error("Expected linked package to be rejected for pack/publish")
+	}
+	if err := ensureNotLinked(projectDir, "other-rules"); err != nil {
+		t.Errorf("Expected unlinked package to pass, got: %v", err)
+	}
+
+	if err := runLink(sourceDir); err == nil {
+		t.Error("Expected linking twice to fail")
+	}
+
+	if err := runUnlink("my-rules"); err != nil {
+		t.Fatalf("runUnlink failed: %v", err)
+	}
+
+	// Installed copy is restored and the source is untouched
+	info, err := os.Lstat(installedDir)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Expected installed directory to be restored, got %v (err %v)", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(installedDir, "installed.md")); err != nil {
+		t.Errorf("Expected installed rule to be restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sourceDir, "style.md")); err != nil {
+		t.Errorf("Expected source to be untouched: %v", err)
+	}
+	if _, err := os.Stat(linksPath(projectDir)); !os.IsNotExist(err) {
+		t.Error("Expected links file to be removed when no links remain")
+	}
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// manifestCmd groups commands that work on manifest files themselves
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Validate and migrate manifests and print their JSON Schemas",
+	Long: `Work with manifest files.
+
+A project lists its dependencies in rulestack.json, and a package source
+declares its packages in rulestack.pkg.json. A JSON Schema for each is built
+into rfh and published alongside the source; 'rfh init' and 'rfh new package'
+point the "$schema" field at it so editors offer completion and flag mistakes
+as you type.`,
+}
+
+var manifestValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a manifest against its schema",
+	Long: `Check a manifest against the JSON Schema of its kind, then against the checks
+rfh makes when loading it. Without a file, the rulestack.json or
+rulestack.pkg.json in the current directory is checked.
+
+Every schema violation is reported with its line and column, so editors and CI
+logs can jump to it. Exits with an error when the file is invalid.
+
+Examples:
+  rfh manifest validate
+  rfh manifest validate packages/security-rules/rulestack.pkg.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := manifest.ProjectManifestName
+		if len(args) == 1 {
+			path = args[0]
+		} else if _, err := os.Stat(path); err != nil {
+			path = manifest.PackageManifestName
+		}
+		return runManifestValidate(path)
+	},
+}
+
+var manifestMigrateCmd = &cobra.Command{
+	Use:   "migrate [dir...]",
+	Short: "Rename a package source's rulestack.json to rulestack.pkg.json",
+	Long: `Rename the rulestack.json of package sources to rulestack.pkg.json, so the
+file name says which kind of manifest it is. Package sources that still use
+rulestack.json load with a warning until the next release.
+
+Directories default to the current one; those whose rulestack.json is a
+project manifest are left alone.
+
+Examples:
+  rfh manifest migrate
+  rfh manifest migrate packages/security-rules packages/logging-rules`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		for _, dir := range args {
+			if err := runManifestMigrate(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var manifestSchemaCmd = &cobra.Command{
+	Use:   "schema <project|package>",
+	Short: "Print the JSON Schema of a manifest mode",
+	Long: `Print the JSON Schema built into rfh for project or package manifests, for
+editors or tools that cannot fetch it from the "$schema" URL.
+
+Examples:
+  rfh manifest schema project > .vscode/rulestack.schema.json
+  rfh manifest schema package`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{manifest.SchemaProject, manifest.SchemaPackage},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := manifest.Schema(args[0])
+		if err != nil {
+			return err
+		}
+		output.Data(schema)
+		return nil
+	},
+}
+
+func runManifestValidate(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	kind := manifest.SchemaKindOf(path, data)
+	problems, err := manifest.ValidateSchemaAs(kind, data)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		output.Fprintf(os.Stderr, "%s:%s\n", path, problem.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s does not match the %s manifest schema (%d problem(s))", path, kind, len(problems))
+	}
+
+	// Checks beyond the schema, such as license expressions and meta-packages
+	if kind == manifest.SchemaProject {
+		_, err = manifest.LoadProjectManifest(path)
+	} else {
+		_, err = manifest.LoadPackageManifests(path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	output.Printf("✅ %s is a valid %s manifest\n", path, kind)
+	return nil
+}
+
+func runManifestMigrate(dir string) error {
+	legacyPath := filepath.Join(dir, manifest.ProjectManifestName)
+	path := filepath.Join(dir, manifest.PackageManifestName)
+
+	if _, err := os.Stat(path); err == nil {
+		output.Printf("✅ %s already uses %s\n", dir, manifest.PackageManifestName)
+		return nil
+	}
+	if !manifest.IsPackageManifest(legacyPath) {
+		if manifest.IsProjectManifest(legacyPath) {
+			output.Printf("✅ %s is a project; its rulestack.json stays\n", dir)
+			return nil
+		}
+		return fmt.Errorf("no package manifest found in %s", dir)
+	}
+
+	if err := os.Rename(legacyPath, path); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", legacyPath, err)
+	}
+	output.Printf("✅ Renamed %s to %s\n", legacyPath, path)
+	return nil
+}
+
+// warnedLegacyManifests records the legacy package manifests already warned about
+var warnedLegacyManifests = make(map[string]bool)
+
+// packageManifestPath returns the package manifest of a package source
+// directory, warning once when it is still a rulestack.json
+func packageManifestPath(dir string) string {
+	path, legacy := manifest.PackageManifestPath(dir)
+	if legacy && !warnedLegacyManifests[path] {
+		warnedLegacyManifests[path] = true
+		output.Fprintf(os.Stderr, "⚠️  %s declares packages; rename it to %s with 'rfh manifest migrate %s'. Package manifests in rulestack.json will stop loading in the next release\n", path, manifest.PackageManifestName, dir)
+	}
+	return path
+}
+
+func init() {
+	manifestCmd.AddCommand(manifestValidateCmd)
+	manifestCmd.AddCommand(manifestSchemaCmd)
+	manifestCmd.AddCommand(manifestMigrateCmd)
+}
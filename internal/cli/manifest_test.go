@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunManifestValidate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	valid := filepath.Join(tempDir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"version": "1.0.0", "dependencies": {"security-rules": "^1.0.0"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := runManifestValidate(valid); err != nil {
+		t.Errorf("expected a valid project manifest, got %v", err)
+	}
+
+	invalid := filepath.Join(tempDir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{\n  \"name\": \"security-rules\",\n  \"version\": 1\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	err := runManifestValidate(invalid)
+	if err == nil || !strings.Contains(err.Error(), "package manifest schema") {
+		t.Errorf("expected a package schema error, got %v", err)
+	}
+
+	if err := runManifestValidate(filepath.Join(tempDir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestRunManifestMigrate(t *testing.T) {
+	sourceDir := t.TempDir()
+	legacyPath := filepath.Join(sourceDir, "rulestack.json")
+	content := `{"name": "security-rules", "version": "1.0.0", "files": ["rules/*.md"]}`
+	if err := os.WriteFile(legacyPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	// Legacy package sources still load until they are migrated
+	if _, err := loadSourceManifest(sourceDir, "security-rules"); err != nil {
+		t.Fatalf("expected a package manifest in rulestack.json to load, got %v", err)
+	}
+
+	if err := runManifestMigrate(sourceDir); err != nil {
+		t.Fatalf("runManifestMigrate failed: %v", err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("expected rulestack.json to be renamed, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(sourceDir, "rulestack.pkg.json")); string(data) != content {
+		t.Errorf("expected rulestack.pkg.json to keep the manifest, got %q", data)
+	}
+	if err := runManifestMigrate(sourceDir); err != nil {
+		t.Errorf("expected migrating twice to succeed, got %v", err)
+	}
+
+	// A project's rulestack.json stays
+	projectDir := t.TempDir()
+	projectPath := filepath.Join(projectDir, "rulestack.json")
+	if err := os.WriteFile(projectPath, []byte(`{"version": "1.0.0", "dependencies": {}}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := runManifestMigrate(projectDir); err != nil {
+		t.Errorf("expected a project to be left alone, got %v", err)
+	}
+	if _, err := os.Stat(projectPath); err != nil {
+		t.Errorf("expected the project's rulestack.json to stay, got %v", err)
+	}
+
+	if err := runManifestMigrate(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without a manifest")
+	}
+}
@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+)
+
+// mcpCmd serves registry operations to AI agents over the Model Context Protocol
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve registry operations to AI agents over MCP",
+	Long: `Run a Model Context Protocol (MCP) server on stdin/stdout, so AI agents can
+search the registry and manage the rulesets of the project it is started in.
+
+Tools:
+  search_packages       Search the active registry
+  get_package_info      A package's versions, manifest and rule file excerpts
+  install_package       Add a registry package to the project
+  list_installed_rules  Installed packages and the paths of their rule files
+
+Agents can only install registry packages, into this project; local and git
+sources are refused. Command output goes to stderr, as stdout carries the
+protocol, and nothing prompts.
+
+Add it to an MCP client with the command 'rfh mcp', run in the project.
+
+Examples:
+  rfh mcp
+  claude mcp add rfh -- rfh mcp`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectRoot, err := findProjectRoot()
+		if err != nil {
+			return fmt.Errorf("failed to find project root: %w", err)
+		}
+
+		// Everything the tools print would corrupt the protocol on stdout
+		protocolOut := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = protocolOut }()
+		nonInteractive = true
+
+		return runMCP(projectRoot, os.Stdin, protocolOut)
+	},
+}
+
+// mcpProtocolVersions are the MCP revisions this server speaks, newest first
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// mcpTool describes a tool to MCP clients
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// mcpContent is a block of a tool result
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpToolResult is the result of a tools/call; tool failures are results with
+// IsError set, not protocol errors, so the agent can read them
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+// objectSchema is the JSON schema of a tool's arguments
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "search_packages",
+		Description: "Search the active rfh registry for AI ruleset packages.",
+		InputSchema: objectSchema(map[string]any{
+			"query":  stringProperty("Search terms"),
+			"tag":    stringProperty("Only packages with this tag"),
+			"target": stringProperty("Only packages for this tool, e.g. cursor or claude-code"),
+			"limit":  map[string]any{"type": "integer", "description": "Maximum results (default 20)"},
+		}, "query"),
+	},
+	{
+		Name:        "get_package_info",
+		Description: "Get a package's versions and description, with its manifest and the first lines of each rule file.",
+		InputSchema: objectSchema(map[string]any{
+			"name":    stringProperty("Package name"),
+			"version": stringProperty("Version to preview (default: latest)"),
+		}, "name"),
+	},
+	{
+		Name:        "install_package",
+		Description: "Add a registry package to the project's rulestack.json and install it.",
+		InputSchema: objectSchema(map[string]any{
+			"package": stringProperty("Package as name@version, or name for the latest version"),
+		}, "package"),
+	},
+	{
+		Name:        "list_installed_rules",
+		Description: "List the project's installed packages and the paths of their rule files.",
+		InputSchema: objectSchema(map[string]any{}),
+	},
+}
+
+// mcpServer answers MCP requests for one project, using the tools of 'rfh serve'
+type mcpServer struct {
+	local *localServer
+}
+
+// runMCP serves newline-delimited JSON-RPC messages from in until it is closed
+func runMCP(projectRoot string, in io.Reader, out io.Writer) error {
+	server := &mcpServer{local: &localServer{projectRoot: projectRoot}}
+	encoder := json.NewEncoder(out)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		resp, reply := server.handle([]byte(line))
+		if !reply {
+			continue
+		}
+		resp.JSONRPC = "2.0"
+		if resp.ID == nil {
+			resp.ID = json.RawMessage("null")
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message; notifications get no reply
+func (s *mcpServer) handle(message []byte) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		return rpcResponse{Error: &rpcError{Code: rpcParseError, Message: err.Error()}}, true
+	}
+	if req.ID == nil {
+		return rpcResponse{}, false
+	}
+
+	switch req.Method {
+	case "initialize":
+		return rpcResponse{ID: req.ID, Result: s.initialize(req.Params)}, true
+	case "ping":
+		return rpcResponse{ID: req.ID, Result: map[string]any{}}, true
+	case "tools/list":
+		return rpcResponse{ID: req.ID, Result: map[string]any{"tools": mcpTools}}, true
+	case "tools/call":
+		result, err := s.callTool(req.Params)
+		if err != nil {
+			return rpcResponse{ID: req.ID, Error: &rpcError{Code: rpcInvalidParams, Message: err.Error()}}, true
+		}
+		return rpcResponse{ID: req.ID, Result: result}, true
+	}
+	return rpcResponse{ID: req.ID, Error: &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method '%s'", req.Method)}}, true
+}
+
+// initialize agrees on the client's protocol version if this server speaks it
+func (s *mcpServer) initialize(params json.RawMessage) map[string]any {
+	var args struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(params, &args)
+
+	protocolVersion := mcpProtocolVersions[0]
+	if slices.Contains(mcpProtocolVersions, args.ProtocolVersion) {
+		protocolVersion = args.ProtocolVersion
+	}
+
+	return map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]any{"name": "rfh", "version": "1.0.0"},
+	}
+}
+
+// callTool runs a tool. An unknown tool is a protocol error; a tool that fails
+// returns its error as the result.
+func (s *mcpServer) callTool(params json.RawMessage) (*mcpToolResult, error) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	tools := map[string]func(json.RawMessage) (any, error){
+		"search_packages":      s.local.search,
+		"get_package_info":     s.packageInfo,
+		"install_package":      s.installPackage,
+		"list_installed_rules": s.installedRules,
+	}
+	tool, ok := tools[call.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool '%s'", call.Name)
+	}
+
+	s.local.mu.Lock()
+	result, err := tool(call.Arguments)
+	s.local.mu.Unlock()
+
+	if err != nil {
+		return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: string(data)}}}, nil
+}
+
+func (s *mcpServer) packageInfo(params json.RawMessage) (any, error) {
+	var args struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	pkg, err := c.GetPackage(ctx, args.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+
+	info := map[string]any{"package": pkg}
+	// Registries without previews still describe the package
+	if preview, err := c.GetPackagePreview(ctx, args.Name, args.Version); err == nil {
+		info["preview"] = preview
+	} else {
+		info["preview_error"] = err.Error()
+	}
+	return info, nil
+}
+
+func (s *mcpServer) installPackage(params json.RawMessage) (any, error) {
+	var args struct {
+		Package string `json:"package"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	spec := args.Package
+	if spec != "" && !strings.Contains(spec, "@") {
+		latest, err := latestVersion(spec)
+		if err != nil {
+			return nil, err
+		}
+		spec += "@" + latest
+	}
+
+	pkgRef, err := parsePackageRef(spec)
+	if err != nil {
+		return nil, err
+	}
+	// Local and git sources could read or run anything outside the project
+	if isSourceSpec(pkgRef.Version) {
+		return nil, fmt.Errorf("only registry packages can be installed over MCP")
+	}
+
+	return s.local.install(json.RawMessage(fmt.Sprintf(`{"packages": [%q]}`, spec)))
+}
+
+// latestVersion looks up a package's latest version on the active registry
+func latestVersion(name string) (string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	pkg, err := c.GetPackage(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get package: %w", err)
+	}
+	if pkg.Latest == "" {
+		return "", fmt.Errorf("package '%s' has no published versions", name)
+	}
+	return pkg.Latest, nil
+}
+
+// packageRules is an installed package with its rule files, as returned by list_installed_rules
+type packageRules struct {
+	installedPackage
+	Rules []string `json:"rules"` // Relative to the project root
+}
+
+func (s *mcpServer) installedRules(params json.RawMessage) (any, error) {
+	packages, err := installedPackages(s.local.projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make([]packageRules, 0, len(packages))
+	for _, pkg := range packages {
+		rules := []string{}
+		ruleFiles, _ := findRuleFiles(pkg.dir)
+		for _, ruleFile := range ruleFiles {
+			if rel, err := filepath.Rel(s.local.projectRoot, filepath.Join(pkg.dir, ruleFile)); err == nil {
+				rules = append(rules, filepath.ToSlash(rel))
+			}
+		}
+		installed = append(installed, packageRules{installedPackage: pkg, Rules: rules})
+	}
+	return installed, nil
+}
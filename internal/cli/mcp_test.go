@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMCP(t *testing.T) {
+	projectRoot := t.TempDir()
+	lock := `{"version": "1.0.0", "packages": {"security-rules": {"version": "1.2.0", "sha256": "abc"}}}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.lock.json"), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rulesDir := filepath.Join(projectRoot, ".rulestack", "security-rules.1.2.0", "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"secrets.mdc", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(rulesDir, name), []byte("# rule\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requests := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "list_installed_rules", "arguments": {}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "install_package", "arguments": {"package": "evil@file:../elsewhere"}}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "publish_package", "arguments": {}}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "resources/list"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runMCP(projectRoot, strings.NewReader(requests), &out); err != nil {
+		t.Fatalf("runMCP failed: %v", err)
+	}
+
+	// The notification gets no reply
+	responses := map[string]map[string]json.RawMessage{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", scanner.Text(), err)
+		}
+		responses[string(resp["id"])] = resp
+	}
+	if len(responses) != 6 {
+		t.Fatalf("got %d responses, want 6", len(responses))
+	}
+
+	var initResult struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(responses["1"]["result"], &initResult)
+	if initResult.ProtocolVersion != "2024-11-05" {
+		t.Errorf("protocol version = %q, want the client's 2024-11-05", initResult.ProtocolVersion)
+	}
+
+	var toolList struct {
+		Tools []mcpTool `json:"tools"`
+	}
+	json.Unmarshal(responses["2"]["result"], &toolList)
+	if len(toolList.Tools) != 4 {
+		t.Errorf("got %d tools, want 4", len(toolList.Tools))
+	}
+
+	var listResult mcpToolResult
+	json.Unmarshal(responses["3"]["result"], &listResult)
+	var rules []struct {
+		Name  string   `json:"name"`
+		Rules []string `json:"rules"`
+	}
+	if listResult.IsError || len(listResult.Content) != 1 {
+		t.Fatalf("list_installed_rules result = %+v", listResult)
+	}
+	json.Unmarshal([]byte(listResult.Content[0].Text), &rules)
+	if len(rules) != 1 || len(rules[0].Rules) != 1 || rules[0].Rules[0] != ".rulestack/security-rules.1.2.0/rules/secrets.mdc" {
+		t.Errorf("installed rules = %+v", rules)
+	}
+
+	var installResult mcpToolResult
+	json.Unmarshal(responses["4"]["result"], &installResult)
+	if !installResult.IsError || !strings.Contains(installResult.Content[0].Text, "only registry packages") {
+		t.Errorf("source install should fail as a tool error, got %+v", installResult)
+	}
+
+	for id, code := range map[string]int{"5": rpcInvalidParams, "6": rpcMethodNotFound} {
+		var rpcErr rpcError
+		json.Unmarshal(responses[id]["error"], &rpcErr)
+		if rpcErr.Code != code {
+			t.Errorf("response %s error = %+v, want code %d", id, rpcErr, code)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// migrateDepsCmd represents the migrate-deps command
+var migrateDepsCmd = &cobra.Command{
+	Use:   "migrate-deps",
+	Short: "Replace deprecated dependencies with their suggested replacements",
+	Long: `Find dependencies in rulestack.json whose version was deprecated with a
+replacement (see 'rfh deprecate --replacement'), and offer to switch each one
+to the highest version of the replacement package in its range.
+
+Each replacement is confirmed separately; --yes accepts them all and
+--dry-run only lists them. The changes are written to rulestack.json; run
+'rfh install .' afterwards to install them. Dependencies inherited through
+"extends" or replaced through "overrides" are left alone.
+
+Examples:
+  rfh migrate-deps
+  rfh migrate-deps --dry-run
+  rfh migrate-deps --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return runMigrateDeps(dryRun)
+	},
+}
+
+// dependencyMigration is a deprecated dependency and what replaces it
+type dependencyMigration struct {
+	Name        string
+	Version     string
+	Replacement string // As the publisher named it, name@range
+	NewName     string
+	NewVersion  string // Highest version of NewName in the replacement's range
+}
+
+func runMigrateDeps(dryRun bool) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+	manifestPath := filepath.Join(projectRoot, "rulestack.json")
+	projectManifest, err := manifest.LoadProjectManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	var refs []client.VersionRef
+	for name, wanted := range projectManifest.Dependencies {
+		if _, overridden := projectManifest.Overrides[name]; overridden || isSourceSpec(wanted) {
+			continue
+		}
+		refs = append(refs, client.VersionRef{Name: name, Version: wanted})
+	}
+	if len(refs) == 0 {
+		output.Printf("ℹ️  No registry dependencies in rulestack.json\n")
+		return nil
+	}
+
+	metadata, err := lookupDependencies(refs, false, projectManifest)
+	if err != nil {
+		return err
+	}
+	migrations, err := planMigrations(metadata, projectManifest)
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		output.Printf("✅ No deprecated dependencies with a replacement\n")
+		return nil
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		output.Printf("⚠️  %s@%s is deprecated; replacement: %s\n", m.Name, m.Version, m.Replacement)
+		if dryRun {
+			output.Printf("   would use %s@%s\n", m.NewName, m.NewVersion)
+			continue
+		}
+		if !confirm(fmt.Sprintf("   Replace %s@%s with %s@%s?", m.Name, m.Version, m.NewName, m.NewVersion), false) {
+			continue
+		}
+		applyMigration(projectManifest, m)
+		applied++
+	}
+
+	if applied == 0 {
+		return nil
+	}
+	if err := manifest.SaveProjectManifest(manifestPath, projectManifest); err != nil {
+		return fmt.Errorf("failed to save project manifest: %w", err)
+	}
+	output.Printf("✅ Replaced %d dependency(ies) in rulestack.json\n", applied)
+	output.Printf("💡 Run 'rfh install .' to install them\n")
+	return nil
+}
+
+// planMigrations resolves the replacement of every deprecated dependency that
+// names one to a version, sorted by dependency name
+func planMigrations(metadata []client.VersionMetadata, projectManifest *manifest.ProjectManifest) ([]dependencyMigration, error) {
+	var migrations []dependencyMigration
+	var c client.RegistryClient
+
+	for _, m := range metadata {
+		if m.Replacement == "" {
+			continue
+		}
+		newName, versionRange, err := manifest.ParseReplacement(m.Replacement)
+		if err != nil {
+			output.Printf("⚠️  %s@%s names an invalid replacement: %v\n", m.Name, m.Version, err)
+			continue
+		}
+
+		migration := dependencyMigration{Name: m.Name, Version: m.Version, Replacement: m.Replacement, NewName: newName}
+		if versionRange.IsExact() {
+			_, migration.NewVersion, _ = strings.Cut(m.Replacement, "@")
+		} else {
+			if c == nil {
+				if c, err = projectClient(projectManifest); err != nil {
+					return nil, err
+				}
+			}
+			ctx, cancel := client.WithTimeout(commandContext)
+			info, err := c.GetPackage(ctx, newName)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up replacement %s: %w", m.Replacement, err)
+			}
+			migration.NewVersion = versionRange.Highest(info.Versions)
+		}
+		if migration.NewVersion == "" {
+			output.Printf("⚠️  %s@%s: no published version matches its replacement %s\n", m.Name, m.Version, m.Replacement)
+			continue
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Name < migrations[j].Name })
+	return migrations, nil
+}
+
+// projectClient returns a client for the project's registry
+func projectClient(projectManifest *manifest.ProjectManifest) (client.RegistryClient, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := useProjectRegistry(&cfg, projectManifest); err != nil {
+		return nil, err
+	}
+	return client.GetClient(cfg, verbose)
+}
+
+// applyMigration swaps a dependency for its replacement, keeping its place in
+// the priority list. A replacement the project already depends on keeps its version.
+func applyMigration(projectManifest *manifest.ProjectManifest, m dependencyMigration) {
+	delete(projectManifest.Dependencies, m.Name)
+	if _, exists := projectManifest.Dependencies[m.NewName]; !exists {
+		projectManifest.Dependencies[m.NewName] = m.NewVersion
+	}
+
+	var priority []string
+	seen := make(map[string]bool)
+	for _, name := range projectManifest.Priority {
+		if name == m.Name {
+			name = m.NewName
+		}
+		if !seen[name] {
+			seen[name] = true
+			priority = append(priority, name)
+		}
+	}
+	projectManifest.Priority = priority
+}
+
+func init() {
+	migrateDepsCmd.Flags().Bool("dry-run", false, "list the replacements without changing rulestack.json")
+}
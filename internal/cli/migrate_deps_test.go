@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestRunMigrateDeps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages/bulk":
+			json.NewEncoder(w).Encode(map[string]interface{}{"packages": []map[string]interface{}{
+				{"name": "security-rules", "version": "1.0.0", "found": true, "deprecated": "Renamed", "replacement": "secure-coding@^2"},
+				{"name": "logging-rules", "version": "1.0.0", "found": true, "deprecated": "Merged", "replacement": "api-rules@1.4.0"},
+				{"name": "api-rules", "version": "1.2.0", "found": true},
+				{"name": "style-rules", "version": "1.0.0", "found": true, "deprecated": "No replacement"},
+			}})
+		case "/v1/packages/secure-coding":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "secure-coding", "latest": "3.0.0", "versions": []string{"1.9.0", "2.0.0", "2.3.1", "3.0.0"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectDir)
+
+	writeProject := func() {
+		os.WriteFile("rulestack.json", []byte(`{
+  "version": "1.0.0",
+  "dependencies": {"security-rules": "1.0.0", "logging-rules": "1.0.0", "api-rules": "1.2.0", "style-rules": "1.0.0"},
+  "priority": ["security-rules", "api-rules"]
+}`), 0644)
+	}
+
+	writeProject()
+	if err := runMigrateDeps(true); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if projectManifest, _ := manifest.LoadProjectManifest("rulestack.json"); projectManifest.Dependencies["security-rules"] != "1.0.0" {
+		t.Errorf("expected a dry run to leave rulestack.json alone, got %v", projectManifest.Dependencies)
+	}
+
+	assumeYes = true
+	t.Cleanup(func() { assumeYes = false })
+	if err := runMigrateDeps(false); err != nil {
+		t.Fatalf("runMigrateDeps failed: %v", err)
+	}
+
+	projectManifest, err := manifest.LoadProjectManifest("rulestack.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"secure-coding": "2.3.1", "api-rules": "1.2.0", "style-rules": "1.0.0"}
+	if !reflect.DeepEqual(projectManifest.Dependencies, expected) {
+		t.Errorf("dependencies = %v, want %v", projectManifest.Dependencies, expected)
+	}
+	if !reflect.DeepEqual(projectManifest.Priority, []string{"secure-coding", "api-rules"}) {
+		t.Errorf("priority = %v", projectManifest.Priority)
+	}
+}
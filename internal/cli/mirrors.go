@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"rulestack/internal/blobcache"
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+)
+
+// registrySource is a configured registry that packages can be installed from
+type registrySource struct {
+	Name     string
+	Registry config.Registry
+	Client   client.RegistryClient
+}
+
+// downloadPackage fetches a package archive from the registry, or from the blob
+// cache when an earlier install or 'rfh cache warm' already fetched it, and
+// counts the install towards the packages warmed ahead of time. size is the
+// archive size the registry reported, or 0 if it did not; an archive that will
+// not fit in the cache or at destPath fails before it is downloaded.
+func (s registrySource) downloadPackage(ctx context.Context, name, version, sha256 string, size int64, destPath string) error {
+	purpose := "download " + name + "@" + version
+	if !blobcache.Has(sha256) {
+		if cacheDir, err := blobcache.Dir(); err == nil {
+			if err := pkg.CheckFreeSpace(cacheDir, size, purpose); err != nil {
+				return err
+			}
+		}
+	}
+	if err := pkg.CheckFreeSpace(filepath.Dir(destPath), size, purpose); err != nil {
+		return err
+	}
+
+	if err := blobcache.Fetch(ctx, s.Client, sha256, destPath); err != nil {
+		return err
+	}
+	if err := blobcache.RecordUse(s.Name, name, version, sha256); err != nil && verbose {
+		output.Printf("⚠️  Failed to record package use: %v\n", err)
+	}
+	return nil
+}
+
+// packageResolver finds the registry that serves each package version: the active
+// registry first, then the project's mirrors in the order rulestack.json lists them
+type packageResolver struct {
+	sources []registrySource
+	bulk    map[string]client.VersionMetadata // Active registry results of one bulk lookup, keyed name@version
+}
+
+// newPackageResolver creates clients for the active registry and the given mirrors
+func newPackageResolver(cfg config.CLIConfig, mirrors []string) (*packageResolver, error) {
+	names := []string{cfg.Current}
+	for _, mirror := range mirrors {
+		if mirror != cfg.Current {
+			names = append(names, mirror)
+		}
+	}
+
+	resolver := &packageResolver{}
+	for _, name := range names {
+		registry, exists := cfg.Registries[name]
+		if !exists {
+			return nil, fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", name)
+		}
+
+		c, err := client.NewRegistryClient(registry, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for registry '%s': %w", name, err)
+		}
+
+		resolver.sources = append(resolver.sources, registrySource{Name: name, Registry: registry, Client: c})
+	}
+
+	return resolver, nil
+}
+
+// useProjectRegistry makes the registry named by "registry" in rulestack.json,
+// if any, the active registry for this command. The config file is not changed.
+func useProjectRegistry(cfg *config.CLIConfig, projectManifest *manifest.ProjectManifest) error {
+	if projectManifest.Registry == "" {
+		return nil
+	}
+	if _, exists := cfg.Registries[projectManifest.Registry]; !exists {
+		return fmt.Errorf("registry '%s' from rulestack.json not found. Use 'rfh registry add' to add it", projectManifest.Registry)
+	}
+	cfg.Current = projectManifest.Registry
+	return nil
+}
+
+// checkConstraints verifies that every mirror is an allowed registry
+func (r *packageResolver) checkConstraints(constraints *manifest.Constraints) error {
+	if constraints == nil {
+		return nil
+	}
+	for _, source := range r.sources[1:] {
+		if err := constraints.CheckRegistry(source.Name, source.Registry.URL); err != nil {
+			return fmt.Errorf("mirror %w", err)
+		}
+	}
+	return nil
+}
+
+// prefetch looks up refs on the active registry with a single bulk request.
+// Versions it does not find are resolved one at a time, mirrors included.
+func (r *packageResolver) prefetch(refs []client.VersionRef) {
+	if len(refs) == 0 {
+		return
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	metadata, err := r.sources[0].Client.GetPackageVersions(ctx, refs)
+	if err != nil {
+		if verbose {
+			output.Printf("⚠️  Bulk lookup failed, resolving packages one at a time: %v\n", err)
+		}
+		return
+	}
+
+	r.bulk = make(map[string]client.VersionMetadata, len(metadata))
+	for _, m := range metadata {
+		if m.Found {
+			r.bulk[m.Name+"@"+m.Version] = m
+		}
+	}
+}
+
+// resolve returns the first registry that has name@version along with its metadata.
+// A registry is skipped when it does not have the version or cannot be reached;
+// the error of the active registry is returned if no registry has it.
+func (r *packageResolver) resolve(name, version string) (*registrySource, client.VersionMetadata, error) {
+	if metadata, ok := r.bulk[name+"@"+version]; ok {
+		return &r.sources[0], metadata, nil
+	}
+
+	var firstErr error
+	for i := range r.sources {
+		source := &r.sources[i]
+
+		metadata, err := lookupVersion(source.Client, name, version)
+		if err == nil {
+			if i > 0 {
+				output.Printf("🪞 %s@%s served by mirror %s\n", name, version, source.Name)
+			}
+			return source, metadata, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+		if !canFallBack(err) {
+			return nil, client.VersionMetadata{}, err
+		}
+		if verbose && i < len(r.sources)-1 {
+			output.Printf("⚠️  %s: %v, trying next registry\n", source.Name, err)
+		}
+	}
+
+	return nil, client.VersionMetadata{}, firstErr
+}
+
+// lookupVersion fetches the metadata of one version with its own timeout, so a
+// registry that hangs does not use up the time of the mirrors after it
+func lookupVersion(c client.RegistryClient, name, version string) (client.VersionMetadata, error) {
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	versionInfo, err := c.GetPackageVersion(ctx, name, version)
+	if err != nil {
+		return client.VersionMetadata{}, err
+	}
+
+	return client.VersionMetadata{
+		Name:        name,
+		Version:     version,
+		Found:       true,
+		SHA256:      versionInfo.SHA256,
+		Integrity:   versionInfo.Integrity,
+		Size:        versionInfo.Size,
+		Deprecated:  versionInfo.Deprecated,
+		Replacement: versionInfo.Replacement,
+	}, nil
+}
+
+// canFallBack reports whether a failed lookup should move on to the next registry.
+// Rejected credentials are reported rather than hidden behind a mirror, and an
+// interrupted command stops.
+func canFallBack(err error) bool {
+	if errors.Is(err, client.ErrUnauthorized) {
+		return false
+	}
+	return commandContext.Err() == nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+)
+
+func TestPackageResolverFallback(t *testing.T) {
+	newRegistry := func(status int, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/packages/security-rules/versions/1.0.0" {
+				http.NotFound(w, r)
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	}
+
+	tests := []struct {
+		name          string
+		primaryStatus int
+		expectSource  string
+		expectErr     error
+	}{
+		{"served by primary", http.StatusOK, "corp", nil},
+		{"missing on primary", http.StatusNotFound, "mirror", nil},
+		{"primary down", http.StatusBadGateway, "mirror", nil},
+		{"rejected credentials are not hidden", http.StatusUnauthorized, "", client.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := newRegistry(tt.primaryStatus, `{"name": "security-rules", "version": "1.0.0", "sha256": "primary-sha"}`)
+			defer primary.Close()
+			mirror := newRegistry(http.StatusOK, `{"name": "security-rules", "version": "1.0.0", "sha256": "mirror-sha"}`)
+			defer mirror.Close()
+
+			cfg := config.CLIConfig{
+				Current: "corp",
+				Registries: map[string]config.Registry{
+					"corp":   {URL: primary.URL, Type: config.RegistryTypeHTTP},
+					"mirror": {URL: mirror.URL, Type: config.RegistryTypeHTTP},
+				},
+			}
+
+			resolver, err := newPackageResolver(cfg, []string{"mirror"})
+			if err != nil {
+				t.Fatalf("newPackageResolver failed: %v", err)
+			}
+
+			source, metadata, err := resolver.resolve("security-rules", "1.0.0")
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve failed: %v", err)
+			}
+			if source.Name != tt.expectSource {
+				t.Errorf("expected %s to serve the package, got %s", tt.expectSource, source.Name)
+			}
+			expectSHA := map[string]string{"corp": "primary-sha", "mirror": "mirror-sha"}[tt.expectSource]
+			if metadata.SHA256 != expectSHA {
+				t.Errorf("expected sha256 %s, got %q", expectSHA, metadata.SHA256)
+			}
+		})
+	}
+}
+
+func TestNewPackageResolverUnknownMirror(t *testing.T) {
+	cfg := config.CLIConfig{
+		Current:    "corp",
+		Registries: map[string]config.Registry{"corp": {URL: "https://rules.example.com", Type: config.RegistryTypeHTTP}},
+	}
+
+	if _, err := newPackageResolver(cfg, []string{"missing"}); err == nil {
+		t.Error("expected an unconfigured mirror to be rejected")
+	}
+}
+
+func TestUseProjectRegistry(t *testing.T) {
+	cfg := config.CLIConfig{
+		Current: "corp",
+		Registries: map[string]config.Registry{
+			"corp": {URL: "https://rules.example.com", Type: config.RegistryTypeHTTP},
+			"team": {URL: "https://team.example.com", Type: config.RegistryTypeHTTP},
+		},
+	}
+
+	if err := useProjectRegistry(&cfg, &manifest.ProjectManifest{}); err != nil || cfg.Current != "corp" {
+		t.Errorf("without a project registry: current %q, err %v", cfg.Current, err)
+	}
+	if err := useProjectRegistry(&cfg, &manifest.ProjectManifest{Registry: "team"}); err != nil || cfg.Current != "team" {
+		t.Errorf("with a project registry: current %q, err %v", cfg.Current, err)
+	}
+	if err := useProjectRegistry(&cfg, &manifest.ProjectManifest{Registry: "missing"}); err == nil {
+		t.Error("expected an unconfigured project registry to be rejected")
+	}
+}
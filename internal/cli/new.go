@@ -0,0 +1,375 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+var (
+	newTemplateDir string
+	newDescription string
+	newLicense     string
+	newAuthor      string
+	newOutputDir   string
+	newForceCreate bool
+)
+
+// TemplateData is passed to rule and package templates
+type TemplateData struct {
+	Name        string // Package or rule name as given
+	ID          string // Rule ID used in frontmatter
+	Title       string // Human readable title derived from the name
+	Description string
+	License     string
+	Author      string
+	Year        int
+}
+
+// defaultRuleTemplate is used for 'rfh new rule' and the example rule in new packages
+const defaultRuleTemplate = `---
+id: {{.ID}}
+description: {{.Description}}
+globs:
+alwaysApply: false
+---
+
+# {{.Title}}
+
+Describe when this rule applies and what the assistant should do.
+
+## Guidelines
+
+- Be specific and actionable
+- Explain the reasoning behind each guideline
+`
+
+// defaultPackageTemplates maps package skeleton paths to their templates
+var defaultPackageTemplates = map[string]string{
+	"rulestack.pkg.json": `{
+  "$schema": "` + manifest.PackageSchemaURL + `",
+  "name": "{{.Name}}",
+  "version": "0.1.0",
+  "description": "{{.Description}}",
+  "targets": ["claude-code"],
+  "tags": [],
+  "files": ["rules/**/*.mdc"],
+  "license": "{{.License}}"
+}
+`,
+	"README.md": `# {{.Title}}
+
+{{.Description}}
+
+## Usage
+
+` + "```bash" + `
+rfh add {{.Name}}@0.1.0
+` + "```" + `
+
+## Rules
+
+Rules live in ` + "`rules/`" + `. Add new ones with ` + "`rfh new rule <name> --dir rules`" + `.
+`,
+	"LICENSE": `{{.License}} License
+
+Copyright (c) {{.Year}} {{if .Author}}{{.Author}}{{else}}The {{.Name}} authors{{end}}
+
+See https://spdx.org/licenses/{{.License}}.html for the full license text.
+`,
+	"rules/{{.ID}}.mdc": defaultRuleTemplate,
+}
+
+// newCmd represents the new command
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Scaffold a new rule or package",
+	Long: `Generate a new rule file or package skeleton from templates.
+
+Built-in templates are used unless a templates directory is given with
+--template-dir or configured as templates_dir in ~/.rfh/config.toml. A templates
+directory may contain:
+  rule.mdc     template for 'rfh new rule'
+  package/     files for 'rfh new package' (paths and contents are templates)
+
+Templates use Go text/template syntax with the fields .Name, .ID, .Title,
+.Description, .License, .Author and .Year.`,
+}
+
+// newRuleCmd represents the new rule command
+var newRuleCmd = &cobra.Command{
+	Use:   "rule <name>",
+	Short: "Create a new rule file with valid frontmatter",
+	Long: `Create <name>.mdc with frontmatter and a starter body.
+
+Examples:
+  rfh new rule no-secrets
+  rfh new rule api-style --dir rules --description "REST API conventions"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNewRule(args[0])
+	},
+}
+
+// newPackageCmd represents the new package command
+var newPackageCmd = &cobra.Command{
+	Use:   "package <name>",
+	Short: "Create a new package skeleton",
+	Long: `Create a package directory with rulestack.pkg.json, a rules/ directory with an
+example rule, README.md and LICENSE.
+
+Examples:
+  rfh new package security-rules
+  rfh new package team-rules --license Apache-2.0 --author "Platform Team"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNewPackage(args[0])
+	},
+}
+
+// runNewRule implements the new rule command logic
+func runNewRule(name string) error {
+	data := newTemplateData(name)
+	if data.ID == "" {
+		return fmt.Errorf("rule name must contain letters or digits")
+	}
+
+	ruleTemplate := defaultRuleTemplate
+	if templateDir := resolveTemplateDir(); templateDir != "" {
+		custom, err := os.ReadFile(filepath.Join(templateDir, "rule.mdc"))
+		if err == nil {
+			ruleTemplate = string(custom)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read rule template: %w", err)
+		}
+	}
+
+	content, err := renderTemplate("rule.mdc", ruleTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	if parseRuleID(content) == "" {
+		return fmt.Errorf("rule template must produce frontmatter with an id field")
+	}
+
+	outputDir := newOutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	rulePath := filepath.Join(outputDir, data.ID+".mdc")
+
+	if err := writeScaffoldFile(rulePath, content); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Created rule: %s\n", rulePath)
+	return nil
+}
+
+// runNewPackage implements the new package command logic
+func runNewPackage(name string) error {
+	data := newTemplateData(name)
+
+	// Validate the name up front so we never leave a half-written skeleton behind
+	probe := manifest.PackageManifest{Name: name, Version: "0.1.0", Files: []string{"rules/**/*.mdc"}, License: data.License}
+	if err := probe.Validate(); err != nil {
+		return err
+	}
+
+	templates := defaultPackageTemplates
+	if templateDir := resolveTemplateDir(); templateDir != "" {
+		custom, err := loadPackageTemplates(filepath.Join(templateDir, "package"))
+		if err != nil {
+			return err
+		}
+		if custom != nil {
+			templates = custom
+		}
+	}
+
+	packageDir := filepath.Join(newOutputDir, name)
+	if _, err := os.Stat(packageDir); err == nil && !newForceCreate {
+		return fmt.Errorf("directory %s already exists. Use --force to overwrite files", packageDir)
+	}
+
+	var created []string
+	for pathTemplate, contentTemplate := range templates {
+		relPath, err := renderTemplate(pathTemplate, pathTemplate, data)
+		if err != nil {
+			return err
+		}
+		content, err := renderTemplate(relPath, contentTemplate, data)
+		if err != nil {
+			return err
+		}
+		if err := writeScaffoldFile(filepath.Join(packageDir, filepath.FromSlash(relPath)), content); err != nil {
+			return err
+		}
+		created = append(created, relPath)
+	}
+
+	if _, err := manifest.LoadPackageManifests(packageManifestPath(packageDir)); err != nil {
+		return fmt.Errorf("generated manifest is invalid: %w", err)
+	}
+
+	output.Printf("✅ Created package: %s\n", packageDir)
+	if verbose {
+		sort.Strings(created)
+		for _, relPath := range created {
+			output.Printf("   - %s\n", relPath)
+		}
+	}
+	output.Printf("💡 Next: add rules with 'rfh new rule <name> --dir %s'\n", filepath.Join(packageDir, "rules"))
+
+	return nil
+}
+
+// newTemplateData builds template data from a name and the command flags
+func newTemplateData(name string) TemplateData {
+	id := sanitizeRuleID(name)
+
+	description := newDescription
+	if description == "" {
+		description = fmt.Sprintf("Rules for %s", strings.ReplaceAll(id, "-", " "))
+	}
+
+	license := newLicense
+	if license == "" {
+		license = "MIT"
+	}
+
+	return TemplateData{
+		Name:        name,
+		ID:          id,
+		Title:       titleFromID(id),
+		Description: description,
+		License:     license,
+		Author:      newAuthor,
+		Year:        time.Now().Year(),
+	}
+}
+
+// sanitizeRuleID lowercases a name and replaces anything but letters and digits with dashes
+func sanitizeRuleID(name string) string {
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// titleFromID turns "no-secrets" into "No Secrets"
+func titleFromID(id string) string {
+	words := strings.Split(id, "-")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// resolveTemplateDir returns the templates directory from the flag or CLI config
+func resolveTemplateDir() string {
+	if newTemplateDir != "" {
+		return newTemplateDir
+	}
+
+	if cfg, err := loadConfig(); err == nil {
+		return cfg.TemplatesDir
+	}
+
+	return ""
+}
+
+// loadPackageTemplates reads every file under dir as a template keyed by its relative path,
+// returning nil when the directory does not exist
+func loadPackageTemplates(dir string) (map[string]string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	templates := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		templates[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package templates from %s: %w", dir, err)
+	}
+
+	return templates, nil
+}
+
+// renderTemplate executes a text/template with the given data
+func renderTemplate(name, text string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// writeScaffoldFile writes a generated file, refusing to overwrite unless --force is set
+func writeScaffoldFile(path, content string) error {
+	if _, err := os.Stat(path); err == nil && !newForceCreate {
+		return fmt.Errorf("%s already exists. Use --force to overwrite", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func init() {
+	newCmd.PersistentFlags().StringVar(&newTemplateDir, "template-dir", "", "directory with custom templates (overrides templates_dir in config)")
+	newCmd.PersistentFlags().StringVar(&newDescription, "description", "", "description written into the generated files")
+	newCmd.PersistentFlags().StringVar(&newOutputDir, "dir", "", "directory to create files in (default: current directory)")
+	newCmd.PersistentFlags().BoolVar(&newForceCreate, "force", false, "overwrite existing files")
+
+	newPackageCmd.Flags().StringVar(&newLicense, "license", "MIT", "SPDX license identifier")
+	newPackageCmd.Flags().StringVar(&newAuthor, "author", "", "copyright holder for LICENSE")
+
+	newCmd.AddCommand(newRuleCmd)
+	newCmd.AddCommand(newPackageCmd)
+}
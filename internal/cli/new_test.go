@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func resetNewFlags(t *testing.T) {
+	t.Helper()
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	newTemplateDir, newDescription, newLicense, newAuthor, newOutputDir = "", "", "", "", ""
+	newForceCreate = false
+	t.Cleanup(func() {
+		newTemplateDir, newDescription, newLicense, newAuthor, newOutputDir = "", "", "", "", ""
+		newForceCreate = false
+	})
+}
+
+func TestRunNewRule(t *testing.T) {
+	resetNewFlags(t)
+	newOutputDir = t.TempDir()
+	newDescription = "Keep secrets out of code"
+
+	if err := runNewRule("No Secrets"); err != nil {
+		t.Fatalf("runNewRule failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(newOutputDir, "no-secrets.mdc"))
+	if err != nil {
+		t.Fatalf("Expected rule file to be created: %v", err)
+	}
+	if id := parseRuleID(string(content)); id != "no-secrets" {
+		t.Errorf("Expected frontmatter id no-secrets, got %q", id)
+	}
+	if !strings.Contains(string(content), "description: Keep secrets out of code") {
+		t.Errorf("Expected description in frontmatter, got:\n%s", content)
+	}
+
+	if err := runNewRule("no-secrets"); err == nil {
+		t.Error("Expected existing rule file not to be overwritten")
+	}
+
+	newForceCreate = true
+	if err := runNewRule("no-secrets"); err != nil {
+		t.Errorf("Expected --force to overwrite, got: %v", err)
+	}
+}
+
+func TestRunNewPackage(t *testing.T) {
+	resetNewFlags(t)
+	newOutputDir = t.TempDir()
+	newAuthor = "Platform Team"
+
+	if err := runNewPackage("security-rules"); err != nil {
+		t.Fatalf("runNewPackage failed: %v", err)
+	}
+
+	packageDir := filepath.Join(newOutputDir, "security-rules")
+	manifests, err := manifest.LoadPackageManifests(filepath.Join(packageDir, "rulestack.pkg.json"))
+	if err != nil {
+		t.Fatalf("Expected valid package manifest: %v", err)
+	}
+	if manifests[0].Name != "security-rules" || manifests[0].License != "MIT" {
+		t.Errorf("Unexpected manifest: %+v", manifests[0])
+	}
+
+	for _, file := range []string{"README.md", "LICENSE", "rules/security-rules.mdc"} {
+		if _, err := os.Stat(filepath.Join(packageDir, file)); err != nil {
+			t.Errorf("Expected %s to be created: %v", file, err)
+		}
+	}
+
+	license, _ := os.ReadFile(filepath.Join(packageDir, "LICENSE"))
+	if !strings.Contains(string(license), "Platform Team") {
+		t.Errorf("Expected author in LICENSE, got:\n%s", license)
+	}
+
+	if err := runNewPackage("security-rules"); err == nil {
+		t.Error("Expected existing package directory to be rejected")
+	}
+
+	if err := runNewPackage("Bad Name"); err == nil {
+		t.Error("Expected invalid package name to be rejected")
+	}
+}
+
+func TestRunNewWithOrgTemplates(t *testing.T) {
+	resetNewFlags(t)
+	newOutputDir = t.TempDir()
+
+	templateDir := t.TempDir()
+	ruleTemplate := "---\nid: acme-{{.ID}}\ndescription: {{.Description}}\n---\n\n# ACME {{.Title}}\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "rule.mdc"), []byte(ruleTemplate), 0644); err != nil {
+		t.Fatalf("Failed to write rule template: %v", err)
+	}
+	packageTemplates := map[string]string{
+		"rulestack.pkg.json":     `{"name": "{{.Name}}", "version": "0.1.0", "files": ["rules/*.mdc"], "license": "UNLICENSED"}`,
+		"rules/{{.ID}}-base.mdc": ruleTemplate,
+	}
+	for path, content := range packageTemplates {
+		fullPath := filepath.Join(templateDir, "package", filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create template dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	// Templates dir configured in the CLI config is picked up without the flag
+	configContent := "current = \"\"\ntemplates_dir = \"" + filepath.ToSlash(templateDir) + "\"\n"
+	if err := os.WriteFile(filepath.Join(os.Getenv("RFH_CONFIG"), "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := runNewRule("logging"); err != nil {
+		t.Fatalf("runNewRule failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(newOutputDir, "logging.mdc"))
+	if id := parseRuleID(string(content)); id != "acme-logging" {
+		t.Errorf("Expected org template id acme-logging, got %q", id)
+	}
+
+	if err := runNewPackage("acme-rules"); err != nil {
+		t.Fatalf("runNewPackage failed: %v", err)
+	}
+	packageDir := filepath.Join(newOutputDir, "acme-rules")
+	if _, err := os.Stat(filepath.Join(packageDir, "rules", "acme-rules-base.mdc")); err != nil {
+		t.Errorf("Expected templated path to be rendered: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(packageDir, "README.md")); !os.IsNotExist(err) {
+		t.Error("Expected org package template to replace the built-in skeleton")
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/version"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show dependencies with newer or deprecated versions",
+	Long: `List registry dependencies whose installed version differs from rulestack.json,
+has a newer release on the registry, or has been deprecated by its publisher.
+
+All dependencies are looked up in a single request. Local and git source
+dependencies are skipped.
+
+With --offline, versions are looked up in the local index kept by
+'rfh index sync' and no request is made.
+
+With --recursive, every project under the current directory is checked, each
+against its own registry.
+
+Examples:
+  rfh outdated
+  rfh outdated --offline
+  rfh outdated --recursive`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		offline, _ := cmd.Flags().GetBool("offline")
+		if recursive {
+			return runInProjects("outdated", func() error { return runOutdated(offline) })
+		}
+		return runOutdated(offline)
+	},
+}
+
+// OutdatedPackage is a dependency that is not current on the registry
+type OutdatedPackage struct {
+	Name        string `json:"name"`
+	Current     string `json:"current,omitempty"` // Installed version, empty if not installed
+	Wanted      string `json:"wanted"`            // Version required by rulestack.json
+	Latest      string `json:"latest"`
+	Deprecated  string `json:"deprecated,omitempty"`
+	Replacement string `json:"replacement,omitempty"` // What the publisher suggests using instead
+}
+
+// runOutdated implements the outdated command logic
+func runOutdated(offline bool) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	outdated, checked, err := projectOutdated(projectRoot, offline)
+	if err != nil {
+		return err
+	}
+
+	if checked == 0 {
+		output.Printf("ℹ️  No registry dependencies in rulestack.json\n")
+		return nil
+	}
+	if len(outdated) == 0 {
+		output.Printf("✅ All %d dependencies are up to date\n", checked)
+		return nil
+	}
+
+	output.Printf("%-30s %-12s %-12s %-12s\n", "Package", "Current", "Wanted", "Latest")
+	for _, pkg := range outdated {
+		current := pkg.Current
+		if current == "" {
+			current = "missing"
+		}
+		output.Printf("%-30s %-12s %-12s %-12s\n", pkg.Name, current, pkg.Wanted, pkg.Latest)
+		if pkg.Deprecated != "" {
+			output.Printf("   ⚠️  deprecated: %s\n", pkg.Deprecated)
+		}
+		if pkg.Replacement != "" {
+			output.Printf("   💡 replace with %s: rfh migrate-deps\n", pkg.Replacement)
+		}
+	}
+
+	return nil
+}
+
+// projectOutdated returns a project's outdated registry dependencies, and how
+// many registry dependencies were checked
+func projectOutdated(projectRoot string, offline bool) ([]OutdatedPackage, int, error) {
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load project manifest: %w", err)
+	}
+	if err := applyInherited(projectRoot, projectManifest); err != nil {
+		return nil, 0, err
+	}
+
+	installed := make(map[string]string)
+	var refs []client.VersionRef
+	for name, wanted := range projectManifest.ResolvedDependencies() {
+		if isSourceSpec(wanted) {
+			continue
+		}
+		refs = append(refs, client.VersionRef{Name: name, Version: wanted})
+		if current, _, err := findInstalledPackage(filepath.Join(projectRoot, ".rulestack"), name); err == nil {
+			installed[name] = current
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, 0, nil
+	}
+
+	metadata, err := lookupDependencies(refs, offline, projectManifest)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return findOutdated(metadata, installed), len(refs), nil
+}
+
+// lookupDependencies resolves the dependencies with one bulk request to the
+// project's registry, or from the local index with --offline
+func lookupDependencies(refs []client.VersionRef, offline bool, projectManifest *manifest.ProjectManifest) ([]client.VersionMetadata, error) {
+	if offline {
+		snapshot, err := loadSyncedSnapshot()
+		if err != nil {
+			return nil, err
+		}
+		metadata := make([]client.VersionMetadata, len(refs))
+		for i, ref := range refs {
+			metadata[i] = snapshot.Lookup(ref)
+		}
+		return metadata, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := useProjectRegistry(&cfg, projectManifest); err != nil {
+		return nil, err
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	metadata, err := c.GetPackageVersions(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up dependencies: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// findOutdated selects the dependencies that are not installed at the wanted version,
+// have a newer release available, or are deprecated, sorted by name
+func findOutdated(metadata []client.VersionMetadata, installed map[string]string) []OutdatedPackage {
+	var outdated []OutdatedPackage
+	for _, m := range metadata {
+		pkg := OutdatedPackage{
+			Name:        m.Name,
+			Current:     installed[m.Name],
+			Wanted:      m.Version,
+			Latest:      m.Latest,
+			Deprecated:  m.Deprecated,
+			Replacement: m.Replacement,
+		}
+		if pkg.Latest == "" {
+			pkg.Latest = m.Version
+		}
+
+		if pkg.Current != pkg.Wanted || isNewerVersion(pkg.Latest, pkg.Wanted) || pkg.Deprecated != "" {
+			outdated = append(outdated, pkg)
+		}
+	}
+
+	sort.Slice(outdated, func(i, j int) bool {
+		return outdated[i].Name < outdated[j].Name
+	})
+	return outdated
+}
+
+// isNewerVersion reports whether candidate is a higher semver than current
+func isNewerVersion(candidate, current string) bool {
+	candidateVersion, err := version.Parse(candidate)
+	if err != nil {
+		return false
+	}
+	currentVersion, err := version.Parse(current)
+	if err != nil {
+		return false
+	}
+	return candidateVersion.IsGreaterThan(currentVersion)
+}
+
+func init() {
+	outdatedCmd.Flags().Bool("offline", false, "Use the local index instead of the registry")
+	outdatedCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "check every project under the current directory")
+}
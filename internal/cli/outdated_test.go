@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"rulestack/internal/client"
+)
+
+func TestFindOutdated(t *testing.T) {
+	metadata := []client.VersionMetadata{
+		{Name: "security-rules", Version: "1.0.0", Found: true, Latest: "1.2.0"},
+		{Name: "current", Version: "2.0.0", Found: true, Latest: "2.0.0"},
+		{Name: "not-installed", Version: "0.1.0", Found: true, Latest: "0.1.0"},
+		{Name: "deprecated", Version: "1.0.0", Found: true, Latest: "1.0.0", Deprecated: "Use security-rules", Replacement: "security-rules@^1"},
+		{Name: "api-rules", Version: "3.0.0", Found: true, Latest: "3.1.0"},
+	}
+	installed := map[string]string{
+		"security-rules": "1.0.0",
+		"current":        "2.0.0",
+		"deprecated":     "1.0.0",
+		"api-rules":      "3.0.0",
+	}
+
+	expected := []OutdatedPackage{
+		{Name: "api-rules", Current: "3.0.0", Wanted: "3.0.0", Latest: "3.1.0"},
+		{Name: "deprecated", Current: "1.0.0", Wanted: "1.0.0", Latest: "1.0.0", Deprecated: "Use security-rules", Replacement: "security-rules@^1"},
+		{Name: "not-installed", Wanted: "0.1.0", Latest: "0.1.0"},
+		{Name: "security-rules", Current: "1.0.0", Wanted: "1.0.0", Latest: "1.2.0"},
+	}
+
+	if got := findOutdated(metadata, installed); !reflect.DeepEqual(got, expected) {
+		t.Errorf("findOutdated() =\n%+v\nwant\n%+v", got, expected)
+	}
+}
@@ -2,17 +2,40 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
 )
 
 var (
-	outputPath     string
-	fileOverride   string // Single file override
-	packageName    string // Non-interactive package name
-	packageVersion string // Non-interactive package version
+	outputPath       string
+	fileOverride     string // Single file override
+	packageName      string // Non-interactive package name
+	packageVersion   string // Non-interactive package version
+	packStrict       bool   // Fail instead of warning when an archive is over budget
+	packFromManifest bool   // Pack the files declared in rulestack.pkg.json
+
+	packCompression      string // Archive compression format, overriding config.toml
+	packCompressionLevel int    // Archive compression level, overriding config.toml
+)
+
+// Default archive budgets for 'rfh pack'. The archive budget matches the
+// registry's default upload limit.
+const (
+	defaultPackMaxArchiveSize = 10 << 20
+	defaultPackMaxFiles       = 200
+	defaultPackMaxFileSize    = 1 << 20
 )
 
+// largestFilesShown is how many of the largest files are listed when an archive is over budget
+const largestFilesShown = 5
+
 // packCmd represents the pack command
 var packCmd = &cobra.Command{
 	Use:   "pack",
@@ -30,19 +53,38 @@ Non-interactive mode:
    - rfh pack --file=my-rule.mdc --package="new-package"  # Creates new package at v1.0.0
    - rfh pack --file=my-rule.mdc --package="new-package" --version="1.2.0"  # Creates new package at v1.2.0
 
+Manifest mode:
+   - rfh pack --from-manifest
+   - Packs the files matched by the "files" patterns of rulestack.pkg.json
+     in the current directory, at the version it declares
+   - Fails if any pattern matches no files
+   - Use --package to choose when rulestack.pkg.json defines several packages
+
 The pack command:
 - Validates .mdc file format
 - Updates rulestack.json with new/updated package info  
 - Manages .rulestack package directories
 - Creates staged archive ready for publishing
 - Handles semantic version validation and incrementing
+- Warns when the archive exceeds the size budgets under [pack] in config.toml,
+  listing its largest files (--strict fails instead)
+- Compresses with gzip, or zstd (.tar.zst) with --compression=zstd. Registries
+  that do not accept zstd are sent a gzip copy on publish
 
 Examples:
   rfh pack --file=my-security-rule.mdc                                    # Interactive
   rfh pack --file=my-rule.mdc --package="new-rules"                      # Create new package
-  rfh pack --file=my-rule.mdc --package="new-rules" --version="2.1.0"    # Create new package with version`,
+  rfh pack --file=my-rule.mdc --package="new-rules" --version="2.1.0"    # Create new package with version
+  rfh pack --file=my-rule.mdc --package="new-rules" --strict             # Fail if over budget
+  rfh pack --file=my-rule.mdc --package="new-rules" --compression=zstd   # Smaller .tar.zst archive
+  rfh pack --from-manifest                                                # Pack as rulestack.pkg.json declares
+  rfh pack --from-manifest --package=network-rules -o network.tgz        # One of several packages`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if packFromManifest {
+			return runManifestPack()
+		}
+
 		if fileOverride == "" {
 			return fmt.Errorf("--file flag is required")
 		}
@@ -51,6 +93,9 @@ Examples:
 		if !isValidMdcFile(fileOverride) {
 			return fmt.Errorf("file must be a valid .mdc file: %s", fileOverride)
 		}
+		if _, err := packArchiveCompression(); err != nil {
+			return err
+		}
 
 		// Check if non-interactive mode
 		if packageName != "" {
@@ -67,6 +112,107 @@ func runInteractivePack(fileName string) error {
 	return createNewPackage(fileName)
 }
 
+// packArchiveCompression returns the compression for packed archives: the flags,
+// then [pack] in config.toml, then gzip at its default level
+func packArchiveCompression() (pkg.Compression, error) {
+	var c pkg.Compression
+	if cfg, err := loadConfig(); err == nil {
+		c = pkg.Compression{Format: cfg.Pack.Compression, Level: cfg.Pack.CompressionLevel}
+	}
+
+	if packCompression != "" {
+		if packCompression != c.Format {
+			c.Level = 0 // A level configured for another format does not carry over
+		}
+		c.Format = packCompression
+	}
+	if packCompressionLevel != 0 {
+		c.Level = packCompressionLevel
+	}
+	if c.Format == "" {
+		c.Format = compression.Gzip
+	}
+
+	if err := compression.Validate(c.Format, c.Level); err != nil {
+		return pkg.Compression{}, err
+	}
+	return c, nil
+}
+
+// stagedArchives returns the archives in the staging directory, in either format
+func stagedArchives(stagingDir string) ([]string, error) {
+	var archives []string
+	for _, format := range compression.Formats {
+		matches, err := filepath.Glob(filepath.Join(stagingDir, "*"+compression.Extension(format)))
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, matches...)
+	}
+	return archives, nil
+}
+
+// archiveBaseName returns the file name of an archive without its extension
+func archiveBaseName(archivePath string) string {
+	name := filepath.Base(archivePath)
+	for _, format := range compression.Formats {
+		if trimmed, ok := strings.CutSuffix(name, compression.Extension(format)); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// packBudget returns the archive budgets configured under [pack] in config.toml,
+// with defaults for anything not set
+func packBudget() pkg.Budget {
+	budget := pkg.Budget{
+		MaxArchiveSize: defaultPackMaxArchiveSize,
+		MaxFiles:       defaultPackMaxFiles,
+		MaxFileSize:    defaultPackMaxFileSize,
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return budget
+	}
+	if cfg.Pack.MaxArchiveSize != 0 {
+		budget.MaxArchiveSize = cfg.Pack.MaxArchiveSize
+	}
+	if cfg.Pack.MaxFiles != 0 {
+		budget.MaxFiles = cfg.Pack.MaxFiles
+	}
+	if cfg.Pack.MaxFileSize != 0 {
+		budget.MaxFileSize = cfg.Pack.MaxFileSize
+	}
+
+	return budget
+}
+
+// checkPackBudget warns about every budget a packed archive exceeds and lists its
+// largest files. With --strict the archive is removed and packing fails.
+func checkPackBudget(info *pkg.ArchiveInfo) error {
+	problems := packBudget().Check(info)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	for _, problem := range problems {
+		output.Printf("⚠️  %v\n", problem)
+	}
+	output.Printf("📊 Largest files:\n")
+	for _, file := range pkg.LargestFiles(info.Files, largestFilesShown) {
+		output.Printf("   %10s  %s\n", pkg.FormatSize(file.Size), file.Path)
+	}
+
+	if packStrict {
+		os.Remove(info.Path)
+		return fmt.Errorf("package is over %d budget(s); trim it or raise the limits under [pack] in config.toml", len(problems))
+	}
+
+	return nil
+}
+
 func init() {
 	packCmd.Flags().StringVarP(&outputPath, "output", "o", "", "output archive path")
 	packCmd.Flags().StringVarP(&fileOverride, "file", "f", "", ".mdc file to pack (required)")
@@ -74,4 +220,8 @@ func init() {
 	// Non-interactive mode flags
 	packCmd.Flags().StringVarP(&packageName, "package", "p", "", "package name (enables non-interactive mode)")
 	packCmd.Flags().StringVarP(&packageVersion, "version", "", "", "package version (auto-increments for existing packages, defaults to 1.0.0 for new packages)")
+	packCmd.Flags().BoolVar(&packFromManifest, "from-manifest", false, "pack the files declared in rulestack.pkg.json in the current directory")
+	packCmd.Flags().BoolVar(&packStrict, "strict", false, "fail instead of warning when the archive exceeds its size budgets")
+	packCmd.Flags().StringVar(&packCompression, "compression", "", "archive compression: gzip or zstd (default from config.toml, else gzip)")
+	packCmd.Flags().IntVar(&packCompressionLevel, "compression-level", 0, "compression level: 1-9 for gzip, 1-22 for zstd (0 uses the format's default)")
 }
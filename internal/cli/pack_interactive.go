@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,65 +8,34 @@ import (
 	"strings"
 
 	"rulestack/internal/manifest"
+	"rulestack/internal/output"
 	"rulestack/internal/version"
 )
 
-// promptUserChoice prompts user for a yes/no choice
-func promptUserChoice(question string) (bool, error) {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	for {
-		fmt.Printf("%s (y/n): ", question)
-		if !scanner.Scan() {
-			return false, fmt.Errorf("failed to read input")
-		}
-
-		response := strings.ToLower(strings.TrimSpace(scanner.Text()))
-		switch response {
-		case "y", "yes":
-			return true, nil
-		case "n", "no":
-			return false, nil
-		default:
-			fmt.Println("Please enter 'y' or 'n'")
-		}
-	}
-}
-
-// promptUserInput prompts user for text input
-func promptUserInput(question string) (string, error) {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	fmt.Printf("%s: ", question)
-	if !scanner.Scan() {
-		return "", fmt.Errorf("failed to read input")
-	}
-
-	return strings.TrimSpace(scanner.Text()), nil
-}
-
 // promptPackageSelection shows existing packages and prompts user to select one
 func promptPackageSelection(packageManifests manifest.PackageManifestFile) (int, error) {
 	if len(packageManifests) == 0 {
 		return -1, fmt.Errorf("no existing packages found")
 	}
+	if !isInteractive() {
+		return -1, fmt.Errorf("package selection is required in non-interactive mode; pass --package")
+	}
 
-	fmt.Println("\nExisting packages:")
+	output.Println("\nExisting packages:")
 	for i, m := range packageManifests {
-		fmt.Printf("  %d) %s (v%s) - %s\n", i+1, m.Name, m.Version, m.Description)
+		output.Printf("  %d) %s (v%s) - %s\n", i+1, m.Name, m.Version, m.Description)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-
 	for {
-		fmt.Printf("Select package (1-%d): ", len(packageManifests))
-		if !scanner.Scan() {
+		output.Printf("Select package (1-%d): ", len(packageManifests))
+		answer, err := readPromptLine()
+		if err != nil {
 			return -1, fmt.Errorf("failed to read input")
 		}
 
-		choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		choice, err := strconv.Atoi(answer)
 		if err != nil || choice < 1 || choice > len(packageManifests) {
-			fmt.Printf("Please enter a number between 1 and %d\n", len(packageManifests))
+			output.Printf("Please enter a number between 1 and %d\n", len(packageManifests))
 			continue
 		}
 
@@ -75,28 +43,26 @@ func promptPackageSelection(packageManifests manifest.PackageManifestFile) (int,
 	}
 }
 
-// promptNewVersion prompts user for new version number with validation
+// promptNewVersion prompts user for new version number with validation. In
+// non-interactive mode the next patch version is used.
 func promptNewVersion(currentVersion string) (string, error) {
 	nextPatch, err := version.IncrementPatchVersion(currentVersion)
 	if err != nil {
 		return "", err
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-
 	for {
-		fmt.Printf("Enter new version (current: %s, default: %s): ", currentVersion, nextPatch)
-		if !scanner.Scan() {
-			return "", fmt.Errorf("failed to read input")
-		}
-
-		input := strings.TrimSpace(scanner.Text())
-		if input == "" {
-			return nextPatch, nil
+		input, err := ask(prompt{Question: fmt.Sprintf("Enter new version (current: %s)", currentVersion), Default: nextPatch})
+		if err != nil {
+			return "", err
 		}
+		input = canonicalVersionArg(input)
 
 		if err := version.ValidateVersionIncrease(currentVersion, input); err != nil {
-			fmt.Printf("Error: %v\n", err)
+			if !isInteractive() {
+				return "", err
+			}
+			output.Printf("Error: %v\n", err)
 			continue
 		}
 
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+// runManifestPack packs a package declared in the current directory's rulestack.pkg.json
+func runManifestPack() error {
+	if fileOverride != "" {
+		return fmt.Errorf("--file cannot be used with --from-manifest; list the files in rulestack.pkg.json")
+	}
+	if packageVersion != "" {
+		return fmt.Errorf("--version cannot be used with --from-manifest; set the version in rulestack.pkg.json")
+	}
+
+	packageManifest, err := selectLinkManifest(".", packageName)
+	if err != nil {
+		return err
+	}
+
+	archiveCompression, err := packArchiveCompression()
+	if err != nil {
+		return err
+	}
+
+	archivePath := outputPath
+	if archivePath == "" {
+		stagingDir := getStagingDirectory()
+		if err := ensureDirectoryExists(stagingDir); err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		archivePath = filepath.Join(stagingDir, fmt.Sprintf("%s-%s%s", packageManifest.Name, packageManifest.Version, compression.Extension(archiveCompression.Format)))
+	}
+
+	info, err := packManifestPackage(".", packageManifest, archivePath, archiveCompression)
+	if err != nil {
+		return err
+	}
+	if err := checkPackBudget(info); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Packed %s v%s from %s\n", packageManifest.Name, packageManifest.Version, manifest.PackageManifestName)
+	output.Printf("📦 Archive: %s\n", info.Path)
+	output.Printf("📏 Size: %d bytes\n", info.SizeBytes)
+	output.Printf("🔒 SHA256: %s\n", info.SHA256)
+	output.Printf("📋 Files included: %d\n", len(info.Files))
+
+	return nil
+}
+
+// packManifestPackage packs the files a package manifest declares, with the
+// manifest itself, into archivePath. Every pattern must match at least one file.
+func packManifestPackage(sourceDir string, packageManifest *manifest.PackageManifest, archivePath string, c pkg.Compression) (*pkg.ArchiveInfo, error) {
+	if err := packageManifest.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ensureNotLinked(sourceDir, packageManifest.Name); err != nil {
+		return nil, err
+	}
+
+	files, err := resolveManifestFiles(sourceDir, packageManifest)
+	if err != nil {
+		return nil, err
+	}
+	if !packageManifest.IsMeta() && !slices.ContainsFunc(files, isRuleFileName) {
+		return nil, fmt.Errorf("no rule files (.md or .mdc) among the files of %s declared in rulestack.pkg.json; a package without rules installs nothing", packageManifest.Name)
+	}
+
+	stageDir, err := tempdir.MkdirTemp("pack-stage-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	for _, file := range files {
+		if err := copyFile(filepath.Join(sourceDir, filepath.FromSlash(file)), filepath.Join(stageDir, filepath.FromSlash(file))); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", file, err)
+		}
+	}
+	if err := manifest.SaveSinglePackageManifest(filepath.Join(stageDir, "rulestack.json"), packageManifest); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	info, err := pkg.PackFromDirectoryCompressed(stageDir, archivePath, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return info, nil
+}
+
+// resolveManifestFiles expands a package manifest's file patterns, and its test
+// fixtures, against sourceDir. It fails on a pattern that matches no files, so a
+// renamed or missing rule is caught before it silently drops out of the archive.
+func resolveManifestFiles(sourceDir string, packageManifest *manifest.PackageManifest) ([]string, error) {
+	patterns := packageManifest.Files
+	if packageManifest.Tests != "" {
+		patterns = append(append([]string{}, patterns...), path.Join(filepath.ToSlash(packageManifest.Tests), "*.json"))
+	}
+
+	fsys := os.DirFS(sourceDir)
+	seen := make(map[string]bool)
+	var files, unmatched []string
+
+	for _, pattern := range patterns {
+		slashPattern := path.Clean(filepath.ToSlash(pattern))
+		if path.IsAbs(slashPattern) || strings.HasPrefix(slashPattern, "../") || slashPattern == ".." {
+			return nil, fmt.Errorf("file pattern %s points outside the package", pattern)
+		}
+
+		matches, err := doublestar.Glob(fsys, slashPattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match pattern %s: %w", pattern, err)
+		}
+
+		matched := false
+		for _, match := range matches {
+			info, err := os.Stat(filepath.Join(sourceDir, filepath.FromSlash(match)))
+			if err != nil || info.IsDir() {
+				continue
+			}
+			matched = true
+			// The manifest is written fresh into every archive
+			if match == manifest.ProjectManifestName || match == manifest.PackageManifestName || seen[match] {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+		if !matched {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf("no files match %s declared in rulestack.pkg.json", strings.Join(unmatched, ", "))
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isRuleFileName reports whether a packed file is a rule, by extension
+func isRuleFileName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".mdc")
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestPackManifestPackage(t *testing.T) {
+	sourceDir := t.TempDir()
+	for name, content := range map[string]string{
+		"rules/security.mdc":      "# Security\n",
+		"rules/nested/deploy.mdc": "# Deploy\n",
+		"rules/notes.txt":         "not shipped\n",
+		"README.md":               "# Readme\n",
+	} {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	packageManifest := &manifest.PackageManifest{
+		Name:    "security-rules",
+		Version: "1.2.0",
+		Files:   []string{"rules/**/*.mdc", "README.md"},
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "security-rules-1.2.0.tgz")
+	info, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{})
+	if err != nil {
+		t.Fatalf("packManifestPackage failed: %v", err)
+	}
+
+	hashes, err := pkg.HashFiles(info.Path)
+	if err != nil {
+		t.Fatalf("HashFiles failed: %v", err)
+	}
+	for _, expected := range []string{"rules/security.mdc", "rules/nested/deploy.mdc", "README.md", "rulestack.json"} {
+		if _, ok := hashes[expected]; !ok {
+			t.Errorf("Expected %s in archive, got %v", expected, hashes)
+		}
+	}
+	if _, ok := hashes["rules/notes.txt"]; ok || len(hashes) != 4 {
+		t.Errorf("Expected only the declared files in archive, got %v", hashes)
+	}
+
+	packageManifest.Files = append(packageManifest.Files, "docs/*.md")
+	_, err = packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{})
+	if err == nil || !strings.Contains(err.Error(), "docs/*.md") {
+		t.Errorf("Expected an error naming the unmatched pattern, got %v", err)
+	}
+
+	packageManifest.Files = []string{"../outside/*.mdc"}
+	if _, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{}); err == nil {
+		t.Error("Expected a pattern outside the package to be rejected")
+	}
+
+	packageManifest.Files = []string{"rules/*.txt"}
+	_, err = packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{})
+	if err == nil || !strings.Contains(err.Error(), "no rule files") {
+		t.Errorf("Expected a package without rule files to be rejected, got %v", err)
+	}
+
+	// A meta-package ships only its manifest
+	metaManifest := &manifest.PackageManifest{
+		Name:         "backend-profile",
+		Version:      "1.0.0",
+		Type:         manifest.PackageTypeMeta,
+		Dependencies: map[string]string{"security-rules": "1.2.0"},
+	}
+	info, err = packManifestPackage(sourceDir, metaManifest, archivePath, pkg.Compression{})
+	if err != nil {
+		t.Fatalf("packManifestPackage failed for a meta-package: %v", err)
+	}
+	if hashes, _ := pkg.HashFiles(info.Path); len(hashes) != 1 || hashes["rulestack.json"] == "" {
+		t.Errorf("Expected only rulestack.json in the meta-package archive, got %v", hashes)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"rulestack/internal/compression"
 	"rulestack/internal/manifest"
+	"rulestack/internal/output"
 	"rulestack/internal/pkg"
 	"rulestack/internal/version"
 )
@@ -23,7 +25,7 @@ type ExistingPackageInfo struct {
 // createNewPackage creates a new package with the given file
 func createNewPackage(fileName string) error {
 	// Prompt for package name
-	packageName, err := promptUserInput("Enter new package name")
+	packageName, err := ask(prompt{Question: "Package name", Flag: "--package"})
 	if err != nil {
 		return err
 	}
@@ -37,6 +39,11 @@ func createNewPackage(fileName string) error {
 
 // createPackageFromMetadata creates a package with specified metadata (no manifest files saved)
 func createPackageFromMetadata(fileName, packageName, version string) error {
+	// Linked packages point at a developer's source tree and must not be packed
+	if err := ensureNotLinked(".", packageName); err != nil {
+		return err
+	}
+
 	// Create package manifest in memory only
 	packageManifest := &manifest.PackageManifest{
 		Name:        packageName,
@@ -73,17 +80,24 @@ func createPackageFromMetadata(fileName, packageName, version string) error {
 		return fmt.Errorf("failed to write manifest to package directory: %w", err)
 	}
 
-	archivePath := filepath.Join(stagingDir, fmt.Sprintf("%s-%s.tgz", packageName, version))
-	info, err := pkg.PackFromDirectory(packageDir, archivePath)
+	archiveCompression, err := packArchiveCompression()
+	if err != nil {
+		return err
+	}
+	archivePath := filepath.Join(stagingDir, fmt.Sprintf("%s-%s%s", packageName, version, compression.Extension(archiveCompression.Format)))
+	info, err := pkg.PackFromDirectoryCompressed(packageDir, archivePath, archiveCompression)
 	if err != nil {
 		return fmt.Errorf("failed to create archive: %w", err)
 	}
+	if err := checkPackBudget(info); err != nil {
+		return err
+	}
 
-	fmt.Printf("✅ Created new package: %s v%s\n", packageName, version)
-	fmt.Printf("📁 Package directory: %s\n", packageDir)
-	fmt.Printf("📦 Archive: %s\n", info.Path)
-	fmt.Printf("📏 Size: %d bytes\n", info.SizeBytes)
-	fmt.Printf("🔒 SHA256: %s\n", info.SHA256)
+	output.Printf("✅ Created new package: %s v%s\n", packageName, version)
+	output.Printf("📁 Package directory: %s\n", packageDir)
+	output.Printf("📦 Archive: %s\n", info.Path)
+	output.Printf("📏 Size: %d bytes\n", info.SizeBytes)
+	output.Printf("🔒 SHA256: %s\n", info.SHA256)
 
 	return nil
 }
@@ -108,6 +122,9 @@ func runNonInteractivePack(fileName string) error {
 	if packageName == "" {
 		return fmt.Errorf("--package is required in non-interactive mode")
 	}
+	if packageVersion != "" {
+		packageVersion = canonicalVersionArg(packageVersion)
+	}
 
 	// Check if this package already exists as an installed dependency
 	existingPkg, err := checkExistingPackage(packageName)
@@ -117,7 +134,7 @@ func runNonInteractivePack(fileName string) error {
 
 	if existingPkg != nil {
 		// Package exists - create updated version with all files
-		fmt.Printf("📦 Found existing package %s@%s with %d files\n",
+		output.Printf("📦 Found existing package %s@%s with %d files\n",
 			existingPkg.Name, existingPkg.Version, len(existingPkg.ExistingFiles))
 
 		if packageVersion == "" {
@@ -127,7 +144,7 @@ func runNonInteractivePack(fileName string) error {
 				return fmt.Errorf("failed to auto-increment version: %w", err)
 			}
 			packageVersion = nextVersion
-			fmt.Printf("🔄 Auto-incrementing version to %s\n", packageVersion)
+			output.Printf("🔄 Auto-incrementing version to %s\n", packageVersion)
 		}
 
 		return createUpdatedPackage(fileName, packageName, packageVersion, existingPkg)
@@ -137,7 +154,7 @@ func runNonInteractivePack(fileName string) error {
 			packageVersion = "1.0.0" // Default version for new packages
 		}
 
-		fmt.Printf("🆕 Creating new package %s@%s\n", packageName, packageVersion)
+		output.Printf("🆕 Creating new package %s@%s\n", packageName, packageVersion)
 		return createNewPackageNonInteractive(fileName, packageName, packageVersion)
 	}
 }
@@ -223,6 +240,11 @@ func findRuleFilesInDirectory(dirPath string) ([]string, error) {
 func createUpdatedPackage(fileName, packageName, newVersion string, existingPkg *ExistingPackageInfo) error {
 	// Pre-flight validations
 
+	// 0. Refuse to build on top of a linked package directory
+	if err := ensureNotLinked(".", packageName); err != nil {
+		return err
+	}
+
 	// 1. Ensure new file exists and is readable
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
 		return fmt.Errorf("input file %s does not exist", fileName)
@@ -305,19 +327,26 @@ func createUpdatedPackage(fileName, packageName, newVersion string, existingPkg
 		return fmt.Errorf("failed to create staging directory: %w", err)
 	}
 
-	archivePath := filepath.Join(stagingDir, fmt.Sprintf("%s-%s.tgz", packageName, newVersion))
-	info, err := pkg.PackFromDirectory(newPackageDir, archivePath)
+	archiveCompression, err := packArchiveCompression()
+	if err != nil {
+		return err
+	}
+	archivePath := filepath.Join(stagingDir, fmt.Sprintf("%s-%s%s", packageName, newVersion, compression.Extension(archiveCompression.Format)))
+	info, err := pkg.PackFromDirectoryCompressed(newPackageDir, archivePath, archiveCompression)
 	if err != nil {
 		return fmt.Errorf("failed to create archive: %w", err)
 	}
+	if err := checkPackBudget(info); err != nil {
+		return err
+	}
 
 	// 12. Success output
-	fmt.Printf("✅ Updated existing package: %s v%s -> v%s\n", packageName, existingPkg.Version, newVersion)
-	fmt.Printf("📁 Package directory: %s\n", newPackageDir)
-	fmt.Printf("📦 Archive: %s\n", info.Path)
-	fmt.Printf("📏 Size: %d bytes\n", info.SizeBytes)
-	fmt.Printf("🔒 SHA256: %s\n", info.SHA256)
-	fmt.Printf("📋 Files included: %s\n", strings.Join(allFiles, ", "))
+	output.Printf("✅ Updated existing package: %s v%s -> v%s\n", packageName, existingPkg.Version, newVersion)
+	output.Printf("📁 Package directory: %s\n", newPackageDir)
+	output.Printf("📦 Archive: %s\n", info.Path)
+	output.Printf("📏 Size: %d bytes\n", info.SizeBytes)
+	output.Printf("🔒 SHA256: %s\n", info.SHA256)
+	output.Printf("📋 Files included: %s\n", strings.Join(allFiles, ", "))
 
 	// Mark success at the end
 	success = true
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/config"
+	"rulestack/internal/output"
+)
+
+// registryPinCmd shows and manages the TLS key pinned for an HTTPS registry
+var registryPinCmd = &cobra.Command{
+	Use:   "pin <name>",
+	Short: "Show or reset the TLS key pinned for a registry",
+	Long: `Show or reset the TLS public key pinned for an HTTPS registry.
+
+The key is pinned the first time the CLI connects to the registry. When the
+registry later presents a different key, a warning is printed; in strict mode
+the connection is refused instead.
+
+Examples:
+  rfh registry pin corp
+  rfh registry pin corp --strict
+  rfh registry pin corp --reset`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reset, _ := cmd.Flags().GetBool("reset")
+		var strict *bool
+		if cmd.Flags().Changed("strict") {
+			value, _ := cmd.Flags().GetBool("strict")
+			strict = &value
+		}
+		return runRegistryPin(args[0], reset, strict)
+	},
+}
+
+func runRegistryPin(name string, reset bool, strict *bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registry, exists := cfg.Registries[name]
+	if !exists {
+		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", name)
+	}
+	if registry.GetEffectiveType() != config.RegistryTypeHTTP {
+		return fmt.Errorf("registry '%s' is not an HTTP registry; use 'rfh registry trust' for Git registries", name)
+	}
+
+	if reset || strict != nil {
+		if reset {
+			registry.PinnedKey = ""
+		}
+		if strict != nil {
+			registry.StrictPinning = *strict
+		}
+		cfg.Registries[name] = registry
+		if err := config.SaveCLI(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if reset {
+			output.Printf("✅ Forgot the pinned key for '%s'; the next connection pins a new one\n", name)
+		}
+	}
+
+	mode := "warn on change"
+	if registry.StrictPinning {
+		mode = "strict"
+	}
+
+	if registry.PinnedKey == "" {
+		output.Printf("📌 %s: no key pinned yet (%s)\n", name, mode)
+	} else {
+		output.Printf("📌 %s: %s (%s)\n", name, registry.PinnedKey, mode)
+	}
+
+	return nil
+}
+
+func init() {
+	registryPinCmd.Flags().Bool("reset", false, "Forget the pinned key so the next connection pins the key it sees")
+	registryPinCmd.Flags().Bool("strict", false, "Refuse connections when the key changes (use --strict=false to only warn)")
+}
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+var (
+	policyConstraints string
+	policyJSON        bool
+)
+
+// policyCmd represents the policy command
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check projects against organization policy",
+	Long: `Check the projects in a repository against the minimum versions set by an
+organization policy.`,
+}
+
+// policyCheckCmd represents the policy check command
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [dir]",
+	Short: "Report projects below their minimum package versions",
+	Long: `Find every project rulestack.json under a directory (the current one by
+default) and report the dependencies declared below the "minimum_versions" of
+an organization policy.
+
+The policy is a constraints file (see 'rfh audit'). With --constraints, one
+policy, from a path or an http(s) URL such as one published next to the
+registry, is applied to every project. Without it, each project is checked
+against the constraints file named in its own rulestack.json, and projects
+without one are skipped.
+
+Package sources, .git, .rulestack, node_modules and vendor directories are not
+searched. Exits with an error when any project violates the policy, so it can
+be used in CI and fleet scans; --json prints a report for tools.
+
+Examples:
+  rfh policy check
+  rfh policy check services --constraints policy/constraints.json
+  rfh policy check --constraints https://rules.example.com/policy.json --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		return runPolicyCheck(dir, policyConstraints, policyJSON)
+	},
+}
+
+// PolicyReport is the result of 'rfh policy check'
+type PolicyReport struct {
+	Projects   []PolicyProject `json:"projects"`
+	Violations int             `json:"violations"`
+}
+
+// PolicyProject is the result for one project
+type PolicyProject struct {
+	Path       string            `json:"path"`             // Relative to the directory searched
+	Policy     string            `json:"policy,omitempty"` // Constraints file or URL checked against
+	Skipped    string            `json:"skipped,omitempty"`
+	Violations []PolicyViolation `json:"violations"`
+}
+
+// PolicyViolation is a dependency declared below its minimum version
+type PolicyViolation struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Minimum string `json:"minimum"`
+}
+
+func runPolicyCheck(dir, policySource string, asJSON bool) error {
+	var policy *manifest.Constraints
+	if policySource != "" {
+		var err error
+		if policy, err = loadPolicy(policySource); err != nil {
+			return err
+		}
+	}
+
+	projectRoots, err := findProjects(dir)
+	if err != nil {
+		return err
+	}
+
+	report := PolicyReport{Projects: []PolicyProject{}}
+	failing := 0
+	for _, projectRoot := range projectRoots {
+		result := checkProjectPolicy(projectRoot, policy, policySource)
+		if relPath, err := filepath.Rel(dir, projectRoot); err == nil {
+			result.Path = filepath.ToSlash(relPath)
+		}
+		if len(result.Violations) > 0 {
+			failing++
+		}
+		report.Violations += len(result.Violations)
+		report.Projects = append(report.Projects, result)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode policy report: %w", err)
+		}
+		output.Data(data)
+	} else {
+		printPolicyReport(report)
+	}
+
+	if report.Violations > 0 {
+		return fmt.Errorf("found %d minimum version violation(s) in %d project(s)", report.Violations, failing)
+	}
+	return nil
+}
+
+// loadPolicy loads a constraints file from a path or an http(s) URL
+func loadPolicy(source string) (*manifest.Constraints, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		constraints, err := manifest.LoadConstraints(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policy: %w", err)
+		}
+		return constraints, nil
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch policy: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch policy: %s returned status %d", source, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch policy: %w", err)
+	}
+
+	constraints, err := manifest.ParseConstraints(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy at %s: %w", source, err)
+	}
+	return constraints, nil
+}
+
+// checkProjectPolicy checks one project's dependencies, including those it
+// inherits through "extends", overrides and aliases, against the minimum
+// versions of a policy, or of its own constraints file when policy is nil
+func checkProjectPolicy(projectRoot string, policy *manifest.Constraints, policySource string) PolicyProject {
+	result := PolicyProject{Policy: policySource, Violations: []PolicyViolation{}}
+
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		result.Skipped = err.Error()
+		return result
+	}
+	// A base configuration that is not installed only hides its dependencies
+	applyInherited(projectRoot, projectManifest)
+
+	if policy == nil {
+		if policy, err = loadProjectConstraints(projectRoot, projectManifest); err != nil {
+			result.Skipped = err.Error()
+			return result
+		}
+		if policy == nil {
+			result.Skipped = "no constraints file in rulestack.json"
+			return result
+		}
+		result.Policy = projectManifest.Constraints
+	}
+
+	// Packages built from source are checked at the version they were locked at
+	var locked map[string]LockPackageEntry
+	if lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot); err == nil {
+		locked = lockManifest.Packages
+	}
+
+	check := func(name, packageName, ver string) {
+		if isSourceSpec(ver) {
+			entry, ok := locked[name]
+			if !ok {
+				return
+			}
+			ver = entry.Version
+		}
+		if minimum, below := policy.BelowMinimum(packageName, ver); below {
+			result.Violations = append(result.Violations, PolicyViolation{Package: packageName, Version: ver, Minimum: minimum})
+		}
+	}
+	for name, declared := range projectManifest.Dependencies {
+		packageName, ver, _ := projectManifest.ResolveDependency(name, declared)
+		check(name, packageName, ver)
+	}
+	for alias := range projectManifest.Aliases {
+		packageName, ver, _ := projectManifest.ResolveAlias(alias)
+		check(alias, packageName, ver)
+	}
+
+	sort.Slice(result.Violations, func(i, j int) bool {
+		if result.Violations[i].Package != result.Violations[j].Package {
+			return result.Violations[i].Package < result.Violations[j].Package
+		}
+		return result.Violations[i].Version < result.Violations[j].Version
+	})
+	return result
+}
+
+func printPolicyReport(report PolicyReport) {
+	if len(report.Projects) == 0 {
+		output.Printf("ℹ️  No projects found\n")
+		return
+	}
+
+	checked := 0
+	for _, project := range report.Projects {
+		switch {
+		case project.Skipped != "":
+			output.Printf("ℹ️  %s: skipped, %s\n", project.Path, project.Skipped)
+		case len(project.Violations) == 0:
+			checked++
+			output.Printf("✅ %s\n", project.Path)
+		default:
+			checked++
+			output.Printf("❌ %s\n", project.Path)
+			for _, violation := range project.Violations {
+				output.Printf("   %s@%s is below the minimum version %s\n", violation.Package, violation.Version, violation.Minimum)
+			}
+		}
+	}
+
+	output.Printf("\n📋 Checked %d of %d project(s)\n", checked, len(report.Projects))
+}
+
+func init() {
+	policyCheckCmd.Flags().StringVar(&policyConstraints, "constraints", "", "constraints file or http(s) URL applied to every project (default: each project's own)")
+	policyCheckCmd.Flags().BoolVar(&policyJSON, "json", false, "print the report as JSON")
+
+	policyCmd.AddCommand(policyCheckCmd)
+}
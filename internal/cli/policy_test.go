@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeMonorepo lays out a repository of projects, a package source and a
+// project inside node_modules that must not be found
+func writeMonorepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"services/api/rulestack.json":      `{"version": "1.0.0", "constraints": "../../policy.json", "dependencies": {"security-rules": "1.0.0", "style-rules": "2.0.0"}, "aliases": {"security-rules-v2": "security-rules@2.0.0"}}`,
+		"services/web/rulestack.json":      `{"version": "1.0.0", "dependencies": {"security-rules": "1.3.0"}}`,
+		"tools/rules/rulestack.json":       `{"name": "team-rules", "version": "1.0.0", "files": ["*.md"]}`,
+		"node_modules/x/rulestack.json":    `{"version": "1.0.0", "dependencies": {"security-rules": "0.1.0"}}`,
+		"policy.json":                      `{"minimum_versions": {"security-rules": "1.2.0"}}`,
+		"services/api/.rulestack/keep.txt": "",
+	} {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindProjects(t *testing.T) {
+	root := writeMonorepo(t)
+
+	projects, err := findProjects(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(root, "services", "api"), filepath.Join(root, "services", "web")}
+	if !reflect.DeepEqual(projects, expected) {
+		t.Errorf("findProjects = %v, want %v", projects, expected)
+	}
+}
+
+func TestCheckProjectPolicy(t *testing.T) {
+	root := writeMonorepo(t)
+	api := filepath.Join(root, "services", "api")
+	web := filepath.Join(root, "services", "web")
+
+	t.Run("own constraints", func(t *testing.T) {
+		result := checkProjectPolicy(api, nil, "")
+		expected := []PolicyViolation{{Package: "security-rules", Version: "1.0.0", Minimum: "1.2.0"}}
+		if !reflect.DeepEqual(result.Violations, expected) {
+			t.Errorf("violations = %+v, want %+v", result.Violations, expected)
+		}
+
+		if result := checkProjectPolicy(web, nil, ""); result.Skipped == "" {
+			t.Error("project without constraints should be skipped")
+		}
+	})
+
+	t.Run("policy from a URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"minimum_versions": {"security-rules": "1.4.0"}}`))
+		}))
+		defer server.Close()
+
+		policy, err := loadPolicy(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result := checkProjectPolicy(web, policy, server.URL); len(result.Violations) != 1 || result.Skipped != "" {
+			t.Errorf("web: %+v", result)
+		}
+		if result := checkProjectPolicy(api, policy, server.URL); len(result.Violations) != 1 {
+			t.Errorf("api: %+v, want only the unaliased security-rules below 1.4.0", result)
+		}
+	})
+
+	t.Run("fails when a project violates the policy", func(t *testing.T) {
+		if err := runPolicyCheck(root, filepath.Join(root, "policy.json"), true); err == nil {
+			t.Error("expected an error for violations")
+		}
+		if err := runPolicyCheck(filepath.Join(root, "tools"), filepath.Join(root, "policy.json"), false); err != nil {
+			t.Errorf("no projects should pass: %v", err)
+		}
+	})
+}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+)
+
+// previewUpdateCmd represents the preview-update command
+var previewUpdateCmd = &cobra.Command{
+	Use:   "preview-update <package>[@version]",
+	Short: "Show how an update would change a package's rule files",
+	Long: `Compare the rule files of an installed package with another version on the
+active registry, the latest by default, before updating to it.
+
+The report lists rule files that would be added, removed or changed, and shows
+the changed words of each changed file, so you can judge how the update alters
+what your AI assistant is told. Nothing is installed; run 'rfh add' to update.
+
+Examples:
+  rfh preview-update security-rules
+  rfh preview-update security-rules@2.0.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPreviewUpdate(args[0])
+	},
+}
+
+// maxWordDiffCells bounds the work of a word diff; larger files are only
+// reported as changed
+const maxWordDiffCells = 4_000_000
+
+// maxShownHunks is how many changes of each file the report shows
+const maxShownHunks = 5
+
+// hunkContextWords is how many unchanged words surround each shown change
+const hunkContextWords = 4
+
+// ruleFileChange is the difference in one rule file between two versions
+type ruleFileChange struct {
+	Path    string
+	Change  string // "added", "removed" or "changed"
+	Words   int    // Words in an added or removed file
+	Added   int    // Words added to a changed file
+	Removed int    // Words removed from a changed file
+	Hunks   []string
+}
+
+func runPreviewUpdate(spec string) error {
+	name, target, _ := strings.Cut(spec, "@")
+	target = canonicalVersionArg(target)
+	if name == "" {
+		return fmt.Errorf("package name cannot be empty")
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+	installed, packageDir, err := findInstalledPackage(filepath.Join(projectRoot, ".rulestack"), name)
+	if err != nil {
+		return fmt.Errorf("%s is not installed; preview it with 'rfh inspect %s@<version>' instead", name, name)
+	}
+
+	if target == "" || target == "latest" {
+		if target, err = latestVersion(name); err != nil {
+			return err
+		}
+	}
+	if target == installed {
+		output.Printf("✅ %s@%s is already installed\n", name, installed)
+		return nil
+	}
+
+	archivePath, _, err := downloadForInspection(name + "@" + target)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archivePath)
+
+	before, err := readInstalledRuleFiles(packageDir)
+	if err != nil {
+		return fmt.Errorf("failed to read installed package: %w", err)
+	}
+	after, err := readArchiveRuleFiles(archivePath)
+	if err != nil {
+		return err
+	}
+
+	changes, unchanged := compareRuleFiles(before, after)
+	printRuleFileChanges(name, installed, target, changes, unchanged)
+	return nil
+}
+
+// isRuleFile reports whether a package file is a Markdown rule file
+func isRuleFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".md" || ext == ".mdc"
+}
+
+// readInstalledRuleFiles reads the rule files of an installed package, keyed by
+// their slash-separated path in the package
+func readInstalledRuleFiles(packageDir string) (map[string]string, error) {
+	// Packages kept in the cache are links to their directory there
+	if resolved, err := filepath.EvalSymlinks(packageDir); err == nil {
+		packageDir = resolved
+	}
+
+	files := make(map[string]string)
+	err := filepath.Walk(packageDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !isRuleFile(info.Name()) {
+			return err
+		}
+		relPath, err := filepath.Rel(packageDir, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+	return files, err
+}
+
+// readArchiveRuleFiles reads the rule files of a package archive, keyed like
+// readInstalledRuleFiles
+func readArchiveRuleFiles(archivePath string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := pkg.WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		if !isRuleFile(header.Name) {
+			return nil
+		}
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		files[path.Clean(header.Name)] = string(data)
+		return nil
+	})
+	return files, err
+}
+
+// compareRuleFiles returns the changes from one version's rule files to
+// another's, sorted by path, and how many files are the same in both
+func compareRuleFiles(before, after map[string]string) ([]ruleFileChange, int) {
+	var changes []ruleFileChange
+	unchanged := 0
+
+	for filePath, old := range before {
+		updated, ok := after[filePath]
+		switch {
+		case !ok:
+			changes = append(changes, ruleFileChange{Path: filePath, Change: "removed", Words: len(strings.Fields(old))})
+		case updated == old:
+			unchanged++
+		default:
+			change := diffWords(strings.Fields(old), strings.Fields(updated))
+			change.Path = filePath
+			changes = append(changes, change)
+		}
+	}
+	for filePath, content := range after {
+		if _, ok := before[filePath]; !ok {
+			changes = append(changes, ruleFileChange{Path: filePath, Change: "added", Words: len(strings.Fields(content))})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, unchanged
+}
+
+// wordOp is one step of a word diff: an unchanged, removed or added word
+type wordOp struct {
+	kind byte // '=', '-' or '+'
+	word string
+}
+
+// diffWords compares two word lists and describes each run of changed words,
+// in git's --word-diff style: [-removed-]{+added+}, with surrounding context.
+// Changes close enough to share context are shown together. Whitespace-only
+// changes leave no hunks.
+func diffWords(old, updated []string) ruleFileChange {
+	change := ruleFileChange{Change: "changed"}
+	if len(old)*len(updated) > maxWordDiffCells {
+		return change
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of old[i:] and updated[j:]
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(updated)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(updated) - 1; j >= 0; j-- {
+			if old[i] == updated[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []wordOp
+	for i, j := 0, 0; i < len(old) || j < len(updated); {
+		switch {
+		case i < len(old) && j < len(updated) && old[i] == updated[j]:
+			ops = append(ops, wordOp{'=', old[i]})
+			i, j = i+1, j+1
+		case j >= len(updated) || (i < len(old) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, wordOp{'-', old[i]})
+			change.Removed++
+			i++
+		default:
+			ops = append(ops, wordOp{'+', updated[j]})
+			change.Added++
+			j++
+		}
+	}
+
+	for k := 0; k < len(ops); k++ {
+		if ops[k].kind == '=' {
+			continue
+		}
+
+		// Extend the hunk over changes separated by less than twice the context
+		end, unchangedRun := k, 0
+		for n := k + 1; n < len(ops) && unchangedRun <= 2*hunkContextWords; n++ {
+			if ops[n].kind == '=' {
+				unchangedRun++
+				continue
+			}
+			end, unchangedRun = n, 0
+		}
+
+		start := max(0, k-hunkContextWords)
+		stop := min(len(ops), end+1+hunkContextWords)
+		change.Hunks = append(change.Hunks, renderWordOps(ops[start:stop], start > 0, stop < len(ops)))
+		k = end
+	}
+
+	return change
+}
+
+// renderWordOps writes a hunk of a word diff, with … marking elided words
+func renderWordOps(ops []wordOp, before, after bool) string {
+	var b strings.Builder
+	if before {
+		b.WriteString("…")
+	}
+	for k := 0; k < len(ops); {
+		n := k
+		for n < len(ops) && ops[n].kind == ops[k].kind {
+			n++
+		}
+		words := make([]string, 0, n-k)
+		for _, op := range ops[k:n] {
+			words = append(words, op.word)
+		}
+
+		// Removed words are followed directly by the words that replace them
+		if b.Len() > 0 && !(ops[k].kind == '+' && k > 0 && ops[k-1].kind == '-') {
+			b.WriteString(" ")
+		}
+		switch ops[k].kind {
+		case '-':
+			b.WriteString("[-" + strings.Join(words, " ") + "-]")
+		case '+':
+			b.WriteString("{+" + strings.Join(words, " ") + "+}")
+		default:
+			b.WriteString(strings.Join(words, " "))
+		}
+		k = n
+	}
+	if after {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
+
+func printRuleFileChanges(name, installed, target string, changes []ruleFileChange, unchanged int) {
+	output.Printf("🔍 %s: %s → %s\n", name, installed, target)
+
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Change]++
+	}
+	output.Printf("   %d added, %d removed, %d changed, %d unchanged rule file(s)\n",
+		counts["added"], counts["removed"], counts["changed"], unchanged)
+
+	for _, change := range changes {
+		switch change.Change {
+		case "added":
+			output.Printf("\n+ %s (%d words)\n", change.Path, change.Words)
+		case "removed":
+			output.Printf("\n- %s (%d words)\n", change.Path, change.Words)
+		default:
+			if change.Hunks == nil && change.Added == 0 && change.Removed == 0 {
+				output.Printf("\n~ %s (whitespace or too large to compare word by word)\n", change.Path)
+				continue
+			}
+			output.Printf("\n~ %s (+%d -%d words)\n", change.Path, change.Added, change.Removed)
+			for i, hunk := range change.Hunks {
+				if i == maxShownHunks {
+					output.Printf("    … %d more change(s)\n", len(change.Hunks)-maxShownHunks)
+					break
+				}
+				output.Printf("    %s\n", hunk)
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		output.Printf("\n✅ The rule files are the same in both versions\n")
+	}
+	output.Printf("\n💡 Update with: rfh add %s@%s\n", name, target)
+}
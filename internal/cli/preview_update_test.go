@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestCompareRuleFiles(t *testing.T) {
+	before := map[string]string{
+		"rules/secrets.mdc": "# Secrets\nNever commit secrets to the repository.\nUse a vault.",
+		"rules/old.md":      "# Old\nGone soon.",
+		"rules/same.md":     "# Same",
+		"rules/spacing.md":  "# Spacing\nOne line.",
+	}
+	after := map[string]string{
+		"rules/secrets.mdc": "# Secrets\nNever store secrets in the repository.\nUse a vault.",
+		"rules/new.mdc":     "# New\nA new rule here.",
+		"rules/same.md":     "# Same",
+		"rules/spacing.md":  "# Spacing\n\nOne   line.",
+	}
+
+	changes, unchanged := compareRuleFiles(before, after)
+	if unchanged != 1 || len(changes) != 4 {
+		t.Fatalf("expected 4 changes and 1 unchanged file, got %+v and %d", changes, unchanged)
+	}
+
+	byPath := make(map[string]ruleFileChange)
+	for _, change := range changes {
+		byPath[change.Path] = change
+	}
+	if added := byPath["rules/new.mdc"]; added.Change != "added" || added.Words != 6 {
+		t.Errorf("expected new.mdc added with 6 words, got %+v", added)
+	}
+	if removed := byPath["rules/old.md"]; removed.Change != "removed" || removed.Words != 4 {
+		t.Errorf("expected old.md removed with 4 words, got %+v", removed)
+	}
+
+	secrets := byPath["rules/secrets.mdc"]
+	if secrets.Change != "changed" || secrets.Added != 2 || secrets.Removed != 2 || len(secrets.Hunks) != 1 {
+		t.Fatalf("expected one hunk with 2 words changed each way, got %+v", secrets)
+	}
+	if want := "# Secrets Never [-commit-]{+store+} secrets [-to-]{+in+} the repository. Use a …"; secrets.Hunks[0] != want {
+		t.Errorf("hunk = %q, want %q", secrets.Hunks[0], want)
+	}
+
+	if spacing := byPath["rules/spacing.md"]; spacing.Change != "changed" || len(spacing.Hunks) != 0 {
+		t.Errorf("expected a whitespace-only change without hunks, got %+v", spacing)
+	}
+}
+
+func TestRunPreviewUpdate(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "secrets.mdc"), []byte("# Never store secrets\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	packageManifest := &manifest.PackageManifest{Name: "security-rules", Version: "1.3.0", Files: []string{"*.mdc"}}
+	archivePath := filepath.Join(t.TempDir(), "security-rules-1.3.0.tgz")
+	info, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{})
+	if err != nil {
+		t.Fatalf("packManifestPackage failed: %v", err)
+	}
+	archiveData, _ := os.ReadFile(archivePath)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages/security-rules":
+			json.NewEncoder(w).Encode(map[string]string{"name": "security-rules", "latest": "1.3.0"})
+		case "/v1/packages/security-rules/versions/1.3.0":
+			json.NewEncoder(w).Encode(map[string]string{"name": "security-rules", "version": "1.3.0", "sha256": info.SHA256})
+		case "/v1/blobs/" + info.SHA256:
+			w.Write(archiveData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	packageDir := filepath.Join(projectDir, ".rulestack", "security-rules.1.2.0")
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(`{"version": "1.0.0", "dependencies": {"security-rules": "1.2.0"}}`), 0644)
+	os.WriteFile(filepath.Join(packageDir, "secrets.mdc"), []byte("# Never commit secrets\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectDir)
+
+	if err := runPreviewUpdate("security-rules"); err != nil {
+		t.Errorf("expected a preview of the latest version, got %v", err)
+	}
+	if err := runPreviewUpdate("security-rules@1.2.0"); err != nil {
+		t.Errorf("expected the installed version to be reported as current, got %v", err)
+	}
+	if err := runPreviewUpdate("network-rules"); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected a not installed error, got %v", err)
+	}
+}
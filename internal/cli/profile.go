@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/config"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/security"
+	"rulestack/internal/tracing"
+)
+
+var (
+	// profileReport prints where the command's time went when it finishes
+	profileReport bool
+
+	// cpuProfilePath and execTracePath name files for a pprof CPU profile and a
+	// runtime execution trace of the command
+	cpuProfilePath string
+	execTracePath  string
+)
+
+// profiling is the state of a command being profiled
+var profiling struct {
+	command string
+	started time.Time
+	profile *tracing.Profile
+	stop    []func()
+}
+
+// profilePhases groups the spans timed during a command into the phases of the
+// report; other spans are reported under their own names
+var profilePhases = map[string]string{
+	"config load":     "config load",
+	"git sync":        "clone/pull",
+	"git download":    "download",
+	"download":        "download",
+	"extract":         "extraction",
+	"write manifests": "manifest writes",
+}
+
+func profilePhase(spanName string) string {
+	if strings.HasPrefix(spanName, "HTTP ") {
+		return "registry calls"
+	}
+	if phase, ok := profilePhases[spanName]; ok {
+		return phase
+	}
+	return spanName
+}
+
+// startProfiling starts the profiling asked for on the command line
+func startProfiling(cmd *cobra.Command) error {
+	profiling.command = getFullCommandName(cmd)
+	profiling.started = time.Now()
+
+	if profileReport {
+		profiling.profile = tracing.NewProfile()
+		tracing.AddProcessor(profiling.profile)
+	}
+
+	if cpuProfilePath != "" {
+		file, err := os.Create(cpuProfilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		profiling.stop = append(profiling.stop, func() {
+			pprof.StopCPUProfile()
+			file.Close()
+		})
+	}
+
+	if execTracePath != "" {
+		file, err := os.Create(execTracePath)
+		if err != nil {
+			return fmt.Errorf("failed to create execution trace: %w", err)
+		}
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to start execution trace: %w", err)
+		}
+		profiling.stop = append(profiling.stop, func() {
+			trace.Stop()
+			file.Close()
+		})
+	}
+
+	return nil
+}
+
+// finishProfiling stops profiling once the command has run and prints the
+// report to stderr, keeping stdout for the command's own output
+func finishProfiling() {
+	for _, stop := range profiling.stop {
+		stop()
+	}
+	profiling.stop = nil
+
+	if profiling.profile == nil {
+		return
+	}
+	elapsed := time.Since(profiling.started)
+
+	phases := make(map[string]*tracing.SpanTotal)
+	var order []string
+	var covered time.Duration
+	for _, total := range profiling.profile.Totals() {
+		name := profilePhase(total.Name)
+		phase, ok := phases[name]
+		if !ok {
+			phase = &tracing.SpanTotal{Name: name}
+			phases[name] = phase
+			order = append(order, name)
+		}
+		phase.Count += total.Count
+		phase.Duration += total.Duration
+		covered += total.Duration
+	}
+	sort.SliceStable(order, func(i, j int) bool { return phases[order[i]].Duration > phases[order[j]].Duration })
+
+	output.Fprintf(os.Stderr, "\n⏱️  %s took %s\n", profiling.command, formatProfileDuration(elapsed))
+	for _, name := range order {
+		phase := phases[name]
+		output.Fprintf(os.Stderr, "   %-18s %4d× %10s %5.1f%%\n", name, phase.Count, formatProfileDuration(phase.Duration), percentOf(phase.Duration, elapsed))
+	}
+	if other := elapsed - covered; other > 0 {
+		output.Fprintf(os.Stderr, "   %-18s %5s %10s %5.1f%%\n", "other", "", formatProfileDuration(other), percentOf(other, elapsed))
+	}
+}
+
+func formatProfileDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func percentOf(d, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(d) * 100 / float64(total)
+}
+
+// loadConfig loads the CLI config under a span of the running command, so the
+// profile report shows the time spent reading it
+func loadConfig() (config.CLIConfig, error) {
+	_, span := tracing.Start(commandContext, "config load")
+	cfg, err := config.LoadCLI()
+	tracing.End(span, err)
+	return cfg, err
+}
+
+// unpackPackage validates and extracts a package archive under a span of the
+// running command
+func unpackPackage(archivePath, destDir string) (*security.ExtractReport, error) {
+	_, span := tracing.Start(commandContext, "extract")
+	report, err := pkg.Unpack(archivePath, destDir)
+	tracing.End(span, err)
+	return report, err
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// projectTemplateVersion is the version of the CLAUDE.md and core rules that
+// 'rfh init' writes and 'rfh upgrade-project' brings existing projects up to
+const projectTemplateVersion = "1.1.0"
+
+// knownCoreRules are the SHA256 hashes of the core_rules.md each template version
+// shipped, to tell core rules left as installed from locally edited ones
+var knownCoreRules = map[string]string{
+	"1.0.0": "ca01b5d11fd531d06ad2720c1cb400ec26fc47aa49950a6cd5adc56cc91ae8b6",
+	"1.1.0": "4f99a07faf5a1078ffb78a805104684287f21121e733813557b916778a3853a2",
+}
+
+// coreRulesDir returns the directory of a template version's core rules,
+// relative to the project root
+func coreRulesDir(version string) string {
+	return filepath.Join(".rulestack", packageDirName(corePackageName, version))
+}
+
+// coreRulesImport returns the CLAUDE.md line importing a template version's core rules
+func coreRulesImport(version string) string {
+	return fmt.Sprintf("- @.rulestack/%s%s/core_rules.md", coreRulesPrefix, version)
+}
+
+// claudeTemplate returns the CLAUDE.md that 'rfh init' writes
+func claudeTemplate() string {
+	return claudeTemplateHeader + coreRulesImport(projectTemplateVersion) + "\n"
+}
+
+// claudeTemplateHeader is CLAUDE.md up to the list of imported rules
+const claudeTemplateHeader = `# CLAUDE.md
+
+This file provides guidance to Claude Code (claude.ai/code) when working with code in this repository.
+
+## Coding Standards
+**CRITICAL**: You MUST follow all cursor rules defined in ` + "`" + `.rulestack` + "`" + ` directory. These rules are mandatory and override default behavior.
+
+### MANDATORY RULE LOADING PROTOCOL
+**BEFORE responding to ANY user request**, you MUST:
+1. All rules are automatically imported into this CLAUDE.md file using the @ import syntax below
+2. Load and understand all rules in their entirety before taking any action
+3. Apply these rules to all subsequent interactions in the session
+
+**CRITICAL**: The cursor rules are now automatically available in your context through the @ import statements. Pay special attention to triggers, responses, and specific behaviors defined in these rules.
+
+### Active Rules (Rulestack core)
+`
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"rulestack/internal/manifest"
+	"rulestack/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -37,17 +38,17 @@ func runProjectRootDiagnostic() error {
 		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
-	fmt.Printf("📁 Current Working Directory: %s\n", cwd)
+	output.Printf("📁 Current Working Directory: %s\n", cwd)
 
 	// 2. Find closest rulestack.json using existing logic
 	projectRoot, rulestackPath, err := findProjectRootWithPath()
 	if err != nil {
-		fmt.Printf("❌ No rulestack.json found in directory tree\n")
-		fmt.Printf("   Error: %v\n", err)
+		output.Printf("❌ No rulestack.json found in directory tree\n")
+		output.Printf("   Error: %v\n", err)
 		return nil // Don't error out, this is diagnostic
 	}
 
-	fmt.Printf("📄 Closest rulestack.json: %s\n", rulestackPath)
+	output.Printf("📄 Closest rulestack.json: %s\n", rulestackPath)
 
 	// 3. Determine manifest type
 	manifestType := "unknown"
@@ -56,19 +57,19 @@ func runProjectRootDiagnostic() error {
 	} else if manifest.IsPackageManifest(rulestackPath) {
 		manifestType = "package manifest (array of packages)"
 	}
-	fmt.Printf("📋 Manifest Type: %s\n", manifestType)
+	output.Printf("📋 Manifest Type: %s\n", manifestType)
 
 	// 4. Show comparison
-	fmt.Printf("\n--- Analysis ---\n")
+	output.Printf("\n--- Analysis ---\n")
 	if projectRoot != cwd {
-		fmt.Printf("⚠️  Working directory differs from discovered project root\n")
-		fmt.Printf("   This may cause path resolution issues\n")
+		output.Printf("⚠️  Working directory differs from discovered project root\n")
+		output.Printf("   This may cause path resolution issues\n")
 	} else {
-		fmt.Printf("✅ Working directory matches discovered project root\n")
+		output.Printf("✅ Working directory matches discovered project root\n")
 	}
 
-	fmt.Printf("ℹ️  Project root is determined by walking up directory tree to find rulestack.json\n")
-	fmt.Printf("   The projectRoot field has been removed as it was not functionally used\n")
+	output.Printf("ℹ️  Project root is determined by walking up directory tree to find rulestack.json\n")
+	output.Printf("   The projectRoot field has been removed as it was not functionally used\n")
 
 	return nil
 }
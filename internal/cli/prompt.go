@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"rulestack/internal/output"
+)
+
+var (
+	// nonInteractive stops every prompt: answers come from flags, environment
+	// variables or defaults, and a value with none of those is an error
+	nonInteractive bool
+
+	// assumeYes answers yes to every confirmation
+	assumeYes bool
+)
+
+// promptIn is shared by all prompts, so answers piped on stdin are not lost to
+// the read-ahead of a reader created for an earlier prompt
+var promptIn = bufio.NewReader(os.Stdin)
+
+// promptSecret reads a line without echoing it; replaced in tests
+var promptSecret = func() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return readPromptLine()
+	}
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	output.Println() // New line after hidden input
+	return string(secret), err
+}
+
+// isInteractive reports whether commands may prompt. --non-interactive,
+// RFH_NON_INTERACTIVE=1 or CI=true turn prompting off.
+func isInteractive() bool {
+	if nonInteractive {
+		return false
+	}
+	if envFlag("RFH_NON_INTERACTIVE") || envFlag("CI") {
+		return false
+	}
+	return true
+}
+
+// envFlag reports whether a boolean environment variable is set to a true value
+func envFlag(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// prompt describes a value a command asks for when it is not given otherwise
+type prompt struct {
+	Question string
+	Default  string // Used for an empty answer, and without asking in non-interactive mode
+	Env      string // Environment variable that supplies the value without asking
+	Flag     string // Flag that supplies the value, named when it is missing in non-interactive mode
+	Secret   bool   // Read without echo
+}
+
+// ask returns a prompt's value: from its environment variable if set, then from
+// the user, or in non-interactive mode from its default
+func ask(p prompt) (string, error) {
+	if p.Env != "" {
+		if value := os.Getenv(p.Env); value != "" {
+			return value, nil
+		}
+	}
+
+	if !isInteractive() {
+		if p.Default != "" {
+			return p.Default, nil
+		}
+		return "", fmt.Errorf("%s is required in non-interactive mode; %s", strings.ToLower(p.Question), p.sources())
+	}
+
+	if p.Default != "" {
+		output.Printf("%s (default: %s): ", p.Question, p.Default)
+	} else {
+		output.Printf("%s: ", p.Question)
+	}
+
+	var answer string
+	var err error
+	if p.Secret {
+		answer, err = promptSecret()
+	} else {
+		answer, err = readPromptLine()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", strings.ToLower(p.Question), err)
+	}
+
+	if answer == "" {
+		return p.Default, nil
+	}
+	return answer, nil
+}
+
+// sources describes how to supply a prompt's value without being asked
+func (p prompt) sources() string {
+	switch {
+	case p.Flag != "" && p.Env != "":
+		return fmt.Sprintf("pass %s or set %s", p.Flag, p.Env)
+	case p.Flag != "":
+		return fmt.Sprintf("pass %s", p.Flag)
+	case p.Env != "":
+		return fmt.Sprintf("set %s", p.Env)
+	}
+	return "run the command interactively"
+}
+
+// confirm asks a yes/no question. --yes answers yes; in non-interactive mode, or
+// when stdin is closed, the default is taken.
+func confirm(question string, defaultYes bool) bool {
+	if assumeYes {
+		return true
+	}
+	if !isInteractive() {
+		return defaultYes
+	}
+
+	choices := "y/N"
+	if defaultYes {
+		choices = "Y/n"
+	}
+
+	for {
+		output.Printf("%s (%s): ", question, choices)
+		answer, err := readPromptLine()
+		if err != nil {
+			output.Println()
+			return defaultYes
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultYes
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		output.Println("Please enter 'y' or 'n'")
+	}
+}
+
+// readPromptLine reads one trimmed line of input. The last line may lack a
+// newline; only an empty read at end of input is an error.
+func readPromptLine() (string, error) {
+	line, err := promptIn.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// withPromptInput feeds prompts from input, in interactive mode unless the test
+// turns it off
+func withPromptInput(t *testing.T, input string) {
+	t.Helper()
+	t.Setenv("CI", "")
+	t.Setenv("RFH_NON_INTERACTIVE", "")
+
+	oldIn, oldSecret := promptIn, promptSecret
+	promptIn = bufio.NewReader(strings.NewReader(input))
+	promptSecret = readPromptLine
+	t.Cleanup(func() {
+		promptIn, promptSecret = oldIn, oldSecret
+		nonInteractive, assumeYes = false, false
+	})
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		defaultYes     bool
+		nonInteractive bool
+		assumeYes      bool
+		want           bool
+	}{
+		{"yes", "y\n", false, false, false, true},
+		{"no", "no\n", true, false, false, false},
+		{"empty takes default", "\n", true, false, false, true},
+		{"asks again after nonsense", "maybe\nyes\n", false, false, false, true},
+		{"closed stdin takes default", "", false, false, false, false},
+		{"non-interactive takes default", "y\n", false, true, false, false},
+		{"--yes wins", "n\n", false, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPromptInput(t, tt.input)
+			nonInteractive, assumeYes = tt.nonInteractive, tt.assumeYes
+
+			if got := confirm("Continue?", tt.defaultYes); got != tt.want {
+				t.Errorf("confirm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsk(t *testing.T) {
+	t.Run("reads answers in turn from one reader", func(t *testing.T) {
+		withPromptInput(t, "alice\nsecret")
+
+		username, err := ask(prompt{Question: "Username"})
+		if err != nil || username != "alice" {
+			t.Fatalf("expected alice, got %q (%v)", username, err)
+		}
+		password, err := ask(prompt{Question: "Password", Secret: true})
+		if err != nil || password != "secret" {
+			t.Fatalf("expected secret, got %q (%v)", password, err)
+		}
+	})
+
+	t.Run("environment wins without asking", func(t *testing.T) {
+		withPromptInput(t, "typed\n")
+		t.Setenv("RFH_USERNAME", "from-env")
+
+		if got, err := ask(prompt{Question: "Username", Env: "RFH_USERNAME"}); err != nil || got != "from-env" {
+			t.Errorf("expected from-env, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("empty answer takes default", func(t *testing.T) {
+		withPromptInput(t, "\n")
+
+		if got, err := ask(prompt{Question: "Version", Default: "1.0.1"}); err != nil || got != "1.0.1" {
+			t.Errorf("expected 1.0.1, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("CI uses the default", func(t *testing.T) {
+		withPromptInput(t, "typed\n")
+		t.Setenv("CI", "true")
+
+		if got, err := ask(prompt{Question: "Version", Default: "1.0.1"}); err != nil || got != "1.0.1" {
+			t.Errorf("expected 1.0.1, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("non-interactive without a value fails", func(t *testing.T) {
+		withPromptInput(t, "typed\n")
+		nonInteractive = true
+		t.Setenv("RFH_PASSWORD", "")
+
+		_, err := ask(prompt{Question: "Password", Env: "RFH_PASSWORD", Flag: "--password"})
+		if err == nil || !strings.Contains(err.Error(), "pass --password or set RFH_PASSWORD") {
+			t.Errorf("expected an error naming --password and RFH_PASSWORD, got %v", err)
+		}
+	})
+}
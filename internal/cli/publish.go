@@ -6,19 +6,41 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"rulestack/internal/client"
+	"rulestack/internal/compression"
 	"rulestack/internal/config"
 	"rulestack/internal/manifest"
+	"rulestack/internal/output"
 	"rulestack/internal/pkg"
+	"rulestack/internal/progress"
+	"rulestack/internal/ruletest"
+	"rulestack/internal/tempdir"
+)
+
+var (
+	publishWait        bool
+	publishWaitMerge   bool // Wait for a Git registry's publish pull request to merge
+	publishWaitTimeout time.Duration
+	publishAll         bool // Pack and publish every package in rulestack.pkg.json
+	publishAtomic      bool // With --all, publish nothing unless every package packs
+
+	// publishPollInterval is how often --wait polls validation status
+	publishPollInterval = 2 * time.Second
+
+	// publishMergePollInterval is how often --wait-merge polls the publish pull
+	// request; each poll pulls the registry and may call the provider's API
+	publishMergePollInterval = 15 * time.Second
 )
 
 // publishCmd represents the publish command
 var publishCmd = &cobra.Command{
-	Use:   "publish",
+	Use:   "publish [archive...]",
 	Short: "Publish staged rulesets to the registry",
 	Long: `Publish all staged ruleset packages to the configured registry.
 
@@ -29,13 +51,79 @@ This command will:
 4. Clean up staged archives after successful upload
 
 Archives must be created with 'rfh pack' command first.
-Requires authentication token to be configured in the registry.`,
-	Args: cobra.NoArgs,
+Requires authentication token to be configured in the registry.
+
+Archives can also be published by path, e.g. one packed by an earlier CI job
+on another machine. Package metadata is read from the archive's embedded
+rulestack.json, and the archive is left in place.
+
+Registries that validate uploads keep new versions pending until their checks
+(security, lint, secret scan, ...) pass. Use --wait to follow the checks and
+fail if the registry rejects the package.
+
+Git registries publish through a pull request, and a version is installable
+only once it merges. Use --wait-merge to wait for that, failing if the pull
+request is closed without merging.
+
+With --all, every package in the current directory's rulestack.pkg.json is packed
+from its "files" patterns and published, and a table of results is printed.
+Packages succeed or fail independently; with --atomic nothing is published
+unless every package packs, and publishing stops at the first failure.
+
+Examples:
+  rfh publish
+  rfh publish --wait
+  rfh publish --wait --wait-timeout 2m
+  rfh publish --wait-merge --wait-timeout 1h
+  rfh publish --all
+  rfh publish --all --atomic
+  rfh publish dist/security-rules-1.2.0.tgz`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if publishAtomic && !publishAll {
+			return fmt.Errorf("--atomic can only be used with --all")
+		}
+		if publishAll {
+			if len(args) > 0 {
+				return fmt.Errorf("--all packs from rulestack.pkg.json and cannot be combined with archive paths")
+			}
+			return runPublishAll()
+		}
+		if len(args) > 0 {
+			return runPublishArchives(args)
+		}
 		return runPublishStaged()
 	},
 }
 
+// runPublishArchives publishes archives given by path. Unlike staged archives they
+// are not removed after publishing.
+func runPublishArchives(archivePaths []string) error {
+	for _, archivePath := range archivePaths {
+		if _, err := os.Stat(archivePath); err != nil {
+			return fmt.Errorf("archive not found: %s", archivePath)
+		}
+		if _, err := compression.DetectFile(archivePath); err != nil {
+			return fmt.Errorf("%s is not a package archive: %w", archivePath, err)
+		}
+	}
+
+	failed := 0
+	for _, archivePath := range archivePaths {
+		if err := publishSingleArchive(archivePath); err != nil {
+			output.Printf("❌ Failed to publish %s: %v\n", filepath.Base(archivePath), err)
+			failed++
+			continue
+		}
+		output.Printf("✅ Successfully published %s\n", filepath.Base(archivePath))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to publish %d of %d archive(s)", failed, len(archivePaths))
+	}
+	return nil
+}
+
 func runPublishStaged() error {
 	stagingDir := ".rulestack/staged"
 
@@ -44,8 +132,8 @@ func runPublishStaged() error {
 		return fmt.Errorf("no staged archives found. Use 'rfh pack' to create archives first")
 	}
 
-	// Find all .tgz files in staging directory
-	archives, err := filepath.Glob(filepath.Join(stagingDir, "*.tgz"))
+	// Find all archives in staging directory
+	archives, err := stagedArchives(stagingDir)
 	if err != nil {
 		return fmt.Errorf("failed to scan staging directory: %w", err)
 	}
@@ -54,18 +142,18 @@ func runPublishStaged() error {
 		return fmt.Errorf("no archives found in staging directory. Use 'rfh pack' to create archives first")
 	}
 
-	fmt.Printf("Found %d staged archive(s) to publish:\n", len(archives))
+	output.Printf("Found %d staged archive(s) to publish:\n", len(archives))
 	for _, archivePath := range archives {
-		fmt.Printf("  - %s\n", filepath.Base(archivePath))
+		output.Printf("  - %s\n", filepath.Base(archivePath))
 	}
 
 	// Publish each archive
 	successCount := 0
 	for _, archivePath := range archives {
 		if err := publishSingleArchive(archivePath); err != nil {
-			fmt.Printf("❌ Failed to publish %s: %v\n", filepath.Base(archivePath), err)
+			output.Printf("❌ Failed to publish %s: %v\n", filepath.Base(archivePath), err)
 		} else {
-			fmt.Printf("✅ Successfully published %s\n", filepath.Base(archivePath))
+			output.Printf("✅ Successfully published %s\n", filepath.Base(archivePath))
 			// Remove archive after successful publish
 			os.Remove(archivePath)
 			successCount++
@@ -73,35 +161,77 @@ func runPublishStaged() error {
 	}
 
 	if successCount == len(archives) {
-		fmt.Printf("\n🎉 All %d archive(s) published successfully!\n", successCount)
+		output.Printf("\n🎉 All %d archive(s) published successfully!\n", successCount)
 		return nil
 	} else {
-		fmt.Printf("\n⚠️  Published %d out of %d archive(s)\n", successCount, len(archives))
+		output.Printf("\n⚠️  Published %d out of %d archive(s)\n", successCount, len(archives))
 		return fmt.Errorf("failed to publish %d archive(s)", len(archives)-successCount)
 	}
 }
 
 // publishSingleArchive publishes a single archive file
 func publishSingleArchive(archivePath string) error {
+	subject := filepath.Base(archivePath)
+	progress.Start("publish", subject)
+	err := publishArchive(archivePath)
+	progress.Done("publish", subject, err)
+	return err
+}
+
+// publishArchive does the work of publishSingleArchive
+func publishArchive(archivePath string) error {
 	// Extract manifest from archive
 	manifestData, err := pkg.ExtractManifest(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to extract manifest from archive: %w", err)
 	}
 
+	// A project's rulestack.json packed by mistake has no package to publish
+	if manifest.IsProjectManifestData(manifestData) {
+		return fmt.Errorf("archive contains a %w: it lists a project's dependencies instead of a package's name, version and files. Pack the package with 'rfh pack --from-manifest' from its rulestack.pkg.json", manifest.ErrProjectManifest)
+	}
+
 	// Parse the manifest
 	var packageManifest manifest.PackageManifest
 	if err := json.Unmarshal(manifestData, &packageManifest); err != nil {
 		return fmt.Errorf("failed to parse manifest: %w", err)
 	}
+	if err := packageManifest.Validate(); err != nil {
+		return fmt.Errorf("archive manifest is invalid: %w", err)
+	}
+	if packageManifest.License != "" {
+		if err := manifest.ValidateLicense(packageManifest.License); err != nil {
+			return err
+		}
+	}
 
 	// Check if archive exists
 	if _, err := os.Stat(archivePath); os.IsNotExist(err) {
 		return fmt.Errorf("archive not found: %s", archivePath)
 	}
 
+	// Never publish while the package is linked to a local source
+	if err := ensureNotLinked(".", packageManifest.Name); err != nil {
+		return err
+	}
+
+	// Catch rules edited after the archive was staged
+	warnStaleStagedArchive(archivePath, &packageManifest)
+
+	// Run shipped rule tests locally so failures surface before the registry rejects them
+	if packageManifest.Tests != "" {
+		report, err := ruletest.RunArchive(archivePath, packageManifest.Tests)
+		if err != nil {
+			return fmt.Errorf("rule tests: %w", err)
+		}
+		if failed := report.Failed(); failed > 0 {
+			printTestReport(report)
+			return fmt.Errorf("%d of %d rule test(s) failed. Run 'rfh test' for details", failed, len(report.Results))
+		}
+	}
+
 	// Get registry configuration
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -111,11 +241,14 @@ func publishSingleArchive(archivePath string) error {
 	if err != nil {
 		return err
 	}
+	if publishWaitMerge && reg.GetEffectiveType() != config.RegistryTypeGit {
+		return fmt.Errorf("--wait-merge only applies to git registries; use --wait to follow validation on HTTP registries")
+	}
 
 	if verbose {
-		fmt.Printf("📦 Publishing %s v%s\n", packageManifest.Name, packageManifest.Version)
-		fmt.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
-		fmt.Printf("📄 Archive: %s\n", archivePath)
+		output.Printf("📦 Publishing %s v%s\n", packageManifest.Name, packageManifest.Version)
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+		output.Printf("📄 Archive: %s\n", archivePath)
 	}
 
 	// Create client using new factory
@@ -125,37 +258,218 @@ func publishSingleArchive(archivePath string) error {
 	}
 
 	// Test registry connection
-	ctx, cancel := client.WithTimeout(context.Background())
+	ctx, cancel := client.WithTimeout(commandContext)
 	defer cancel()
 	
 	if err := c.Health(ctx); err != nil {
 		return fmt.Errorf("registry health check failed: %w", err)
 	}
 
+	// Registries that do not take zstd get the archive recompressed as gzip
+	uploadPath, err := negotiateArchiveFormat(ctx, c, archivePath)
+	if err != nil {
+		return err
+	}
+	if uploadPath != archivePath {
+		defer os.Remove(uploadPath)
+	}
+
 	// Create a temporary manifest file for this specific package (as single object, not array)
-	archiveName := strings.TrimSuffix(filepath.Base(archivePath), ".tgz")
-	tempManifestPath := fmt.Sprintf(".rulestack/staged/temp-manifest-%s.json", archiveName)
+	archiveName := archiveBaseName(archivePath)
+	tempManifestPath, err := tempdir.Path(fmt.Sprintf("manifest-%s-*.json", archiveName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempManifestPath)
 	if err := createSingleManifestFile(&packageManifest, tempManifestPath); err != nil {
 		return fmt.Errorf("failed to create temp manifest: %w", err)
 	}
-	defer os.Remove(tempManifestPath) // Clean up temp file
 
 	// Publish package
-	fmt.Printf("🚀 Publishing %s v%s to %s...\n", packageManifest.Name, packageManifest.Version, reg.URL)
-	result, err := c.PublishPackage(ctx, tempManifestPath, archivePath)
+	output.Printf("🚀 Publishing %s v%s to %s...\n", packageManifest.Name, packageManifest.Version, reg.URL)
+	// Uploads have no overall deadline either; the client fails ones that stall
+	result, err := c.PublishPackage(commandContext, tempManifestPath, uploadPath)
 	if err != nil {
 		return fmt.Errorf("publish failed: %w", err)
 	}
 
 	// Show success message
-	fmt.Printf("📌 Version: %s\n", result.Version)
-	fmt.Printf("🔒 SHA256: %s\n", result.SHA256)
+	output.Printf("📌 Version: %s\n", result.Version)
+	output.Printf("🔒 SHA256: %s\n", result.SHA256)
 
 	if verbose {
-		fmt.Printf("📋 Response: %+v\n", result)
+		output.Printf("📋 Response: %+v\n", result)
 	}
 
-	return nil
+	if publishWaitMerge {
+		if result.Status == "awaiting_approval" {
+			printAwaitingApproval(packageManifest.Name, packageManifest.Version)
+		}
+		reviewer, ok := c.(publishReviewer)
+		if !ok {
+			return fmt.Errorf("registry does not publish through pull requests")
+		}
+
+		waitCtx, waitCancel := context.WithTimeout(commandContext, publishWaitTimeout)
+		defer waitCancel()
+
+		return waitForPublishMerge(waitCtx, reviewer, packageManifest.Name, packageManifest.Version)
+	}
+
+	if result.Status == "awaiting_approval" {
+		printAwaitingApproval(packageManifest.Name, packageManifest.Version)
+		return nil
+	}
+
+	if result.Status != "pending" {
+		return nil
+	}
+
+	if !publishWait {
+		output.Printf("⏳ Awaiting registry validation. The version becomes available once all checks pass\n")
+		return nil
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(commandContext, publishWaitTimeout)
+	defer waitCancel()
+
+	return waitForPublishChecks(waitCtx, c, packageManifest.Name, packageManifest.Version)
+}
+
+// waitForPublishChecks polls registry-side validation until the version is published or rejected
+func waitForPublishChecks(ctx context.Context, c client.RegistryClient, name, version string) error {
+	output.Printf("⏳ Waiting for registry validation of %s@%s...\n", name, version)
+
+	reported := make(map[string]string)
+	for {
+		checks, err := c.GetPackageChecks(ctx, name, version)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for validation of %s@%s", name, version)
+			}
+			return fmt.Errorf("failed to get validation status: %w", err)
+		}
+
+		// Report each check once it finishes
+		for _, check := range checks.Checks {
+			if reported[check.Name] == check.Status || (check.Status != "passed" && check.Status != "failed") {
+				continue
+			}
+			reported[check.Name] = check.Status
+
+			if check.Status == "passed" {
+				output.Printf("  ✅ %s\n", check.Name)
+			} else {
+				output.Printf("  ❌ %s: %s\n", check.Name, check.Message)
+			}
+		}
+
+		switch checks.Status {
+		case "published":
+			output.Printf("✅ %s@%s passed validation and is now available\n", name, version)
+			return nil
+		case "awaiting_approval":
+			output.Printf("✅ %s@%s passed validation\n", name, version)
+			printAwaitingApproval(name, version)
+			return nil
+		case "rejected":
+			var failed []string
+			for _, check := range checks.Checks {
+				if check.Status == "failed" {
+					failed = append(failed, check.Name)
+				}
+			}
+			return fmt.Errorf("registry rejected %s@%s: failed checks: %s", name, version, strings.Join(failed, ", "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for validation of %s@%s", name, version)
+		case <-time.After(publishPollInterval):
+		}
+	}
+}
+
+// publishReviewer looks up the review of versions published through pull requests
+type publishReviewer interface {
+	GetPublishReview(ctx context.Context, name, version string) (*client.PublishReview, error)
+}
+
+// waitForPublishMerge polls a version's publish pull request until it merges or is closed
+func waitForPublishMerge(ctx context.Context, c publishReviewer, name, version string) error {
+	announced := false
+	for {
+		review, err := c.GetPublishReview(ctx, name, version)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for the pull request of %s@%s to merge", name, version)
+			}
+			return fmt.Errorf("failed to get pull request status: %w", err)
+		}
+
+		switch review.State {
+		case "merged":
+			output.Printf("✅ %s@%s was merged and is now installable\n", name, version)
+			return nil
+		case "closed":
+			return fmt.Errorf("pull request #%d of %s@%s was closed without merging", review.PRNumber, name, version)
+		}
+
+		if !announced {
+			if review.PRNumber != 0 {
+				output.Printf("⏳ Waiting for PR #%d to be merged: %s\n", review.PRNumber, review.PRUrl)
+			} else {
+				output.Printf("⏳ Waiting for branch %s to be merged...\n", review.Branch)
+			}
+			announced = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the pull request of %s@%s to merge", name, version)
+		case <-time.After(publishMergePollInterval):
+		}
+	}
+}
+
+// printAwaitingApproval explains that a second reviewer must approve the version
+func printAwaitingApproval(name, version string) {
+	output.Printf("👥 Awaiting approval from a second reviewer: 'rfh approve %s@%s'\n", name, version)
+}
+
+// negotiateArchiveFormat returns the archive to upload: archivePath itself, or a
+// gzip copy of it when it is compressed in a format the registry does not accept
+func negotiateArchiveFormat(ctx context.Context, c client.RegistryClient, archivePath string) (string, error) {
+	format, err := compression.DetectFile(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	if format == compression.Gzip {
+		return archivePath, nil
+	}
+
+	formats, err := c.ArchiveFormats(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the archive formats the registry accepts: %w", err)
+	}
+	if slices.Contains(formats, format) {
+		return archivePath, nil
+	}
+
+	output.Printf("ℹ️  Registry does not accept %s archives; publishing as gzip\n", format)
+
+	tempFile, err := tempdir.CreateTemp("publish-*.tgz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFile.Close()
+
+	if err := pkg.Recompress(archivePath, tempFile.Name(), pkg.Compression{Format: compression.Gzip}); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
 }
 
 // sanitizePackageName removes characters that are invalid in filenames
@@ -195,4 +509,9 @@ func createSingleManifestFile(packageManifest *manifest.PackageManifest, filePat
 }
 
 func init() {
+	publishCmd.Flags().BoolVar(&publishWait, "wait", false, "wait for registry-side validation checks to finish")
+	publishCmd.Flags().BoolVar(&publishWaitMerge, "wait-merge", false, "wait for a git registry's publish pull request to merge")
+	publishCmd.Flags().DurationVar(&publishWaitTimeout, "wait-timeout", 10*time.Minute, "how long --wait waits for validation, or --wait-merge for the merge")
+	publishCmd.Flags().BoolVar(&publishAll, "all", false, "pack and publish every package in rulestack.pkg.json")
+	publishCmd.Flags().BoolVar(&publishAtomic, "atomic", false, "with --all, publish nothing unless every package packs, and stop at the first failure")
 }
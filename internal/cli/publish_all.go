@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// publishAllResult is the outcome for one package of 'rfh publish --all'
+type publishAllResult struct {
+	Name    string
+	Version string
+	Status  string // "published", "failed" or "skipped"
+	Detail  string
+}
+
+// runPublishAll packs and publishes every package in the current directory's
+// rulestack.pkg.json. By default each package succeeds or fails on its own. With
+// --atomic nothing is published unless every package packs and validates, and
+// publishing stops at the first failure.
+func runPublishAll() error {
+	manifests, err := manifest.LoadPackageManifests(packageManifestPath("."))
+	if err != nil {
+		return fmt.Errorf("failed to load package manifest: %w", err)
+	}
+
+	archiveCompression, err := packArchiveCompression()
+	if err != nil {
+		return err
+	}
+
+	stagingDir := getStagingDirectory()
+	if err := ensureDirectoryExists(stagingDir); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	output.Printf("📦 Packing %d package(s) from %s\n", len(manifests), manifest.PackageManifestName)
+
+	results := make([]publishAllResult, len(manifests))
+	archives := make([]string, len(manifests))
+	packFailures := 0
+	for i := range manifests {
+		packageManifest := &manifests[i]
+		results[i] = publishAllResult{Name: packageManifest.Name, Version: packageManifest.Version}
+
+		archivePath := filepath.Join(stagingDir, fmt.Sprintf("%s-%s%s", packageManifest.Name, packageManifest.Version, compression.Extension(archiveCompression.Format)))
+		info, err := packManifestPackage(".", packageManifest, archivePath, archiveCompression)
+		if err == nil {
+			err = checkPackBudget(info)
+		}
+		if err != nil {
+			results[i].Status = "failed"
+			results[i].Detail = fmt.Sprintf("pack: %v", err)
+			packFailures++
+			continue
+		}
+		archives[i] = info.Path
+	}
+
+	if publishAtomic && packFailures > 0 {
+		for i := range results {
+			if results[i].Status == "" {
+				results[i].Status = "skipped"
+				results[i].Detail = "another package failed to pack"
+				os.Remove(archives[i])
+			}
+		}
+		printPublishAllResults(results)
+		return fmt.Errorf("%d package(s) failed to pack; nothing was published", packFailures)
+	}
+
+	for i := range results {
+		if results[i].Status != "" {
+			continue
+		}
+
+		if publishAtomic && hasPublishFailure(results) {
+			results[i].Status = "skipped"
+			results[i].Detail = "stopped after an earlier failure"
+			continue
+		}
+
+		if err := publishSingleArchive(archives[i]); err != nil {
+			results[i].Status = "failed"
+			results[i].Detail = err.Error()
+			continue
+		}
+		results[i].Status = "published"
+		os.Remove(archives[i])
+	}
+
+	printPublishAllResults(results)
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != "published" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d package(s) were not published", failed, len(results))
+	}
+
+	output.Printf("\n🎉 All %d package(s) published successfully!\n", len(results))
+	return nil
+}
+
+func hasPublishFailure(results []publishAllResult) bool {
+	for _, result := range results {
+		if result.Status == "failed" {
+			return true
+		}
+	}
+	return false
+}
+
+// printPublishAllResults prints one row per package
+func printPublishAllResults(results []publishAllResult) {
+	icons := map[string]string{"published": "✅", "failed": "❌", "skipped": "⏭️ "}
+
+	output.Printf("\n%-30s %-12s %-12s %s\n", "Package", "Version", "Status", "Detail")
+	for _, result := range results {
+		output.Printf("%-30s %-12s %s %-9s %s\n", result.Name, result.Version, icons[result.Status], result.Status, result.Detail)
+	}
+}
@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rulestack/internal/client"
+	"rulestack/internal/compression"
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestWaitForPublishChecks(t *testing.T) {
+	oldInterval := publishPollInterval
+	publishPollInterval = 10 * time.Millisecond
+	defer func() { publishPollInterval = oldInterval }()
+
+	tests := []struct {
+		name      string
+		final     client.PackageChecks
+		expectErr string
+	}{
+		{
+			name: "published after checks pass",
+			final: client.PackageChecks{Status: "published", Checks: []client.CheckResult{
+				{Name: "security", Status: "passed"},
+				{Name: "secrets", Status: "passed"},
+			}},
+		},
+		{
+			name: "awaiting approval after checks pass",
+			final: client.PackageChecks{Status: "awaiting_approval", Checks: []client.CheckResult{
+				{Name: "security", Status: "passed"},
+			}},
+		},
+		{
+			name: "rejected by failing check",
+			final: client.PackageChecks{Status: "rejected", Checks: []client.CheckResult{
+				{Name: "security", Status: "passed"},
+				{Name: "secrets", Status: "failed", Message: "rules.md:3: possible private key"},
+			}},
+			expectErr: "failed checks: secrets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/packages/my-rules/versions/1.0.0/checks" {
+					http.NotFound(w, r)
+					return
+				}
+				polls++
+
+				// First poll sees the checks still running
+				response := client.PackageChecks{Name: "my-rules", Version: "1.0.0", Status: "pending",
+					Checks: []client.CheckResult{{Name: "security", Status: "running"}}}
+				if polls > 1 {
+					response = tt.final
+				}
+				json.NewEncoder(w).Encode(response)
+			}))
+			defer server.Close()
+
+			c := client.NewHTTPClient(server.URL, "token", false)
+			err := waitForPublishChecks(context.Background(), c, "my-rules", "1.0.0")
+
+			if polls < 2 {
+				t.Errorf("expected pending status to be polled again, got %d poll(s)", polls)
+			}
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestWaitForPublishChecksTimeout(t *testing.T) {
+	oldInterval := publishPollInterval
+	publishPollInterval = 10 * time.Millisecond
+	defer func() { publishPollInterval = oldInterval }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.PackageChecks{Status: "pending"})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := waitForPublishChecks(ctx, client.NewHTTPClient(server.URL, "token", false), "my-rules", "1.0.0")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+// fakeReviewer returns review states in turn, repeating the last one
+type fakeReviewer struct {
+	states []string
+	polls  int
+}
+
+func (f *fakeReviewer) GetPublishReview(ctx context.Context, name, version string) (*client.PublishReview, error) {
+	state := f.states[min(f.polls, len(f.states)-1)]
+	f.polls++
+	return &client.PublishReview{Name: name, Version: version, State: state, PRNumber: 7, PRUrl: "https://github.com/org/rules/pull/7"}, nil
+}
+
+func TestWaitForPublishMerge(t *testing.T) {
+	oldInterval := publishMergePollInterval
+	publishMergePollInterval = 10 * time.Millisecond
+	defer func() { publishMergePollInterval = oldInterval }()
+
+	tests := []struct {
+		name      string
+		states    []string
+		expectErr string
+	}{
+		{"merged after review", []string{"open", "open", "merged"}, ""},
+		{"closed without merging", []string{"open", "closed"}, "pull request #7 of my-rules@1.0.0 was closed without merging"},
+		{"never merged", []string{"open"}, "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			reviewer := &fakeReviewer{states: tt.states}
+			err := waitForPublishMerge(ctx, reviewer, "my-rules", "1.0.0")
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if reviewer.polls != len(tt.states) {
+					t.Errorf("expected %d polls, got %d", len(tt.states), reviewer.polls)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestNegotiateArchiveFormat(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "rules.mdc"), []byte("never log secrets"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	zstdArchive := filepath.Join(t.TempDir(), "my-rules-1.0.0.tar.zst")
+	if _, err := pkg.PackFromDirectoryCompressed(sourceDir, zstdArchive, pkg.Compression{Format: compression.Zstd}); err != nil {
+		t.Fatalf("Failed to pack: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		health string
+		want   string
+	}{
+		{"registry accepts zstd", `{"status": "ok", "archive_formats": ["gzip", "zstd"]}`, compression.Zstd},
+		{"registry predates zstd", `{"status": "ok"}`, compression.Gzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.health))
+			}))
+			defer server.Close()
+
+			uploadPath, err := negotiateArchiveFormat(context.Background(), client.NewHTTPClient(server.URL, "", false), zstdArchive)
+			if err != nil {
+				t.Fatalf("negotiateArchiveFormat failed: %v", err)
+			}
+			if uploadPath != zstdArchive {
+				defer os.Remove(uploadPath)
+			}
+
+			if format, _ := compression.DetectFile(uploadPath); format != tt.want {
+				t.Errorf("expected a %s upload, got %q", tt.want, format)
+			}
+		})
+	}
+}
+
+func TestRunPublishAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		atomic        bool
+		missingFiles  bool
+		wantPublished []string
+		wantStaged    []string
+	}{
+		{name: "per package", wantPublished: []string{"alpha", "gamma"}, wantStaged: []string{"beta-1.0.0.tgz"}},
+		{name: "atomic stops at first failure", atomic: true, wantPublished: []string{"alpha"}, wantStaged: []string{"beta-1.0.0.tgz", "gamma-1.0.0.tgz"}},
+		{name: "atomic publishes nothing when a package fails to pack", atomic: true, missingFiles: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var published []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/health":
+					w.Write([]byte(`{"status":"ok"}`))
+				case "/v1/packages":
+					file, _, err := r.FormFile("manifest")
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					var m struct{ Name, Version string }
+					json.NewDecoder(file).Decode(&m)
+					if m.Name == "beta" {
+						http.Error(w, "version already exists", http.StatusConflict)
+						return
+					}
+					published = append(published, m.Name)
+					json.NewEncoder(w).Encode(map[string]string{"name": m.Name, "version": m.Version, "status": "published"})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			configDir := t.TempDir()
+			t.Setenv("RFH_CONFIG", configDir)
+			configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+			if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			projectDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			defer os.Chdir(oldWd)
+			os.Chdir(projectDir)
+
+			var manifests []map[string]interface{}
+			for _, name := range []string{"alpha", "beta", "gamma"} {
+				if err := os.MkdirAll(filepath.Join("rules", name), 0755); err != nil {
+					t.Fatalf("Failed to create rules dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join("rules", name, "rule.mdc"), []byte("# "+name+"\n"), 0644); err != nil {
+					t.Fatalf("Failed to write rule: %v", err)
+				}
+				pattern := "rules/" + name + "/*.mdc"
+				if tt.missingFiles && name == "gamma" {
+					pattern = "rules/missing/*.mdc"
+				}
+				manifests = append(manifests, map[string]interface{}{"name": name, "version": "1.0.0", "files": []string{pattern}})
+			}
+			data, _ := json.Marshal(manifests)
+			if err := os.WriteFile("rulestack.pkg.json", data, 0644); err != nil {
+				t.Fatalf("Failed to write manifest: %v", err)
+			}
+
+			publishAtomic = tt.atomic
+			defer func() { publishAtomic = false }()
+
+			if err := runPublishAll(); err == nil {
+				t.Fatal("Expected an error when a package is not published")
+			}
+
+			if strings.Join(published, ",") != strings.Join(tt.wantPublished, ",") {
+				t.Errorf("Expected %v published, got %v", tt.wantPublished, published)
+			}
+
+			staged, _ := stagedArchives(getStagingDirectory())
+			var stagedNames []string
+			for _, archivePath := range staged {
+				stagedNames = append(stagedNames, filepath.Base(archivePath))
+			}
+			if strings.Join(stagedNames, ",") != strings.Join(tt.wantStaged, ",") {
+				t.Errorf("Expected %v left staged, got %v", tt.wantStaged, stagedNames)
+			}
+		})
+	}
+}
+
+func TestRunPublishArchives(t *testing.T) {
+	var published []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/v1/packages":
+			file, _, err := r.FormFile("manifest")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var m struct{ Name, Version string }
+			json.NewDecoder(file).Decode(&m)
+			published = append(published, m.Name+"@"+m.Version)
+			json.NewEncoder(w).Encode(map[string]string{"name": m.Name, "version": m.Version, "status": "published"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	// Pack in one directory, publish from another with no .rulestack/
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "rule.mdc"), []byte("# Rule\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	packageManifest := &manifest.PackageManifest{Name: "ci-rules", Version: "2.0.0", Files: []string{"*.mdc"}}
+	archivePath := filepath.Join(t.TempDir(), "ci-rules-2.0.0.tgz")
+	if _, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{}); err != nil {
+		t.Fatalf("packManifestPackage failed: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(t.TempDir())
+
+	if err := runPublishArchives([]string{archivePath}); err != nil {
+		t.Fatalf("runPublishArchives failed: %v", err)
+	}
+	if strings.Join(published, ",") != "ci-rules@2.0.0" {
+		t.Errorf("Expected ci-rules@2.0.0 published, got %v", published)
+	}
+	if _, err := os.Stat(archivePath); err != nil {
+		t.Errorf("Expected the archive to be left in place: %v", err)
+	}
+
+	if err := runPublishArchives([]string{"missing.tgz"}); err == nil {
+		t.Error("Expected a missing archive to be rejected")
+	}
+}
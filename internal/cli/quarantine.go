@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+var trustDryRun bool
+
+// trustCmd represents the trust command
+var trustCmd = &cobra.Command{
+	Use:   "trust [package]",
+	Short: "Activate the rules of a quarantined package",
+	Long: `Activate the rules of a package held in quarantine.
+
+With "quarantine": true in rulestack.json, packages are unpacked into .rulestack/
+but their rules are not referenced from CLAUDE.md until someone has reviewed
+them and run 'rfh trust'. Without a package, lists the quarantined packages.
+With --dry-run, the CLAUDE.md changes are printed as a unified diff and the
+package stays quarantined.
+
+Examples:
+  rfh trust
+  rfh trust security-rules
+  rfh trust security-rules --dry-run`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completePackageRefs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return runTrustList()
+		}
+		return runTrust(args[0])
+	},
+}
+
+// runTrust takes a package out of quarantine and wires its rules into CLAUDE.md
+func runTrust(name string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
+	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	entry, exists := lockManifest.Packages[name]
+	if !exists {
+		return fmt.Errorf("package '%s' is not in rulestack.lock.json", name)
+	}
+	if !entry.Quarantined {
+		output.Printf("ℹ️  %s@%s is not quarantined\n", name, entry.Version)
+		return nil
+	}
+
+	if trustDryRun {
+		updates, err := previewEditorChanges(projectRoot, func() error {
+			return updateClaudeFile(projectRoot, installedPackageRef(name, entry))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update CLAUDE.md: %w", err)
+		}
+		printEditorDiffs(updates)
+		output.Printf("ℹ️  Dry run: %s@%s is still quarantined\n", name, entry.Version)
+		return nil
+	}
+
+	entry.Quarantined = false
+	lockManifest.Packages[name] = entry
+	if err := saveLockManifest(lockPath, lockManifest); err != nil {
+		return fmt.Errorf("failed to save lock manifest: %w", err)
+	}
+
+	if err := updateClaudeFile(projectRoot, installedPackageRef(name, entry)); err != nil {
+		return fmt.Errorf("failed to update CLAUDE.md: %w", err)
+	}
+
+	output.Printf("✅ Trusted %s@%s; its rules are now active\n", name, entry.Version)
+	return nil
+}
+
+// runTrustList prints the packages waiting in quarantine
+func runTrustList() error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	var quarantined []string
+	for name, entry := range lockManifest.Packages {
+		if entry.Quarantined {
+			quarantined = append(quarantined, name)
+		}
+	}
+	sort.Strings(quarantined)
+
+	if len(quarantined) == 0 {
+		output.Printf("No quarantined packages\n")
+		return nil
+	}
+
+	output.Printf("🔒 Quarantined packages:\n")
+	for _, name := range quarantined {
+		entry := lockManifest.Packages[name]
+		ref := installedPackageRef(name, entry)
+		output.Printf("  %s@%s (.rulestack/%s.%s/)\n", name, entry.Version, ref.Name, ref.Version)
+	}
+
+	return nil
+}
+
+// quarantineEntry decides whether a lock entry about to replace previous is
+// quarantined. Packages are only quarantined when the project enables it, and
+// reinstalling exactly what was already trusted keeps it trusted, so a fresh
+// checkout does not quarantine packages its lock file records as reviewed.
+func quarantineEntry(projectRoot string, previous *LockPackageEntry, entry *LockPackageEntry) {
+	if previous != nil && previous.Version == entry.Version && previous.SHA256 == entry.SHA256 {
+		entry.Quarantined = previous.Quarantined
+		return
+	}
+
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	entry.Quarantined = err == nil && projectManifest.Quarantine
+}
+
+// wirePackageRules references an installed package's rules from CLAUDE.md,
+// unless its lock entry is quarantined
+func wirePackageRules(projectRoot, lockName string, installed *PackageRef) error {
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	return wireRules(projectRoot, lockName, installed, lockManifest.Packages[lockName].Quarantined)
+}
+
+// wireStagedRules is wirePackageRules for a package a dry run staged without
+// recording it: entry is the lock entry it would get under lockName
+func wireStagedRules(projectRoot, lockName string, installed *PackageRef, entry LockPackageEntry) error {
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	if previous, exists := lockManifest.Packages[lockName]; exists {
+		quarantineEntry(projectRoot, &previous, &entry)
+	} else {
+		quarantineEntry(projectRoot, nil, &entry)
+	}
+	return wireRules(projectRoot, lockName, installed, entry.Quarantined)
+}
+
+// wireRules references an installed package's rules from CLAUDE.md unless it is
+// quarantined
+func wireRules(projectRoot, lockName string, installed *PackageRef, quarantined bool) error {
+	if quarantined {
+		output.Printf("🔒 %s is quarantined. Review .rulestack/%s/ and run 'rfh trust %s' to activate its rules\n",
+			lockName, packageDirName(installed.Name, installed.Version), lockName)
+		return nil
+	}
+
+	// A new core version replaces the old one rather than adding to it
+	if installed.Name == corePackageName {
+		if err := switchCoreRules(projectRoot, installed.Version); err != nil {
+			return err
+		}
+	}
+
+	return updateClaudeFile(projectRoot, installed)
+}
+
+// reviewPackage shows the rule files of a downloaded package in a pager, as a
+// diff against previousDir when another version is installed, and asks whether
+// to go ahead
+func reviewPackage(archivePath, previousDir, name, version string) (bool, error) {
+	stageDir, err := tempdir.MkdirTemp("review-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create review directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	if _, err := pkg.Unpack(archivePath, stageDir); err != nil {
+		return false, fmt.Errorf("failed to extract package: %w", err)
+	}
+
+	content, err := reviewContent(stageDir, previousDir)
+	if err != nil {
+		return false, err
+	}
+	if err := showInPager(content); err != nil {
+		return false, err
+	}
+
+	return confirm(fmt.Sprintf("❓ Add %s@%s?", name, version), false), nil
+}
+
+// reviewContent renders the rule files of an unpacked package, or a diff against
+// the previously installed version when there is one and diff is available
+func reviewContent(packageDir, previousDir string) (string, error) {
+	if previousDir != "" {
+		output, err := exec.Command("diff", "-ruN", previousDir, packageDir).Output()
+		var exitErr *exec.ExitError
+		// diff exits with 1 when the directories differ
+		if err == nil || (errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			if len(output) == 0 {
+				return "No changes from the installed version\n", nil
+			}
+			return string(output), nil
+		}
+	}
+
+	ruleFiles, err := findRuleFiles(packageDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to find rule files in package: %w", err)
+	}
+
+	var content strings.Builder
+	for _, ruleFile := range ruleFiles {
+		data, err := os.ReadFile(filepath.Join(packageDir, ruleFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", ruleFile, err)
+		}
+		fmt.Fprintf(&content, "==> %s <==\n%s\n", filepath.ToSlash(ruleFile), data)
+	}
+
+	return content.String(), nil
+}
+
+// showInPager pages content through $PAGER (less by default) when stdout is a
+// terminal, and prints it otherwise
+func showInPager(content string) error {
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		output.Print(content)
+		return nil
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			output.Print(content)
+			return nil
+		}
+		return fmt.Errorf("pager failed: %w", err)
+	}
+
+	return nil
+}
+
+// markReviewed takes a package that was reviewed while being added out of quarantine
+func markReviewed(projectRoot, lockName string) error {
+	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
+	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	entry, exists := lockManifest.Packages[lockName]
+	if !exists || !entry.Quarantined {
+		return nil
+	}
+
+	entry.Quarantined = false
+	lockManifest.Packages[lockName] = entry
+	if err := saveLockManifest(lockPath, lockManifest); err != nil {
+		return fmt.Errorf("failed to save lock manifest: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+	trustCmd.Flags().BoolVar(&trustDryRun, "dry-run", false, "print the CLAUDE.md changes as a unified diff without trusting the package")
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupQuarantineProject(t *testing.T, quarantine bool) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(tempDir)
+
+	manifestContent := `{"version": "1.0.0", "dependencies": {}}`
+	if quarantine {
+		manifestContent = `{"version": "1.0.0", "dependencies": {}, "quarantine": true}`
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	packageDir := filepath.Join(tempDir, ".rulestack", "security-rules.1.0.0")
+	os.MkdirAll(packageDir, 0755)
+	if err := os.WriteFile(filepath.Join(packageDir, "secure.md"), []byte("# Secure coding\n"), 0644); err != nil {
+		t.Fatalf("Failed to create rule file: %v", err)
+	}
+
+	return tempDir
+}
+
+func TestQuarantine(t *testing.T) {
+	tempDir := setupQuarantineProject(t, true)
+	installed := &PackageRef{Name: "security-rules", Version: "1.0.0"}
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	if err := wirePackageRules(tempDir, "security-rules", installed); err != nil {
+		t.Fatalf("wirePackageRules failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("expected a quarantined package to stay out of CLAUDE.md")
+	}
+
+	// Reinstalling the same quarantined version does not trust it
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	lockManifest, _ := loadOrCreateLockManifest(filepath.Join(tempDir, "rulestack.lock.json"), tempDir)
+	if !lockManifest.Packages["security-rules"].Quarantined {
+		t.Fatal("expected the package to stay quarantined")
+	}
+
+	if err := runTrust("security-rules"); err != nil {
+		t.Fatalf("runTrust failed: %v", err)
+	}
+	claude, err := os.ReadFile(filepath.Join(tempDir, "CLAUDE.md"))
+	if err != nil || !strings.Contains(string(claude), "@.rulestack/security-rules.1.0.0/secure.md") {
+		t.Errorf("expected trusted rules in CLAUDE.md, got %q (%v)", claude, err)
+	}
+
+	// A trusted version stays trusted when reinstalled, but a new version is quarantined
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	lockManifest, _ = loadOrCreateLockManifest(filepath.Join(tempDir, "rulestack.lock.json"), tempDir)
+	if lockManifest.Packages["security-rules"].Quarantined {
+		t.Error("expected reinstalling a trusted version to keep it trusted")
+	}
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.1.0", SHA256: "def"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	lockManifest, _ = loadOrCreateLockManifest(filepath.Join(tempDir, "rulestack.lock.json"), tempDir)
+	if !lockManifest.Packages["security-rules"].Quarantined {
+		t.Error("expected a new version to be quarantined")
+	}
+
+	if err := runTrust("missing-rules"); err == nil {
+		t.Error("expected trusting a package that is not installed to fail")
+	}
+}
+
+func TestQuarantineDisabled(t *testing.T) {
+	tempDir := setupQuarantineProject(t, false)
+
+	if err := updateLockEntry(tempDir, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc"}); err != nil {
+		t.Fatalf("updateLockEntry failed: %v", err)
+	}
+	if err := wirePackageRules(tempDir, "security-rules", &PackageRef{Name: "security-rules", Version: "1.0.0"}); err != nil {
+		t.Fatalf("wirePackageRules failed: %v", err)
+	}
+	if claude, err := os.ReadFile(filepath.Join(tempDir, "CLAUDE.md")); err != nil || !strings.Contains(string(claude), "security-rules.1.0.0/secure.md") {
+		t.Errorf("expected rules to be wired without quarantine, got %q (%v)", claude, err)
+	}
+}
+
+func TestReviewContent(t *testing.T) {
+	newDir := t.TempDir()
+	os.WriteFile(filepath.Join(newDir, "secure.md"), []byte("# Secure coding\nAlways validate input.\n"), 0644)
+	os.WriteFile(filepath.Join(newDir, "rulestack.json"), []byte(`{}`), 0644)
+
+	content, err := reviewContent(newDir, "")
+	if err != nil {
+		t.Fatalf("reviewContent failed: %v", err)
+	}
+	if !strings.Contains(content, "==> secure.md <==\n# Secure coding") || strings.Contains(content, "rulestack.json") {
+		t.Errorf("expected the rule files to be listed, got %q", content)
+	}
+
+	oldDir := t.TempDir()
+	os.WriteFile(filepath.Join(oldDir, "secure.md"), []byte("# Secure coding\n"), 0644)
+	os.WriteFile(filepath.Join(oldDir, "rulestack.json"), []byte(`{}`), 0644)
+
+	content, err = reviewContent(newDir, oldDir)
+	if err != nil {
+		t.Fatalf("reviewContent failed: %v", err)
+	}
+	if !strings.Contains(content, "+Always validate input.") && !strings.Contains(content, "==> secure.md <==") {
+		t.Errorf("expected a diff against the installed version, got %q", content)
+	}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// recursive runs install, outdated and verify in every project under the
+// current directory
+var recursive bool
+
+// projectSearchSkipDirs are never searched for projects
+var projectSearchSkipDirs = map[string]bool{
+	".git":         true,
+	".rulestack":   true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// findProjects returns the directories under dir whose rulestack.json is a
+// project manifest, sorted by path
+func findProjects(dir string) ([]string, error) {
+	var projectRoots []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && projectSearchSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "rulestack.json" && manifest.IsProjectManifest(path) {
+			projectRoots = append(projectRoots, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s for projects: %w", dir, err)
+	}
+
+	sort.Strings(projectRoots)
+	return projectRoots, nil
+}
+
+// projectRun is the outcome of a command in one project of a recursive run
+type projectRun struct {
+	Path string
+	Err  error
+}
+
+// runInProjects runs a command in every project under the current directory,
+// from the project's own directory so that its rulestack.json, registry and
+// mirrors apply. A failing project does not stop the others; the runs are
+// summarized at the end.
+func runInProjects(command string, run func() error) error {
+	startDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	defer os.Chdir(startDir)
+
+	projectRoots, err := findProjects(startDir)
+	if err != nil {
+		return err
+	}
+	if len(projectRoots) == 0 {
+		output.Printf("ℹ️  No projects found under %s\n", startDir)
+		return nil
+	}
+
+	runs := make([]projectRun, len(projectRoots))
+	failed := 0
+	for i, projectRoot := range projectRoots {
+		relPath, err := filepath.Rel(startDir, projectRoot)
+		if err != nil {
+			relPath = projectRoot
+		}
+		runs[i].Path = filepath.ToSlash(relPath)
+
+		output.Printf("\n📁 %s\n", runs[i].Path)
+		if err := os.Chdir(projectRoot); err != nil {
+			runs[i].Err = err
+		} else {
+			runs[i].Err = run()
+		}
+		if runs[i].Err != nil {
+			output.Printf("❌ %v\n", runs[i].Err)
+			failed++
+		}
+	}
+
+	output.Printf("\n📊 %s in %d project(s): %d succeeded, %d failed\n", command, len(runs), len(runs)-failed, failed)
+	for _, r := range runs {
+		if r.Err != nil {
+			output.Printf("   ❌ %s: %v\n", r.Path, r.Err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed in %d of %d project(s)", command, failed, len(runs))
+	}
+	return nil
+}
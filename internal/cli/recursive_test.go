@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunInProjects(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	root := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+
+	for _, project := range []string{"services/api", "services/web"} {
+		dir := filepath.Join(root, filepath.FromSlash(project))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.Chdir(dir)
+		if err := runInit(false, false); err != nil {
+			t.Fatalf("init %s failed: %v", project, err)
+		}
+	}
+	os.Chdir(root)
+
+	var visited []string
+	err := runInProjects("verify", func() error {
+		wd, _ := os.Getwd()
+		visited = append(visited, filepath.Base(wd))
+		return runVerify()
+	})
+	if err != nil {
+		t.Fatalf("verify of clean projects failed: %v", err)
+	}
+	if strings.Join(visited, ",") != "api,web" {
+		t.Errorf("visited %v, want api then web", visited)
+	}
+	if wd, _ := os.Getwd(); wd != root {
+		t.Errorf("working directory left at %s", wd)
+	}
+
+	// A tampered project fails the run without stopping the others
+	coreRulesPath := filepath.Join(root, "services", "api", coreRulesDir(projectTemplateVersion), "core_rules.md")
+	if err := os.WriteFile(coreRulesPath, []byte("# Edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	visited = nil
+	err = runInProjects("verify", func() error {
+		wd, _ := os.Getwd()
+		visited = append(visited, filepath.Base(wd))
+		return runVerify()
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 project(s)") {
+		t.Errorf("error = %v, want verify failed in 1 of 2 projects", err)
+	}
+	if len(visited) != 2 {
+		t.Errorf("visited %v, want both projects", visited)
+	}
+}
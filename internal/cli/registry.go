@@ -1,14 +1,15 @@
 package cli
 
 import (
-	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"rulestack/internal/client"
 	"rulestack/internal/config"
+	"rulestack/internal/output"
+	"rulestack/internal/retention"
 )
 
 // registryCmd represents the registry command
@@ -33,18 +34,22 @@ Registry Types:
 
 Examples:
   rfh registry add public https://registry.rulestack.dev
-  rfh registry add github https://github.com/org/registry --type git`,
+  rfh registry add github https://github.com/org/registry --type git
+  rfh registry add secure https://github.com/org/registry --type git --require-approval
+  rfh registry add corp git@ghe.example.com:platform/rules.git --type git --api-url https://ghe.example.com/api/v3`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		url := args[1]
 		registryType, _ := cmd.Flags().GetString("type")
+		requireApproval, _ := cmd.Flags().GetBool("require-approval")
+		apiURL, _ := cmd.Flags().GetString("api-url")
 
 		if registryType == "" {
 			registryType = string(config.RegistryTypeHTTP)
 		}
 
-		return runRegistryAdd(name, url, config.RegistryType(registryType))
+		return runRegistryAdd(name, url, config.RegistryType(registryType), requireApproval, apiURL)
 	},
 }
 
@@ -71,7 +76,7 @@ The active registry is used when no --registry flag is specified.`,
 	},
 }
 
-func runRegistryAdd(name, url string, registryType config.RegistryType) error {
+func runRegistryAdd(name, url string, registryType config.RegistryType, requireApproval bool, apiURL string) error {
 	// Validate registry type
 	if err := config.ValidateRegistryType(registryType); err != nil {
 		return err
@@ -79,22 +84,31 @@ func runRegistryAdd(name, url string, registryType config.RegistryType) error {
 
 	// Validate URL based on type
 	if registryType == config.RegistryTypeGit {
-		if !strings.HasPrefix(url, "https://github.com/") &&
-			!strings.HasPrefix(url, "https://gitlab.com/") &&
-			!strings.HasPrefix(url, "git@") {
-			fmt.Printf("⚠️  Warning: Git registry URL may not be valid\n")
+		if client.DefaultAPIURL(url) == "" && apiURL == "" {
+			output.Printf("⚠️  Warning: Git registry URL may not be valid\n")
 		}
 	}
 
-	cfg, err := config.LoadCLI()
+	if apiURL != "" {
+		if registryType != config.RegistryTypeGit {
+			return fmt.Errorf("--api-url only applies to git registries")
+		}
+		if err := config.ValidateAPIURL(apiURL); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Add registry with type
 	cfg.Registries[name] = config.Registry{
-		URL:  url,
-		Type: registryType,
+		URL:             url,
+		Type:            registryType,
+		RequireApproval: requireApproval,
+		APIURL:          apiURL,
 	}
 
 	// Set as current if it's the first one
@@ -107,36 +121,46 @@ func runRegistryAdd(name, url string, registryType config.RegistryType) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Added registry '%s'\n", name)
-	fmt.Printf("🌐 URL: %s\n", url)
-	fmt.Printf("📋 Type: %s\n", registryType)
+	output.Printf("✅ Added registry '%s'\n", name)
+	output.Printf("🌐 URL: %s\n", url)
+	output.Printf("📋 Type: %s\n", registryType)
+	if registryType == config.RegistryTypeGit {
+		if apiURL != "" {
+			output.Printf("🔌 API: %s\n", apiURL)
+		} else if derived := client.DefaultAPIURL(url); derived != "" {
+			output.Printf("🔌 API: %s (derived; override with --api-url)\n", derived)
+		}
+	}
+	if requireApproval {
+		output.Printf("👥 Publishes require approval from a second reviewer\n")
+	}
 
 	if cfg.Current == name {
-		fmt.Printf("⭐ Set as active registry\n")
+		output.Printf("⭐ Set as active registry\n")
 	}
 
 	if registryType == config.RegistryTypeHTTP {
-		fmt.Printf("💡 Use 'rfh auth login' to authenticate with this registry\n")
+		output.Printf("💡 Use 'rfh auth login' to authenticate with this registry\n")
 	} else if registryType == config.RegistryTypeGit {
-		fmt.Printf("💡 Set git_token in config or use GITHUB_TOKEN environment variable for authentication\n")
+		output.Printf("💡 Set git_token in config or use GITHUB_TOKEN environment variable for authentication\n")
 	}
 
 	return nil
 }
 
 func runRegistryList() error {
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	if len(cfg.Registries) == 0 {
-		fmt.Printf("No registries configured.\n")
-		fmt.Printf("Add a registry with: rfh registry add <name> <url> [--type remote-http|git]\n")
+		output.Printf("No registries configured.\n")
+		output.Printf("Add a registry with: rfh registry add <name> <url> [--type remote-http|git]\n")
 		return nil
 	}
 
-	fmt.Printf("📋 Configured registries:\n\n")
+	output.Printf("📋 Configured registries:\n\n")
 	for name, reg := range cfg.Registries {
 		marker := "  "
 		if cfg.Current == name {
@@ -145,28 +169,35 @@ func runRegistryList() error {
 
 		registryType := reg.GetEffectiveType()
 
-		fmt.Printf("%s%s (%s)\n", marker, name, registryType)
-		fmt.Printf("    URL: %s\n", reg.URL)
+		output.Printf("%s%s (%s)\n", marker, name, registryType)
+		output.Printf("    URL: %s\n", reg.URL)
 
 		// Show appropriate token status based on type
+		tokenStatus := "[configured]"
+		if reg.AuthToken() == "" {
+			tokenStatus = fmt.Sprintf("[issued for %s, not sent]", reg.TokenURL)
+		}
 		if registryType == config.RegistryTypeHTTP && reg.JWTToken != "" {
-			fmt.Printf("    JWT Token: [configured]\n")
+			output.Printf("    JWT Token: %s\n", tokenStatus)
 		} else if registryType == config.RegistryTypeGit && reg.GitToken != "" {
-			fmt.Printf("    Git Token: [configured]\n")
+			output.Printf("    Git Token: %s\n", tokenStatus)
+		}
+		if reg.Defaults.Target != "" {
+			output.Printf("    Default target: %s\n", reg.Defaults.Target)
 		}
 
-		fmt.Printf("\n")
+		output.Printf("\n")
 	}
 
 	if cfg.Current != "" {
-		fmt.Printf("* = active registry\n")
+		output.Printf("* = active registry\n")
 	}
 
 	return nil
 }
 
 func runRegistryUse(name string) error {
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -184,8 +215,8 @@ func runRegistryUse(name string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Set '%s' as active registry\n", name)
-	fmt.Printf("🌐 URL: %s\n", cfg.Registries[name].URL)
+	output.Printf("✅ Set '%s' as active registry\n", name)
+	output.Printf("🌐 URL: %s\n", cfg.Registries[name].URL)
 
 	return nil
 }
@@ -232,7 +263,7 @@ Examples:
 }
 
 func runRegistryRemove(name string) error {
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -251,7 +282,7 @@ func runRegistryRemove(name string) error {
 	// If this was the current registry, clear the current setting
 	if cfg.Current == name {
 		cfg.Current = ""
-		fmt.Printf("⚠️  Removed active registry. Use 'rfh registry use' to set a new active registry.\n")
+		output.Printf("⚠️  Removed active registry. Use 'rfh registry use' to set a new active registry.\n")
 	}
 
 	// Save config
@@ -259,15 +290,15 @@ func runRegistryRemove(name string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Removed registry '%s'\n", name)
-	fmt.Printf("🌐 URL was: %s\n", url)
+	output.Printf("✅ Removed registry '%s'\n", name)
+	output.Printf("🌐 URL was: %s\n", url)
 
 	return nil
 }
 
 func runRegistryInit(token string) error {
 	// 1. Load config
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -283,56 +314,151 @@ func runRegistryInit(token string) error {
 		return fmt.Errorf("active registry '%s' is not a Git registry (type: %s). Only Git registries can be initialized", registryName, registry.GetEffectiveType())
 	}
 
-	fmt.Printf("🔧 Initializing Git registry '%s'...\n", registryName)
-	fmt.Printf("🌐 URL: %s\n", registry.URL)
+	output.Printf("🔧 Initializing Git registry '%s'...\n", registryName)
+	output.Printf("🌐 URL: %s\n", registry.URL)
 
 	// 4. Store token in config and save immediately
 	registry.GitToken = token
+	registry.TokenURL = registry.URL
 	cfg.Registries[registryName] = registry
 	
 	if err := config.SaveCLI(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
-	fmt.Printf("🔑 Token stored in config\n")
+	output.Printf("🔑 Token stored in config\n")
 
 	// 5. Initialize repository structure
 	err = initializeGitRegistryStructure(registryName, &registry)
 	if err != nil {
 		// Token is already saved, so give appropriate feedback
-		fmt.Printf("⚠️  Repository structure initialization failed: %v\n", err)
-		fmt.Printf("💡 Token has been saved. You can retry initialization later.\n")
+		output.Printf("⚠️  Repository structure initialization failed: %v\n", err)
+		output.Printf("💡 Token has been saved. You can retry initialization later.\n")
 		return fmt.Errorf("failed to initialize registry structure: %w", err)
 	}
 
 	// 6. Success feedback
-	fmt.Printf("✅ Registry '%s' initialized successfully\n", registryName)
-	fmt.Printf("📁 Default structure created in remote repository\n")
-	fmt.Printf("💡 Registry is now ready for publishing packages\n")
+	output.Printf("✅ Registry '%s' initialized successfully\n", registryName)
+	output.Printf("📁 Default structure created in remote repository\n")
+	output.Printf("💡 Registry is now ready for publishing packages\n")
 
 	return nil
 }
 
 func initializeGitRegistryStructure(registryName string, registry *config.Registry) error {
 	// Create temporary GitClient with the token
-	c, err := client.NewGitClient(registry.URL, registry.GitToken, verbose)
+	c, err := client.NewGitClient(registry.URL, registry.AuthToken(), verbose)
 	if err != nil {
 		return fmt.Errorf("failed to create Git client: %w", err)
 	}
 
-	ctx, cancel := client.WithTimeout(context.Background())
+	ctx, cancel := client.WithTimeout(commandContext)
 	defer cancel()
 
-	fmt.Printf("🚀 Setting up repository structure...\n")
+	output.Printf("🚀 Setting up repository structure...\n")
 	return c.InitializeRegistry(ctx)
 }
 
+var registryGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Apply a retention policy to the active Git registry",
+	Long: `Remove old versions from the active Git registry's metadata under a retention policy.
+
+Changes are proposed as a pull request like a publish. The newest version of every
+package is always kept. By default version directories stay in place so lockfiles
+pinning an expired version keep installing; use --prune-archives to delete them too.
+Pruned archives remain in the repository's Git history until it is rewritten.
+
+Examples:
+  rfh registry gc --keep 10 --dry-run
+  rfh registry gc --prerelease-days 30
+  rfh registry gc --keep 5 --prerelease-days 14 --prune-archives`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keep, _ := cmd.Flags().GetInt("keep")
+		prereleaseDays, _ := cmd.Flags().GetInt("prerelease-days")
+		pruneArchives, _ := cmd.Flags().GetBool("prune-archives")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		opts := client.GCOptions{
+			Policy: retention.Policy{
+				KeepVersions:     keep,
+				PrereleaseMaxAge: time.Duration(prereleaseDays) * 24 * time.Hour,
+			},
+			PruneArchives: pruneArchives,
+			DryRun:        dryRun,
+		}
+		return runRegistryGC(opts)
+	},
+}
+
+func runRegistryGC(opts client.GCOptions) error {
+	if opts.Policy.KeepVersions < 0 || opts.Policy.PrereleaseMaxAge < 0 {
+		return fmt.Errorf("--keep and --prerelease-days must not be negative")
+	}
+	if !opts.Policy.Enabled() {
+		return fmt.Errorf("no retention policy given: set --keep or --prerelease-days")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, registry, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if registry.GetEffectiveType() != config.RegistryTypeGit {
+		return fmt.Errorf("active registry '%s' is not a Git registry (type: %s). Only Git registries can be garbage collected", registryName, registry.GetEffectiveType())
+	}
+
+	c, err := client.NewGitClient(registry.URL, registry.AuthToken(), verbose)
+	if err != nil {
+		return fmt.Errorf("failed to create Git client: %w", err)
+	}
+
+	ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+	defer cancel()
+
+	output.Printf("🧹 Collecting garbage in registry '%s'...\n", registryName)
+	result, err := c.CollectGarbage(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to collect garbage: %w", err)
+	}
+
+	for _, expired := range result.Expired {
+		output.Printf("  🗑️  %s@%s\n", expired.Name, expired.Version)
+	}
+	if result.FreedBytes > 0 {
+		output.Printf("💾 Archives removed: %d bytes\n", result.FreedBytes)
+	}
+
+	if opts.DryRun || len(result.Expired) == 0 {
+		output.Printf("ℹ️  %s\n", result.Message)
+		return nil
+	}
+
+	output.Printf("✅ %s\n", result.Message)
+	return nil
+}
+
 func init() {
 	registryAddCmd.Flags().String("type", "remote-http", "Registry type (remote-http or git)")
+	registryAddCmd.Flags().Bool("require-approval", false, "Require a second reviewer to approve publishes (git registries)")
+	registryAddCmd.Flags().String("api-url", "", "REST API base of the Git provider, for enterprise servers with non-standard endpoints (git registries)")
 	registryInitCmd.Flags().String("token", "", "GitHub personal access token (required)")
+	registryGCCmd.Flags().Int("keep", 0, "Keep only the newest N versions of each package (0 keeps all)")
+	registryGCCmd.Flags().Int("prerelease-days", 0, "Remove pre-release versions older than this many days (0 keeps them)")
+	registryGCCmd.Flags().Bool("prune-archives", false, "Also delete the archives of removed versions")
+	registryGCCmd.Flags().Bool("dry-run", false, "Show what would be removed without changing the registry")
 
 	registryCmd.AddCommand(registryAddCmd)
 	registryCmd.AddCommand(registryListCmd)
 	registryCmd.AddCommand(registryUseCmd)
 	registryCmd.AddCommand(registryInitCmd)
 	registryCmd.AddCommand(registryRemoveCmd)
+	registryCmd.AddCommand(registryGCCmd)
+	registryCmd.AddCommand(registryTrustCmd)
+	registryCmd.AddCommand(registryPinCmd)
 }
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/config"
+	"rulestack/internal/index"
+	"rulestack/internal/output"
+)
+
+// registryRenameCmd renames a registry
+var registryRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a registry",
+	Long: `Rename a registry, keeping its URL, credentials, pinned key and local index.
+
+Projects refer to registries by name in mirrors, allowed registries and
+rulestack.lock.json; update those after renaming a registry they use.
+
+Examples:
+  rfh registry rename copr corp`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRegistryRename(args[0], args[1])
+	},
+}
+
+// registrySetCmd edits a registry in place
+var registrySetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Change a registry's URL, type, token, API URL or defaults",
+	Long: `Change a registry's settings in place, without removing and re-adding it.
+
+--token replaces the JWT token of an HTTP registry, or the git token of a Git
+registry. A token is only sent to the URL it was stored for, so after changing
+--url the old token is kept but not sent until it is replaced with --token or
+'rfh auth login'.
+
+Examples:
+  rfh registry set corp --url https://registry.company.com
+  rfh registry set corp --token "$RFH_TOKEN"
+  rfh registry set github --type git --token ghp_xxxxxxxxxxxx
+  rfh registry set corp --default-target cursor
+  rfh registry set github --api-url https://ghe.example.com/api/v3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var changes registryChanges
+		flags := cmd.Flags()
+		if flags.Changed("url") {
+			value, _ := flags.GetString("url")
+			changes.URL = &value
+		}
+		if flags.Changed("type") {
+			value, _ := flags.GetString("type")
+			changes.Type = &value
+		}
+		if flags.Changed("token") {
+			value, _ := flags.GetString("token")
+			changes.Token = &value
+		}
+		if flags.Changed("default-target") {
+			value, _ := flags.GetString("default-target")
+			changes.DefaultTarget = &value
+		}
+		if flags.Changed("api-url") {
+			value, _ := flags.GetString("api-url")
+			changes.APIURL = &value
+		}
+		return runRegistrySet(args[0], changes)
+	},
+}
+
+// registryChanges are the settings 'rfh registry set' changes; nil leaves one as is
+type registryChanges struct {
+	URL           *string
+	Type          *string
+	Token         *string
+	DefaultTarget *string
+	APIURL        *string
+}
+
+func runRegistryRename(oldName, newName string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registry, exists := cfg.Registries[oldName]
+	if !exists {
+		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", oldName)
+	}
+	if newName == "" {
+		return fmt.Errorf("new registry name cannot be empty")
+	}
+	if _, taken := cfg.Registries[newName]; taken {
+		return fmt.Errorf("registry '%s' already exists", newName)
+	}
+
+	delete(cfg.Registries, oldName)
+	cfg.Registries[newName] = registry
+	if cfg.Current == oldName {
+		cfg.Current = newName
+	}
+
+	if err := config.SaveCLI(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	// The local index is kept under the registry's name
+	if oldPath, err := index.Path(oldName); err == nil {
+		if newPath, err := index.Path(newName); err == nil {
+			if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+				output.Printf("⚠️  Could not move the local index: %v. Run 'rfh index sync' to rebuild it\n", err)
+			}
+		}
+	}
+
+	output.Printf("✅ Renamed registry '%s' to '%s'\n", oldName, newName)
+	output.Printf("💡 Update projects that name '%s' as a mirror or allowed registry\n", oldName)
+	return nil
+}
+
+func runRegistrySet(name string, changes registryChanges) error {
+	if changes == (registryChanges{}) {
+		return fmt.Errorf("nothing to change: pass --url, --type, --token, --default-target or --api-url")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registry, exists := cfg.Registries[name]
+	if !exists {
+		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", name)
+	}
+
+	if changes.Type != nil {
+		registryType := config.RegistryType(*changes.Type)
+		if err := config.ValidateRegistryType(registryType); err != nil {
+			return err
+		}
+		registry.Type = registryType
+		output.Printf("📋 Type: %s\n", registryType)
+	}
+
+	if changes.URL != nil && *changes.URL != registry.URL {
+		if *changes.URL == "" {
+			return fmt.Errorf("registry URL cannot be empty")
+		}
+
+		// Tokens stored before their URL was recorded belong to the old URL
+		hasToken := registry.JWTToken != "" || registry.GitToken != ""
+		if hasToken && registry.TokenURL == "" {
+			registry.TokenURL = registry.URL
+		}
+		if !config.SameRegistryURL(registry.URL, *changes.URL) {
+			registry.PinnedKey = ""
+		}
+
+		registry.URL = *changes.URL
+		output.Printf("🌐 URL: %s\n", registry.URL)
+		if hasToken && changes.Token == nil && registry.AuthToken() == "" {
+			output.Printf("⚠️  The stored token was issued for %s and will not be sent to the new URL\n", registry.TokenURL)
+			output.Printf("💡 Use --token or 'rfh auth login' to authenticate with it\n")
+		}
+	}
+
+	if changes.Token != nil {
+		if registry.GetEffectiveType() == config.RegistryTypeGit {
+			registry.GitToken = *changes.Token
+		} else {
+			registry.JWTToken = *changes.Token
+		}
+		registry.TokenURL = registry.URL
+		if *changes.Token == "" {
+			registry.TokenURL = ""
+			output.Printf("🗑️  Token removed\n")
+		} else {
+			output.Printf("🔑 Token updated\n")
+		}
+	}
+
+	if changes.DefaultTarget != nil {
+		registry.Defaults.Target = *changes.DefaultTarget
+		output.Printf("🎯 Default target: %s\n", valueOrNone(registry.Defaults.Target))
+	}
+
+	if changes.APIURL != nil {
+		if *changes.APIURL != "" {
+			if registry.GetEffectiveType() != config.RegistryTypeGit {
+				return fmt.Errorf("--api-url only applies to git registries")
+			}
+			if err := config.ValidateAPIURL(*changes.APIURL); err != nil {
+				return err
+			}
+		}
+		registry.APIURL = *changes.APIURL
+		if registry.APIURL != "" {
+			output.Printf("🔌 API: %s\n", registry.APIURL)
+		} else {
+			output.Printf("🔌 API: derived from the registry URL\n")
+		}
+	}
+
+	cfg.Registries[name] = registry
+	if err := config.SaveCLI(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	output.Printf("✅ Updated registry '%s'\n", name)
+	return nil
+}
+
+// valueOrNone shows an empty setting as "(none)"
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func init() {
+	registrySetCmd.Flags().String("url", "", "new registry URL")
+	registrySetCmd.Flags().String("type", "", "new registry type (remote-http or git)")
+	registrySetCmd.Flags().String("token", "", "new authentication token (empty to remove it)")
+	registrySetCmd.Flags().String("default-target", "", "default target filter for search and browse (empty to clear it)")
+	registrySetCmd.Flags().String("api-url", "", "REST API base of the Git provider (empty to derive it from the URL)")
+
+	registryCmd.AddCommand(registryRenameCmd)
+	registryCmd.AddCommand(registrySetCmd)
+}
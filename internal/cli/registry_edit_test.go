@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rulestack/internal/config"
+)
+
+func TestRunRegistryRename(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+
+	cfg := config.CLIConfig{
+		Current: "copr",
+		Registries: map[string]config.Registry{
+			"copr":  {URL: "https://registry.company.com", Type: config.RegistryTypeHTTP, JWTToken: "jwt", Username: "alice"},
+			"other": {URL: "https://other.example.com", Type: config.RegistryTypeHTTP},
+		},
+	}
+	if err := config.SaveCLI(cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	indexDir := filepath.Join(configDir, "index")
+	os.MkdirAll(indexDir, 0755)
+	os.WriteFile(filepath.Join(indexDir, "copr.json"), []byte("{}"), 0644)
+
+	if err := runRegistryRename("copr", "other"); err == nil {
+		t.Error("expected renaming onto an existing registry to fail")
+	}
+	if err := runRegistryRename("missing", "x"); err == nil {
+		t.Error("expected renaming a missing registry to fail")
+	}
+
+	if err := runRegistryRename("copr", "corp"); err != nil {
+		t.Fatalf("runRegistryRename failed: %v", err)
+	}
+
+	cfg, err := config.LoadCLI()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, exists := cfg.Registries["copr"]; exists {
+		t.Error("expected the old name to be gone")
+	}
+	if reg := cfg.Registries["corp"]; reg.JWTToken != "jwt" || reg.Username != "alice" {
+		t.Errorf("expected credentials to move with the registry, got %+v", reg)
+	}
+	if cfg.Current != "corp" {
+		t.Errorf("expected the active registry to follow the rename, got %q", cfg.Current)
+	}
+	if _, err := os.Stat(filepath.Join(indexDir, "corp.json")); err != nil {
+		t.Errorf("expected the local index to be moved: %v", err)
+	}
+}
+
+func TestRunRegistrySet(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+
+	cfg := config.CLIConfig{
+		Current: "corp",
+		Registries: map[string]config.Registry{
+			"corp": {URL: "https://registry.company.com", Type: config.RegistryTypeHTTP, JWTToken: "old-jwt", PinnedKey: "sha256/abc"},
+		},
+	}
+	if err := config.SaveCLI(cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	load := func() config.Registry {
+		t.Helper()
+		cfg, err := config.LoadCLI()
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+		return cfg.Registries["corp"]
+	}
+	str := func(s string) *string { return &s }
+
+	if err := runRegistrySet("corp", registryChanges{}); err == nil {
+		t.Error("expected an error when nothing changes")
+	}
+	if err := runRegistrySet("corp", registryChanges{Type: str("ftp")}); err == nil {
+		t.Error("expected an invalid type to be rejected")
+	}
+
+	// A new URL keeps the old token, but scoped to the URL it was issued for
+	if err := runRegistrySet("corp", registryChanges{URL: str("https://rules.company.com")}); err != nil {
+		t.Fatalf("runRegistrySet failed: %v", err)
+	}
+	reg := load()
+	if reg.URL != "https://rules.company.com" || reg.JWTToken != "old-jwt" || reg.AuthToken() != "" {
+		t.Errorf("expected the old token kept but not sent, got %+v", reg)
+	}
+	if reg.PinnedKey != "" {
+		t.Errorf("expected the pinned key of the old host to be dropped, got %q", reg.PinnedKey)
+	}
+
+	if err := runRegistrySet("corp", registryChanges{Token: str("new-jwt"), DefaultTarget: str("cursor")}); err != nil {
+		t.Fatalf("runRegistrySet failed: %v", err)
+	}
+	reg = load()
+	if reg.AuthToken() != "new-jwt" || reg.Defaults.Target != "cursor" {
+		t.Errorf("expected the new token in use and the default target set, got %+v", reg)
+	}
+
+	if err := runRegistrySet("corp", registryChanges{Type: str("git"), Token: str("ghp")}); err != nil {
+		t.Fatalf("runRegistrySet failed: %v", err)
+	}
+	reg = load()
+	if reg.Type != config.RegistryTypeGit || reg.GitToken != "ghp" || reg.AuthToken() != "ghp" {
+		t.Errorf("expected a git registry with the git token, got %+v", reg)
+	}
+
+	if err := runRegistrySet("corp", registryChanges{APIURL: str("ghe.example.com")}); err == nil {
+		t.Error("expected an API URL without a scheme to be rejected")
+	}
+	if err := runRegistrySet("corp", registryChanges{APIURL: str("https://ghe.example.com/api/v3")}); err != nil {
+		t.Fatalf("runRegistrySet failed: %v", err)
+	}
+	if reg = load(); reg.APIURL != "https://ghe.example.com/api/v3" {
+		t.Errorf("expected the API URL set, got %q", reg.APIURL)
+	}
+	if err := runRegistrySet("corp", registryChanges{APIURL: str("")}); err != nil {
+		t.Fatalf("runRegistrySet failed: %v", err)
+	}
+	if reg = load(); reg.APIURL != "" {
+		t.Errorf("expected the API URL cleared, got %q", reg.APIURL)
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+// registryPingCmd times a round trip to a registry and checks its credential
+var registryPingCmd = &cobra.Command{
+	Use:   "ping [name]",
+	Short: "Time a registry round trip and check the stored credential",
+	Long: `Time a round trip to a registry, the active one unless a name is given, and
+check whether its stored credential is accepted.
+
+For HTTP registries the health check is split into DNS lookup, TCP connect,
+TLS handshake and time to first byte, and the token is checked against the
+user profile endpoint. For Git registries listing the remote's refs checks the
+token, followed by a clone, or a fetch into the existing cache.
+
+Exits with an error when the registry is unreachable or rejects the credential.
+
+Examples:
+  rfh registry ping
+  rfh registry ping corp`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		return runRegistryPing(name)
+	},
+}
+
+func runRegistryPing(name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if name == "" {
+		if name, _, err = getCurrentRegistry(cfg); err != nil {
+			return err
+		}
+	}
+	registry, exists := cfg.Registries[name]
+	if !exists {
+		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", name)
+	}
+
+	c, err := client.GetClientForRegistry(cfg, name, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+	defer cancel()
+
+	output.Printf("🏓 Pinging %s (%s, %s)\n", name, registry.URL, registry.GetEffectiveType())
+	report, pingErr := c.Ping(ctx)
+	if report != nil {
+		printPingReport(report)
+	}
+	if pingErr != nil {
+		return fmt.Errorf("ping failed: %w", pingErr)
+	}
+
+	if report.Auth == client.AuthRejected {
+		return fmt.Errorf("registry '%s' rejected the stored credential", name)
+	}
+	return nil
+}
+
+func printPingReport(report *client.PingReport) {
+	for _, phase := range report.Phases {
+		output.Printf("   %-12s %s\n", phase.Name, formatDuration(phase.Duration))
+	}
+	if report.Total > 0 {
+		output.Printf("   %-12s %s\n", "total", formatDuration(report.Total))
+	}
+
+	detail := ""
+	if report.AuthDetail != "" {
+		detail = " - " + report.AuthDetail
+	}
+	switch report.Auth {
+	case client.AuthAccepted:
+		output.Printf("🔑 Credential: ✅ accepted%s\n", detail)
+	case client.AuthRejected:
+		output.Printf("🔑 Credential: ❌ rejected%s\n", detail)
+	case client.AuthNone:
+		output.Printf("🔑 Credential: none configured%s\n", detail)
+	default:
+		output.Printf("🔑 Credential: ⚠️  not checked%s\n", detail)
+	}
+}
+
+// formatDuration rounds a duration for display, e.g. "12ms" or "1.4s"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func init() {
+	registryCmd.AddCommand(registryPingCmd)
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// reportReasons are the reasons the registry accepts abuse reports for
+var reportReasons = []string{"malicious", "spam", "impersonation", "other"}
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report <package>[@<version>]",
+	Short: "Report a malicious or spam package to the registry's moderators",
+	Long: `Report a package, or one version of it, to the active registry's moderators.
+
+Reports are queued for the registry's admins, who can dismiss them, yank the
+reported versions, or yank them and ban the accounts that published them. You
+can have one open report per package. Reports are only available on HTTP
+registries.
+
+Reasons: malicious, spam, impersonation, other
+
+Examples:
+  rfh report security-rulez --reason impersonation --message "Copies security-rules"
+  rfh report style-rules@2.1.0 --reason malicious --message "Rule tells agents to upload .env files"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePackageRefs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reason, _ := cmd.Flags().GetString("reason")
+		message, _ := cmd.Flags().GetString("message")
+		return runReport(args[0], reason, message)
+	},
+}
+
+// runReport implements the report command logic
+func runReport(spec, reason, message string) error {
+	name, version := spec, ""
+	if strings.Contains(spec, "@") {
+		pkgRef, err := parsePackageRef(spec)
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+		name, version = pkgRef.Name, pkgRef.Version
+	} else if err := manifest.ValidateName(spec); err != nil {
+		return fmt.Errorf("invalid package reference: %w", err)
+	}
+	if !slices.Contains(reportReasons, reason) {
+		return fmt.Errorf("--reason must be one of: %s", strings.Join(reportReasons, ", "))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	report, err := c.ReportPackage(ctx, name, version, reason, message)
+	if err != nil {
+		return fmt.Errorf("failed to report %s: %w", spec, err)
+	}
+
+	output.Printf("🚩 Reported %s for %s (report #%d)\n", spec, report.Reason, report.ID)
+	output.Printf("The registry's moderators will review it\n")
+	return nil
+}
+
+func init() {
+	reportCmd.Flags().String("reason", "", "Why the package is abusive: malicious, spam, impersonation or other")
+	reportCmd.Flags().String("message", "", "Details for the moderators")
+}
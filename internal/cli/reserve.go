@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+// reserveCmd represents the reserve command
+var reserveCmd = &cobra.Command{
+	Use:   "reserve <package>",
+	Short: "Reserve a package name before its first publish",
+	Long: `Reserve a package name on the active registry so nobody else can publish it first.
+
+Reservations expire after --days (default 30, capped by the registry). Running
+the command again extends your reservation. Publishing the package claims the
+name and ends the reservation. Reservations are only available on HTTP registries.
+
+Examples:
+  rfh reserve security-rules
+  rfh reserve security-rules --days 60
+  rfh reserve security-rules --release`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		release, _ := cmd.Flags().GetBool("release")
+		return runReserve(args[0], days, release)
+	},
+}
+
+// runReserve implements the reserve command logic
+func runReserve(name string, days int, release bool) error {
+	if err := manifest.ValidateName(name); err != nil {
+		return err
+	}
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	if release {
+		if err := c.ReleasePackage(ctx, name); err != nil {
+			return fmt.Errorf("failed to release %s: %w", name, err)
+		}
+		output.Printf("✅ Released reservation of %s\n", name)
+		return nil
+	}
+
+	reservation, err := c.ReservePackage(ctx, name, days)
+	if err != nil {
+		return fmt.Errorf("failed to reserve %s: %w", name, err)
+	}
+
+	output.Printf("🔒 Reserved %s for %s\n", reservation.Name, reservation.ReservedBy)
+	output.Printf("⏳ Expires: %s\n", reservation.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+func init() {
+	reserveCmd.Flags().Int("days", 30, "Days to hold the name")
+	reserveCmd.Flags().Bool("release", false, "Release your reservation instead")
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		release   bool
+		method    string
+		status    int
+		body      string
+		expectErr string
+	}{
+		{
+			name:   "reserved",
+			method: "POST",
+			status: http.StatusCreated,
+			body:   `{"name": "my-rules", "reserved_by": "alice", "expires_at": "2030-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "held by someone else",
+			method:    "POST",
+			status:    http.StatusConflict,
+			body:      `{"error": "Package name is reserved by bob until 2030-01-01"}`,
+			expectErr: "reserved by bob",
+		},
+		{
+			name:    "released",
+			release: true,
+			method:  "DELETE",
+			status:  http.StatusOK,
+			body:    `{"name": "my-rules", "released": true}`,
+		},
+		{
+			name:      "release without reservation",
+			release:   true,
+			method:    "DELETE",
+			status:    http.StatusNotFound,
+			body:      `{"error": "You do not hold a reservation for this name"}`,
+			expectErr: "do not hold a reservation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method || r.URL.Path != "/v1/packages/my-rules/reservation" {
+					http.NotFound(w, r)
+					return
+				}
+				if r.Method == "POST" {
+					var req map[string]int
+					if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["days"] != 45 {
+						t.Errorf("expected days 45 in request body, got %v (%v)", req, err)
+					}
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			configDir := t.TempDir()
+			t.Setenv("RFH_CONFIG", configDir)
+			configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+			if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			err := runReserve("my-rules", 45, tt.release)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	t.Run("invalid name", func(t *testing.T) {
+		if err := runReserve("Bad Name", 30, false); err == nil {
+			t.Error("expected invalid package name to be rejected")
+		}
+	})
+}
@@ -1,17 +1,41 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 
+	"rulestack/internal/client"
 	"rulestack/internal/config"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/progress"
+	"rulestack/internal/tempdir"
+	"rulestack/internal/tracing"
 )
 
 var (
 	verbose bool
+
+	// progressFormat is "text" for the normal output, or "json" to also write
+	// progress events for long operations to stderr as JSON lines
+	progressFormat string
+
+	// plainOutput drops emoji and live progress from the output. Without the
+	// flag it is on when stdout is not a terminal or NO_COLOR is set.
+	plainOutput bool
+
+	// commandContext carries the running command's trace span to registry calls
+	commandContext = context.Background()
+
+	// commandName is the running command, e.g. "rfh install", for install events
+	commandName string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,26 +46,106 @@ var rootCmd = &cobra.Command{
 discover, and install AI rules for use with tools like Claude Code, Cursor, and Windsurf.
 
 Registry for Humans - making AI rulesets accessible and shareable.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		commandContext = cmd.Context()
+		commandName = getFullCommandName(cmd)
+		if err := startProfiling(cmd); err != nil {
+			return err
+		}
+
+		switch progressFormat {
+		case "text":
+		case "json":
+			progress.Enable(os.Stderr)
+		default:
+			return fmt.Errorf("invalid --progress '%s': use text or json", progressFormat)
+		}
+		if cmd.Flags().Changed("plain") {
+			output.SetPlain(plainOutput)
+		}
+
 		// Load .env file if it exists
 		config.LoadEnvFile(".env")
 
+		trace.SpanFromContext(commandContext).SetName(getFullCommandName(cmd))
+
 		if verbose {
-			fmt.Printf("RFH version: 1.0.0\n")
+			output.Printf("RFH version: 1.0.0\n")
+			if tracing.Enabled() {
+				output.Printf("🔎 Trace ID: %s\n", tracing.TraceID(commandContext))
+			}
+		}
+
+		// Remove temp files a crashed or killed run left behind
+		if removed, err := tempdir.Sweep(tempdir.MaxAge); err == nil && removed > 0 && verbose {
+			output.Printf("🧹 Removed %d stale temp file(s)\n", removed)
 		}
 
+		// Offer the public registry on first run
+		offerDefaultRegistry(getFullCommandName(cmd))
+
 		// Check for root user and display security warning
-		if cfg, err := config.LoadCLI(); err == nil {
+		if cfg, err := loadConfig(); err == nil {
 			commandName := getFullCommandName(cmd)
 			checkAndWarnRootUser(cfg, commandName)
+			warnTokenExpiry(cfg, commandName, time.Now())
 		}
+		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx := context.Background()
+
+	// Tracing only helps diagnose slow commands, so a broken setup never blocks one
+	shutdownTracing, err := tracing.Setup(ctx, "rfh")
+	if err != nil {
+		output.Fprintf(os.Stderr, "⚠️  Tracing disabled: %v\n", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
+	ctx, span := tracing.Start(ctx, "rfh")
+	err = rootCmd.ExecuteContext(ctx)
+	finishProfiling()
+	tracing.End(span, err)
+
+	if hint := errorHint(err); hint != "" {
+		output.Fprintf(os.Stderr, "💡 %s\n", hint)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdownTracing(flushCtx)
+
+	return err
+}
+
+// errorHint suggests how to get past a registry error, or returns "" when
+// there is nothing more to say than the error itself
+func errorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, client.ErrVersionExists):
+		return "Published versions cannot be replaced; pack a new version with 'rfh pack --version <next>' and publish that"
+	case errors.Is(err, client.ErrQuotaExceeded):
+		return "The registry limits archive sizes; trim the package's files or ask the registry's operators to raise the limit"
+	case errors.Is(err, client.ErrInvalidName):
+		return "Package names use lowercase letters, digits, '-' and '_', optionally under a @scope/; rename the package in rulestack.pkg.json"
+	case errors.Is(err, client.ErrForbidden):
+		return "Check which account you are logged in as with 'rfh auth whoami'; a registry admin can grant the role this needs"
+	case errors.Is(err, client.ErrPendingReview):
+		return "The version becomes installable once a registry maintainer merges its publish pull request; publishers can wait with 'rfh publish --wait-merge'"
+	case errors.Is(err, client.ErrTimedOut):
+		return "The registry did not respond in time or the transfer stalled; check your connection with 'rfh registry ping' and try again"
+	case errors.Is(err, pkg.ErrInsufficientSpace):
+		return "Free up disk space, or set TMPDIR (TMP on Windows) to a directory on a disk with more room"
+	case errors.Is(err, client.ErrRateLimited):
+		return "The registry is rate limiting requests; wait a minute and try again"
+	}
+	return ""
 }
 
 func init() {
@@ -49,16 +153,52 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "never prompt; use flags, environment variables and defaults (also RFH_NON_INTERACTIVE=1 or CI=true)")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmation prompts")
+	rootCmd.PersistentFlags().StringVar(&progressFormat, "progress", "text", "progress output: text, or json for JSON-line progress events on stderr")
+	rootCmd.PersistentFlags().BoolVar(&profileReport, "profile", false, "report where the command's time went (config load, registry calls, clone/pull, download, extraction, manifest writes) on stderr")
+	rootCmd.PersistentFlags().StringVar(&cpuProfilePath, "cpu-profile", "", "write a pprof CPU profile of the command to this file")
+	rootCmd.PersistentFlags().StringVar(&execTracePath, "exec-trace", "", "write a runtime execution trace of the command to this file (view with go tool trace)")
+	rootCmd.PersistentFlags().BoolVar(&plainOutput, "plain", false, "plain output without emoji or live progress, for CI logs and screen readers (default when stdout is not a terminal or NO_COLOR is set; --plain=false to keep them)")
 
 	// Add subcommands
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(upgradeProjectCmd)
+	rootCmd.AddCommand(manifestCmd)
 	rootCmd.AddCommand(packCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(stagingCmd)
 	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(reserveCmd)
+	rootCmd.AddCommand(shareCmd)
+	rootCmd.AddCommand(deprecateCmd)
+	rootCmd.AddCommand(reportCmd)
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(browseCmd)
+	rootCmd.AddCommand(authorCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(migrateDepsCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(policyCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(previewUpdateCmd)
+	rootCmd.AddCommand(trustCmd)
+	rootCmd.AddCommand(integrateCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+	rootCmd.AddCommand(devCmd)
+	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(registryCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(indexCmd)
 	rootCmd.AddCommand(authCmd)
 }
 
@@ -70,7 +210,7 @@ func initConfig() {
 // Helper function to handle errors
 func checkErr(err error) {
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		output.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -1,20 +1,24 @@
 package cli
 
 import (
-	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"rulestack/internal/client"
 	"rulestack/internal/config"
+	"rulestack/internal/output"
 )
 
 var (
-	searchTag    string
-	searchTarget string
-	searchLimit  int
+	searchTag      string
+	searchTarget   string
+	searchLimit    int
+	searchOffline  bool
+	searchPreview  bool
+	searchOfficial bool
 )
 
 // searchCmd represents the search command
@@ -24,13 +28,21 @@ var searchCmd = &cobra.Command{
 	Long: `Search for rulesets in the configured registry.
 
 You can filter results by tags and targets to find rulesets that match
-your specific needs.
+your specific needs. With --preview, the top result's manifest and the
+first lines of each of its rule files are shown as well.
+
+Packages the registry's admins marked official, or whose publishing
+organization they verified, are labelled [official] and [verified: <org>].
+--official shows only official packages, to steer clear of impostors.
 
 Examples:
   rfh search security
   rfh search "secure coding" --tag=javascript
   rfh search linting --target=cursor
-  rfh search react --limit=10`,
+  rfh search react --limit=10
+  rfh search security --offline
+  rfh search security --preview
+  rfh search security --official`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runSearch(args[0])
@@ -38,8 +50,15 @@ Examples:
 }
 
 func runSearch(query string) error {
+	if searchPreview && searchOffline {
+		return fmt.Errorf("--preview cannot be used with --offline")
+	}
+	if searchOfficial && searchOffline {
+		return fmt.Errorf("--official cannot be used with --offline")
+	}
+
 	// Get registry configuration
-	cfg, err := config.LoadCLI()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -56,81 +75,186 @@ func runSearch(query string) error {
 		return fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
 	}
 
+	// The registry's default target applies unless --target is given
+	target := searchTarget
+	if target == "" {
+		target = reg.Defaults.Target
+	}
+
 	if verbose {
-		fmt.Printf("🔍 Searching for: %s\n", query)
-		fmt.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+		output.Printf("🔍 Searching for: %s\n", query)
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
 		if searchTag != "" {
-			fmt.Printf("🏷️  Tag filter: %s\n", searchTag)
+			output.Printf("🏷️  Tag filter: %s\n", searchTag)
 		}
-		if searchTarget != "" {
-			fmt.Printf("🎯 Target filter: %s\n", searchTarget)
+		if target != "" {
+			output.Printf("🎯 Target filter: %s\n", target)
 		}
 	}
 
-	// Create client using new factory
-	c, err := client.GetClient(cfg, verbose)
-	if err != nil {
-		return err
-	}
-
-	// Search packages using new interface
-	ctx, cancel := client.WithTimeout(context.Background())
-	defer cancel()
-	
 	opts := client.SearchOptions{
-		Query:  query,
-		Tag:    searchTag,
-		Target: searchTarget,
-		Limit:  searchLimit,
+		Query:    query,
+		Tag:      searchTag,
+		Target:   target,
+		Official: searchOfficial,
+		Limit:    searchLimit,
 	}
-	
-	packages, err := c.SearchPackages(ctx, opts)
+
+	packages, err := searchPackages(cfg, opts)
 	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		return err
 	}
 
 	if len(packages) == 0 {
-		fmt.Printf("No rulesets found matching '%s'\n", query)
-		if searchTag != "" || searchTarget != "" {
-			fmt.Printf("Try removing filters or using different search terms.\n")
+		output.Printf("No rulesets found matching '%s'\n", query)
+		if searchTag != "" || target != "" || searchOfficial {
+			output.Printf("Try removing filters or using different search terms.\n")
 		}
 		return nil
 	}
 
 	// Display results
-	fmt.Printf("📋 Found %d ruleset(s):\n\n", len(packages))
+	output.Printf("📋 Found %d ruleset(s):\n\n", len(packages))
 
 	for _, pkg := range packages {
 		name := pkg.Name
 		version := pkg.Latest
 		description := pkg.Description
 
-		fmt.Printf("📦 %s@%s\n", name, version)
+		if badges := packageBadges(pkg); badges != "" {
+			output.Printf("📦 %s@%s %s\n", name, version, badges)
+		} else {
+			output.Printf("📦 %s@%s\n", name, version)
+		}
 
 		if description != "" {
-			fmt.Printf("   %s\n", description)
+			output.Printf("   %s\n", description)
 		}
 
 		// Display versions
 		if len(pkg.Versions) > 1 {
-			fmt.Printf("   📋 Versions: %s\n", strings.Join(pkg.Versions, ", "))
+			output.Printf("   📋 Versions: %s\n", strings.Join(pkg.Versions, ", "))
 		}
 
 		// Display tags
 		if len(pkg.Tags) > 0 {
-			fmt.Printf("   🏷️  Tags: %s\n", strings.Join(pkg.Tags, ", "))
+			output.Printf("   🏷️  Tags: %s\n", strings.Join(pkg.Tags, ", "))
+		}
+
+		if pkg.License != "" {
+			output.Printf("   ⚖️  License: %s\n", pkg.License)
 		}
 
-		fmt.Printf("\n")
+		if len(pkg.Dependencies) > 0 {
+			output.Printf("   🔗 Depends on: %s\n", formatDependencies(pkg.Dependencies))
+		}
+
+		output.Printf("\n")
+	}
+
+	if searchPreview {
+		printSearchPreview(cfg, packages[0])
 	}
 
-	fmt.Printf("💡 Install with: rfh add <package-name>@<version>\n")
+	output.Printf("💡 Install with: rfh add <package-name>@<version>\n")
 
 	return nil
 }
 
+// packageBadges labels the badges registry admins gave a package, e.g.
+// "[official] [verified: acme]", or returns "" when it has none
+func packageBadges(pkg client.Package) string {
+	var badges []string
+	if pkg.Official {
+		badges = append(badges, "[official]")
+	}
+	if pkg.VerifiedOrg != "" {
+		badges = append(badges, fmt.Sprintf("[verified: %s]", pkg.VerifiedOrg))
+	}
+	return strings.Join(badges, " ")
+}
+
+// formatDependencies lists dependencies by name, e.g. "base-rules@^1.2, logging-rules@2.0.0"
+func formatDependencies(dependencies map[string]string) string {
+	refs := make([]string, 0, len(dependencies))
+	for name, versionRange := range dependencies {
+		refs = append(refs, name+"@"+versionRange)
+	}
+	sort.Strings(refs)
+	return strings.Join(refs, ", ")
+}
+
+// searchPackages searches the registry, or the local index with --offline
+func searchPackages(cfg config.CLIConfig, opts client.SearchOptions) ([]client.Package, error) {
+	if searchOffline {
+		snapshot, err := loadSyncedSnapshot()
+		if err != nil {
+			return nil, err
+		}
+		return snapshot.Search(opts), nil
+	}
+
+	// Create client using new factory
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search packages using new interface
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	packages, err := c.SearchPackages(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	return packages, nil
+}
+
+// printSearchPreview shows the rule file excerpts of a search result. A preview
+// that cannot be fetched is reported but does not fail the search.
+func printSearchPreview(cfg config.CLIConfig, result client.Package) {
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		output.Printf("⚠️  Preview unavailable: %v\n\n", err)
+		return
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	preview, err := c.GetPackagePreview(ctx, result.Name, result.Latest)
+	if err != nil {
+		output.Printf("⚠️  Preview unavailable: %v\n\n", err)
+		return
+	}
+
+	output.Printf("🔎 Preview of %s@%s:\n\n", preview.Name, preview.Version)
+	if len(preview.Targets) > 0 {
+		output.Printf("   🎯 Targets: %s\n\n", strings.Join(preview.Targets, ", "))
+	}
+	if len(preview.Files) == 0 {
+		output.Printf("   (no rule files)\n\n")
+		return
+	}
+	for _, file := range preview.Files {
+		output.Printf("   📄 %s\n", file.Path)
+		for _, line := range strings.Split(file.Excerpt, "\n") {
+			output.Printf("      %s\n", line)
+		}
+		if file.Truncated {
+			output.Printf("      …\n")
+		}
+		output.Printf("\n")
+	}
+}
+
 func init() {
 	searchCmd.Flags().StringVar(&searchTag, "tag", "", "filter by tag")
 	searchCmd.Flags().StringVar(&searchTarget, "target", "", "filter by target (cursor, claude-code, etc.)")
 	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "limit number of results")
+	searchCmd.Flags().BoolVar(&searchOffline, "offline", false, "search the local index instead of the registry (see 'rfh index sync')")
+	searchCmd.Flags().BoolVar(&searchPreview, "preview", false, "show the top result's manifest and the first lines of its rule files")
+	searchCmd.Flags().BoolVar(&searchOfficial, "official", false, "only show packages the registry's admins marked official")
 }
@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+var (
+	serveLocal   bool
+	serveSocket  string
+	serveDataDir string
+	servePort    string
+)
+
+// serveCmd runs a registry, or rfh as a long-lived local server for editor extensions
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a private registry, or serve rfh operations to editor extensions",
+	Long: `Run a private registry from the rfh binary, or with --local, serve rfh
+operations to editor extensions.
+
+Without --local, rfh runs the registry API server on a SQLite database and
+local archive storage, so a small team can host a registry with one command.
+Everything lives in the data directory (~/.rfh/registry by default). The first
+run creates it, with generated secrets in its config.toml, and a 'root' user
+whose password is printed once. config.toml takes any API server setting, and
+the API server's environment variables override it.
+
+With --local, rfh runs as a long-lived process that editor extensions drive
+over a unix socket, instead of spawning rfh for every operation. The server
+works on the project it is started in, and uses the same registries,
+credentials and cache as the rfh command.
+
+Requests are JSON-RPC 2.0 calls, POSTed over HTTP to /rpc on the socket:
+
+  search    {"query", "tag", "target", "limit"}  Search the active registry
+  install   {"packages": ["name@version", ...]}  Add packages; none installs rulestack.json
+  list      {}                                   Installed packages, from rulestack.json's lock file
+  outdated  {"offline"}                          Dependencies with newer or deprecated versions
+
+The socket is created at .rulestack/rfh.sock in the project (or --socket),
+readable only by the current user. Calls run one at a time, never prompt, and
+write the usual command output to the server's stdout.
+
+Examples:
+  rfh serve
+  rfh serve --data-dir /srv/rulestack --port 9000
+  rfh serve --local
+  rfh serve --local --socket /tmp/rfh.sock
+  curl --unix-socket .rulestack/rfh.sock http://rfh/rpc \
+    -d '{"jsonrpc":"2.0","id":1,"method":"outdated","params":{}}'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !serveLocal {
+			flags := map[string]string{}
+			if cmd.Flags().Changed("port") {
+				flags["port"] = servePort
+			}
+			return runServeRegistry(serveDataDir, flags)
+		}
+		return runServe(serveSocket)
+	},
+}
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCallFailed     = -32000 // The operation itself failed
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// invalidParamsError marks a call that failed on its parameters rather than in the operation
+type invalidParamsError struct{ err error }
+
+func (e invalidParamsError) Error() string { return e.err.Error() }
+
+// localServer answers JSON-RPC calls for one project
+type localServer struct {
+	projectRoot string
+
+	// The commands the calls run share package state, so calls run one at a time
+	mu sync.Mutex
+}
+
+// installedPackage is an entry in the lock file, as returned by 'list'
+type installedPackage struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Registry    string `json:"registry,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Quarantined bool   `json:"quarantined,omitempty"`
+
+	dir string // Where the package is unpacked
+}
+
+func runServe(socketPath string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+	if socketPath == "" {
+		socketPath = filepath.Join(projectRoot, ".rulestack", "rfh.sock")
+	}
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+
+	// Nobody is at a terminal to answer prompts
+	nonInteractive = true
+
+	ctx, stop := signal.NotifyContext(commandContext, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Handler: newLocalServer(projectRoot)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	output.Printf("🚀 Serving %s on %s (Ctrl+C to stop)\n", projectRoot, socketPath)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	output.Printf("\n👋 Server stopped\n")
+	return nil
+}
+
+// listenUnix listens on a unix socket only the current user can use. A socket
+// left behind by a server that is no longer running is replaced.
+func listenUnix(socketPath string) (net.Listener, error) {
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("an rfh server is already listening on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+func newLocalServer(projectRoot string) http.Handler {
+	s := &localServer{projectRoot: projectRoot}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /rpc", s.handleRPC)
+	return mux
+}
+
+func (s *localServer) handleRPC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPC(w, rpcResponse{Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &rpcError{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"}})
+		return
+	}
+
+	method, ok := s.methods()[req.Method]
+	if !ok {
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method '%s'", req.Method)}})
+		return
+	}
+
+	s.mu.Lock()
+	result, err := method(req.Params)
+	s.mu.Unlock()
+
+	if err != nil {
+		code := rpcCallFailed
+		var paramsErr invalidParamsError
+		if errors.As(err, &paramsErr) {
+			code = rpcInvalidParams
+		}
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &rpcError{Code: code, Message: err.Error()}})
+		return
+	}
+	writeRPC(w, rpcResponse{ID: req.ID, Result: result})
+}
+
+func writeRPC(w http.ResponseWriter, resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// methods maps JSON-RPC method names to their implementations
+func (s *localServer) methods() map[string]func(json.RawMessage) (any, error) {
+	return map[string]func(json.RawMessage) (any, error){
+		"search":   s.search,
+		"install":  s.install,
+		"list":     s.list,
+		"outdated": s.outdated,
+	}
+}
+
+// decodeParams decodes a call's parameters; they may be omitted
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParamsError{fmt.Errorf("invalid params: %w", err)}
+	}
+	return nil
+}
+
+func (s *localServer) search(params json.RawMessage) (any, error) {
+	var args struct {
+		Query  string `json:"query"`
+		Tag    string `json:"tag"`
+		Target string `json:"target"`
+		Limit  int    `json:"limit"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	opts := client.SearchOptions{Query: args.Query, Tag: args.Tag, Target: args.Target, Limit: args.Limit}
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+
+	// Configuration is reloaded for every call, so logins and registry changes apply
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	_, registry, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Target == "" {
+		opts.Target = registry.Defaults.Target
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	packages, err := c.SearchPackages(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if packages == nil {
+		packages = []client.Package{}
+	}
+	return packages, nil
+}
+
+func (s *localServer) install(params json.RawMessage) (any, error) {
+	var args struct {
+		Packages []string `json:"packages"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	if len(args.Packages) == 0 {
+		if err := runInstall(); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range args.Packages {
+		if err := runAdd(spec, "", false); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", spec, err)
+		}
+	}
+
+	return s.list(nil)
+}
+
+func (s *localServer) list(params json.RawMessage) (any, error) {
+	return installedPackages(s.projectRoot)
+}
+
+// installedPackages lists the packages in a project's lock file, sorted by name
+func installedPackages(projectRoot string) ([]installedPackage, error) {
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	installed := make([]installedPackage, 0, len(lockManifest.Packages))
+	for name, entry := range lockManifest.Packages {
+		installed = append(installed, installedPackage{
+			Name:        name,
+			Version:     entry.Version,
+			Registry:    entry.Registry,
+			Source:      entry.Source,
+			Quarantined: entry.Quarantined,
+			dir:         installedPackageDir(filepath.Join(projectRoot, ".rulestack"), name, entry),
+		})
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		return installed[i].Name < installed[j].Name
+	})
+	return installed, nil
+}
+
+func (s *localServer) outdated(params json.RawMessage) (any, error) {
+	var args struct {
+		Offline bool `json:"offline"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	outdated, checked, err := projectOutdated(s.projectRoot, args.Offline)
+	if err != nil {
+		return nil, err
+	}
+	if outdated == nil {
+		outdated = []OutdatedPackage{}
+	}
+	return map[string]any{"checked": checked, "outdated": outdated}, nil
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveLocal, "local", false, "serve over a unix socket for editor extensions")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "socket path (default: .rulestack/rfh.sock in the project)")
+	serveCmd.Flags().StringVar(&serveDataDir, "data-dir", "", "registry data directory (default: ~/.rfh/registry)")
+	serveCmd.Flags().StringVar(&servePort, "port", "8080", "port the registry listens on")
+}
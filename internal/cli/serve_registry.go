@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"rulestack/internal/api"
+	"rulestack/internal/config"
+	"rulestack/internal/db"
+	"rulestack/internal/output"
+)
+
+// registryConfigFile holds the settings of a registry served by 'rfh serve', in
+// the API server's config file format
+const registryConfigFile = "config.toml"
+
+func runServeRegistry(dataDir string, flags map[string]string) error {
+	if dataDir == "" {
+		configDir, err := config.ConfigDir()
+		if err != nil {
+			return fmt.Errorf("failed to find config directory: %w", err)
+		}
+		dataDir = filepath.Join(configDir, "registry")
+	}
+
+	created, err := setupRegistryDataDir(dataDir)
+	if err != nil {
+		return err
+	}
+	if created {
+		output.Printf("🆕 Created registry data directory %s\n", dataDir)
+	}
+
+	cfg, err := registryServerConfig(dataDir, flags)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Connect(cfg.DBURL)
+	if err != nil {
+		return fmt.Errorf("failed to open registry database: %w", err)
+	}
+	defer database.Close()
+
+	password, err := randomSecret(12)
+	if err != nil {
+		return err
+	}
+	createdRoot, err := api.EnsureRootUser(database, password)
+	if err != nil {
+		return fmt.Errorf("failed to create root user: %w", err)
+	}
+	if createdRoot {
+		output.Printf("🔑 Created user 'root' with password %s\n", password)
+		output.Printf("   It is only shown once; use it to create accounts for your team\n")
+	}
+
+	url := "http://localhost:" + cfg.APIPort
+	output.Printf("🚀 Registry serving on %s (Ctrl+C to stop)\n", url)
+	output.Printf("   Data: %s\n", dataDir)
+	output.Printf("   Use it with: rfh registry add local %s\n", url)
+
+	return api.Serve(database, cfg)
+}
+
+// setupRegistryDataDir creates the data directory and, on first run, a config
+// file with generated secrets. It reports whether the config file was created.
+func setupRegistryDataDir(dataDir string) (bool, error) {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	configPath := filepath.Join(dataDir, registryConfigFile)
+	if _, err := os.Stat(configPath); err == nil {
+		return false, nil
+	}
+
+	tokenSalt, err := randomSecret(32)
+	if err != nil {
+		return false, err
+	}
+	jwtSecret, err := randomSecret(32)
+	if err != nil {
+		return false, err
+	}
+
+	content := fmt.Sprintf(`# Settings for the registry served by 'rfh serve'. Any API server setting
+# can be added here; see 'rulestack-api --help'. The database and archives
+# always live in this directory.
+token_salt = %q
+jwt_secret = %q
+`, tokenSalt, jwtSecret)
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		return false, fmt.Errorf("failed to write registry config: %w", err)
+	}
+	return true, nil
+}
+
+// registryServerConfig resolves the API server configuration for a data
+// directory: a SQLite database and archive storage inside it, and the data
+// directory's config file under the environment and flags
+func registryServerConfig(dataDir string, flags map[string]string) (config.Config, error) {
+	settings := map[string]string{
+		"database-url": db.SQLiteScheme + filepath.Join(dataDir, "registry.db"),
+		"storage-path": filepath.Join(dataDir, "storage"),
+	}
+	for key, value := range flags {
+		settings[key] = value
+	}
+
+	values, err := config.Resolve(settings, filepath.Join(dataDir, registryConfigFile))
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg, err := values.Config()
+	if err != nil {
+		return config.Config{}, fmt.Errorf("invalid registry configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// randomSecret returns n random bytes, hex encoded
+func randomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
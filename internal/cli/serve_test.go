@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// callRPC posts a JSON-RPC request to the server and decodes the response
+func callRPC(t *testing.T, server *httptest.Server, body string) rpcResponse {
+	t.Helper()
+	resp, err := server.Client().Post(server.URL+"/rpc", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded struct {
+		rpcResponse
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	decoded.rpcResponse.Result = decoded.Result
+	return decoded.rpcResponse
+}
+
+func TestLocalServerRPC(t *testing.T) {
+	projectRoot := t.TempDir()
+	lock := `{"version": "1.0.0", "packages": {
+		"security-rules": {"version": "1.2.0", "sha256": "abc", "registry": "corp"},
+		"api-rules": {"version": "0.1.0", "sha256": "def", "quarantined": true}
+	}}`
+	if err := os.WriteFile(filepath.Join(projectRoot, "rulestack.lock.json"), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(newLocalServer(projectRoot))
+	defer server.Close()
+
+	t.Run("list", func(t *testing.T) {
+		resp := callRPC(t, server, `{"jsonrpc": "2.0", "id": 1, "method": "list"}`)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %+v", resp.Error)
+		}
+		if string(resp.ID) != "1" {
+			t.Errorf("id = %s, want 1", resp.ID)
+		}
+
+		var installed []installedPackage
+		if err := json.Unmarshal(resp.Result.(json.RawMessage), &installed); err != nil {
+			t.Fatal(err)
+		}
+		if len(installed) != 2 || installed[0].Name != "api-rules" || !installed[0].Quarantined || installed[1].Registry != "corp" {
+			t.Errorf("list = %+v", installed)
+		}
+	})
+
+	errorTests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"malformed JSON", `{"jsonrpc": `, rpcParseError},
+		{"missing method", `{"jsonrpc": "2.0", "id": 2}`, rpcInvalidRequest},
+		{"unknown method", `{"jsonrpc": "2.0", "id": 3, "method": "publish"}`, rpcMethodNotFound},
+		{"invalid params", `{"jsonrpc": "2.0", "id": 4, "method": "outdated", "params": {"offline": "yes"}}`, rpcInvalidParams},
+		{"failed call", `{"jsonrpc": "2.0", "id": 5, "method": "outdated"}`, rpcCallFailed},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callRPC(t, server, tt.body)
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("error = %+v, want code %d", resp.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestListenUnixReplacesStaleSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "rfh.sock")
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A second server must not take over a live socket
+	if _, err := listenUnix(socketPath); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("expected an already listening error, got %v", err)
+	}
+
+	// Leave the socket file behind, as a killed server would
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, err = listenUnix(socketPath)
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	defer listener.Close()
+
+	server := &http.Server{Handler: newLocalServer(t.TempDir())}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	resp, err := client.Post("http://rfh/rpc", "application/json", strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "list"}`))
+	if err != nil {
+		t.Fatalf("request over socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/output"
+)
+
+// shareCmd represents the share command
+var shareCmd = &cobra.Command{
+	Use:   "share <package>@<version>",
+	Short: "Create a time-limited download link for a package version",
+	Long: `Create a link that downloads a package version from the active registry,
+for someone without a registry account.
+
+The link works until it expires (--expires, default 24h, at most 30 days on the
+registry) and cannot be revoked before then, short of rotating the registry's
+JWT secret. Only published versions can be shared, by the version's publisher or
+an admin. Share links are only available on HTTP registries.
+
+Examples:
+  rfh share security-rules@1.2.0
+  rfh share security-rules@1.2.0 --expires 72h`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expires, _ := cmd.Flags().GetDuration("expires")
+		return runShare(args[0], expires)
+	},
+}
+
+// runShare implements the share command logic
+func runShare(spec string, expires time.Duration) error {
+	pkgRef, err := parsePackageRef(spec)
+	if err != nil {
+		return err
+	}
+	if expires < time.Minute {
+		return fmt.Errorf("--expires must be at least 1m")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName, reg, err := getCurrentRegistry(cfg)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("🌐 Registry: %s (%s)\n", registryName, reg.URL)
+	}
+
+	c, err := client.GetClient(cfg, verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := client.WithTimeout(commandContext)
+	defer cancel()
+
+	link, err := c.ShareVersion(ctx, pkgRef.Name, pkgRef.Version, expires)
+	if err != nil {
+		return fmt.Errorf("failed to share %s@%s: %w", pkgRef.Name, pkgRef.Version, err)
+	}
+
+	output.Printf("🔗 %s\n", link.URL)
+	output.Printf("⏳ Expires: %s\n", link.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	output.Printf("   Anyone with the link can download %s@%s until then\n", link.Name, link.Version)
+	return nil
+}
+
+func init() {
+	shareCmd.Flags().Duration("expires", 24*time.Hour, "How long the link works")
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunShare(t *testing.T) {
+	var expiresIn int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/packages/my-rules/versions/1.0.0/share" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			ExpiresIn int64 `json:"expires_in"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		expiresIn = req.ExpiresIn
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name": "my-rules", "version": "1.0.0", "path": "/v1/shared/my-rules/1.0.0?expires=1&signature=ab", "expires_at": "2030-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"" + server.URL + "\"\ntype = \"remote-http\"\njwt_token = \"token\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	if err := runShare("my-rules@1.0.0", 72*time.Hour); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expiresIn != 72*3600 {
+		t.Errorf("expected expires_in of 72h, got %d seconds", expiresIn)
+	}
+
+	if err := runShare("my-rules@2.0.0", time.Hour); err == nil || !strings.Contains(err.Error(), "version not found") {
+		t.Errorf("expected version not found, got %v", err)
+	}
+	if err := runShare("my-rules@1.0.0", time.Second); err == nil {
+		t.Error("expected an error for a link shorter than a minute")
+	}
+}
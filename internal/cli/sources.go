@@ -0,0 +1,468 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"rulestack/internal/blobcache"
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+const (
+	fileSourcePrefix = "file:"
+	gitSourcePrefix  = "git+"
+
+	// urlSourceHash introduces the SHA256 an archive URL dependency is pinned to,
+	// as in https://example.com/rules-1.0.0.tgz#sha256=<hex>
+	urlSourceHash = "sha256="
+)
+
+// isSourceSpec reports whether a dependency version is a local path, git URL or
+// archive URL rather than a registry version
+func isSourceSpec(spec string) bool {
+	return strings.HasPrefix(spec, fileSourcePrefix) || strings.HasPrefix(spec, gitSourcePrefix) || isURLSource(spec)
+}
+
+// isURLSource reports whether a dependency version is the URL of an archive
+func isURLSource(spec string) bool {
+	return strings.HasPrefix(spec, "https://") || strings.HasPrefix(spec, "http://")
+}
+
+// parseURLSource splits "https://host/rules.tgz#sha256=<hex>" into the archive
+// URL and the SHA256 it must have
+func parseURLSource(spec string) (string, string, error) {
+	archiveURL, fragment, _ := strings.Cut(spec, "#")
+	sha256, ok := strings.CutPrefix(fragment, urlSourceHash)
+	if !ok {
+		return "", "", fmt.Errorf("archive URL '%s' is not pinned: append #sha256=<hash>, or pass --sha256 to 'rfh add'", spec)
+	}
+	if decoded, err := hex.DecodeString(sha256); err != nil || len(decoded) != 32 {
+		return "", "", fmt.Errorf("invalid sha256 '%s' for %s", sha256, archiveURL)
+	}
+	return archiveURL, strings.ToLower(sha256), nil
+}
+
+// needsRegistry reports whether any dependency has to be fetched from a registry
+func needsRegistry(dependencies map[string]string) bool {
+	for name, spec := range dependencies {
+		if !isSourceSpec(spec) && !isBundledCore(name, spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGitSource splits "git+https://host/org/repo#ref" into repository URL and ref
+func parseGitSource(spec string) (string, string, error) {
+	repoURL, ref, _ := strings.Cut(strings.TrimPrefix(spec, gitSourcePrefix), "#")
+	if repoURL == "" {
+		return "", "", fmt.Errorf("invalid git dependency '%s': use git+https://host/org/repo#ref", spec)
+	}
+	return repoURL, ref, nil
+}
+
+// installSourcePackage packs a file: or git+ dependency on the fly, or downloads
+// an archive URL dependency, and installs it into .rulestack, returning the lock
+// entry describing what was installed
+func installSourcePackage(projectRoot, name, spec string) (*LockPackageEntry, error) {
+	if isURLSource(spec) {
+		return installURLPackage(projectRoot, name, spec)
+	}
+
+	entry := &LockPackageEntry{Source: spec}
+
+	var sourceDir string
+	switch {
+	case strings.HasPrefix(spec, fileSourcePrefix):
+		sourceDir = strings.TrimPrefix(spec, fileSourcePrefix)
+		if !filepath.IsAbs(sourceDir) {
+			sourceDir = filepath.Join(projectRoot, sourceDir)
+		}
+	case strings.HasPrefix(spec, gitSourcePrefix):
+		repoURL, ref, err := parseGitSource(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		cloneDir, err := tempdir.MkdirTemp("git-source-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(cloneDir)
+
+		ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+		defer cancel()
+
+		commit, err := client.FetchGitSource(ctx, repoURL, ref, cloneDir, gitTokenForURL(repoURL), verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", repoURL, err)
+		}
+		entry.Commit = commit
+		sourceDir = cloneDir
+	default:
+		return nil, fmt.Errorf("unsupported dependency source '%s'", spec)
+	}
+
+	packageManifest, err := loadSourceManifest(sourceDir, name)
+	if err != nil {
+		return nil, err
+	}
+	entry.Version = packageManifest.Version
+
+	if err := checkCompatibility(projectRoot, name, packageManifest.Version, packageManifest.Requires); err != nil {
+		return nil, err
+	}
+	if err := checkLicensePolicy(projectRoot, name, packageManifest.Version, packageManifest.License); err != nil {
+		return nil, err
+	}
+
+	// Stage the manifest's files so the archive matches what a registry would serve
+	stageDir, err := tempdir.MkdirTemp("source-stage-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	if err := stageSourceFiles(sourceDir, stageDir, packageManifest); err != nil {
+		return nil, err
+	}
+
+	archivePath := stageDir + ".tgz"
+	if _, err := pkg.PackFromDirectory(stageDir, archivePath); err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", name, err)
+	}
+	defer os.Remove(archivePath)
+
+	packageDir := packageInstallDir(projectRoot, fmt.Sprintf("%s.%s", name, packageManifest.Version))
+	if err := os.RemoveAll(packageDir); err != nil {
+		return nil, fmt.Errorf("failed to clear previous install: %w", err)
+	}
+
+	extracted, err := unpackPackage(archivePath, packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract package: %w", err)
+	}
+
+	contentHash, err := hashDirectoryContents(packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash installed package: %w", err)
+	}
+	entry.SHA256 = contentHash
+
+	entry.Files = extracted.Hashes()
+
+	return entry, nil
+}
+
+// installURLPackage downloads an archive URL dependency, checks it against the
+// SHA256 it is pinned to, and installs it like a registry package
+func installURLPackage(projectRoot, name, spec string) (*LockPackageEntry, error) {
+	archivePath, packageManifest, err := fetchURLArchive(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archivePath)
+
+	if packageManifest.Name != name {
+		return nil, fmt.Errorf("archive at %s contains package '%s', not '%s'", spec, packageManifest.Name, name)
+	}
+	if err := checkCompatibility(projectRoot, name, packageManifest.Version, packageManifest.Requires); err != nil {
+		return nil, err
+	}
+	if err := checkLicensePolicy(projectRoot, name, packageManifest.Version, packageManifest.License); err != nil {
+		return nil, err
+	}
+
+	_, sha256, _ := parseURLSource(spec)
+	verified, err := checkArchive(archivePath, "", sha256)
+	if err != nil {
+		return nil, fmt.Errorf("archive from %s failed its integrity check: %w", spec, err)
+	}
+
+	packageDir := packageInstallDir(projectRoot, fmt.Sprintf("%s.%s", name, packageManifest.Version))
+	if err := os.RemoveAll(packageDir); err != nil {
+		return nil, fmt.Errorf("failed to clear previous install: %w", err)
+	}
+
+	extracted, err := unpackPackage(archivePath, packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract package: %w", err)
+	}
+
+	return &LockPackageEntry{
+		Version:   packageManifest.Version,
+		SHA256:    sha256,
+		Integrity: verified,
+		Source:    spec,
+		Files:     extracted.Hashes(),
+	}, nil
+}
+
+// fetchURLArchive downloads the archive of an archive URL spec, through the blob
+// cache, to a temp file and reads its manifest. The caller removes the file.
+func fetchURLArchive(spec string) (string, *manifest.PackageManifest, error) {
+	archiveURL, sha256, err := parseURLSource(spec)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tempFile, err := tempdir.CreateTemp("url-*.tgz")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFile.Close()
+
+	ctx, cancel := client.WithCustomTimeout(commandContext, 5*client.DefaultTimeout)
+	defer cancel()
+
+	if err := blobcache.Fetch(ctx, client.URLArchive{URL: archiveURL}, sha256, tempFile.Name()); err != nil {
+		os.Remove(tempFile.Name())
+		return "", nil, fmt.Errorf("failed to download %s: %w", archiveURL, err)
+	}
+
+	packageManifest, err := archiveManifest(tempFile.Name())
+	if err == nil && (packageManifest.Name == "" || packageManifest.Version == "") {
+		err = fmt.Errorf("archive at %s has no package manifest naming it", archiveURL)
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", nil, err
+	}
+
+	return tempFile.Name(), packageManifest, nil
+}
+
+// loadSourceManifest finds the package manifest for name in a source directory
+func loadSourceManifest(sourceDir, name string) (*manifest.PackageManifest, error) {
+	manifestPath := packageManifestPath(sourceDir)
+	manifests, err := manifest.LoadPackageManifests(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package manifest from %s: %w", sourceDir, err)
+	}
+
+	for i := range manifests {
+		if manifests[i].Name == name {
+			return &manifests[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("package '%s' not found in %s", name, manifestPath)
+}
+
+// stageSourceFiles copies the manifest's files and a single-package manifest into stageDir.
+// Sources can be untrusted git checkouts, so symlinks are never followed: a link
+// such as rules/x.md -> ~/.ssh/id_rsa would otherwise be installed into the project.
+func stageSourceFiles(sourceDir, stageDir string, packageManifest *manifest.PackageManifest) error {
+	fsys := os.DirFS(sourceDir)
+	copied := 0
+
+	root, err := filepath.EvalSymlinks(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", sourceDir, err)
+	}
+
+	patterns := packageManifest.Files
+	if packageManifest.Tests != "" {
+		// Ship rule test fixtures so registries can run them on publish
+		patterns = append(append([]string{}, patterns...), path.Join(filepath.ToSlash(packageManifest.Tests), "*.json"))
+	}
+
+	for _, pattern := range patterns {
+		matches, err := doublestar.Glob(fsys, filepath.ToSlash(pattern))
+		if err != nil {
+			return fmt.Errorf("failed to match pattern %s: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			src := filepath.Join(sourceDir, filepath.FromSlash(match))
+			if info, err := os.Lstat(src); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			// A symlinked directory higher up the path can still lead outside the source
+			resolved, err := filepath.EvalSymlinks(src)
+			if err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			if err := copyFile(src, filepath.Join(stageDir, filepath.FromSlash(match))); err != nil {
+				return fmt.Errorf("failed to stage %s: %w", match, err)
+			}
+			copied++
+		}
+	}
+
+	if copied == 0 && !packageManifest.IsMeta() {
+		return fmt.Errorf("no files in %s matched the manifest patterns", sourceDir)
+	}
+
+	return manifest.SaveSinglePackageManifest(filepath.Join(stageDir, "rulestack.json"), packageManifest)
+}
+
+// hashDirectoryContents returns a SHA256 over the relative paths and contents of all
+// files in dir, independent of timestamps and archive encoding
+func hashDirectoryContents(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	hasher := sha256.New()
+	for _, relPath := range files {
+		file, err := os.Open(filepath.Join(dir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hasher, "%s\x00", relPath)
+		_, err = io.Copy(hasher, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+		hasher.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// gitTokenForURL returns the git token of a Git registry on the same host, if any.
+// Tokens are only sent over HTTPS.
+func gitTokenForURL(repoURL string) string {
+	target, err := url.Parse(repoURL)
+	if err != nil || target.Scheme != "https" {
+		return ""
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return ""
+	}
+
+	for _, registry := range cfg.Registries {
+		if registry.GetEffectiveType() != config.RegistryTypeGit {
+			continue
+		}
+		token := registry.AuthToken()
+		if token == "" {
+			continue
+		}
+		if registryURL, err := url.Parse(registry.URL); err == nil && registryURL.Scheme == "https" && strings.EqualFold(registryURL.Host, target.Host) {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// runAddURL adds the package in the archive at archiveURL, named by the archive's
+// own manifest, as a dependency pinned to the archive's SHA256
+func runAddURL(archiveURL, sha256, alias string, review bool) error {
+	spec := archiveURL
+	if sha256 != "" {
+		if strings.Contains(archiveURL, "#") {
+			return fmt.Errorf("pass the hash either with --sha256 or in the URL, not both")
+		}
+		spec = archiveURL + "#" + urlSourceHash + sha256
+	}
+
+	archivePath, packageManifest, err := fetchURLArchive(spec)
+	if err != nil {
+		return err
+	}
+	os.Remove(archivePath)
+
+	return runAdd(packageManifest.Name+"@"+spec, alias, review)
+}
+
+// addSourcePackage installs a file:, git+ or archive URL dependency and records it in the project
+func addSourcePackage(projectRoot string, pkgRef *PackageRef) error {
+	manifestPath := filepath.Join(projectRoot, "rulestack.json")
+	projectManifest, err := loadOrCreateProjectManifest(manifestPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	constraints, err := loadProjectConstraints(projectRoot, projectManifest)
+	if err != nil {
+		return err
+	}
+	if constraints != nil {
+		if err := constraints.CheckPackage(pkgRef.Name, pkgRef.Version); err != nil {
+			return err
+		}
+	}
+
+	if verbose {
+		output.Printf("📂 Packing %s from %s...\n", pkgRef.Name, pkgRef.Version)
+	}
+
+	entry, err := installSourcePackage(projectRoot, pkgRef.Name, pkgRef.Version)
+	if err != nil {
+		return err
+	}
+
+	if previewingInstall() {
+		if err := wireStagedRules(projectRoot, pkgRef.Name, &PackageRef{Name: pkgRef.Name, Version: entry.Version}, *entry); err != nil {
+			return fmt.Errorf("failed to update CLAUDE.md: %w", err)
+		}
+		return nil
+	}
+
+	// The source spec itself is the dependency; the lock records what it resolved to
+	projectManifest.Dependencies[pkgRef.Name] = pkgRef.Version
+	if err := manifest.SaveProjectManifest(manifestPath, projectManifest); err != nil {
+		return fmt.Errorf("failed to save project manifest: %w", err)
+	}
+
+	if err := updateLockEntry(projectRoot, pkgRef.Name, *entry); err != nil {
+		return err
+	}
+
+	installedRef := &PackageRef{Name: pkgRef.Name, Version: entry.Version}
+	if err := wirePackageRules(projectRoot, pkgRef.Name, installedRef); err != nil {
+		if verbose {
+			output.Printf("⚠️ Warning: Failed to update CLAUDE.md: %v\n", err)
+		}
+	}
+
+	// Keep installed packages where the project's "storage" setting wants them
+	if err := syncPackageStorage(projectRoot); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Successfully added %s@%s from %s\n", pkgRef.Name, entry.Version, pkgRef.Version)
+
+	checkRuleConflicts(projectRoot, projectManifest.ResolvedDependencies(), projectManifest.Priority)
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestParsePackageRef_SourceSpecs(t *testing.T) {
+	tests := []struct {
+		spec          string
+		expectName    string
+		expectVersion string
+		expectErr     bool
+	}{
+		{"my-rules@1.0.0", "my-rules", "1.0.0", false},
+		{"my-rules@file:../my-rules", "my-rules", "file:../my-rules", false},
+		{"team-rules@git+https://github.com/org/repo#v1.2.0", "team-rules", "git+https://github.com/org/repo#v1.2.0", false},
+		{"team-rules@git+ssh://git@github.com/org/repo", "team-rules", "git+ssh://git@github.com/org/repo", false},
+		{"url-rules@https://example.com/rules.tgz#sha256=abc", "url-rules", "https://example.com/rules.tgz#sha256=abc", false},
+		{"my-rules@1.0.0@2.0.0", "", "", true},
+		// Versions as typed on the command line are canonicalized; ranges are not
+		{"my-rules@v1.2.3", "my-rules", "1.2.3", false},
+		{"my-rules@1.2", "my-rules", "1.2.0", false},
+		{"my-rules@^1.2", "my-rules", "^1.2", false},
+		{"my-rules@latest", "my-rules", "latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ref, err := parsePackageRef(tt.spec)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parsePackageRef() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if err != nil {
+				return
+			}
+			if ref.Name != tt.expectName || ref.Version != tt.expectVersion {
+				t.Errorf("parsePackageRef() = %s@%s, want %s@%s", ref.Name, ref.Version, tt.expectName, tt.expectVersion)
+			}
+		})
+	}
+}
+
+func TestParseGitSource(t *testing.T) {
+	repoURL, ref, err := parseGitSource("git+https://github.com/org/repo#v1.2.0")
+	if err != nil {
+		t.Fatalf("parseGitSource failed: %v", err)
+	}
+	if repoURL != "https://github.com/org/repo" || ref != "v1.2.0" {
+		t.Errorf("parseGitSource() = (%s, %s)", repoURL, ref)
+	}
+
+	if _, _, err := parseGitSource("git+"); err == nil {
+		t.Error("Expected error for empty git URL")
+	}
+}
+
+func TestRunInstall_FileDependency(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Package source next to the project
+	sourceDir := filepath.Join(tempDir, "my-rules")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "rules"), 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+	sourceManifest := `{"name": "my-rules", "version": "0.2.0", "files": ["rules/*.md"]}`
+	if err := os.WriteFile(filepath.Join(sourceDir, "rulestack.pkg.json"), []byte(sourceManifest), 0644); err != nil {
+		t.Fatalf("Failed to write source manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "rules", "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "notes.txt"), []byte("not packaged"), 0644); err != nil {
+		t.Fatalf("Failed to write extra file: %v", err)
+	}
+
+	projectDir := filepath.Join(tempDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	manifestContent := `{"version": "1.0.0", "dependencies": {"my-rules": "file:../my-rules"}}`
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(manifestContent), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectDir)
+
+	// No registry configured: file dependencies must not need one
+	t.Setenv("RFH_CONFIG", filepath.Join(tempDir, ".rfh"))
+
+	if err := runInstall(); err != nil {
+		t.Fatalf("Expected install to succeed, got: %v", err)
+	}
+
+	packageDir := filepath.Join(projectDir, ".rulestack", "my-rules.0.2.0")
+	if _, err := os.Stat(filepath.Join(packageDir, "rules", "style.md")); err != nil {
+		t.Errorf("Expected rule file to be installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(packageDir, "notes.txt")); err == nil {
+		t.Error("Expected files outside manifest patterns to be excluded")
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, "rulestack.lock.json"))
+	if err != nil {
+		t.Fatalf("Failed to read lock file: %v", err)
+	}
+	var lock LockManifest
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("Failed to parse lock file: %v", err)
+	}
+
+	entry := lock.Packages["my-rules"]
+	if entry.Source != "file:../my-rules" || entry.Version != "0.2.0" {
+		t.Errorf("Unexpected lock entry: %+v", entry)
+	}
+
+	expectedHash, err := hashDirectoryContents(packageDir)
+	if err != nil {
+		t.Fatalf("Failed to hash package dir: %v", err)
+	}
+	if entry.SHA256 != expectedHash {
+		t.Errorf("Expected content hash %s, got %s", expectedHash, entry.SHA256)
+	}
+}
+
+func TestRunAddURL(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// An archive published outside any registry
+	stageDir := filepath.Join(tempDir, "stage")
+	if err := os.MkdirAll(filepath.Join(stageDir, "rules"), 0755); err != nil {
+		t.Fatalf("Failed to create stage dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(stageDir, "rulestack.json"), []byte(`{"name": "url-rules", "version": "1.0.0", "files": ["rules/*.md"]}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(stageDir, "rules", "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	archivePath := filepath.Join(tempDir, "url-rules-1.0.0.tgz")
+	if _, err := pkg.PackFromDirectory(stageDir, archivePath); err != nil {
+		t.Fatalf("Failed to pack archive: %v", err)
+	}
+	archive, _ := os.ReadFile(archivePath)
+	sum := sha256.Sum256(archive)
+	archiveSHA := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer server.Close()
+	archiveURL := server.URL + "/url-rules-1.0.0.tgz"
+
+	projectDir := filepath.Join(tempDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "rulestack.json"), []byte(`{"version": "1.0.0", "dependencies": {}}`), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(projectDir)
+
+	// No registry configured: archive URLs must not need one
+	t.Setenv("RFH_CONFIG", filepath.Join(tempDir, ".rfh"))
+
+	if err := runAddURL(archiveURL, "", "", false); err == nil || !strings.Contains(err.Error(), "not pinned") {
+		t.Errorf("Expected an unpinned URL to be refused, got: %v", err)
+	}
+	if err := runAddURL(archiveURL, strings.Repeat("0", 64), "", false); err == nil {
+		t.Error("Expected an archive with the wrong sha256 to be refused")
+	}
+
+	if err := runAddURL(archiveURL, archiveSHA, "", false); err != nil {
+		t.Fatalf("Expected add to succeed, got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(projectDir, ".rulestack", "url-rules.1.0.0", "rules", "style.md")); err != nil {
+		t.Errorf("Expected rule file to be installed: %v", err)
+	}
+
+	spec := archiveURL + "#sha256=" + archiveSHA
+	data, _ := os.ReadFile(filepath.Join(projectDir, "rulestack.json"))
+	if !strings.Contains(string(data), spec) {
+		t.Errorf("Expected the pinned URL as the dependency, got %s", data)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, "rulestack.lock.json"))
+	if err != nil {
+		t.Fatalf("Failed to read lock file: %v", err)
+	}
+	var lock LockManifest
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("Failed to parse lock file: %v", err)
+	}
+	entry := lock.Packages["url-rules"]
+	if entry.Source != spec || entry.SHA256 != archiveSHA || entry.Version != "1.0.0" || entry.Files["rules/style.md"] == "" {
+		t.Errorf("Unexpected lock entry: %+v", entry)
+	}
+}
+
+func TestStageSourceFiles_SkipsSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	secretDir := filepath.Join(tempDir, "home", ".ssh")
+	if err := os.MkdirAll(secretDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretDir, "id_rsa"), []byte("PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sourceDir := filepath.Join(tempDir, "checkout")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "rules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "rules", "style.md"), []byte("# Style\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(secretDir, "id_rsa"), filepath.Join(sourceDir, "rules", "x.md")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(secretDir, filepath.Join(sourceDir, "rules", "keys")); err != nil {
+		t.Fatal(err)
+	}
+
+	stageDir := filepath.Join(tempDir, "stage")
+	packageManifest := &manifest.PackageManifest{Name: "my-rules", Version: "0.2.0", Files: []string{"rules/**/*"}}
+	if err := stageSourceFiles(sourceDir, stageDir, packageManifest); err != nil {
+		t.Fatalf("stageSourceFiles failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(stageDir, "rules", "style.md")); err != nil {
+		t.Errorf("Expected the regular rule file to be staged: %v", err)
+	}
+	for _, link := range []string{"rules/x.md", "rules/keys/id_rsa"} {
+		if _, err := os.Lstat(filepath.Join(stageDir, filepath.FromSlash(link))); err == nil {
+			t.Errorf("Expected %s to be skipped", link)
+		}
+	}
+}
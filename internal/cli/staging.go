@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+var (
+	stagingCleanOlderThan time.Duration
+	stagingCleanDryRun    bool
+)
+
+// stagingCmd represents the staging command
+var stagingCmd = &cobra.Command{
+	Use:   "staging",
+	Short: "Manage archives staged for publishing",
+	Long:  `Manage the archives 'rfh pack' stages in .rulestack/staged/ for 'rfh publish'.`,
+}
+
+// stagingListCmd represents the staging list command
+var stagingListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List staged archives",
+	Long: `List staged archives with the package name, version, SHA256, size and age of each.
+
+Examples:
+  rfh staging list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStagingList()
+	},
+}
+
+// stagingInspectCmd represents the staging inspect command
+var stagingInspectCmd = &cobra.Command{
+	Use:   "inspect <archive|package@version>",
+	Short: "Show the files in a staged archive",
+	Long: `Show the manifest and file listing of a staged archive, named by its file
+name or by package@version.
+
+Examples:
+  rfh staging inspect security-rules-1.2.0.tgz
+  rfh staging inspect security-rules@1.2.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStagingInspect(args[0])
+	},
+}
+
+// stagingCleanCmd represents the staging clean command
+var stagingCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove staged archives",
+	Long: `Remove staged archives, or with --older-than only those staged longer ago.
+
+Examples:
+  rfh staging clean
+  rfh staging clean --older-than 168h
+  rfh staging clean --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStagingClean(stagingCleanOlderThan, stagingCleanDryRun)
+	},
+}
+
+// stagedArchive describes an archive in the staging directory
+type stagedArchive struct {
+	Path     string
+	Name     string // Package name from the embedded manifest; empty if unreadable
+	Version  string
+	SHA256   string
+	Size     int64
+	StagedAt time.Time
+}
+
+// loadStagedArchives describes every archive in the staging directory, oldest first
+func loadStagedArchives(stagingDir string) ([]stagedArchive, error) {
+	paths, err := stagedArchives(stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan staging directory: %w", err)
+	}
+
+	var archives []stagedArchive
+	for _, archivePath := range paths {
+		stat, err := os.Stat(archivePath)
+		if err != nil {
+			continue
+		}
+		archive := stagedArchive{Path: archivePath, Size: stat.Size(), StagedAt: stat.ModTime()}
+
+		if sha, err := pkg.CalculateSHA256(archivePath); err == nil {
+			archive.SHA256 = sha
+		}
+		if data, err := pkg.ExtractManifest(archivePath); err == nil {
+			var packageManifest manifest.PackageManifest
+			if json.Unmarshal(data, &packageManifest) == nil {
+				archive.Name = packageManifest.Name
+				archive.Version = packageManifest.Version
+			}
+		}
+
+		archives = append(archives, archive)
+	}
+
+	sort.Slice(archives, func(i, j int) bool { return archives[i].StagedAt.Before(archives[j].StagedAt) })
+	return archives, nil
+}
+
+func runStagingList() error {
+	archives, err := loadStagedArchives(getStagingDirectory())
+	if err != nil {
+		return err
+	}
+
+	if len(archives) == 0 {
+		output.Println("No staged packages found")
+		return nil
+	}
+
+	output.Printf("%-30s %-12s %-14s %-10s %s\n", "Package", "Version", "SHA256", "Size", "Age")
+	for _, archive := range archives {
+		name, version := archive.Name, archive.Version
+		if name == "" {
+			name, version = filepath.Base(archive.Path), "?"
+		}
+		output.Printf("%-30s %-12s %-14s %-10s %s\n", name, version, shortHash(archive.SHA256),
+			pkg.FormatSize(archive.Size), formatAge(time.Since(archive.StagedAt)))
+	}
+
+	return nil
+}
+
+func runStagingInspect(ref string) error {
+	archive, err := findStagedArchive(getStagingDirectory(), ref)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("📦 %s\n", filepath.Base(archive.Path))
+	if archive.Name != "" {
+		output.Printf("📌 Package: %s@%s\n", archive.Name, archive.Version)
+	}
+	output.Printf("🔒 SHA256: %s\n", archive.SHA256)
+	output.Printf("📏 Size: %s\n", pkg.FormatSize(archive.Size))
+	output.Printf("🕒 Staged: %s (%s ago)\n", archive.StagedAt.Format(time.RFC3339), formatAge(time.Since(archive.StagedAt)))
+
+	output.Printf("📋 Files:\n")
+	count := 0
+	err = pkg.WalkFiles(archive.Path, func(header *tar.Header, content io.Reader) error {
+		output.Printf("   %10s  %s\n", pkg.FormatSize(header.Size), header.Name)
+		count++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	output.Printf("   %d file(s)\n", count)
+
+	return nil
+}
+
+// findStagedArchive finds a staged archive by file name, path or package@version
+func findStagedArchive(stagingDir, ref string) (*stagedArchive, error) {
+	archives, err := loadStagedArchives(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	name, version, isPackageRef := strings.Cut(ref, "@")
+	if isPackageRef {
+		version = canonicalVersionArg(version)
+	}
+	for i, archive := range archives {
+		if filepath.Base(archive.Path) == filepath.Base(ref) ||
+			(isPackageRef && archive.Name == name && archive.Version == version) {
+			return &archives[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no staged archive matches '%s'. Run 'rfh staging list' to see staged archives", ref)
+}
+
+func runStagingClean(olderThan time.Duration, dryRun bool) error {
+	stagingDir := getStagingDirectory()
+	archives, err := loadStagedArchives(stagingDir)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	var freed int64
+	for _, archive := range archives {
+		if olderThan > 0 && time.Since(archive.StagedAt) < olderThan {
+			continue
+		}
+
+		if dryRun {
+			output.Printf("🗑️  Would remove %s\n", filepath.Base(archive.Path))
+		} else {
+			if err := os.Remove(archive.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", archive.Path, err)
+			}
+			output.Printf("🗑️  Removed %s\n", filepath.Base(archive.Path))
+		}
+		removed++
+		freed += archive.Size
+	}
+
+	// Manifests left behind by interrupted publishes of earlier rfh versions
+	if !dryRun {
+		leftovers, _ := filepath.Glob(filepath.Join(stagingDir, "temp-manifest-*.json"))
+		for _, leftover := range leftovers {
+			os.Remove(leftover)
+		}
+	}
+
+	if removed == 0 {
+		output.Println("Nothing to clean")
+		return nil
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	output.Printf("✅ %s %d archive(s), %s\n", verb, removed, pkg.FormatSize(freed))
+	return nil
+}
+
+// warnStaleStagedArchive warns when a staged archive no longer matches what packing
+// the package from rulestack.pkg.json in the current directory would produce, e.g.
+// because a rule was edited after 'rfh pack'. Archives of packages not declared
+// there are not checked.
+func warnStaleStagedArchive(archivePath string, packageManifest *manifest.PackageManifest) {
+	manifests, err := manifest.LoadPackageManifests(packageManifestPath("."))
+	if err != nil {
+		return
+	}
+
+	var source *manifest.PackageManifest
+	for i := range manifests {
+		if manifests[i].Name == packageManifest.Name && manifests[i].Version == packageManifest.Version {
+			source = &manifests[i]
+		}
+	}
+	if source == nil {
+		return
+	}
+
+	changed, err := stagedArchiveDrift(".", source, archivePath)
+	if err != nil || len(changed) == 0 {
+		return
+	}
+
+	output.Printf("⚠️  Staged archive for %s@%s differs from its sources: %s\n",
+		packageManifest.Name, packageManifest.Version, strings.Join(changed, ", "))
+	output.Printf("💡 Run 'rfh pack --from-manifest --package=%s' to restage it\n", packageManifest.Name)
+}
+
+// stagedArchiveDrift packs a package afresh and returns the files whose content
+// differs from the staged archive, or that only one of them contains
+func stagedArchiveDrift(sourceDir string, packageManifest *manifest.PackageManifest, archivePath string) ([]string, error) {
+	tempDir, err := tempdir.MkdirTemp("drift-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	fresh, err := packManifestPackage(sourceDir, packageManifest, filepath.Join(tempDir, "fresh.tgz"), pkg.Compression{})
+	if err != nil {
+		return nil, err
+	}
+
+	freshHashes, err := pkg.HashFiles(fresh.Path)
+	if err != nil {
+		return nil, err
+	}
+	stagedHashes, err := pkg.HashFiles(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for path, hash := range freshHashes {
+		if stagedHashes[path] != hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range stagedHashes {
+		if _, ok := freshHashes[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// shortHash abbreviates a SHA256 for tables
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// formatAge renders a duration coarsely, e.g. "45s", "3h" or "2d"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func init() {
+	stagingCleanCmd.Flags().DurationVar(&stagingCleanOlderThan, "older-than", 0, "only remove archives staged longer ago than this, e.g. 168h")
+	stagingCleanCmd.Flags().BoolVar(&stagingCleanDryRun, "dry-run", false, "show what would be removed without removing it")
+
+	stagingCmd.AddCommand(stagingListCmd)
+	stagingCmd.AddCommand(stagingInspectCmd)
+	stagingCmd.AddCommand(stagingCleanCmd)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestStagingArchives(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(sourceDir, "rules"), 0755); err != nil {
+		t.Fatalf("Failed to create rules dir: %v", err)
+	}
+	rulePath := filepath.Join(sourceDir, "rules", "security.mdc")
+	if err := os.WriteFile(rulePath, []byte("# Security\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	packageManifest := &manifest.PackageManifest{Name: "security-rules", Version: "1.2.0", Files: []string{"rules/*.mdc"}}
+	stagingDir := t.TempDir()
+	archivePath := filepath.Join(stagingDir, "security-rules-1.2.0.tgz")
+	if _, err := packManifestPackage(sourceDir, packageManifest, archivePath, pkg.Compression{}); err != nil {
+		t.Fatalf("packManifestPackage failed: %v", err)
+	}
+
+	archive, err := findStagedArchive(stagingDir, "security-rules@1.2.0")
+	if err != nil {
+		t.Fatalf("findStagedArchive failed: %v", err)
+	}
+	if archive.Path != archivePath || archive.SHA256 == "" {
+		t.Errorf("Expected %s with its hash, got %+v", archivePath, archive)
+	}
+	if _, err := findStagedArchive(stagingDir, "security-rules@2.0.0"); err == nil {
+		t.Error("Expected an unknown version not to match")
+	}
+
+	changed, err := stagedArchiveDrift(sourceDir, packageManifest, archivePath)
+	if err != nil || len(changed) != 0 {
+		t.Errorf("Expected a freshly staged archive to match its sources, got %v (%v)", changed, err)
+	}
+
+	if err := os.WriteFile(rulePath, []byte("# Security, edited\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit rule: %v", err)
+	}
+	changed, err = stagedArchiveDrift(sourceDir, packageManifest, archivePath)
+	if err != nil || strings.Join(changed, ",") != "rules/security.mdc" {
+		t.Errorf("Expected the edited rule reported, got %v (%v)", changed, err)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second: "30s",
+		5 * time.Minute:  "5m",
+		3 * time.Hour:    "3h",
+		50 * time.Hour:   "2d",
+	}
+
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -1,40 +1,367 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+)
+
+var (
+	statusJSON    bool
+	statusOffline bool
 )
 
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show packages staged for publishing",
-	Long:  `Lists .tgz packages in the staging directory that are ready for publishing.`,
+	Short: "Show whether the project is healthy",
+	Long: `Summarize the state of the project on one screen: the active registry and
+login, installed, outdated and missing packages, differences between
+rulestack.json and rulestack.lock.json, whether CLAUDE.md imports the installed
+rules, quarantined packages, and packages staged for publishing.
+
+Outdated packages are looked up on the registry with one request, or in the
+local index kept by 'rfh index sync' with --offline. Outside a project only the
+registry and staged packages are shown.
+
+Examples:
+  rfh status
+  rfh status --offline
+  rfh status --json`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runStatus()
+		return runStatus(statusJSON, statusOffline)
 	},
 }
 
-func runStatus() error {
-	stagingDir := ".rulestack/staged"
-	pattern := filepath.Join(stagingDir, "*.tgz")
+// StatusReport is what 'rfh status' reports
+type StatusReport struct {
+	Registry *RegistryStatus `json:"registry"` // Nil when no registry is active
+	Project  *ProjectStatus  `json:"project"`  // Nil outside a project
+	Staged   []string        `json:"staged"`   // Archives in .rulestack/staged/
+	Problems []string        `json:"problems"` // Everything that makes the project unhealthy
+	Healthy  bool            `json:"healthy"`
+}
+
+// RegistryStatus is the active registry and who is logged in to it
+type RegistryStatus struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LoggedIn bool   `json:"logged_in"`
+	Username string `json:"username,omitempty"`
+}
+
+// ProjectStatus is the state of the packages a project depends on
+type ProjectStatus struct {
+	Root          string            `json:"root"`
+	Installed     int               `json:"installed"`
+	Outdated      []OutdatedPackage `json:"outdated"`
+	OutdatedCheck string            `json:"outdated_check,omitempty"` // Why the registry was not asked for newer versions
+	Missing       []string          `json:"missing"`
+	LockDrift     []string          `json:"lock_drift"` // Differences between rulestack.json and rulestack.lock.json
+	Editor        EditorStatus      `json:"editor"`
+	Quarantined   []string          `json:"quarantined"`
+}
+
+// EditorStatus is whether the editor integration file imports the installed rules
+type EditorStatus struct {
+	File    string   `json:"file"`
+	Present bool     `json:"present"`
+	Unwired []string `json:"unwired"` // Trusted packages whose rules are not imported
+}
+
+func runStatus(asJSON, offline bool) error {
+	report := &StatusReport{
+		Staged:   []string{},
+		Problems: []string{},
+	}
+
+	if cfg, err := loadConfig(); err == nil {
+		if name, reg, err := getCurrentRegistry(cfg); err == nil {
+			report.Registry = &RegistryStatus{
+				Name:     name,
+				URL:      reg.URL,
+				LoggedIn: reg.Username != "" && reg.AuthToken() != "",
+				Username: reg.Username,
+			}
+		}
+	}
+
+	if projectRoot, err := findProjectRoot(); err == nil {
+		project, err := projectStatus(projectRoot, report.Registry != nil || offline, offline)
+		if err != nil {
+			return err
+		}
+		report.Project = project
+		report.Problems = projectProblems(project)
+	}
 
-	files, err := filepath.Glob(pattern)
+	staged, err := stagedArchives(filepath.Join(".rulestack", "staged"))
 	if err != nil {
 		return fmt.Errorf("failed to scan staging directory: %w", err)
 	}
+	for _, archive := range staged {
+		report.Staged = append(report.Staged, filepath.Base(archive))
+	}
+	report.Healthy = len(report.Problems) == 0
 
-	if len(files) == 0 {
-		fmt.Println("No staged packages found")
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		output.Data(data)
 		return nil
 	}
 
-	for _, file := range files {
-		filename := filepath.Base(file)
-		fmt.Println(filename)
+	printStatus(report)
+	return nil
+}
+
+// projectStatus works out the state of a project's packages. The registry is
+// only asked for newer versions when checkRegistry is set.
+func projectStatus(projectRoot string, checkRegistry, offline bool) (*ProjectStatus, error) {
+	status := &ProjectStatus{
+		Root:        projectRoot,
+		Outdated:    []OutdatedPackage{},
+		Missing:     []string{},
+		LockDrift:   []string{},
+		Quarantined: []string{},
+		Editor:      EditorStatus{File: "CLAUDE.md", Unwired: []string{}},
 	}
 
-	return nil
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project manifest: %w", err)
+	}
+	if err := applyInherited(projectRoot, projectManifest); err != nil {
+		status.Missing = append(status.Missing, projectManifest.Extends)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	requirements, err := analyzePackageRequirements(projectRoot, projectManifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze package requirements: %w", err)
+	}
+
+	installed := make(map[string]string)
+	var refs []client.VersionRef
+	for _, req := range requirements {
+		if req.Condition != "" {
+			continue
+		}
+		if req.InstalledVersion == "" {
+			status.Missing = append(status.Missing, fmt.Sprintf("%s@%s", req.Name, req.RequiredVersion))
+			continue
+		}
+		status.Installed++
+		if req.Action == "update" {
+			status.Outdated = append(status.Outdated, OutdatedPackage{
+				Name:    req.Name,
+				Current: req.InstalledVersion,
+				Wanted:  req.RequiredVersion,
+				Latest:  req.RequiredVersion,
+			})
+		}
+		if req.Alias == "" && !isSourceSpec(req.RequiredVersion) {
+			refs = append(refs, client.VersionRef{Name: req.Package, Version: req.RequiredVersion})
+			installed[req.Package] = req.InstalledVersion
+		}
+	}
+
+	// The registry also knows about newer releases and deprecations
+	switch {
+	case len(refs) == 0:
+	case !checkRegistry:
+		status.OutdatedCheck = "no registry configured"
+	default:
+		metadata, err := lookupDependencies(refs, offline, projectManifest)
+		if err != nil {
+			status.OutdatedCheck = err.Error()
+			break
+		}
+		status.Outdated = status.Outdated[:0]
+		for _, pkg := range findOutdated(metadata, installed) {
+			if pkg.Current != "" {
+				status.Outdated = append(status.Outdated, pkg)
+			}
+		}
+	}
+
+	status.LockDrift = lockDrift(projectManifest, lockManifest)
+
+	for name, entry := range lockManifest.Packages {
+		if entry.Quarantined {
+			status.Quarantined = append(status.Quarantined, name)
+		}
+	}
+
+	claude, err := os.ReadFile(filepath.Join(projectRoot, "CLAUDE.md"))
+	status.Editor.Present = err == nil
+	if status.Editor.Present {
+		rulestackDir := filepath.Join(projectRoot, ".rulestack")
+		for name, entry := range lockManifest.Packages {
+			if entry.Quarantined {
+				continue
+			}
+			packageDir := installedPackageDir(rulestackDir, name, entry)
+			ruleFiles, err := findRuleFiles(packageDir)
+			if err != nil || len(ruleFiles) == 0 {
+				continue
+			}
+			if !strings.Contains(string(claude), ".rulestack/"+filepath.Base(packageDir)+"/") {
+				status.Editor.Unwired = append(status.Editor.Unwired, name)
+			}
+		}
+	}
+
+	sort.Strings(status.Missing)
+	sort.Strings(status.Quarantined)
+	sort.Strings(status.Editor.Unwired)
+	return status, nil
+}
+
+// lockDrift lists where rulestack.lock.json no longer matches rulestack.json,
+// which 'rfh install .' puts right
+func lockDrift(projectManifest *manifest.ProjectManifest, lockManifest *LockManifest) []string {
+	wanted := make(map[string]string)
+	for name, declared := range projectManifest.Dependencies {
+		if !projectManifest.Applies(name) {
+			continue
+		}
+		_, resolved, _ := projectManifest.ResolveDependency(name, declared)
+		wanted[name] = resolved
+	}
+	for alias := range projectManifest.Aliases {
+		_, resolved, _ := projectManifest.ResolveAlias(alias)
+		wanted[alias] = resolved
+	}
+
+	drift := []string{}
+	for name, want := range wanted {
+		entry, ok := lockManifest.Packages[name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("%s is in rulestack.json but not in rulestack.lock.json", name))
+		case isSourceSpec(want) && entry.Source != want:
+			drift = append(drift, fmt.Sprintf("%s is locked from %s, rulestack.json wants %s", name, entry.Source, want))
+		case !isSourceSpec(want) && entry.Version != want:
+			drift = append(drift, fmt.Sprintf("%s is locked at %s, rulestack.json wants %s", name, entry.Version, want))
+		}
+	}
+	for name := range lockManifest.Packages {
+		if _, ok := wanted[name]; !ok {
+			drift = append(drift, fmt.Sprintf("%s is in rulestack.lock.json but not in rulestack.json", name))
+		}
+	}
+
+	sort.Strings(drift)
+	return drift
+}
+
+// projectProblems lists what keeps a project from being healthy. Outdated and
+// quarantined packages are reported, but are not problems by themselves.
+func projectProblems(project *ProjectStatus) []string {
+	problems := []string{}
+	if len(project.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d package(s) missing", len(project.Missing)))
+	}
+	if len(project.LockDrift) > 0 {
+		problems = append(problems, fmt.Sprintf("rulestack.lock.json differs from rulestack.json in %d place(s)", len(project.LockDrift)))
+	}
+	if !project.Editor.Present {
+		problems = append(problems, fmt.Sprintf("%s is missing", project.Editor.File))
+	} else if len(project.Editor.Unwired) > 0 {
+		problems = append(problems, fmt.Sprintf("%s does not import %d package(s)", project.Editor.File, len(project.Editor.Unwired)))
+	}
+	return problems
+}
+
+func printStatus(report *StatusReport) {
+	if report.Registry == nil {
+		output.Printf("🌐 Registry: none ('rfh registry add' to add one)\n")
+	} else {
+		output.Printf("🌐 Registry: %s (%s)\n", report.Registry.Name, report.Registry.URL)
+		if report.Registry.LoggedIn {
+			output.Printf("👤 Logged in as %s\n", report.Registry.Username)
+		} else {
+			output.Printf("👤 Not logged in ('rfh auth login' to log in)\n")
+		}
+	}
+
+	if project := report.Project; project == nil {
+		output.Printf("📁 Not in an rfh project ('rfh init' to create one)\n")
+	} else {
+		output.Printf("📁 Project: %s\n", project.Root)
+		output.Printf("📦 Packages: %d installed, %d outdated, %d missing\n", project.Installed, len(project.Outdated), len(project.Missing))
+		for _, pkg := range project.Outdated {
+			output.Printf("   ⬆️  %s %s → %s\n", pkg.Name, pkg.Current, pkg.Latest)
+		}
+		for _, missing := range project.Missing {
+			output.Printf("   ❌ %s is not installed\n", missing)
+		}
+		if project.OutdatedCheck != "" {
+			output.Printf("   ℹ️  Newer versions not checked: %s\n", project.OutdatedCheck)
+		}
+
+		if len(project.LockDrift) == 0 {
+			output.Printf("🔒 Lock file: in sync with rulestack.json\n")
+		} else {
+			output.Printf("🔒 Lock file: %d difference(s) from rulestack.json\n", len(project.LockDrift))
+			for _, drift := range project.LockDrift {
+				output.Printf("   ⚠️  %s\n", drift)
+			}
+		}
+
+		switch {
+		case !project.Editor.Present:
+			output.Printf("📝 %s: missing ('rfh init' to create it)\n", project.Editor.File)
+		case len(project.Editor.Unwired) > 0:
+			output.Printf("📝 %s: does not import %s\n", project.Editor.File, strings.Join(project.Editor.Unwired, ", "))
+		default:
+			output.Printf("📝 %s: imports all installed rules\n", project.Editor.File)
+		}
+
+		if len(project.Quarantined) == 0 {
+			output.Printf("🛡️  Quarantined: none\n")
+		} else {
+			output.Printf("🛡️  Quarantined: %s ('rfh trust <package>' after review)\n", strings.Join(project.Quarantined, ", "))
+		}
+	}
+
+	if len(report.Staged) == 0 {
+		output.Println("No staged packages found")
+	} else {
+		output.Printf("📤 Staged for publishing:\n")
+		for _, archive := range report.Staged {
+			output.Printf("   %s\n", archive)
+		}
+	}
+
+	if report.Project == nil {
+		return
+	}
+	if report.Healthy {
+		output.Printf("\n✅ Project is healthy\n")
+		return
+	}
+	output.Printf("\n⚠️  Project needs attention: %s. Run 'rfh install .' to fix missing packages and lock file differences\n", strings.Join(report.Problems, "; "))
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "print the status as JSON")
+	statusCmd.Flags().BoolVar(&statusOffline, "offline", false, "use the local index instead of the registry")
 }
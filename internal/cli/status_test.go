@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestProjectStatus(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+	projectRoot := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+
+	if err := runInit(false, false); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	status, err := projectStatus(projectRoot, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := projectProblems(status); len(problems) != 0 || status.Installed != 1 {
+		t.Errorf("fresh project: installed %d, problems %v", status.Installed, problems)
+	}
+
+	// A dependency that was never installed, and a package CLAUDE.md does not import
+	manifestPath := filepath.Join(projectRoot, "rulestack.json")
+	projectManifest, err := manifest.LoadProjectManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectManifest.Dependencies["security-rules"] = "1.2.0"
+	if err := manifest.SaveProjectManifest(manifestPath, projectManifest); err != nil {
+		t.Fatal(err)
+	}
+
+	packageDir := filepath.Join(projectRoot, ".rulestack", "logging-rules.1.0.0")
+	os.MkdirAll(packageDir, 0755)
+	os.WriteFile(filepath.Join(packageDir, "logging.md"), []byte("# Logging\n"), 0644)
+	if err := updateLockEntry(projectRoot, "logging-rules", LockPackageEntry{Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(projectRoot, "rulestack.lock.json")
+	lockManifest, err := loadOrCreateLockManifest(lockPath, projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockManifest.Packages["team-rules"] = LockPackageEntry{Version: "2.0.0", Quarantined: true}
+	if err := saveLockManifest(lockPath, lockManifest); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = projectStatus(projectRoot, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(status.Missing, []string{"security-rules@1.2.0"}) {
+		t.Errorf("missing = %v", status.Missing)
+	}
+	expectedDrift := []string{
+		"logging-rules is in rulestack.lock.json but not in rulestack.json",
+		"security-rules is in rulestack.json but not in rulestack.lock.json",
+		"team-rules is in rulestack.lock.json but not in rulestack.json",
+	}
+	if !reflect.DeepEqual(status.LockDrift, expectedDrift) {
+		t.Errorf("lock drift = %v, want %v", status.LockDrift, expectedDrift)
+	}
+	if !reflect.DeepEqual(status.Editor.Unwired, []string{"logging-rules"}) {
+		t.Errorf("unwired = %v", status.Editor.Unwired)
+	}
+	if !reflect.DeepEqual(status.Quarantined, []string{"team-rules"}) {
+		t.Errorf("quarantined = %v", status.Quarantined)
+	}
+	if problems := projectProblems(status); len(problems) != 3 {
+		t.Errorf("problems = %v, want missing, lock drift and CLAUDE.md", problems)
+	}
+}
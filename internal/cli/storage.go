@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rulestack/internal/manifest"
+)
+
+// gitignoreStart and gitignoreEnd delimit the .gitignore lines rfh manages
+const (
+	gitignoreStart = "# >>> rfh storage: installed packages live in the rfh cache (managed by rfh)"
+	gitignoreEnd   = "# <<< rfh storage"
+)
+
+// gitignoreStorageRules keep the links to cached packages out of version
+// control, while project rules in .rulestack/project/ and the install event
+// log stay committed
+var gitignoreStorageRules = []string{
+	"/.rulestack/*",
+	"!/.rulestack/project/",
+	"!/.rulestack/" + eventsLogName,
+}
+
+// packageCacheDir returns the directory a project's packages are kept in with
+// "storage": "cache", keyed by the project's absolute path
+func packageCacheDir(projectRoot string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	absRoot, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256([]byte(absRoot))
+	dirName := fmt.Sprintf("%s-%s", filepath.Base(absRoot), hex.EncodeToString(h[:8]))
+	return filepath.Join(homeDir, ".rfh", "cache", "projects", dirName), nil
+}
+
+// isPackageDir reports whether a .rulestack/ entry is a package directory,
+// including a link to one kept elsewhere
+func isPackageDir(rulestackDir string, entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(rulestackDir, entry.Name()))
+	return err == nil && info.IsDir()
+}
+
+// isDirLink reports whether path is a symlink or, on Windows, a junction
+func isDirLink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&(os.ModeSymlink|os.ModeIrregular) != 0
+}
+
+// syncPackageStorage moves installed packages to where the project's "storage"
+// setting keeps them, and updates .gitignore to match. Packages linked with
+// 'rfh link' are left alone.
+func syncPackageStorage(projectRoot string) error {
+	projectManifest, err := loadOrCreateProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+	useCache := projectManifest.Storage == manifest.StorageCache
+
+	cacheDir, err := packageCacheDir(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to locate package cache: %w", err)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+	links, err := loadLinks(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	installed := make(map[string]bool)
+	for name, entry := range lockManifest.Packages {
+		installed[filepath.Base(installedPackageDir(rulestackDir, name, entry))] = true
+	}
+	if lockManifest.Extends != nil {
+		installed[filepath.Base(installedPackageDir(rulestackDir, lockManifest.Extends.Package, *lockManifest.Extends))] = true
+	}
+	for name, linked := range links {
+		delete(installed, packageDirName(name, linked.Version))
+	}
+
+	for dirName := range installed {
+		packageDir := filepath.Join(rulestackDir, dirName)
+		cachedDir := filepath.Join(cacheDir, dirName)
+		if useCache {
+			err = moveToCache(packageDir, cachedDir)
+		} else {
+			err = moveFromCache(packageDir, cachedDir)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Drop cached packages the project no longer installs, and links to them
+	if entries, err := os.ReadDir(rulestackDir); err == nil {
+		for _, entry := range entries {
+			linkPath := filepath.Join(rulestackDir, entry.Name())
+			if installed[entry.Name()] || !isDirLink(linkPath) {
+				continue
+			}
+			if target, err := os.Readlink(linkPath); err == nil && filepath.Clean(target) == filepath.Join(cacheDir, entry.Name()) {
+				os.Remove(linkPath)
+			}
+		}
+	}
+	if entries, err := os.ReadDir(cacheDir); err == nil {
+		for _, entry := range entries {
+			if !useCache || !installed[entry.Name()] {
+				os.RemoveAll(filepath.Join(cacheDir, entry.Name()))
+			}
+		}
+		if !useCache {
+			os.Remove(cacheDir)
+		}
+	}
+
+	return updateGitignore(projectRoot, useCache)
+}
+
+// moveToCache moves an installed package into the cache and links it back
+func moveToCache(packageDir, cachedDir string) error {
+	if _, err := os.Lstat(packageDir); os.IsNotExist(err) || isDirLink(packageDir) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachedDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create package cache: %w", err)
+	}
+	if err := os.RemoveAll(cachedDir); err != nil {
+		return fmt.Errorf("failed to clear cached %s: %w", filepath.Base(cachedDir), err)
+	}
+	if err := moveDir(packageDir, cachedDir); err != nil {
+		return fmt.Errorf("failed to move %s to the package cache: %w", filepath.Base(packageDir), err)
+	}
+	if err := createDirLink(cachedDir, packageDir); err != nil {
+		return fmt.Errorf("failed to link cached %s: %w", filepath.Base(packageDir), err)
+	}
+	return nil
+}
+
+// moveFromCache replaces a link to a cached package with the package itself
+func moveFromCache(packageDir, cachedDir string) error {
+	if !isDirLink(packageDir) {
+		return nil
+	}
+	if target, err := os.Readlink(packageDir); err != nil || filepath.Clean(target) != filepath.Clean(cachedDir) {
+		return nil
+	}
+
+	if err := os.Remove(packageDir); err != nil {
+		return fmt.Errorf("failed to remove link %s: %w", packageDir, err)
+	}
+	if err := moveDir(cachedDir, packageDir); err != nil {
+		return fmt.Errorf("failed to move %s out of the package cache: %w", filepath.Base(packageDir), err)
+	}
+	return nil
+}
+
+// moveDir renames a directory, copying it when the destination is on another
+// file system
+func moveDir(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, relPath), 0o755)
+		}
+		return copyFile(path, filepath.Join(dst, relPath))
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// updateGitignore adds or removes the lines that keep cached packages out of
+// version control, leaving the rest of .gitignore as it is
+func updateGitignore(projectRoot string, ignoreInstalled bool) error {
+	gitignorePath := filepath.Join(projectRoot, ".gitignore")
+	data, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	content := string(data)
+
+	// Take out the managed block, then put it back at the end if wanted
+	var kept []string
+	inBlock, hadBlock := false, false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case line == gitignoreStart:
+			inBlock, hadBlock = true, true
+		case line == gitignoreEnd:
+			inBlock = false
+		case !inBlock:
+			kept = append(kept, line)
+		}
+	}
+	if !ignoreInstalled && !hadBlock {
+		return nil
+	}
+	updated := strings.TrimRight(strings.Join(kept, "\n"), "\n")
+
+	if ignoreInstalled {
+		block := strings.Join(append(append([]string{gitignoreStart}, gitignoreStorageRules...), gitignoreEnd), "\n")
+		if updated != "" {
+			updated += "\n\n"
+		}
+		updated += block
+	}
+	if updated != "" {
+		updated += "\n"
+	}
+
+	if updated == content {
+		return nil
+	}
+	if err := os.WriteFile(gitignorePath, []byte(updated), 0o644); err != nil {
+		return fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestSyncPackageStorage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("junction creation requires cmd.exe")
+	}
+	t.Setenv("HOME", t.TempDir())
+	projectRoot := t.TempDir()
+
+	packageDir := filepath.Join(projectRoot, ".rulestack", "security-rules.1.0.0")
+	projectRulesDir := filepath.Join(projectRoot, ".rulestack", "project")
+	for _, dir := range []string{filepath.Join(packageDir, "rules"), projectRulesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(packageDir, "rules", "secrets.md"), []byte("# Secrets\n"), 0644)
+	os.WriteFile(filepath.Join(projectRulesDir, "local.md"), []byte("# Local\n"), 0644)
+	os.WriteFile(filepath.Join(projectRoot, ".gitignore"), []byte("node_modules/\n"), 0644)
+
+	files, err := hashInstalledFiles(packageDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := updateLockEntry(projectRoot, "security-rules", LockPackageEntry{Version: "1.0.0", SHA256: "abc", Files: files}); err != nil {
+		t.Fatal(err)
+	}
+
+	setStorage := func(storage string) {
+		t.Helper()
+		projectManifest := manifest.CreateProjectManifest()
+		projectManifest.Dependencies["security-rules"] = "1.0.0"
+		projectManifest.Storage = storage
+		if err := manifest.SaveProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectManifest); err != nil {
+			t.Fatal(err)
+		}
+		if err := syncPackageStorage(projectRoot); err != nil {
+			t.Fatalf("sync to %s storage failed: %v", storage, err)
+		}
+	}
+
+	cacheDir, err := packageCacheDir(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("into the cache", func(t *testing.T) {
+		setStorage(manifest.StorageCache)
+
+		if !isDirLink(packageDir) {
+			t.Fatal("installed package should be a link into the cache")
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, "security-rules.1.0.0", "rules", "secrets.md")); err != nil {
+			t.Errorf("package not in the cache: %v", err)
+		}
+		if isDirLink(projectRulesDir) {
+			t.Error("project rules should stay in the project")
+		}
+
+		// The package is still found, read and verified through the link
+		if ruleFiles, err := findRuleFiles(packageDir); err != nil || len(ruleFiles) != 1 {
+			t.Errorf("rule files through link = %v, %v", ruleFiles, err)
+		}
+		if changes, err := verifyInstalledPackage(packageDir, LockPackageEntry{Files: files}); err != nil || len(changes) != 0 {
+			t.Errorf("verify through link = %v, %v", changes, err)
+		}
+		if version, _, err := findInstalledPackage(filepath.Join(projectRoot, ".rulestack"), "security-rules"); err != nil || version != "1.0.0" {
+			t.Errorf("findInstalledPackage = %q, %v", version, err)
+		}
+
+		gitignore, _ := os.ReadFile(filepath.Join(projectRoot, ".gitignore"))
+		for _, want := range append([]string{"node_modules/", gitignoreStart}, gitignoreStorageRules...) {
+			if !strings.Contains(string(gitignore), want) {
+				t.Errorf(".gitignore missing %q:\n%s", want, gitignore)
+			}
+		}
+
+		// Syncing again changes nothing
+		setStorage(manifest.StorageCache)
+		again, _ := os.ReadFile(filepath.Join(projectRoot, ".gitignore"))
+		if string(again) != string(gitignore) {
+			t.Errorf(".gitignore changed on a second sync:\n%s", again)
+		}
+	})
+
+	t.Run("back into the project", func(t *testing.T) {
+		setStorage(manifest.StorageProject)
+
+		if isDirLink(packageDir) {
+			t.Fatal("installed package should be a directory again")
+		}
+		if _, err := os.Stat(filepath.Join(packageDir, "rules", "secrets.md")); err != nil {
+			t.Errorf("package not restored: %v", err)
+		}
+		if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+			t.Error("project cache directory should be removed")
+		}
+
+		gitignore, _ := os.ReadFile(filepath.Join(projectRoot, ".gitignore"))
+		if string(gitignore) != "node_modules/\n" {
+			t.Errorf(".gitignore = %q, want the managed lines removed", gitignore)
+		}
+	})
+}
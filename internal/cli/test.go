@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/ruletest"
+)
+
+var testPackageName string
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [path]",
+	Short: "Run rule test fixtures for a package",
+	Long: `Run the rule test fixtures of a package source directory (default: current
+directory) and report which fixtures pass.
+
+Fixtures are JSON files in the package's tests directory ("tests" unless the
+package manifest sets "tests"). Each fixture describes an example prompt and/or
+file paths and the rule IDs expected (and not expected) to apply:
+
+  {
+    "name": "handler edits trigger API rules",
+    "prompt": "add a new REST endpoint",
+    "files": ["internal/api/handlers.go"],
+    "expect": ["api-style"],
+    "expect_not": ["frontend-style"]
+  }
+
+A rule applies when its frontmatter sets alwaysApply: true, when a fixture file
+matches one of its globs, or when the prompt contains one of its keywords.
+
+Packages whose manifest sets "tests" ship their fixtures, and registries run them
+before accepting a publish.
+
+Examples:
+  rfh test
+  rfh test ../my-rules --package security-rules`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		packagePath := "."
+		if len(args) == 1 {
+			packagePath = args[0]
+		}
+		return runTest(packagePath)
+	},
+}
+
+// runTest implements the test command logic
+func runTest(packagePath string) error {
+	testsDir := ruletest.DefaultDir
+	if packageManifest := loadTestManifest(packagePath, testPackageName); packageManifest != nil {
+		if packageManifest.Tests != "" {
+			testsDir = packageManifest.Tests
+		}
+		output.Printf("🧪 Testing %s@%s\n", packageManifest.Name, packageManifest.Version)
+	} else if testPackageName != "" {
+		return fmt.Errorf("package '%s' not found in %s", testPackageName, packagePath)
+	}
+
+	report, err := ruletest.Run(packagePath, testsDir)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		output.Printf("📋 Loaded %d rule(s) and %d fixture(s)\n", len(report.Rules), len(report.Results))
+	}
+
+	printTestReport(report)
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d rule test(s) failed", failed, len(report.Results))
+	}
+
+	output.Printf("\n✅ All %d rule test(s) passed\n", len(report.Results))
+	return nil
+}
+
+// loadTestManifest returns the package manifest in packagePath, or nil when the
+// directory has no package manifest (fixtures can still run against loose rules)
+func loadTestManifest(packagePath, packageName string) *manifest.PackageManifest {
+	manifests, err := manifest.LoadPackageManifests(packageManifestPath(packagePath))
+	if err != nil {
+		return nil
+	}
+
+	for i := range manifests {
+		if packageName == "" || manifests[i].Name == packageName {
+			return &manifests[i]
+		}
+	}
+
+	return nil
+}
+
+// printTestReport prints one line per fixture plus details for failures
+func printTestReport(report *ruletest.Report) {
+	for _, result := range report.Results {
+		if result.Passed() {
+			output.Printf("✅ %s\n", result.Fixture.Name)
+			if verbose && len(result.Matched) > 0 {
+				output.Printf("   matched: %s\n", strings.Join(result.Matched, ", "))
+			}
+			continue
+		}
+
+		output.Printf("❌ %s (%s)\n", result.Fixture.Name, filepath.Base(result.Fixture.Path))
+		if len(result.Missing) > 0 {
+			output.Printf("   expected but not applied: %s\n", strings.Join(result.Missing, ", "))
+		}
+		if len(result.Unexpected) > 0 {
+			output.Printf("   applied but expected not to: %s\n", strings.Join(result.Unexpected, ", "))
+		}
+		if len(result.Unknown) > 0 {
+			output.Printf("   unknown rule IDs: %s\n", strings.Join(result.Unknown, ", "))
+		}
+	}
+}
+
+func init() {
+	testCmd.Flags().StringVar(&testPackageName, "package", "", "package to test when the manifest defines several")
+}
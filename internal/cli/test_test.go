@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunTest(t *testing.T) {
+	packageDir := t.TempDir()
+	files := map[string]string{
+		"rulestack.pkg.json":  `{"name": "api-rules", "version": "1.0.0", "files": ["rules/*.mdc"], "tests": "fixtures"}`,
+		"rules/api.mdc":       "---\nid: api-style\nglobs: internal/api/**\nkeywords: endpoint\n---\n# API\n",
+		"fixtures/api.json":   `{"name": "handler edit", "files": ["internal/api/handlers.go"], "expect": ["api-style"]}`,
+		"fixtures/other.json": `{"name": "unrelated prompt", "prompt": "fix the css", "expect_not": ["api-style"]}`,
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(packageDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	if err := runTest(packageDir); err != nil {
+		t.Fatalf("Expected fixtures to pass, got: %v", err)
+	}
+
+	failing := `{"name": "prompt mentions endpoint", "prompt": "add an endpoint", "expect_not": ["api-style"]}`
+	if err := os.WriteFile(filepath.Join(packageDir, "fixtures", "failing.json"), []byte(failing), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	if err := runTest(packageDir); err == nil {
+		t.Error("Expected failing fixture to fail the run")
+	}
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+	"rulestack/internal/output"
+)
+
+// registryTrustCmd manages the keys a Git registry's publishes must be signed with
+var registryTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage keys trusted to sign a Git registry's publishes",
+	Long: `Manage the OpenPGP keys trusted to sign publish commits on a Git registry.
+
+Once a registry has trusted keys, every package file and the index must come
+from a commit signed by one of them, whatever the commit's message. Registries
+with package files from unsigned or untrusted commits are rejected before
+anything is installed from them.`,
+}
+
+var registryTrustAddCmd = &cobra.Command{
+	Use:   "add <key-file>",
+	Short: "Trust an OpenPGP public key",
+	Long: `Trust an armored OpenPGP public key to sign publishes on a Git registry.
+
+Examples:
+  gpg --armor --export release@example.com > release.asc
+  rfh registry trust add release.asc
+  rfh registry trust add release.asc --registry github`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registryName, _ := cmd.Flags().GetString("registry")
+		return runRegistryTrustAdd(registryName, args[0])
+	},
+}
+
+var registryTrustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted keys",
+	Long: `List the fingerprints of the keys trusted to sign publishes on a Git registry.
+
+Examples:
+  rfh registry trust list
+  rfh registry trust list --registry github`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registryName, _ := cmd.Flags().GetString("registry")
+		return runRegistryTrustList(registryName)
+	},
+}
+
+var registryTrustRemoveCmd = &cobra.Command{
+	Use:   "remove <fingerprint>",
+	Short: "Stop trusting a key",
+	Long: `Stop trusting a key, given its fingerprint or 16-character key ID.
+
+Examples:
+  rfh registry trust remove 3AA5C34371567BD2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registryName, _ := cmd.Flags().GetString("registry")
+		return runRegistryTrustRemove(registryName, args[0])
+	},
+}
+
+// loadTrustRegistry loads the CLI config and the Git registry whose keys are managed,
+// defaulting to the active registry
+func loadTrustRegistry(registryName string) (config.CLIConfig, string, config.Registry, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return cfg, "", config.Registry{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if registryName == "" {
+		registryName = cfg.Current
+	}
+	if registryName == "" {
+		return cfg, "", config.Registry{}, fmt.Errorf("no active registry configured. Use --registry or 'rfh registry use'")
+	}
+
+	registry, exists := cfg.Registries[registryName]
+	if !exists {
+		return cfg, "", config.Registry{}, fmt.Errorf("registry '%s' not found. Use 'rfh registry list' to see available registries", registryName)
+	}
+	if registry.GetEffectiveType() != config.RegistryTypeGit {
+		return cfg, "", config.Registry{}, fmt.Errorf("registry '%s' is not a Git registry; only Git registries verify signed publishes", registryName)
+	}
+
+	return cfg, registryName, registry, nil
+}
+
+func runRegistryTrustAdd(registryName, keyFile string) error {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	armoredKey := string(data)
+
+	fingerprint, err := client.KeyFingerprint(armoredKey)
+	if err != nil {
+		return err
+	}
+
+	cfg, registryName, registry, err := loadTrustRegistry(registryName)
+	if err != nil {
+		return err
+	}
+
+	for _, trusted := range registry.TrustedKeys {
+		if existing, err := client.KeyFingerprint(trusted); err == nil && existing == fingerprint {
+			output.Printf("ℹ️  Key %s is already trusted for '%s'\n", fingerprint, registryName)
+			return nil
+		}
+	}
+
+	registry.TrustedKeys = append(registry.TrustedKeys, armoredKey)
+	cfg.Registries[registryName] = registry
+	if err := config.SaveCLI(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	output.Printf("🔑 Trusted key %s for '%s'\n", fingerprint, registryName)
+	return nil
+}
+
+func runRegistryTrustList(registryName string) error {
+	_, registryName, registry, err := loadTrustRegistry(registryName)
+	if err != nil {
+		return err
+	}
+
+	if len(registry.TrustedKeys) == 0 {
+		output.Printf("No trusted keys for '%s'; publish signatures are not verified.\n", registryName)
+		return nil
+	}
+
+	output.Printf("Keys trusted to sign publishes on '%s':\n", registryName)
+	for _, trusted := range registry.TrustedKeys {
+		fingerprint, err := client.KeyFingerprint(trusted)
+		if err != nil {
+			output.Printf("  ⚠️  unreadable key: %v\n", err)
+			continue
+		}
+		output.Printf("  %s\n", fingerprint)
+	}
+
+	return nil
+}
+
+func runRegistryTrustRemove(registryName, fingerprint string) error {
+	fingerprint = strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+	if len(fingerprint) < 16 {
+		return fmt.Errorf("use the full fingerprint or the 16-character key ID")
+	}
+
+	cfg, registryName, registry, err := loadTrustRegistry(registryName)
+	if err != nil {
+		return err
+	}
+
+	var kept []string
+	removed := ""
+	for _, trusted := range registry.TrustedKeys {
+		existing, err := client.KeyFingerprint(trusted)
+		if err == nil && strings.HasSuffix(existing, fingerprint) {
+			removed = existing
+			continue
+		}
+		kept = append(kept, trusted)
+	}
+	if removed == "" {
+		return fmt.Errorf("key %s is not trusted for '%s'", fingerprint, registryName)
+	}
+
+	registry.TrustedKeys = kept
+	cfg.Registries[registryName] = registry
+	if err := config.SaveCLI(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	output.Printf("✅ Removed trusted key %s from '%s'\n", removed, registryName)
+	if len(kept) == 0 {
+		output.Printf("⚠️  No trusted keys remain; publish signatures are no longer verified.\n")
+	}
+
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{registryTrustAddCmd, registryTrustListCmd, registryTrustRemoveCmd} {
+		cmd.Flags().String("registry", "", "Registry to manage (defaults to the active registry)")
+		registryTrustCmd.AddCommand(cmd)
+	}
+}
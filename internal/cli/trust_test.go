@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	"rulestack/internal/config"
+)
+
+func TestRegistryTrust(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"github\"\n\n[registries.github]\nurl = \"https://github.com/org/registry\"\ntype = \"git\"\n\n" +
+		"[registries.public]\nurl = \"https://registry.rulestack.dev\"\ntype = \"remote-http\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	entity, err := openpgp.NewEntity("publisher", "", "publisher@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var buf bytes.Buffer
+	w, _ := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	entity.Serialize(w)
+	w.Close()
+	keyFile := filepath.Join(t.TempDir(), "publisher.asc")
+	os.WriteFile(keyFile, buf.Bytes(), 0644)
+
+	trustedKeys := func() []string {
+		cfg, err := config.LoadCLI()
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+		return cfg.Registries["github"].TrustedKeys
+	}
+
+	if err := runRegistryTrustAdd("", keyFile); err != nil {
+		t.Fatalf("trust add failed: %v", err)
+	}
+	if err := runRegistryTrustAdd("github", keyFile); err != nil {
+		t.Fatalf("expected adding a trusted key again to succeed, got %v", err)
+	}
+	if keys := trustedKeys(); len(keys) != 1 {
+		t.Fatalf("expected one trusted key, got %d", len(keys))
+	}
+
+	if err := runRegistryTrustAdd("public", keyFile); err == nil {
+		t.Error("expected trusting a key for an HTTP registry to fail")
+	}
+
+	if err := runRegistryTrustRemove("", "0000000000000000"); err == nil {
+		t.Error("expected removing an unknown key to fail")
+	}
+	if err := runRegistryTrustRemove("", entity.PrimaryKey.KeyIdString()); err != nil {
+		t.Fatalf("trust remove failed: %v", err)
+	}
+	if keys := trustedKeys(); len(keys) != 0 {
+		t.Errorf("expected no trusted keys after removal, got %d", len(keys))
+	}
+}
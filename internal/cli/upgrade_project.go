@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/manifest"
+	"rulestack/internal/output"
+	"rulestack/internal/version"
+)
+
+var upgradeProjectDryRun bool
+
+// upgradeProjectCmd represents the upgrade-project command
+var upgradeProjectCmd = &cobra.Command{
+	Use:   "upgrade-project",
+	Short: "Upgrade CLAUDE.md and the core rules to the current template",
+	Long: `Upgrade a project created by an older 'rfh init' to the CLAUDE.md and core
+rules template of this rfh version.
+
+The core rules are replaced with the current version, and the CLAUDE.md import
+of the old core rules is pointed at the new ones; the rest of CLAUDE.md is left
+as it is. A diff of every change is shown before anything is written. The
+template version is recorded as "template" in rulestack.json, and the core
+rules as the "core" dependency.
+
+Examples:
+  rfh upgrade-project --dry-run
+  rfh upgrade-project
+  rfh upgrade-project --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUpgradeProject(upgradeProjectDryRun)
+	},
+}
+
+// fileUpdate is a change 'rfh upgrade-project' makes to one file
+type fileUpdate struct {
+	Path string // Relative to the project root
+	From string // Path the file moves from, if it moves
+	Old  string // Current content, empty for a new file
+	New  string
+}
+
+func runUpgradeProject(dryRun bool) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	manifestPath := filepath.Join(projectRoot, "rulestack.json")
+	projectManifest, err := manifest.LoadProjectManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project manifest: %w", err)
+	}
+
+	// Projects from before the template was versioned are known by their core rules
+	current := projectManifest.Template
+	if current == "" {
+		current = installedCoreVersion(filepath.Join(projectRoot, ".rulestack"))
+	}
+
+	if current == projectTemplateVersion {
+		output.Printf("✅ Project is on the current template (v%s)\n", projectTemplateVersion)
+		return nil
+	}
+	if current != "" {
+		if cmp, err := version.CompareVersions(current, projectTemplateVersion); err == nil && cmp > 0 {
+			return fmt.Errorf("project template v%s is newer than this rfh supports (v%s); upgrade rfh", current, projectTemplateVersion)
+		}
+	}
+
+	updates, err := planTemplateUpgrade(projectRoot, current)
+	if err != nil {
+		return err
+	}
+
+	from := "an unversioned template"
+	if current != "" {
+		from = "v" + current
+	}
+	output.Printf("📋 Upgrading project from %s to v%s\n", from, projectTemplateVersion)
+
+	if current != "" {
+		coreRulesPath := filepath.Join(projectRoot, coreRulesDir(current), "core_rules.md")
+		if data, err := os.ReadFile(coreRulesPath); err == nil && fmt.Sprintf("%x", sha256.Sum256(data)) != knownCoreRules[current] {
+			output.Printf("⚠️  %s was modified locally; the changes below will discard the edits\n", filepath.ToSlash(coreRulesDir(current)))
+		}
+	}
+
+	for _, update := range updates {
+		printFileUpdate(update)
+	}
+
+	if dryRun {
+		output.Printf("ℹ️  Dry run: nothing was changed\n")
+		return nil
+	}
+	if !confirm("Apply these changes?", false) {
+		output.Printf("Upgrade cancelled\n")
+		return nil
+	}
+
+	for _, update := range updates {
+		if err := applyFileUpdate(projectRoot, update); err != nil {
+			return err
+		}
+	}
+
+	// Record the new core rules as the "core" dependency; this also removes the
+	// old core rules directory
+	if err := installBundledCore(projectRoot); err != nil {
+		return fmt.Errorf("failed to install core rules: %w", err)
+	}
+
+	projectManifest.Template = projectTemplateVersion
+	if projectManifest.Dependencies == nil {
+		projectManifest.Dependencies = make(map[string]string)
+	}
+	projectManifest.Dependencies[corePackageName] = projectTemplateVersion
+	if err := manifest.SaveProjectManifest(manifestPath, projectManifest); err != nil {
+		return fmt.Errorf("failed to save project manifest: %w", err)
+	}
+
+	output.Printf("✅ Upgraded project to template v%s\n", projectTemplateVersion)
+	return nil
+}
+
+// planTemplateUpgrade works out the file changes that move a project from one
+// template version (empty if unknown) to the current one
+func planTemplateUpgrade(projectRoot, current string) ([]fileUpdate, error) {
+	var updates []fileUpdate
+
+	// The core rules move to the new version's directory
+	coreUpdate := fileUpdate{
+		Path: filepath.ToSlash(filepath.Join(coreRulesDir(projectTemplateVersion), "core_rules.md")),
+		New:  coreRules,
+	}
+	if current != "" {
+		oldCorePath := filepath.ToSlash(filepath.Join(coreRulesDir(current), "core_rules.md"))
+		if data, err := os.ReadFile(filepath.Join(projectRoot, oldCorePath)); err == nil {
+			coreUpdate.From = oldCorePath
+			coreUpdate.Old = string(data)
+		}
+	}
+	updates = append(updates, coreUpdate)
+
+	claudeUpdate := fileUpdate{Path: "CLAUDE.md"}
+	data, err := os.ReadFile(filepath.Join(projectRoot, "CLAUDE.md"))
+	switch {
+	case os.IsNotExist(err):
+		claudeUpdate.New = claudeTemplate()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	default:
+		claudeUpdate.Old = string(data)
+		claudeUpdate.New = upgradeCoreImport(claudeUpdate.Old, current)
+	}
+	if claudeUpdate.New != claudeUpdate.Old {
+		updates = append(updates, claudeUpdate)
+	}
+
+	return updates, nil
+}
+
+// upgradeCoreImport points CLAUDE.md's import of the old core rules at the
+// current ones, adding the import when there is none
+func upgradeCoreImport(content, current string) string {
+	newImport := coreRulesImport(projectTemplateVersion)
+	if strings.Contains(content, newImport) {
+		return content
+	}
+	if current != "" && strings.Contains(content, coreRulesImport(current)) {
+		return strings.Replace(content, coreRulesImport(current), newImport, 1)
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "Active Rules (Rulestack core)") {
+			lines = append(lines[:i+1], append([]string{newImport}, lines[i+1:]...)...)
+			return strings.Join(lines, "\n")
+		}
+	}
+	return strings.TrimRight(content, "\n") + "\n\n## Active Rules (Rulestack core)\n" + newImport + "\n"
+}
+
+func applyFileUpdate(projectRoot string, update fileUpdate) error {
+	path := filepath.Join(projectRoot, filepath.FromSlash(update.Path))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", update.Path, err)
+	}
+	if err := os.WriteFile(path, []byte(update.New), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", update.Path, err)
+	}
+
+	if update.From != "" {
+		if err := os.Remove(filepath.Join(projectRoot, filepath.FromSlash(update.From))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", update.From, err)
+		}
+	}
+	return nil
+}
+
+func printFileUpdate(update fileUpdate) {
+	switch {
+	case update.From != "":
+		output.Printf("\n📝 %s → %s\n", update.From, update.Path)
+	case update.Old == "":
+		output.Printf("\n📄 %s (new)\n", update.Path)
+	default:
+		output.Printf("\n📝 %s\n", update.Path)
+	}
+	for _, line := range lineDiff(update.Old, update.New, 2) {
+		output.Printf("   %s\n", line)
+	}
+}
+
+// diffLine is a line of a diff: unchanged (' '), removed ('-') or added ('+')
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines compares two texts line by line, using their longest common
+// subsequence
+func diffLines(oldText, newText string) []diffLine {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			lines = append(lines, diffLine{' ', oldLines[i]})
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', oldLines[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', newLines[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// lineDiff returns the lines that differ between two texts, prefixed with "-"
+// or "+", with up to context unchanged lines around each change. Longer runs
+// of unchanged lines are elided.
+func lineDiff(oldText, newText string, context int) []string {
+	lines := diffLines(oldText, newText)
+
+	// Keep changed lines and the context around them
+	keep := make([]bool, len(lines))
+	for n, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for k := max(0, n-context); k <= min(len(lines)-1, n+context); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out []string
+	elided := false
+	for n, line := range lines {
+		if !keep[n] {
+			if !elided {
+				out = append(out, "  ...")
+				elided = true
+			}
+			continue
+		}
+		elided = false
+		out = append(out, string(line.op)+" "+line.text)
+	}
+	return out
+}
+
+// splitLines splits text into lines, without a trailing empty line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func init() {
+	upgradeProjectCmd.Flags().BoolVar(&upgradeProjectDryRun, "dry-run", false, "show the changes without applying them")
+}
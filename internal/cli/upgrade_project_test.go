@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rulestack/internal/manifest"
+)
+
+func TestCoreRulesHashIsRecorded(t *testing.T) {
+	if got := fmt.Sprintf("%x", sha256.Sum256([]byte(coreRules))); got != knownCoreRules[projectTemplateVersion] {
+		t.Errorf("knownCoreRules[%q] = %s, but coreRules hashes to %s", projectTemplateVersion, knownCoreRules[projectTemplateVersion], got)
+	}
+}
+
+// setupLegacyProject creates a project as an rfh init from before template
+// versioning left it, with a dependency's rules added to CLAUDE.md
+func setupLegacyProject(t *testing.T) string {
+	t.Helper()
+	projectRoot := t.TempDir()
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(projectRoot)
+
+	if err := manifest.SaveProjectManifest("rulestack.json", manifest.CreateProjectManifest()); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(".rulestack", "core.v1.0.0"), 0755)
+	os.WriteFile(filepath.Join(".rulestack", "core.v1.0.0", "core_rules.md"), []byte("# Core RuleStack Rules v1.0.0\n"), 0644)
+	claude := "# CLAUDE.md\n\n### Active Rules (Rulestack core)\n- @.rulestack/core.v1.0.0/core_rules.md\n- @.rulestack/security-rules.1.2.0/secrets.md\n"
+	os.WriteFile("CLAUDE.md", []byte(claude), 0644)
+	return projectRoot
+}
+
+func TestUpgradeProject(t *testing.T) {
+	projectRoot := setupLegacyProject(t)
+
+	// A dry run changes nothing
+	if err := runUpgradeProject(true); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectRoot, coreRulesDir(projectTemplateVersion))); !os.IsNotExist(err) {
+		t.Fatal("dry run wrote the new core rules")
+	}
+
+	assumeYes = true
+	t.Cleanup(func() { assumeYes = false })
+	if err := runUpgradeProject(false); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectRoot, coreRulesDir(projectTemplateVersion), "core_rules.md"))
+	if err != nil || string(data) != coreRules {
+		t.Errorf("new core rules not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectRoot, ".rulestack", "core.v1.0.0")); !os.IsNotExist(err) {
+		t.Error("old core rules directory not removed")
+	}
+
+	claude, _ := os.ReadFile(filepath.Join(projectRoot, "CLAUDE.md"))
+	if strings.Contains(string(claude), "core.v1.0.0") || !strings.Contains(string(claude), coreRulesImport(projectTemplateVersion)) {
+		t.Errorf("core import not upgraded:\n%s", claude)
+	}
+	if !strings.Contains(string(claude), "- @.rulestack/security-rules.1.2.0/secrets.md") {
+		t.Errorf("package rules lost from CLAUDE.md:\n%s", claude)
+	}
+
+	projectManifest, err := manifest.LoadProjectManifest(filepath.Join(projectRoot, "rulestack.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectManifest.Template != projectTemplateVersion {
+		t.Errorf("template = %q, want %q", projectManifest.Template, projectTemplateVersion)
+	}
+	if projectManifest.Dependencies[corePackageName] != projectTemplateVersion {
+		t.Errorf("core dependency = %q, want %q", projectManifest.Dependencies[corePackageName], projectTemplateVersion)
+	}
+
+	// Nothing is left to do afterwards
+	if err := runUpgradeProject(false); err != nil {
+		t.Fatalf("second upgrade failed: %v", err)
+	}
+}
+
+func TestUpgradeProjectNewerTemplate(t *testing.T) {
+	projectRoot := setupLegacyProject(t)
+	projectManifest := manifest.CreateProjectManifest()
+	projectManifest.Template = "99.0.0"
+	if err := manifest.SaveProjectManifest(filepath.Join(projectRoot, "rulestack.json"), projectManifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runUpgradeProject(false); err == nil || !strings.Contains(err.Error(), "upgrade rfh") {
+		t.Errorf("expected an error asking to upgrade rfh, got %v", err)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\n"
+	newText := "a\nb\nc\nD\ne\nf\ng\nh\n"
+
+	expected := []string{
+		"  ...",
+		"  b",
+		"  c",
+		"- d",
+		"+ D",
+		"  e",
+		"  f",
+		"  g",
+		"+ h",
+	}
+	if got := lineDiff(oldText, newText, 2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("lineDiff() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if got := lineDiff("same\n", "same\n", 2); len(got) != 1 || got[0] != "  ..." {
+		t.Errorf("identical texts should show no changes, got %q", got)
+	}
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"rulestack/internal/client"
+	"rulestack/internal/integrity"
+	"rulestack/internal/output"
+	"rulestack/internal/security"
+	"rulestack/internal/tempdir"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check installed rule files for local modifications",
+	Long: `Recompute the hash of every file under .rulestack/ and compare it with the
+hashes recorded in rulestack.lock.json when the package was installed.
+
+Reports files that were modified, deleted or added since installation. Packages
+installed before per-file hashes were recorded are checked against their archive,
+downloaded again from the registry that served them.
+
+Exits with an error when any difference is found, so it can be used in CI.
+With --recursive, every project under the current directory is verified and
+the command fails if any of them does.
+
+Examples:
+  rfh verify
+  rfh verify --recursive`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if recursive {
+			return runInProjects("verify", runVerify)
+		}
+		return runVerify()
+	},
+}
+
+func init() {
+	verifyCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "verify every project under the current directory")
+}
+
+// fileChange is a difference between an installed file and what was installed
+type fileChange struct {
+	Path   string
+	Change string // "modified", "missing" or "added"
+}
+
+// runVerify implements the verify command logic
+func runVerify() error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find project root: %w", err)
+	}
+
+	lockManifest, err := loadOrCreateLockManifest(filepath.Join(projectRoot, "rulestack.lock.json"), projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load lock manifest: %w", err)
+	}
+
+	entries := make(map[string]LockPackageEntry, len(lockManifest.Packages)+1)
+	for name, entry := range lockManifest.Packages {
+		entries[name] = entry
+	}
+	if lockManifest.Extends != nil {
+		entries[lockManifest.Extends.Package] = *lockManifest.Extends
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rulestackDir := filepath.Join(projectRoot, ".rulestack")
+	tracked := make(map[string]bool, len(entries))
+	changed, unverified := 0, 0
+
+	for _, name := range names {
+		entry := entries[name]
+		packageDir := installedPackageDir(rulestackDir, name, entry)
+		tracked[filepath.Base(packageDir)] = true
+
+		changes, err := verifyInstalledPackage(packageDir, entry)
+		if err != nil {
+			output.Printf("⚠️  %s@%s: not verified: %v\n", name, entry.Version, err)
+			unverified++
+			continue
+		}
+		if len(changes) == 0 {
+			output.Printf("✅ %s@%s\n", name, entry.Version)
+			continue
+		}
+
+		changed += len(changes)
+		output.Printf("❌ %s@%s\n", name, entry.Version)
+		for _, change := range changes {
+			output.Printf("   %-8s %s\n", change.Change, change.Path)
+		}
+	}
+
+	// Directories such as project rules or core rules from before they were a
+	// package have no lock entry
+	if dirEntries, err := os.ReadDir(rulestackDir); err == nil {
+		for _, dirEntry := range dirEntries {
+			if isPackageDir(rulestackDir, dirEntry) && !tracked[dirEntry.Name()] {
+				output.Printf("ℹ️  %s: not in rulestack.lock.json, not verified\n", dirEntry.Name())
+			}
+		}
+	}
+
+	if changed > 0 {
+		return fmt.Errorf("found %d locally modified file(s) under .rulestack/. Reinstall the affected packages to restore them", changed)
+	}
+	if unverified > 0 {
+		return fmt.Errorf("could not verify %d package(s)", unverified)
+	}
+
+	return nil
+}
+
+// installedPackageDir returns the directory a lock entry's package is unpacked into
+func installedPackageDir(rulestackDir, name string, entry LockPackageEntry) string {
+	ref := installedPackageRef(name, entry)
+	return filepath.Join(rulestackDir, packageDirName(ref.Name, ref.Version))
+}
+
+// installedPackageRef returns the name and version a lock entry's package is
+// installed under. Overrides install the replacement package under its own name.
+func installedPackageRef(name string, entry LockPackageEntry) *PackageRef {
+	if entry.OverriddenFrom != "" && entry.Package != "" {
+		name = entry.Package
+	}
+	return &PackageRef{Name: name, Version: entry.Version}
+}
+
+// verifyInstalledPackage compares an installed package directory with the file
+// hashes recorded at install time
+func verifyInstalledPackage(packageDir string, entry LockPackageEntry) ([]fileChange, error) {
+	if _, err := os.Stat(packageDir); err != nil {
+		return nil, fmt.Errorf("package directory is missing; run 'rfh install .'")
+	}
+
+	// Packages kept in the cache are links to their directory there
+	if resolved, err := filepath.EvalSymlinks(packageDir); err == nil {
+		packageDir = resolved
+	}
+
+	expected := entry.Files
+	if expected == nil {
+		// Packages built from source record a hash of their whole directory
+		if entry.Source != "" {
+			contentHash, err := hashDirectoryContents(packageDir)
+			if err != nil {
+				return nil, err
+			}
+			if contentHash != entry.SHA256 {
+				return []fileChange{{Path: ".", Change: "modified"}}, nil
+			}
+			return nil, nil
+		}
+
+		var err error
+		if expected, err = downloadFileHashes(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	actual, err := hashInstalledFiles(packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash installed files: %w", err)
+	}
+
+	var changes []fileChange
+	for path, sum := range expected {
+		switch actualSum, ok := actual[path]; {
+		case !ok:
+			changes = append(changes, fileChange{Path: path, Change: "missing"})
+		case actualSum != sum:
+			changes = append(changes, fileChange{Path: path, Change: "modified"})
+		}
+	}
+	for path := range actual {
+		if _, ok := expected[path]; !ok {
+			changes = append(changes, fileChange{Path: path, Change: "added"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
+// hashInstalledFiles returns the SHA-256 of every file in dir, keyed like the
+// hashes recorded when the package was extracted
+func hashInstalledFiles(dir string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, file); err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(relPath)] = fmt.Sprintf("%x", hasher.Sum(nil))
+		return nil
+	})
+
+	return hashes, err
+}
+
+// downloadFileHashes gets the file hashes of a package installed before they were
+// recorded in the lock file, from its archive on the registry that served it
+func downloadFileHashes(entry LockPackageEntry) (map[string]string, error) {
+	if entry.SHA256 == "" {
+		return nil, fmt.Errorf("no sha256 recorded in rulestack.lock.json")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	registryName := entry.Registry
+	if registryName == "" {
+		registryName = cfg.Current
+	}
+	c, err := client.GetClientForRegistry(cfg, registryName, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("no per-file hashes recorded, and the archive cannot be fetched: %w", err)
+	}
+
+	tempFile, err := tempdir.CreateTemp("verify-*.tgz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	// Large archives may take longer than a request deadline to download
+	if err := c.DownloadBlob(commandContext, entry.SHA256, tempFile.Name()); err != nil {
+		return nil, fmt.Errorf("failed to download archive from %s: %w", registryName, err)
+	}
+
+	// The archive itself must be the one that was installed
+	if _, err := checkArchive(tempFile.Name(), entry.Integrity, entry.SHA256); err != nil {
+		return nil, fmt.Errorf("archive downloaded from %s does not match rulestack.lock.json: %w", registryName, err)
+	}
+
+	// The validator checks paths against a destination; none is written to
+	report, err := security.NewPackageValidator(nil).ScanArchive(tempFile.Name(), filepath.Join(os.TempDir(), "rfh-verify"))
+	if err != nil {
+		return nil, fmt.Errorf("archive downloaded from %s failed validation: %w", registryName, err)
+	}
+	return report.Hashes(), nil
+}
+
+// checkArchive checks a downloaded archive against the digests recorded for it:
+// the integrity string, or just the sha256 from registries and lock files that
+// predate them. It returns the digest checked in the most preferred algorithm,
+// to record in the lock file.
+func checkArchive(path, expectedIntegrity, sha256 string) (string, error) {
+	expected, err := integrity.Expect(expectedIntegrity, sha256)
+	if err != nil {
+		return "", err
+	}
+	if err := integrity.CheckFile(path, expected); err != nil {
+		return "", err
+	}
+	preferred, _ := expected.Preferred()
+	return preferred.String(), nil
+}
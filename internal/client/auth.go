@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"rulestack/internal/config"
 )
 
 // AuthClient handles authentication API calls
@@ -20,11 +22,23 @@ func NewAuthClient(baseURL string) *AuthClient {
 	return &AuthClient{
 		BaseURL: baseURL,
 		Client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:       requestTimeout,
+			Transport:     newTransport(),
+			CheckRedirect: keepTokenOnOrigin(baseURL),
 		},
 	}
 }
 
+// NewRegistryAuthClient creates an authentication client for a registry whose
+// TLS connections are checked against its pinned key, like its registry client
+func NewRegistryAuthClient(registry config.Registry) *AuthClient {
+	c := NewAuthClient(registry.URL)
+	if pin := registryKeyPin(registry); pin != nil {
+		c.SetKeyPin(pin)
+	}
+	return c
+}
+
 // RegisterRequest represents user registration data
 type RegisterRequest struct {
 	Username string `json:"username"`
@@ -41,9 +55,11 @@ type LoginRequest struct {
 
 // AuthResponse represents authentication response
 type AuthResponse struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	User      struct {
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	ExpiresIn   int       `json:"expires_in,omitempty"`   // Seconds the token is valid for
+	IdleTimeout int       `json:"idle_timeout,omitempty"` // Seconds without use after which the session ends, 0 if it does not
+	User        struct {
 		ID       int    `json:"id"`
 		Username string `json:"username"`
 		Email    string `json:"email"`
@@ -0,0 +1,279 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"rulestack/internal/integrity"
+	"rulestack/internal/output"
+	"rulestack/internal/progress"
+)
+
+// chunkedUploadThreshold is the archive size above which PublishPackage uploads
+// in chunks the registry acknowledges one at a time
+var chunkedUploadThreshold int64 = 8 << 20
+
+// chunkRetries is how many failed chunks in a row are retried from the
+// registry's acknowledged offset before the upload gives up
+const chunkRetries = 3
+
+// uploadStateSuffix names the file kept next to an archive while its chunked
+// upload is unfinished, so a later publish resumes instead of starting over
+const uploadStateSuffix = ".upload"
+
+// errChunkedUnsupported reports a registry without the chunked upload endpoints
+var errChunkedUnsupported = errors.New("registry does not support chunked uploads")
+
+// uploadStatus is a registry's description of a chunked upload session
+type uploadStatus struct {
+	ID        string `json:"id"`
+	Size      int64  `json:"size"`
+	Offset    int64  `json:"offset"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+// uploadState is the resumable state of an unfinished upload
+type uploadState struct {
+	Registry string `json:"registry"`
+	ID       string `json:"id"`
+	SHA256   string `json:"sha256"`
+}
+
+// publishChunked uploads an archive through a chunked upload session, resuming
+// one left by an earlier attempt, and completes the publish with the manifest
+func (c *HTTPClient) publishChunked(ctx context.Context, manifestPath, archivePath string, size int64) (*PublishResult, error) {
+	digests, err := integrity.File(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash archive: %w", err)
+	}
+	sha256Digest, _ := digests.Get(integrity.SHA256)
+	sum := sha256Digest.Hex()
+
+	statePath := archivePath + uploadStateSuffix
+	status, err := c.resumeUpload(ctx, statePath, sum)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		if status, err = c.createUpload(ctx, size); err != nil {
+			return nil, err
+		}
+		state, _ := json.Marshal(uploadState{Registry: c.baseURL, ID: status.ID, SHA256: sum})
+		if err := os.WriteFile(statePath, state, 0644); err != nil && c.verbose {
+			output.Printf("⚠️  Could not save upload state, an interrupted upload will restart: %v\n", err)
+		}
+	} else if c.verbose {
+		output.Printf("🔁 Resuming upload at %d of %d bytes\n", status.Offset, status.Size)
+	}
+
+	if err := c.uploadChunks(ctx, archivePath, status); err != nil {
+		return nil, err
+	}
+
+	result, err := c.completeUpload(ctx, status.ID, manifestPath, digests)
+	if !errors.Is(err, ErrNetworkError) {
+		// The session ends with the publish, whatever its outcome
+		os.Remove(statePath)
+	}
+	return result, err
+}
+
+// resumeUpload returns the registry's session for an earlier upload of the same
+// archive, or nil when there is none to resume
+func (c *HTTPClient) resumeUpload(ctx context.Context, statePath, sum string) (*uploadStatus, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return nil, nil
+	}
+	var state uploadState
+	if json.Unmarshal(data, &state) != nil || state.Registry != c.baseURL || state.SHA256 != sum {
+		os.Remove(statePath)
+		return nil, nil
+	}
+
+	status, err := c.getUpload(ctx, state.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// Expired or already completed
+			os.Remove(statePath)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+func (c *HTTPClient) createUpload(ctx context.Context, size int64) (*uploadStatus, error) {
+	body, _ := json.Marshal(map[string]int64{"size": size})
+	resp, err := c.makeRequestWithContext(ctx, "POST", "/v1/uploads", bytes.NewReader(body), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return decodeUploadStatus(resp.Body)
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, errChunkedUnsupported
+	case http.StatusUnauthorized:
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	default:
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, respBody, ErrPublishFailed,
+			fmt.Sprintf("status %d: %s", resp.StatusCode, errorMessage(respBody)))
+	}
+}
+
+func (c *HTTPClient) getUpload(ctx context.Context, id string) (*uploadStatus, error) {
+	resp, err := c.makeRequestWithContext(ctx, "GET", "/v1/uploads/"+id, nil, "")
+	if err != nil {
+		return nil, NewRegistryError(ErrNetworkError, err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return decodeUploadStatus(resp.Body)
+	case http.StatusNotFound:
+		return nil, NewRegistryError(ErrNotFound, "upload not found")
+	case http.StatusUnauthorized:
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	default:
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, respBody, ErrNetworkError,
+			fmt.Sprintf("status %d: %s", resp.StatusCode, errorMessage(respBody)))
+	}
+}
+
+// uploadChunks sends the rest of the archive from the registry's acknowledged
+// offset. A failed chunk is retried from wherever the registry says it got to.
+func (c *HTTPClient) uploadChunks(ctx context.Context, archivePath string, status *uploadStatus) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chunkSize := status.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 4 << 20
+	}
+
+	// Progress covers what is left to send, from each point the upload resumes at
+	event := progress.Event{Op: "upload", Phase: "progress", Subject: filepath.Base(archivePath)}
+	var remaining io.Reader
+	seek := func(offset int64) error {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		remaining = progress.Reader(file, event, status.Size-offset)
+		return nil
+	}
+
+	offset := status.Offset
+	if err := seek(offset); err != nil {
+		return err
+	}
+	failures := 0
+	for offset < status.Size {
+		length := min(chunkSize, status.Size-offset)
+		next, err := c.patchUpload(ctx, status.ID, offset, io.LimitReader(remaining, length))
+		if err == nil && next == offset+length {
+			offset = next
+			failures = 0
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("registry stored %d bytes of a %d byte chunk", next-offset, length)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("upload interrupted at %d of %d bytes; publish again to resume: %w", offset, status.Size, ctx.Err())
+		}
+
+		failures++
+		if failures > chunkRetries {
+			return fmt.Errorf("upload failed at %d of %d bytes; publish again to resume: %w", offset, status.Size, err)
+		}
+		if c.verbose {
+			output.Printf("⚠️  Chunk at %d failed, resuming: %v\n", offset, err)
+		}
+		current, statusErr := c.getUpload(ctx, status.ID)
+		if statusErr != nil {
+			return fmt.Errorf("upload failed at %d of %d bytes; publish again to resume: %w", offset, status.Size, err)
+		}
+		offset = current.Offset
+		if err := seek(offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchUpload sends one chunk and returns the registry's new offset
+func (c *HTTPClient) patchUpload(ctx context.Context, id string, offset int64, chunk io.Reader) (int64, error) {
+	resp, err := c.makeTransferRequest(ctx, "PATCH", "/v1/uploads/"+id, chunk, map[string]string{
+		"Content-Type":  "application/octet-stream",
+		"Upload-Offset": strconv.FormatInt(offset, 10),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("chunk upload failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+	status, err := decodeUploadStatus(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	return status.Offset, nil
+}
+
+// completeUpload publishes an uploaded archive with its manifest. Registries
+// that predate integrity strings check the sha256 alone.
+func (c *HTTPClient) completeUpload(ctx context.Context, id, manifestPath string, digests integrity.Integrity) (*PublishResult, error) {
+	sha256Digest, _ := digests.Get(integrity.SHA256)
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	if err := c.addFileToForm(writer, "manifest", manifestPath); err != nil {
+		return nil, fmt.Errorf("failed to add manifest: %w", err)
+	}
+	if err := writer.WriteField("sha256", sha256Digest.Hex()); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteField("integrity", digests.String()); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.makeRequestWithContext(ctx, "POST", "/v1/uploads/"+id+"/complete", &form, writer.FormDataContentType())
+	if err != nil {
+		return nil, NewRegistryError(ErrNetworkError, err.Error())
+	}
+	defer resp.Body.Close()
+
+	return c.publishResponse(resp)
+}
+
+func decodeUploadStatus(body io.Reader) (*uploadStatus, error) {
+	var status uploadStatus
+	if err := json.NewDecoder(body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode upload session: %w", err)
+	}
+	return &status, nil
+}
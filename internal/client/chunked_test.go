@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"rulestack/internal/integrity"
+)
+
+// fakeUploadRegistry serves the chunked upload endpoints for one session,
+// failing the first chunk partway through
+type fakeUploadRegistry struct {
+	received  []byte
+	size      int64
+	creates   int
+	failFirst bool
+	sha256    string
+	integrity string
+}
+
+func (f *fakeUploadRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := func(code int) {
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(uploadStatus{ID: "abc", Size: f.size, Offset: int64(len(f.received)), ChunkSize: 100})
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v1/uploads":
+		var req struct{ Size int64 }
+		json.NewDecoder(r.Body).Decode(&req)
+		f.size, f.received = req.Size, nil
+		f.creates++
+		status(http.StatusCreated)
+	case r.Method == "GET" && r.URL.Path == "/v1/uploads/abc":
+		status(http.StatusOK)
+	case r.Method == "PATCH" && r.URL.Path == "/v1/uploads/abc":
+		offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset"))
+		if offset != len(f.received) {
+			status(http.StatusConflict)
+			return
+		}
+		chunk, _ := io.ReadAll(r.Body)
+		if f.failFirst {
+			// The connection dropped after part of the chunk arrived
+			f.failFirst = false
+			f.received = append(f.received, chunk[:len(chunk)/2]...)
+			http.Error(w, "connection reset", http.StatusBadGateway)
+			return
+		}
+		f.received = append(f.received, chunk...)
+		status(http.StatusOK)
+	case r.Method == "POST" && r.URL.Path == "/v1/uploads/abc/complete":
+		f.sha256 = r.FormValue("sha256")
+		f.integrity = r.FormValue("integrity")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"name": "rules", "version": "1.0.0", "sha256": %q}`, f.sha256)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPublishPackageChunked(t *testing.T) {
+	old := chunkedUploadThreshold
+	chunkedUploadThreshold = 100
+	t.Cleanup(func() { chunkedUploadThreshold = old })
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.json")
+	archivePath := filepath.Join(dir, "rules-1.0.0.tgz")
+	archive := bytes.Repeat([]byte("0123456789"), 35)
+	os.WriteFile(manifestPath, []byte(`{"name": "rules", "version": "1.0.0"}`), 0644)
+	os.WriteFile(archivePath, archive, 0644)
+	sum := fmt.Sprintf("%x", sha256.Sum256(archive))
+
+	t.Run("resumes a failed chunk", func(t *testing.T) {
+		registry := &fakeUploadRegistry{failFirst: true}
+		server := httptest.NewServer(registry)
+		defer server.Close()
+
+		result, err := NewHTTPClient(server.URL, "token", false).PublishPackage(context.Background(), manifestPath, archivePath)
+		if err != nil {
+			t.Fatalf("PublishPackage failed: %v", err)
+		}
+		if !bytes.Equal(registry.received, archive) || registry.sha256 != sum || result.SHA256 != sum {
+			t.Errorf("registry received %d of %d bytes with sha256 %q", len(registry.received), len(archive), registry.sha256)
+		}
+		if expected, _ := integrity.Parse(registry.integrity); len(expected) != len(integrity.Algorithms) || integrity.CheckFile(archivePath, expected) != nil {
+			t.Errorf("expected the archive's digests sent with the upload, got %q", registry.integrity)
+		}
+		if _, err := os.Stat(archivePath + uploadStateSuffix); !os.IsNotExist(err) {
+			t.Error("expected the upload state removed after publishing")
+		}
+	})
+
+	t.Run("resumes an earlier session", func(t *testing.T) {
+		registry := &fakeUploadRegistry{size: int64(len(archive)), received: archive[:250]}
+		server := httptest.NewServer(registry)
+		defer server.Close()
+
+		state, _ := json.Marshal(uploadState{Registry: server.URL, ID: "abc", SHA256: sum})
+		os.WriteFile(archivePath+uploadStateSuffix, state, 0644)
+
+		if _, err := NewHTTPClient(server.URL, "token", false).PublishPackage(context.Background(), manifestPath, archivePath); err != nil {
+			t.Fatalf("PublishPackage failed: %v", err)
+		}
+		if registry.creates != 0 || !bytes.Equal(registry.received, archive) {
+			t.Errorf("expected the session resumed at 250 bytes, got %d creates and %d bytes", registry.creates, len(registry.received))
+		}
+	})
+
+	t.Run("falls back without chunked uploads", func(t *testing.T) {
+		var published bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/packages" {
+				http.NotFound(w, r)
+				return
+			}
+			published = true
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"name": "rules", "version": "1.0.0"}`)
+		}))
+		defer server.Close()
+
+		if _, err := NewHTTPClient(server.URL, "token", false).PublishPackage(context.Background(), manifestPath, archivePath); err != nil || !published {
+			t.Errorf("expected a single-request publish, got %v", err)
+		}
+	})
+}
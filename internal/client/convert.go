@@ -5,12 +5,16 @@ import "time"
 // PackageToMap converts Package to map for backward compatibility
 func PackageToMap(p *Package) map[string]interface{} {
 	return map[string]interface{}{
-		"name":        p.Name,
-		"description": p.Description,
-		"latest":      p.Latest,
-		"versions":    p.Versions,
-		"tags":        p.Tags,
-		"updated_at":  p.UpdatedAt,
+		"name":         p.Name,
+		"description":  p.Description,
+		"latest":       p.Latest,
+		"versions":     p.Versions,
+		"tags":         p.Tags,
+		"license":      p.License,
+		"dependencies": p.Dependencies,
+		"official":     p.Official,
+		"verified_org": p.VerifiedOrg,
+		"updated_at":   p.UpdatedAt,
 	}
 }
 
@@ -22,7 +26,9 @@ func PackageVersionToMap(pv *PackageVersion) map[string]interface{} {
 		"description":  pv.Description,
 		"dependencies": pv.Dependencies,
 		"sha256":       pv.SHA256,
+		"integrity":    pv.Integrity,
 		"size":         pv.Size,
+		"license":      pv.License,
 		"published_at": pv.PublishedAt,
 		"metadata":     pv.Metadata,
 	}
@@ -57,6 +63,16 @@ func MapToPackage(m map[string]interface{}) *Package {
 			}
 		}
 	}
+	if license, ok := m["license"].(string); ok {
+		p.License = license
+	}
+	p.Dependencies = mapToDependencies(m["dependencies"])
+	if official, ok := m["official"].(bool); ok {
+		p.Official = official
+	}
+	if verifiedOrg, ok := m["verified_org"].(string); ok {
+		p.VerifiedOrg = verifiedOrg
+	}
 	if updatedAt, ok := m["updated_at"].(time.Time); ok {
 		p.UpdatedAt = updatedAt
 	}
@@ -77,23 +93,35 @@ func MapToPackageVersion(m map[string]interface{}) *PackageVersion {
 	if desc, ok := m["description"].(string); ok {
 		pv.Description = desc
 	}
-	if deps, ok := m["dependencies"].(map[string]interface{}); ok {
-		pv.Dependencies = make(map[string]string)
-		for k, v := range deps {
-			if str, ok := v.(string); ok {
-				pv.Dependencies[k] = str
-			}
-		}
-	}
+	pv.Dependencies = mapToDependencies(m["dependencies"])
 	if sha256, ok := m["sha256"].(string); ok {
 		pv.SHA256 = sha256
 	}
-	if size, ok := m["size"].(int64); ok {
+	if integrity, ok := m["integrity"].(string); ok {
+		pv.Integrity = integrity
+	}
+	// Decoded JSON numbers are float64; HTTP registries report the size as size_bytes
+	switch size := m["size"].(type) {
+	case int64:
 		pv.Size = size
+	case float64:
+		pv.Size = int64(size)
+	}
+	if size, ok := m["size_bytes"].(float64); ok && pv.Size == 0 {
+		pv.Size = int64(size)
 	}
 	if publishedAt, ok := m["published_at"].(time.Time); ok {
 		pv.PublishedAt = publishedAt
 	}
+	if deprecated, ok := m["deprecated"].(string); ok {
+		pv.Deprecated = deprecated
+	}
+	if replacement, ok := m["replacement"].(string); ok {
+		pv.Replacement = replacement
+	}
+	if license, ok := m["license"].(string); ok {
+		pv.License = license
+	}
 	if metadata, ok := m["metadata"].(map[string]interface{}); ok {
 		pv.Metadata = metadata
 	}
@@ -101,13 +129,30 @@ func MapToPackageVersion(m map[string]interface{}) *PackageVersion {
 	return pv
 }
 
+// mapToDependencies converts a decoded "dependencies" object to a map of
+// version ranges, or nil if there is none
+func mapToDependencies(value interface{}) map[string]string {
+	deps, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	dependencies := make(map[string]string, len(deps))
+	for k, v := range deps {
+		if str, ok := v.(string); ok {
+			dependencies[k] = str
+		}
+	}
+	return dependencies
+}
+
 // PublishResultToMap converts PublishResult to map for backward compatibility
 func PublishResultToMap(pr *PublishResult) map[string]interface{} {
 	return map[string]interface{}{
-		"name":    pr.Name,
-		"version": pr.Version,
-		"sha256":  pr.SHA256,
-		"url":     pr.URL,
-		"message": pr.Message,
+		"name":      pr.Name,
+		"version":   pr.Version,
+		"sha256":    pr.SHA256,
+		"integrity": pr.Integrity,
+		"url":       pr.URL,
+		"message":   pr.Message,
 	}
 }
@@ -68,12 +68,16 @@ func TestPackageVersionToMap(t *testing.T) {
 func TestMapToPackage(t *testing.T) {
 	updatedAt := time.Now()
 	m := map[string]interface{}{
-		"name":        "test-package",
-		"description": "A test package",
-		"latest":      "1.0.0",
-		"versions":    []interface{}{"1.0.0", "0.9.0"},
-		"tags":        []interface{}{"security", "rules"},
-		"updated_at":  updatedAt,
+		"name":         "test-package",
+		"description":  "A test package",
+		"latest":       "1.0.0",
+		"versions":     []interface{}{"1.0.0", "0.9.0"},
+		"tags":         []interface{}{"security", "rules"},
+		"license":      "Apache-2.0",
+		"dependencies": map[string]interface{}{"base-rules": "^1.2"},
+		"official":     true,
+		"verified_org": "acme",
+		"updated_at":   updatedAt,
 	}
 
 	pkg := MapToPackage(m)
@@ -93,7 +97,37 @@ func TestMapToPackage(t *testing.T) {
 	if len(pkg.Tags) != 2 || pkg.Tags[0] != "security" {
 		t.Errorf("expected tags [security, rules], got %v", pkg.Tags)
 	}
+	if pkg.License != "Apache-2.0" {
+		t.Errorf("expected license %q, got %q", "Apache-2.0", pkg.License)
+	}
+	if pkg.Dependencies["base-rules"] != "^1.2" {
+		t.Errorf("expected dependencies {base-rules: ^1.2}, got %v", pkg.Dependencies)
+	}
+	if !pkg.Official || pkg.VerifiedOrg != "acme" {
+		t.Errorf("expected the official badge verified for acme, got %v and %q", pkg.Official, pkg.VerifiedOrg)
+	}
 	if pkg.UpdatedAt != updatedAt {
 		t.Errorf("expected updated_at %v, got %v", updatedAt, pkg.UpdatedAt)
 	}
 }
+
+func TestMapToPackageVersionSize(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]interface{}
+		want int64
+	}{
+		{"int64 size", map[string]interface{}{"size": int64(512)}, 512},
+		{"decoded JSON size", map[string]interface{}{"size": float64(1024)}, 1024},
+		{"HTTP registry size_bytes", map[string]interface{}{"size_bytes": float64(2048)}, 2048},
+		{"no size", map[string]interface{}{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MapToPackageVersion(tt.m).Size; got != tt.want {
+				t.Errorf("expected size %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package client
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
 
 // Common registry error types
 var (
@@ -16,20 +20,63 @@ var (
 	ErrNotImplemented    = fmt.Errorf("not implemented")
 	ErrNotFound          = fmt.Errorf("not found")
 	ErrInvalidOperation  = fmt.Errorf("invalid operation")
+	ErrUntrustedRegistry = fmt.Errorf("untrusted registry")
+	ErrKeyPinMismatch    = fmt.Errorf("pinned key mismatch")
+	ErrVersionExists     = fmt.Errorf("version already exists")
+	ErrQuotaExceeded     = fmt.Errorf("quota exceeded")
+	ErrInvalidName       = fmt.Errorf("invalid package name")
+	ErrForbidden         = fmt.Errorf("forbidden")
+
+	// ErrPendingReview is a Git registry version whose publish pull request has
+	// not merged yet. It is also an ErrVersionNotFound.
+	ErrPendingReview = &subError{"version pending review", ErrVersionNotFound}
+
+	// ErrTimedOut is a request the registry did not answer in time, or an
+	// archive transfer that stopped making progress. It is also an ErrNetworkError.
+	ErrTimedOut = &subError{"timed out", ErrNetworkError}
 )
 
+// subError is an error type that is a case of a more general one
+type subError struct {
+	message string
+	parent  error
+}
+
+func (e *subError) Error() string { return e.message }
+
+func (e *subError) Unwrap() error { return e.parent }
+
+// apiErrorCodes maps the error codes of HTTP registry responses to error types.
+// Codes not listed keep the error type of the request that failed.
+var apiErrorCodes = map[string]error{
+	"unauthorized":     ErrUnauthorized,
+	"forbidden":        ErrForbidden,
+	"name_reserved":    ErrForbidden,
+	"version_exists":   ErrVersionExists,
+	"quota_exceeded":   ErrQuotaExceeded,
+	"name_invalid":     ErrInvalidName,
+	"invalid_manifest": ErrInvalidManifest,
+	"rate_limited":     ErrRateLimited,
+}
+
 // RegistryError provides detailed error information
 type RegistryError struct {
-	Type    error
-	Message string
-	Details map[string]interface{}
+	Type      error
+	Message   string
+	Details   map[string]interface{}
+	Code      string // Error code of an HTTP registry's response, e.g. "version_exists"
+	RequestID string // ID of the failed request, for the registry's operators
 }
 
 func (e *RegistryError) Error() string {
+	message := e.Type.Error()
 	if e.Message != "" {
-		return fmt.Sprintf("%v: %s", e.Type, e.Message)
+		message = fmt.Sprintf("%v: %s", e.Type, e.Message)
 	}
-	return e.Type.Error()
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request %s)", e.RequestID)
+	}
+	return message
 }
 
 func (e *RegistryError) Unwrap() error {
@@ -44,3 +91,31 @@ func NewRegistryError(errType error, message string) *RegistryError {
 		Details: make(map[string]interface{}),
 	}
 }
+
+// responseError reads an HTTP registry's error response. Its error code picks
+// the error type when rfh knows it; otherwise the error has type errType and
+// message, as for registries that predate error codes.
+func responseError(resp *http.Response, body []byte, errType error, message string) *RegistryError {
+	var apiErr struct {
+		Error     string                 `json:"error"`
+		Code      string                 `json:"code"`
+		Details   map[string]interface{} `json:"details"`
+		RequestID string                 `json:"request_id"`
+	}
+	json.Unmarshal(body, &apiErr)
+
+	err := NewRegistryError(errType, message)
+	if known, ok := apiErrorCodes[apiErr.Code]; ok && apiErr.Error != "" {
+		err.Type = known
+		err.Message = apiErr.Error
+	}
+	for key, value := range apiErr.Details {
+		err.Details[key] = value
+	}
+	err.Code = apiErr.Code
+	err.RequestID = apiErr.RequestID
+	if err.RequestID == "" {
+		err.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	return err
+}
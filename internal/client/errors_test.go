@@ -2,6 +2,8 @@ package client
 
 import (
 	"errors"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -41,3 +43,77 @@ func TestRegistryError(t *testing.T) {
 		}
 	})
 }
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		header      string
+		wantType    error
+		wantMessage string
+		wantID      string
+	}{
+		{
+			name:        "version exists",
+			body:        `{"error": "Package version already exists", "code": "version_exists", "details": {"version": "1.0.0"}, "request_id": "abc123"}`,
+			wantType:    ErrVersionExists,
+			wantMessage: "Package version already exists",
+			wantID:      "abc123",
+		},
+		{
+			name:        "quota exceeded",
+			body:        `{"error": "Archive exceeds the maximum size of 10 bytes", "code": "quota_exceeded"}`,
+			wantType:    ErrQuotaExceeded,
+			wantMessage: "Archive exceeds the maximum size of 10 bytes",
+		},
+		{
+			name:        "name invalid",
+			body:        `{"error": "invalid name", "code": "name_invalid"}`,
+			wantType:    ErrInvalidName,
+			wantMessage: "invalid name",
+		},
+		{
+			name:        "forbidden",
+			body:        `{"error": "Insufficient permissions", "code": "forbidden"}`,
+			wantType:    ErrForbidden,
+			wantMessage: "Insufficient permissions",
+		},
+		{
+			name:        "unknown code keeps the request's error type",
+			body:        `{"error": "Failed to publish package version", "code": "internal_error"}`,
+			header:      "req-7",
+			wantType:    ErrPublishFailed,
+			wantMessage: "status 500",
+			wantID:      "req-7",
+		},
+		{
+			name:        "registry without codes",
+			body:        `{"error": "Package version already exists"}`,
+			wantType:    ErrPublishFailed,
+			wantMessage: "status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("X-Request-ID", tt.header)
+			}
+
+			err := responseError(resp, []byte(tt.body), ErrPublishFailed, "status 500")
+			if !errors.Is(err, tt.wantType) {
+				t.Errorf("expected %v, got %v", tt.wantType, err.Type)
+			}
+			if !strings.Contains(err.Message, tt.wantMessage) {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, err.Message)
+			}
+			if err.RequestID != tt.wantID {
+				t.Errorf("expected request ID %q, got %q", tt.wantID, err.RequestID)
+			}
+			if tt.wantID != "" && !strings.HasSuffix(err.Error(), "(request "+tt.wantID+")") {
+				t.Errorf("expected the request ID in %q", err.Error())
+			}
+		})
+	}
+}
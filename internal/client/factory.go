@@ -12,11 +12,22 @@ func NewRegistryClient(registry config.Registry, verbose bool) (RegistryClient,
 	switch registryType {
 	case config.RegistryTypeHTTP:
 		// Create new HTTP client that implements RegistryClient interface
-		return NewHTTPClient(registry.URL, registry.JWTToken, verbose), nil
+		httpClient := NewHTTPClient(registry.URL, registry.AuthToken(), verbose)
+		if pin := registryKeyPin(registry); pin != nil {
+			httpClient.SetKeyPin(pin)
+		}
+		return httpClient, nil
 
 	case config.RegistryTypeGit:
 		// Git client will be implemented in later phases
-		return NewGitRegistryClient(registry.URL, registry.GitToken, verbose)
+		gitClient, err := NewGitClient(registry.URL, registry.AuthToken(), verbose)
+		if err != nil {
+			return nil, err
+		}
+		gitClient.requireApproval = registry.RequireApproval
+		gitClient.apiURL = registry.APIURL
+		gitClient.trustedKeys = registry.TrustedKeys
+		return gitClient, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported registry type: %s", registryType)
@@ -34,7 +45,7 @@ func GetClient(cfg config.CLIConfig, verbose bool) (RegistryClient, error) {
 		return nil, fmt.Errorf("active registry '%s' not found in configuration", cfg.Current)
 	}
 
-	return NewRegistryClient(registry, verbose)
+	return newConfiguredClient(cfg, registry, verbose)
 }
 
 // GetClientForRegistry creates a client for a specific named registry
@@ -44,7 +55,20 @@ func GetClientForRegistry(cfg config.CLIConfig, registryName string, verbose boo
 		return nil, fmt.Errorf("registry '%s' not found", registryName)
 	}
 
-	return NewRegistryClient(registry, verbose)
+	return newConfiguredClient(cfg, registry, verbose)
+}
+
+// newConfiguredClient creates a client for a registry with the CLI-wide settings
+// that apply to it, such as the Git cache size cap
+func newConfiguredClient(cfg config.CLIConfig, registry config.Registry, verbose bool) (RegistryClient, error) {
+	c, err := NewRegistryClient(registry, verbose)
+	if err != nil {
+		return nil, err
+	}
+	if gitClient, ok := c.(*GitClient); ok {
+		gitClient.SetCacheMaxSize(cfg.Cache.MaxSize)
+	}
+	return c, nil
 }
 
 // Placeholder functions for clients that will be implemented in later phases
@@ -1,7 +1,11 @@
 package client
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"rulestack/internal/config"
+	"sync"
 	"testing"
 )
 
@@ -91,3 +95,65 @@ func TestNewRegistryClient(t *testing.T) {
 		}
 	})
 }
+
+func TestCredentialIsolation(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	record := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			seen[name] = r.Header.Get("Authorization")
+			mu.Unlock()
+			w.Write([]byte(`{"status": "ok"}`))
+		}
+	}
+
+	other := httptest.NewServer(record("other"))
+	defer other.Close()
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" && r.URL.Query().Get("redirected") == "" {
+			http.Redirect(w, r, other.URL+"/v1/health", http.StatusFound)
+			return
+		}
+		record("registry")(w, r)
+	}))
+	defer registry.Close()
+
+	t.Run("token not sent to a registry it was not issued for", func(t *testing.T) {
+		c, err := NewRegistryClient(config.Registry{URL: registry.URL, Type: config.RegistryTypeHTTP, JWTToken: "secret", TokenURL: "https://elsewhere.example.com"}, false)
+		if err != nil {
+			t.Fatalf("NewRegistryClient failed: %v", err)
+		}
+		resp, err := c.(*HTTPClient).makeRequestWithContext(context.Background(), "GET", "/v1/health?redirected=1", nil, "")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if seen["registry"] != "" {
+			t.Errorf("expected no Authorization header, got %q", seen["registry"])
+		}
+	})
+
+	t.Run("token dropped on redirect to another origin", func(t *testing.T) {
+		c, err := NewRegistryClient(config.Registry{URL: registry.URL, Type: config.RegistryTypeHTTP, JWTToken: "secret", TokenURL: registry.URL}, false)
+		if err != nil {
+			t.Fatalf("NewRegistryClient failed: %v", err)
+		}
+		if err := c.Health(context.Background()); err != nil {
+			t.Fatalf("Health failed: %v", err)
+		}
+		if seen["other"] != "" {
+			t.Errorf("expected the redirect target not to receive the token, got %q", seen["other"])
+		}
+
+		resp, err := c.(*HTTPClient).makeRequestWithContext(context.Background(), "GET", "/v1/health?redirected=1", nil, "")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if seen["registry"] != "Bearer secret" {
+			t.Errorf("expected the issuing registry to receive the token, got %q", seen["registry"])
+		}
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	nethttp "net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,12 +17,20 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/go-github/v67/github"
 
+	"rulestack/internal/compression"
 	rfhconfig "rulestack/internal/config"
+	"rulestack/internal/output"
+	"rulestack/internal/progress"
+	"rulestack/internal/tempdir"
+	"rulestack/internal/tracing"
+	"rulestack/internal/version"
 )
 
 // GitClient implements RegistryClient for Git-based registries
@@ -31,11 +41,23 @@ type GitClient struct {
 	cacheDir string
 	repo     *git.Repository
 	mu       sync.Mutex // Protects repo operations
+
+	requireApproval bool   // Publish PRs must be approved by a second reviewer
+	apiURL          string // REST API base of the hosting provider; derived from repoURL when empty
+
+	cacheMaxSize int64 // Cap on the whole Git registry cache; see SetCacheMaxSize
+	cacheChecked bool  // The cache has been trimmed to the cap by this client
+
+	trustedKeys  []string      // Armored OpenPGP keys commits changing packages must be signed with; none disables verification
+	verifiedHead plumbing.Hash // Last HEAD whose publish history passed verification
 }
 
 // Ensure GitClient implements RegistryClient
 var _ RegistryClient = (*GitClient)(nil)
 
+// installTracedTransport makes go-git's HTTPS clones, fetches and pushes traced
+var installTracedTransport sync.Once
+
 // NewGitClient creates a new Git registry client
 func NewGitClient(repoURL, gitToken string, verbose bool) (*GitClient, error) {
 	// Clean up repo URL
@@ -44,6 +66,10 @@ func NewGitClient(repoURL, gitToken string, verbose bool) (*GitClient, error) {
 		repoURL += ".git"
 	}
 
+	installTracedTransport.Do(func() {
+		gitclient.InstallProtocol("https", http.NewClient(&nethttp.Client{Transport: tracing.NewTransport(nil)}))
+	})
+
 	// Determine cache directory
 	cacheDir, err := getGitCacheDir(repoURL)
 	if err != nil {
@@ -86,9 +112,26 @@ func getGitCacheDir(repoURL string) (string, error) {
 
 // ensureRepo ensures the repository is cloned and up to date
 func (c *GitClient) ensureRepo(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "git sync")
+	defer span.End()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if err := c.syncRepo(ctx); err != nil {
+		return err
+	}
+	c.recordCacheUse()
+
+	if len(c.trustedKeys) > 0 {
+		return c.verifyPublishHistory()
+	}
+
+	return nil
+}
+
+// syncRepo opens or clones the cached repository and pulls the latest changes
+func (c *GitClient) syncRepo(ctx context.Context) error {
 	// Check if already cloned
 	if c.repo != nil {
 		return c.pullLatest(ctx)
@@ -98,12 +141,13 @@ func (c *GitClient) ensureRepo(ctx context.Context) error {
 	if _, err := os.Stat(filepath.Join(c.cacheDir, ".git")); err == nil {
 		// Repository exists, open it
 		if c.verbose {
-			fmt.Printf("📂 Opening cached repository at %s\n", c.cacheDir)
+			output.Printf("📂 Opening cached repository at %s\n", c.cacheDir)
 		}
 
 		repo, err := git.PlainOpen(c.cacheDir)
 		if err != nil {
-			return fmt.Errorf("failed to open repository: %w", err)
+			output.Fprintf(os.Stderr, "⚠️  Registry cache at %s is unreadable (%v); cloning it again\n", c.cacheDir, err)
+			return c.recloneRepo(ctx)
 		}
 
 		c.repo = repo
@@ -117,8 +161,8 @@ func (c *GitClient) ensureRepo(ctx context.Context) error {
 // cloneRepo clones the repository to the cache directory
 func (c *GitClient) cloneRepo(ctx context.Context) error {
 	if c.verbose {
-		fmt.Printf("📥 Cloning repository %s\n", c.repoURL)
-		fmt.Printf("📂 Cache directory: %s\n", c.cacheDir)
+		output.Printf("📥 Cloning repository %s\n", c.repoURL)
+		output.Printf("📂 Cache directory: %s\n", c.cacheDir)
 	}
 
 	// Create cache directory
@@ -129,11 +173,7 @@ func (c *GitClient) cloneRepo(ctx context.Context) error {
 	// Prepare clone options
 	cloneOpts := &git.CloneOptions{
 		URL:      c.repoURL,
-		Progress: nil,
-	}
-
-	if c.verbose {
-		cloneOpts.Progress = os.Stdout
+		Progress: gitProgressOutput("clone", c.repoURL, c.verbose),
 	}
 
 	// Add authentication if token provided
@@ -142,7 +182,9 @@ func (c *GitClient) cloneRepo(ctx context.Context) error {
 	}
 
 	// Clone with context
+	progress.Start("clone", c.repoURL)
 	repo, err := git.PlainCloneContext(ctx, c.cacheDir, false, cloneOpts)
+	progress.Done("clone", c.repoURL, err)
 	if err != nil {
 		if err == transport.ErrAuthenticationRequired {
 			return NewRegistryError(ErrUnauthorized,
@@ -154,7 +196,7 @@ func (c *GitClient) cloneRepo(ctx context.Context) error {
 	c.repo = repo
 
 	if c.verbose {
-		fmt.Printf("✅ Repository cloned successfully\n")
+		output.Printf("✅ Repository cloned successfully\n")
 	}
 
 	return nil
@@ -172,16 +214,18 @@ func (c *GitClient) pullLatest(ctx context.Context) error {
 	}
 
 	if c.verbose {
-		fmt.Printf("🔄 Pulling latest changes\n")
+		output.Printf("🔄 Pulling latest changes\n")
 	}
 
 	// Prepare pull options
 	pullOpts := &git.PullOptions{
 		RemoteName: "origin",
+		Progress:   gitProgressOutput("fetch", c.repoURL, c.verbose),
 	}
 
-	if c.verbose {
-		pullOpts.Progress = os.Stdout
+	// Pull the checked-out branch, which need not be the one the remote's HEAD names
+	if head, err := c.repo.Head(); err == nil && head.Name().IsBranch() {
+		pullOpts.ReferenceName = head.Name()
 	}
 
 	// Add authentication if token provided
@@ -190,24 +234,89 @@ func (c *GitClient) pullLatest(ctx context.Context) error {
 	}
 
 	// Pull with context
+	progress.Start("fetch", c.repoURL)
 	err = w.PullContext(ctx, pullOpts)
+	if err == git.NoErrAlreadyUpToDate {
+		progress.Done("fetch", c.repoURL, nil)
+	} else {
+		progress.Done("fetch", c.repoURL, err)
+	}
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		if err == transport.ErrAuthenticationRequired {
 			return NewRegistryError(ErrUnauthorized,
 				"authentication required - provide a Git token for private repositories")
 		}
+		if errors.Is(err, git.ErrNonFastForwardUpdate) || errors.Is(err, git.ErrUnstagedChanges) {
+			return c.resetToRemote(ctx, err)
+		}
 		return fmt.Errorf("failed to pull latest changes: %w", err)
 	}
 
 	if err == git.NoErrAlreadyUpToDate && c.verbose {
-		fmt.Printf("✅ Already up to date\n")
+		output.Printf("✅ Already up to date\n")
 	} else if c.verbose {
-		fmt.Printf("✅ Pulled latest changes\n")
+		output.Printf("✅ Pulled latest changes\n")
+	}
+
+	return nil
+}
+
+// resetToRemote recovers a cache that can no longer be fast-forwarded, as after
+// the registry's history was force-pushed or the cache was changed locally, by
+// hard-resetting it to the remote branch. A cache that cannot be reset is
+// cloned again.
+func (c *GitClient) resetToRemote(ctx context.Context, cause error) error {
+	output.Fprintf(os.Stderr, "⚠️  Registry cache diverged from %s (%v); resetting it to the remote\n", c.repoURL, cause)
+
+	if err := c.hardResetToOrigin(); err != nil {
+		output.Fprintf(os.Stderr, "⚠️  Could not reset the registry cache (%v); cloning it again\n", err)
+		return c.recloneRepo(ctx)
+	}
+
+	if c.verbose {
+		output.Printf("✅ Reset cache to the remote\n")
+	}
+	return nil
+}
+
+// hardResetToOrigin moves the checked-out branch to its fetched remote branch,
+// discarding local commits and changes
+func (c *GitClient) hardResetToOrigin() error {
+	head, err := c.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return fmt.Errorf("HEAD is not on a branch")
+	}
+
+	branch := head.Name().Short()
+	remoteRef, err := c.repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return fmt.Errorf("failed to find origin/%s: %w", branch, err)
+	}
+
+	w, err := c.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if err := w.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to origin/%s: %w", branch, err)
 	}
 
 	return nil
 }
 
+// recloneRepo replaces the cache with a fresh clone of the registry
+func (c *GitClient) recloneRepo(ctx context.Context) error {
+	c.repo = nil
+	if err := os.RemoveAll(c.cacheDir); err != nil {
+		return fmt.Errorf("failed to remove registry cache: %w", err)
+	}
+	return c.cloneRepo(ctx)
+}
+
 // getAuth returns authentication configuration
 func (c *GitClient) getAuth() transport.AuthMethod {
 	if c.gitToken == "" {
@@ -233,6 +342,25 @@ func (c *GitClient) getAuth() transport.AuthMethod {
 	}
 }
 
+// gitHubClient returns the registry repository and a GitHub API client for its host
+func (c *GitClient) gitHubClient() (*gitRepoURL, *GitHubClient, error) {
+	repo, err := parseGitHubURL(c.repoURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse repository URL: %w", err)
+	}
+
+	apiURL := c.apiURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL(c.repoURL)
+	}
+	githubClient, err := NewGitHubClient(c.gitToken, apiURL, c.verbose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, githubClient, nil
+}
+
 // Health checks if the Git registry is accessible
 func (c *GitClient) Health(ctx context.Context) error {
 	// Try to ensure repository is accessible
@@ -259,12 +387,26 @@ func (c *GitClient) Health(ctx context.Context) error {
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Git registry is healthy (packages: %v, index: %v)\n", hasPackages, hasIndex)
+		output.Printf("✅ Git registry is healthy (packages: %v, index: %v)\n", hasPackages, hasIndex)
 	}
 
 	return nil
 }
 
+// ArchiveFormats returns the archive formats listed in the registry's index.json.
+// Registries that list none take gzip only.
+func (c *GitClient) ArchiveFormats(ctx context.Context) ([]string, error) {
+	index, err := c.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(index.ArchiveFormats) == 0 {
+		return []string{compression.Gzip}, nil
+	}
+	return index.ArchiveFormats, nil
+}
+
 // getPackagePath returns the path to a package directory
 func (c *GitClient) getPackagePath(packageName string) string {
 	return filepath.Join(c.cacheDir, "packages", packageName)
@@ -300,7 +442,7 @@ func (c *GitClient) Clean() error {
 	defer c.mu.Unlock()
 
 	if c.verbose {
-		fmt.Printf("🧹 Cleaning cache directory: %s\n", c.cacheDir)
+		output.Printf("🧹 Cleaning cache directory: %s\n", c.cacheDir)
 	}
 
 	c.repo = nil
@@ -316,7 +458,12 @@ func (c *GitClient) SetVerbose(verbose bool) {
 
 func (c *GitClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]Package, error) {
 	if c.verbose {
-		fmt.Printf("🔍 Searching packages with query: %s\n", opts.Query)
+		output.Printf("🔍 Searching packages with query: %s\n", opts.Query)
+	}
+
+	// Nobody administers a git registry beyond reviewing its pull requests
+	if opts.Official {
+		return nil, NewRegistryError(ErrNotImplemented, "official badges are not available for git registries")
 	}
 
 	// Load registry index
@@ -340,6 +487,7 @@ func (c *GitClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]P
 			Description: entry.Description,
 			Latest:      entry.Latest,
 			Tags:        entry.Tags,
+			License:     entry.License,
 			UpdatedAt:   entry.UpdatedAt,
 		}
 
@@ -349,6 +497,7 @@ func (c *GitClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]P
 			for i, v := range metadata.Versions {
 				pkg.Versions[i] = v.Version
 			}
+			pkg.Dependencies = metadata.Dependencies
 		}
 
 		results = append(results, pkg)
@@ -361,7 +510,7 @@ func (c *GitClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]P
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Found %d packages\n", len(results))
+		output.Printf("✅ Found %d packages\n", len(results))
 	}
 
 	return results, nil
@@ -369,7 +518,7 @@ func (c *GitClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]P
 
 func (c *GitClient) GetPackage(ctx context.Context, name string) (*Package, error) {
 	if c.verbose {
-		fmt.Printf("📦 Getting package: %s\n", name)
+		output.Printf("📦 Getting package: %s\n", name)
 	}
 
 	// Ensure repository is up to date
@@ -390,20 +539,23 @@ func (c *GitClient) GetPackage(ctx context.Context, name string) (*Package, erro
 
 	// Convert to Package struct
 	pkg := &Package{
-		Name:        metadata.Name,
-		Description: metadata.Description,
-		Latest:      metadata.Latest,
-		Tags:        metadata.Tags,
-		UpdatedAt:   metadata.UpdatedAt,
-		Versions:    make([]string, len(metadata.Versions)),
+		Name:         metadata.Name,
+		Description:  metadata.Description,
+		Latest:       metadata.LatestVersion(),
+		Tags:         metadata.Tags,
+		License:      metadata.License,
+		Dependencies: metadata.Dependencies,
+		UpdatedAt:    metadata.UpdatedAt,
+		Versions:     make([]string, len(metadata.Versions)),
 	}
 
 	for i, v := range metadata.Versions {
 		pkg.Versions[i] = v.Version
 	}
+	version.SortVersions(pkg.Versions)
 
 	if c.verbose {
-		fmt.Printf("✅ Found package with %d versions\n", len(pkg.Versions))
+		output.Printf("✅ Found package with %d versions\n", len(pkg.Versions))
 	}
 
 	return pkg, nil
@@ -411,7 +563,7 @@ func (c *GitClient) GetPackage(ctx context.Context, name string) (*Package, erro
 
 func (c *GitClient) GetPackageVersion(ctx context.Context, name, version string) (*PackageVersion, error) {
 	if c.verbose {
-		fmt.Printf("📦 Getting package version: %s@%s\n", name, version)
+		output.Printf("📦 Getting package version: %s@%s\n", name, version)
 	}
 
 	// Ensure repository is up to date
@@ -421,6 +573,10 @@ func (c *GitClient) GetPackageVersion(ctx context.Context, name, version string)
 
 	// Check if version exists
 	if !c.versionExists(name, version) {
+		// A version whose publish pull request is open is not installable yet
+		if review, err := c.publishReview(ctx, name, version); err == nil && review.State == "open" {
+			return nil, NewRegistryError(ErrPendingReview, describeReview(review))
+		}
 		return nil, NewRegistryError(ErrVersionNotFound,
 			fmt.Sprintf("%s@%s", name, version))
 	}
@@ -438,33 +594,70 @@ func (c *GitClient) GetPackageVersion(ctx context.Context, name, version string)
 		Description:  manifest.Description,
 		Dependencies: manifest.Dependencies,
 		SHA256:       manifest.SHA256,
+		Integrity:    manifest.Integrity,
 		Size:         manifest.Size,
 		PublishedAt:  manifest.PublishedAt,
+		License:      manifest.License,
 		Metadata:     manifest.Metadata,
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Found version published at %s\n", pv.PublishedAt.Format(time.RFC3339))
+		output.Printf("✅ Found version published at %s\n", pv.PublishedAt.Format(time.RFC3339))
 	}
 
 	return pv, nil
 }
 
+// GetPackagePreview builds a preview from the version's archive in the local clone
+func (c *GitClient) GetPackagePreview(ctx context.Context, name, version string) (*PackagePreview, error) {
+	if version == "" {
+		pkg, err := c.GetPackage(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		version = pkg.Latest
+	}
+
+	pkgVersion, err := c.GetPackageVersion(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	tempFile, err := tempdir.CreateTemp("preview-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	if err := c.DownloadBlob(ctx, pkgVersion.SHA256, tempFile.Name()); err != nil {
+		return nil, err
+	}
+
+	return BuildPackagePreview(tempFile.Name(), DefaultPreviewLines)
+}
+
 // PublishPackage publishes a package to the Git registry (Phase 7 - Direct Collaborator Mode)
 // This completely replaces the Phase 6 fork-based implementation
 func (c *GitClient) PublishPackage(ctx context.Context, manifestPath, archivePath string) (*PublishResult, error) {
+	ctx, span := tracing.Start(ctx, "git publish")
+	defer span.End()
+
 	if c.verbose {
-		fmt.Printf("📦 Publishing package to Git registry (direct collaborator mode)\n")
+		output.Printf("📦 Publishing package to Git registry (direct collaborator mode)\n")
 	}
 
 	// Parse manifest for package info
-	manifestData, err := os.ReadFile(manifestPath)
+	manifest, err := readPublishManifest(manifestPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read manifest: %w", err)
+		return nil, err
 	}
-	var manifest GitManifest
-	if err := json.Unmarshal(manifestData, &manifest); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+
+	// Sensitive registries must not let a publisher merge their own PR
+	if c.requireApproval {
+		if err := c.ensureReviewRequired(ctx); err != nil {
+			return nil, err
+		}
 	}
 
 	// Work directly with the target repository (no fork management)
@@ -473,6 +666,21 @@ func (c *GitClient) PublishPackage(ctx context.Context, manifestPath, archivePat
 		return nil, fmt.Errorf("failed to prepare repository: %w", err)
 	}
 
+	// Branch from the registry as it is on the remote, and leave the cache there
+	// afterwards so searches and installs do not read the unmerged publish branch
+	baseBranch, err := c.defaultBranch(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.returnToBranch(repo, baseBranch); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.returnToBranch(repo, baseBranch); err != nil {
+			output.Fprintf(os.Stderr, "⚠️  Could not return the registry cache to %s: %v\n", baseBranch, err)
+		}
+	}()
+
 	// Create publish branch (reuse existing Phase 6 helper)
 	branchName, err := c.createPublishBranch(repo, manifest.Name, manifest.Version)
 	if err != nil {
@@ -480,17 +688,17 @@ func (c *GitClient) PublishPackage(ctx context.Context, manifestPath, archivePat
 	}
 
 	// Add package files (reuse existing Phase 6 helper)
-	if err := c.addPackageFiles(repo, manifestPath, archivePath); err != nil {
+	if err := c.addPackageFiles(repo, manifest, archivePath); err != nil {
 		return nil, fmt.Errorf("failed to add package files: %w", err)
 	}
 
 	// Update registry index (reuse existing Phase 6 helper)
-	if err := c.updateRegistryIndex(repo, &manifest); err != nil {
+	if err := c.updateRegistryIndex(repo, manifest); err != nil {
 		return nil, fmt.Errorf("failed to update index: %w", err)
 	}
 
 	// Create commit (reuse existing Phase 6 helper)
-	_, err = c.createCommit(repo, &manifest)
+	_, err = c.createCommit(repo, manifest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create commit: %w", err)
 	}
@@ -501,34 +709,106 @@ func (c *GitClient) PublishPackage(ctx context.Context, manifestPath, archivePat
 	}
 
 	// Create pull request via GitHub API (same repository)
-	pr, err := c.createPullRequestForPackage(ctx, branchName, &manifest)
+	pr, err := c.createPullRequestForPackage(ctx, branchName, manifest)
 	if err != nil {
 		// If GitHub API fails, provide manual URL for same repository
-		owner, repoName, _ := parseGitHubURL(c.repoURL)
-		manualURL := fmt.Sprintf("https://github.com/%s/%s/compare/main...%s",
-			owner, repoName, branchName) // Same repo - direct collaborator access
+		webURL := strings.TrimSuffix(c.repoURL, ".git")
+		if ghRepo, err := parseGitHubURL(c.repoURL); err == nil {
+			webURL = ghRepo.WebURL()
+		}
+		manualURL := fmt.Sprintf("%s/compare/%s...%s", webURL, baseBranch, branchName) // Same repo - direct collaborator access
 
 		if c.verbose {
-			fmt.Printf("⚠️ GitHub API PR creation failed: %v\n", err)
-			fmt.Printf("💡 Branch pushed successfully. Create PR manually: %s\n", manualURL)
+			output.Printf("⚠️ GitHub API PR creation failed: %v\n", err)
+			output.Printf("💡 Branch pushed successfully. Create PR manually: %s\n", manualURL)
 		}
 
 		return &PublishResult{
-			Name:    manifest.Name,
-			Version: manifest.Version,
-			SHA256:  manifest.SHA256,
-			PRUrl:   manualURL,
-			Message: fmt.Sprintf("Branch pushed. Create PR manually: %s", manualURL),
+			Name:      manifest.Name,
+			Version:   manifest.Version,
+			SHA256:    manifest.SHA256,
+			Integrity: manifest.Integrity,
+			PRUrl:     manualURL,
+			Message:   fmt.Sprintf("Branch pushed. Create PR manually: %s", manualURL),
 		}, nil
 	}
 
-	return &PublishResult{
-		Name:    manifest.Name,
-		Version: manifest.Version,
-		SHA256:  manifest.SHA256,
-		PRUrl:   pr.GetHTMLURL(),
-		Message: fmt.Sprintf("Pull request created successfully: %s", pr.GetHTMLURL()),
-	}, nil
+	result := &PublishResult{
+		Name:      manifest.Name,
+		Version:   manifest.Version,
+		SHA256:    manifest.SHA256,
+		Integrity: manifest.Integrity,
+		PRUrl:     pr.GetHTMLURL(),
+		Message:   fmt.Sprintf("Pull request created successfully: %s", pr.GetHTMLURL()),
+	}
+
+	if c.requireApproval {
+		result.Status = "awaiting_approval"
+	}
+
+	return result, nil
+}
+
+// ensureReviewRequired verifies the registry's default branch cannot be merged into
+// without an approving review
+func (c *GitClient) ensureReviewRequired(ctx context.Context) error {
+	ghRepo, githubClient, err := c.gitHubClient()
+	if err != nil {
+		return err
+	}
+	owner, repoName := ghRepo.Owner, ghRepo.Name
+
+	repository, err := githubClient.GetRepository(ctx, owner, repoName)
+	if err != nil {
+		return err
+	}
+
+	branch := repository.GetDefaultBranch()
+	count, verified, err := githubClient.RequiredApprovingReviews(ctx, owner, repoName, branch)
+	if err != nil {
+		return err
+	}
+
+	if !verified {
+		return NewRegistryError(ErrInvalidOperation, fmt.Sprintf(
+			"registry requires approval but the review requirements of branch %s of %s/%s could not be verified. "+
+				"Reading branch protection needs admin access to the repository", branch, owner, repoName))
+	}
+
+	if count < 1 {
+		return NewRegistryError(ErrInvalidOperation, fmt.Sprintf(
+			"registry requires approval but branch %s of %s/%s does not require pull request reviews. "+
+				"Enable branch protection with at least 1 required approving review", branch, owner, repoName))
+	}
+
+	return nil
+}
+
+// ApprovePackage submits an approving review on the publish pull request for a version
+func (c *GitClient) ApprovePackage(ctx context.Context, name, version string) error {
+	ghRepo, githubClient, err := c.gitHubClient()
+	if err != nil {
+		return err
+	}
+	owner, repoName := ghRepo.Owner, ghRepo.Name
+
+	branchName := publishBranchName(name, version)
+	pr, err := githubClient.FindOpenPullRequest(ctx, owner, repoName, branchName)
+	if err != nil {
+		return err
+	}
+
+	user, err := githubClient.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pr.GetUser().GetLogin() == user.GetLogin() {
+		return NewRegistryError(ErrUnauthorized, "publishers cannot approve their own versions")
+	}
+
+	return githubClient.ApprovePullRequest(ctx, owner, repoName, pr.GetNumber(),
+		fmt.Sprintf("Approved %s@%s via rfh approve", name, version))
 }
 
 // cloneRepository clones the target repository directly (no fork management)
@@ -548,32 +828,32 @@ func (c *GitClient) cloneRepository(ctx context.Context, repoURL string) (*git.R
 		if err := c.updateRepository(ctx, repo); err != nil {
 			return nil, err
 		}
+		c.recordCacheUse()
 
 		return repo, nil
 	}
 
 	// Clone repository
 	if c.verbose {
-		fmt.Printf("📥 Cloning repository: %s\n", repoURL)
+		output.Printf("📥 Cloning repository: %s\n", repoURL)
 	}
 
 	cloneOpts := &git.CloneOptions{
 		URL:      repoURL,
-		Progress: nil,
-	}
-
-	if c.verbose {
-		cloneOpts.Progress = os.Stdout
+		Progress: gitProgressOutput("clone", repoURL, c.verbose),
 	}
 
 	if c.gitToken != "" {
 		cloneOpts.Auth = c.getAuth()
 	}
 
+	progress.Start("clone", repoURL)
 	repo, err := git.PlainCloneContext(ctx, cacheDir, false, cloneOpts)
+	progress.Done("clone", repoURL, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
+	c.recordCacheUse()
 
 	return repo, nil
 }
@@ -581,19 +861,26 @@ func (c *GitClient) cloneRepository(ctx context.Context, repoURL string) (*git.R
 // updateRepository updates the repository from remote
 func (c *GitClient) updateRepository(ctx context.Context, repo *git.Repository) error {
 	if c.verbose {
-		fmt.Printf("🔄 Updating repository from remote\n")
+		output.Printf("🔄 Updating repository from remote\n")
 	}
 
 	// Fetch latest changes
 	fetchOpts := &git.FetchOptions{
 		RemoteName: "origin",
+		Progress:   gitProgressOutput("fetch", c.repoURL, false),
 	}
 
 	if c.gitToken != "" {
 		fetchOpts.Auth = c.getAuth()
 	}
 
+	progress.Start("fetch", c.repoURL)
 	err := repo.FetchContext(ctx, fetchOpts)
+	if err == git.NoErrAlreadyUpToDate {
+		progress.Done("fetch", c.repoURL, nil)
+	} else {
+		progress.Done("fetch", c.repoURL, err)
+	}
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		return fmt.Errorf("failed to fetch from remote: %w", err)
 	}
@@ -603,14 +890,12 @@ func (c *GitClient) updateRepository(ctx context.Context, repo *git.Repository)
 
 // createPullRequestForPackage creates a PR for package publication (same repository)
 func (c *GitClient) createPullRequestForPackage(ctx context.Context, branchName string, manifest *GitManifest) (*github.PullRequest, error) {
-	// Parse repository URL directly
-	owner, repo, err := parseGitHubURL(c.repoURL)
+	// Resolve the repository and an API client for its host
+	ghRepo, githubClient, err := c.gitHubClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse repository URL: %w", err)
+		return nil, err
 	}
-
-	// Create GitHub client
-	githubClient := NewGitHubClient(c.gitToken, c.verbose)
+	owner, repo := ghRepo.Owner, ghRepo.Name
 
 	// Verify collaborator access
 	if err := githubClient.CheckCollaboratorAccess(ctx, owner, repo); err != nil {
@@ -657,6 +942,11 @@ func (c *GitClient) createPullRequestForPackage(ctx context.Context, branchName
 		manifest.Name,
 		manifest.Version)
 
+	if c.requireApproval {
+		body += fmt.Sprintf("\n\n### Review Required\nThis registry requires a second reviewer. "+
+			"Approve with `rfh approve %s@%s` or a GitHub review before merging.", manifest.Name, manifest.Version)
+	}
+
 	// Create pull request (same repository: branch -> main)
 	title := fmt.Sprintf("Publish %s@%s", manifest.Name, manifest.Version)
 	baseBranch := repository.GetDefaultBranch()
@@ -669,9 +959,115 @@ func (c *GitClient) createPullRequestForPackage(ctx context.Context, branchName
 	return pr, nil
 }
 
+// GetPackageVersions looks up many package versions after a single sync of the registry
+func (c *GitClient) GetPackageVersions(ctx context.Context, refs []VersionRef) ([]VersionMetadata, error) {
+	if err := c.ensureRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([]VersionMetadata, len(refs))
+	for i, ref := range refs {
+		results[i] = VersionMetadata{Name: ref.Name, Version: ref.Version}
+
+		metadata, err := c.loadPackageMetadata(ref.Name)
+		if err != nil {
+			continue
+		}
+		results[i].Latest = metadata.LatestVersion()
+
+		for _, v := range metadata.Versions {
+			if v.Version == ref.Version {
+				results[i].Found = true
+				results[i].SHA256 = v.SHA256
+				results[i].Integrity = v.Integrity
+				results[i].Size = v.Size
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// GetIndex always returns the whole index: the clone is already up to date after
+// ensureRepo, so there is no cheaper delta to compute
+func (c *GitClient) GetIndex(ctx context.Context, since string) (*IndexDelta, error) {
+	index, err := c.loadIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry index: %w", err)
+	}
+
+	delta := &IndexDelta{Full: true}
+	for _, entry := range index.Packages {
+		pkg := IndexPackage{
+			Name:        entry.Name,
+			Description: entry.Description,
+			Latest:      entry.Latest,
+			Tags:        entry.Tags,
+			Versions:    []IndexVersion{},
+		}
+
+		if metadata, err := c.loadPackageMetadata(entry.Name); err == nil {
+			pkg.Latest = metadata.LatestVersion()
+			for _, v := range metadata.Versions {
+				pkg.Versions = append(pkg.Versions, IndexVersion{
+					Version:     v.Version,
+					SHA256:      v.SHA256,
+					Integrity:   v.Integrity,
+					Size:        v.Size,
+					PublishedAt: v.PublishedAt,
+				})
+			}
+		}
+
+		delta.Packages = append(delta.Packages, pkg)
+	}
+
+	return delta, nil
+}
+
+// DeprecatePackage is not supported: deprecate by changing the registry through a pull request
+func (c *GitClient) DeprecatePackage(ctx context.Context, name, version, message, replacement string) error {
+	return NewRegistryError(ErrNotImplemented, "deprecation is not available for git registries")
+}
+
+// GetPackageChecks is not supported: git registries gate publishes through pull request review
+func (c *GitClient) GetPackageChecks(ctx context.Context, name, version string) (*PackageChecks, error) {
+	return nil, NewRegistryError(ErrNotImplemented, "validation checks are not available for git registries")
+}
+
+// ReservePackage is not supported: a git registry's maintainers decide which names are merged
+func (c *GitClient) ReservePackage(ctx context.Context, name string, days int) (*Reservation, error) {
+	return nil, NewRegistryError(ErrNotImplemented, "name reservations are not available for git registries")
+}
+
+// ReleasePackage is not supported, see ReservePackage
+func (c *GitClient) ReleasePackage(ctx context.Context, name string) error {
+	return NewRegistryError(ErrNotImplemented, "name reservations are not available for git registries")
+}
+
+// GetAuthor is not supported: a git registry has no user accounts of its own
+func (c *GitClient) GetAuthor(ctx context.Context, username string) (*Author, error) {
+	return nil, NewRegistryError(ErrNotImplemented, "author pages are not available for git registries")
+}
+
+// ReportPackage is not supported: abuse in a git registry is reported to its
+// maintainers, such as through an issue
+func (c *GitClient) ReportPackage(ctx context.Context, name, version, reason, message string) (*Report, error) {
+	return nil, NewRegistryError(ErrNotImplemented, "abuse reports are not available for git registries")
+}
+
+// ShareVersion is not supported: a git registry's own access controls decide
+// who can read its packages
+func (c *GitClient) ShareVersion(ctx context.Context, name, version string, expiresIn time.Duration) (*ShareLink, error) {
+	return nil, NewRegistryError(ErrNotImplemented, "share links are not available for git registries")
+}
+
 func (c *GitClient) DownloadBlob(ctx context.Context, sha256Hash, destPath string) error {
+	ctx, span := tracing.Start(ctx, "git download")
+	defer span.End()
+
 	if c.verbose {
-		fmt.Printf("📥 Downloading blob: %s\n", sha256Hash)
+		output.Printf("📥 Downloading blob: %s\n", sha256Hash)
 	}
 
 	// Ensure repository is up to date
@@ -679,19 +1075,22 @@ func (c *GitClient) DownloadBlob(ctx context.Context, sha256Hash, destPath strin
 		return err
 	}
 
-	// Find the archive file by hash
+	// Find the archive file by hash, or rebuild it from the file store
 	archivePath, err := c.findArchiveByHash(sha256Hash)
 	if err != nil {
-		return err
-	}
-
-	// Copy file to destination
-	if err := c.copyFile(archivePath, destPath); err != nil {
+		rebuilt, rebuildErr := c.rebuildArchiveByHash(sha256Hash, destPath)
+		if rebuildErr != nil {
+			return rebuildErr
+		}
+		if !rebuilt {
+			return err
+		}
+	} else if err := c.copyFile(archivePath, destPath); err != nil {
 		return fmt.Errorf("failed to copy archive: %w", err)
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Downloaded to %s\n", destPath)
+		output.Printf("✅ Downloaded to %s\n", destPath)
 	}
 
 	return nil
@@ -711,7 +1110,7 @@ func (c *GitClient) loadIndex(ctx context.Context) (*GitRegistryIndex, error) {
 	// Check if index exists
 	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
 		if c.verbose {
-			fmt.Printf("⚠️  Index not found, attempting to rebuild from packages directory\n")
+			output.Printf("⚠️  Index not found, attempting to rebuild from packages directory\n")
 		}
 		// Try to rebuild index from packages directory
 		return c.rebuildIndex()
@@ -726,7 +1125,7 @@ func (c *GitClient) loadIndex(ctx context.Context) (*GitRegistryIndex, error) {
 	var index GitRegistryIndex
 	if err := json.Unmarshal(data, &index); err != nil {
 		if c.verbose {
-			fmt.Printf("⚠️  Index corrupted, rebuilding from packages directory\n")
+			output.Printf("⚠️  Index corrupted, rebuilding from packages directory\n")
 		}
 		// If index is corrupted, try to rebuild
 		return c.rebuildIndex()
@@ -833,8 +1232,8 @@ func (c *GitClient) findArchiveByHash(sha256Hash string) (string, error) {
 			return err
 		}
 
-		// Look for archive.tar.gz files
-		if filepath.Base(path) == "archive.tar.gz" {
+		// Look for archive.tar.gz and archive.tar.zst files
+		if name := filepath.Base(path); name == "archive.tar.gz" || name == "archive.tar.zst" {
 			// Calculate hash of file
 			hash, err := c.calculateFileHash(path)
 			if err != nil {
@@ -910,7 +1309,7 @@ func (c *GitClient) rebuildIndex() (*GitRegistryIndex, error) {
 	}
 
 	if c.verbose {
-		fmt.Printf("🔄 Rebuilding index from packages directory\n")
+		output.Printf("🔄 Rebuilding index from packages directory\n")
 	}
 
 	// Walk through packages directory
@@ -935,8 +1334,9 @@ func (c *GitClient) rebuildIndex() (*GitRegistryIndex, error) {
 		index.Packages[packageName] = GitPackageEntry{
 			Name:        metadata.Name,
 			Description: metadata.Description,
-			Latest:      metadata.Latest,
+			Latest:      metadata.LatestVersion(),
 			Tags:        metadata.Tags,
+			License:     metadata.License,
 			UpdatedAt:   metadata.UpdatedAt,
 		}
 		index.PackageCount++
@@ -948,31 +1348,30 @@ func (c *GitClient) rebuildIndex() (*GitRegistryIndex, error) {
 // InitializeRegistry creates the initial structure for an empty Git registry
 func (c *GitClient) InitializeRegistry(ctx context.Context) error {
 	if c.verbose {
-		fmt.Printf("🔧 Initializing Git registry at %s\n", c.repoURL)
-		fmt.Printf("📁 Cache directory: %s\n", c.cacheDir)
+		output.Printf("🔧 Initializing Git registry at %s\n", c.repoURL)
+		output.Printf("📁 Cache directory: %s\n", c.cacheDir)
 	}
 
 	// 1. Try to clone existing repository first, then initialize if needed
 	if c.verbose {
-		fmt.Printf("📋 Step 1: Attempting to clone existing repository...\n")
+		output.Printf("📋 Step 1: Attempting to clone existing repository...\n")
 	}
-	
+
 	// Clean up any existing cache directory first
 	if err := os.RemoveAll(c.cacheDir); err != nil {
 		return fmt.Errorf("failed to clean cache directory: %w", err)
 	}
-	
+
 	// Try to clone the existing repository
-	cloneAuth := &http.BasicAuth{Username: "git", Password: c.gitToken}
 	repo, err := git.PlainClone(c.cacheDir, false, &git.CloneOptions{
 		URL:  c.repoURL,
-		Auth: cloneAuth,
+		Auth: c.getAuth(),
 	})
-	
+
 	if err != nil {
 		if c.verbose {
-			fmt.Printf("📋 Clone failed (likely empty repository): %v\n", err)
-			fmt.Printf("📋 Creating new local repository...\n")
+			output.Printf("📋 Clone failed (likely empty repository): %v\n", err)
+			output.Printf("📋 Creating new local repository...\n")
 		}
 		// If clone fails, create new repository
 		if err := c.initLocalEmptyRepo(); err != nil {
@@ -983,7 +1382,7 @@ func (c *GitClient) InitializeRegistry(ctx context.Context) error {
 			return fmt.Errorf("failed to open local repository: %w", err)
 		}
 	} else if c.verbose {
-		fmt.Printf("✅ Successfully cloned existing repository\n")
+		output.Printf("✅ Successfully cloned existing repository\n")
 	}
 
 	// 2. Get worktree for the repository
@@ -992,29 +1391,29 @@ func (c *GitClient) InitializeRegistry(ctx context.Context) error {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ Repository worktree ready\n")
+		output.Printf("✅ Repository worktree ready\n")
 	}
 
 	if c.verbose {
-		fmt.Printf("📋 Step 3: Creating initial structure and files...\n")
+		output.Printf("📋 Step 3: Creating initial structure and files...\n")
 	}
 	if err := c.createInitialStructure(w); err != nil {
 		return fmt.Errorf("failed to create initial structure: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ Initial structure and commit created\n")
+		output.Printf("✅ Initial structure and commit created\n")
 	}
 
 	// 3. Push to remote repository
 	if c.verbose {
-		fmt.Printf("📋 Step 4: Pushing to remote repository...\n")
+		output.Printf("📋 Step 4: Pushing to remote repository...\n")
 	}
 	if err := c.pushToRemote(ctx, repo); err != nil {
 		return fmt.Errorf("failed to push to remote: %w", err)
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Repository initialized successfully\n")
+		output.Printf("✅ Repository initialized successfully\n")
 	}
 
 	return nil
@@ -1034,10 +1433,13 @@ func (c *GitClient) initLocalEmptyRepo() error {
 
 	// Initialize new Git repository
 	if c.verbose {
-		fmt.Printf("📁 Creating local repository at %s\n", c.cacheDir)
+		output.Printf("📁 Creating local repository at %s\n", c.cacheDir)
 	}
 
-	_, err := git.PlainInit(c.cacheDir, false)
+	// New registries start on main, the branch pushToRemote pushes for them
+	_, err := git.PlainInitWithOptions(c.cacheDir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize git repository: %w", err)
 	}
@@ -1048,13 +1450,13 @@ func (c *GitClient) initLocalEmptyRepo() error {
 // createInitialStructure creates the initial registry directory structure and files
 func (c *GitClient) createInitialStructure(w *git.Worktree) error {
 	if c.verbose {
-		fmt.Printf("📋 Creating initial registry structure\n")
+		output.Printf("📋 Creating initial registry structure\n")
 	}
 
 	// Create packages directory
 	packagesDir := filepath.Join(c.cacheDir, "packages")
 	if c.verbose {
-		fmt.Printf("📁 Creating packages directory: %s\n", packagesDir)
+		output.Printf("📁 Creating packages directory: %s\n", packagesDir)
 	}
 	if err := os.MkdirAll(packagesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create packages directory: %w", err)
@@ -1062,7 +1464,7 @@ func (c *GitClient) createInitialStructure(w *git.Worktree) error {
 
 	// Create initial index.json
 	if c.verbose {
-		fmt.Printf("📄 Creating index.json...\n")
+		output.Printf("📄 Creating index.json...\n")
 	}
 	index := &GitRegistryIndex{
 		Version:      "1.0",
@@ -1081,7 +1483,7 @@ func (c *GitClient) createInitialStructure(w *git.Worktree) error {
 		return fmt.Errorf("failed to write index.json: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ index.json created at %s\n", indexPath)
+		output.Printf("✅ index.json created at %s\n", indexPath)
 	}
 
 	// Create README.md
@@ -1108,29 +1510,29 @@ For more information, visit: https://github.com/richardhannah/rfh
 
 	readmePath := filepath.Join(c.cacheDir, "README.md")
 	if c.verbose {
-		fmt.Printf("📄 Creating README.md...\n")
+		output.Printf("📄 Creating README.md...\n")
 	}
 	if err := os.WriteFile(readmePath, []byte(readme), 0644); err != nil {
 		return fmt.Errorf("failed to write README.md: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ README.md created at %s\n", readmePath)
+		output.Printf("✅ README.md created at %s\n", readmePath)
 	}
 
 	// Add all files to Git
 	if c.verbose {
-		fmt.Printf("📋 Adding files to git staging...\n")
+		output.Printf("📋 Adding files to git staging...\n")
 	}
 	if _, err := w.Add("."); err != nil {
 		return fmt.Errorf("failed to add files to git: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ Files staged for commit\n")
+		output.Printf("✅ Files staged for commit\n")
 	}
 
 	// Create initial commit
 	if c.verbose {
-		fmt.Printf("📋 Creating initial commit...\n")
+		output.Printf("📋 Creating initial commit...\n")
 	}
 	commitHash, err := w.Commit("Initial registry structure", &git.CommitOptions{
 		Author: &object.Signature{
@@ -1143,28 +1545,28 @@ For more information, visit: https://github.com/richardhannah/rfh
 		return fmt.Errorf("failed to create initial commit: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("✅ Initial commit created: %s\n", commitHash.String()[:8])
+		output.Printf("✅ Initial commit created: %s\n", commitHash.String()[:8])
 	}
 
 	// Validate that files actually exist
 	if c.verbose {
-		fmt.Printf("📋 Validating created files...\n")
+		output.Printf("📋 Validating created files...\n")
 		if _, err := os.Stat(filepath.Join(c.cacheDir, "index.json")); err != nil {
-			fmt.Printf("⚠️  index.json not found: %v\n", err)
+			output.Printf("⚠️  index.json not found: %v\n", err)
 		} else {
-			fmt.Printf("✅ index.json exists\n")
+			output.Printf("✅ index.json exists\n")
 		}
-		
+
 		if _, err := os.Stat(filepath.Join(c.cacheDir, "README.md")); err != nil {
-			fmt.Printf("⚠️  README.md not found: %v\n", err)
+			output.Printf("⚠️  README.md not found: %v\n", err)
 		} else {
-			fmt.Printf("✅ README.md exists\n")
+			output.Printf("✅ README.md exists\n")
 		}
-		
+
 		if _, err := os.Stat(filepath.Join(c.cacheDir, "packages")); err != nil {
-			fmt.Printf("⚠️  packages directory not found: %v\n", err)
+			output.Printf("⚠️  packages directory not found: %v\n", err)
 		} else {
-			fmt.Printf("✅ packages directory exists\n")
+			output.Printf("✅ packages directory exists\n")
 		}
 	}
 
@@ -1174,13 +1576,13 @@ For more information, visit: https://github.com/richardhannah/rfh
 // pushToRemote pushes the local repository to the remote origin
 func (c *GitClient) pushToRemote(ctx context.Context, repo *git.Repository) error {
 	if c.verbose {
-		fmt.Printf("🚀 Pushing initial structure to remote repository\n")
-		fmt.Printf("📋 Remote URL: %s\n", c.repoURL)
+		output.Printf("🚀 Pushing initial structure to remote repository\n")
+		output.Printf("📋 Remote URL: %s\n", c.repoURL)
 	}
 
 	// Add remote origin (if not already exists from clone)
 	if c.verbose {
-		fmt.Printf("📋 Checking/adding remote origin...\n")
+		output.Printf("📋 Checking/adding remote origin...\n")
 	}
 	_, err := repo.Remote("origin")
 	if err != nil {
@@ -1191,73 +1593,75 @@ func (c *GitClient) pushToRemote(ctx context.Context, repo *git.Repository) erro
 		})
 		if err != nil {
 			if c.verbose {
-				fmt.Printf("⚠️  Failed to add remote origin: %v\n", err)
+				output.Printf("⚠️  Failed to add remote origin: %v\n", err)
 			}
 		} else if c.verbose {
-			fmt.Printf("✅ Remote origin added\n")
+			output.Printf("✅ Remote origin added\n")
 		}
 	} else if c.verbose {
-		fmt.Printf("✅ Remote origin already exists\n")
+		output.Printf("✅ Remote origin already exists\n")
 	}
 
 	// Configure authentication
 	if c.verbose {
-		fmt.Printf("📋 Configuring authentication...\n")
-	}
-	auth := &http.BasicAuth{
-		Username: "git",
-		Password: c.gitToken,
+		output.Printf("📋 Configuring authentication...\n")
 	}
+	auth := c.getAuth()
 
 	// Skip fetch since we either cloned or are creating new content
 
 	// Check what we're about to push
 	if c.verbose {
-		fmt.Printf("📋 Checking repository state before push...\n")
+		output.Printf("📋 Checking repository state before push...\n")
 		ref, err := repo.Head()
 		if err != nil {
-			fmt.Printf("⚠️  Could not get HEAD: %v\n", err)
+			output.Printf("⚠️  Could not get HEAD: %v\n", err)
 		} else {
-			fmt.Printf("📋 HEAD commit: %s\n", ref.Hash().String()[:8])
+			output.Printf("📋 HEAD commit: %s\n", ref.Hash().String()[:8])
 		}
-		
+
 		// Check if we have any commits
 		iter, err := repo.Log(&git.LogOptions{})
 		if err != nil {
-			fmt.Printf("⚠️  Could not get log: %v\n", err)
+			output.Printf("⚠️  Could not get log: %v\n", err)
 		} else {
 			commitCount := 0
 			err = iter.ForEach(func(c *object.Commit) error {
 				commitCount++
 				return nil
 			})
-			fmt.Printf("📋 Local commits: %d\n", commitCount)
+			output.Printf("📋 Local commits: %d\n", commitCount)
 			iter.Close()
 		}
 	}
 
-	// Push to remote
+	// Push the checked-out branch: main for new registries, or the default
+	// branch of a cloned one
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
 	if c.verbose {
-		fmt.Printf("📋 Pushing to remote (main branch)...\n")
+		output.Printf("📋 Pushing to remote (%s branch)...\n", head.Name().Short())
 	}
 	err = repo.PushContext(ctx, &git.PushOptions{
 		RemoteName: "origin",
-		RefSpecs:   []config.RefSpec{config.RefSpec("refs/heads/main:refs/heads/main")},
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name()))},
 		Auth:       auth,
-		Progress:   os.Stdout,
+		Progress:   output.Live(),
 	})
 	if err != nil {
 		errStr := err.Error()
 		if c.verbose {
-			fmt.Printf("⚠️  Push error: %s\n", errStr)
+			output.Printf("⚠️  Push error: %s\n", errStr)
 		}
-		
+
 		// Don't mask any errors - show the real problem
 		return fmt.Errorf("failed to push to remote: %w", err)
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Successfully pushed to remote repository\n")
+		output.Printf("✅ Successfully pushed to remote repository\n")
 	}
 	return nil
-}
\ No newline at end of file
+}
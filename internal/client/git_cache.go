@@ -0,0 +1,190 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rulestack/internal/output"
+)
+
+// DefaultGitCacheMaxSize caps the Git registry cache when no size is configured
+const DefaultGitCacheMaxSize int64 = 1 << 30
+
+// gitCacheUsageFile records when a cached registry was last used. It lives in
+// the clone's .git directory so it never shows up in the worktree.
+const gitCacheUsageFile = "rfh-usage.json"
+
+// GitCacheEntry is one Git registry cached under ~/.rfh/cache/git
+type GitCacheEntry struct {
+	Dir      string
+	URL      string // Empty for caches last used before usage was recorded
+	Size     int64
+	LastUsed time.Time
+}
+
+type gitCacheUsage struct {
+	URL      string    `json:"url"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// GitCacheRoot returns the directory Git registries are cloned into
+func GitCacheRoot() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".rfh", "cache", "git"), nil
+}
+
+// GitCacheDir returns the directory a Git registry is cloned into
+func GitCacheDir(repoURL string) (string, error) {
+	repoURL = strings.TrimRight(repoURL, "/")
+	if !strings.HasSuffix(repoURL, ".git") {
+		repoURL += ".git"
+	}
+	return getGitCacheDir(repoURL)
+}
+
+// SetCacheMaxSize caps the total size of the Git registry cache, evicting the
+// least recently used registries once this one has synced. Zero uses
+// DefaultGitCacheMaxSize; a negative size turns eviction off.
+func (c *GitClient) SetCacheMaxSize(size int64) {
+	c.cacheMaxSize = size
+}
+
+// recordCacheUse marks the cached registry as just used and, the first time,
+// evicts other registries over the cache's size cap
+func (c *GitClient) recordCacheUse() {
+	data, _ := json.Marshal(gitCacheUsage{URL: c.repoURL, LastUsed: time.Now().UTC()})
+	if err := os.WriteFile(filepath.Join(c.cacheDir, ".git", gitCacheUsageFile), data, 0644); err != nil && c.verbose {
+		output.Printf("⚠️  Failed to record cache use: %v\n", err)
+	}
+
+	if c.cacheChecked || c.cacheMaxSize < 0 {
+		return
+	}
+	c.cacheChecked = true
+
+	maxSize := c.cacheMaxSize
+	if maxSize == 0 {
+		maxSize = DefaultGitCacheMaxSize
+	}
+	evicted, err := EvictGitCache(maxSize, c.cacheDir)
+	if err != nil && c.verbose {
+		output.Printf("⚠️  Failed to trim the registry cache: %v\n", err)
+	}
+	if c.verbose {
+		for _, entry := range evicted {
+			output.Printf("🗑️  Evicted cached registry %s\n", filepath.Base(entry.Dir))
+		}
+	}
+}
+
+// ListGitCache returns the cached Git registries, most recently used first
+func ListGitCache() ([]GitCacheEntry, error) {
+	root, err := GitCacheRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read registry cache: %w", err)
+	}
+
+	var entries []GitCacheEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry := GitCacheEntry{Dir: filepath.Join(root, dir.Name())}
+
+		if data, err := os.ReadFile(filepath.Join(entry.Dir, ".git", gitCacheUsageFile)); err == nil {
+			var usage gitCacheUsage
+			if json.Unmarshal(data, &usage) == nil {
+				entry.URL = usage.URL
+				entry.LastUsed = usage.LastUsed
+			}
+		}
+		if entry.LastUsed.IsZero() {
+			if info, err := dir.Info(); err == nil {
+				entry.LastUsed = info.ModTime()
+			}
+		}
+
+		if entry.Size, err = dirSize(entry.Dir); err != nil {
+			return nil, fmt.Errorf("failed to measure %s: %w", dir.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LastUsed.After(entries[j].LastUsed) })
+	return entries, nil
+}
+
+// EvictGitCache removes the least recently used cached registries until the
+// cache fits in maxSize bytes. The directories in keep are never removed, even
+// if the cache stays over the cap. It returns the registries removed.
+func EvictGitCache(maxSize int64, keep ...string) ([]GitCacheEntry, error) {
+	entries, err := ListGitCache()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var evicted []GitCacheEntry
+	for i := len(entries) - 1; i >= 0 && total > maxSize; i-- {
+		entry := entries[i]
+		if isKeptCacheDir(entry.Dir, keep) {
+			continue
+		}
+		if err := os.RemoveAll(entry.Dir); err != nil {
+			return evicted, fmt.Errorf("failed to remove %s: %w", filepath.Base(entry.Dir), err)
+		}
+		total -= entry.Size
+		evicted = append(evicted, entry)
+	}
+
+	return evicted, nil
+}
+
+func isKeptCacheDir(dir string, keep []string) bool {
+	for _, kept := range keep {
+		if filepath.Clean(kept) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirSize returns the total size of the regular files under dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvictGitCache(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	root, err := GitCacheRoot()
+	if err != nil {
+		t.Fatalf("GitCacheRoot failed: %v", err)
+	}
+
+	now := time.Now()
+	writeCache := func(name string, size int, lastUsed time.Time) string {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+			t.Fatalf("failed to create cache dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "blob"), make([]byte, size), 0644); err != nil {
+			t.Fatalf("failed to write blob: %v", err)
+		}
+		data, _ := json.Marshal(gitCacheUsage{URL: "https://github.com/org/" + name + ".git", LastUsed: lastUsed})
+		if err := os.WriteFile(filepath.Join(dir, ".git", gitCacheUsageFile), data, 0644); err != nil {
+			t.Fatalf("failed to write usage: %v", err)
+		}
+		return dir
+	}
+
+	oldest := writeCache("oldest", 1000, now.Add(-72*time.Hour))
+	writeCache("older", 1000, now.Add(-48*time.Hour))
+	recent := writeCache("recent", 1000, now.Add(-time.Hour))
+
+	entries, err := ListGitCache()
+	if err != nil {
+		t.Fatalf("ListGitCache failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Dir != recent || entries[0].URL != "https://github.com/org/recent.git" {
+		t.Fatalf("expected the most recently used first, got %+v", entries)
+	}
+
+	// The oldest is being used, so the next oldest goes instead
+	evicted, err := EvictGitCache(2500, oldest)
+	if err != nil {
+		t.Fatalf("EvictGitCache failed: %v", err)
+	}
+	if len(evicted) != 1 || filepath.Base(evicted[0].Dir) != "older" {
+		t.Fatalf("expected only 'older' to be evicted, got %+v", evicted)
+	}
+	for _, dir := range []string{oldest, recent} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected %s to be kept: %v", filepath.Base(dir), err)
+		}
+	}
+
+	if evicted, err := EvictGitCache(2500); err != nil || len(evicted) != 0 {
+		t.Errorf("expected nothing evicted under the cap, got %+v, %v", evicted, err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	rfhmanifest "rulestack/internal/manifest"
 )
 
 func TestGitRegistryDiscovery(t *testing.T) {
@@ -69,14 +71,16 @@ func TestGitRegistryDiscovery(t *testing.T) {
 
 	// Create version manifest
 	manifest := GitManifest{
-		Name:         "test-package",
-		Version:      "1.0.0",
-		Description:  "A test package",
-		Dependencies: map[string]string{"dep1": "^1.0.0"},
-		SHA256:       "abc123",
-		Size:         1024,
-		PublishedAt:  time.Now(),
-		Publisher:    "test-publisher",
+		PackageManifest: rfhmanifest.PackageManifest{
+			Name:         "test-package",
+			Version:      "1.0.0",
+			Description:  "A test package",
+			Dependencies: map[string]string{"dep1": "^1.0.0"},
+		},
+		SHA256:      "abc123",
+		Size:        1024,
+		PublishedAt: time.Now(),
+		Publisher:   "test-publisher",
 	}
 
 	manifestData, _ := json.MarshalIndent(manifest, "", "  ")
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testRegistry is a Git registry on a throwaway bare repository. It merges
+// publish branches itself, the way a maintainer would on the hosting provider.
+type testRegistry struct {
+	t      testing.TB
+	url    string
+	remote *git.Repository
+}
+
+// newTestRegistry creates an empty bare repository and initializes it as a registry
+func newTestRegistry(t testing.TB) *testRegistry {
+	t.Helper()
+
+	// Clients keep their caches under ~/.rfh
+	t.Setenv("HOME", t.TempDir())
+
+	dir := filepath.Join(t.TempDir(), "registry.git")
+	remote, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		Bare:        true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create remote: %v", err)
+	}
+
+	// A file:// URL is not a GitHub repository, so publishes fall back to a
+	// manual pull request link instead of calling the API
+	r := &testRegistry{t: t, url: "file://" + dir, remote: remote}
+	if err := r.client().InitializeRegistry(context.Background()); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	return r
+}
+
+// client returns a client with a cache of its own, as on another machine
+func (r *testRegistry) client() *GitClient {
+	r.t.Helper()
+
+	c, err := NewGitClient(r.url, "", false)
+	if err != nil {
+		r.t.Fatalf("NewGitClient failed: %v", err)
+	}
+	c.cacheDir = filepath.Join(r.t.TempDir(), "cache")
+	return c
+}
+
+// publish publishes a version whose archive holds content, returning the archive
+func (r *testRegistry) publish(c *GitClient, name, version string) ([]byte, error) {
+	dir, err := os.MkdirTemp(r.t.TempDir(), "publish")
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(dir, "manifest.json")
+	archivePath := filepath.Join(dir, "archive.tar.gz")
+	manifest := `{"name":"` + name + `","version":"` + version + `","description":"Rules for ` + name + `"}`
+	archive := []byte("archive of " + name + "@" + version)
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		return nil, err
+	}
+
+	result, err := c.PublishPackage(context.Background(), manifestPath, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	if result.SHA256 != hex.EncodeToString(sum[:]) {
+		r.t.Errorf("publish of %s@%s reported SHA256 %s", name, version, result.SHA256)
+	}
+	if !strings.Contains(result.PRUrl, "publish/"+name+"/"+version) {
+		r.t.Errorf("expected a pull request link for the publish branch, got %q", result.PRUrl)
+	}
+	return archive, nil
+}
+
+// merge merges a publish branch into main: a fast-forward when main has not
+// moved, else a merge commit taking the package's directory from the branch and
+// keeping main's index.json, as when resolving a conflict in the index
+func (r *testRegistry) merge(name, version string) {
+	r.t.Helper()
+
+	branch, err := r.remote.Reference(plumbing.NewBranchReferenceName(publishBranchName(name, version)), true)
+	if err != nil {
+		r.t.Fatalf("publish branch of %s@%s not pushed: %v", name, version, err)
+	}
+	main, err := r.remote.Reference(plumbing.NewBranchReferenceName("main"), true)
+	if err != nil {
+		r.t.Fatalf("failed to read main: %v", err)
+	}
+	branchCommit, _ := r.remote.CommitObject(branch.Hash())
+	mainCommit, _ := r.remote.CommitObject(main.Hash())
+
+	if ok, _ := mainCommit.IsAncestor(branchCommit); ok {
+		if err := r.remote.Storer.SetReference(plumbing.NewHashReference(main.Name(), branch.Hash())); err != nil {
+			r.t.Fatalf("failed to fast-forward main: %v", err)
+		}
+		return
+	}
+
+	r.commitToMain("Merge "+branch.Name().Short(), []plumbing.Hash{branch.Hash()}, func(root string) {
+		tree, err := branchCommit.Tree()
+		if err != nil {
+			r.t.Fatalf("failed to read branch tree: %v", err)
+		}
+		err = tree.Files().ForEach(func(f *object.File) error {
+			if !strings.HasPrefix(f.Name, "packages/"+name+"/") {
+				return nil
+			}
+			content, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(root, filepath.FromSlash(f.Name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte(content), 0644)
+		})
+		if err != nil {
+			r.t.Fatalf("failed to copy %s from the branch: %v", name, err)
+		}
+	})
+}
+
+// commitToMain commits a change to main from a maintainer's clone, which has
+// every branch, so extra parents may be publish branch tips
+func (r *testRegistry) commitToMain(message string, extraParents []plumbing.Hash, change func(root string)) {
+	r.t.Helper()
+
+	root := filepath.Join(r.t.TempDir(), "maintainer")
+	repo, err := git.PlainClone(root, false, &git.CloneOptions{URL: r.url, ReferenceName: plumbing.NewBranchReferenceName("main")})
+	if err != nil {
+		r.t.Fatalf("failed to clone the registry: %v", err)
+	}
+	head, _ := repo.Head()
+
+	change(root)
+
+	w, _ := repo.Worktree()
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		r.t.Fatalf("failed to stage: %v", err)
+	}
+	_, err = w.Commit(message, &git.CommitOptions{
+		Author:  &object.Signature{Name: "Maintainer", Email: "maintainer@example.com", When: time.Now()},
+		Parents: append([]plumbing.Hash{head.Hash()}, extraParents...),
+	})
+	if err != nil {
+		r.t.Fatalf("failed to commit: %v", err)
+	}
+	if err := repo.Push(&git.PushOptions{}); err != nil {
+		r.t.Fatalf("failed to push main: %v", err)
+	}
+}
+
+func TestGitRegistryEndToEnd(t *testing.T) {
+	reg := newTestRegistry(t)
+	installer := reg.client()
+	ctx := context.Background()
+
+	if err := installer.Health(ctx); err != nil {
+		t.Fatalf("Health of a new registry failed: %v", err)
+	}
+	if results, err := installer.SearchPackages(ctx, SearchOptions{}); err != nil || len(results) != 0 {
+		t.Fatalf("expected a new registry to be empty, got %v (%v)", results, err)
+	}
+
+	// Publish, then install once the pull request merges
+	archive, err := reg.publish(reg.client(), "security-rules", "1.0.0")
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if _, err := installer.GetPackageVersion(ctx, "security-rules", "1.0.0"); !errors.Is(err, ErrPendingReview) {
+		t.Errorf("expected the unmerged version to be pending review, got %v", err)
+	}
+
+	reg.merge("security-rules", "1.0.0")
+
+	results, err := installer.SearchPackages(ctx, SearchOptions{Query: "security"})
+	if err != nil || len(results) != 1 || results[0].Latest != "1.0.0" {
+		t.Fatalf("expected security-rules@1.0.0 in search, got %+v (%v)", results, err)
+	}
+	pv, err := installer.GetPackageVersion(ctx, "security-rules", "1.0.0")
+	if err != nil {
+		t.Fatalf("GetPackageVersion failed: %v", err)
+	}
+	dest := filepath.Join(t.TempDir(), "security-rules.tgz")
+	if err := installer.DownloadBlob(ctx, pv.SHA256, dest); err != nil {
+		t.Fatalf("DownloadBlob failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); !bytes.Equal(data, archive) {
+		t.Errorf("downloaded archive does not match the published one")
+	}
+
+	// Two publishers working at once each get a branch of their own
+	names := []string{"style-rules", "test-rules"}
+	archives := make([][]byte, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		publisher := reg.client()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			archives[i], errs[i] = reg.publish(publisher, name, "1.0.0")
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent publish of %s failed: %v", names[i], err)
+		}
+	}
+	for _, name := range names {
+		reg.merge(name, "1.0.0")
+	}
+
+	for i, name := range names {
+		pv, err := installer.GetPackageVersion(ctx, name, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetPackageVersion(%s) after merge failed: %v", name, err)
+		}
+		dest := filepath.Join(t.TempDir(), name+".tgz")
+		if err := installer.DownloadBlob(ctx, pv.SHA256, dest); err != nil {
+			t.Fatalf("DownloadBlob(%s) failed: %v", name, err)
+		}
+		if data, _ := os.ReadFile(dest); !bytes.Equal(data, archives[i]) {
+			t.Errorf("downloaded archive of %s does not match the published one", name)
+		}
+	}
+
+	// A registry whose index was dropped, e.g. to settle merge conflicts in it,
+	// is re-indexed from its packages directory
+	reg.commitToMain("Drop index", nil, func(root string) {
+		if err := os.Remove(filepath.Join(root, "index.json")); err != nil {
+			t.Fatalf("failed to remove index.json: %v", err)
+		}
+	})
+	results, err = installer.SearchPackages(ctx, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchPackages after dropping the index failed: %v", err)
+	}
+	found := make(map[string]string)
+	for _, pkg := range results {
+		found[pkg.Name] = pkg.Latest
+	}
+	for _, name := range append(names, "security-rules") {
+		if found[name] != "1.0.0" {
+			t.Errorf("expected %s@1.0.0 in the rebuilt index, got %v", name, found)
+		}
+	}
+}
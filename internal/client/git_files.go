@@ -0,0 +1,230 @@
+package client
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+// GitLayoutFiles is the index.json layout that stores each package file once, in
+// packages/<name>/files/<sha256>, shared by every version that contains it. Version
+// manifests list their files and archives are rebuilt from them on download, so a
+// release that changes one rule adds one blob instead of a whole archive.
+const GitLayoutFiles = "files"
+
+// usesFileStore reports whether the registry checked out at root stores versions
+// as per-file blobs
+func (c *GitClient) usesFileStore(root string) bool {
+	data, err := os.ReadFile(filepath.Join(root, "index.json"))
+	if err != nil {
+		return false
+	}
+
+	var index GitRegistryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return false
+	}
+	return index.Layout == GitLayoutFiles
+}
+
+// storeVersionFiles writes every file in the archive to the package's file store
+// and records them in the manifest. The manifest's SHA-256 and size become those of
+// the reproducible archive the files are rebuilt into, which is always gzip.
+func (c *GitClient) storeVersionFiles(packageDir string, manifest *GitManifest, archivePath string) error {
+	filesDir := filepath.Join(packageDir, "files")
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create file store: %w", err)
+	}
+
+	var entries []GitFileEntry
+	err := pkg.WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		hash, size, err := storeBlob(filesDir, content)
+		if err != nil {
+			return fmt.Errorf("failed to store %s: %w", header.Name, err)
+		}
+		entries = append(entries, GitFileEntry{
+			Path:   path.Clean(filepath.ToSlash(header.Name)),
+			SHA256: hash,
+			Size:   size,
+			Mode:   header.Mode & 0777,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := tempdir.MkdirTemp("files-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	info, err := c.packFromStore(packageDir, entries, filepath.Join(tempDir, "archive.tar.gz"))
+	if err != nil {
+		return err
+	}
+
+	manifest.Files = entries
+	manifest.SHA256 = info.SHA256
+	manifest.Integrity = info.Integrity
+	manifest.Size = info.SizeBytes
+	manifest.Format = ""
+
+	if c.verbose {
+		output.Printf("🗂️  Stored %d files for %s@%s\n", len(entries), manifest.Name, manifest.Version)
+	}
+
+	return nil
+}
+
+// storeBlob writes content to dir/<sha256> unless a blob with that hash is already
+// stored
+func storeBlob(dir string, content io.Reader) (string, int64, error) {
+	temp, err := os.CreateTemp(dir, ".blob-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(temp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hasher), content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	blobPath := filepath.Join(dir, hash)
+	if _, err := os.Stat(blobPath); err == nil {
+		return hash, size, nil
+	}
+	if err := os.Rename(temp.Name(), blobPath); err != nil {
+		return "", 0, err
+	}
+
+	return hash, size, nil
+}
+
+// packFromStore builds a version's archive from the package's file store
+func (c *GitClient) packFromStore(packageDir string, entries []GitFileEntry, outputPath string) (*pkg.ArchiveInfo, error) {
+	files := make([]pkg.ReproducibleFile, len(entries))
+	for i, entry := range entries {
+		files[i] = pkg.ReproducibleFile{
+			Path:   entry.Path,
+			Mode:   entry.Mode,
+			Source: filepath.Join(packageDir, "files", entry.SHA256),
+		}
+	}
+
+	info, err := pkg.PackReproducible(files, outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build archive from file store: %w", err)
+	}
+	return info, nil
+}
+
+// rebuildArchiveByHash looks for a version stored as files whose archive has the
+// given hash and rebuilds the archive into destPath. It reports false when no such
+// version exists.
+func (c *GitClient) rebuildArchiveByHash(sha256Hash, destPath string) (bool, error) {
+	manifestPaths, err := filepath.Glob(filepath.Join(c.cacheDir, "packages", "*", "versions", "*", "manifest.json"))
+	if err != nil {
+		return false, fmt.Errorf("error searching for archive: %w", err)
+	}
+
+	for _, manifestPath := range manifestPaths {
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			continue
+		}
+		var manifest GitManifest
+		if err := json.Unmarshal(data, &manifest); err != nil || manifest.SHA256 != sha256Hash || len(manifest.Files) == 0 {
+			continue
+		}
+
+		packageDir := filepath.Dir(filepath.Dir(filepath.Dir(manifestPath)))
+		info, err := c.packFromStore(packageDir, manifest.Files, destPath)
+		if err != nil {
+			return false, err
+		}
+		if info.SHA256 != sha256Hash {
+			os.Remove(destPath)
+			return false, fmt.Errorf("archive rebuilt for %s@%s has hash %s, expected %s",
+				manifest.Name, manifest.Version, info.SHA256, sha256Hash)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// pruneFileBlobs removes blobs from a package's file store that no remaining
+// version references
+func (c *GitClient) pruneFileBlobs(w *git.Worktree, root, name string) error {
+	packageDir := filepath.Join(root, "packages", name)
+	blobs, err := os.ReadDir(filepath.Join(packageDir, "files"))
+	if err != nil {
+		return nil // Package is not in the file store
+	}
+
+	referenced, err := referencedBlobs(packageDir)
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		if blob.IsDir() || referenced[blob.Name()] {
+			continue
+		}
+		blobPath := filepath.ToSlash(filepath.Join("packages", name, "files", blob.Name()))
+		if _, err := w.Remove(blobPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", blobPath, err)
+		}
+		if c.verbose {
+			output.Printf("🗑️  Removed %s\n", blobPath)
+		}
+	}
+
+	return nil
+}
+
+// referencedBlobs returns the hashes of the stored files any version of a package
+// lists
+func referencedBlobs(packageDir string) (map[string]bool, error) {
+	manifestPaths, err := filepath.Glob(filepath.Join(packageDir, "versions", "*", "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	for _, manifestPath := range manifestPaths {
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", manifestPath, err)
+		}
+		var manifest GitManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", manifestPath, err)
+		}
+		for _, file := range manifest.Files {
+			referenced[file.SHA256] = true
+		}
+	}
+
+	return referenced, nil
+}
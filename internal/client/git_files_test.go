@@ -0,0 +1,93 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rfhmanifest "rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	cacheDir := t.TempDir()
+	packageDir := filepath.Join(cacheDir, "packages", "security-rules")
+	c := &GitClient{cacheDir: cacheDir}
+
+	publish := func(version string, files map[string]string) *GitManifest {
+		sourceDir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(sourceDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+		info, err := pkg.PackFromDirectory(sourceDir, filepath.Join(t.TempDir(), "archive.tgz"))
+		if err != nil {
+			t.Fatalf("PackFromDirectory failed: %v", err)
+		}
+
+		manifest := &GitManifest{PackageManifest: rfhmanifest.PackageManifest{Name: "security-rules", Version: version}}
+		if err := c.storeVersionFiles(packageDir, manifest, info.Path); err != nil {
+			t.Fatalf("storeVersionFiles failed: %v", err)
+		}
+
+		versionDir := filepath.Join(packageDir, "versions", version)
+		if err := os.MkdirAll(versionDir, 0755); err != nil {
+			t.Fatalf("failed to create version dir: %v", err)
+		}
+		data, _ := json.Marshal(manifest)
+		if err := os.WriteFile(filepath.Join(versionDir, "manifest.json"), data, 0644); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+		return manifest
+	}
+
+	v1 := publish("1.0.0", map[string]string{"rulestack.json": "{}", "rules/secrets.mdc": "no secrets"})
+	v2 := publish("1.1.0", map[string]string{"rulestack.json": "{}", "rules/secrets.mdc": "no secrets, ever"})
+
+	blobs, err := os.ReadDir(filepath.Join(packageDir, "files"))
+	if err != nil {
+		t.Fatalf("failed to read file store: %v", err)
+	}
+	if len(blobs) != 3 {
+		t.Errorf("expected the shared manifest stored once (3 blobs), got %d", len(blobs))
+	}
+
+	for _, manifest := range []*GitManifest{v1, v2} {
+		destPath := filepath.Join(t.TempDir(), "download.tgz")
+		rebuilt, err := c.rebuildArchiveByHash(manifest.SHA256, destPath)
+		if err != nil || !rebuilt {
+			t.Fatalf("expected %s to be rebuilt, got %v (%v)", manifest.Version, rebuilt, err)
+		}
+
+		hashes, err := pkg.HashFiles(destPath)
+		if err != nil {
+			t.Fatalf("HashFiles failed: %v", err)
+		}
+		for _, file := range manifest.Files {
+			if hashes[file.Path] != file.SHA256 {
+				t.Errorf("%s@%s: expected %s with hash %s, got %q", manifest.Name, manifest.Version, file.Path, file.SHA256, hashes[file.Path])
+			}
+		}
+	}
+
+	if rebuilt, err := c.rebuildArchiveByHash("unknown", filepath.Join(t.TempDir(), "x.tgz")); rebuilt || err != nil {
+		t.Errorf("expected an unknown hash not to be rebuilt, got %v (%v)", rebuilt, err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(packageDir, "versions", "1.0.0")); err != nil {
+		t.Fatalf("failed to remove version: %v", err)
+	}
+	referenced, err := referencedBlobs(packageDir)
+	if err != nil {
+		t.Fatalf("referencedBlobs failed: %v", err)
+	}
+	if len(referenced) != 2 {
+		t.Errorf("expected only 1.1.0's 2 files referenced, got %v", referenced)
+	}
+}
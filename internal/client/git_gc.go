@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+
+	"rulestack/internal/output"
+	"rulestack/internal/retention"
+	"rulestack/internal/tracing"
+	"rulestack/internal/version"
+)
+
+// GCOptions controls a garbage collection run on a Git registry
+type GCOptions struct {
+	Policy retention.Policy
+	// PruneArchives deletes the version directories of expired versions. Without it
+	// only metadata is rewritten, so lockfiles pinning an expired archive keep working.
+	PruneArchives bool
+	// DryRun reports what would be collected without changing the registry
+	DryRun bool
+}
+
+// GCVersion is a package version removed by garbage collection
+type GCVersion struct {
+	Name    string
+	Version string
+	Size    int64
+}
+
+// GCResult summarizes a garbage collection run
+type GCResult struct {
+	Expired    []GCVersion
+	FreedBytes int64 // Archive bytes removed from the working tree (only with PruneArchives)
+	PRUrl      string
+	Message    string
+}
+
+// CollectGarbage applies a retention policy to the registry. Changes are proposed as
+// a pull request, like publishes, so they can be reviewed before anything is removed.
+func (c *GitClient) CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error) {
+	ctx, span := tracing.Start(ctx, "git gc")
+	defer span.End()
+
+	repo, err := c.cloneRepository(ctx, c.repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare repository: %w", err)
+	}
+
+	ghRepo, githubClient, err := c.gitHubClient()
+	if err != nil {
+		return nil, err
+	}
+	owner, repoName := ghRepo.Owner, ghRepo.Name
+	baseBranch := "main"
+	if repository, err := githubClient.GetRepository(ctx, owner, repoName); err == nil && repository.GetDefaultBranch() != "" {
+		baseBranch = repository.GetDefaultBranch()
+	}
+
+	// Decide from the registry as it is on the remote, not a stale local branch,
+	// and leave the cache there afterwards
+	if err := c.returnToBranch(repo, baseBranch); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.returnToBranch(repo, baseBranch); err != nil {
+			output.Fprintf(os.Stderr, "⚠️  Could not return the registry cache to %s: %v\n", baseBranch, err)
+		}
+	}()
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := w.Filesystem.Root()
+
+	expiredByPackage, err := c.findExpiredVersions(root, opts.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GCResult{}
+	for _, name := range sortedPackageNames(expiredByPackage) {
+		result.Expired = append(result.Expired, expiredByPackage[name]...)
+	}
+	if opts.PruneArchives {
+		for _, expired := range result.Expired {
+			result.FreedBytes += expired.Size
+		}
+	}
+
+	if len(result.Expired) == 0 {
+		result.Message = "Nothing to collect"
+		return result, nil
+	}
+	if opts.DryRun {
+		result.Message = fmt.Sprintf("%d version(s) would be removed", len(result.Expired))
+		return result, nil
+	}
+
+	branchName, err := c.createBranch(repo, "gc/"+time.Now().UTC().Format("20060102-150405"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	for name, expired := range expiredByPackage {
+		if err := c.removeExpiredVersions(w, root, name, expired, opts.PruneArchives); err != nil {
+			return nil, err
+		}
+	}
+
+	var versionList string
+	for _, expired := range result.Expired {
+		versionList += fmt.Sprintf("- %s@%s\n", expired.Name, expired.Version)
+	}
+
+	message := fmt.Sprintf("Collect garbage: remove %d expired version(s)\n\n%s", len(result.Expired), versionList)
+	if _, err := w.Commit(message, &git.CommitOptions{Author: c.getAuthor()}); err != nil {
+		return nil, fmt.Errorf("failed to create commit: %w", err)
+	}
+
+	if err := c.pushBranch(ctx, repo, branchName); err != nil {
+		return nil, fmt.Errorf("failed to push branch: %w", err)
+	}
+
+	body := "## 🧹 Registry Garbage Collection\n\nExpired versions:\n" + versionList
+	if opts.PruneArchives {
+		body += "\nArchives of these versions are deleted. Lockfiles pinning them will no longer install.\n"
+	} else {
+		body += "\nVersion directories are kept, so lockfiles pinning these archives keep working.\n"
+	}
+	body += "\n---\n*This pull request was automatically generated by RuleStack CLI*"
+
+	pr, err := githubClient.CreatePullRequest(ctx, owner, repoName, "Collect garbage", branchName, baseBranch, body)
+	if err != nil {
+		manualURL := fmt.Sprintf("%s/compare/%s...%s", ghRepo.WebURL(), baseBranch, branchName)
+		result.PRUrl = manualURL
+		result.Message = fmt.Sprintf("Branch pushed. Create PR manually: %s", manualURL)
+		return result, nil
+	}
+
+	result.PRUrl = pr.GetHTMLURL()
+	result.Message = fmt.Sprintf("Pull request created successfully: %s", pr.GetHTMLURL())
+	return result, nil
+}
+
+// findExpiredVersions applies the policy to every package's metadata.json
+func (c *GitClient) findExpiredVersions(root string, policy retention.Policy) (map[string][]GCVersion, error) {
+	entries, err := os.ReadDir(filepath.Join(root, "packages"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read packages: %w", err)
+	}
+
+	now := time.Now()
+	expiredByPackage := make(map[string][]GCVersion)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		metadata, err := readPackageMetadata(filepath.Join(root, "packages", entry.Name()))
+		if err != nil {
+			if c.verbose {
+				output.Printf("⚠️  Skipping %s: %v\n", entry.Name(), err)
+			}
+			continue
+		}
+
+		candidates := make([]retention.Candidate, len(metadata.Versions))
+		sizes := make(map[string]int64, len(metadata.Versions))
+		for i, v := range metadata.Versions {
+			candidates[i] = retention.Candidate{Version: v.Version, PublishedAt: v.PublishedAt}
+			sizes[v.Version] = v.Size
+		}
+
+		for _, expired := range retention.Expired(candidates, policy, now) {
+			expiredByPackage[entry.Name()] = append(expiredByPackage[entry.Name()], GCVersion{
+				Name:    entry.Name(),
+				Version: expired.Version,
+				Size:    sizes[expired.Version],
+			})
+		}
+	}
+
+	return expiredByPackage, nil
+}
+
+// removeExpiredVersions rewrites a package's metadata without the expired versions
+// and, when pruning, deletes their version directories
+func (c *GitClient) removeExpiredVersions(w *git.Worktree, root, name string, expired []GCVersion, prune bool) error {
+	packageDir := filepath.Join(root, "packages", name)
+	metadata, err := readPackageMetadata(packageDir)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(expired))
+	for _, v := range expired {
+		remove[v.Version] = true
+	}
+
+	var kept []GitVersionSummary
+	for _, v := range metadata.Versions {
+		if !remove[v.Version] {
+			kept = append(kept, v)
+		}
+	}
+	metadata.Versions = kept
+	metadata.Latest = latestVersion(kept, metadata.Latest)
+	metadata.UpdatedAt = time.Now()
+
+	data, _ := json.MarshalIndent(metadata, "", "  ")
+	if err := os.WriteFile(filepath.Join(packageDir, "metadata.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata for %s: %w", name, err)
+	}
+	if _, err := w.Add(filepath.ToSlash(filepath.Join("packages", name, "metadata.json"))); err != nil {
+		return fmt.Errorf("failed to stage metadata for %s: %w", name, err)
+	}
+
+	if !prune {
+		return nil
+	}
+
+	for _, v := range expired {
+		versionPath := filepath.ToSlash(filepath.Join("packages", name, "versions", v.Version))
+		if _, err := os.Stat(filepath.Join(root, versionPath)); err != nil {
+			continue
+		}
+		if _, err := w.Remove(versionPath); err != nil {
+			return fmt.Errorf("failed to remove %s@%s: %w", name, v.Version, err)
+		}
+		if c.verbose {
+			output.Printf("🗑️  Removed %s\n", versionPath)
+		}
+	}
+
+	// Files shared with kept versions stay; the rest go with their versions
+	return c.pruneFileBlobs(w, root, name)
+}
+
+// readPackageMetadata loads packages/<name>/metadata.json
+func readPackageMetadata(packageDir string) (*GitPackageMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(packageDir, "metadata.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	var metadata GitPackageMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// latestVersion returns the latest of versions as version.Latest picks it, or
+// fallback if none parse
+func latestVersion(versions []GitVersionSummary, fallback string) string {
+	raw := make([]string, len(versions))
+	for i, v := range versions {
+		raw[i] = v.Version
+	}
+	if latest := version.Latest(raw); latest != "" {
+		return latest
+	}
+	return fallback
+}
+
+// sortedPackageNames returns the package names of a per-package map in order
+func sortedPackageNames(byPackage map[string][]GCVersion) []string {
+	names := make([]string, 0, len(byPackage))
+	for name := range byPackage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
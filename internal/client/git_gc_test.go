@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rulestack/internal/retention"
+)
+
+func TestFindExpiredVersions(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+
+	writeMetadata := func(metadata GitPackageMetadata) {
+		dir := filepath.Join(root, "packages", metadata.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create package dir: %v", err)
+		}
+		data, _ := json.Marshal(metadata)
+		if err := os.WriteFile(filepath.Join(dir, "metadata.json"), data, 0644); err != nil {
+			t.Fatalf("failed to write metadata: %v", err)
+		}
+	}
+
+	writeMetadata(GitPackageMetadata{
+		Name:   "security-rules",
+		Latest: "2.0.0",
+		Versions: []GitVersionSummary{
+			{Version: "1.0.0", Size: 100, PublishedAt: now.Add(-72 * time.Hour)},
+			{Version: "2.0.0-beta.1", Size: 200, PublishedAt: now.Add(-48 * time.Hour)},
+			{Version: "2.0.0", Size: 300, PublishedAt: now.Add(-24 * time.Hour)},
+		},
+	})
+	writeMetadata(GitPackageMetadata{
+		Name:     "single",
+		Latest:   "1.0.0",
+		Versions: []GitVersionSummary{{Version: "1.0.0", PublishedAt: now.Add(-72 * time.Hour)}},
+	})
+
+	c := &GitClient{}
+	expired, err := c.findExpiredVersions(root, retention.Policy{KeepVersions: 2})
+	if err != nil {
+		t.Fatalf("findExpiredVersions failed: %v", err)
+	}
+
+	if len(expired["single"]) != 0 {
+		t.Errorf("expected a package's only version to be kept, got %v", expired["single"])
+	}
+	got := expired["security-rules"]
+	if len(got) != 1 || got[0].Version != "1.0.0" || got[0].Size != 100 {
+		t.Errorf("expected security-rules@1.0.0 (100 bytes) to expire, got %v", got)
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		expected string
+	}{
+		{"highest semver", []string{"1.0.0", "1.10.0", "1.2.0"}, "1.10.0"},
+		{"release beats pre-release", []string{"2.0.0-rc.1", "2.0.0"}, "2.0.0"},
+		{"no valid versions keeps fallback", []string{"latest"}, "fallback"},
+		{"empty keeps fallback", nil, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var versions []GitVersionSummary
+			for _, v := range tt.versions {
+				versions = append(versions, GitVersionSummary{Version: v})
+			}
+			if got := latestVersion(versions, "fallback"); got != tt.expected {
+				t.Errorf("latestVersion() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitPackageMetadataLatestVersion(t *testing.T) {
+	// Metadata written by older clients names the last version published
+	metadata := GitPackageMetadata{
+		Latest:   "1.4.1",
+		Versions: []GitVersionSummary{{Version: "1.4.0"}, {Version: "2.0.0"}, {Version: "2.1.0-rc.1"}, {Version: "1.4.1"}},
+	}
+	if got := metadata.LatestVersion(); got != "2.0.0" {
+		t.Errorf("LatestVersion() = %q, want 2.0.0", got)
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Ping times listing the remote's refs, which also checks the token, and then
+// cloning the registry, or fetching into the existing cache
+func (c *GitClient) Ping(ctx context.Context) (*PingReport, error) {
+	report := &PingReport{Auth: AuthNone}
+	start := time.Now()
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{c.repoURL}})
+	phaseStart := time.Now()
+	_, err := remote.ListContext(ctx, &git.ListOptions{Auth: c.getAuth()})
+	report.Phases = append(report.Phases, PingPhase{Name: "ls-remote", Duration: time.Since(phaseStart)})
+	if err != nil {
+		if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+			report.Auth = AuthRejected
+			if c.gitToken == "" {
+				report.Auth = AuthNone
+				report.AuthDetail = "repository is private; set a git token"
+			} else {
+				report.AuthDetail = "token expired, revoked or lacks access to the repository"
+			}
+			report.Total = time.Since(start)
+			return report, NewRegistryError(ErrUnauthorized, "authentication failed")
+		}
+		report.Auth = AuthUnknown
+		report.Total = time.Since(start)
+		return report, NewRegistryError(ErrConnectionFailed, fmt.Sprintf("registry unreachable: %v", err))
+	}
+	if c.gitToken != "" {
+		report.Auth = AuthAccepted
+	}
+
+	phase := "fetch"
+	if _, err := os.Stat(filepath.Join(c.cacheDir, ".git")); err != nil {
+		phase = "clone"
+	}
+	phaseStart = time.Now()
+	err = c.ensureRepo(ctx)
+	report.Phases = append(report.Phases, PingPhase{Name: phase, Duration: time.Since(phaseStart)})
+	report.Total = time.Since(start)
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Git hosting providers, told apart by the repository's host
+const (
+	providerGitHub    = "github" // github.com, and any host not known to be another provider
+	providerGitLab    = "gitlab"
+	providerBitbucket = "bitbucket"
+)
+
+// gitRepoURL identifies a repository on a Git hosting provider
+type gitRepoURL struct {
+	Scheme string // Scheme of the web and API addresses, "https" unless the URL used "http"
+	Host   string // Hostname, with the port for HTTP(S) URLs
+	Owner  string
+	Name   string
+}
+
+// scpLikeURL matches the scp-style SSH syntax, as in git@github.com:owner/repo.git
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// parseRepoURL extracts the host, owner and repo from a repository URL. It
+// accepts HTTPS, SSH and scp-style URLs, hosts with ports, and trailing paths
+// such as /tree/main.
+func parseRepoURL(repoURL string) (*gitRepoURL, error) {
+	raw := strings.TrimSpace(repoURL)
+	repo := &gitRepoURL{Scheme: "https"}
+
+	var path string
+	if m := scpLikeURL.FindStringSubmatch(raw); m != nil && !strings.Contains(raw, "://") {
+		repo.Host, path = m[1], m[2]
+	} else {
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid repository URL format")
+		}
+		switch u.Scheme {
+		case "http", "https":
+			repo.Scheme = u.Scheme
+			repo.Host = u.Host
+		default:
+			// An SSH port says nothing about where the web UI and API listen
+			repo.Host = u.Hostname()
+		}
+		path = u.Path
+	}
+	repo.Host = strings.ToLower(repo.Host)
+	if repo.Host == "www.github.com" {
+		repo.Host = "github.com"
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("could not parse owner/repo from URL")
+	}
+	repo.Owner = parts[0]
+	repo.Name = strings.TrimSuffix(parts[1], ".git")
+	if repo.Owner == "" || repo.Name == "" {
+		return nil, fmt.Errorf("could not parse owner/repo from URL")
+	}
+
+	return repo, nil
+}
+
+// Provider returns the hosting provider of the repository
+func (r *gitRepoURL) Provider() string {
+	hostname := strings.Split(r.Host, ":")[0]
+	switch {
+	case strings.Contains(hostname, "gitlab"):
+		return providerGitLab
+	case strings.Contains(hostname, "bitbucket"):
+		return providerBitbucket
+	default:
+		return providerGitHub
+	}
+}
+
+// WebURL returns the address of the repository's web pages
+func (r *gitRepoURL) WebURL() string {
+	return fmt.Sprintf("%s://%s/%s/%s", r.Scheme, r.Host, r.Owner, r.Name)
+}
+
+// DefaultAPIURL derives the REST API base of the provider hosting a repository:
+// api.github.com and api.bitbucket.org for the public services, /api/v3 on
+// GitHub Enterprise servers and /api/v4 on GitLab instances. It returns "" when
+// the repository URL cannot be parsed.
+func DefaultAPIURL(repoURL string) string {
+	repo, err := parseRepoURL(repoURL)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case repo.Host == "github.com":
+		return "https://api.github.com/"
+	case repo.Host == "bitbucket.org":
+		return "https://api.bitbucket.org/2.0/"
+	case repo.Provider() == providerGitLab:
+		return fmt.Sprintf("%s://%s/api/v4/", repo.Scheme, repo.Host)
+	case repo.Provider() == providerGitHub:
+		return fmt.Sprintf("%s://%s/api/v3/", repo.Scheme, repo.Host)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,30 @@
+package client
+
+import "testing"
+
+func TestDefaultAPIURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoURL string
+		want    string
+	}{
+		{"github.com", "https://github.com/org/rules.git", "https://api.github.com/"},
+		{"github.com over SSH", "git@github.com:org/rules.git", "https://api.github.com/"},
+		{"GitHub Enterprise", "https://ghe.example.com/org/rules", "https://ghe.example.com/api/v3/"},
+		{"GitHub Enterprise with port", "http://ghe.internal:8080/org/rules", "http://ghe.internal:8080/api/v3/"},
+		{"GitHub Enterprise over SSH", "ssh://git@ghe.example.com:7999/org/rules.git", "https://ghe.example.com/api/v3/"},
+		{"gitlab.com", "https://gitlab.com/org/rules.git", "https://gitlab.com/api/v4/"},
+		{"self-hosted GitLab", "git@gitlab.example.com:org/rules.git", "https://gitlab.example.com/api/v4/"},
+		{"bitbucket.org", "https://bitbucket.org/org/rules.git", "https://api.bitbucket.org/2.0/"},
+		{"self-hosted Bitbucket", "https://bitbucket.example.com/org/rules.git", ""},
+		{"unparseable", "not a url", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultAPIURL(tt.repoURL); got != tt.want {
+				t.Errorf("DefaultAPIURL(%q) = %q, want %q", tt.repoURL, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,14 +16,76 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/integrity"
+	rfhmanifest "rulestack/internal/manifest"
+	"rulestack/internal/output"
 )
 
+// publishBranchName returns the branch a version is published on
+func publishBranchName(packageName, version string) string {
+	return fmt.Sprintf("publish/%s/%s", packageName, version)
+}
+
 // createPublishBranch creates a new branch for publishing
 func (c *GitClient) createPublishBranch(repo *git.Repository, packageName, version string) (string, error) {
-	branchName := fmt.Sprintf("publish/%s/%s", packageName, version)
+	return c.createBranch(repo, publishBranchName(packageName, version))
+}
+
+// GetPublishReview reports whether a version's publish pull request is still
+// open, was merged or was closed. A version on the default branch is merged.
+func (c *GitClient) GetPublishReview(ctx context.Context, name, version string) (*PublishReview, error) {
+	if err := c.ensureRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.publishReview(ctx, name, version)
+}
+
+// publishReview looks up a version's publish pull request in the synced clone
+// and, for GitHub registries, through the API
+func (c *GitClient) publishReview(ctx context.Context, name, version string) (*PublishReview, error) {
+	review := &PublishReview{Name: name, Version: version, Branch: publishBranchName(name, version)}
+	if c.versionExists(name, version) {
+		review.State = "merged"
+		return review, nil
+	}
+
+	// Publish branches are fetched with the default branch
+	if _, err := c.repo.Reference(plumbing.NewRemoteReferenceName("origin", review.Branch), true); err != nil {
+		return nil, NewRegistryError(ErrVersionNotFound, fmt.Sprintf("%s@%s", name, version))
+	}
+	review.State = "open"
+
+	ghRepo, githubClient, err := c.gitHubClient()
+	if err != nil {
+		return review, nil
+	}
+	pr, err := githubClient.FindPullRequest(ctx, ghRepo.Owner, ghRepo.Name, review.Branch)
+	if err != nil {
+		if c.verbose {
+			output.Printf("⚠️  Could not look up the pull request of %s: %v\n", review.Branch, err)
+		}
+		return review, nil
+	}
+
+	review.PRNumber = pr.GetNumber()
+	review.PRUrl = pr.GetHTMLURL()
+	switch {
+	case pr.GetMerged() || pr.MergedAt != nil:
+		review.State = "merged"
+	case pr.GetState() == "closed":
+		review.State = "closed"
+	}
 
+	return review, nil
+}
+
+// createBranch creates a branch at HEAD and checks it out
+func (c *GitClient) createBranch(repo *git.Repository, branchName string) (string, error) {
 	if c.verbose {
-		fmt.Printf("🌿 Creating branch: %s\n", branchName)
+		output.Printf("🌿 Creating branch: %s\n", branchName)
 	}
 
 	// Get current HEAD
@@ -63,30 +125,101 @@ func (c *GitClient) createPublishBranch(repo *git.Repository, packageName, versi
 	return branchName, nil
 }
 
-// addPackageFiles adds package files to the repository
-func (c *GitClient) addPackageFiles(repo *git.Repository, manifestPath, archivePath string) error {
-	// Parse manifest to get package info
+// defaultBranch returns the registry's default branch: the one origin/HEAD
+// points at, else main or master, whichever the remote has
+func (c *GitClient) defaultBranch(repo *git.Repository) (string, error) {
+	if ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().Short(), "origin/"), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if _, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), false); err == nil {
+			return branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to find the registry's default branch")
+}
+
+// returnToBranch checks out a branch reset to its remote branch, so the cache
+// reads the registry as it is on the remote again after work on another branch
+func (c *GitClient) returnToBranch(repo *git.Repository, branch string) error {
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return fmt.Errorf("failed to find origin/%s: %w", branch, err)
+	}
+
+	// Point the local branch at the remote one, creating it if it is missing
+	ref := plumbing.NewBranchReferenceName(branch)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(ref, remoteRef.Hash())); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", branch, err)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if err := w.Checkout(&git.CheckoutOptions{Branch: ref, Force: true}); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// describeReview names a version with its pull request, or its branch when the
+// pull request is unknown
+func describeReview(review *PublishReview) string {
+	if review.PRNumber != 0 {
+		return fmt.Sprintf("%s@%s (PR #%d: %s)", review.Name, review.Version, review.PRNumber, review.PRUrl)
+	}
+	return fmt.Sprintf("%s@%s (branch %s)", review.Name, review.Version, review.Branch)
+}
+
+// readPublishManifest reads the package manifest a version is published from
+func readPublishManifest(manifestPath string) (*GitManifest, error) {
 	manifestData, err := os.ReadFile(manifestPath)
 	if err != nil {
-		return fmt.Errorf("failed to read manifest: %w", err)
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
 	}
 
-	var manifest GitManifest
-	if err := json.Unmarshal(manifestData, &manifest); err != nil {
-		return fmt.Errorf("failed to parse manifest: %w", err)
+	var packageManifest rfhmanifest.PackageManifest
+	if err := json.Unmarshal(manifestData, &packageManifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
+	// A version's manifest.json is not a package source for editors to check
+	packageManifest.Schema = ""
+
+	return &GitManifest{PackageManifest: packageManifest}, nil
+}
+
+// addPackageFiles adds package files to the repository, recording the archive
+// in manifest as it writes the version's manifest.json
+func (c *GitClient) addPackageFiles(repo *git.Repository, manifest *GitManifest, archivePath string) error {
 	// Calculate archive hash
 	archiveHash, archiveSize, err := c.calculateFileInfo(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to calculate archive info: %w", err)
 	}
 
+	digests, err := integrity.File(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to calculate archive info: %w", err)
+	}
+
 	// Update manifest with archive info
 	manifest.SHA256 = archiveHash
+	manifest.Integrity = digests.String()
 	manifest.Size = archiveSize
 	manifest.PublishedAt = time.Now()
 
+	archiveName := "archive.tar.gz"
+	if format, err := compression.DetectFile(archivePath); err == nil && format == compression.Zstd {
+		manifest.Format = compression.Zstd
+		archiveName = "archive.tar.zst"
+	}
+
 	// Get worktree
 	w, err := repo.Worktree()
 	if err != nil {
@@ -101,6 +234,15 @@ func (c *GitClient) addPackageFiles(repo *git.Repository, manifestPath, archiveP
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	// Registries using the file store keep each file once per package instead of
+	// an archive per version
+	storeFiles := c.usesFileStore(w.Filesystem.Root())
+	if storeFiles {
+		if err := c.storeVersionFiles(packageDir, manifest, archivePath); err != nil {
+			return err
+		}
+	}
+
 	// Write manifest
 	manifestDest := filepath.Join(versionDir, "manifest.json")
 	updatedManifest, _ := json.MarshalIndent(manifest, "", "  ")
@@ -109,13 +251,15 @@ func (c *GitClient) addPackageFiles(repo *git.Repository, manifestPath, archiveP
 	}
 
 	// Copy archive
-	archiveDest := filepath.Join(versionDir, "archive.tar.gz")
-	if err := c.copyFile(archivePath, archiveDest); err != nil {
-		return fmt.Errorf("failed to copy archive: %w", err)
+	if !storeFiles {
+		archiveDest := filepath.Join(versionDir, archiveName)
+		if err := c.copyFile(archivePath, archiveDest); err != nil {
+			return fmt.Errorf("failed to copy archive: %w", err)
+		}
 	}
 
 	// Update package metadata
-	if err := c.updatePackageMetadata(packageDir, &manifest); err != nil {
+	if err := c.updatePackageMetadata(packageDir, manifest); err != nil {
 		return fmt.Errorf("failed to update package metadata: %w", err)
 	}
 
@@ -126,7 +270,7 @@ func (c *GitClient) addPackageFiles(repo *git.Repository, manifestPath, archiveP
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Added package files for %s@%s\n", manifest.Name, manifest.Version)
+		output.Printf("✅ Added package files for %s@%s\n", manifest.Name, manifest.Version)
 	}
 
 	return nil
@@ -174,7 +318,7 @@ func (c *GitClient) updatePackageMetadata(packageDir string, manifest *GitManife
 	}
 
 	// Update metadata
-	metadata.Latest = manifest.Version
+	metadata.License = manifest.License
 	metadata.UpdatedAt = time.Now()
 
 	// Add version if not exists
@@ -186,6 +330,7 @@ func (c *GitClient) updatePackageMetadata(packageDir string, manifest *GitManife
 			metadata.Versions[i] = GitVersionSummary{
 				Version:     manifest.Version,
 				SHA256:      manifest.SHA256,
+				Integrity:   manifest.Integrity,
 				Size:        manifest.Size,
 				PublishedAt: manifest.PublishedAt,
 			}
@@ -197,10 +342,15 @@ func (c *GitClient) updatePackageMetadata(packageDir string, manifest *GitManife
 		metadata.Versions = append(metadata.Versions, GitVersionSummary{
 			Version:     manifest.Version,
 			SHA256:      manifest.SHA256,
+			Integrity:   manifest.Integrity,
 			Size:        manifest.Size,
 			PublishedAt: manifest.PublishedAt,
 		})
 	}
+	metadata.Latest = latestVersion(metadata.Versions, manifest.Version)
+	if metadata.Latest == manifest.Version {
+		metadata.Dependencies = manifest.Dependencies
+	}
 
 	// Write updated metadata
 	data, _ := json.MarshalIndent(metadata, "", "  ")
@@ -229,12 +379,22 @@ func (c *GitClient) updateRegistryIndex(repo *git.Repository, manifest *GitManif
 		}
 	}
 
+	// Publishing a fix to an older version does not change the latest one
+	latest := manifest.Version
+	if data, err := os.ReadFile(filepath.Join(w.Filesystem.Root(), "packages", manifest.Name, "metadata.json")); err == nil {
+		var metadata GitPackageMetadata
+		if json.Unmarshal(data, &metadata) == nil {
+			latest = metadata.LatestVersion()
+		}
+	}
+
 	// Update index
 	index.UpdatedAt = time.Now()
 	index.Packages[manifest.Name] = GitPackageEntry{
 		Name:        manifest.Name,
 		Description: manifest.Description,
-		Latest:      manifest.Version,
+		Latest:      latest,
+		License:     manifest.License,
 		UpdatedAt:   time.Now(),
 	}
 
@@ -273,7 +433,7 @@ func (c *GitClient) createCommit(repo *git.Repository, manifest *GitManifest) (p
 	message += fmt.Sprintf("- Size: %d bytes\n", manifest.Size)
 
 	if c.verbose {
-		fmt.Printf("💬 Creating commit: %s@%s\n", manifest.Name, manifest.Version)
+		output.Printf("💬 Creating commit: %s@%s\n", manifest.Name, manifest.Version)
 	}
 
 	// Get author info
@@ -289,7 +449,7 @@ func (c *GitClient) createCommit(repo *git.Repository, manifest *GitManifest) (p
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Created commit: %s\n", commit.String()[:7])
+		output.Printf("✅ Created commit: %s\n", commit.String()[:7])
 	}
 
 	return commit, nil
@@ -318,7 +478,7 @@ func (c *GitClient) getAuthor() *object.Signature {
 // pushBranch pushes the branch to the remote repository
 func (c *GitClient) pushBranch(ctx context.Context, repo *git.Repository, branchName string) error {
 	if c.verbose {
-		fmt.Printf("📤 Pushing branch: %s\n", branchName)
+		output.Printf("📤 Pushing branch: %s\n", branchName)
 	}
 
 	pushOpts := &git.PushOptions{
@@ -333,7 +493,7 @@ func (c *GitClient) pushBranch(ctx context.Context, repo *git.Repository, branch
 	}
 
 	if c.verbose {
-		pushOpts.Progress = os.Stdout
+		pushOpts.Progress = output.Live()
 	}
 
 	err := repo.PushContext(ctx, pushOpts)
@@ -342,7 +502,7 @@ func (c *GitClient) pushBranch(ctx context.Context, repo *git.Repository, branch
 	}
 
 	if c.verbose {
-		fmt.Printf("✅ Branch pushed successfully\n")
+		output.Printf("✅ Branch pushed successfully\n")
 	}
 
 	return nil
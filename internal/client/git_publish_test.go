@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	rfhmanifest "rulestack/internal/manifest"
 )
 
 func TestGitPublishing(t *testing.T) {
@@ -24,45 +26,122 @@ func TestGitPublishing(t *testing.T) {
 		tests := []struct {
 			name      string
 			url       string
+			wantHost  string
 			wantOwner string
 			wantRepo  string
+			wantWeb   string
 			wantErr   bool
 		}{
 			{
 				name:      "valid https URL",
 				url:       "https://github.com/owner/repo.git",
+				wantHost:  "github.com",
 				wantOwner: "owner",
 				wantRepo:  "repo",
-				wantErr:   false,
+				wantWeb:   "https://github.com/owner/repo",
 			},
 			{
 				name:      "valid https URL without .git",
 				url:       "https://github.com/owner/repo",
+				wantHost:  "github.com",
+				wantOwner: "owner",
+				wantRepo:  "repo",
+				wantWeb:   "https://github.com/owner/repo",
+			},
+			{
+				name:      "trailing path",
+				url:       "https://github.com/owner/repo/tree/main/packages/",
+				wantHost:  "github.com",
+				wantOwner: "owner",
+				wantRepo:  "repo",
+				wantWeb:   "https://github.com/owner/repo",
+			},
+			{
+				name:      "scp-style SSH URL",
+				url:       "git@github.com:owner/repo.git",
+				wantHost:  "github.com",
+				wantOwner: "owner",
+				wantRepo:  "repo",
+				wantWeb:   "https://github.com/owner/repo",
+			},
+			{
+				name:      "SSH URL with port",
+				url:       "ssh://git@github.com:22/owner/repo.git",
+				wantHost:  "github.com",
 				wantOwner: "owner",
 				wantRepo:  "repo",
-				wantErr:   false,
+				wantWeb:   "https://github.com/owner/repo",
+			},
+			{
+				name:      "enterprise host",
+				url:       "https://GHE.Example.com/platform/rules.git",
+				wantHost:  "ghe.example.com",
+				wantOwner: "platform",
+				wantRepo:  "rules",
+				wantWeb:   "https://ghe.example.com/platform/rules",
+			},
+			{
+				name:      "enterprise host with port",
+				url:       "http://ghe.internal:8080/platform/rules",
+				wantHost:  "ghe.internal:8080",
+				wantOwner: "platform",
+				wantRepo:  "rules",
+				wantWeb:   "http://ghe.internal:8080/platform/rules",
+			},
+			{
+				name:      "enterprise scp-style SSH URL",
+				url:       "git@ghe.example.com:platform/rules.git",
+				wantHost:  "ghe.example.com",
+				wantOwner: "platform",
+				wantRepo:  "rules",
+				wantWeb:   "https://ghe.example.com/platform/rules",
+			},
+			{
+				name:      "enterprise SSH URL with port",
+				url:       "ssh://git@ghe.example.com:7999/platform/rules.git",
+				wantHost:  "ghe.example.com",
+				wantOwner: "platform",
+				wantRepo:  "rules",
+				wantWeb:   "https://ghe.example.com/platform/rules",
+			},
+			{
+				name:    "non-GitHub URL",
+				url:     "https://gitlab.com/owner/repo.git",
+				wantErr: true,
+			},
+			{
+				name:    "Bitbucket SSH URL",
+				url:     "git@bitbucket.org:owner/repo.git",
+				wantErr: true,
 			},
 			{
-				name:      "non-GitHub URL",
-				url:       "https://gitlab.com/owner/repo.git",
-				wantOwner: "",
-				wantRepo:  "",
-				wantErr:   true,
+				name:    "missing repo",
+				url:     "https://github.com/owner",
+				wantErr: true,
 			},
 		}
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				owner, repo, err := parseGitHubURL(tt.url)
+				repo, err := parseGitHubURL(tt.url)
 				if (err != nil) != tt.wantErr {
 					t.Errorf("parseGitHubURL() error = %v, wantErr %v", err, tt.wantErr)
 					return
 				}
-				if owner != tt.wantOwner {
-					t.Errorf("parseGitHubURL() owner = %v, want %v", owner, tt.wantOwner)
+				if tt.wantErr {
+					return
+				}
+				if repo.Host != tt.wantHost {
+					t.Errorf("parseGitHubURL() host = %v, want %v", repo.Host, tt.wantHost)
+				}
+				if repo.Owner != tt.wantOwner {
+					t.Errorf("parseGitHubURL() owner = %v, want %v", repo.Owner, tt.wantOwner)
 				}
-				if repo != tt.wantRepo {
-					t.Errorf("parseGitHubURL() repo = %v, want %v", repo, tt.wantRepo)
+				if repo.Name != tt.wantRepo {
+					t.Errorf("parseGitHubURL() repo = %v, want %v", repo.Name, tt.wantRepo)
+				}
+				if got := repo.WebURL(); got != tt.wantWeb {
+					t.Errorf("WebURL() = %v, want %v", got, tt.wantWeb)
 				}
 			})
 		}
@@ -109,13 +188,15 @@ func TestGitPublishing(t *testing.T) {
 
 		// Create test manifest
 		manifest := &GitManifest{
-			Name:         "test-package",
-			Version:      "1.0.0",
-			Description:  "A test package",
-			SHA256:       "abcdef123456",
-			Size:         1024,
-			PublishedAt:  time.Now(),
-			Publisher:    "test-user",
+			PackageManifest: rfhmanifest.PackageManifest{
+				Name:        "test-package",
+				Version:     "1.0.0",
+				Description: "A test package",
+			},
+			SHA256:      "abcdef123456",
+			Size:        1024,
+			PublishedAt: time.Now(),
+			Publisher:   "test-user",
 		}
 
 		// Update metadata (first time - creates new)
@@ -150,6 +231,7 @@ func TestGitPublishing(t *testing.T) {
 
 		// Update with new version
 		manifest.Version = "1.1.0"
+		manifest.Dependencies = map[string]string{"base-rules": "^1.2"}
 		err = client.updatePackageMetadata(packageDir, manifest)
 		if err != nil {
 			t.Fatalf("Second updatePackageMetadata failed: %v", err)
@@ -166,6 +248,27 @@ func TestGitPublishing(t *testing.T) {
 		if len(metadata.Versions) != 2 {
 			t.Errorf("Expected 2 versions, got %d", len(metadata.Versions))
 		}
+
+		if metadata.Dependencies["base-rules"] != "^1.2" {
+			t.Errorf("Expected the latest version's dependencies, got %v", metadata.Dependencies)
+		}
+
+		// A fix to an older version published last is not the latest
+		manifest.Version = "1.0.1"
+		manifest.Dependencies = nil
+		if err := client.updatePackageMetadata(packageDir, manifest); err != nil {
+			t.Fatalf("Third updatePackageMetadata failed: %v", err)
+		}
+		data, _ = os.ReadFile(metadataPath)
+		metadata = GitPackageMetadata{}
+		json.Unmarshal(data, &metadata)
+
+		if metadata.Latest != "1.1.0" {
+			t.Errorf("Expected latest version to stay '1.1.0', got '%s'", metadata.Latest)
+		}
+		if metadata.Dependencies["base-rules"] != "^1.2" {
+			t.Errorf("Expected the latest version's dependencies to stay, got %v", metadata.Dependencies)
+		}
 	})
 
 	t.Run("GetAuthor", func(t *testing.T) {
@@ -202,4 +305,43 @@ func TestGitPublishing(t *testing.T) {
 		}
 	})
 
-}
\ No newline at end of file
+}
+func TestReadPublishManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	source := `{
+  "$schema": "` + rfhmanifest.PackageSchemaURL + `",
+  "name": "security-rules",
+  "version": "1.2.0",
+  "files": ["rules/*.mdc"],
+  "targets": ["cursor"],
+  "license": "MIT",
+  "dependencies": {"base-rules": "^1.2"}
+}`
+	if err := os.WriteFile(manifestPath, []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	manifest, err := readPublishManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("Expected a package manifest with file patterns to be read, got %v", err)
+	}
+	if manifest.Name != "security-rules" || manifest.License != "MIT" || manifest.Dependencies["base-rules"] != "^1.2" || len(manifest.Targets) != 1 {
+		t.Errorf("Expected the package manifest's fields, got %+v", manifest.PackageManifest)
+	}
+
+	// The stored manifest.json lists stored files, not the patterns packed from
+	manifest.SHA256 = "abc123"
+	manifest.Files = []GitFileEntry{{Path: "rules/secrets.mdc", SHA256: "def456", Size: 10}}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+
+	var stored GitManifest
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Failed to read back manifest.json: %v", err)
+	}
+	if stored.Schema != "" || stored.SHA256 != "abc123" || len(stored.Files) != 1 || stored.Files[0].Path != "rules/secrets.mdc" || stored.Dependencies["base-rules"] != "^1.2" {
+		t.Errorf("Expected the published manifest to round-trip, got %s", data)
+	}
+}
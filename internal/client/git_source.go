@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"rulestack/internal/output"
+	"rulestack/internal/progress"
+)
+
+// gitProgressOutput is where go-git reports clone and fetch progress: JSON
+// events with --progress=json, stdout when verbose outside plain mode, or nowhere
+func gitProgressOutput(op, repoURL string, verbose bool) io.Writer {
+	if events := progress.GitProgress(progress.Event{Op: op, Subject: repoURL}); events != nil {
+		return events
+	}
+	if verbose {
+		return output.Live()
+	}
+	return nil
+}
+
+// FetchGitSource clones a package source repository into destDir and checks out
+// ref (tag, branch or commit; empty means the default branch). It returns the
+// resolved commit hash so callers can record exactly what was installed.
+func FetchGitSource(ctx context.Context, repoURL, ref, destDir, gitToken string, verbose bool) (string, error) {
+	// Reuse GitClient auth handling for provider-specific usernames
+	auth := (&GitClient{repoURL: repoURL, gitToken: gitToken}).getAuth()
+
+	cloneOpts := &git.CloneOptions{
+		URL:      repoURL,
+		Auth:     auth,
+		Progress: gitProgressOutput("clone", repoURL, verbose),
+	}
+
+	if verbose {
+		output.Printf("📥 Cloning source repository %s\n", repoURL)
+	}
+
+	progress.Start("clone", repoURL)
+	repo, err := git.PlainCloneContext(ctx, destDir, false, cloneOpts)
+	progress.Done("clone", repoURL, err)
+	if err != nil {
+		if err == transport.ErrAuthenticationRequired {
+			return "", NewRegistryError(ErrUnauthorized,
+				"authentication required - provide a Git token for private repositories")
+		}
+		return "", NewRegistryError(ErrConnectionFailed, fmt.Sprintf("failed to clone source repository: %v", err))
+	}
+
+	if ref == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		return head.Hash().String(), nil
+	}
+
+	// Try the ref as given, then as a remote branch
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		hash, err = repo.ResolveRevision(plumbing.Revision("refs/remotes/origin/" + ref))
+		if err != nil {
+			return "", NewRegistryError(ErrNotFound, fmt.Sprintf("ref '%s' not found in %s", ref, repoURL))
+		}
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if err := w.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return "", fmt.Errorf("failed to checkout %s: %w", ref, err)
+	}
+
+	return hash.String(), nil
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile writes a file to a repository's worktree and commits it
+func commitFile(t *testing.T, repo *git.Repository, name, content string) plumbing.Hash {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.Filesystem.Root(), name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatalf("failed to add %s: %v", name, err)
+	}
+	hash, err := w.Commit("Update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	return hash
+}
+
+func TestSyncRepoRecoversDivergedCache(t *testing.T) {
+	remoteDir := t.TempDir()
+	remote, err := git.PlainInit(remoteDir, false)
+	if err != nil {
+		t.Fatalf("failed to init remote: %v", err)
+	}
+	first := commitFile(t, remote, "index.json", `{"packages":{}}`)
+	commitFile(t, remote, "index.json", `{"packages":{"a":{}}}`)
+
+	c := &GitClient{repoURL: remoteDir, cacheDir: filepath.Join(t.TempDir(), "cache")}
+	ctx := context.Background()
+	if err := c.syncRepo(ctx); err != nil {
+		t.Fatalf("initial sync failed: %v", err)
+	}
+
+	// Force-push: rewrite the remote's history from the first commit
+	w, _ := remote.Worktree()
+	if err := w.Reset(&git.ResetOptions{Commit: first, Mode: git.HardReset}); err != nil {
+		t.Fatalf("failed to reset remote: %v", err)
+	}
+	rewritten := commitFile(t, remote, "index.json", `{"packages":{"b":{}}}`)
+
+	if err := c.syncRepo(ctx); err != nil {
+		t.Fatalf("sync after force-push failed: %v", err)
+	}
+	head, err := c.repo.Head()
+	if err != nil {
+		t.Fatalf("failed to read cache HEAD: %v", err)
+	}
+	if head.Hash() != rewritten {
+		t.Errorf("expected the cache reset to %s, got %s", rewritten, head.Hash())
+	}
+	data, _ := os.ReadFile(filepath.Join(c.cacheDir, "index.json"))
+	if string(data) != `{"packages":{"b":{}}}` {
+		t.Errorf("expected the worktree to match the remote, got %s", data)
+	}
+
+	// A cache that cannot be opened is cloned again
+	if err := os.RemoveAll(filepath.Join(c.cacheDir, ".git")); err != nil {
+		t.Fatalf("failed to remove .git: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.cacheDir, ".git"), []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("failed to corrupt cache: %v", err)
+	}
+	fresh := &GitClient{repoURL: remoteDir, cacheDir: c.cacheDir}
+	if err := fresh.syncRepo(ctx); err != nil {
+		t.Fatalf("sync of a corrupt cache failed: %v", err)
+	}
+	if head, err := fresh.repo.Head(); err != nil || head.Hash() != rewritten {
+		t.Errorf("expected a fresh clone at %s, got %v (%v)", rewritten, head, err)
+	}
+}
+
+func TestPublishReturnsCacheToDefaultBranch(t *testing.T) {
+	remoteDir := t.TempDir()
+	remote, err := git.PlainInit(remoteDir, false)
+	if err != nil {
+		t.Fatalf("failed to init remote: %v", err)
+	}
+	commitFile(t, remote, "index.json", `{"version":"1.0","packages":{}}`)
+
+	// A file:// URL is not a GitHub repository, so publishes fall back to a
+	// manual pull request link instead of calling the API
+	c := &GitClient{repoURL: "file://" + remoteDir, cacheDir: filepath.Join(t.TempDir(), "cache")}
+	ctx := context.Background()
+
+	publish := func(version string) {
+		t.Helper()
+		dir := t.TempDir()
+		manifestPath := filepath.Join(dir, "manifest.json")
+		archivePath := filepath.Join(dir, "archive.tar.gz")
+		manifest := `{"name":"security-rules","version":"` + version + `","description":"Security rules"}`
+		if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+		if err := os.WriteFile(archivePath, []byte("archive "+version), 0644); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+		if _, err := c.PublishPackage(ctx, manifestPath, archivePath); err != nil {
+			t.Fatalf("PublishPackage(%s) failed: %v", version, err)
+		}
+	}
+
+	// merge fast-forwards the remote's default branch to a publish branch
+	merge := func(version string) {
+		t.Helper()
+		ref, err := remote.Reference(plumbing.NewBranchReferenceName("publish/security-rules/"+version), true)
+		if err != nil {
+			t.Fatalf("publish branch of %s not pushed: %v", version, err)
+		}
+		w, _ := remote.Worktree()
+		if err := w.Reset(&git.ResetOptions{Commit: ref.Hash(), Mode: git.HardReset}); err != nil {
+			t.Fatalf("failed to merge %s: %v", version, err)
+		}
+	}
+
+	search := func() []Package {
+		t.Helper()
+		results, err := c.SearchPackages(ctx, SearchOptions{})
+		if err != nil {
+			t.Fatalf("SearchPackages failed: %v", err)
+		}
+		return results
+	}
+
+	assertOnMaster := func() {
+		t.Helper()
+		repo, err := git.PlainOpen(c.cacheDir)
+		if err != nil {
+			t.Fatalf("failed to open cache: %v", err)
+		}
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatalf("failed to read cache HEAD: %v", err)
+		}
+		if head.Name() != plumbing.NewBranchReferenceName("master") {
+			t.Errorf("expected the cache back on master, got %s", head.Name())
+		}
+	}
+
+	publish("1.0.0")
+	assertOnMaster()
+	if results := search(); len(results) != 0 {
+		t.Errorf("expected an unmerged publish to stay out of search, got %+v", results)
+	}
+
+	// Installing the unmerged version points at its pending review
+	_, err = c.GetPackageVersion(ctx, "security-rules", "1.0.0")
+	if !errors.Is(err, ErrPendingReview) || !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected a pending review that is also not found, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "branch publish/security-rules/1.0.0") {
+		t.Errorf("expected the error to name the publish branch, got %v", err)
+	}
+	if review, err := c.GetPublishReview(ctx, "security-rules", "1.0.0"); err != nil || review.State != "open" {
+		t.Errorf("expected an open review, got %+v (%v)", review, err)
+	}
+	if _, err := c.GetPublishReview(ctx, "security-rules", "9.9.9"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected a version never published to be not found, got %v", err)
+	}
+
+	merge("1.0.0")
+	results := search()
+	if len(results) != 1 || results[0].Latest != "1.0.0" {
+		t.Fatalf("expected security-rules@1.0.0 after the merge, got %+v", results)
+	}
+	if review, err := c.GetPublishReview(ctx, "security-rules", "1.0.0"); err != nil || review.State != "merged" {
+		t.Errorf("expected a merged review, got %+v (%v)", review, err)
+	}
+
+	// The next publish branches from the merged default branch
+	publish("1.1.0")
+	assertOnMaster()
+	merge("1.1.0")
+	results = search()
+	if len(results) != 1 || results[0].Latest != "1.1.0" || len(results[0].Versions) != 2 {
+		t.Errorf("expected both versions with 1.1.0 latest, got %+v", results)
+	}
+}
+
+func BenchmarkLoadIndex(b *testing.B) {
+	reg := newTestRegistry(b)
+
+	// A registry with 10k packages listed in its index
+	index := GitRegistryIndex{Version: "1.0", UpdatedAt: time.Now(), Packages: make(map[string]GitPackageEntry)}
+	for i := 0; i < 10000; i++ {
+		name := fmt.Sprintf("rules-%05d", i)
+		index.Packages[name] = GitPackageEntry{
+			Name:        name,
+			Description: "Coding rules for team " + name,
+			Latest:      "1.2.3",
+			UpdatedAt:   index.UpdatedAt,
+			Tags:        []string{"security", "style"},
+			License:     "MIT",
+		}
+	}
+	index.PackageCount = len(index.Packages)
+	data, err := json.Marshal(index)
+	if err != nil {
+		b.Fatal(err)
+	}
+	reg.commitToMain("Import packages", nil, func(root string) {
+		if err := os.WriteFile(filepath.Join(root, "index.json"), data, 0644); err != nil {
+			b.Fatalf("failed to write index.json: %v", err)
+		}
+	})
+
+	c := reg.client()
+	ctx := context.Background()
+	if _, err := c.loadIndex(ctx); err != nil {
+		b.Fatalf("loadIndex failed: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loaded, err := c.loadIndex(ctx)
+		if err != nil {
+			b.Fatalf("loadIndex failed: %v", err)
+		}
+		if len(loaded.Packages) != 10000 {
+			b.Fatalf("expected 10000 packages, got %d", len(loaded.Packages))
+		}
+	}
+}
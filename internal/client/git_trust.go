@@ -0,0 +1,155 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// isSignedPath reports whether a registry file must come from a trusted commit:
+// the package archives and metadata under packages/, and the index
+func isSignedPath(path string) bool {
+	return path == "index.json" || strings.HasPrefix(path, "packages/")
+}
+
+// KeyFingerprint returns the primary key fingerprint of an armored OpenPGP public key
+func KeyFingerprint(armoredKey string) (string, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid OpenPGP public key: %w", err)
+	}
+	if len(keyring) != 1 {
+		return "", fmt.Errorf("expected one OpenPGP public key, found %d", len(keyring))
+	}
+
+	return fmt.Sprintf("%X", keyring[0].PrimaryKey.Fingerprint), nil
+}
+
+// verifyPublishHistory checks that the content of every package file and of
+// the index at HEAD was committed by a trusted key: each file is followed back
+// to the commit that introduced its current content, whatever that commit's
+// message, and that commit must be signed by one of the trusted keys. Older
+// versions of a file that have since been replaced are not checked. A registry
+// with any file from an unsigned or untrusted commit is rejected as a whole.
+func (c *GitClient) verifyPublishHistory() error {
+	head, err := c.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Hash() == c.verifiedHead {
+		return nil
+	}
+
+	headCommit, err := c.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	tree, err := headCommit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+
+	// Files still to trace, by the commit they are traced from, with the blob
+	// each must have there
+	pending := map[plumbing.Hash]map[string]plumbing.Hash{head.Hash(): {}}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if isSignedPath(f.Name) {
+			pending[head.Hash()][f.Name] = f.Hash
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+
+	trusted := make(map[plumbing.Hash]bool)
+	queue := []plumbing.Hash{head.Hash()}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		files := pending[hash]
+		if len(files) == 0 {
+			continue
+		}
+		delete(pending, hash)
+
+		commit, err := c.repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		parents, err := commitParentTrees(commit)
+		if err != nil {
+			return err
+		}
+
+		for path, blob := range files {
+			// A file a parent already had is traced further back through it
+			from := plumbing.ZeroHash
+			for i, parentTree := range parents {
+				if entry, err := parentTree.FindEntry(path); err == nil && entry.Hash == blob {
+					from = commit.ParentHashes[i]
+					break
+				}
+			}
+			if from != plumbing.ZeroHash {
+				if pending[from] == nil {
+					pending[from] = make(map[string]plumbing.Hash)
+				}
+				pending[from][path] = blob
+				queue = append(queue, from)
+				continue
+			}
+
+			// This commit introduced the file's content
+			if _, checked := trusted[hash]; !checked {
+				trusted[hash] = c.signedByTrustedKey(commit)
+			}
+			if !trusted[hash] {
+				subject, _, _ := strings.Cut(commit.Message, "\n")
+				reason := "is not signed by a trusted key"
+				if commit.PGPSignature == "" {
+					reason = "is not signed"
+				}
+				return NewRegistryError(ErrUntrustedRegistry,
+					fmt.Sprintf("commit %s (%s) changes %s but %s", hash.String()[:12], subject, path, reason))
+			}
+		}
+	}
+
+	c.verifiedHead = head.Hash()
+	return nil
+}
+
+// commitParentTrees returns the trees of a commit's parents, in order
+func commitParentTrees(commit *object.Commit) ([]*object.Tree, error) {
+	var trees []*object.Tree
+	err := commit.Parents().ForEach(func(parent *object.Commit) error {
+		tree, err := parent.Tree()
+		if err != nil {
+			return err
+		}
+		trees = append(trees, tree)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parents of %s: %w", commit.Hash, err)
+	}
+	return trees, nil
+}
+
+// signedByTrustedKey reports whether a commit carries a valid signature from one
+// of the trusted keys
+func (c *GitClient) signedByTrustedKey(commit *object.Commit) bool {
+	if commit.PGPSignature == "" {
+		return false
+	}
+	for _, key := range c.trustedKeys {
+		if _, err := commit.Verify(key); err == nil {
+			return true
+		}
+	}
+	return false
+}
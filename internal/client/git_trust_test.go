@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func newTestKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("failed to armor key: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("failed to serialize key: %v", err)
+	}
+	w.Close()
+
+	return entity, buf.String()
+}
+
+// newSignedRegistry creates a repository and returns a function committing a
+// change to path, signed with key unless it is nil
+func newSignedRegistry(t *testing.T) (*git.Repository, func(path, message string, key *openpgp.Entity)) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+
+	commit := func(path, message string, key *openpgp.Entity) {
+		t.Helper()
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		data, _ := os.ReadFile(fullPath)
+		os.WriteFile(fullPath, append(data, '.'), 0644)
+		worktree.Add(path)
+		_, err := worktree.Commit(message, &git.CommitOptions{
+			Author:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+			SignKey: key,
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	return repo, commit
+}
+
+func TestVerifyPublishHistory(t *testing.T) {
+	trusted, trustedKey := newTestKey(t, "publisher")
+	untrusted, _ := newTestKey(t, "mallory")
+
+	repo, commit := newSignedRegistry(t)
+	c := &GitClient{repo: repo, trustedKeys: []string{trustedKey}}
+
+	// Content replaced by a signed commit since needs no signature of its own
+	commit("index.json", "Initialize registry", nil)
+	commit("index.json", "Publish security-rules@1.0.0\n\n- Package: security-rules", trusted)
+	if err := c.verifyPublishHistory(); err != nil {
+		t.Fatalf("expected a trusted history to verify, got %v", err)
+	}
+
+	commit("index.json", "Publish security-rules@1.1.0", untrusted)
+	if err := c.verifyPublishHistory(); !errors.Is(err, ErrUntrustedRegistry) {
+		t.Errorf("expected a publish signed by an untrusted key to be rejected, got %v", err)
+	}
+
+	commit("index.json", "Publish security-rules@1.2.0", nil)
+	if err := c.verifyPublishHistory(); !errors.Is(err, ErrUntrustedRegistry) {
+		t.Errorf("expected an unsigned publish to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyPublishHistory_UnsignedArchiveChange(t *testing.T) {
+	trusted, trustedKey := newTestKey(t, "publisher")
+
+	repo, commit := newSignedRegistry(t)
+	c := &GitClient{repo: repo, trustedKeys: []string{trustedKey}}
+
+	archive := "packages/security-rules/1.0.0/security-rules-1.0.0.tgz"
+	commit(archive, "Publish security-rules@1.0.0", trusted)
+	commit("README.md", "Update README", nil)
+	if err := c.verifyPublishHistory(); err != nil {
+		t.Fatalf("expected unsigned changes outside packages/ to be allowed, got %v", err)
+	}
+
+	// Whatever its subject, a commit changing an archive must be signed
+	commit(archive, "Fix typo", nil)
+	err := c.verifyPublishHistory()
+	if !errors.Is(err, ErrUntrustedRegistry) || !strings.Contains(err.Error(), archive) {
+		t.Errorf("expected an unsigned archive change to be rejected, got %v", err)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	entity, armored := newTestKey(t, "publisher")
+
+	fingerprint, err := KeyFingerprint(armored)
+	if err != nil {
+		t.Fatalf("KeyFingerprint failed: %v", err)
+	}
+	if len(fingerprint) != 40 || fingerprint[24:] != entity.PrimaryKey.KeyIdString() {
+		t.Errorf("expected the primary key fingerprint, got %s", fingerprint)
+	}
+
+	if _, err := KeyFingerprint("not a key"); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+}
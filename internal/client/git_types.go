@@ -1,6 +1,10 @@
 package client
 
-import "time"
+import (
+	"time"
+
+	rfhmanifest "rulestack/internal/manifest"
+)
 
 // GitRegistryIndex represents the root index.json file
 type GitRegistryIndex struct {
@@ -8,6 +12,11 @@ type GitRegistryIndex struct {
 	UpdatedAt    time.Time                 `json:"updated_at"`
 	PackageCount int                       `json:"package_count"`
 	Packages     map[string]GitPackageEntry `json:"packages"`
+	// ArchiveFormats lists the archive formats publishers may use; empty means gzip only
+	ArchiveFormats []string `json:"archive_formats,omitempty"`
+	// Layout is GitLayoutFiles when versions are stored as per-file blobs; empty
+	// means one archive per version
+	Layout string `json:"layout,omitempty"`
 }
 
 // GitPackageEntry represents a package entry in the index
@@ -17,6 +26,7 @@ type GitPackageEntry struct {
 	Latest      string    `json:"latest"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Tags        []string  `json:"tags,omitempty"`
+	License     string    `json:"license,omitempty"`
 }
 
 // GitPackageMetadata represents the metadata.json file
@@ -26,27 +36,54 @@ type GitPackageMetadata struct {
 	Latest      string              `json:"latest"`
 	Versions    []GitVersionSummary `json:"versions"`
 	Tags        []string            `json:"tags,omitempty"`
+	License     string              `json:"license,omitempty"`
 	CreatedAt   time.Time           `json:"created_at"`
 	UpdatedAt   time.Time           `json:"updated_at"`
+
+	// Dependencies of the latest version, so searches need not load its manifest
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// LatestVersion returns the highest version the metadata lists. The "latest"
+// field is only a fallback: older clients set it to the last version published,
+// even when that was a fix to an older major version.
+func (m *GitPackageMetadata) LatestVersion() string {
+	return latestVersion(m.Versions, m.Latest)
 }
 
 // GitVersionSummary represents a version in package metadata
 type GitVersionSummary struct {
 	Version     string    `json:"version"`
 	SHA256      string    `json:"sha256"`
+	Integrity   string    `json:"integrity,omitempty"`
 	Size        int64     `json:"size"`
 	PublishedAt time.Time `json:"published_at"`
 }
 
 // GitManifest represents a version's manifest.json
 type GitManifest struct {
-	Name         string                 `json:"name"`
-	Version      string                 `json:"version"`
-	Description  string                 `json:"description"`
-	Dependencies map[string]string      `json:"dependencies,omitempty"`
-	SHA256       string                 `json:"sha256"`
-	Size         int64                  `json:"size"`
-	PublishedAt  time.Time              `json:"published_at"`
-	Publisher    string                 `json:"publisher"`
-	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	// The package manifest the version was published from
+	rfhmanifest.PackageManifest
+
+	// What the registry records about the published archive
+	SHA256      string                 `json:"sha256"`
+	Integrity   string                 `json:"integrity,omitempty"` // Digests of the archive as sha512-...
+	Size        int64                  `json:"size"`
+	PublishedAt time.Time              `json:"published_at"`
+	Publisher   string                 `json:"publisher"`
+	Format      string                 `json:"format,omitempty"` // Archive compression; empty means gzip
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// Files lists the version's files when it is stored in the package's file
+	// store instead of as an archive. It replaces the package's file patterns,
+	// which only matter when packing.
+	Files []GitFileEntry `json:"files,omitempty"`
+}
+
+// GitFileEntry is a file of a version kept in packages/<name>/files/<sha256>
+type GitFileEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Mode   int64  `json:"mode,omitempty"`
 }
\ No newline at end of file
@@ -2,12 +2,17 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/go-github/v67/github"
 	"golang.org/x/oauth2"
+
+	"rulestack/internal/output"
+	"rulestack/internal/tracing"
 )
 
 // GitHubClient handles GitHub API operations
@@ -17,26 +22,36 @@ type GitHubClient struct {
 	verbose bool
 }
 
-// NewGitHubClient creates a new GitHub API client
-func NewGitHubClient(token string, verbose bool) *GitHubClient {
-	ctx := context.Background()
+// NewGitHubClient creates a new GitHub API client. An empty apiURL uses
+// api.github.com; GitHub Enterprise servers pass their REST API base, to which
+// /api/v3 is added when missing.
+func NewGitHubClient(token, apiURL string, verbose bool) (*GitHubClient, error) {
+	// oauth2 builds on the HTTP client in the context, so API calls are traced
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: tracing.NewTransport(nil)})
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
 
 	client := github.NewClient(tc)
+	if apiURL != "" {
+		var err error
+		client, err = client.WithEnterpriseURLs(apiURL, apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", apiURL, err)
+		}
+	}
 
 	return &GitHubClient{
 		client:  client,
 		verbose: verbose,
-	}
+	}, nil
 }
 
 // GetAuthenticatedUser gets information about the authenticated user
 func (g *GitHubClient) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
 	if g.verbose {
-		fmt.Printf("🔍 Getting authenticated user info\n")
+		output.Printf("🔍 Getting authenticated user info\n")
 	}
 
 	user, _, err := g.client.Users.Get(ctx, "")
@@ -45,49 +60,23 @@ func (g *GitHubClient) GetAuthenticatedUser(ctx context.Context) (*github.User,
 	}
 
 	if g.verbose {
-		fmt.Printf("✅ Authenticated as: %s\n", user.GetLogin())
+		output.Printf("✅ Authenticated as: %s\n", user.GetLogin())
 	}
 
 	return user, nil
 }
 
-// parseGitHubURL extracts owner and repo from GitHub URL
-func parseGitHubURL(repoURL string) (owner, repo string, err error) {
-	if !strings.Contains(repoURL, "github.com") {
-		return "", "", fmt.Errorf("not a GitHub URL")
-	}
-
-	// Handle different URL formats
-	repoURL = strings.TrimSuffix(repoURL, ".git")
-
-	// Parse URL - handle both https://github.com/owner/repo and git@github.com:owner/repo
-	var parts []string
-	if strings.Contains(repoURL, "github.com/") {
-		parts = strings.Split(repoURL, "/")
-	} else if strings.Contains(repoURL, "github.com:") {
-		parts = strings.Split(strings.Replace(repoURL, ":", "/", -1), "/")
-	} else {
-		return "", "", fmt.Errorf("invalid GitHub URL format")
-	}
-
-	if len(parts) < 2 {
-		return "", "", fmt.Errorf("invalid GitHub URL format")
-	}
-
-	// Find github.com in parts and extract owner/repo
-	for i, part := range parts {
-		if part == "github.com" && i+2 < len(parts) {
-			owner = parts[i+1]
-			repo = parts[i+2]
-			break
-		}
+// parseGitHubURL extracts the host, owner and repo from a GitHub URL. Any host
+// other than a GitLab or Bitbucket one is taken to be a GitHub Enterprise server.
+func parseGitHubURL(repoURL string) (*gitRepoURL, error) {
+	repo, err := parseRepoURL(repoURL)
+	if err != nil {
+		return nil, err
 	}
-
-	if owner == "" || repo == "" {
-		return "", "", fmt.Errorf("could not parse owner/repo from URL")
+	if repo.Provider() != providerGitHub {
+		return nil, fmt.Errorf("not a GitHub URL")
 	}
-
-	return owner, repo, nil
+	return repo, nil
 }
 
 // GetRepository gets repository information (no fork needed)
@@ -98,7 +87,7 @@ func (g *GitHubClient) GetRepository(ctx context.Context, owner, repo string) (*
 	}
 
 	if g.verbose {
-		fmt.Printf("📁 Repository: %s (default branch: %s)\n",
+		output.Printf("📁 Repository: %s (default branch: %s)\n",
 			repository.GetFullName(), repository.GetDefaultBranch())
 	}
 
@@ -109,9 +98,9 @@ func (g *GitHubClient) GetRepository(ctx context.Context, owner, repo string) (*
 // This is for collaborators creating PRs from branch to main on the same repo
 func (g *GitHubClient) CreatePullRequest(ctx context.Context, owner, repo, title, branchName, baseBranch, body string) (*github.PullRequest, error) {
 	if g.verbose {
-		fmt.Printf("📝 Creating pull request: %s\n", title)
-		fmt.Printf("   Repository: %s/%s\n", owner, repo)
-		fmt.Printf("   Branch: %s -> %s\n", branchName, baseBranch)
+		output.Printf("📝 Creating pull request: %s\n", title)
+		output.Printf("   Repository: %s/%s\n", owner, repo)
+		output.Printf("   Branch: %s -> %s\n", branchName, baseBranch)
 	}
 
 	newPR := &github.NewPullRequest{
@@ -136,8 +125,8 @@ func (g *GitHubClient) CreatePullRequest(ctx context.Context, owner, repo, title
 	}
 
 	if g.verbose {
-		fmt.Printf("✅ Pull request created: %s\n", pr.GetHTMLURL())
-		fmt.Printf("   PR #%d: %s\n", pr.GetNumber(), pr.GetTitle())
+		output.Printf("✅ Pull request created: %s\n", pr.GetHTMLURL())
+		output.Printf("   PR #%d: %s\n", pr.GetNumber(), pr.GetTitle())
 	}
 
 	return pr, nil
@@ -153,6 +142,90 @@ func (g *GitHubClient) GetPullRequest(ctx context.Context, owner, repo string, n
 	return pr, nil
 }
 
+// FindOpenPullRequest finds the open pull request for a branch of the repository
+func (g *GitHubClient) FindOpenPullRequest(ctx context.Context, owner, repo, branchName string) (*github.PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State: "open",
+		Head:  owner + ":" + branchName,
+	}
+
+	prs, _, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, NewRegistryError(ErrNetworkError, fmt.Sprintf("failed to list pull requests: %v", err))
+	}
+
+	if len(prs) == 0 {
+		return nil, NewRegistryError(ErrNotFound, fmt.Sprintf("no open pull request for branch %s", branchName))
+	}
+
+	return prs[0], nil
+}
+
+// FindPullRequest finds the most recent pull request, open or not, for a branch of the repository
+func (g *GitHubClient) FindPullRequest(ctx context.Context, owner, repo, branchName string) (*github.PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State: "all",
+		Head:  owner + ":" + branchName,
+	}
+
+	prs, _, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, NewRegistryError(ErrNetworkError, fmt.Sprintf("failed to list pull requests: %v", err))
+	}
+
+	if len(prs) == 0 {
+		return nil, NewRegistryError(ErrNotFound, fmt.Sprintf("no pull request for branch %s", branchName))
+	}
+
+	return prs[0], nil
+}
+
+// ApprovePullRequest submits an approving review on a pull request
+func (g *GitHubClient) ApprovePullRequest(ctx context.Context, owner, repo string, number int, body string) error {
+	if g.verbose {
+		output.Printf("👍 Approving PR #%d in %s/%s\n", number, owner, repo)
+	}
+
+	review := &github.PullRequestReviewRequest{
+		Event: github.String("APPROVE"),
+		Body:  github.String(body),
+	}
+
+	if _, _, err := g.client.PullRequests.CreateReview(ctx, owner, repo, number, review); err != nil {
+		return NewRegistryError(ErrInvalidOperation, fmt.Sprintf("failed to approve PR #%d: %v", number, err))
+	}
+
+	return nil
+}
+
+// RequiredApprovingReviews returns how many approving reviews branch protection requires
+// before merging. verified is false when the token may not read the protection rules,
+// which is the case for collaborators without admin rights.
+func (g *GitHubClient) RequiredApprovingReviews(ctx context.Context, owner, repo, branch string) (count int, verified bool, err error) {
+	b, _, err := g.client.Repositories.GetBranch(ctx, owner, repo, branch, 1)
+	if err != nil {
+		return 0, false, NewRegistryError(ErrNotFound, fmt.Sprintf("failed to get branch %s: %v", branch, err))
+	}
+
+	if !b.GetProtected() {
+		return 0, true, nil
+	}
+
+	protection, _, err := g.client.Repositories.GetBranchProtection(ctx, owner, repo, branch)
+	if errors.Is(err, github.ErrBranchNotProtected) {
+		return 0, true, nil
+	}
+	if err != nil {
+		// Branch is protected but the details need admin access
+		return 0, false, nil
+	}
+
+	if reviews := protection.GetRequiredPullRequestReviews(); reviews != nil {
+		return reviews.RequiredApprovingReviewCount, true, nil
+	}
+	return 0, true, nil
+}
+
 // CheckCollaboratorAccess verifies user has write access to the repository
 func (g *GitHubClient) CheckCollaboratorAccess(ctx context.Context, owner, repo string) error {
 	user, err := g.GetAuthenticatedUser(ctx)
@@ -168,7 +241,7 @@ func (g *GitHubClient) CheckCollaboratorAccess(ctx context.Context, owner, repo
 	}
 
 	if g.verbose {
-		fmt.Printf("✅ User %s has access to %s/%s\n", user.GetLogin(), owner, repo)
+		output.Printf("✅ User %s has access to %s/%s\n", user.GetLogin(), owner, repo)
 	}
 
 	return nil
@@ -185,7 +258,7 @@ func (g *GitHubClient) WaitForRateLimit(ctx context.Context) error {
 	if core.Remaining < 10 {
 		waitTime := time.Until(core.Reset.Time)
 		if g.verbose {
-			fmt.Printf("⏳ Rate limit low (%d remaining). Waiting %v\n",
+			output.Printf("⏳ Rate limit low (%d remaining). Waiting %v\n",
 				core.Remaining, waitTime)
 		}
 
@@ -209,7 +282,7 @@ func (g *GitHubClient) CheckRateLimit(ctx context.Context) error {
 
 	core := rateLimit.GetCore()
 	if g.verbose {
-		fmt.Printf("📊 Rate limit: %d/%d remaining (resets at %v)\n",
+		output.Printf("📊 Rate limit: %d/%d remaining (resets at %v)\n",
 			core.Remaining, core.Limit, core.Reset.Time)
 	}
 
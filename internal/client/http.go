@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -15,15 +16,26 @@ import (
 	"strings"
 	"time"
 
+	"rulestack/internal/compression"
 	"rulestack/internal/config"
+	"rulestack/internal/integrity"
+	"rulestack/internal/output"
+	"rulestack/internal/pkg"
+	"rulestack/internal/progress"
+	"rulestack/internal/tracing"
 )
 
+// blobAccept is the Accept header sent when downloading archives
+var blobAccept = compression.ContentType(compression.Zstd) + ", " + compression.ContentType(compression.Gzip)
+
 // HTTPClient represents an HTTP client for the RuleStack registry
 type HTTPClient struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
-	verbose    bool
+	baseURL        string
+	token          string
+	httpClient     *http.Client
+	verbose        bool
+	requestTimeout time.Duration // Deadline of API calls
+	stallTimeout   time.Duration // How long archive transfers may make no progress
 }
 
 // Ensure HTTPClient implements RegistryClient
@@ -33,13 +45,35 @@ var _ RegistryClient = (*HTTPClient)(nil)
 func NewHTTPClient(baseURL, token string, verbose bool) *HTTPClient {
 	baseURL = strings.TrimRight(baseURL, "/")
 
+	// No client-wide timeout: it would also cut off large archive transfers.
+	// API calls get a deadline per request instead.
 	return &HTTPClient{
 		baseURL: baseURL,
 		token:   token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport:     tracing.NewTransport(newTransport()),
+			CheckRedirect: keepTokenOnOrigin(baseURL),
 		},
-		verbose: verbose,
+		verbose:        verbose,
+		requestTimeout: requestTimeout,
+		stallTimeout:   transferStallTimeout,
+	}
+}
+
+// keepTokenOnOrigin drops the Authorization header from redirects that leave the
+// registry's scheme, host and port. net/http already does this for other
+// domains, but keeps it for subdomains and for HTTPS to HTTP downgrades.
+func keepTokenOnOrigin(baseURL string) func(*http.Request, []*http.Request) error {
+	origin, _ := url.Parse(baseURL)
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		if origin == nil || !strings.EqualFold(req.URL.Scheme, origin.Scheme) || !strings.EqualFold(req.URL.Host, origin.Host) {
+			req.Header.Del("Authorization")
+		}
+		return nil
 	}
 }
 
@@ -63,6 +97,9 @@ func (c *HTTPClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]
 	if opts.Target != "" {
 		params.Add("target", opts.Target)
 	}
+	if opts.Official {
+		params.Add("official", "true")
+	}
 	if opts.Limit > 0 {
 		params.Add("limit", strconv.Itoa(opts.Limit))
 	}
@@ -79,8 +116,8 @@ func (c *HTTPClient) SearchPackages(ctx context.Context, opts SearchOptions) ([]
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, NewRegistryError(ErrNetworkError,
-			fmt.Sprintf("search failed (status %d): %s", resp.StatusCode, string(body)))
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("search failed (status %d): %s", resp.StatusCode, errorMessage(body)))
 	}
 
 	// Parse response as maps first (for backward compatibility)
@@ -114,8 +151,8 @@ func (c *HTTPClient) GetPackage(ctx context.Context, name string) (*Package, err
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, NewRegistryError(ErrNetworkError,
-			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, string(body)))
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, errorMessage(body)))
 	}
 
 	var result map[string]interface{}
@@ -141,10 +178,14 @@ func (c *HTTPClient) GetPackageVersion(ctx context.Context, name, version string
 			fmt.Sprintf("%s@%s", name, version))
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, NewRegistryError(ErrNetworkError,
-			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, string(body)))
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, errorMessage(body)))
 	}
 
 	var result map[string]interface{}
@@ -155,40 +196,225 @@ func (c *HTTPClient) GetPackageVersion(ctx context.Context, name, version string
 	return MapToPackageVersion(result), nil
 }
 
+// GetPackagePreview fetches a package version's preview from the registry
+func (c *HTTPClient) GetPackagePreview(ctx context.Context, name, version string) (*PackagePreview, error) {
+	path := fmt.Sprintf("/v1/packages/%s/preview", name)
+	if version != "" {
+		path += "?version=" + url.QueryEscape(version)
+	}
+
+	resp, err := c.makeRequestWithContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, NewRegistryError(ErrVersionNotFound, fmt.Sprintf("%s@%s", name, version))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	var preview PackagePreview
+	if err := json.NewDecoder(resp.Body).Decode(&preview); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &preview, nil
+}
+
+// GetPackageVersions looks up many package versions with one bulk request. Registries
+// without the bulk endpoint are queried one version at a time instead.
+func (c *HTTPClient) GetPackageVersions(ctx context.Context, refs []VersionRef) ([]VersionMetadata, error) {
+	specs := make([]string, len(refs))
+	for i, ref := range refs {
+		specs[i] = ref.Name + "@" + ref.Version
+	}
+
+	payload, _ := json.Marshal(map[string][]string{"packages": specs})
+	resp, err := c.makeRequestWithContext(ctx, "POST", "/v1/packages/bulk", bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return c.getPackageVersionsSequentially(ctx, refs)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("bulk lookup failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	var result struct {
+		Packages []VersionMetadata `json:"packages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Packages, nil
+}
+
+// GetIndex fetches the packages changed since cursor, or the whole index when it is empty
+func (c *HTTPClient) GetIndex(ctx context.Context, since string) (*IndexDelta, error) {
+	path := "/v1/index"
+	if since != "" {
+		path += "?" + url.Values{"since": {since}}.Encode()
+	}
+
+	resp, err := c.makeRequestWithContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, NewRegistryError(ErrNotImplemented, "registry does not support index sync")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("index sync failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	var delta IndexDelta
+	if err := json.NewDecoder(resp.Body).Decode(&delta); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &delta, nil
+}
+
+// getPackageVersionsSequentially is the bulk lookup fallback for older registries
+func (c *HTTPClient) getPackageVersionsSequentially(ctx context.Context, refs []VersionRef) ([]VersionMetadata, error) {
+	results := make([]VersionMetadata, len(refs))
+	for i, ref := range refs {
+		results[i] = VersionMetadata{Name: ref.Name, Version: ref.Version}
+
+		pv, err := c.GetPackageVersion(ctx, ref.Name, ref.Version)
+		if errors.Is(err, ErrVersionNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].Found = true
+		results[i].SHA256 = pv.SHA256
+		results[i].Integrity = pv.Integrity
+		results[i].Size = pv.Size
+		results[i].Deprecated = pv.Deprecated
+		results[i].Replacement = pv.Replacement
+	}
+
+	return results, nil
+}
+
+// DeprecatePackage sets or clears the deprecation of a package version, or of
+// every version when version is empty
+func (c *HTTPClient) DeprecatePackage(ctx context.Context, name, version, message, replacement string) error {
+	path := fmt.Sprintf("/v1/packages/%s/versions/%s/deprecate", name, version)
+	ref := name + "@" + version
+	if version == "" {
+		path = fmt.Sprintf("/v1/packages/%s/deprecate", name)
+		ref = name
+	}
+
+	payload, _ := json.Marshal(map[string]string{"message": message, "replacement": replacement})
+	resp, err := c.makeRequestWithContext(ctx, "POST", path, bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusForbidden:
+		return responseError(resp, body, ErrUnauthorized, errorMessage(body))
+	case http.StatusNotFound:
+		return NewRegistryError(ErrVersionNotFound, ref)
+	case http.StatusBadRequest:
+		return NewRegistryError(ErrInvalidOperation, errorMessage(body))
+	default:
+		return responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("deprecate failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+}
+
 // PublishPackage publishes a package to the registry
 func (c *HTTPClient) PublishPackage(ctx context.Context, manifestPath, archivePath string) (*PublishResult, error) {
-	// Create multipart form
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	// Add manifest file
-	if err := c.addFileToForm(writer, "manifest", manifestPath); err != nil {
-		return nil, fmt.Errorf("failed to add manifest: %w", err)
+	// Large archives go up in chunks, so a dropped connection costs one chunk
+	if stat, err := os.Stat(archivePath); err == nil && stat.Size() > chunkedUploadThreshold {
+		result, err := c.publishChunked(ctx, manifestPath, archivePath, stat.Size())
+		if !errors.Is(err, errChunkedUnsupported) {
+			return result, err
+		}
+		if c.verbose {
+			output.Printf("ℹ️  Registry does not support chunked uploads, sending the archive in one request\n")
+		}
 	}
 
-	// Add archive file
-	if err := c.addFileToForm(writer, "archive", archivePath); err != nil {
-		return nil, fmt.Errorf("failed to add archive: %w", err)
+	// The registry checks the archive it receives against these digests
+	digests, err := integrity.File(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash archive: %w", err)
 	}
 
-	writer.Close()
+	// Stream the multipart form so the archive is never held in memory
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		if err := c.addFileToForm(writer, "manifest", manifestPath); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to add manifest: %w", err))
+			return
+		}
+		if err := writer.WriteField("integrity", digests.String()); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if err := c.addFileToForm(writer, "archive", archivePath); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to add archive: %w", err))
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
 
 	// Make request
-	resp, err := c.makeRequestWithContext(ctx, "POST", "/v1/packages", &buf, writer.FormDataContentType())
+	resp, err := c.makeTransferRequest(ctx, "POST", "/v1/packages", pr, map[string]string{"Content-Type": writer.FormDataContentType()})
+	pr.Close() // Unblocks the writer if the request ended early
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	return c.publishResponse(resp)
+}
+
+// publishResponse reads the registry's answer to a publish
+func (c *HTTPClient) publishResponse(resp *http.Response) (*PublishResult, error) {
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode == http.StatusUnauthorized {
 		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, NewRegistryError(ErrPublishFailed,
-			fmt.Sprintf("status %d: %s", resp.StatusCode, string(body)))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, responseError(resp, body, ErrPublishFailed,
+			fmt.Sprintf("status %d: %s", resp.StatusCode, errorMessage(body)))
 	}
 
 	var result map[string]interface{}
@@ -196,20 +422,268 @@ func (c *HTTPClient) PublishPackage(ctx context.Context, manifestPath, archivePa
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &PublishResult{
-		Name:    getStringFromMap(result, "name"),
-		Version: getStringFromMap(result, "version"),
-		SHA256:  getStringFromMap(result, "sha256"),
-		URL:     c.baseURL + "/v1/packages",
-		Message: "Package published successfully",
-	}, nil
+	publishResult := &PublishResult{
+		Name:      getStringFromMap(result, "name"),
+		Version:   getStringFromMap(result, "version"),
+		SHA256:    getStringFromMap(result, "sha256"),
+		Integrity: getStringFromMap(result, "integrity"),
+		URL:       c.baseURL + "/v1/packages",
+		Status:    getStringFromMap(result, "status"),
+		Message:   "Package published successfully",
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		publishResult.Message = "Package uploaded and awaiting registry validation"
+	}
+
+	return publishResult, nil
+}
+
+// ApprovePackage approves a package version awaiting a second reviewer
+func (c *HTTPClient) ApprovePackage(ctx context.Context, name, version string) error {
+	path := fmt.Sprintf("/v1/packages/%s/versions/%s/approve", name, version)
+
+	resp, err := c.makeRequestWithContext(ctx, "POST", path, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	message := errorMessage(body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusForbidden:
+		return responseError(resp, body, ErrUnauthorized, message)
+	case http.StatusNotFound:
+		return NewRegistryError(ErrVersionNotFound, fmt.Sprintf("%s@%s", name, version))
+	case http.StatusConflict:
+		return responseError(resp, body, ErrInvalidOperation, message)
+	default:
+		return responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("approve failed (status %d): %s", resp.StatusCode, message))
+	}
+}
+
+// ReservePackage reserves a package name for the current user
+func (c *HTTPClient) ReservePackage(ctx context.Context, name string, days int) (*Reservation, error) {
+	path := fmt.Sprintf("/v1/packages/%s/reservation", name)
+
+	payload, _ := json.Marshal(map[string]int{"days": days})
+	resp, err := c.makeRequestWithContext(ctx, "POST", path, bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var reservation Reservation
+		if err := json.Unmarshal(body, &reservation); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &reservation, nil
+	case http.StatusUnauthorized:
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusForbidden:
+		return nil, responseError(resp, body, ErrUnauthorized, errorMessage(body))
+	case http.StatusBadRequest, http.StatusConflict:
+		return nil, NewRegistryError(ErrInvalidOperation, errorMessage(body))
+	default:
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("reserve failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+}
+
+// GetAuthor gets a user's public profile and the packages they publish
+func (c *HTTPClient) GetAuthor(ctx context.Context, username string) (*Author, error) {
+	path := fmt.Sprintf("/v1/users/%s", url.PathEscape(username))
+
+	resp, err := c.makeRequestWithContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, NewRegistryError(ErrNotFound, fmt.Sprintf("user %s", username))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	var result struct {
+		Author
+		Packages []map[string]interface{} `json:"packages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	author := result.Author
+	for _, m := range result.Packages {
+		pkg := MapToPackage(m)
+		if version, ok := m["version"].(string); ok {
+			pkg.Latest = version
+		}
+		author.Packages = append(author.Packages, *pkg)
+	}
+	return &author, nil
+}
+
+// ReportPackage files an abuse report against a package or one of its versions
+func (c *HTTPClient) ReportPackage(ctx context.Context, name, version, reason, message string) (*Report, error) {
+	path := fmt.Sprintf("/v1/packages/%s/report", name)
+
+	payload, _ := json.Marshal(map[string]string{"reason": reason, "message": message, "version": version})
+	resp, err := c.makeRequestWithContext(ctx, "POST", path, bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var report Report
+		if err := json.Unmarshal(body, &report); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &report, nil
+	case http.StatusUnauthorized:
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusForbidden:
+		return nil, responseError(resp, body, ErrUnauthorized, errorMessage(body))
+	case http.StatusNotFound:
+		if version != "" {
+			return nil, NewRegistryError(ErrVersionNotFound, fmt.Sprintf("%s@%s", name, version))
+		}
+		return nil, NewRegistryError(ErrPackageNotFound, name)
+	case http.StatusBadRequest, http.StatusConflict:
+		return nil, NewRegistryError(ErrInvalidOperation, errorMessage(body))
+	default:
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("report failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+}
+
+// ShareVersion creates a signed download link for a package version
+func (c *HTTPClient) ShareVersion(ctx context.Context, name, version string, expiresIn time.Duration) (*ShareLink, error) {
+	path := fmt.Sprintf("/v1/packages/%s/versions/%s/share", name, version)
+
+	payload, _ := json.Marshal(map[string]int64{"expires_in": int64(expiresIn.Seconds())})
+	resp, err := c.makeRequestWithContext(ctx, "POST", path, bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	message := errorMessage(body)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var link struct {
+			ShareLink
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(body, &link); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		link.ShareLink.URL = c.baseURL + link.Path
+		return &link.ShareLink, nil
+	case http.StatusUnauthorized:
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusForbidden:
+		return nil, responseError(resp, body, ErrUnauthorized, message)
+	case http.StatusNotFound:
+		return nil, NewRegistryError(ErrVersionNotFound, fmt.Sprintf("%s@%s", name, version))
+	case http.StatusBadRequest:
+		return nil, NewRegistryError(ErrInvalidOperation, message)
+	default:
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("share failed (status %d): %s", resp.StatusCode, message))
+	}
+}
+
+// ReleasePackage releases the current user's reservation of a package name
+func (c *HTTPClient) ReleasePackage(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/v1/packages/%s/reservation", name)
+
+	resp, err := c.makeRequestWithContext(ctx, "DELETE", path, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return NewRegistryError(ErrUnauthorized, "authentication required")
+	case http.StatusNotFound:
+		return NewRegistryError(ErrInvalidOperation, errorMessage(body))
+	default:
+		return responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("release failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+}
+
+// GetPackageChecks gets the validation status of a package version
+func (c *HTTPClient) GetPackageChecks(ctx context.Context, name, version string) (*PackageChecks, error) {
+	path := fmt.Sprintf("/v1/packages/%s/versions/%s/checks", name, version)
+
+	resp, err := c.makeRequestWithContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, NewRegistryError(ErrVersionNotFound,
+			fmt.Sprintf("%s@%s", name, version))
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, NewRegistryError(ErrUnauthorized, "authentication required")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("request failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	var checks PackageChecks
+	if err := json.NewDecoder(resp.Body).Decode(&checks); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &checks, nil
 }
 
 // DownloadBlob downloads a blob by SHA256 hash
 func (c *HTTPClient) DownloadBlob(ctx context.Context, sha256, destPath string) error {
+	ctx, span := tracing.Start(ctx, "download")
+	defer span.End()
+
 	path := fmt.Sprintf("/v1/blobs/%s", sha256)
 
-	resp, err := c.makeRequestWithContext(ctx, "GET", path, nil, "")
+	// Registries refuse zstd archives to clients that do not list them
+	accept := map[string]string{"Accept": blobAccept}
+	resp, err := c.makeTransferRequest(ctx, "GET", path, nil, accept)
 	if err != nil {
 		return err
 	}
@@ -217,8 +691,12 @@ func (c *HTTPClient) DownloadBlob(ctx context.Context, sha256, destPath string)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return NewRegistryError(ErrNetworkError,
-			fmt.Sprintf("download failed (status %d): %s", resp.StatusCode, string(body)))
+		return responseError(resp, body, ErrNetworkError,
+			fmt.Sprintf("download failed (status %d): %s", resp.StatusCode, errorMessage(body)))
+	}
+
+	if err := pkg.CheckFreeSpace(filepath.Dir(destPath), resp.ContentLength, "download "+sha256); err != nil {
+		return err
 	}
 
 	// Create destination file
@@ -229,18 +707,43 @@ func (c *HTTPClient) DownloadBlob(ctx context.Context, sha256, destPath string)
 	defer outFile.Close()
 
 	// Copy data
-	_, err = io.Copy(outFile, resp.Body)
+	body := progress.Reader(resp.Body, progress.Event{Op: "download", Phase: "progress", Subject: sha256}, max(resp.ContentLength, 0))
+	_, err = io.Copy(outFile, body)
 	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
 	if c.verbose {
-		fmt.Printf("📥 Downloaded %s\n", destPath)
+		output.Printf("📥 Downloaded %s\n", destPath)
 	}
 
 	return nil
 }
 
+// ArchiveFormats asks the registry which archive formats it accepts. Registries
+// that predate zstd support do not report any and take gzip only.
+func (c *HTTPClient) ArchiveFormats(ctx context.Context) ([]string, error) {
+	resp, err := c.makeRequestWithContext(ctx, "GET", "/v1/health", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewRegistryError(ErrNetworkError,
+			fmt.Sprintf("registry health check failed (status %d)", resp.StatusCode))
+	}
+
+	var health struct {
+		ArchiveFormats []string `json:"archive_formats"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || len(health.ArchiveFormats) == 0 {
+		return []string{compression.Gzip}, nil
+	}
+
+	return health.ArchiveFormats, nil
+}
+
 // Health checks if the registry is healthy
 func (c *HTTPClient) Health(ctx context.Context) error {
 	resp, err := c.makeRequestWithContext(ctx, "GET", "/v1/health", nil, "")
@@ -259,10 +762,50 @@ func (c *HTTPClient) Health(ctx context.Context) error {
 
 // makeRequestWithContext makes an HTTP request with authentication and context
 func (c *HTTPClient) makeRequestWithContext(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	return c.makeRequestWithHeaders(ctx, method, path, body, headers)
+}
+
+// makeRequestWithHeaders makes an API request with authentication, context and
+// extra request headers. The response must be read within the request timeout.
+func (c *HTTPClient) makeRequestWithHeaders(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, c.requestTimeout,
+		fmt.Errorf("%w: no response within %s", ErrTimedOut, c.requestTimeout))
+	resp, err := c.doRequest(ctx, method, path, body, headers)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// makeTransferRequest makes a request that uploads or downloads an archive. It
+// has no overall deadline, so large archives are not cut off, but fails once
+// neither the request nor the response body has moved for the stall timeout.
+func (c *HTTPClient) makeTransferRequest(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	t := newTransfer(ctx, c.stallTimeout)
+	if body != nil {
+		body = t.reader(body)
+	}
+	resp, err := c.doRequest(t.ctx, method, path, body, headers)
+	if err != nil {
+		t.stop()
+		return nil, t.err(err)
+	}
+	resp.Body = t.body(resp.Body)
+	return resp, nil
+}
+
+// doRequest sends a request with authentication and extra request headers
+func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
 	url := c.baseURL + path
 
 	if c.verbose {
-		fmt.Printf("🌐 %s %s\n", method, url)
+		output.Printf("🌐 %s %s\n", method, url)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
@@ -279,19 +822,21 @@ func (c *HTTPClient) makeRequestWithContext(ctx context.Context, method, path st
 			if len(tokenPreview) > 20 {
 				tokenPreview = tokenPreview[:20] + "..."
 			}
-			fmt.Printf("🔍 Setting Authorization header: Bearer %s\n", tokenPreview)
+			output.Printf("🔍 Setting Authorization header: Bearer %s\n", tokenPreview)
 		}
 	} else if c.verbose {
-		fmt.Printf("⚠️  No token available - sending request without Authorization header\n")
+		output.Printf("⚠️  No token available - sending request without Authorization header\n")
 	}
 
-	// Set content type if provided
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, ErrTimedOut) {
+			return nil, cause
+		}
 		if ctx.Err() != nil {
 			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
 		}
@@ -299,7 +844,7 @@ func (c *HTTPClient) makeRequestWithContext(ctx context.Context, method, path st
 	}
 
 	if c.verbose {
-		fmt.Printf("🔍 HTTP Response: %d %s\n", resp.StatusCode, resp.Status)
+		output.Printf("🔍 HTTP Response: %d %s\n", resp.StatusCode, resp.Status)
 		if resp.StatusCode >= 400 {
 			// Log response headers for debugging auth issues
 			authHeader := resp.Request.Header.Get("Authorization")
@@ -308,9 +853,9 @@ func (c *HTTPClient) makeRequestWithContext(ctx context.Context, method, path st
 				if len(tokenPart) > 20 {
 					tokenPart = tokenPart[:20] + "..."
 				}
-				fmt.Printf("🔍 Request had Authorization: Bearer %s\n", tokenPart)
+				output.Printf("🔍 Request had Authorization: Bearer %s\n", tokenPart)
 			} else {
-				fmt.Printf("⚠️  Request had no Authorization header\n")
+				output.Printf("⚠️  Request had no Authorization header\n")
 			}
 		}
 	}
@@ -331,10 +876,26 @@ func (c *HTTPClient) addFileToForm(writer *multipart.Writer, fieldName, filePath
 		return err
 	}
 
-	_, err = io.Copy(part, file)
+	var size int64
+	if stat, err := file.Stat(); err == nil {
+		size = stat.Size()
+	}
+	event := progress.Event{Op: "upload", Phase: "progress", Subject: filepath.Base(filePath)}
+
+	_, err = io.Copy(part, progress.Reader(file, event, size))
 	return err
 }
 
+// errorMessage extracts the message from a {"error": "..."} response body,
+// falling back to the raw body
+func errorMessage(body []byte) string {
+	var errorBody map[string]interface{}
+	if json.Unmarshal(body, &errorBody) == nil && getStringFromMap(errorBody, "error") != "" {
+		return getStringFromMap(errorBody, "error")
+	}
+	return strings.TrimSpace(string(body))
+}
+
 // getStringFromMap safely gets a string value from a map
 func getStringFromMap(m map[string]interface{}, key string) string {
 	if val, ok := m[key]; ok {
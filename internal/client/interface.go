@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"time"
+
 	"rulestack/internal/config"
 )
 
@@ -16,15 +18,56 @@ type RegistryClient interface {
 	// Get information about a specific package version
 	GetPackageVersion(ctx context.Context, name, version string) (*PackageVersion, error)
 
+	// Get a package version's manifest and the first lines of its rule files; an
+	// empty version previews the latest
+	GetPackagePreview(ctx context.Context, name, version string) (*PackagePreview, error)
+
+	// Get metadata for many package versions in one round trip
+	GetPackageVersions(ctx context.Context, refs []VersionRef) ([]VersionMetadata, error)
+
+	// Get the packages changed since a previous sync cursor, or all packages for an empty cursor
+	GetIndex(ctx context.Context, since string) (*IndexDelta, error)
+
+	// Deprecate a package version, or every version when version is empty, with a
+	// message and optionally a replacement ("name@range"); empty values clear it
+	DeprecatePackage(ctx context.Context, name, version, message, replacement string) error
+
 	// Publish a package to the registry
 	PublishPackage(ctx context.Context, manifestPath, archivePath string) (*PublishResult, error)
 
+	// Get the registry-side validation status of a published package version
+	GetPackageChecks(ctx context.Context, name, version string) (*PackageChecks, error)
+
+	// Approve a package version awaiting a second reviewer
+	ApprovePackage(ctx context.Context, name, version string) error
+
+	// Reserve a package name ahead of its first publish
+	ReservePackage(ctx context.Context, name string, days int) (*Reservation, error)
+
+	// Release a package name reservation
+	ReleasePackage(ctx context.Context, name string) error
+
+	// Get a user's public profile and the packages they publish
+	GetAuthor(ctx context.Context, username string) (*Author, error)
+
+	// Report a package, or one version of it, to the registry's moderators
+	ReportPackage(ctx context.Context, name, version, reason, message string) (*Report, error)
+
+	// Create a link that downloads a package version without an account until it expires
+	ShareVersion(ctx context.Context, name, version string, expiresIn time.Duration) (*ShareLink, error)
+
 	// Download a package archive by hash
 	DownloadBlob(ctx context.Context, sha256, destPath string) error
 
+	// Get the archive formats the registry accepts for publishing
+	ArchiveFormats(ctx context.Context) ([]string, error)
+
 	// Check if registry is accessible
 	Health(ctx context.Context) error
 
+	// Time a round trip to the registry and check the stored credential
+	Ping(ctx context.Context) (*PingReport, error)
+
 	// Get registry type identifier
 	Type() config.RegistryType
 }
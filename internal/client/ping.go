@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Credential check outcomes of a ping
+const (
+	AuthAccepted = "accepted"
+	AuthRejected = "rejected"
+	AuthNone     = "none"    // No credential is configured, or it is not sent to this URL
+	AuthUnknown  = "unknown" // The check itself failed
+)
+
+// PingPhase is one timed step of a ping
+type PingPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// PingReport is where the time of a request to a registry goes and whether the
+// stored credential is accepted
+type PingReport struct {
+	Phases     []PingPhase
+	Total      time.Duration
+	Auth       string // AuthAccepted, AuthRejected, AuthNone or AuthUnknown
+	AuthDetail string // Who the credential belongs to, or why it was rejected
+}
+
+// Ping times a health check against the registry, split into DNS lookup, TCP
+// connect, TLS handshake and time to first byte, then checks the token against
+// the user profile endpoint
+func (c *HTTPClient) Ping(ctx context.Context) (*PingReport, error) {
+	var dnsStart, connectStart, tlsStart, requestStart time.Time
+	report := &PingReport{}
+	add := func(name string, start time.Time) {
+		if !start.IsZero() {
+			report.Phases = append(report.Phases, PingPhase{Name: name, Duration: time.Since(start)})
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { add("dns", dnsStart) },
+		ConnectStart:         func(string, string) { connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { add("tcp", connectStart) },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { add("tls", tlsStart) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { requestStart = time.Now() },
+		GotFirstResponseByte: func() { add("first byte", requestStart) },
+	}
+
+	// A reused connection would skip the DNS, TCP and TLS steps
+	c.httpClient.CloseIdleConnections()
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", c.baseURL+"/v1/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return report, NewRegistryError(ErrConnectionFailed, fmt.Sprintf("registry unreachable: %v", err))
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	report.Total = time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		return report, NewRegistryError(ErrNetworkError, fmt.Sprintf("registry health check failed (status %d)", resp.StatusCode))
+	}
+
+	report.Auth, report.AuthDetail = c.checkToken(ctx)
+	return report, nil
+}
+
+// checkToken asks the registry who the token belongs to
+func (c *HTTPClient) checkToken(ctx context.Context) (string, string) {
+	if c.token == "" {
+		return AuthNone, ""
+	}
+
+	resp, err := c.makeRequestWithContext(ctx, "GET", "/v1/auth/profile", nil, "")
+	if err != nil {
+		return AuthUnknown, err.Error()
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var profile UserProfile
+		if err := json.NewDecoder(resp.Body).Decode(&profile); err == nil && profile.Username != "" {
+			return AuthAccepted, fmt.Sprintf("%s (%s)", profile.Username, profile.Role)
+		}
+		return AuthAccepted, ""
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return AuthRejected, "token expired or revoked; run 'rfh auth login'"
+	}
+	return AuthUnknown, fmt.Sprintf("unexpected status %d", resp.StatusCode)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClientPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/health":
+			w.Write([]byte(`{"status": "ok"}`))
+		case "/v1/auth/profile":
+			if r.Header.Get("Authorization") != "Bearer good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(UserProfile{Username: "alice", Role: "publisher"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		wantAuth string
+	}{
+		{"accepted", "good", AuthAccepted},
+		{"rejected", "expired", AuthRejected},
+		{"no token", "", AuthNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewHTTPClient(server.URL, tt.token, false).Ping(context.Background())
+			if err != nil {
+				t.Fatalf("Ping failed: %v", err)
+			}
+			if report.Auth != tt.wantAuth {
+				t.Errorf("Auth = %q, want %q (%s)", report.Auth, tt.wantAuth, report.AuthDetail)
+			}
+
+			phases := make(map[string]bool)
+			for _, phase := range report.Phases {
+				phases[phase.Name] = true
+			}
+			if !phases["tcp"] || !phases["first byte"] || report.Total <= 0 {
+				t.Errorf("expected tcp and first byte timings and a total, got %+v", report)
+			}
+		})
+	}
+
+	if _, err := NewHTTPClient("http://127.0.0.1:1", "", false).Ping(context.Background()); err == nil {
+		t.Error("expected an unreachable registry to fail")
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"rulestack/internal/config"
+	"rulestack/internal/output"
+	"rulestack/internal/tracing"
+)
+
+// KeyPin pins the public key of an HTTP registry's TLS certificate, trusting the
+// key seen on first use
+type KeyPin struct {
+	Pinned string // Pin recorded on first use; empty until then
+	Strict bool   // Refuse connections presenting a different key instead of warning
+	// Record saves the pin seen on first use
+	Record func(pin string) error
+
+	recordOnce sync.Once
+	warnOnce   sync.Once
+}
+
+// PublicKeyPin returns the pin of a certificate: the SHA-256 of its public key,
+// so the pin survives certificate renewals that keep the key
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// check verifies the key presented by a TLS connection against the pin. It runs
+// after normal certificate verification, which still applies.
+func (p *KeyPin) check(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	seen := PublicKeyPin(cs.PeerCertificates[0])
+
+	if p.Pinned == "" {
+		p.recordOnce.Do(func() {
+			if p.Record == nil {
+				return
+			}
+			if err := p.Record(seen); err != nil {
+				output.Fprintf(os.Stderr, "⚠️  Failed to pin TLS key for %s: %v\n", cs.ServerName, err)
+				return
+			}
+			output.Fprintf(os.Stderr, "📌 Pinned TLS key for %s: %s\n", cs.ServerName, seen)
+		})
+		return nil
+	}
+
+	if seen == p.Pinned {
+		return nil
+	}
+
+	if p.Strict {
+		return NewRegistryError(ErrKeyPinMismatch,
+			fmt.Sprintf("TLS key for %s is %s, but %s is pinned", cs.ServerName, seen, p.Pinned))
+	}
+
+	p.warnOnce.Do(func() {
+		output.Fprintf(os.Stderr, "\n⚠️  WARNING: THE TLS KEY OF %s HAS CHANGED\n", cs.ServerName)
+		output.Fprintf(os.Stderr, "   Pinned:   %s\n", p.Pinned)
+		output.Fprintf(os.Stderr, "   Received: %s\n", seen)
+		output.Fprintf(os.Stderr, "   Someone may be intercepting your connection to the registry.\n")
+		output.Fprintf(os.Stderr, "   If the registry rotated its key, run 'rfh registry pin <name> --reset'.\n\n")
+	})
+	return nil
+}
+
+// registryKeyPin returns the pin a registry's TLS connections are checked
+// against, or nil when it is not served over HTTPS
+func registryKeyPin(registry config.Registry) *KeyPin {
+	if !strings.HasPrefix(registry.URL, "https://") {
+		return nil
+	}
+	return &KeyPin{
+		Pinned: registry.PinnedKey,
+		Strict: registry.StrictPinning,
+		Record: func(pin string) error { return recordKeyPin(registry.URL, pin) },
+	}
+}
+
+// pinnedTransport returns a transport that checks every TLS connection against the pin
+func pinnedTransport(pin *KeyPin) *http.Transport {
+	transport := newTransport()
+	transport.TLSClientConfig = &tls.Config{VerifyConnection: pin.check}
+	return transport
+}
+
+// SetKeyPin makes the client check every TLS connection against the pin
+func (c *HTTPClient) SetKeyPin(pin *KeyPin) {
+	c.httpClient.Transport = tracing.NewTransport(pinnedTransport(pin))
+}
+
+// SetKeyPin makes the client check every TLS connection against the pin, so
+// credentials are never sent to a server presenting another key
+func (c *AuthClient) SetKeyPin(pin *KeyPin) {
+	c.Client.Transport = pinnedTransport(pin)
+}
+
+// recordKeyPin saves a pin seen on first use to every configured registry at url
+// that has none yet
+func recordKeyPin(url, pin string) error {
+	cfg, err := config.LoadCLI()
+	if err != nil {
+		return err
+	}
+
+	for name, registry := range cfg.Registries {
+		if registry.URL == url && registry.PinnedKey == "" {
+			registry.PinnedKey = pin
+			cfg.Registries[name] = registry
+		}
+	}
+
+	return config.SaveCLI(cfg)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rulestack/internal/config"
+)
+
+func TestKeyPin(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	serverPin := PublicKeyPin(server.Certificate())
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{server.Certificate()}, ServerName: "registry.example.com"}
+
+	t.Run("first use records the key", func(t *testing.T) {
+		recorded := ""
+		pin := &KeyPin{Record: func(p string) error { recorded = p; return nil }}
+		if err := pin.check(state); err != nil || recorded != serverPin {
+			t.Errorf("expected %s to be recorded, got %q (%v)", serverPin, recorded, err)
+		}
+	})
+
+	t.Run("matching key", func(t *testing.T) {
+		pin := &KeyPin{Pinned: serverPin, Strict: true}
+		if err := pin.check(state); err != nil {
+			t.Errorf("expected the pinned key to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("changed key warns", func(t *testing.T) {
+		pin := &KeyPin{Pinned: "sha256/other"}
+		if err := pin.check(state); err != nil {
+			t.Errorf("expected a changed key to only warn, got %v", err)
+		}
+	})
+
+	t.Run("changed key in strict mode", func(t *testing.T) {
+		pin := &KeyPin{Pinned: "sha256/other", Strict: true}
+		if err := pin.check(state); !errors.Is(err, ErrKeyPinMismatch) {
+			t.Errorf("expected a changed key to be refused, got %v", err)
+		}
+	})
+
+	t.Run("strict mode refuses the connection", func(t *testing.T) {
+		// The test server's certificate is only trusted by its own client's transport
+		c := NewHTTPClient(server.URL, "", false)
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.VerifyConnection = (&KeyPin{Pinned: "sha256/other", Strict: true}).check
+		c.httpClient.Transport = transport
+
+		if err := c.Health(context.Background()); !errors.Is(err, ErrKeyPinMismatch) {
+			t.Errorf("expected the request to fail on the pinned key, got %v", err)
+		}
+	})
+}
+
+func TestRecordKeyPin(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("RFH_CONFIG", configDir)
+	configContent := "current = \"corp\"\n\n[registries.corp]\nurl = \"https://registry.example.com\"\ntype = \"remote-http\"\n\n" +
+		"[registries.other]\nurl = \"https://other.example.com\"\ntype = \"remote-http\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	if err := recordKeyPin("https://registry.example.com", "sha256/abc"); err != nil {
+		t.Fatalf("recordKeyPin failed: %v", err)
+	}
+
+	cfg, err := config.LoadCLI()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Registries["corp"].PinnedKey != "sha256/abc" || cfg.Registries["other"].PinnedKey != "" {
+		t.Errorf("expected only the matching registry to be pinned, got %+v", cfg.Registries)
+	}
+}
+
+func TestAuthClientKeyPin(t *testing.T) {
+	loggedIn := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loggedIn = true
+		w.Write([]byte(`{"token": "secret"}`))
+	}))
+	defer server.Close()
+
+	// The test server's certificate is only trusted through its own pool
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	newClient := func(pinned string) *AuthClient {
+		c := NewRegistryAuthClient(config.Registry{URL: server.URL, PinnedKey: pinned, StrictPinning: true})
+		c.Client.Transport.(*http.Transport).TLSClientConfig.RootCAs = pool
+		return c
+	}
+
+	_, err := newClient("sha256/other").Login(LoginRequest{Username: "alice", Password: "secret123"})
+	if !errors.Is(err, ErrKeyPinMismatch) {
+		t.Errorf("expected the login to fail on the pinned key, got %v", err)
+	}
+	if loggedIn {
+		t.Error("expected the credentials never to reach a server with another key")
+	}
+
+	if _, err := newClient(PublicKeyPin(server.Certificate())).Login(LoginRequest{Username: "alice", Password: "secret123"}); err != nil || !loggedIn {
+		t.Errorf("expected a login to the pinned server to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	rfhmanifest "rulestack/internal/manifest"
+	"rulestack/internal/pkg"
+)
+
+// DefaultPreviewLines is how many lines of each rule file a preview shows
+const DefaultPreviewLines = 8
+
+// maxPreviewLineLength truncates long lines, e.g. minified content, in previews
+const maxPreviewLineLength = 200
+
+// PackagePreview is a package version's manifest details and the opening lines of
+// its rule files, for judging a package without installing it
+type PackagePreview struct {
+	Name        string        `json:"name"`
+	Version     string        `json:"version"`
+	Description string        `json:"description,omitempty"`
+	License     string        `json:"license,omitempty"`
+	Targets     []string      `json:"targets,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Files       []FilePreview `json:"files"`
+}
+
+// FilePreview is the first lines of one rule file
+type FilePreview struct {
+	Path      string `json:"path"`
+	Excerpt   string `json:"excerpt"`
+	Truncated bool   `json:"truncated,omitempty"` // The file continues past the excerpt
+}
+
+// BuildPackagePreview reads the embedded manifest and the first lines of every
+// Markdown rule file in a package archive
+func BuildPackagePreview(archivePath string, lines int) (*PackagePreview, error) {
+	preview := &PackagePreview{Files: []FilePreview{}}
+
+	if data, err := pkg.ExtractManifest(archivePath); err == nil {
+		var manifest rfhmanifest.PackageManifest
+		if err := json.Unmarshal(data, &manifest); err == nil {
+			preview.Name = manifest.Name
+			preview.Version = manifest.Version
+			preview.Description = manifest.Description
+			preview.License = manifest.License
+			preview.Targets = manifest.Targets
+			preview.Tags = manifest.Tags
+		}
+	}
+
+	err := pkg.WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		ext := strings.ToLower(path.Ext(header.Name))
+		if ext != ".md" && ext != ".mdc" {
+			return nil
+		}
+
+		excerpt, truncated, err := readExcerpt(content, lines)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		preview.Files = append(preview.Files, FilePreview{
+			Path:      path.Clean(header.Name),
+			Excerpt:   excerpt,
+			Truncated: truncated,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// readExcerpt returns the first lines of r and whether anything follows them
+func readExcerpt(r io.Reader, lines int) (string, bool, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var kept []string
+	for scanner.Scan() {
+		if len(kept) == lines {
+			return strings.Join(kept, "\n"), true, nil
+		}
+		line := scanner.Text()
+		if len(line) > maxPreviewLineLength {
+			line = line[:maxPreviewLineLength] + "…"
+		}
+		kept = append(kept, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+
+	return strings.Join(kept, "\n"), false, nil
+}
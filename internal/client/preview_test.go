@@ -0,0 +1,61 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rulestack/internal/pkg"
+)
+
+func TestBuildPackagePreview(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := map[string]string{
+		"rulestack.json":    `{"name": "security-rules", "version": "1.2.0", "description": "Secure coding", "targets": ["cursor"]}`,
+		"rules/secrets.mdc": "# Secrets\nNever commit secrets.\nUse a vault.\nRotate keys.",
+		"rules/short.md":    "# Short\nOne line.",
+		"tests/case.json":   `{}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	info, err := pkg.PackFromDirectory(sourceDir, filepath.Join(t.TempDir(), "archive.tgz"))
+	if err != nil {
+		t.Fatalf("PackFromDirectory failed: %v", err)
+	}
+
+	preview, err := BuildPackagePreview(info.Path, 2)
+	if err != nil {
+		t.Fatalf("BuildPackagePreview failed: %v", err)
+	}
+
+	if preview.Name != "security-rules" || preview.Version != "1.2.0" || preview.Description != "Secure coding" {
+		t.Errorf("expected manifest details in preview, got %+v", preview)
+	}
+	if len(preview.Files) != 2 {
+		t.Fatalf("expected only the 2 rule files, got %+v", preview.Files)
+	}
+
+	byPath := make(map[string]FilePreview)
+	for _, file := range preview.Files {
+		byPath[file.Path] = file
+	}
+	if secrets := byPath["rules/secrets.mdc"]; secrets.Excerpt != "# Secrets\nNever commit secrets." || !secrets.Truncated {
+		t.Errorf("expected secrets.mdc cut to 2 lines, got %+v", secrets)
+	}
+	if short := byPath["rules/short.md"]; short.Excerpt != "# Short\nOne line." || short.Truncated {
+		t.Errorf("expected short.md in full, got %+v", short)
+	}
+
+	long, truncated, err := readExcerpt(strings.NewReader(strings.Repeat("x", 500)), 8)
+	if err != nil || truncated || len([]rune(long)) != maxPreviewLineLength+1 {
+		t.Errorf("expected a long line cut to %d characters, got %d", maxPreviewLineLength, len([]rune(long)))
+	}
+}
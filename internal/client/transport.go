@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Timeouts of HTTP registry requests. Connecting and waiting for a response are
+// bounded on their own, so an unreachable registry fails fast; API calls have
+// an overall deadline, while archive transfers only fail once they stall.
+const (
+	dialTimeout           = 10 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	requestTimeout        = 30 * time.Second // Deadline of API calls, body included
+	transferStallTimeout  = 60 * time.Second // How long a transfer may go without moving any bytes
+)
+
+// newTransport returns a transport that keeps connections to the registry open
+// for reuse and negotiates HTTP/2 over TLS
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// cancelOnClose releases a request's deadline once its response body is closed.
+// What callers left unread, such as the newline after a JSON body, is drained
+// first so the connection can be reused.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	io.CopyN(io.Discard, b.ReadCloser, 64<<10)
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// transfer bounds a request that moves an archive by its progress rather than
+// a deadline: it is canceled once no bytes move either way for the timeout
+type transfer struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func newTransfer(ctx context.Context, timeout time.Duration) *transfer {
+	t := &transfer{timeout: timeout}
+	t.ctx, t.cancel = context.WithCancelCause(ctx)
+	t.timer = time.AfterFunc(timeout, func() {
+		t.cancel(fmt.Errorf("%w: transfer stalled for %s", ErrTimedOut, timeout))
+	})
+	return t
+}
+
+// err reports a stall as the cause of a failure it led to
+func (t *transfer) err(err error) error {
+	if cause := context.Cause(t.ctx); errors.Is(cause, ErrTimedOut) {
+		return cause
+	}
+	return err
+}
+
+func (t *transfer) stop() {
+	t.timer.Stop()
+	t.cancel(nil)
+}
+
+// reader resets the stall timer whenever r yields data. It has no Close, so
+// sending a request body does not end the transfer.
+func (t *transfer) reader(r io.Reader) io.Reader {
+	return &transferReader{Reader: r, transfer: t}
+}
+
+// body resets the stall timer as a response body is read and ends the transfer
+// when it is closed
+func (t *transfer) body(rc io.ReadCloser) io.ReadCloser {
+	return &transferBody{transferReader: transferReader{Reader: rc, transfer: t}, closer: rc}
+}
+
+type transferReader struct {
+	io.Reader
+	transfer *transfer
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.transfer.timer.Reset(r.transfer.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = r.transfer.err(err)
+	}
+	return n, err
+}
+
+type transferBody struct {
+	transferReader
+	closer io.Closer
+}
+
+func (b *transferBody) Close() error {
+	err := b.closer.Close()
+	b.transfer.stop()
+	return err
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientTimeouts(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages":
+			// Slower than the request timeout
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("[]"))
+		case "/v1/blobs/slow":
+			// Takes longer than the request timeout in all, but keeps sending
+			for i := 0; i < 6; i++ {
+				w.Write([]byte("chunk"))
+				w.(http.Flusher).Flush()
+				time.Sleep(40 * time.Millisecond)
+			}
+		case "/v1/blobs/stalled":
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+		default:
+			w.Write([]byte(`{"status":"ok"}`))
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	c := NewHTTPClient(server.URL, "", false)
+	c.requestTimeout = 100 * time.Millisecond
+	c.stallTimeout = 100 * time.Millisecond
+	ctx := context.Background()
+
+	t.Run("reuses connections", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			if err := c.Health(ctx); err != nil {
+				t.Fatalf("Health failed: %v", err)
+			}
+		}
+		if n := connections.Load(); n != 1 {
+			t.Errorf("expected one connection for sequential requests, got %d", n)
+		}
+	})
+
+	t.Run("API calls have a deadline", func(t *testing.T) {
+		_, err := c.SearchPackages(ctx, SearchOptions{})
+		if !errors.Is(err, ErrTimedOut) || !errors.Is(err, ErrNetworkError) {
+			t.Errorf("expected a timeout, got %v", err)
+		}
+	})
+
+	t.Run("downloads that make progress outlast the deadline", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "slow.tgz")
+		if err := c.DownloadBlob(ctx, "slow", dest); err != nil {
+			t.Fatalf("DownloadBlob failed: %v", err)
+		}
+		if data, _ := os.ReadFile(dest); string(data) != strings.Repeat("chunk", 6) {
+			t.Errorf("unexpected download %q", data)
+		}
+	})
+
+	t.Run("stalled downloads fail", func(t *testing.T) {
+		err := c.DownloadBlob(ctx, "stalled", filepath.Join(t.TempDir(), "stalled.tgz"))
+		if !errors.Is(err, ErrTimedOut) || !strings.Contains(err.Error(), "stalled") {
+			t.Errorf("expected a stalled transfer, got %v", err)
+		}
+	})
+}
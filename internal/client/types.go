@@ -4,20 +4,25 @@ import "time"
 
 // SearchOptions contains parameters for package search
 type SearchOptions struct {
-	Query  string
-	Tag    string
-	Target string
-	Limit  int
+	Query    string
+	Tag      string
+	Target   string
+	Official bool // Only packages registry admins marked official
+	Limit    int
 }
 
 // Package represents a package in the registry
 type Package struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Latest      string    `json:"latest"`
-	Versions    []string  `json:"versions"`
-	Tags        []string  `json:"tags"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Latest       string            `json:"latest"`
+	Versions     []string          `json:"versions"`
+	Tags         []string          `json:"tags"`
+	License      string            `json:"license,omitempty"`      // SPDX license expression of the latest version
+	Dependencies map[string]string `json:"dependencies,omitempty"` // Packages the latest version builds on, by version range
+	Official     bool              `json:"official,omitempty"`     // Registry admins marked the package official
+	VerifiedOrg  string            `json:"verified_org,omitempty"` // Organization registry admins verified as the publisher
+	UpdatedAt    time.Time         `json:"updated_at"`
 }
 
 // PackageVersion represents a specific version of a package
@@ -27,17 +32,133 @@ type PackageVersion struct {
 	Description  string                 `json:"description"`
 	Dependencies map[string]string      `json:"dependencies"`
 	SHA256       string                 `json:"sha256"`
+	Integrity    string                 `json:"integrity,omitempty"` // Digests as sha512-...; registries that predate them only give the sha256
 	Size         int64                  `json:"size"`
 	PublishedAt  time.Time              `json:"published_at"`
 	Metadata     map[string]interface{} `json:"metadata"`
+	Deprecated   string                 `json:"deprecated,omitempty"`  // Deprecation message, empty if current
+	Replacement  string                 `json:"replacement,omitempty"` // What to use instead when deprecated, e.g. other-rules@^2
+	License      string                 `json:"license,omitempty"`     // SPDX license expression
 }
 
 // PublishResult contains information about a published package
 type PublishResult struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	SHA256    string `json:"sha256"`
+	Integrity string `json:"integrity,omitempty"`
+	URL       string `json:"url,omitempty"`    // For HTTP registries
+	PRUrl     string `json:"pr_url,omitempty"` // For Git registries
+	Status    string `json:"status,omitempty"` // "pending" while registry-side validation runs
+	Message   string `json:"message"`
+}
+
+// CheckResult is the outcome of one registry-side validation check
+type CheckResult struct {
 	Name    string `json:"name"`
-	Version string `json:"version"`
-	SHA256  string `json:"sha256"`
-	URL     string `json:"url,omitempty"`    // For HTTP registries
-	PRUrl   string `json:"pr_url,omitempty"` // For Git registries
-	Message string `json:"message"`
+	Status  string `json:"status"` // pending, running, passed or failed
+	Message string `json:"message,omitempty"`
+}
+
+// PackageChecks is the validation status of an uploaded package version
+type PackageChecks struct {
+	Name    string        `json:"name"`
+	Version string        `json:"version"`
+	Status  string        `json:"status"` // pending, published or rejected
+	Checks  []CheckResult `json:"checks"`
+}
+
+// PublishReview is the review state of a version published to a Git registry
+// through a pull request
+type PublishReview struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Branch   string `json:"branch"`
+	State    string `json:"state"`               // open, merged or closed
+	PRNumber int    `json:"pr_number,omitempty"` // 0 when the provider's API could not be asked
+	PRUrl    string `json:"pr_url,omitempty"`
+}
+
+// Reservation holds a package name for a user until its first publish
+type Reservation struct {
+	Name       string    `json:"name"`
+	ReservedBy string    `json:"reserved_by"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Author is a registry user's public profile with the packages they publish
+type Author struct {
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	MemberSince time.Time `json:"member_since"`
+	Packages    []Package `json:"-"` // Latest version of each, newest package first
+}
+
+// Report is an abuse report queued for the registry's moderators
+type Report struct {
+	ID        int       `json:"id"`
+	Package   string    `json:"package"`
+	Version   string    `json:"version,omitempty"`
+	Reason    string    `json:"reason"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShareLink is a time-limited download link for a package version
+type ShareLink struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VersionRef names one package version
+type VersionRef struct {
+	Name    string
+	Version string
+}
+
+// VersionMetadata is the registry's summary of one package version from a bulk lookup
+type VersionMetadata struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Found       bool   `json:"found"`
+	SHA256      string `json:"sha256,omitempty"`
+	Integrity   string `json:"integrity,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Deprecated  string `json:"deprecated,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	Latest      string `json:"latest,omitempty"` // Newest published version of the package, if known
+}
+
+// IndexVersion is one published version in an index entry
+type IndexVersion struct {
+	Version     string    `json:"version"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Integrity   string    `json:"integrity,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	Deprecated  string    `json:"deprecated,omitempty"`
+	Replacement string    `json:"replacement,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// IndexPackage is the complete index entry of a package. An entry without versions
+// means the package no longer has anything installable.
+type IndexPackage struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Latest      string         `json:"latest,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Targets     []string       `json:"targets,omitempty"`
+	Versions    []IndexVersion `json:"versions"`
+}
+
+// IndexDelta is the result of an index sync
+type IndexDelta struct {
+	Cursor   string         `json:"cursor"` // Pass back as since on the next sync
+	Full     bool           `json:"full"`   // Packages is the whole registry, not just changes
+	Packages []IndexPackage `json:"packages"`
 }
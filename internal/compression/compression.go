@@ -0,0 +1,138 @@
+// Package compression reads and writes the compressed streams package archives
+// are stored in: gzip (the default) and zstd.
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archive compression formats
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Formats lists every supported format, default first
+var Formats = []string{Gzip, Zstd}
+
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Validate checks a format and level. Level 0 uses the format's default; gzip
+// takes levels 1-9 and zstd 1-22.
+func Validate(format string, level int) error {
+	maxLevel := 0
+	switch format {
+	case Gzip, "":
+		maxLevel = gzip.BestCompression
+	case Zstd:
+		maxLevel = 22
+	default:
+		return fmt.Errorf("%w: %s (use %s or %s)", ErrUnknownFormat, format, Gzip, Zstd)
+	}
+
+	if level < 0 || level > maxLevel {
+		return fmt.Errorf("compression level %d is out of range for %s (1-%d)", level, formatName(format), maxLevel)
+	}
+	return nil
+}
+
+// NewWriter compresses everything written to w in the given format and level
+func NewWriter(w io.Writer, format string, level int) (io.WriteCloser, error) {
+	if err := Validate(format, level); err != nil {
+		return nil, err
+	}
+
+	if format == Zstd {
+		encoderLevel := zstd.SpeedDefault
+		if level > 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(encoderLevel))
+	}
+
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// NewReader decompresses r, detecting its format from the leading magic bytes
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	format, err := detect(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == Zstd {
+		decoder, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	}
+
+	gzReader, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	return gzReader, nil
+}
+
+// DetectFile returns the format of a compressed file
+func DetectFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return detect(bufio.NewReader(file))
+}
+
+func detect(r *bufio.Reader) (string, error) {
+	header, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd, nil
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip, nil
+	}
+	return "", fmt.Errorf("%w: not a gzip or zstd stream", ErrUnknownFormat)
+}
+
+// Extension returns the file extension of a tar archive compressed with format
+func Extension(format string) string {
+	if format == Zstd {
+		return ".tar.zst"
+	}
+	return ".tgz"
+}
+
+// ContentType returns the media type of a stream compressed with format
+func ContentType(format string) string {
+	if format == Zstd {
+		return "application/zstd"
+	}
+	return "application/gzip"
+}
+
+func formatName(format string) string {
+	if format == "" {
+		return Gzip
+	}
+	return format
+}
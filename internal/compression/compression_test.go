@@ -0,0 +1,77 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	content := strings.Repeat("rule content\n", 100)
+
+	for _, format := range Formats {
+		for _, level := range []int{0, 1, 9} {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format, level)
+			if err != nil {
+				t.Fatalf("NewWriter(%s, %d) failed: %v", format, level, err)
+			}
+			io.WriteString(writer, content)
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), "archive"+Extension(format))
+			if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatalf("failed to write archive: %v", err)
+			}
+			if detected, err := DetectFile(path); err != nil || detected != format {
+				t.Errorf("expected %s detected, got %q (%v)", format, detected, err)
+			}
+
+			reader, err := NewReader(&buf)
+			if err != nil {
+				t.Fatalf("NewReader failed for %s: %v", format, err)
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil || string(data) != content {
+				t.Errorf("%s level %d did not round-trip: %v", format, level, err)
+			}
+		}
+	}
+}
+
+func TestNewReaderUnknownFormat(t *testing.T) {
+	_, err := NewReader(strings.NewReader("plain text"))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		format    string
+		level     int
+		expectErr bool
+	}{
+		{"", 0, false},
+		{Gzip, 9, false},
+		{Gzip, 10, true},
+		{Zstd, 19, false},
+		{Zstd, 23, true},
+		{Zstd, -1, true},
+		{"brotli", 0, true},
+	}
+
+	for _, tt := range tests {
+		err := Validate(tt.format, tt.level)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("Validate(%q, %d): expected error %v, got %v", tt.format, tt.level, tt.expectErr, err)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -21,11 +23,89 @@ type Registry struct {
 	Username string       `toml:"username,omitempty"`  // Username for this registry
 	JWTToken string       `toml:"jwt_token,omitempty"` // JWT token for this registry
 	GitToken string       `toml:"git_token,omitempty"` // New field for git auth
+
+	RequireApproval bool     `toml:"require_approval,omitempty"` // Git registries: publish PRs need a second reviewer
+	APIURL          string   `toml:"api_url,omitempty"`          // Git registries: REST API base of the hosting provider, derived from the URL when empty
+	TrustedKeys     []string `toml:"trusted_keys,omitempty"`     // Git registries: armored OpenPGP keys commits changing packages must be signed with
+	PinnedKey       string   `toml:"pinned_key,omitempty"`       // HTTPS registries: TLS public key pinned on first use
+	StrictPinning   bool     `toml:"strict_pinning,omitempty"`   // HTTPS registries: fail instead of warning when the pinned key changes
+
+	// URL the stored tokens were issued for. Tokens are only sent to that URL, so
+	// pointing a registry somewhere else does not hand its credentials over.
+	TokenURL string `toml:"token_url,omitempty"`
+
+	Defaults RegistryDefaults `toml:"defaults,omitempty"`
+}
+
+// RegistryDefaults are defaults for commands run against a registry
+type RegistryDefaults struct {
+	Target string `toml:"target,omitempty"` // Target filter for search and browse when --target is not given
 }
 
 type CLIConfig struct {
-	Current    string              `toml:"current"`
-	Registries map[string]Registry `toml:"registries"`
+	Current      string              `toml:"current"`
+	Registries   map[string]Registry `toml:"registries"`
+	TemplatesDir string              `toml:"templates_dir,omitempty"` // Org-specific templates for 'rfh new'
+	Pack         PackConfig          `toml:"pack,omitempty"`
+	Cache        CacheConfig         `toml:"cache,omitempty"`
+	Events       EventsConfig        `toml:"events,omitempty"`
+}
+
+// PackConfig holds how 'rfh pack' builds archives. Zero budgets use the built-in
+// defaults; negative ones turn the check off.
+type PackConfig struct {
+	MaxArchiveSize int64 `toml:"max_archive_size,omitempty"` // Compressed archive size in bytes
+	MaxFiles       int   `toml:"max_files,omitempty"`        // Number of files in the archive
+	MaxFileSize    int64 `toml:"max_file_size,omitempty"`    // Uncompressed size of any single file in bytes
+
+	Compression      string `toml:"compression,omitempty"`       // "gzip" (default) or "zstd"
+	CompressionLevel int    `toml:"compression_level,omitempty"` // 0 uses the format's default
+}
+
+// CacheConfig holds how much disk the local caches may use
+type CacheConfig struct {
+	// MaxSize caps the Git registry clones under ~/.rfh/cache/git in bytes; the
+	// least recently used are evicted beyond it. Zero uses the 1 GiB default;
+	// negative turns eviction off.
+	MaxSize int64 `toml:"max_size,omitempty"`
+}
+
+// EventsConfig holds what runs when a project's packages change
+type EventsConfig struct {
+	// Hook is a command run with each install event as JSON on stdin, after it
+	// is appended to the project's .rulestack/events.log
+	Hook string `toml:"hook,omitempty"`
+}
+
+// DefaultRegistryName is the name the default public registry is added under
+const DefaultRegistryName = "public"
+
+// DefaultRegistryURL is the public registry offered on first run. Builds for
+// other registries set it with -ldflags "-X rulestack/internal/config.DefaultRegistryURL=...".
+var DefaultRegistryURL = "https://registry.rulestack.dev"
+
+// DefaultRegistry returns the URL of the registry to offer on first run:
+// RFH_DEFAULT_REGISTRY if set, otherwise DefaultRegistryURL. It is empty when
+// RFH_DEFAULT_REGISTRY is "none", which turns the offer off.
+func DefaultRegistry() string {
+	if override := strings.TrimSpace(os.Getenv("RFH_DEFAULT_REGISTRY")); override != "" {
+		if strings.EqualFold(override, "none") {
+			return ""
+		}
+		return override
+	}
+	return DefaultRegistryURL
+}
+
+// ConfigExists reports whether the config file has been written, which it is
+// once a registry is added or the first-run registry offer is answered
+func ConfigExists() bool {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(configPath)
+	return err == nil
 }
 
 // ConfigDir returns the CLI config directory path
@@ -122,6 +202,52 @@ func ValidateRegistryType(t RegistryType) error {
 	}
 }
 
+// ValidateAPIURL checks that a Git provider API base is an absolute HTTP(S) URL
+func ValidateAPIURL(apiURL string) error {
+	u, err := url.Parse(apiURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid API URL %q: must be an absolute http(s) URL such as https://github.example.com/api/v3", apiURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid API URL %q: must not have a query or fragment", apiURL)
+	}
+	return nil
+}
+
+// AuthToken returns the token to authenticate to the registry with: the JWT for
+// HTTP registries, the git token for Git registries. It is empty when the token
+// was issued for a different URL than the registry now has.
+func (r Registry) AuthToken() string {
+	token := r.JWTToken
+	if r.GetEffectiveType() == RegistryTypeGit {
+		token = r.GitToken
+	}
+	if token == "" || (r.TokenURL != "" && !SameRegistryURL(r.TokenURL, r.URL)) {
+		return ""
+	}
+	return token
+}
+
+// SameRegistryURL reports whether two registry URLs point at the same place,
+// ignoring case in the scheme and host, default ports and trailing slashes
+func SameRegistryURL(a, b string) bool {
+	return normalizeRegistryURL(a) == normalizeRegistryURL(b)
+}
+
+func normalizeRegistryURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(raw, "/")
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "https" && port == "443") && !(scheme == "http" && port == "80") {
+		host += ":" + port
+	}
+	return scheme + "://" + host + strings.TrimRight(u.EscapedPath(), "/")
+}
+
 // GetEffectiveType returns the effective type for a registry
 func (r Registry) GetEffectiveType() RegistryType {
 	if r.Type == "" {
@@ -269,3 +269,65 @@ func TestSaveCLI(t *testing.T) {
 		}
 	})
 }
+
+func TestRegistryAuthToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry Registry
+		want     string
+	}{
+		{"unscoped token", Registry{URL: "https://rules.example.com", JWTToken: "jwt"}, "jwt"},
+		{"issued for this URL", Registry{URL: "https://rules.example.com", JWTToken: "jwt", TokenURL: "https://rules.example.com/"}, "jwt"},
+		{"host case and default port ignored", Registry{URL: "https://Rules.Example.com:443", JWTToken: "jwt", TokenURL: "https://rules.example.com"}, "jwt"},
+		{"issued for another host", Registry{URL: "https://evil.example.com", JWTToken: "jwt", TokenURL: "https://rules.example.com"}, ""},
+		{"issued over HTTPS, now HTTP", Registry{URL: "http://rules.example.com", JWTToken: "jwt", TokenURL: "https://rules.example.com"}, ""},
+		{"issued for another path", Registry{URL: "https://example.com/team-b", JWTToken: "jwt", TokenURL: "https://example.com/team-a"}, ""},
+		{"git registry uses the git token", Registry{URL: "https://github.com/org/rules", Type: RegistryTypeGit, JWTToken: "jwt", GitToken: "ghp", TokenURL: "https://github.com/org/rules"}, "ghp"},
+		{"git token for another repository", Registry{URL: "https://github.com/other/rules", Type: RegistryTypeGit, GitToken: "ghp", TokenURL: "https://github.com/org/rules"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.registry.AuthToken(); got != tt.want {
+				t.Errorf("AuthToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAPIURL(t *testing.T) {
+	valid := []string{"https://ghe.example.com/api/v3", "http://ghe.internal:8080/api/v3/", "https://api.github.com"}
+	for _, apiURL := range valid {
+		if err := ValidateAPIURL(apiURL); err != nil {
+			t.Errorf("ValidateAPIURL(%q) = %v, want nil", apiURL, err)
+		}
+	}
+
+	invalid := []string{"", "ghe.example.com/api/v3", "ftp://ghe.example.com", "https://", "https://ghe.example.com/api/v3?token=x"}
+	for _, apiURL := range invalid {
+		if err := ValidateAPIURL(apiURL); err == nil {
+			t.Errorf("ValidateAPIURL(%q) = nil, want an error", apiURL)
+		}
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		want     string
+	}{
+		{"built-in URL", "", DefaultRegistryURL},
+		{"overridden", "https://rules.example.com", "https://rules.example.com"},
+		{"turned off", "none", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RFH_DEFAULT_REGISTRY", tt.override)
+			if got := DefaultRegistry(); got != tt.want {
+				t.Errorf("DefaultRegistry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,39 +1,293 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// KnownValidationChecks lists the publish validation checks the registry can run
+var KnownValidationChecks = []string{"security", "lint", "secrets", "webhook"}
+
+// KnownSessionBindings lists what a session can be bound to at login
+var KnownSessionBindings = []string{"network", "device"}
+
 type Config struct {
 	DBURL       string
 	StoragePath string
 	APIPort     string
 	TokenSalt   string
 	JWTSecret   string
+
+	// TokenLifetime is how long the token a login returns is valid. Sessions also
+	// end after SessionIdleTimeout without use, or SessionMaxLifetime after login
+	// (0 disables either).
+	TokenLifetime      time.Duration
+	SessionIdleTimeout time.Duration
+	SessionMaxLifetime time.Duration
+
+	// SessionBinding ties sessions to the network and/or device they logged in
+	// from. A session used elsewhere is flagged, or ended when
+	// SessionAnomalyAction is "revoke", and SessionAlertWebhookURL is notified.
+	SessionBinding         []string
+	SessionAnomalyAction   string
+	SessionAlertWebhookURL string
+
+	// MaxArchiveSize is the largest package archive accepted on publish, in bytes
+	MaxArchiveSize int64
+
+	// RequireRuleTests rejects publishes that do not ship passing rule tests
+	RequireRuleTests bool
+
+	// ValidationChecks run asynchronously on every upload before it becomes visible
+	ValidationChecks     []string
+	ValidationWebhookURL string
+
+	// RequireApproval holds validated versions until a second user approves them
+	RequireApproval bool
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For hops are believed
+	TrustedProxies []netip.Prefix
+
+	// ReservationMaxDays caps how long a package name can be reserved before first publish (0 disables)
+	ReservationMaxDays int
+
+	// CacheSize bounds the in-process cache of search and metadata reads (0 disables it)
+	CacheSize int
+	CacheTTL  time.Duration
+
+	// Retention expires old published versions in the background (0 disables a rule)
+	RetentionKeepVersions   int
+	RetentionPrereleaseDays int
+	RetentionInterval       time.Duration
+
+	// UpstreamURL turns on pull-through mode: versions missing locally are fetched
+	// from this registry and cached as if published here
+	UpstreamURL   string
+	UpstreamToken string
 }
 
+// Load reads the configuration from the environment and the config file named
+// by CONFIG_FILE, exiting on invalid settings
 func Load() Config {
+	values, err := Resolve(nil, getEnv(ConfigFileEnv, ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, err := values.Config()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// Config validates resolved settings and builds the server configuration
+func (v *Values) Config() (Config, error) {
 	cfg := Config{
-		DBURL:       os.Getenv("DATABASE_URL"),
-		StoragePath: getEnv("STORAGE_PATH", "./storage"),
-		APIPort:     getEnv("PORT", "8080"),
-		TokenSalt:   os.Getenv("TOKEN_SALT"),
-		JWTSecret:   os.Getenv("JWT_SECRET"),
+		DBURL:       v.Get("database-url"),
+		StoragePath: v.Get("storage-path"),
+		APIPort:     v.Get("port"),
+		TokenSalt:   v.Get("token-salt"),
+		JWTSecret:   v.Get("jwt-secret"),
+
+		ValidationChecks:     parseChecks(v.Get("validation-checks")),
+		ValidationWebhookURL: v.Get("validation-webhook-url"),
+
+		SessionBinding:         parseChecks(v.Get("session-binding")),
+		SessionAnomalyAction:   v.Get("session-anomaly-action"),
+		SessionAlertWebhookURL: v.Get("session-alert-webhook-url"),
+
+		UpstreamURL:   strings.TrimSuffix(v.Get("upstream-registry-url"), "/"),
+		UpstreamToken: v.Get("upstream-registry-token"),
 	}
 
 	// Validate required fields
-	if cfg.DBURL == "" {
-		log.Fatal("DATABASE_URL environment variable is required")
+	for _, key := range []string{"database-url", "token-salt", "jwt-secret"} {
+		if v.Get(key) == "" {
+			return Config{}, fmt.Errorf("%s is required (set --%s, %s or %s in the config file)", key, key, settingEnv(key), strings.ReplaceAll(key, "-", "_"))
+		}
 	}
-	if cfg.TokenSalt == "" {
-		log.Fatal("TOKEN_SALT environment variable is required")
+	if port, err := strconv.Atoi(cfg.APIPort); err != nil || port < 1 || port > 65535 {
+		return Config{}, fmt.Errorf("%s must be a port number between 1 and 65535", v.describe("port"))
 	}
-	if cfg.JWTSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
+
+	var err error
+	if cfg.RequireRuleTests, err = v.getBool("require-rule-tests"); err != nil {
+		return Config{}, err
+	}
+	if cfg.RequireApproval, err = v.getBool("require-approval"); err != nil {
+		return Config{}, err
 	}
 
-	return cfg
+	if cfg.TokenLifetime, err = v.getDuration("token-lifetime", "720h"); err != nil {
+		return Config{}, err
+	}
+	if cfg.SessionIdleTimeout, err = v.getOptionalDuration("session-idle-timeout", "8h"); err != nil {
+		return Config{}, err
+	}
+	if cfg.SessionMaxLifetime, err = v.getOptionalDuration("session-max-lifetime", "168h"); err != nil {
+		return Config{}, err
+	}
+
+	maxArchiveSize, err := v.getInt("max-archive-size")
+	if err != nil {
+		return Config{}, err
+	}
+	if maxArchiveSize == 0 {
+		return Config{}, fmt.Errorf("%s must be greater than zero", v.describe("max-archive-size"))
+	}
+	cfg.MaxArchiveSize = int64(maxArchiveSize)
+
+	if cfg.ReservationMaxDays, err = v.getInt("reservation-max-days"); err != nil {
+		return Config{}, err
+	}
+	if cfg.CacheSize, err = v.getInt("cache-size"); err != nil {
+		return Config{}, err
+	}
+	if cfg.CacheTTL, err = v.getDuration("cache-ttl", "30s"); err != nil {
+		return Config{}, err
+	}
+	if cfg.RetentionKeepVersions, err = v.getInt("retention-keep-versions"); err != nil {
+		return Config{}, err
+	}
+	if cfg.RetentionPrereleaseDays, err = v.getInt("retention-prerelease-days"); err != nil {
+		return Config{}, err
+	}
+	if cfg.RetentionInterval, err = v.getDuration("retention-interval", "24h"); err != nil {
+		return Config{}, err
+	}
+
+	for _, binding := range cfg.SessionBinding {
+		if !slices.Contains(KnownSessionBindings, binding) {
+			return Config{}, fmt.Errorf("%s: unknown binding %q (known: %s)", v.describe("session-binding"), binding, strings.Join(KnownSessionBindings, ", "))
+		}
+	}
+	if cfg.SessionAnomalyAction != "flag" && cfg.SessionAnomalyAction != "revoke" {
+		return Config{}, fmt.Errorf("%s must be flag or revoke, got %q", v.describe("session-anomaly-action"), cfg.SessionAnomalyAction)
+	}
+	if cfg.SessionAlertWebhookURL != "" {
+		if u, err := url.Parse(cfg.SessionAlertWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("%s must be an http(s) URL", v.describe("session-alert-webhook-url"))
+		}
+	}
+
+	if cfg.UpstreamURL != "" {
+		if u, err := url.Parse(cfg.UpstreamURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("%s must be an http(s) URL", v.describe("upstream-registry-url"))
+		}
+	}
+
+	trustedProxies, err := parseTrustedProxies(v.Get("trusted-proxies"))
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", v.describe("trusted-proxies"), err)
+	}
+	cfg.TrustedProxies = trustedProxies
+
+	// A configured webhook is always consulted
+	if cfg.ValidationWebhookURL != "" && !slices.Contains(cfg.ValidationChecks, "webhook") {
+		cfg.ValidationChecks = append(cfg.ValidationChecks, "webhook")
+	}
+	for _, check := range cfg.ValidationChecks {
+		if !slices.Contains(KnownValidationChecks, check) {
+			return Config{}, fmt.Errorf("%s: unknown check %q (known: %s)", v.describe("validation-checks"), check, strings.Join(KnownValidationChecks, ", "))
+		}
+	}
+	if slices.Contains(cfg.ValidationChecks, "webhook") && cfg.ValidationWebhookURL == "" {
+		return Config{}, fmt.Errorf("validation-webhook-url is required when the webhook check is enabled")
+	}
+
+	return cfg, nil
+}
+
+// parseChecks splits a comma-separated list such as the publish checks or
+// session bindings; "none" turns the feature off
+func parseChecks(value string) []string {
+	var checks []string
+	for _, check := range strings.Split(value, ",") {
+		check = strings.TrimSpace(check)
+		if check != "" && check != "none" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs or single IP addresses
+func parseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy CIDR %q", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// getInt reads a non-negative integer setting
+func (v *Values) getInt(key string) (int, error) {
+	value, err := strconv.Atoi(v.Get(key))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", v.describe(key), v.Get(key))
+	}
+	return value, nil
+}
+
+// getBool reads a true/false setting
+func (v *Values) getBool(key string) (bool, error) {
+	value, err := strconv.ParseBool(v.Get(key))
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", v.describe(key), v.Get(key))
+	}
+	return value, nil
+}
+
+// getDuration reads a positive duration setting
+func (v *Values) getDuration(key, example string) (time.Duration, error) {
+	value, err := time.ParseDuration(v.Get(key))
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as %s, got %q", v.describe(key), example, v.Get(key))
+	}
+	return value, nil
+}
+
+// getOptionalDuration reads a duration setting where 0 turns the feature off
+func (v *Values) getOptionalDuration(key, example string) (time.Duration, error) {
+	value, err := time.ParseDuration(v.Get(key))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a duration such as %s, or 0 to disable, got %q", v.describe(key), example, v.Get(key))
+	}
+	return value, nil
+}
+
+func settingEnv(key string) string {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s.Env
+		}
+	}
+	return ""
 }
 
 func getEnv(key, defaultValue string) string {
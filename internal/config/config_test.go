@@ -2,7 +2,11 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetEnv(t *testing.T) {
@@ -116,6 +120,178 @@ func TestLoadConfig(t *testing.T) {
 	})
 }
 
+func TestResolve(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://rfh:hunter2@db/rulestack")
+	t.Setenv("TOKEN_SALT", "")
+	t.Setenv("CACHE_TTL", "1m")
+	t.Setenv("PORT", "")
+
+	configFile := filepath.Join(t.TempDir(), "api.toml")
+	os.WriteFile(configFile, []byte(`
+token_salt = "file_salt"
+jwt_secret = "file_jwt"
+port = 9000
+cache_ttl = "5m"
+cache_size = 10
+require_approval = true
+validation_checks = ["security", "lint"]
+`), 0644)
+
+	values, err := Resolve(map[string]string{"cache-size": "20"}, configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := values.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags beat the environment, which beats the file, which beats defaults
+	if cfg.CacheSize != 20 || cfg.CacheTTL != time.Minute || cfg.APIPort != "9000" || cfg.StoragePath != "./storage" {
+		t.Errorf("precedence: size %d, ttl %v, port %q, storage %q", cfg.CacheSize, cfg.CacheTTL, cfg.APIPort, cfg.StoragePath)
+	}
+	if cfg.TokenSalt != "file_salt" || !cfg.RequireApproval || !reflect.DeepEqual(cfg.ValidationChecks, []string{"security", "lint"}) {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+
+	var out strings.Builder
+	values.Print(&out)
+	for _, want := range []string{
+		`database_url = "postgres://rfh:xxxxx@db/rulestack"  # DATABASE_URL`,
+		`token_salt = "<redacted>"  # ` + configFile,
+		`cache_size = "20"  # --cache-size`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("print-config missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "file_jwt") {
+		t.Errorf("print-config leaked a secret:\n%s", out.String())
+	}
+
+	t.Run("invalid values name their source", func(t *testing.T) {
+		values, err := Resolve(map[string]string{"cache-ttl": "soon"}, configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := values.Config(); err == nil || !strings.Contains(err.Error(), "cache-ttl (from --cache-ttl)") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("session lifetimes", func(t *testing.T) {
+		values, err := Resolve(map[string]string{"token-lifetime": "12h", "session-idle-timeout": "30m"}, configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := values.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.TokenLifetime != 12*time.Hour || cfg.SessionIdleTimeout != 30*time.Minute || cfg.SessionMaxLifetime != 0 {
+			t.Errorf("token %v, idle %v, max %v", cfg.TokenLifetime, cfg.SessionIdleTimeout, cfg.SessionMaxLifetime)
+		}
+
+		for source, flags := range map[string]map[string]string{
+			"token-lifetime (from --token-lifetime)":             {"token-lifetime": "0"},
+			"session-max-lifetime (from --session-max-lifetime)": {"session-max-lifetime": "-1h"},
+		} {
+			values, err := Resolve(flags, configFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := values.Config(); err == nil || !strings.Contains(err.Error(), source) {
+				t.Errorf("error = %v, want one naming %s", err, source)
+			}
+		}
+	})
+
+	t.Run("session binding", func(t *testing.T) {
+		values, err := Resolve(map[string]string{"session-binding": "network, device", "session-anomaly-action": "revoke"}, configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := values.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cfg.SessionBinding, []string{"network", "device"}) || cfg.SessionAnomalyAction != "revoke" {
+			t.Errorf("binding %v, action %q", cfg.SessionBinding, cfg.SessionAnomalyAction)
+		}
+
+		for _, flags := range []map[string]string{
+			{"session-binding": "country"},
+			{"session-anomaly-action": "ignore"},
+			{"session-alert-webhook-url": "ftp://alerts.example.com"},
+		} {
+			values, err := Resolve(flags, configFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := values.Config(); err == nil {
+				t.Errorf("expected %v to be rejected", flags)
+			}
+		}
+	})
+
+	t.Run("unknown config file keys are rejected", func(t *testing.T) {
+		os.WriteFile(configFile, []byte(`cache_tll = "5m"`), 0644)
+		if _, err := Resolve(nil, configFile); err == nil || !strings.Contains(err.Error(), "cache_tll") {
+			t.Errorf("error = %v", err)
+		}
+	})
+}
+
+func TestParseChecks(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"security,lint,secrets", []string{"security", "lint", "secrets"}},
+		{" security , webhook ", []string{"security", "webhook"}},
+		{"none", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseChecks(tt.value); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseChecks(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  []string
+		expectErr bool
+	}{
+		{"", nil, false},
+		{"10.0.0.0/8, 192.168.1.10", []string{"10.0.0.0/8", "192.168.1.10/32"}, false},
+		{"10.1.2.3/8,::1", []string{"10.0.0.0/8", "::1/128"}, false},
+		{"proxy.internal", nil, true},
+		{"10.0.0.0/33", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			prefixes, err := parseTrustedProxies(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseTrustedProxies(%q) error = %v, expectErr %v", tt.value, err, tt.expectErr)
+			}
+
+			var got []string
+			for _, prefix := range prefixes {
+				got = append(got, prefix.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseTrustedProxies(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
 // Helper function to set or unset environment variable
 func setOrUnset(key, value string) {
 	if value == "" {
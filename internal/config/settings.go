@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ConfigFileEnv names the environment variable that points at a server config file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Setting is one server setting. It can be set by the command-line flag named
+// after its key, its environment variable or, by key with underscores, in the
+// config file.
+type Setting struct {
+	Key     string
+	Env     string
+	Default string
+	Usage   string
+	Secret  bool // Redacted by --print-config
+}
+
+// FileKey is the setting's name in the TOML config file
+func (s Setting) FileKey() string {
+	return strings.ReplaceAll(s.Key, "-", "_")
+}
+
+// Settings lists every setting the API server reads
+var Settings = []Setting{
+	{Key: "database-url", Env: "DATABASE_URL", Usage: "PostgreSQL connection URL (required)", Secret: true},
+	{Key: "storage-path", Env: "STORAGE_PATH", Default: "./storage", Usage: "directory for package archives"},
+	{Key: "port", Env: "PORT", Default: "8080", Usage: "port to listen on"},
+	{Key: "token-salt", Env: "TOKEN_SALT", Usage: "salt for API token hashes (required)", Secret: true},
+	{Key: "jwt-secret", Env: "JWT_SECRET", Usage: "secret for signing session tokens (required)", Secret: true},
+	{Key: "token-lifetime", Env: "TOKEN_LIFETIME", Default: "720h", Usage: "how long a login's session token is valid"},
+	{Key: "session-idle-timeout", Env: "SESSION_IDLE_TIMEOUT", Default: "0", Usage: "end sessions unused for this long (0 never does)"},
+	{Key: "session-max-lifetime", Env: "SESSION_MAX_LIFETIME", Default: "0", Usage: "end sessions this long after login, whatever their token says (0 never does)"},
+	{Key: "session-binding", Env: "SESSION_BINDING", Default: "none", Usage: "comma-separated session bindings to watch: network, device, or none"},
+	{Key: "session-anomaly-action", Env: "SESSION_ANOMALY_ACTION", Default: "flag", Usage: "what to do with a session used outside its binding: flag or revoke"},
+	{Key: "session-alert-webhook-url", Env: "SESSION_ALERT_WEBHOOK_URL", Usage: "URL notified when a session is used outside its binding"},
+	{Key: "max-archive-size", Env: "MAX_ARCHIVE_SIZE", Default: strconv.Itoa(10 << 20), Usage: "largest archive accepted on publish, in bytes"},
+	{Key: "require-rule-tests", Env: "REQUIRE_RULE_TESTS", Default: "false", Usage: "reject publishes without passing rule tests"},
+	{Key: "validation-checks", Env: "VALIDATION_CHECKS", Default: "security,lint,secrets", Usage: "comma-separated publish validation checks, or none"},
+	{Key: "validation-webhook-url", Env: "VALIDATION_WEBHOOK_URL", Usage: "URL of the webhook validation check"},
+	{Key: "require-approval", Env: "REQUIRE_APPROVAL", Default: "false", Usage: "hold validated versions until a second user approves them"},
+	{Key: "trusted-proxies", Env: "TRUSTED_PROXIES", Usage: "comma-separated CIDRs or addresses of trusted reverse proxies"},
+	{Key: "reservation-max-days", Env: "RESERVATION_MAX_DAYS", Default: "90", Usage: "longest a name can be reserved before first publish (0 disables)"},
+	{Key: "cache-size", Env: "CACHE_SIZE", Default: "1000", Usage: "maximum cached responses (0 disables the cache)"},
+	{Key: "cache-ttl", Env: "CACHE_TTL", Default: "30s", Usage: "how long a cached response is served"},
+	{Key: "retention-keep-versions", Env: "RETENTION_KEEP_VERSIONS", Default: "0", Usage: "keep only the newest N versions of each package (0 keeps all)"},
+	{Key: "retention-prerelease-days", Env: "RETENTION_PRERELEASE_DAYS", Default: "0", Usage: "remove pre-releases older than this many days (0 keeps all)"},
+	{Key: "retention-interval", Env: "RETENTION_INTERVAL", Default: "24h", Usage: "how often the retention job runs"},
+	{Key: "upstream-registry-url", Env: "UPSTREAM_REGISTRY_URL", Usage: "registry to fetch missing versions from (pull-through mode)"},
+	{Key: "upstream-registry-token", Env: "UPSTREAM_REGISTRY_TOKEN", Usage: "token sent to the upstream registry", Secret: true},
+}
+
+// Values are resolved settings and where each one came from
+type Values struct {
+	values  map[string]string
+	sources map[string]string
+}
+
+// Resolve reads every setting from, highest precedence first, flags (by setting
+// key), the environment, the TOML config file (if configFile is not empty) and
+// the defaults
+func Resolve(flags map[string]string, configFile string) (*Values, error) {
+	v := &Values{values: map[string]string{}, sources: map[string]string{}}
+	for _, s := range Settings {
+		v.values[s.Key] = s.Default
+		v.sources[s.Key] = "default"
+	}
+
+	if configFile != "" {
+		fileValues, err := readConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			v.values[key] = value
+			v.sources[key] = configFile
+		}
+	}
+
+	for _, s := range Settings {
+		if value := os.Getenv(s.Env); value != "" {
+			v.values[s.Key] = value
+			v.sources[s.Key] = s.Env
+		}
+	}
+
+	for key, value := range flags {
+		if _, ok := v.values[key]; !ok {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+		v.values[key] = value
+		v.sources[key] = "--" + key
+	}
+
+	return v, nil
+}
+
+// readConfigFile reads a TOML config file into values keyed by setting key.
+// Numbers, booleans and lists are accepted as well as strings.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	byFileKey := map[string]Setting{}
+	for _, s := range Settings {
+		byFileKey[s.FileKey()] = s
+	}
+
+	values := map[string]string{}
+	for fileKey, value := range raw {
+		s, ok := byFileKey[fileKey]
+		if !ok {
+			return nil, fmt.Errorf("invalid config file %s: unknown setting %q", path, fileKey)
+		}
+		str, err := tomlValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %s: %w", path, fileKey, err)
+		}
+		values[s.Key] = str
+	}
+	return values, nil
+}
+
+func tomlValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("list items must be strings")
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// Get returns a setting's value
+func (v *Values) Get(key string) string {
+	return v.values[key]
+}
+
+// describe names a setting and where its value came from, for error messages
+func (v *Values) describe(key string) string {
+	source := v.sources[key]
+	if source == "default" {
+		return key
+	}
+	return fmt.Sprintf("%s (from %s)", key, source)
+}
+
+// Print writes the effective configuration as TOML, with secrets redacted and
+// the source of each value noted
+func (v *Values) Print(w io.Writer) {
+	fmt.Fprintln(w, "# Effective configuration (flags > environment > config file > defaults)")
+	for _, s := range Settings {
+		value := v.values[s.Key]
+		if s.Secret && value != "" {
+			value = redact(s.Key, value)
+		}
+		fmt.Fprintf(w, "%s = %s  # %s\n", s.FileKey(), strconv.Quote(value), v.sources[s.Key])
+	}
+}
+
+var dsnPassword = regexp.MustCompile(`password=\S+`)
+
+// redact hides a secret value; database URLs keep everything but the password
+func redact(key, value string) string {
+	if key != "database-url" {
+		return "<redacted>"
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(value, "password=xxxxx")
+}
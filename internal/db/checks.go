@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateVersionChecks registers the validation checks that will run for a package version
+func (db *DB) CreateVersionChecks(versionID int, names []string) error {
+	return createVersionChecks(db.context(), db, versionID, names)
+}
+
+// createVersionChecks inserts pending checks using e, which may be a transaction
+func createVersionChecks(ctx context.Context, e sqlx.ExecerContext, versionID int, names []string) error {
+	query := `
+        INSERT INTO package_version_checks (version_id, name)
+        VALUES ($1, $2)
+        ON CONFLICT (version_id, name) DO UPDATE
+        SET status = 'pending', message = NULL, started_at = NULL, finished_at = NULL`
+
+	for _, name := range names {
+		if _, err := e.ExecContext(ctx, query, versionID, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartVersionCheck marks a validation check as running
+func (db *DB) StartVersionCheck(versionID int, name string) error {
+	query := `
+        UPDATE package_version_checks
+        SET status = 'running', started_at = $3
+        WHERE version_id = $1 AND name = $2`
+
+	_, err := db.ExecContext(db.context(), query, versionID, name, time.Now())
+	return err
+}
+
+// FinishVersionCheck records the outcome of a validation check
+func (db *DB) FinishVersionCheck(versionID int, name string, passed bool, message string) error {
+	status := "passed"
+	if !passed {
+		status = "failed"
+	}
+
+	var msg *string
+	if message != "" {
+		msg = &message
+	}
+
+	query := `
+        UPDATE package_version_checks
+        SET status = $3, message = $4, finished_at = $5
+        WHERE version_id = $1 AND name = $2`
+
+	_, err := db.ExecContext(db.context(), query, versionID, name, status, msg, time.Now())
+	return err
+}
+
+// GetVersionChecks returns the validation checks for a package version in creation order
+func (db *DB) GetVersionChecks(versionID int) ([]VersionCheck, error) {
+	query := `
+        SELECT id, version_id, name, status, message, started_at, finished_at
+        FROM package_version_checks
+        WHERE version_id = $1
+        ORDER BY id`
+
+	checks := []VersionCheck{}
+	if err := db.SelectContext(db.context(), &checks, query, versionID); err != nil {
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// SetPackageVersionStatus moves a package version to pending, published or rejected
+func (db *DB) SetPackageVersionStatus(versionID int, status string) error {
+	_, err := db.ExecContext(db.context(), `UPDATE package_versions SET status = $2 WHERE id = $1`, versionID, status)
+	return err
+}
+
+// ApprovePackageVersion publishes a version awaiting approval, recording the approver.
+// It returns sql.ErrNoRows when the version is not awaiting approval.
+func (db *DB) ApprovePackageVersion(versionID, approverID int) error {
+	query := `
+        UPDATE package_versions
+        SET status = 'published', approved_by = $2, approved_at = $3
+        WHERE id = $1 AND status = 'awaiting_approval'`
+
+	result, err := db.ExecContext(db.context(), query, versionID, approverID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListPendingVersions returns versions whose validation has not completed, oldest first
+func (db *DB) ListPendingVersions() ([]NamedPackageVersion, error) {
+	return db.listNamedVersions(VersionStatusPending)
+}
+
+// listNamedVersions returns the versions in a status across all packages, oldest first
+func (db *DB) listNamedVersions(status string) ([]NamedPackageVersion, error) {
+	query := `
+        SELECT pv.id, pv.package_id, pv.version, pv.description, pv.targets, pv.tags,
+               pv.sha256, pv.size_bytes, pv.blob_path, pv.status, pv.published_by,
+               pv.approved_by, pv.approved_at, pv.created_at, p.name AS package_name
+        FROM package_versions pv
+        JOIN packages p ON p.id = pv.package_id
+        WHERE pv.status = $1
+        ORDER BY pv.created_at`
+
+	var versions []NamedPackageVersion
+	if err := db.SelectContext(db.context(), &versions, query, status); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
@@ -1,29 +1,57 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // postgres driver
+	"github.com/lib/pq" // postgres driver
 )
 
 // DB holds the database connection
 type DB struct {
 	*sqlx.DB
+
+	ctx context.Context // Context queries run under, see WithContext
 }
 
-// Connect establishes a connection to the database
+// Connect establishes a connection to the database: PostgreSQL, or a SQLite file
+// for URLs starting with sqlite://
 func Connect(databaseURL string) (*DB, error) {
-	sqlxDB, err := sqlx.Connect("postgres", databaseURL)
+	if strings.HasPrefix(databaseURL, SQLiteScheme) {
+		return connectSQLite(databaseURL)
+	}
+
+	connector, err := pq.NewConnector(databaseURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// Every statement is traced as a child of the context it runs under
+	sqlxDB := sqlx.NewDb(sql.OpenDB(tracedConnector{Connector: connector, system: "postgresql"}), "postgres")
+
 	// Test the connection
 	if err := sqlxDB.Ping(); err != nil {
 		sqlxDB.Close()
 		return nil, err
 	}
 
-	return &DB{sqlxDB}, nil
+	return &DB{DB: sqlxDB}, nil
+}
+
+// WithContext returns a DB whose queries run under ctx, so they are canceled with
+// the request and their spans join the request's trace
+func (db *DB) WithContext(ctx context.Context) *DB {
+	return &DB{DB: db.DB, ctx: ctx}
+}
+
+// context returns the context queries run under
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
 }
 
 // Close closes the database connection
@@ -33,5 +61,5 @@ func (db *DB) Close() error {
 
 // Health checks if the database connection is healthy
 func (db *DB) Health() error {
-	return db.Ping()
+	return db.PingContext(db.context())
 }
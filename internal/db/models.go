@@ -1,6 +1,9 @@
 package db
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/lib/pq"
@@ -8,23 +11,130 @@ import (
 
 // Package represents a package in the registry
 type Package struct {
-	ID        int       `db:"id" json:"id"`
-	Name      string    `db:"name" json:"name"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Official    bool      `db:"official" json:"official"`                   // Badge set by registry admins
+	VerifiedOrg *string   `db:"verified_org" json:"verified_org,omitempty"` // Organization admins verified as the publisher
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 }
 
 // PackageVersion represents a specific version of a package
 type PackageVersion struct {
-	ID          int            `db:"id" json:"id"`
-	PackageID   int            `db:"package_id" json:"package_id"`
-	Version     string         `db:"version" json:"version"`
-	Description *string        `db:"description" json:"description"`
-	Targets     pq.StringArray `db:"targets" json:"targets"`
-	Tags        pq.StringArray `db:"tags" json:"tags"`
-	SHA256      *string        `db:"sha256" json:"sha256"`
-	SizeBytes   *int           `db:"size_bytes" json:"size_bytes"`
-	BlobPath    *string        `db:"blob_path" json:"blob_path"`
-	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	ID           int            `db:"id" json:"id"`
+	PackageID    int            `db:"package_id" json:"package_id"`
+	Version      string         `db:"version" json:"version"`
+	Description  *string        `db:"description" json:"description"`
+	Targets      pq.StringArray `db:"targets" json:"targets"`
+	Tags         pq.StringArray `db:"tags" json:"tags"`
+	SHA256       *string        `db:"sha256" json:"sha256"`
+	Integrity    *string        `db:"integrity" json:"integrity,omitempty"` // Archive digests as sha512-...; nil for versions published before they were recorded
+	SizeBytes    *int           `db:"size_bytes" json:"size_bytes"`
+	BlobPath     *string        `db:"blob_path" json:"blob_path"`
+	Status       string         `db:"status" json:"status"`
+	PublishedBy  *int           `db:"published_by" json:"published_by,omitempty"`
+	ApprovedBy   *int           `db:"approved_by" json:"approved_by,omitempty"`
+	ApprovedAt   *time.Time     `db:"approved_at" json:"approved_at,omitempty"`
+	Deprecated   *string        `db:"deprecated" json:"deprecated,omitempty"`
+	Replacement  *string        `db:"replacement" json:"replacement,omitempty"`   // What to use instead of a deprecated version, e.g. other-rules@^2
+	Upstream     *string        `db:"upstream" json:"upstream,omitempty"`         // Registry a pull-through copy was cached from
+	License      *string        `db:"license" json:"license,omitempty"`           // SPDX license expression
+	Dependencies Dependencies   `db:"dependencies" json:"dependencies,omitempty"` // Packages the version builds on, by version range
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Dependencies maps package names to version ranges, stored as a JSON object
+type Dependencies map[string]string
+
+// Value implements the driver.Valuer interface for database storage
+func (d Dependencies) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(d))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (d *Dependencies) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan Dependencies from type %T", value)
+	}
+	return json.Unmarshal(data, (*map[string]string)(d))
+}
+
+// NamedPackageVersion is a package version together with its package name
+type NamedPackageVersion struct {
+	PackageVersion
+	PackageName string `db:"package_name"`
+}
+
+// PackageReservation holds a package name for a user until its first publish
+type PackageReservation struct {
+	ID        int       `db:"id" json:"-"`
+	Name      string    `db:"name" json:"name"`
+	UserID    int       `db:"user_id" json:"-"`
+	Username  string    `db:"username" json:"reserved_by"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// PackageReport is a user's report of an abusive package, queued for moderation
+type PackageReport struct {
+	ID          int        `db:"id" json:"id"`
+	PackageID   int        `db:"package_id" json:"-"`
+	PackageName string     `db:"package_name" json:"package"`
+	Version     *string    `db:"version" json:"version,omitempty"` // Reported version, or nil for the whole package
+	ReporterID  int        `db:"reporter_id" json:"-"`
+	Reporter    string     `db:"reporter" json:"reported_by"`
+	Reason      string     `db:"reason" json:"reason"` // One of ReportReasons
+	Message     *string    `db:"message" json:"message,omitempty"`
+	Status      string     `db:"status" json:"status"`
+	ResolvedBy  *int       `db:"resolved_by" json:"-"`
+	Resolver    *string    `db:"resolver" json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ReportReasons are the reasons a package can be reported for
+var ReportReasons = []string{"malicious", "spam", "impersonation", "other"}
+
+// Package report statuses: open until an admin resolves the report with an action
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed" // No action taken
+	ReportStatusYanked    = "yanked"    // The reported versions were withdrawn
+	ReportStatusBanned    = "banned"    // Withdrawn, and their publishers' accounts deactivated
+)
+
+// Package version statuses
+const (
+	VersionStatusPending          = "pending"           // Uploaded, validation checks still running
+	VersionStatusAwaitingApproval = "awaiting_approval" // Checks passed; a second reviewer must approve
+	VersionStatusPublished        = "published"         // Visible to clients
+	VersionStatusRejected         = "rejected"          // At least one check failed
+)
+
+// VersionCheck is the result of one validation check run against a package version
+type VersionCheck struct {
+	ID         int        `db:"id" json:"-"`
+	VersionID  int        `db:"version_id" json:"-"`
+	Name       string     `db:"name" json:"name"`
+	Status     string     `db:"status" json:"status"` // pending, running, passed or failed
+	Message    *string    `db:"message" json:"message,omitempty"`
+	StartedAt  *time.Time `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
 }
 
 // PackageInfo combines package and version info for API responses
@@ -35,13 +145,17 @@ type PackageInfo struct {
 
 // SearchResult represents a search result
 type SearchResult struct {
-	ID          int            `db:"id" json:"id"`
-	Name        string         `db:"name" json:"name"`
-	Version     string         `db:"version" json:"version"`
-	Description *string        `db:"description" json:"description"`
-	Targets     pq.StringArray `db:"targets" json:"targets"`
-	Tags        pq.StringArray `db:"tags" json:"tags"`
-	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	ID           int            `db:"id" json:"id"`
+	Name         string         `db:"name" json:"name"`
+	Version      string         `db:"version" json:"version"`
+	Description  *string        `db:"description" json:"description"`
+	Targets      pq.StringArray `db:"targets" json:"targets"`
+	Tags         pq.StringArray `db:"tags" json:"tags"`
+	License      *string        `db:"license" json:"license,omitempty"`
+	Dependencies Dependencies   `db:"dependencies" json:"dependencies,omitempty"`
+	Official     bool           `db:"official" json:"official"`
+	VerifiedOrg  *string        `db:"verified_org" json:"verified_org,omitempty"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
 }
 
 // FullPackageName returns the package name (no scope support)
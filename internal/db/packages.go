@@ -1,8 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // GetOrCreatePackage gets existing package or creates new one
@@ -20,10 +25,10 @@ func (db *DB) GetOrCreatePackage(name string) (*Package, error) {
 	query := `
         INSERT INTO packages (name) 
         VALUES ($1) 
-        RETURNING id, name, created_at`
+        RETURNING id, name, official, verified_org, created_at`
 
 	var newPkg Package
-	err = db.Get(&newPkg, query, name)
+	err = db.GetContext(db.context(), &newPkg, query, name)
 	if err != nil {
 		return nil, err
 	}
@@ -33,10 +38,10 @@ func (db *DB) GetOrCreatePackage(name string) (*Package, error) {
 
 // GetPackage retrieves a package by name
 func (db *DB) GetPackage(name string) (*Package, error) {
-	query := `SELECT id, name, created_at FROM packages WHERE name = $1`
+	query := `SELECT id, name, official, verified_org, created_at FROM packages WHERE name = $1`
 
 	var pkg Package
-	err := db.Get(&pkg, query, name)
+	err := db.GetContext(db.context(), &pkg, query, name)
 	if err != nil {
 		return nil, err
 	}
@@ -46,22 +51,38 @@ func (db *DB) GetPackage(name string) (*Package, error) {
 
 // CreatePackageVersion creates a new package version
 func (db *DB) CreatePackageVersion(version PackageVersion) (*PackageVersion, error) {
+	return createPackageVersion(db.context(), db, version)
+}
+
+// createPackageVersion inserts a package version using q, which may be a transaction
+func createPackageVersion(ctx context.Context, q sqlx.QueryerContext, version PackageVersion) (*PackageVersion, error) {
 	query := `
         INSERT INTO package_versions 
-        (package_id, version, description, targets, tags, sha256, size_bytes, blob_path)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-        RETURNING id, package_id, version, description, targets, tags, sha256, size_bytes, blob_path, created_at`
+        (package_id, version, description, targets, tags, sha256, integrity, size_bytes, blob_path, status, published_by, upstream, license, dependencies)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING id, package_id, version, description, targets, tags, sha256, integrity, size_bytes, blob_path,
+                  status, published_by, approved_by, approved_at, upstream, license, dependencies, created_at`
+
+	if version.Status == "" {
+		version.Status = VersionStatusPublished
+	}
 
 	var newVersion PackageVersion
-	err := db.Get(&newVersion, query,
+	err := sqlx.GetContext(ctx, q, &newVersion, query,
 		version.PackageID,
 		version.Version,
 		version.Description,
 		version.Targets,
 		version.Tags,
 		version.SHA256,
+		version.Integrity,
 		version.SizeBytes,
 		version.BlobPath,
+		version.Status,
+		version.PublishedBy,
+		version.Upstream,
+		version.License,
+		version.Dependencies,
 	)
 
 	if err != nil {
@@ -75,7 +96,8 @@ func (db *DB) CreatePackageVersion(version PackageVersion) (*PackageVersion, err
 func (db *DB) GetPackageVersion(name string, version string) (*PackageVersion, error) {
 	query := `
 		SELECT pv.id, pv.package_id, pv.version, pv.description, pv.targets, pv.tags, 
-			   pv.sha256, pv.size_bytes, pv.blob_path, pv.created_at
+			   pv.sha256, pv.integrity, pv.size_bytes, pv.blob_path, pv.status, pv.published_by, pv.approved_by,
+			   pv.approved_at, pv.deprecated, pv.replacement, pv.upstream, pv.license, pv.dependencies, pv.created_at
 		FROM package_versions pv
 		JOIN packages p ON p.id = pv.package_id
 		WHERE p.name = $1 AND pv.version = $2`
@@ -84,7 +106,7 @@ func (db *DB) GetPackageVersion(name string, version string) (*PackageVersion, e
 	fmt.Printf("[DEBUG] GetPackageVersion parameters: [%s, %s]\n", name, version)
 
 	var pkgVersion PackageVersion
-	err := db.Get(&pkgVersion, query, name, version)
+	err := db.GetContext(db.context(), &pkgVersion, query, name, version)
 	if err != nil {
 		fmt.Printf("[ERROR] GetPackageVersion SQL error: %v\n", err)
 		return nil, err
@@ -94,13 +116,15 @@ func (db *DB) GetPackageVersion(name string, version string) (*PackageVersion, e
 	return &pkgVersion, nil
 }
 
-// SearchPackages searches for packages
-func (db *DB) SearchPackages(query string, tag string, target string, limit int) ([]SearchResult, error) {
+// SearchPackages searches for packages, only those with the official badge when
+// officialOnly is set
+func (db *DB) SearchPackages(query string, tag string, target string, officialOnly bool, limit int) ([]SearchResult, error) {
 	sqlQuery := `
-        SELECT DISTINCT p.id, p.name, pv.version, pv.description, pv.targets, pv.tags, p.created_at
+        SELECT DISTINCT p.id, p.name, pv.version, pv.description, pv.targets, pv.tags, pv.license, pv.dependencies,
+               p.official, p.verified_org, p.created_at
         FROM packages p
         JOIN package_versions pv ON p.id = pv.package_id
-        WHERE 1=1`
+        WHERE pv.status = 'published'`
 
 	args := []interface{}{}
 	argCount := 0
@@ -124,6 +148,10 @@ func (db *DB) SearchPackages(query string, tag string, target string, limit int)
 		args = append(args, target)
 	}
 
+	if officialOnly {
+		sqlQuery += " AND p.official"
+	}
+
 	sqlQuery += " ORDER BY p.created_at DESC"
 
 	if limit > 0 {
@@ -133,10 +161,81 @@ func (db *DB) SearchPackages(query string, tag string, target string, limit int)
 	}
 
 	var results []SearchResult
-	err := db.Select(&results, sqlQuery, args...)
+	err := db.SelectContext(db.context(), &results, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	return results, nil
 }
+
+// ListAuthorPackages returns the published versions a user published, newest
+// package first, in the shape of search results
+func (db *DB) ListAuthorPackages(userID int) ([]SearchResult, error) {
+	query := `
+        SELECT p.id, p.name, pv.version, pv.description, pv.targets, pv.tags, pv.license, pv.dependencies,
+               p.official, p.verified_org, p.created_at
+        FROM packages p
+        JOIN package_versions pv ON p.id = pv.package_id
+        WHERE pv.status = 'published' AND pv.published_by = $1
+        ORDER BY p.created_at DESC, p.name`
+
+	results := []SearchResult{}
+	if err := db.SelectContext(db.context(), &results, query, userID); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SetPackageBadges sets the official badge and verified organization of a
+// package; a nil verifiedOrg clears it
+func (db *DB) SetPackageBadges(packageID int, official bool, verifiedOrg *string) error {
+	_, err := db.ExecContext(db.context(), `UPDATE packages SET official = $2, verified_org = $3 WHERE id = $1`, packageID, official, verifiedOrg)
+	return err
+}
+
+// ListPublishedVersionsOf returns every published version of the named packages
+func (db *DB) ListPublishedVersionsOf(names []string) ([]NamedPackageVersion, error) {
+	query := `
+        SELECT p.name AS package_name, pv.id, pv.package_id, pv.version, pv.description, pv.targets,
+               pv.tags, pv.sha256, pv.integrity, pv.size_bytes, pv.status, pv.published_by, pv.deprecated, pv.replacement, pv.created_at
+        FROM package_versions pv
+        JOIN packages p ON p.id = pv.package_id
+        WHERE p.name = ANY($1) AND pv.status = 'published'`
+
+	var versions []NamedPackageVersion
+	err := db.SelectContext(db.context(), &versions, query, pq.Array(names))
+	return versions, err
+}
+
+// SetVersionDeprecation deprecates a version with message and an optional
+// replacement, or clears both when message is nil
+func (db *DB) SetVersionDeprecation(versionID int, message, replacement *string) error {
+	_, err := db.ExecContext(db.context(), `UPDATE package_versions SET deprecated = $2, replacement = $3 WHERE id = $1`, versionID, message, replacement)
+	return err
+}
+
+// SetPackageDeprecation deprecates every published version of a package like
+// SetVersionDeprecation, returning how many versions it changed
+func (db *DB) SetPackageDeprecation(packageID int, message, replacement *string) (int64, error) {
+	result, err := db.ExecContext(db.context(), `
+		UPDATE package_versions SET deprecated = $2, replacement = $3
+		WHERE package_id = $1 AND status = 'published'`, packageID, message, replacement)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListChangedPackages returns the names of packages changed after since, along with
+// the database time the list was taken at, which callers use as their next cursor
+func (db *DB) ListChangedPackages(since time.Time) ([]string, time.Time, error) {
+	var now time.Time
+	if err := db.GetContext(db.context(), &now, `SELECT now()`); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var names []string
+	err := db.SelectContext(db.context(), &names, `SELECT name FROM packages WHERE updated_at > $1 ORDER BY name`, since)
+	return names, now, err
+}
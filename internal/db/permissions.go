@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// Permission is an action a role can be granted
+type Permission string
+
+const (
+	PermissionRead           Permission = "read"            // Authenticated reads such as check results and the profile
+	PermissionPublish        Permission = "publish"         // Publish, approve, deprecate, share and reserve packages
+	PermissionAdmin          Permission = "admin"           // Manage users and registry operations
+	PermissionYank           Permission = "yank"            // Withdraw published versions when resolving reports
+	PermissionModerate       Permission = "moderate"        // Review and resolve abuse reports
+	PermissionManageOrg      Permission = "manage-org"      // Set official and verified-organization badges
+	PermissionManageWebhooks Permission = "manage-webhooks" // Reserved for webhook administration
+)
+
+// KnownPermissions lists every permission the registry checks
+var KnownPermissions = []Permission{
+	PermissionRead, PermissionPublish, PermissionAdmin, PermissionYank,
+	PermissionModerate, PermissionManageOrg, PermissionManageWebhooks,
+}
+
+// PermissionMatrix maps each role to the permissions it grants. Root is not
+// listed: it always has every permission.
+type PermissionMatrix map[UserRole][]Permission
+
+// DefaultPermissionMatrix is what roles grant until the role_permissions table
+// is loaded; the migrations seed the table with the same rows
+var DefaultPermissionMatrix = PermissionMatrix{
+	RoleUser:      {PermissionRead},
+	RolePublisher: {PermissionRead, PermissionPublish},
+	RoleAdmin: {
+		PermissionRead, PermissionPublish, PermissionAdmin, PermissionYank,
+		PermissionModerate, PermissionManageOrg, PermissionManageWebhooks,
+	},
+}
+
+// activePermissions is the matrix HasPermission consults
+var activePermissions atomic.Pointer[PermissionMatrix]
+
+func init() {
+	SetPermissionMatrix(DefaultPermissionMatrix)
+}
+
+// Allows reports whether role is granted permission
+func (m PermissionMatrix) Allows(role UserRole, permission Permission) bool {
+	if role == RoleRoot {
+		return true
+	}
+	return slices.Contains(m[role], permission)
+}
+
+// SetPermissionMatrix makes HasPermission consult m
+func SetPermissionMatrix(m PermissionMatrix) {
+	activePermissions.Store(&m)
+}
+
+// ActivePermissionMatrix returns the matrix HasPermission consults
+func ActivePermissionMatrix() PermissionMatrix {
+	return *activePermissions.Load()
+}
+
+// LoadPermissionMatrix reads the role_permissions table. Rows naming a
+// permission the registry does not know are rejected, so a typo cannot
+// silently leave a role without access.
+func (db *DB) LoadPermissionMatrix() (PermissionMatrix, error) {
+	var rows []struct {
+		Role       UserRole   `db:"role"`
+		Permission Permission `db:"permission"`
+	}
+	if err := db.SelectContext(db.context(), &rows, `SELECT role, permission FROM role_permissions ORDER BY role, permission`); err != nil {
+		return nil, err
+	}
+
+	matrix := PermissionMatrix{}
+	for _, row := range rows {
+		if !slices.Contains(KnownPermissions, row.Permission) {
+			return nil, fmt.Errorf("role %s has unknown permission %q", row.Role, row.Permission)
+		}
+		matrix[row.Role] = append(matrix[row.Role], row.Permission)
+	}
+	return matrix, nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissionMatrix(t *testing.T) {
+	database, err := Connect(SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	// The seeded table grants what the default matrix does
+	matrix, err := database.LoadPermissionMatrix()
+	if err != nil {
+		t.Fatalf("LoadPermissionMatrix failed: %v", err)
+	}
+	for _, role := range []UserRole{RoleUser, RolePublisher, RoleAdmin, RoleRoot} {
+		for _, permission := range KnownPermissions {
+			if got, want := matrix.Allows(role, permission), DefaultPermissionMatrix.Allows(role, permission); got != want {
+				t.Errorf("%s %s: loaded %v, default %v", role, permission, got, want)
+			}
+		}
+	}
+
+	// Operators can grant roles more permissions
+	if _, err := database.Exec(`INSERT INTO role_permissions (role, permission) VALUES ('publisher', 'yank')`); err != nil {
+		t.Fatal(err)
+	}
+	if matrix, err = database.LoadPermissionMatrix(); err != nil {
+		t.Fatal(err)
+	}
+	SetPermissionMatrix(matrix)
+	defer SetPermissionMatrix(DefaultPermissionMatrix)
+	if !RolePublisher.HasPermission(PermissionYank) || RolePublisher.HasPermission(PermissionModerate) || !RoleRoot.HasPermission(PermissionManageWebhooks) {
+		t.Errorf("unexpected permissions from %v", matrix)
+	}
+
+	if _, err := database.Exec(`INSERT INTO role_permissions (role, permission) VALUES ('admin', 'moderation')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.LoadPermissionMatrix(); err == nil {
+		t.Error("expected an unknown permission to be rejected")
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionExists is returned when publishing a version that is already recorded
+var ErrVersionExists = errors.New("package version already exists")
+
+// PublishPackageVersion records a package version, creating its package if needed,
+// together with its pending validation checks in a single transaction.
+//
+// commitBlob runs last, just before the transaction commits, and should move the
+// archive into place. If it fails nothing is recorded. A crash after it runs but
+// before the commit leaves at most an unreferenced archive, never a version row
+// pointing at a missing archive.
+func (db *DB) PublishPackageVersion(name string, version PackageVersion, checks []string, commitBlob func() error) (*PackageVersion, error) {
+	tx, err := db.BeginTxx(db.context(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A reserved name can only be claimed by the user holding the reservation
+	reservation, err := getActiveReservation(db.context(), tx, name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check name reservation: %w", err)
+	}
+	if reservation != nil && (version.PublishedBy == nil || *version.PublishedBy != reservation.UserID) {
+		return nil, ErrNameReserved
+	}
+
+	// Upsert so concurrent first publishes of a package do not race on its row
+	var packageID int
+	err = tx.GetContext(db.context(), &packageID, `
+        INSERT INTO packages (name)
+        VALUES ($1)
+        ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+        RETURNING id`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package: %w", err)
+	}
+	version.PackageID = packageID
+
+	// A rejected upload does not reserve its version number, unless moderators
+	// yanked it
+	_, err = tx.ExecContext(db.context(), `
+        DELETE FROM package_versions
+        WHERE package_id = $1 AND version = $2 AND status = 'rejected'
+          AND NOT EXISTS (
+              SELECT 1 FROM package_version_checks c
+              WHERE c.version_id = package_versions.id AND c.name = $3)`, packageID, version.Version, ModerationCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear rejected version: %w", err)
+	}
+
+	created, err := createPackageVersion(db.context(), tx, version)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrVersionExists
+		}
+		return nil, fmt.Errorf("failed to create package version: %w", err)
+	}
+
+	if err := createVersionChecks(db.context(), tx, created.ID, checks); err != nil {
+		return nil, fmt.Errorf("failed to schedule validation checks: %w", err)
+	}
+
+	// The first publish claims the name, so its reservation is no longer needed
+	if _, err := tx.ExecContext(db.context(), `DELETE FROM package_reservations WHERE name = $1`, name); err != nil {
+		return nil, fmt.Errorf("failed to claim name reservation: %w", err)
+	}
+
+	if err := commitBlob(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit publish: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListBlobPaths returns the archive path of every recorded package version
+func (db *DB) ListBlobPaths() ([]string, error) {
+	var paths []string
+	err := db.SelectContext(db.context(), &paths, `SELECT blob_path FROM package_versions WHERE blob_path IS NOT NULL`)
+	return paths, err
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAlreadyReported is returned when a user reports a package they already
+// have an open report against
+var ErrAlreadyReported = errors.New("package already reported")
+
+// ModerationCheck is the version check recorded as failed on versions yanked
+// by moderators. It keeps their version numbers from being published again.
+const ModerationCheck = "moderation"
+
+const reportColumns = `
+        SELECT r.id, r.package_id, p.name AS package_name, r.version, r.reporter_id, u.username AS reporter,
+               r.reason, r.message, r.status, r.resolved_by, a.username AS resolver, r.resolved_at, r.created_at
+        FROM package_reports r
+        JOIN packages p ON p.id = r.package_id
+        JOIN users u ON u.id = r.reporter_id
+        LEFT JOIN users a ON a.id = r.resolved_by`
+
+// CreatePackageReport queues a report of a package, or one of its versions when
+// version is set, for moderation
+func (db *DB) CreatePackageReport(packageID int, version *string, reporterID int, reason string, message *string) (*PackageReport, error) {
+	var reportID int
+	err := db.GetContext(db.context(), &reportID, `
+        INSERT INTO package_reports (package_id, version, reporter_id, reason, message)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id`, packageID, version, reporterID, reason, message)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyReported
+		}
+		return nil, err
+	}
+
+	return db.GetPackageReport(reportID)
+}
+
+// GetPackageReport returns a report by ID, or sql.ErrNoRows
+func (db *DB) GetPackageReport(id int) (*PackageReport, error) {
+	var report PackageReport
+	if err := db.GetContext(db.context(), &report, reportColumns+` WHERE r.id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListPackageReports returns the reports in a status, or all of them when status
+// is empty, oldest first
+func (db *DB) ListPackageReports(status string) ([]PackageReport, error) {
+	query := reportColumns
+	args := []interface{}{}
+	if status != "" {
+		query += ` WHERE r.status = $1`
+		args = append(args, status)
+	}
+	query += ` ORDER BY r.created_at, r.id`
+
+	reports := []PackageReport{}
+	if err := db.SelectContext(db.context(), &reports, query, args...); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// YankPackageVersions withdraws the published versions of a package, or only
+// version when it is set, recording why as a failed moderation check. It
+// returns the versions it yanked.
+func (db *DB) YankPackageVersions(packageID int, version *string, reason string) ([]PackageVersion, error) {
+	tx, err := db.BeginTxx(db.context(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+        SELECT id, package_id, version, status, published_by, created_at
+        FROM package_versions
+        WHERE package_id = $1 AND status = 'published'`
+	args := []interface{}{packageID}
+	if version != nil {
+		query += ` AND version = $2`
+		args = append(args, *version)
+	}
+
+	var versions []PackageVersion
+	if err := tx.SelectContext(db.context(), &versions, query, args...); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, v := range versions {
+		if _, err := tx.ExecContext(db.context(), `UPDATE package_versions SET status = 'rejected' WHERE id = $1`, v.ID); err != nil {
+			return nil, err
+		}
+		_, err := tx.ExecContext(db.context(), `
+            INSERT INTO package_version_checks (version_id, name, status, message, started_at, finished_at)
+            VALUES ($1, $2, 'failed', $3, $4, $4)
+            ON CONFLICT (version_id, name) DO UPDATE
+            SET status = 'failed', message = EXCLUDED.message, started_at = EXCLUDED.started_at, finished_at = EXCLUDED.finished_at`,
+			v.ID, ModerationCheck, reason, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// ResolvePackageReports closes a report with the status of the action taken.
+// Yanks and bans act on the package, so they also close its other open reports.
+// It returns how many reports were closed.
+func (db *DB) ResolvePackageReports(reportID int, status string, resolverID int) (int64, error) {
+	query := `
+        UPDATE package_reports
+        SET status = $2, resolved_by = $3, resolved_at = $4
+        WHERE status = 'open' AND id = $1`
+	if status != ReportStatusDismissed {
+		query = `
+        UPDATE package_reports
+        SET status = $2, resolved_by = $3, resolved_at = $4
+        WHERE status = 'open'
+          AND package_id = (SELECT package_id FROM package_reports WHERE id = $1)`
+	}
+
+	result, err := db.ExecContext(db.context(), query, reportID, status, resolverID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
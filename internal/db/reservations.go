@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNameReserved is returned when a package name is held by another user
+var ErrNameReserved = errors.New("package name is reserved")
+
+// ReservePackageName reserves name for userID until expiresAt. A user may extend
+// their own reservation; another user's reservation blocks until it expires.
+func (db *DB) ReservePackageName(name string, userID int, expiresAt time.Time) (*PackageReservation, error) {
+	query := `
+        INSERT INTO package_reservations (name, user_id, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (name) DO UPDATE
+        SET user_id = EXCLUDED.user_id, expires_at = EXCLUDED.expires_at, created_at = now()
+        WHERE package_reservations.user_id = EXCLUDED.user_id
+           OR package_reservations.expires_at <= now()
+        RETURNING id`
+
+	var reservationID int
+	err := db.GetContext(db.context(), &reservationID, query, name, userID, expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNameReserved
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reservation PackageReservation
+	err = db.GetContext(db.context(), &reservation, `
+        SELECT r.id, r.name, r.user_id, u.username, r.expires_at, r.created_at
+        FROM package_reservations r
+        JOIN users u ON u.id = r.user_id
+        WHERE r.id = $1`, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetActiveReservation returns the unexpired reservation for name, or sql.ErrNoRows
+func (db *DB) GetActiveReservation(name string) (*PackageReservation, error) {
+	return getActiveReservation(db.context(), db, name)
+}
+
+// getActiveReservation looks up a reservation using q, which may be a transaction
+func getActiveReservation(ctx context.Context, q sqlx.QueryerContext, name string) (*PackageReservation, error) {
+	query := `
+        SELECT r.id, r.name, r.user_id, u.username, r.expires_at, r.created_at
+        FROM package_reservations r
+        JOIN users u ON u.id = r.user_id
+        WHERE r.name = $1 AND r.expires_at > now()`
+
+	var reservation PackageReservation
+	if err := sqlx.GetContext(ctx, q, &reservation, query, name); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// ReleasePackageName drops userID's reservation of name, or returns sql.ErrNoRows
+func (db *DB) ReleasePackageName(name string, userID int) error {
+	result, err := db.ExecContext(db.context(), `DELETE FROM package_reservations WHERE name = $1 AND user_id = $2`, name, userID)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
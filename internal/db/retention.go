@@ -0,0 +1,21 @@
+package db
+
+// ListPublishedVersions returns every published version across all packages, oldest first
+func (db *DB) ListPublishedVersions() ([]NamedPackageVersion, error) {
+	return db.listNamedVersions(VersionStatusPublished)
+}
+
+// DeletePackageVersion removes a version; its validation check rows cascade
+func (db *DB) DeletePackageVersion(versionID int) error {
+	_, err := db.ExecContext(db.context(), `DELETE FROM package_versions WHERE id = $1`, versionID)
+	return err
+}
+
+// CountVersionsWithBlobPath counts versions of any status that still use an
+// archive file. Versions with identical content may each have a file of their
+// own, so files are counted by path rather than by hash.
+func (db *DB) CountVersionsWithBlobPath(blobPath string) (int, error) {
+	var count int
+	err := db.GetContext(db.context(), &count, `SELECT COUNT(*) FROM package_versions WHERE blob_path = $1`, blobPath)
+	return count, err
+}
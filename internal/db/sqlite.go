@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteScheme prefixes database URLs that open a SQLite file, as in sqlite:///var/lib/rfh/registry.db
+const SQLiteScheme = "sqlite://"
+
+//go:embed sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// sqliteTimeFormat is how the driver writes Go times with _time_format=sqlite.
+// now() and column defaults use the same UTC text, so timestamps compare as strings.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+func init() {
+	sqlite.MustRegisterScalarFunction("now", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(sqliteTimeFormat), nil
+	})
+
+	// array_contains(array, value) stands in for PostgreSQL's value = ANY(array)
+	sqlite.MustRegisterDeterministicScalarFunction("array_contains", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var array pq.StringArray
+		if err := array.Scan(args[0]); err != nil {
+			return nil, err
+		}
+		value, _ := args[1].(string)
+		for _, item := range array {
+			if item == value {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// connectSQLite opens a SQLite database file, creating it and applying the schema as needed
+func connectSQLite(databaseURL string) (*DB, error) {
+	file := strings.TrimPrefix(databaseURL, SQLiteScheme)
+	if file == "" {
+		return nil, fmt.Errorf("sqlite database URL has no file path")
+	}
+	dsn := "file:" + file + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+	sqlxDB := sqlx.NewDb(sql.OpenDB(tracedConnector{Connector: sqliteConnector{dsn: dsn}, system: "sqlite"}), "sqlite")
+
+	// One writer at a time; the registry is meant for small teams
+	sqlxDB.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(sqlxDB); err != nil {
+		sqlxDB.Close()
+		return nil, err
+	}
+
+	return &DB{DB: sqlxDB}, nil
+}
+
+// migrateSQLite applies the schema files the database has not seen, tracked in user_version
+func migrateSQLite(sqlxDB *sqlx.DB) error {
+	var applied int
+	if err := sqlxDB.Get(&applied, `PRAGMA user_version`); err != nil {
+		return fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	files, err := sqliteMigrations.ReadDir("sqlite")
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	for i, file := range files {
+		if i < applied {
+			continue
+		}
+		schema, err := sqliteMigrations.ReadFile(path.Join("sqlite", file.Name()))
+		if err != nil {
+			return err
+		}
+
+		tx, err := sqlxDB.Beginx()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(schema)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply %s: %w", file.Name(), err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", file.Name(), err)
+		}
+	}
+	return nil
+}
+
+// sqliteConnector opens SQLite connections that accept the registry's PostgreSQL queries
+type sqliteConnector struct {
+	dsn string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{Conn: conn}, nil
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return sqliteDriver
+}
+
+// sqliteDriver is the registered driver, which carries the functions added in init
+var sqliteDriver = func() driver.Driver {
+	sqlDB, _ := sql.Open("sqlite", "")
+	defer sqlDB.Close()
+	return sqlDB.Driver()
+}()
+
+var anyPattern = regexp.MustCompile(`(\S+) = ANY\(([^)]+)\)`)
+
+// translateQuery rewrites the PostgreSQL the registry speaks into SQLite. Only the
+// constructs the queries use are covered: ILIKE and value = ANY(array).
+func translateQuery(query string) string {
+	query = strings.ReplaceAll(query, " ILIKE ", " LIKE ")
+	return anyPattern.ReplaceAllString(query, "array_contains($2, $1)")
+}
+
+// sqliteConn translates queries, writes times in UTC and reads back computed timestamps
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, translateQuery(query), utcArgs(args))
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteRows{Rows: rows}, nil
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, translateQuery(query), utcArgs(args))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, translateQuery(query))
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+// utcArgs converts time arguments to UTC, so stored timestamps compare as text
+func utcArgs(args []driver.NamedValue) []driver.NamedValue {
+	for i, arg := range args {
+		if t, ok := arg.Value.(time.Time); ok {
+			args[i].Value = t.UTC()
+		}
+	}
+	return args
+}
+
+// sqliteRows reads timestamps from columns without a declared type, such as
+// SELECT now() and RETURNING, as times rather than text
+type sqliteRows struct {
+	driver.Rows
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+
+	typed, _ := r.Rows.(driver.RowsColumnTypeDatabaseTypeName)
+	for i, value := range dest {
+		text, ok := value.(string)
+		if !ok || (typed != nil && typed.ColumnTypeDatabaseTypeName(i) != "") {
+			continue
+		}
+		if t, err := time.Parse(sqliteTimeFormat, text); err == nil {
+			dest[i] = t
+		}
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation in either database
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
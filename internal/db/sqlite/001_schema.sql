@@ -0,0 +1,117 @@
+-- SQLite schema for single-binary registries ('rfh serve'), equivalent to the
+-- PostgreSQL migrations V1 to V12. Changes to those migrations need a matching
+-- file here, numbered in order.
+--
+-- Timestamps are UTC text in the format the driver writes Go times in, so they
+-- compare correctly as strings. Arrays are PostgreSQL array literals.
+
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'publisher', 'admin', 'root')),
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_login TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE UNIQUE INDEX only_one_root_user ON users (role) WHERE role = 'root';
+CREATE INDEX idx_users_role ON users(role);
+
+CREATE TABLE user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_used TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    user_agent TEXT,
+    ip_address TEXT
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX idx_user_sessions_expires_at ON user_sessions(expires_at);
+
+CREATE TABLE tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    name TEXT,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP
+);
+
+CREATE TABLE packages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_packages_updated_at ON packages(updated_at);
+
+CREATE TABLE package_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    package_id INT NOT NULL REFERENCES packages(id) ON DELETE CASCADE,
+    version TEXT NOT NULL,
+    description TEXT,
+    targets TEXT,
+    tags TEXT,
+    sha256 TEXT,
+    size_bytes INT,
+    blob_path TEXT,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    status TEXT NOT NULL DEFAULT 'published'
+        CHECK (status IN ('pending', 'awaiting_approval', 'published', 'rejected')),
+    published_by INT REFERENCES users(id) ON DELETE SET NULL,
+    approved_by INT REFERENCES users(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP,
+    deprecated TEXT,
+    upstream TEXT,
+    license TEXT,
+    UNIQUE (package_id, version)
+);
+
+CREATE INDEX idx_package_versions_package_id ON package_versions(package_id);
+CREATE INDEX idx_package_versions_status ON package_versions(status);
+
+-- Any change to a version marks its package changed, for delta index sync
+CREATE TRIGGER touch_package_on_version_insert AFTER INSERT ON package_versions
+BEGIN
+    UPDATE packages SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.package_id;
+END;
+
+CREATE TRIGGER touch_package_on_version_update AFTER UPDATE ON package_versions
+BEGIN
+    UPDATE packages SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.package_id;
+END;
+
+CREATE TRIGGER touch_package_on_version_delete AFTER DELETE ON package_versions
+BEGIN
+    UPDATE packages SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = OLD.package_id;
+END;
+
+CREATE TABLE package_version_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INT NOT NULL REFERENCES package_versions(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
+    message TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    UNIQUE (version_id, name)
+);
+
+CREATE INDEX idx_package_version_checks_version_id ON package_version_checks(version_id);
+
+CREATE TABLE package_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_package_reservations_expires_at ON package_reservations(expires_at);
@@ -0,0 +1,3 @@
+-- Replacement: a deprecated version can name the package and version range to use instead
+
+ALTER TABLE package_versions ADD COLUMN replacement TEXT;
@@ -0,0 +1,5 @@
+-- Badges: registry admins mark packages as official, and record the organization
+-- whose ownership of a package they have verified
+
+ALTER TABLE packages ADD COLUMN official BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE packages ADD COLUMN verified_org TEXT;
@@ -0,0 +1,20 @@
+-- Abuse reports: users flag malicious or spam packages for admins to dismiss,
+-- yank the package, or ban its publishers
+
+CREATE TABLE package_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    package_id INT NOT NULL REFERENCES packages(id) ON DELETE CASCADE,
+    version TEXT,
+    reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('malicious', 'spam', 'impersonation', 'other')),
+    message TEXT,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'yanked', 'banned')),
+    resolved_by INT REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_package_reports_status ON package_reports(status);
+
+-- One open report per user and package
+CREATE UNIQUE INDEX one_open_report_per_reporter ON package_reports(package_id, reporter_id) WHERE status = 'open';
@@ -0,0 +1,7 @@
+-- Public profiles: optional fields users fill in about themselves, shown on
+-- their author page with the packages they publish
+
+ALTER TABLE users ADD COLUMN display_name VARCHAR(100);
+ALTER TABLE users ADD COLUMN profile_url TEXT;
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+ALTER TABLE users ADD COLUMN bio TEXT;
@@ -0,0 +1,20 @@
+-- Role permission matrix: the permissions each role grants, read when the
+-- server starts. Root is not listed because it always has every permission.
+
+CREATE TABLE role_permissions (
+    role TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('user', 'read'),
+    ('publisher', 'read'),
+    ('publisher', 'publish'),
+    ('admin', 'read'),
+    ('admin', 'publish'),
+    ('admin', 'admin'),
+    ('admin', 'yank'),
+    ('admin', 'moderate'),
+    ('admin', 'manage-org'),
+    ('admin', 'manage-webhooks');
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLite(t *testing.T) {
+	database, err := Connect(SQLiteScheme + filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer database.Close()
+
+	user, err := database.CreateUser(CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "secret123", Role: RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	other, err := database.CreateUser(CreateUserRequest{Username: "bob", Email: "bob@example.com", Password: "secret123", Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RolePublisher || !user.IsActive || user.CreatedAt.IsZero() {
+		t.Errorf("created user = %+v", user)
+	}
+
+	t.Run("sessions", func(t *testing.T) {
+		if _, err := database.CreateUserSession(user.ID, "live", time.Now().Add(time.Hour), nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.CreateUserSession(user.ID, "expired", time.Now().Add(-time.Hour), nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if found, _, err := database.ValidateUserSession("live"); err != nil || found.Username != "alice" {
+			t.Errorf("live session: %v, %v", found, err)
+		}
+		if _, _, err := database.ValidateUserSession("expired"); err == nil {
+			t.Error("expired session should not validate")
+		}
+	})
+
+	since := time.Now().Add(-time.Minute)
+	published, err := database.PublishPackageVersion("security-rules", PackageVersion{
+		Version:      "1.0.0",
+		Tags:         []string{"security", "owasp"},
+		Targets:      []string{"claude-code"},
+		PublishedBy:  &user.ID,
+		Dependencies: Dependencies{"base-rules": "^1.2"},
+	}, []string{"lint"}, func() error { return nil })
+	if err != nil {
+		t.Fatalf("PublishPackageVersion failed: %v", err)
+	}
+	if published.CreatedAt.IsZero() || len(published.Tags) != 2 || published.Dependencies["base-rules"] != "^1.2" {
+		t.Errorf("published version = %+v", published)
+	}
+
+	t.Run("duplicate versions", func(t *testing.T) {
+		_, err := database.PublishPackageVersion("security-rules", PackageVersion{Version: "1.0.0"}, nil, func() error { return nil })
+		if err != ErrVersionExists {
+			t.Errorf("err = %v, want ErrVersionExists", err)
+		}
+	})
+
+	t.Run("deprecation", func(t *testing.T) {
+		message, replacement := "Renamed", "secure-coding@^2"
+		if count, err := database.SetPackageDeprecation(published.PackageID, &message, &replacement); err != nil || count != 1 {
+			t.Fatalf("deprecate = %d, %v", count, err)
+		}
+		version, err := database.GetPackageVersion("security-rules", "1.0.0")
+		if err != nil || version.Replacement == nil || *version.Replacement != replacement {
+			t.Errorf("deprecated version = %+v, %v", version, err)
+		}
+		if err := database.SetVersionDeprecation(published.ID, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("search and lookups", func(t *testing.T) {
+		if results, err := database.SearchPackages("SECURITY", "owasp", "claude-code", false, 10); err != nil || len(results) != 1 || results[0].Dependencies["base-rules"] != "^1.2" {
+			t.Errorf("search = %v, %v", results, err)
+		}
+		if results, err := database.SearchPackages("", "style", "", false, 10); err != nil || len(results) != 0 {
+			t.Errorf("search by missing tag = %v, %v", results, err)
+		}
+		if versions, err := database.ListPublishedVersionsOf([]string{"security-rules", "other"}); err != nil || len(versions) != 1 {
+			t.Errorf("published versions = %v, %v", versions, err)
+		}
+		if checks, err := database.GetVersionChecks(published.ID); err != nil || len(checks) != 1 {
+			t.Errorf("checks = %v, %v", checks, err)
+		}
+	})
+
+	t.Run("badges", func(t *testing.T) {
+		if results, err := database.SearchPackages("", "", "", true, 10); err != nil || len(results) != 0 {
+			t.Errorf("official search before the badge = %v, %v", results, err)
+		}
+		org := "acme"
+		if err := database.SetPackageBadges(published.PackageID, true, &org); err != nil {
+			t.Fatal(err)
+		}
+		results, err := database.SearchPackages("", "", "", true, 10)
+		if err != nil || len(results) != 1 || !results[0].Official || results[0].VerifiedOrg == nil || *results[0].VerifiedOrg != org {
+			t.Errorf("official search = %+v, %v", results, err)
+		}
+		if pkg, err := database.GetPackage("security-rules"); err != nil || !pkg.Official {
+			t.Errorf("package = %+v, %v", pkg, err)
+		}
+	})
+
+	t.Run("changed packages", func(t *testing.T) {
+		names, now, err := database.ListChangedPackages(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 || now.Before(since) {
+			t.Errorf("changed = %v at %v", names, now)
+		}
+		if names, _, _ := database.ListChangedPackages(now.Add(time.Second)); len(names) != 0 {
+			t.Errorf("changed after now = %v", names)
+		}
+	})
+
+	t.Run("reservations", func(t *testing.T) {
+		reservation, err := database.ReservePackageName("style-rules", user.ID, time.Now().Add(time.Hour))
+		if err != nil || reservation.Username != "alice" {
+			t.Fatalf("reserve = %+v, %v", reservation, err)
+		}
+		if _, err := database.ReservePackageName("style-rules", other.ID, time.Now().Add(time.Hour)); err != ErrNameReserved {
+			t.Errorf("err = %v, want ErrNameReserved", err)
+		}
+		if _, err := database.GetActiveReservation("style-rules"); err != nil {
+			t.Errorf("active reservation: %v", err)
+		}
+	})
+}
@@ -74,6 +74,23 @@ type User struct {
 	IsActive     bool       `json:"is_active" db:"is_active"`
 }
 
+// UserProfile holds the optional fields a user shows about themselves on their
+// author page
+type UserProfile struct {
+	DisplayName *string `json:"display_name,omitempty" db:"display_name"`
+	URL         *string `json:"url,omitempty" db:"profile_url"`
+	AvatarURL   *string `json:"avatar_url,omitempty" db:"avatar_url"`
+	Bio         *string `json:"bio,omitempty" db:"bio"`
+}
+
+// Author is the public view of an active user, without their email or role
+type Author struct {
+	ID       int    `json:"-" db:"id"`
+	Username string `json:"username" db:"username"`
+	UserProfile
+	CreatedAt time.Time `json:"member_since" db:"created_at"`
+}
+
 // UserSession represents a user authentication session
 type UserSession struct {
 	ID        int       `json:"id" db:"id"`
@@ -84,6 +101,9 @@ type UserSession struct {
 	LastUsed  time.Time `json:"last_used" db:"last_used"`
 	UserAgent *string   `json:"user_agent" db:"user_agent"`
 	IPAddress *string   `json:"ip_address" db:"ip_address"`
+
+	// FlaggedAt is when the session was first used from an unexpected network or device
+	FlaggedAt *time.Time `json:"flagged_at,omitempty" db:"flagged_at"`
 }
 
 // CreateUserRequest represents user registration data
@@ -120,7 +140,7 @@ func (db *DB) CreateUser(req CreateUserRequest) (*User, error) {
 		RETURNING id, username, email, password_hash, role, created_at, updated_at, last_login, is_active`
 
 	var user User
-	err = db.Get(&user, query, req.Username, req.Email, string(hashedPassword), req.Role)
+	err = db.GetContext(db.context(), &user, query, req.Username, req.Email, string(hashedPassword), req.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +156,7 @@ func (db *DB) GetUserByUsername(username string) (*User, error) {
 		WHERE username = $1 AND is_active = true`
 
 	var user User
-	err := db.Get(&user, query, username)
+	err := db.GetContext(db.context(), &user, query, username)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +172,7 @@ func (db *DB) GetUserByID(id int) (*User, error) {
 		WHERE id = $1 AND is_active = true`
 
 	var user User
-	err := db.Get(&user, query, id)
+	err := db.GetContext(db.context(), &user, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -171,10 +191,10 @@ func (db *DB) CreateUserSession(userID int, tokenHash string, expiresAt time.Tim
 	query := `
 		INSERT INTO user_sessions (user_id, token_hash, expires_at, user_agent, ip_address)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, token_hash, expires_at, created_at, last_used, user_agent, ip_address`
+		RETURNING id, user_id, token_hash, expires_at, created_at, last_used, user_agent, ip_address, flagged_at`
 
 	var session UserSession
-	err := db.Get(&session, query, userID, tokenHash, expiresAt, userAgent, ipAddress)
+	err := db.GetContext(db.context(), &session, query, userID, tokenHash, expiresAt, userAgent, ipAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -186,12 +206,12 @@ func (db *DB) CreateUserSession(userID int, tokenHash string, expiresAt time.Tim
 func (db *DB) ValidateUserSession(tokenHash string) (*User, *UserSession, error) {
 	query := `
 		SELECT u.id, u.username, u.email, u.password_hash, u.role, u.created_at, u.updated_at, u.last_login, u.is_active,
-		       s.id, s.user_id, s.token_hash, s.expires_at, s.created_at, s.last_used, s.user_agent, s.ip_address
+		       s.id, s.user_id, s.token_hash, s.expires_at, s.created_at, s.last_used, s.user_agent, s.ip_address, s.flagged_at
 		FROM users u
 		JOIN user_sessions s ON u.id = s.user_id
 		WHERE s.token_hash = $1 AND s.expires_at > now() AND u.is_active = true`
 
-	rows, err := db.Query(query, tokenHash)
+	rows, err := db.QueryContext(db.context(), query, tokenHash)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -208,7 +228,7 @@ func (db *DB) ValidateUserSession(tokenHash string) (*User, *UserSession, error)
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.IsActive,
 		&session.ID, &session.UserID, &session.TokenHash, &session.ExpiresAt,
-		&session.CreatedAt, &session.LastUsed, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastUsed, &session.UserAgent, &session.IPAddress, &session.FlaggedAt,
 	)
 	if err != nil {
 		return nil, nil, err
@@ -217,17 +237,37 @@ func (db *DB) ValidateUserSession(tokenHash string) (*User, *UserSession, error)
 	return &user, &session, nil
 }
 
+// DeleteUserSession ends a session
+func (db *DB) DeleteUserSession(sessionID int) error {
+	_, err := db.ExecContext(db.context(), `DELETE FROM user_sessions WHERE id = $1`, sessionID)
+	return err
+}
+
+// FlagUserSession marks a session as used suspiciously. It reports false when
+// the session was already flagged.
+func (db *DB) FlagUserSession(sessionID int) (bool, error) {
+	result, err := db.ExecContext(db.context(), `UPDATE user_sessions SET flagged_at = now() WHERE id = $1 AND flagged_at IS NULL`, sessionID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
 // UpdateLastLogin updates the user's last login timestamp
 func (db *DB) UpdateLastLogin(userID int) error {
 	query := `UPDATE users SET last_login = now() WHERE id = $1`
-	_, err := db.Exec(query, userID)
+	_, err := db.ExecContext(db.context(), query, userID)
 	return err
 }
 
 // UpdateSessionLastUsed updates the session's last used timestamp
 func (db *DB) UpdateSessionLastUsed(sessionID int) error {
 	query := `UPDATE user_sessions SET last_used = now() WHERE id = $1`
-	_, err := db.Exec(query, sessionID)
+	_, err := db.ExecContext(db.context(), query, sessionID)
 	return err
 }
 
@@ -239,33 +279,33 @@ func (db *DB) ChangeUserPassword(userID int, newPassword string) error {
 	}
 
 	query := `UPDATE users SET password_hash = $1, updated_at = now() WHERE id = $2`
-	_, err = db.Exec(query, string(hashedPassword), userID)
+	_, err = db.ExecContext(db.context(), query, string(hashedPassword), userID)
 	return err
 }
 
 // DeleteUser soft deletes a user account
 func (db *DB) DeleteUser(userID int) error {
 	// Start transaction
-	tx, err := db.Beginx()
+	tx, err := db.BeginTxx(db.context(), nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
 	// Soft delete user
-	_, err = tx.Exec(`UPDATE users SET is_active = false, updated_at = now() WHERE id = $1`, userID)
+	_, err = tx.ExecContext(db.context(), `UPDATE users SET is_active = false, updated_at = now() WHERE id = $1`, userID)
 	if err != nil {
 		return err
 	}
 
 	// Delete all user sessions
-	_, err = tx.Exec(`DELETE FROM user_sessions WHERE user_id = $1`, userID)
+	_, err = tx.ExecContext(db.context(), `DELETE FROM user_sessions WHERE user_id = $1`, userID)
 	if err != nil {
 		return err
 	}
 
 	// Delete old API tokens
-	_, err = tx.Exec(`DELETE FROM tokens WHERE user_id = $1`, userID)
+	_, err = tx.ExecContext(db.context(), `DELETE FROM tokens WHERE user_id = $1`, userID)
 	if err != nil {
 		return err
 	}
@@ -273,10 +313,44 @@ func (db *DB) DeleteUser(userID int) error {
 	return tx.Commit()
 }
 
+// GetUserProfile returns a user's profile fields
+func (db *DB) GetUserProfile(userID int) (*UserProfile, error) {
+	var profile UserProfile
+	err := db.GetContext(db.context(), &profile, `SELECT display_name, profile_url, avatar_url, bio FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateUserProfile replaces a user's profile fields; nil fields are cleared
+func (db *DB) UpdateUserProfile(userID int, profile UserProfile) error {
+	query := `
+		UPDATE users
+		SET display_name = $2, profile_url = $3, avatar_url = $4, bio = $5, updated_at = now()
+		WHERE id = $1`
+	_, err := db.ExecContext(db.context(), query, userID, profile.DisplayName, profile.URL, profile.AvatarURL, profile.Bio)
+	return err
+}
+
+// GetAuthor returns the public profile of an active user, or sql.ErrNoRows
+func (db *DB) GetAuthor(username string) (*Author, error) {
+	query := `
+		SELECT id, username, display_name, profile_url, avatar_url, bio, created_at
+		FROM users
+		WHERE username = $1 AND is_active = true`
+
+	var author Author
+	if err := db.GetContext(db.context(), &author, query, username); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
 // CleanupExpiredSessions removes expired sessions from the database
 func (db *DB) CleanupExpiredSessions() error {
 	query := `DELETE FROM user_sessions WHERE expires_at <= now()`
-	_, err := db.Exec(query)
+	_, err := db.ExecContext(db.context(), query)
 	return err
 }
 
@@ -290,25 +364,11 @@ func (db *DB) ListUsers(limit, offset int) ([]User, error) {
 		LIMIT $1 OFFSET $2`
 
 	var users []User
-	err := db.Select(&users, query, limit, offset)
+	err := db.SelectContext(db.context(), &users, query, limit, offset)
 	return users, err
 }
 
-// HasPermission checks if a user role has permission for a specific action
-func (r UserRole) HasPermission(action string) bool {
-	// Root has access to everything
-	if r == RoleRoot {
-		return true
-	}
-
-	switch action {
-	case "read":
-		return r == RoleUser || r == RolePublisher || r == RoleAdmin
-	case "publish":
-		return r == RolePublisher || r == RoleAdmin
-	case "admin":
-		return r == RoleAdmin
-	default:
-		return false
-	}
+// HasPermission checks if a user role has a permission in the active permission matrix
+func (r UserRole) HasPermission(permission Permission) bool {
+	return activePermissions.Load().Allows(r, permission)
 }
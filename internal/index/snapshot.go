@@ -0,0 +1,214 @@
+// Package index keeps a local snapshot of a registry's package index, synced
+// incrementally, for offline search, shell completions and outdated checks.
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rulestack/internal/client"
+	"rulestack/internal/config"
+)
+
+// Snapshot is the locally stored index of one registry
+type Snapshot struct {
+	Registry string                         `json:"registry"`
+	URL      string                         `json:"url"`
+	Cursor   string                         `json:"cursor,omitempty"` // Sync cursor returned by the registry
+	SyncedAt time.Time                      `json:"synced_at"`
+	Packages map[string]client.IndexPackage `json:"packages"`
+
+	path string
+}
+
+// Path returns where the snapshot of a registry is stored
+func Path(registryName string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index", registryName+".json"), nil
+}
+
+// Load reads the snapshot of a registry. A missing or unreadable snapshot, or one
+// taken from a different URL, yields an empty snapshot that the next sync fills in full.
+func Load(registryName, url string) (*Snapshot, error) {
+	path, err := Path(registryName)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := &Snapshot{Registry: registryName, URL: url, Packages: make(map[string]client.IndexPackage), path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return empty, nil
+		}
+		return nil, fmt.Errorf("failed to read index snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.URL != url {
+		return empty, nil
+	}
+	if snapshot.Packages == nil {
+		snapshot.Packages = make(map[string]client.IndexPackage)
+	}
+	snapshot.path = path
+
+	return &snapshot, nil
+}
+
+// Synced reports whether the snapshot has been synced at least once
+func (s *Snapshot) Synced() bool {
+	return !s.SyncedAt.IsZero()
+}
+
+// Apply merges a sync result into the snapshot
+func (s *Snapshot) Apply(delta *client.IndexDelta, now time.Time) {
+	if delta.Full {
+		s.Packages = make(map[string]client.IndexPackage, len(delta.Packages))
+	}
+
+	for _, pkg := range delta.Packages {
+		if len(pkg.Versions) == 0 {
+			delete(s.Packages, pkg.Name)
+			continue
+		}
+		s.Packages[pkg.Name] = pkg
+	}
+
+	s.Cursor = delta.Cursor
+	s.SyncedAt = now
+}
+
+// Save writes the snapshot, replacing the previous one atomically
+func (s *Snapshot) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode index snapshot: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Sync fetches the changes since the last sync from the registry and saves the
+// snapshot. It returns the number of package entries the registry sent.
+func Sync(ctx context.Context, c client.RegistryClient, s *Snapshot) (int, error) {
+	delta, err := c.GetIndex(ctx, s.Cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	s.Apply(delta, time.Now())
+	if err := s.Save(); err != nil {
+		return 0, err
+	}
+
+	return len(delta.Packages), nil
+}
+
+// Search matches packages like the registry's search: the query against name and
+// description, tag and target exactly. Results are sorted by name.
+func (s *Snapshot) Search(opts client.SearchOptions) []client.Package {
+	query := strings.ToLower(opts.Query)
+
+	var results []client.Package
+	for _, name := range s.Names() {
+		pkg := s.Packages[name]
+		if query != "" && !strings.Contains(strings.ToLower(pkg.Name), query) &&
+			!strings.Contains(strings.ToLower(pkg.Description), query) {
+			continue
+		}
+		if opts.Tag != "" && !contains(pkg.Tags, opts.Tag) {
+			continue
+		}
+		if opts.Target != "" && !contains(pkg.Targets, opts.Target) {
+			continue
+		}
+
+		result := client.Package{
+			Name:        pkg.Name,
+			Description: pkg.Description,
+			Latest:      pkg.Latest,
+			Tags:        pkg.Tags,
+		}
+		for _, v := range pkg.Versions {
+			result.Versions = append(result.Versions, v.Version)
+			if v.PublishedAt.After(result.UpdatedAt) {
+				result.UpdatedAt = v.PublishedAt
+			}
+		}
+		results = append(results, result)
+
+		if opts.Limit > 0 && len(results) == opts.Limit {
+			break
+		}
+	}
+
+	return results
+}
+
+// Lookup returns the snapshot's metadata for one package version, in the same shape
+// as a bulk lookup. Found is false when the version is not in the snapshot.
+func (s *Snapshot) Lookup(ref client.VersionRef) client.VersionMetadata {
+	result := client.VersionMetadata{Name: ref.Name, Version: ref.Version}
+
+	pkg, ok := s.Packages[ref.Name]
+	if !ok {
+		return result
+	}
+	result.Latest = pkg.Latest
+
+	for _, v := range pkg.Versions {
+		if v.Version == ref.Version {
+			result.Found = true
+			result.SHA256 = v.SHA256
+			result.Integrity = v.Integrity
+			result.Size = v.Size
+			result.Deprecated = v.Deprecated
+			result.Replacement = v.Replacement
+		}
+	}
+
+	return result
+}
+
+// Names returns the package names in the snapshot in order
+func (s *Snapshot) Names() []string {
+	names := make([]string, 0, len(s.Packages))
+	for name := range s.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	"rulestack/internal/client"
+)
+
+func pkg(name, description string, tags []string, versions ...string) client.IndexPackage {
+	entry := client.IndexPackage{Name: name, Description: description, Tags: tags, Versions: []client.IndexVersion{}}
+	for _, v := range versions {
+		entry.Versions = append(entry.Versions, client.IndexVersion{Version: v, SHA256: "sha-" + name + "-" + v})
+		entry.Latest = v
+	}
+	return entry
+}
+
+func TestSnapshotApply(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+
+	snapshot, err := Load("corp", "https://rules.example.com")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if snapshot.Synced() {
+		t.Fatal("expected a new snapshot to be unsynced")
+	}
+
+	snapshot.Apply(&client.IndexDelta{Cursor: "c1", Full: true, Packages: []client.IndexPackage{
+		pkg("security-rules", "Secure coding", nil, "1.0.0"),
+		pkg("logging-rules", "Logging", nil, "2.0.0"),
+	}}, time.Now())
+
+	snapshot.Apply(&client.IndexDelta{Cursor: "c2", Packages: []client.IndexPackage{
+		pkg("security-rules", "Secure coding", nil, "1.0.0", "1.1.0"),
+		pkg("logging-rules", "Logging", nil),
+		pkg("api-rules", "API design", nil, "0.1.0"),
+	}}, time.Now())
+
+	if got := snapshot.Names(); len(got) != 2 || got[0] != "api-rules" || got[1] != "security-rules" {
+		t.Errorf("expected api-rules and security-rules after the delta, got %v", got)
+	}
+	if snapshot.Packages["security-rules"].Latest != "1.1.0" {
+		t.Errorf("expected security-rules to be replaced by the delta entry")
+	}
+	if snapshot.Cursor != "c2" {
+		t.Errorf("expected cursor c2, got %q", snapshot.Cursor)
+	}
+
+	// A full sync replaces everything
+	snapshot.Apply(&client.IndexDelta{Cursor: "c3", Full: true, Packages: []client.IndexPackage{
+		pkg("other-rules", "", nil, "1.0.0"),
+	}}, time.Now())
+	if got := snapshot.Names(); len(got) != 1 || got[0] != "other-rules" {
+		t.Errorf("expected a full sync to replace the snapshot, got %v", got)
+	}
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	t.Setenv("RFH_CONFIG", t.TempDir())
+
+	snapshot, _ := Load("corp", "https://rules.example.com")
+	snapshot.Apply(&client.IndexDelta{Cursor: "c1", Full: true, Packages: []client.IndexPackage{
+		pkg("security-rules", "", nil, "1.0.0"),
+	}}, time.Now())
+	if err := snapshot.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load("corp", "https://rules.example.com")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !loaded.Synced() || loaded.Cursor != "c1" || len(loaded.Packages) != 1 {
+		t.Errorf("expected saved snapshot to load back, got cursor %q with %d packages", loaded.Cursor, len(loaded.Packages))
+	}
+
+	moved, err := Load("corp", "https://new.example.com")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if moved.Synced() || moved.Cursor != "" || len(moved.Packages) != 0 {
+		t.Error("expected a snapshot of a different registry URL to be discarded")
+	}
+}
+
+func TestSnapshotSearch(t *testing.T) {
+	snapshot := &Snapshot{Packages: map[string]client.IndexPackage{}}
+	for _, p := range []client.IndexPackage{
+		pkg("security-rules", "Secure coding guidelines", []string{"security"}, "1.0.0", "1.1.0"),
+		pkg("logging-rules", "Structured logging", []string{"observability"}, "2.0.0"),
+		pkg("secrets-scan", "Find leaked credentials", []string{"security"}, "0.1.0"),
+	} {
+		snapshot.Packages[p.Name] = p
+	}
+
+	tests := []struct {
+		name     string
+		opts     client.SearchOptions
+		expected []string
+	}{
+		{"matches name", client.SearchOptions{Query: "SECURITY"}, []string{"security-rules"}},
+		{"matches description", client.SearchOptions{Query: "logging"}, []string{"logging-rules"}},
+		{"tag filter", client.SearchOptions{Tag: "security"}, []string{"secrets-scan", "security-rules"}},
+		{"limit", client.SearchOptions{Tag: "security", Limit: 1}, []string{"secrets-scan"}},
+		{"no match", client.SearchOptions{Query: "react"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, result := range snapshot.Search(tt.opts) {
+				got = append(got, result.Name)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+
+	if results := snapshot.Search(client.SearchOptions{Query: "security-rules"}); len(results[0].Versions) != 2 {
+		t.Errorf("expected search results to list every version, got %v", results[0].Versions)
+	}
+}
+
+func TestSnapshotLookup(t *testing.T) {
+	entry := pkg("security-rules", "", nil, "1.0.0", "1.1.0")
+	entry.Versions[0].Deprecated = "Use 1.1.0"
+	snapshot := &Snapshot{Packages: map[string]client.IndexPackage{"security-rules": entry}}
+
+	got := snapshot.Lookup(client.VersionRef{Name: "security-rules", Version: "1.0.0"})
+	if !got.Found || got.Latest != "1.1.0" || got.Deprecated != "Use 1.1.0" || got.SHA256 != "sha-security-rules-1.0.0" {
+		t.Errorf("unexpected lookup result %+v", got)
+	}
+
+	if got := snapshot.Lookup(client.VersionRef{Name: "security-rules", Version: "9.0.0"}); got.Found || got.Latest != "1.1.0" {
+		t.Errorf("expected unknown version to be not found with latest set, got %+v", got)
+	}
+	if got := snapshot.Lookup(client.VersionRef{Name: "missing", Version: "1.0.0"}); got.Found || got.Latest != "" {
+		t.Errorf("expected unknown package to be not found, got %+v", got)
+	}
+}
@@ -0,0 +1,268 @@
+// Package integrity computes and checks the digests that identify package
+// archives. Digests are written as in Subresource Integrity: the algorithm, a
+// dash and the base64 digest, with several digests of the same content separated
+// by spaces, as in "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= sha512-...".
+package integrity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zeebo/blake3"
+)
+
+// Digest algorithms
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+	BLAKE3 = "blake3"
+)
+
+// Algorithms lists every supported algorithm, least preferred first. BLAKE3's
+// 256-bit digest resists collisions no better than SHA-256, so SHA-512 is
+// preferred over it.
+var Algorithms = []string{SHA256, BLAKE3, SHA512}
+
+var ErrMismatch = errors.New("integrity mismatch")
+
+// Digest is one algorithm's hash of some content
+type Digest struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// String formats the digest as algorithm-base64
+func (d Digest) String() string {
+	return d.Algorithm + "-" + base64.StdEncoding.EncodeToString(d.Sum)
+}
+
+// Hex returns the digest in hex, as SHA-256 digests are written in blob URLs
+func (d Digest) Hex() string {
+	return hex.EncodeToString(d.Sum)
+}
+
+// Integrity lists digests of the same content, at most one per algorithm
+type Integrity []Digest
+
+// Parse reads an integrity string. Digests in algorithms this version does not
+// know are skipped, so registries can add algorithms without breaking older
+// clients. A bare hex SHA-256, as recorded before integrity strings, is read as
+// a sha256 digest.
+func Parse(s string) (Integrity, error) {
+	var result Integrity
+	for _, token := range strings.Fields(s) {
+		// Options after '?' are allowed by Subresource Integrity and ignored
+		token, _, _ = strings.Cut(token, "?")
+
+		algorithm, encoded, found := strings.Cut(token, "-")
+		if !found {
+			sum, err := hex.DecodeString(token)
+			if err != nil || len(sum) != sha256.Size {
+				return nil, fmt.Errorf("invalid integrity %q: expected algorithm-base64", token)
+			}
+			result = result.with(Digest{Algorithm: SHA256, Sum: sum})
+			continue
+		}
+
+		algorithm = strings.ToLower(algorithm)
+		size, known := digestSize(algorithm)
+		if !known {
+			continue
+		}
+		sum, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(sum) != size {
+			return nil, fmt.Errorf("invalid %s digest %q", algorithm, encoded)
+		}
+		result = result.with(Digest{Algorithm: algorithm, Sum: sum})
+	}
+	return result, nil
+}
+
+// Expect returns the digests a registry recorded for an archive: its integrity
+// string, or only the hex SHA-256 for registries and lock files that predate them
+func Expect(integrity, sha256Hex string) (Integrity, error) {
+	parsed, err := Parse(integrity)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) > 0 || sha256Hex == "" {
+		return parsed, nil
+	}
+	return Parse(sha256Hex)
+}
+
+// String formats the digests, separated by spaces
+func (i Integrity) String() string {
+	parts := make([]string, len(i))
+	for n, digest := range i {
+		parts[n] = digest.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// Get returns the digest in an algorithm
+func (i Integrity) Get(algorithm string) (Digest, bool) {
+	for _, digest := range i {
+		if digest.Algorithm == algorithm {
+			return digest, true
+		}
+	}
+	return Digest{}, false
+}
+
+// Preferred returns the digest in the most preferred algorithm
+func (i Integrity) Preferred() (Digest, bool) {
+	for n := len(Algorithms) - 1; n >= 0; n-- {
+		if digest, ok := i.Get(Algorithms[n]); ok {
+			return digest, true
+		}
+	}
+	return Digest{}, false
+}
+
+// Check compares the expected digests with those computed for the content.
+// Every algorithm in both must match, and at least one must be in both.
+func (i Integrity) Check(actual Integrity) error {
+	compared := 0
+	for _, expected := range i {
+		digest, ok := actual.Get(expected.Algorithm)
+		if !ok {
+			continue
+		}
+		if !bytes.Equal(digest.Sum, expected.Sum) {
+			return fmt.Errorf("%w: expected %s, got %s", ErrMismatch, expected, digest)
+		}
+		compared++
+	}
+	if compared == 0 {
+		return fmt.Errorf("%w: no digest in a common algorithm", ErrMismatch)
+	}
+	return nil
+}
+
+// algorithms lists the algorithms of the digests
+func (i Integrity) algorithms() []string {
+	algorithms := make([]string, len(i))
+	for n, digest := range i {
+		algorithms[n] = digest.Algorithm
+	}
+	return algorithms
+}
+
+func (i Integrity) with(digest Digest) Integrity {
+	for n := range i {
+		if i[n].Algorithm == digest.Algorithm {
+			i[n] = digest
+			return i
+		}
+	}
+	return append(i, digest)
+}
+
+// Hasher computes digests in several algorithms in one pass
+type Hasher struct {
+	algorithms []string
+	hashes     []hash.Hash
+	writer     io.Writer
+}
+
+// NewHasher hashes with the given algorithms, or with every supported one when
+// none are given. Unknown algorithms are ignored.
+func NewHasher(algorithms ...string) *Hasher {
+	if len(algorithms) == 0 {
+		algorithms = Algorithms
+	}
+
+	h := &Hasher{}
+	writers := []io.Writer{}
+	for _, algorithm := range algorithms {
+		hasher := newHash(algorithm)
+		if hasher == nil {
+			continue
+		}
+		h.algorithms = append(h.algorithms, algorithm)
+		h.hashes = append(h.hashes, hasher)
+		writers = append(writers, hasher)
+	}
+	h.writer = io.MultiWriter(writers...)
+	return h
+}
+
+func (h *Hasher) Write(p []byte) (int, error) {
+	return h.writer.Write(p)
+}
+
+// Integrity returns the digests of everything written so far
+func (h *Hasher) Integrity() Integrity {
+	result := make(Integrity, len(h.hashes))
+	for n, hasher := range h.hashes {
+		result[n] = Digest{Algorithm: h.algorithms[n], Sum: hasher.Sum(nil)}
+	}
+	return result
+}
+
+// SHA256 returns the hex SHA-256 of everything written so far, or "" if the
+// hasher does not compute it
+func (h *Hasher) SHA256() string {
+	digest, ok := h.Integrity().Get(SHA256)
+	if !ok {
+		return ""
+	}
+	return digest.Hex()
+}
+
+// File hashes a file with the given algorithms, or with every supported one
+func File(path string, algorithms ...string) (Integrity, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hasher := NewHasher(algorithms...)
+	if _, err := io.Copy(hasher, file); err != nil {
+		return nil, err
+	}
+	return hasher.Integrity(), nil
+}
+
+// CheckFile checks a file against the expected digests
+func CheckFile(path string, expected Integrity) error {
+	if len(expected) == 0 {
+		return fmt.Errorf("%w: no digest to check against", ErrMismatch)
+	}
+	actual, err := File(path, expected.algorithms()...)
+	if err != nil {
+		return err
+	}
+	return expected.Check(actual)
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case SHA256:
+		return sha256.New()
+	case SHA512:
+		return sha512.New()
+	case BLAKE3:
+		return blake3.New()
+	}
+	return nil
+}
+
+func digestSize(algorithm string) (int, bool) {
+	hasher := newHash(algorithm)
+	if hasher == nil {
+		return 0, false
+	}
+	return hasher.Size(), true
+}
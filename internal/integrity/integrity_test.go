@@ -0,0 +1,84 @@
+package integrity
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntegrity(t *testing.T) {
+	hasher := NewHasher()
+	hasher.Write([]byte("hello"))
+	computed := hasher.Integrity()
+
+	if got := hasher.SHA256(); got != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("SHA256() = %s", got)
+	}
+	if preferred, _ := computed.Preferred(); preferred.Algorithm != SHA512 {
+		t.Errorf("preferred algorithm = %s, want %s", preferred.Algorithm, SHA512)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		parsed, err := Parse(computed.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != computed.String() {
+			t.Errorf("parsed %s, want %s", parsed, computed)
+		}
+		if err := parsed.Check(computed); err != nil {
+			t.Errorf("Check: %v", err)
+		}
+	})
+
+	t.Run("legacy and unknown digests", func(t *testing.T) {
+		parsed, err := Parse("sha384-abc?opt " + hasher.SHA256())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(parsed) != 1 || parsed[0].Algorithm != SHA256 {
+			t.Errorf("parsed = %s", parsed)
+		}
+
+		expected, err := Expect("", hasher.SHA256())
+		if err != nil || expected.Check(computed) != nil {
+			t.Errorf("Expect from sha256 = %s, %v", expected, err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{"sha512-short", "sha256-" + hasher.SHA256(), "abc123"} {
+			if _, err := Parse(input); err == nil {
+				t.Errorf("Parse(%q) should fail", input)
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		other := NewHasher(BLAKE3)
+		other.Write([]byte("goodbye"))
+		if err := computed.Check(other.Integrity()); !errors.Is(err, ErrMismatch) {
+			t.Errorf("err = %v, want ErrMismatch", err)
+		}
+
+		sha512Only := NewHasher(SHA512)
+		if err := sha512Only.Integrity().Check(NewHasher(SHA256).Integrity()); !errors.Is(err, ErrMismatch) {
+			t.Errorf("no common algorithm: err = %v", err)
+		}
+	})
+
+	t.Run("files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "archive.tgz")
+		os.WriteFile(path, []byte("hello"), 0o644)
+
+		blake3Only, _ := computed.Get(BLAKE3)
+		if err := CheckFile(path, Integrity{blake3Only}); err != nil {
+			t.Errorf("CheckFile: %v", err)
+		}
+		os.WriteFile(path, []byte("tampered"), 0o644)
+		if err := CheckFile(path, computed); !errors.Is(err, ErrMismatch) {
+			t.Errorf("tampered file: err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"rulestack/internal/version"
+)
+
+// BaseConfigFile is the file a base configuration package ships its settings in
+const BaseConfigFile = "rulestack.base.json"
+
+// BaseConfig holds the project settings a base configuration package provides to
+// the projects that extend it
+type BaseConfig struct {
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	Targets      []string          `json:"targets,omitempty"`
+}
+
+// LoadBaseConfig reads and validates a base configuration file
+func LoadBaseConfig(path string) (*BaseConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base configuration: %w", err)
+	}
+
+	var base BaseConfig
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse base configuration JSON: %w", err)
+	}
+
+	for name := range base.Dependencies {
+		if !nameRegex.MatchString(name) {
+			return nil, fmt.Errorf("%w: base dependency '%s' is not a valid package name", ErrInvalidName, name)
+		}
+	}
+	for _, target := range base.Targets {
+		if !validTargets[target] {
+			return nil, fmt.Errorf("%w: invalid target '%s' in base configuration", ErrInvalidManifest, target)
+		}
+	}
+
+	return &base, nil
+}
+
+// ParseExtends splits an "extends" value of the form "name@range" into the package
+// name and version range
+func ParseExtends(extends string) (string, *version.Range, error) {
+	name, rangeStr, found := strings.Cut(extends, "@")
+	if !found || !nameRegex.MatchString(name) {
+		return "", nil, fmt.Errorf("%w: extends must be name@version or name@^major, got '%s'", ErrInvalidName, extends)
+	}
+
+	versionRange, err := version.ParseRange(rangeStr)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: extends '%s': %v", ErrInvalidVersion, extends, err)
+	}
+
+	return name, versionRange, nil
+}
+
+// ParseReplacement splits the replacement named by a deprecation, "name@range"
+// like an extends value, into the package name and version range
+func ParseReplacement(replacement string) (string, *version.Range, error) {
+	name, rangeStr, found := strings.Cut(replacement, "@")
+	if !found || !nameRegex.MatchString(name) {
+		return "", nil, fmt.Errorf("%w: replacement must be name@version or name@^major, got '%s'", ErrInvalidName, replacement)
+	}
+
+	versionRange, err := version.ParseRange(rangeStr)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: replacement '%s': %v", ErrInvalidVersion, replacement, err)
+	}
+
+	return name, versionRange, nil
+}
+
+// Merge applies a base configuration to the project manifest in memory. The
+// project's own dependencies win over the base's; targets are combined.
+func (pm *ProjectManifest) Merge(base *BaseConfig) {
+	pm.Inherit("", base.Dependencies)
+
+	for _, target := range base.Targets {
+		if !slices.Contains(pm.Targets, target) {
+			pm.Targets = append(pm.Targets, target)
+		}
+	}
+}
+
+// Inherit adds dependencies the project does not declare itself in memory: those
+// of the meta-package requiredBy, or of the base configuration when requiredBy is
+// empty. It returns the names it added, in order.
+func (pm *ProjectManifest) Inherit(requiredBy string, dependencies map[string]string) []string {
+	var added []string
+	for name, ver := range dependencies {
+		if _, exists := pm.Dependencies[name]; exists {
+			continue
+		}
+		if pm.inherited == nil {
+			pm.inherited = make(map[string]string)
+		}
+		pm.Dependencies[name] = ver
+		pm.inherited[name] = requiredBy
+		added = append(added, name)
+	}
+	slices.Sort(added)
+	return added
+}
+
+// IsInherited reports whether a dependency came from the base configuration or
+// a meta-package rather than the project's own rulestack.json
+func (pm *ProjectManifest) IsInherited(name string) bool {
+	_, inherited := pm.inherited[name]
+	return inherited
+}
+
+// RequiredBy returns the meta-package that brought in an inherited dependency,
+// or "" for the project's own dependencies and those of the base configuration
+func (pm *ProjectManifest) RequiredBy(name string) string {
+	return pm.inherited[name]
+}
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseExtends(t *testing.T) {
+	name, versionRange, err := ParseExtends("org-base-config@^2")
+	if err != nil {
+		t.Fatalf("ParseExtends failed: %v", err)
+	}
+	if name != "org-base-config" || versionRange.IsExact() {
+		t.Errorf("expected org-base-config with a range, got %s (exact %v)", name, versionRange.IsExact())
+	}
+
+	for _, invalid := range []string{"org-base-config", "org-base-config@latest", "Org Base@1.0.0"} {
+		if _, _, err := ParseExtends(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+
+	pm := &ProjectManifest{Version: "1.0.0", Dependencies: map[string]string{}, Extends: "org-base-config@~2"}
+	if err := pm.Validate(); err == nil {
+		t.Error("expected an invalid extends range to fail validation")
+	}
+}
+
+func TestMergeBaseConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BaseConfigFile)
+	content := `{"dependencies": {"security-rules": "1.0.0", "logging-rules": "2.0.0"}, "targets": ["claude-code", "cursor"]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write base config: %v", err)
+	}
+
+	base, err := LoadBaseConfig(path)
+	if err != nil {
+		t.Fatalf("LoadBaseConfig failed: %v", err)
+	}
+
+	pm := &ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"security-rules": "1.2.0"},
+		Targets:      []string{"cursor"},
+	}
+	pm.Merge(base)
+
+	if pm.Dependencies["security-rules"] != "1.2.0" || pm.IsInherited("security-rules") {
+		t.Errorf("expected the project's own dependency to win, got %v", pm.Dependencies)
+	}
+	if pm.Dependencies["logging-rules"] != "2.0.0" || !pm.IsInherited("logging-rules") {
+		t.Errorf("expected logging-rules to be inherited from the base, got %v", pm.Dependencies)
+	}
+	if len(pm.Targets) != 2 || pm.Targets[0] != "cursor" || pm.Targets[1] != "claude-code" {
+		t.Errorf("expected combined targets [cursor claude-code], got %v", pm.Targets)
+	}
+}
+
+func TestInheritMetaDependencies(t *testing.T) {
+	pm := &ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"backend-profile": "1.0.0", "security-rules": "1.2.0"},
+	}
+
+	added := pm.Inherit("backend-profile", map[string]string{"security-rules": "1.0.0", "logging-rules": "2.0.0", "api-rules": "1.1.0"})
+	if len(added) != 2 || added[0] != "api-rules" || added[1] != "logging-rules" {
+		t.Errorf("expected only the undeclared dependencies added, got %v", added)
+	}
+	if pm.Dependencies["security-rules"] != "1.2.0" || pm.RequiredBy("security-rules") != "" {
+		t.Errorf("expected the project's own dependency to win, got %v", pm.Dependencies)
+	}
+	if !pm.IsInherited("logging-rules") || pm.RequiredBy("logging-rules") != "backend-profile" {
+		t.Errorf("expected logging-rules to be required by backend-profile, got %q", pm.RequiredBy("logging-rules"))
+	}
+
+	if added := pm.Inherit("backend-profile", map[string]string{"logging-rules": "2.0.0"}); len(added) != 0 {
+		t.Errorf("expected nothing added a second time, got %v", added)
+	}
+}
@@ -0,0 +1,107 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rulestack/internal/version"
+)
+
+// Requirements declares what a package needs from the project it is installed
+// into, from the package manifest's "requires" field
+type Requirements struct {
+	Targets []string          `json:"targets,omitempty"` // The project must use at least one of these targets
+	Core    string            `json:"core,omitempty"`    // Lowest acceptable core rules version
+	Peers   map[string]string `json:"peers,omitempty"`   // Packages that must also be installed, with their lowest acceptable version
+}
+
+// Environment describes a project for checking package requirements against
+type Environment struct {
+	Targets  []string          // Targets from the project manifest; none means target requirements are not checked
+	Core     string            // Installed core rules version, empty when none is installed
+	Packages map[string]string // Versions of the project's other packages by name
+}
+
+var ErrIncompatible = errors.New("incompatible package")
+
+// Validate checks that the requirements are well formed
+func (r *Requirements) Validate() error {
+	for _, target := range r.Targets {
+		if !validTargets[target] {
+			return fmt.Errorf("%w: requires invalid target '%s'", ErrInvalidManifest, target)
+		}
+	}
+
+	if r.Core != "" && !versionRegex.MatchString(r.Core) {
+		return fmt.Errorf("%w: required core version must be semantic version (x.y.z)", ErrInvalidVersion)
+	}
+
+	for name, minVersion := range r.Peers {
+		if !nameRegex.MatchString(name) {
+			return fmt.Errorf("%w: required peer '%s' is not a valid package name", ErrInvalidName, name)
+		}
+		if !versionRegex.MatchString(minVersion) {
+			return fmt.Errorf("%w: required version of peer '%s' must be semantic version (x.y.z)", ErrInvalidVersion, name)
+		}
+	}
+
+	return nil
+}
+
+// Check verifies the requirements against a project and returns every unmet one,
+// each saying what to change in the project to meet it
+func (r *Requirements) Check(env Environment) []error {
+	var problems []error
+
+	if len(r.Targets) > 0 && len(env.Targets) > 0 && !sharesTarget(r.Targets, env.Targets) {
+		problems = append(problems, fmt.Errorf("%w: requires target %s, but the project targets %s; add one to \"targets\" in rulestack.json",
+			ErrIncompatible, strings.Join(r.Targets, " or "), strings.Join(env.Targets, ", ")))
+	}
+
+	if r.Core != "" {
+		switch cmp, err := version.CompareVersions(env.Core, r.Core); {
+		case env.Core == "":
+			problems = append(problems, fmt.Errorf("%w: requires core rules >= %s, but none are installed; run 'rfh init'", ErrIncompatible, r.Core))
+		case err != nil || cmp < 0:
+			problems = append(problems, fmt.Errorf("%w: requires core rules >= %s, but %s is installed; upgrade the core rules in .rulestack/", ErrIncompatible, r.Core, env.Core))
+		}
+	}
+
+	peers := make([]string, 0, len(r.Peers))
+	for name := range r.Peers {
+		peers = append(peers, name)
+	}
+	sort.Strings(peers)
+
+	for _, name := range peers {
+		minVersion := r.Peers[name]
+		installed, ok := env.Packages[name]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%w: requires %s >= %s; run 'rfh add %s@%s'", ErrIncompatible, name, minVersion, name, minVersion))
+			continue
+		}
+		// Peers built from source have no registry version to compare
+		if !versionRegex.MatchString(installed) {
+			continue
+		}
+		if cmp, err := version.CompareVersions(installed, minVersion); err != nil || cmp < 0 {
+			problems = append(problems, fmt.Errorf("%w: requires %s >= %s, but the project uses %s; update it in rulestack.json", ErrIncompatible, name, minVersion, installed))
+		}
+	}
+
+	return problems
+}
+
+// sharesTarget reports whether the two target lists have a target in common
+func sharesTarget(required, project []string) bool {
+	for _, target := range required {
+		for _, projectTarget := range project {
+			if target == projectTarget {
+				return true
+			}
+		}
+	}
+	return false
+}
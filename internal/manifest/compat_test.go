@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRequirementsCheck(t *testing.T) {
+	requires := &Requirements{
+		Targets: []string{"claude-code"},
+		Core:    "1.1.0",
+		Peers:   map[string]string{"logging-rules": "1.1.0"},
+	}
+
+	tests := []struct {
+		name     string
+		env      Environment
+		expected []string
+	}{
+		{
+			name:     "compatible",
+			env:      Environment{Targets: []string{"cursor", "claude-code"}, Core: "1.2.0", Packages: map[string]string{"logging-rules": "1.1.0"}},
+			expected: nil,
+		},
+		{
+			name:     "project without targets",
+			env:      Environment{Core: "1.1.0", Packages: map[string]string{"logging-rules": "file:../logging"}},
+			expected: nil,
+		},
+		{
+			name:     "wrong target",
+			env:      Environment{Targets: []string{"cursor"}, Core: "1.1.0", Packages: map[string]string{"logging-rules": "2.0.0"}},
+			expected: []string{"requires target claude-code"},
+		},
+		{
+			name:     "old core and peer",
+			env:      Environment{Core: "1.0.0", Packages: map[string]string{"logging-rules": "1.0.5"}},
+			expected: []string{"requires core rules >= 1.1.0, but 1.0.0", "requires logging-rules >= 1.1.0, but the project uses 1.0.5"},
+		},
+		{
+			name:     "nothing installed",
+			env:      Environment{},
+			expected: []string{"none are installed", "rfh add logging-rules@1.1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := requires.Check(tt.env)
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("expected %d problems, got %v", len(tt.expected), problems)
+			}
+			for i, problem := range problems {
+				if !errors.Is(problem, ErrIncompatible) || !strings.Contains(problem.Error(), tt.expected[i]) {
+					t.Errorf("expected problem containing %q, got %v", tt.expected[i], problem)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirementsValidate(t *testing.T) {
+	invalid := []*Requirements{
+		{Targets: []string{"emacs"}},
+		{Core: ">=1.1.0"},
+		{Peers: map[string]string{"logging-rules": "latest"}},
+	}
+
+	for _, requires := range invalid {
+		if err := requires.Validate(); err == nil {
+			t.Errorf("expected %+v to fail validation", requires)
+		}
+	}
+
+	pm := CreateSamplePackageManifest()
+	pm.Requires = &Requirements{Targets: []string{"copilot"}, Core: "1.0.0"}
+	if err := pm.Validate(); err != nil {
+		t.Errorf("expected valid requirements to pass package validation, got %v", err)
+	}
+}
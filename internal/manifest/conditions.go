@@ -0,0 +1,77 @@
+package manifest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Condition limits when a dependency is installed, from the project manifest's
+// "conditions" field. Every field set must match; an empty field matches anything.
+type Condition struct {
+	Targets []string `json:"targets,omitempty"` // Only when the project targets at least one of these
+	OS      []string `json:"os,omitempty"`      // Only on one of these operating systems: windows, macos or linux
+}
+
+// validOS lists the operating systems conditions can name, by GOOS
+var validOS = map[string]string{
+	"windows": "windows",
+	"macos":   "darwin",
+	"linux":   "linux",
+}
+
+// currentOS is the GOOS conditions are evaluated against
+var currentOS = runtime.GOOS
+
+// Validate checks that the condition is well formed
+func (c *Condition) Validate(name string) error {
+	if len(c.Targets) == 0 && len(c.OS) == 0 {
+		return fmt.Errorf("%w: condition for '%s' must set targets or os", ErrInvalidManifest, name)
+	}
+	for _, target := range c.Targets {
+		if !validTargets[target] {
+			return fmt.Errorf("%w: condition for '%s' has invalid target '%s'", ErrInvalidManifest, name, target)
+		}
+	}
+	for _, osName := range c.OS {
+		if _, ok := validOS[osName]; !ok {
+			return fmt.Errorf("%w: condition for '%s' has invalid os '%s' (use windows, macos or linux)", ErrInvalidManifest, name, osName)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the condition holds for a project using targets
+func (c *Condition) Matches(targets []string) bool {
+	if len(c.Targets) > 0 && !sharesTarget(c.Targets, targets) {
+		return false
+	}
+	if len(c.OS) > 0 {
+		for _, osName := range c.OS {
+			if validOS[osName] == currentOS {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// String describes the condition, e.g. "targets cursor; os windows or macos"
+func (c *Condition) String() string {
+	var parts []string
+	if len(c.Targets) > 0 {
+		parts = append(parts, "targets "+strings.Join(c.Targets, " or "))
+	}
+	if len(c.OS) > 0 {
+		parts = append(parts, "os "+strings.Join(c.OS, " or "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Applies reports whether a dependency's condition, if it has one, holds for
+// the project on this machine
+func (pm *ProjectManifest) Applies(name string) bool {
+	condition, ok := pm.Conditions[name]
+	return !ok || condition.Matches(pm.Targets)
+}
@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditionMatches(t *testing.T) {
+	defer func(goos string) { currentOS = goos }(currentOS)
+	currentOS = "darwin"
+
+	tests := []struct {
+		name      string
+		condition Condition
+		targets   []string
+		want      bool
+	}{
+		{"target used", Condition{Targets: []string{"cursor"}}, []string{"cursor", "claude-code"}, true},
+		{"target not used", Condition{Targets: []string{"cursor"}}, []string{"claude-code"}, false},
+		{"project without targets", Condition{Targets: []string{"cursor"}}, nil, false},
+		{"os matches", Condition{OS: []string{"windows", "macos"}}, nil, true},
+		{"os does not match", Condition{OS: []string{"linux"}}, nil, false},
+		{"target and os", Condition{Targets: []string{"cursor"}, OS: []string{"macos"}}, []string{"cursor"}, true},
+		{"target but not os", Condition{Targets: []string{"cursor"}, OS: []string{"windows"}}, []string{"cursor"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.Matches(tt.targets); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.targets, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionsValidate(t *testing.T) {
+	pm := &ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"cursor-rules": "1.0.0"},
+		Conditions:   map[string]Condition{"cursor-rules": {Targets: []string{"cursor"}, OS: []string{"windows"}}},
+	}
+	if err := pm.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, conditions := range map[string]map[string]Condition{
+		"empty condition":  {"cursor-rules": {}},
+		"invalid target":   {"cursor-rules": {Targets: []string{"vim"}}},
+		"invalid os":       {"cursor-rules": {OS: []string{"darwin"}}},
+		"not a dependency": {"other-rules": {Targets: []string{"cursor"}}},
+	} {
+		pm.Conditions = conditions
+		if err := pm.Validate(); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("%s: expected an invalid manifest error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"rulestack/internal/version"
+)
+
+// Constraints represents an organization-distributed policy file referenced from
+// the project manifest's "constraints" field
+type Constraints struct {
+	AllowedRegistries []string          `json:"allowed_registries,omitempty"` // Registry names or URLs
+	Blocked           []string          `json:"blocked,omitempty"`            // "name" blocks all versions, "name@version" one version
+	Required          []string          `json:"required,omitempty"`           // Packages every project must depend on
+	MinimumVersions   map[string]string `json:"minimum_versions,omitempty"`   // Lowest acceptable version per package
+	AllowedLicenses   []string          `json:"allowed_licenses,omitempty"`   // SPDX identifiers packages may be licensed under
+}
+
+var ErrConstraintViolation = errors.New("constraint violation")
+
+// LoadConstraints reads and validates a constraints file
+func LoadConstraints(path string) (*Constraints, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints file: %w", err)
+	}
+
+	return ParseConstraints(data)
+}
+
+// ParseConstraints parses and validates constraints JSON, such as a policy file
+// fetched from a URL
+func ParseConstraints(data []byte) (*Constraints, error) {
+	var constraints Constraints
+	if err := json.Unmarshal(data, &constraints); err != nil {
+		return nil, fmt.Errorf("failed to parse constraints JSON: %w", err)
+	}
+
+	if err := constraints.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid constraints file: %w", err)
+	}
+
+	return &constraints, nil
+}
+
+// Validate checks that the constraints file is well formed
+func (c *Constraints) Validate() error {
+	for _, entry := range c.Blocked {
+		name, ver, hasVersion := strings.Cut(entry, "@")
+		if name == "" {
+			return fmt.Errorf("%w: blocked entry '%s' has no package name", ErrInvalidManifest, entry)
+		}
+		if hasVersion && !versionRegex.MatchString(ver) {
+			return fmt.Errorf("%w: blocked entry '%s' must use name@x.y.z", ErrInvalidVersion, entry)
+		}
+	}
+
+	for name, minVersion := range c.MinimumVersions {
+		if !versionRegex.MatchString(minVersion) {
+			return fmt.Errorf("%w: minimum version for '%s' must be semantic version (x.y.z)", ErrInvalidVersion, name)
+		}
+	}
+
+	for _, license := range c.AllowedLicenses {
+		if err := ValidateLicense(license); err != nil || strings.ContainsAny(license, " ()") {
+			return fmt.Errorf("%w: allowed license '%s' must be a single SPDX identifier", ErrInvalidLicense, license)
+		}
+	}
+
+	return nil
+}
+
+// CheckRegistry verifies a registry is allowed, matching on either its name or URL
+func (c *Constraints) CheckRegistry(name, url string) error {
+	if len(c.AllowedRegistries) == 0 {
+		return nil
+	}
+
+	for _, allowed := range c.AllowedRegistries {
+		if allowed == name || strings.TrimSuffix(allowed, "/") == strings.TrimSuffix(url, "/") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: registry '%s' (%s) is not in the allowed registries", ErrConstraintViolation, name, url)
+}
+
+// CheckPackage verifies a single package version against blocked and minimum version rules
+func (c *Constraints) CheckPackage(name, ver string) error {
+	for _, entry := range c.Blocked {
+		blockedName, blockedVersion, hasVersion := strings.Cut(entry, "@")
+		if blockedName != name {
+			continue
+		}
+		if !hasVersion {
+			return fmt.Errorf("%w: package '%s' is blocked", ErrConstraintViolation, name)
+		}
+		if blockedVersion == ver {
+			return fmt.Errorf("%w: %s@%s is blocked", ErrConstraintViolation, name, ver)
+		}
+	}
+
+	if minVersion, ok := c.MinimumVersions[name]; ok {
+		cmp, err := version.CompareVersions(ver, minVersion)
+		if err != nil {
+			return fmt.Errorf("%w: cannot compare %s@%s with minimum %s: %v", ErrConstraintViolation, name, ver, minVersion, err)
+		}
+		if cmp < 0 {
+			return fmt.Errorf("%w: %s@%s is below the minimum version %s", ErrConstraintViolation, name, ver, minVersion)
+		}
+	}
+
+	return nil
+}
+
+// BelowMinimum reports whether a package version is below the minimum version
+// the constraints require, returning that minimum. Versions that cannot be
+// compared with the minimum count as below it.
+func (c *Constraints) BelowMinimum(name, ver string) (string, bool) {
+	minVersion, ok := c.MinimumVersions[name]
+	if !ok {
+		return "", false
+	}
+	cmp, err := version.CompareVersions(ver, minVersion)
+	return minVersion, err != nil || cmp < 0
+}
+
+// CheckLicense verifies a package version's license expression against the allowed
+// licenses. Packages without a license are rejected once a list is configured.
+func (c *Constraints) CheckLicense(name, ver, license string) error {
+	if len(c.AllowedLicenses) == 0 {
+		return nil
+	}
+
+	if license == "" {
+		return fmt.Errorf("%w: %s@%s declares no license; allowed licenses are %s", ErrConstraintViolation, name, ver, strings.Join(c.AllowedLicenses, ", "))
+	}
+
+	allowed, err := LicenseAllowed(license, c.AllowedLicenses)
+	if err != nil {
+		return fmt.Errorf("%w: %s@%s has an invalid license: %v", ErrConstraintViolation, name, ver, err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s@%s is licensed under %s; allowed licenses are %s", ErrConstraintViolation, name, ver, license, strings.Join(c.AllowedLicenses, ", "))
+	}
+
+	return nil
+}
+
+// CheckDependencies verifies a full dependency set, including required packages,
+// and returns every violation found (sorted by package name)
+func (c *Constraints) CheckDependencies(dependencies map[string]string) []error {
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []error
+	for _, name := range names {
+		if err := c.CheckPackage(name, dependencies[name]); err != nil {
+			violations = append(violations, err)
+		}
+	}
+
+	for _, required := range c.Required {
+		if _, ok := dependencies[required]; !ok {
+			violations = append(violations, fmt.Errorf("%w: required package '%s' is missing from dependencies", ErrConstraintViolation, required))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConstraintsCheckPackage(t *testing.T) {
+	constraints := &Constraints{
+		Blocked:         []string{"evil-rules", "flaky-rules@1.2.0"},
+		MinimumVersions: map[string]string{"security-rules": "1.1.0"},
+	}
+
+	tests := []struct {
+		name      string
+		pkg       string
+		version   string
+		expectErr bool
+	}{
+		{"blocked package", "evil-rules", "1.0.0", true},
+		{"blocked version", "flaky-rules", "1.2.0", true},
+		{"other version of blocked version", "flaky-rules", "1.2.1", false},
+		{"below minimum", "security-rules", "1.0.9", true},
+		{"at minimum", "security-rules", "1.1.0", false},
+		{"above minimum", "security-rules", "2.0.0", false},
+		{"unconstrained", "style-rules", "0.1.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := constraints.CheckPackage(tt.pkg, tt.version)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("CheckPackage(%s, %s) error = %v, expectErr %v", tt.pkg, tt.version, err, tt.expectErr)
+			}
+			if err != nil && !errors.Is(err, ErrConstraintViolation) {
+				t.Errorf("expected ErrConstraintViolation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConstraintsCheckRegistry(t *testing.T) {
+	constraints := &Constraints{AllowedRegistries: []string{"corp", "https://rules.example.com/"}}
+
+	if err := constraints.CheckRegistry("corp", "https://anything.example.com"); err != nil {
+		t.Errorf("expected registry allowed by name, got %v", err)
+	}
+	if err := constraints.CheckRegistry("other", "https://rules.example.com"); err != nil {
+		t.Errorf("expected registry allowed by URL, got %v", err)
+	}
+	if err := constraints.CheckRegistry("public", "https://public.example.com"); err == nil {
+		t.Error("expected registry outside allow list to be rejected")
+	}
+
+	open := &Constraints{}
+	if err := open.CheckRegistry("public", "https://public.example.com"); err != nil {
+		t.Errorf("expected any registry allowed without allow list, got %v", err)
+	}
+}
+
+func TestConstraintsCheckDependencies(t *testing.T) {
+	constraints := &Constraints{
+		Blocked:  []string{"evil-rules"},
+		Required: []string{"security-rules", "core-rules"},
+	}
+
+	violations := constraints.CheckDependencies(map[string]string{
+		"evil-rules":     "1.0.0",
+		"security-rules": "1.0.0",
+	})
+
+	if len(violations) != 2 {
+		t.Fatalf("expected 2 violations (blocked + missing required), got %d: %v", len(violations), violations)
+	}
+}
+
+func TestConstraintsBelowMinimum(t *testing.T) {
+	constraints := &Constraints{MinimumVersions: map[string]string{"security-rules": "1.1.0"}}
+
+	if minimum, below := constraints.BelowMinimum("security-rules", "1.0.9"); !below || minimum != "1.1.0" {
+		t.Errorf("1.0.9: minimum %q, below %v", minimum, below)
+	}
+	if _, below := constraints.BelowMinimum("security-rules", "1.1.0"); below {
+		t.Error("1.1.0 should meet the minimum")
+	}
+	if _, below := constraints.BelowMinimum("style-rules", "0.1.0"); below {
+		t.Error("unconstrained package should never be below a minimum")
+	}
+}
+
+func TestLoadConstraints(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Run("valid file", func(t *testing.T) {
+		path := filepath.Join(tempDir, "valid.json")
+		content := `{"allowed_registries": ["corp"], "blocked": ["bad@1.0.0"], "required": ["core"], "minimum_versions": {"core": "1.0.0"}}`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write constraints: %v", err)
+		}
+
+		constraints, err := LoadConstraints(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(constraints.AllowedRegistries) != 1 || constraints.MinimumVersions["core"] != "1.0.0" {
+			t.Errorf("constraints not parsed correctly: %+v", constraints)
+		}
+	})
+
+	t.Run("invalid minimum version", func(t *testing.T) {
+		path := filepath.Join(tempDir, "invalid.json")
+		if err := os.WriteFile(path, []byte(`{"minimum_versions": {"core": "latest"}}`), 0644); err != nil {
+			t.Fatalf("failed to write constraints: %v", err)
+		}
+
+		if _, err := LoadConstraints(path); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("expected ErrInvalidVersion, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidLicense = errors.New("invalid license")
+
+// spdxLicenses are the SPDX license identifiers accepted in manifests. The list
+// covers the licenses rule packages are published under in practice; anything
+// else can be declared as LicenseRef-<name>.
+var spdxLicenses = map[string]bool{
+	"0BSD": true, "AFL-3.0": true, "AGPL-3.0-only": true, "AGPL-3.0-or-later": true,
+	"Apache-1.1": true, "Apache-2.0": true, "APSL-2.0": true, "Artistic-2.0": true,
+	"BlueOak-1.0.0": true, "BSD-1-Clause": true, "BSD-2-Clause": true, "BSD-2-Clause-Patent": true,
+	"BSD-3-Clause": true, "BSD-3-Clause-Clear": true, "BSD-4-Clause": true, "BSL-1.0": true,
+	"BUSL-1.1": true, "CC-BY-3.0": true, "CC-BY-4.0": true, "CC-BY-NC-4.0": true,
+	"CC-BY-NC-SA-4.0": true, "CC-BY-ND-4.0": true, "CC-BY-SA-3.0": true, "CC-BY-SA-4.0": true,
+	"CC0-1.0": true, "CDDL-1.0": true, "CDDL-1.1": true, "CECILL-2.1": true,
+	"ECL-2.0": true, "EFL-2.0": true, "ElasticLicense-2.0": true, "EPL-1.0": true,
+	"EPL-2.0": true, "EUPL-1.1": true, "EUPL-1.2": true, "GFDL-1.3-only": true,
+	"GFDL-1.3-or-later": true, "GPL-2.0-only": true, "GPL-2.0-or-later": true, "GPL-3.0-only": true,
+	"GPL-3.0-or-later": true, "ISC": true, "LGPL-2.1-only": true, "LGPL-2.1-or-later": true,
+	"LGPL-3.0-only": true, "LGPL-3.0-or-later": true, "LPPL-1.3c": true, "MIT": true,
+	"MIT-0": true, "MPL-1.1": true, "MPL-2.0": true, "MS-PL": true,
+	"MS-RL": true, "MulanPSL-2.0": true, "NCSA": true, "ODbL-1.0": true,
+	"OFL-1.1": true, "OSL-3.0": true, "PostgreSQL": true, "PSF-2.0": true,
+	"Python-2.0": true, "SSPL-1.0": true, "Unicode-3.0": true, "Unlicense": true,
+	"UPL-1.0": true, "W3C": true, "WTFPL": true, "X11": true,
+	"Zlib": true, "ZPL-2.1": true,
+}
+
+// spdxExceptions are the SPDX exception identifiers accepted after WITH
+var spdxExceptions = map[string]bool{
+	"Autoconf-exception-3.0": true, "Bison-exception-2.2": true, "Classpath-exception-2.0": true,
+	"GCC-exception-3.1": true, "LLVM-exception": true, "OpenJDK-assembly-exception-1.0": true,
+}
+
+var licenseRefRegex = regexp.MustCompile(`^LicenseRef-[A-Za-z0-9.\-]+$`)
+
+// licenseUnlicensed marks a proprietary package not offered under any license. It
+// cannot be combined with other licenses.
+const licenseUnlicensed = "UNLICENSED"
+
+// licenseExpr is a parsed SPDX license expression: either a single license, or
+// two expressions joined by AND or OR
+type licenseExpr struct {
+	ID          string // License identifier, without a trailing "+"
+	Exception   string // Exception named by WITH, if any
+	Op          string // "AND" or "OR" for compound expressions
+	Left, Right *licenseExpr
+}
+
+// ValidateLicense checks that license is a valid SPDX license expression, such as
+// "MIT", "Apache-2.0 OR MIT" or "GPL-2.0-or-later WITH Classpath-exception-2.0",
+// or UNLICENSED
+func ValidateLicense(license string) error {
+	_, err := parseLicense(license)
+	return err
+}
+
+// LicenseAllowed reports whether a license expression can be used under a list of
+// allowed licenses: every license joined by AND must be allowed, and at least one
+// side of each OR
+func LicenseAllowed(license string, allowed []string) (bool, error) {
+	expr, err := parseLicense(license)
+	if err != nil {
+		return false, err
+	}
+
+	set := make(map[string]bool, len(allowed))
+	for _, id := range allowed {
+		set[strings.ToLower(id)] = true
+	}
+
+	return expr.allowed(set), nil
+}
+
+func (e *licenseExpr) allowed(set map[string]bool) bool {
+	switch e.Op {
+	case "AND":
+		return e.Left.allowed(set) && e.Right.allowed(set)
+	case "OR":
+		return e.Left.allowed(set) || e.Right.allowed(set)
+	}
+	return set[strings.ToLower(e.ID)]
+}
+
+// parseLicense parses an SPDX license expression. AND binds tighter than OR, as
+// in the SPDX specification; operators are case-sensitive.
+func parseLicense(license string) (*licenseExpr, error) {
+	if strings.TrimSpace(license) == licenseUnlicensed {
+		return &licenseExpr{ID: licenseUnlicensed}, nil
+	}
+
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(license))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: license is empty", ErrInvalidLicense)
+	}
+
+	p := &licenseParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected '%s' in '%s'", ErrInvalidLicense, p.tokens[p.pos], license)
+	}
+
+	return expr, nil
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token
+}
+
+func (p *licenseParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *licenseParser) parseOr() (*licenseExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "OR" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &licenseExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *licenseParser) parseAnd() (*licenseExpr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "AND" {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &licenseExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *licenseParser) parseTerm() (*licenseExpr, error) {
+	token := p.next()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("%w: expression ends early", ErrInvalidLicense)
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("%w: missing ')'", ErrInvalidLicense)
+		}
+		return expr, nil
+	case ")", "AND", "OR", "WITH":
+		return nil, fmt.Errorf("%w: unexpected '%s'", ErrInvalidLicense, token)
+	}
+
+	id := strings.TrimSuffix(token, "+")
+	if !spdxLicenses[id] && !licenseRefRegex.MatchString(id) {
+		return nil, fmt.Errorf("%w: '%s' is not an SPDX license identifier (use LicenseRef-<name> for others)", ErrInvalidLicense, id)
+	}
+	expr := &licenseExpr{ID: id}
+
+	if p.peek() == "WITH" {
+		p.next()
+		exception := p.next()
+		if !spdxExceptions[exception] {
+			return nil, fmt.Errorf("%w: '%s' is not an SPDX license exception", ErrInvalidLicense, exception)
+		}
+		expr.Exception = exception
+	}
+
+	return expr, nil
+}
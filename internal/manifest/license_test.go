@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateLicense(t *testing.T) {
+	tests := []struct {
+		license   string
+		expectErr bool
+	}{
+		{"MIT", false},
+		{"Apache-2.0 OR MIT", false},
+		{"(MIT OR Apache-2.0) AND CC-BY-4.0", false},
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0", false},
+		{"MPL-1.1+", false},
+		{"LicenseRef-Acme-Internal", false},
+		{"UNLICENSED", false},
+		{"MIT OR UNLICENSED", true},
+		{"", true},
+		{"mit-ish", true},
+		{"MIT OR", true},
+		{"(MIT", true},
+		{"MIT Apache-2.0", true},
+		{"MIT WITH Made-Up-exception", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.license, func(t *testing.T) {
+			err := ValidateLicense(tt.license)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ValidateLicense(%q) error = %v, expectErr %v", tt.license, err, tt.expectErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidLicense) {
+				t.Errorf("expected ErrInvalidLicense, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConstraintsCheckLicense(t *testing.T) {
+	constraints := &Constraints{AllowedLicenses: []string{"MIT", "Apache-2.0"}}
+
+	tests := []struct {
+		name      string
+		license   string
+		expectErr bool
+	}{
+		{"allowed", "MIT", false},
+		{"either side of OR allowed", "GPL-3.0-only OR Apache-2.0", false},
+		{"AND needs both", "MIT AND CC-BY-4.0", true},
+		{"not allowed", "GPL-3.0-only", true},
+		{"no license", "", true},
+		{"invalid", "not-a-license", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := constraints.CheckLicense("security-rules", "1.0.0", tt.license)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("CheckLicense(%q) error = %v, expectErr %v", tt.license, err, tt.expectErr)
+			}
+			if err != nil && !errors.Is(err, ErrConstraintViolation) {
+				t.Errorf("expected ErrConstraintViolation, got %v", err)
+			}
+		})
+	}
+
+	if err := (&Constraints{}).CheckLicense("security-rules", "1.0.0", ""); err != nil {
+		t.Errorf("expected no license policy to allow anything, got %v", err)
+	}
+	if err := (&Constraints{AllowedLicenses: []string{"MIT OR Apache-2.0"}}).Validate(); err == nil {
+		t.Error("expected an allowed license expression to be rejected")
+	}
+}
@@ -5,26 +5,65 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+
+	"rulestack/internal/version"
 )
 
 // ProjectManifest represents the rulestack.json file in project mode (dependency management)
 type ProjectManifest struct {
-	Version      string            `json:"version"`
-	Dependencies map[string]string `json:"dependencies"`
+	Schema       string               `json:"$schema,omitempty"` // JSON Schema for editor support, ProjectSchemaURL
+	Version      string               `json:"version"`
+	Dependencies map[string]string    `json:"dependencies"`
+	Priority     []string             `json:"priority,omitempty"`    // Package precedence when installed rules conflict (first wins)
+	Constraints  string               `json:"constraints,omitempty"` // Path to an organization constraints file, relative to the project root
+	Overrides    map[string]string    `json:"overrides,omitempty"`   // Forced "version" or replacement "name@version" per package
+	Mirrors      []string             `json:"mirrors,omitempty"`     // Registries tried in order when the active registry lacks a package or is down
+	Aliases      map[string]string    `json:"aliases,omitempty"`     // Extra "name@version" installs under another name, e.g. a second major version
+	Targets      []string             `json:"targets,omitempty"`     // Editors/agents the project uses, checked against package requirements
+	Extends      string               `json:"extends,omitempty"`     // Base configuration package ("name@^2") whose settings are merged in
+	Quarantine   bool                 `json:"quarantine,omitempty"`  // Keep newly installed packages out of CLAUDE.md until 'rfh trust'
+	Template     string               `json:"template,omitempty"`    // Version of the CLAUDE.md and core rules template, see 'rfh upgrade-project'
+	Storage      string               `json:"storage,omitempty"`     // Where installed packages are kept: StorageProject (default) or StorageCache
+	Registry     string               `json:"registry,omitempty"`    // Configured registry the project uses instead of the active one
+	Conditions   map[string]Condition `json:"conditions,omitempty"`  // When each listed dependency is installed, by target or OS
+
+	inherited map[string]string // Dependencies merged in from elsewhere: the meta-package that brought each in, or "" for the base configuration
 }
 
+// Package storage modes for ProjectManifest.Storage
+const (
+	StorageProject = "project" // Installed packages live in .rulestack/
+	StorageCache   = "cache"   // Installed packages live in the user's rfh cache, linked from .rulestack/
+)
+
 // PackageManifest represents a single ruleset package entry
 type PackageManifest struct {
-	Name        string   `json:"name"`
-	Version     string   `json:"version"`
-	Description string   `json:"description,omitempty"`
-	Targets     []string `json:"targets,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
-	Files       []string `json:"files"`
-	License     string   `json:"license,omitempty"`
+	Schema      string        `json:"$schema,omitempty"` // JSON Schema for editor support, PackageSchemaURL
+	Name        string        `json:"name"`
+	Version     string        `json:"version"`
+	Description string        `json:"description,omitempty"`
+	Targets     []string      `json:"targets,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Files       []string      `json:"files"`
+	License     string        `json:"license,omitempty"`
+	Tests       string        `json:"tests,omitempty"`    // Rule test fixtures dir; registries run them on publish
+	Requires    *Requirements `json:"requires,omitempty"` // Compatibility checked against the installing project
+
+	// Type is PackageTypeMeta for a meta-package: one that ships no files and
+	// only brings in Dependencies, e.g. an organization's "backend-profile"
+	Type string `json:"type,omitempty"`
+
+	// Dependencies are the packages this one builds on, by version range. A
+	// meta-package installs them and must name exact versions.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
 }
 
+// PackageTypeMeta marks a package manifest as a meta-package
+const PackageTypeMeta = "meta"
+
 // PackageManifestFile represents the entire rulestack.json file in package mode (array of packages)
 type PackageManifestFile []PackageManifest
 
@@ -37,6 +76,7 @@ var (
 	ErrInvalidName     = errors.New("invalid package name")
 	ErrInvalidVersion  = errors.New("invalid version")
 	ErrEmptyManifest   = errors.New("manifest file cannot be empty")
+	ErrProjectManifest = errors.New("project manifest, not a package manifest")
 )
 
 // nameRegex matches valid package names (with or without scope)
@@ -45,6 +85,14 @@ var nameRegex = regexp.MustCompile(`^(@[a-z0-9][a-z0-9\-_]*\/)?[a-z0-9][a-z0-9\-
 // versionRegex matches semantic versions
 var versionRegex = regexp.MustCompile(`^\d+\.\d+\.\d+(-[a-zA-Z0-9\-]+)?(\+[a-zA-Z0-9\-]+)?$`)
 
+// validTargets lists the editors and agents packages can target
+var validTargets = map[string]bool{
+	"cursor":      true,
+	"claude-code": true,
+	"windsurf":    true,
+	"copilot":     true,
+}
+
 // PROJECT MANIFEST FUNCTIONS
 
 // LoadProjectManifest reads and validates a project manifest from file
@@ -94,12 +142,142 @@ func (pm *ProjectManifest) Validate() error {
 		return fmt.Errorf("%w: dependencies field is required (can be empty object)", ErrInvalidManifest)
 	}
 
+	for name, override := range pm.Overrides {
+		if _, _, err := parseOverride(name, override); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range pm.Targets {
+		if !validTargets[target] {
+			return fmt.Errorf("%w: invalid target '%s'", ErrInvalidManifest, target)
+		}
+	}
+
+	if pm.Extends != "" {
+		if _, _, err := ParseExtends(pm.Extends); err != nil {
+			return err
+		}
+	}
+
+	for alias, spec := range pm.Aliases {
+		if _, exists := pm.Dependencies[alias]; exists {
+			return fmt.Errorf("%w: alias '%s' clashes with a dependency of the same name", ErrInvalidManifest, alias)
+		}
+		if _, _, err := parseAlias(alias, spec); err != nil {
+			return err
+		}
+	}
+
+	for name, condition := range pm.Conditions {
+		if _, exists := pm.Dependencies[name]; !exists {
+			return fmt.Errorf("%w: condition for '%s', which is not a dependency", ErrInvalidManifest, name)
+		}
+		if err := condition.Validate(name); err != nil {
+			return err
+		}
+	}
+
+	if pm.Storage != "" && pm.Storage != StorageProject && pm.Storage != StorageCache {
+		return fmt.Errorf("%w: storage must be '%s' or '%s'", ErrInvalidManifest, StorageProject, StorageCache)
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range pm.Priority {
+		if seen[name] {
+			return fmt.Errorf("%w: package '%s' listed more than once in priority", ErrInvalidManifest, name)
+		}
+		seen[name] = true
+	}
+
 	return nil
 }
 
+// ResolveDependency applies any override for a dependency and returns the package
+// name and version that should actually be installed
+func (pm *ProjectManifest) ResolveDependency(name, version string) (string, string, bool) {
+	override, ok := pm.Overrides[name]
+	if !ok {
+		return name, version, false
+	}
+
+	resolvedName, resolvedVersion, err := parseOverride(name, override)
+	if err != nil {
+		return name, version, false
+	}
+
+	return resolvedName, resolvedVersion, true
+}
+
+// ResolvedDependencies returns the dependencies whose conditions hold, with
+// overrides applied, keyed by installed package name
+func (pm *ProjectManifest) ResolvedDependencies() map[string]string {
+	resolved := make(map[string]string, len(pm.Dependencies))
+	for name, version := range pm.Dependencies {
+		if !pm.Applies(name) {
+			continue
+		}
+		resolvedName, resolvedVersion, _ := pm.ResolveDependency(name, version)
+		resolved[resolvedName] = resolvedVersion
+	}
+	return resolved
+}
+
+// ResolveAlias returns the package name and version installed under an alias
+func (pm *ProjectManifest) ResolveAlias(alias string) (string, string, bool) {
+	spec, ok := pm.Aliases[alias]
+	if !ok {
+		return "", "", false
+	}
+
+	name, version, err := parseAlias(alias, spec)
+	if err != nil {
+		return "", "", false
+	}
+
+	return name, version, true
+}
+
+// parseAlias parses an alias value of the form "name@version"
+func parseAlias(alias, spec string) (string, string, error) {
+	if !nameRegex.MatchString(alias) {
+		return "", "", fmt.Errorf("%w: alias '%s' is not a valid package name", ErrInvalidName, alias)
+	}
+
+	name, version, found := strings.Cut(spec, "@")
+	if !found || !nameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("%w: alias '%s' must point to a registry package as name@version", ErrInvalidName, alias)
+	}
+
+	if !versionRegex.MatchString(version) {
+		return "", "", fmt.Errorf("%w: alias '%s' must pin an exact version (x.y.z)", ErrInvalidVersion, alias)
+	}
+
+	return name, version, nil
+}
+
+// parseOverride parses an override value of the form "version" or "name@version"
+func parseOverride(name, override string) (string, string, error) {
+	resolvedName, resolvedVersion := name, override
+	if replacement, ver, found := strings.Cut(override, "@"); found {
+		resolvedName, resolvedVersion = replacement, ver
+	}
+
+	if !nameRegex.MatchString(resolvedName) {
+		return "", "", fmt.Errorf("%w: override for '%s' has invalid package name '%s'", ErrInvalidName, name, resolvedName)
+	}
+
+	if !versionRegex.MatchString(resolvedVersion) {
+		return "", "", fmt.Errorf("%w: override for '%s' must be a version or name@version", ErrInvalidVersion, name)
+	}
+
+	return resolvedName, resolvedVersion, nil
+}
+
 // CreateProjectManifest creates a new project manifest with default values
 func CreateProjectManifest() *ProjectManifest {
 	return &ProjectManifest{
+		Schema:       ProjectSchemaURL,
 		Version:      "1.0.0",
 		Dependencies: make(map[string]string),
 	}
@@ -114,6 +292,11 @@ func LoadPackageManifests(path string) (PackageManifestFile, error) {
 		return nil, fmt.Errorf("failed to read package manifests: %w", err)
 	}
 
+	// A project's rulestack.json would otherwise fail with "name is required"
+	if IsProjectManifestData(data) {
+		return nil, fmt.Errorf("%w: %s lists the project's dependencies; packing and publishing need a %s with name, version and files (see 'rfh new package')", ErrProjectManifest, path, PackageManifestName)
+	}
+
 	var manifests PackageManifestFile
 	if err := json.Unmarshal(data, &manifests); err != nil {
 		// Try to parse as single manifest for backward compatibility
@@ -194,14 +377,36 @@ func SaveSinglePackageManifest(path string, manifest *PackageManifest) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+// ValidateName checks that name is a valid package name
+func ValidateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("%w: name must match pattern %s", ErrInvalidName, nameRegex.String())
+	}
+	return nil
+}
+
+// ValidateDependencies checks that a package's dependencies name valid
+// packages and versions or ranges such as ^1.2
+func ValidateDependencies(dependencies map[string]string) error {
+	for name, ver := range dependencies {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+		if _, err := version.ParseRange(ver); err != nil {
+			return fmt.Errorf("%w: dependency %s@%s must be a version or range such as 1.2.0, ^1.2 or ~1.2.0", ErrInvalidVersion, name, ver)
+		}
+	}
+	return nil
+}
+
 // Validate checks if the package manifest is valid
 func (pm *PackageManifest) Validate() error {
 	if pm.Name == "" {
 		return fmt.Errorf("%w: name is required", ErrInvalidManifest)
 	}
 
-	if !nameRegex.MatchString(pm.Name) {
-		return fmt.Errorf("%w: name must match pattern %s", ErrInvalidName, nameRegex.String())
+	if err := ValidateName(pm.Name); err != nil {
+		return err
 	}
 
 	if pm.Version == "" {
@@ -212,27 +417,64 @@ func (pm *PackageManifest) Validate() error {
 		return fmt.Errorf("%w: version must be semantic version (x.y.z)", ErrInvalidVersion)
 	}
 
-	if len(pm.Files) == 0 {
-		return fmt.Errorf("%w: files array cannot be empty", ErrInvalidManifest)
+	switch pm.Type {
+	case "":
+		if len(pm.Files) == 0 {
+			return fmt.Errorf("%w: files array cannot be empty", ErrInvalidManifest)
+		}
+		if err := ValidateDependencies(pm.Dependencies); err != nil {
+			return err
+		}
+	case PackageTypeMeta:
+		if len(pm.Files) > 0 {
+			return fmt.Errorf("%w: a meta-package ships no files; list its packages under dependencies", ErrInvalidManifest)
+		}
+		if len(pm.Dependencies) == 0 {
+			return fmt.Errorf("%w: a meta-package must declare dependencies", ErrInvalidManifest)
+		}
+		for name, ver := range pm.Dependencies {
+			if err := ValidateName(name); err != nil {
+				return err
+			}
+			if !versionRegex.MatchString(ver) {
+				return fmt.Errorf("%w: dependency %s@%s must be an exact semantic version (x.y.z)", ErrInvalidVersion, name, ver)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown package type '%s'", ErrInvalidManifest, pm.Type)
 	}
 
 	// Validate targets
-	validTargets := map[string]bool{
-		"cursor":      true,
-		"claude-code": true,
-		"windsurf":    true,
-		"copilot":     true,
-	}
-
 	for _, target := range pm.Targets {
 		if !validTargets[target] {
 			return fmt.Errorf("%w: invalid target '%s'", ErrInvalidManifest, target)
 		}
 	}
 
+	if pm.Requires != nil {
+		if err := pm.Requires.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if pm.License != "" {
+		if err := ValidateLicense(pm.License); err != nil {
+			return err
+		}
+	}
+
+	if pm.Tests != "" && (filepath.IsAbs(pm.Tests) || strings.HasPrefix(filepath.ToSlash(filepath.Clean(pm.Tests)), "..")) {
+		return fmt.Errorf("%w: tests must be a directory inside the package", ErrInvalidManifest)
+	}
+
 	return nil
 }
 
+// IsMeta reports whether the package is a meta-package
+func (pm *PackageManifest) IsMeta() bool {
+	return pm.Type == PackageTypeMeta
+}
+
 // GetPackageName returns the package name (no scope support)
 func (pm *PackageManifest) GetPackageName() string {
 	return pm.Name
@@ -290,6 +532,36 @@ func CreateSampleFile() ManifestFile {
 
 // UTILITY FUNCTIONS
 
+// Manifest file names. A project lists its dependencies in rulestack.json and a
+// package source declares its packages in rulestack.pkg.json. Package archives
+// still carry their manifest as rulestack.json.
+const (
+	ProjectManifestName = "rulestack.json"
+	PackageManifestName = "rulestack.pkg.json"
+)
+
+// PackageManifestPath returns the package manifest of a package source
+// directory. Sources from before rulestack.pkg.json declare their packages in
+// rulestack.json; that file is returned with legacy set, and is read until the
+// next release. A project's rulestack.json is returned when there is no package
+// manifest, so loading it explains the mix-up; otherwise the rulestack.pkg.json
+// path is.
+func PackageManifestPath(dir string) (path string, legacy bool) {
+	path = filepath.Join(dir, PackageManifestName)
+	if _, err := os.Stat(path); err == nil {
+		return path, false
+	}
+
+	legacyPath := filepath.Join(dir, ProjectManifestName)
+	if IsPackageManifest(legacyPath) {
+		return legacyPath, true
+	}
+	if IsProjectManifest(legacyPath) {
+		return legacyPath, false
+	}
+	return path, false
+}
+
 // IsProjectManifest checks if a rulestack.json file contains a project manifest
 func IsProjectManifest(path string) bool {
 	data, err := os.ReadFile(path)
@@ -307,6 +579,18 @@ func IsProjectManifest(path string) bool {
 	return pm.Dependencies != nil
 }
 
+// IsProjectManifestData reports whether manifest JSON is a project manifest: an
+// object with dependencies and no package name
+func IsProjectManifestData(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	_, hasDependencies := fields["dependencies"]
+	_, hasName := fields["name"]
+	return hasDependencies && !hasName
+}
+
 // IsPackageManifest checks if a rulestack.json file contains package manifests
 func IsPackageManifest(path string) bool {
 	data, err := os.ReadFile(path)
@@ -2,6 +2,7 @@ package manifest
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -119,6 +120,92 @@ func TestManifestValidation(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "valid meta-package",
+			manifest: Manifest{
+				Name:         "backend-profile",
+				Version:      "1.0.0",
+				Type:         PackageTypeMeta,
+				Dependencies: map[string]string{"security-rules": "1.2.0", "logging-rules": "2.0.0"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "meta-package with files",
+			manifest: Manifest{
+				Name:         "backend-profile",
+				Version:      "1.0.0",
+				Type:         PackageTypeMeta,
+				Files:        []string{"rules/*.md"},
+				Dependencies: map[string]string{"security-rules": "1.2.0"},
+			},
+			expectErr: true,
+			errType:   ErrInvalidManifest,
+		},
+		{
+			name: "meta-package without dependencies",
+			manifest: Manifest{
+				Name:    "backend-profile",
+				Version: "1.0.0",
+				Type:    PackageTypeMeta,
+			},
+			expectErr: true,
+			errType:   ErrInvalidManifest,
+		},
+		{
+			name: "meta-package with version range",
+			manifest: Manifest{
+				Name:         "backend-profile",
+				Version:      "1.0.0",
+				Type:         PackageTypeMeta,
+				Dependencies: map[string]string{"security-rules": "^1.2.0"},
+			},
+			expectErr: true,
+			errType:   ErrInvalidVersion,
+		},
+		{
+			name: "dependencies on a rule package",
+			manifest: Manifest{
+				Name:         "test-rules",
+				Version:      "1.0.0",
+				Files:        []string{"rules/*.md"},
+				Dependencies: map[string]string{"security-rules": "^1.2", "logging-rules": "~2.0.1", "base-rules": "1.0.0"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "dependency with invalid range",
+			manifest: Manifest{
+				Name:         "test-rules",
+				Version:      "1.0.0",
+				Files:        []string{"rules/*.md"},
+				Dependencies: map[string]string{"security-rules": ">=1.2"},
+			},
+			expectErr: true,
+			errType:   ErrInvalidVersion,
+		},
+		{
+			name: "dependency with invalid name",
+			manifest: Manifest{
+				Name:         "test-rules",
+				Version:      "1.0.0",
+				Files:        []string{"rules/*.md"},
+				Dependencies: map[string]string{"Security Rules": "1.2.0"},
+			},
+			expectErr: true,
+			errType:   ErrInvalidName,
+		},
+		{
+			name: "unknown package type",
+			manifest: Manifest{
+				Name:    "test-rules",
+				Version: "1.0.0",
+				Type:    "bundle",
+				Files:   []string{"rules/*.md"},
+			},
+			expectErr: true,
+			errType:   ErrInvalidManifest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,6 +307,16 @@ func TestLoadManifest(t *testing.T) {
 			t.Error("expected error for missing file")
 		}
 	})
+
+	t.Run("refuses a project manifest", func(t *testing.T) {
+		projectPath := filepath.Join(tempDir, "project.json")
+		os.WriteFile(projectPath, []byte(`{"version": "1.0.0", "dependencies": {"security-rules": "1.0.0"}}`), 0644)
+
+		_, err := Load(projectPath)
+		if !errors.Is(err, ErrProjectManifest) {
+			t.Errorf("error = %v, want ErrProjectManifest", err)
+		}
+	})
 }
 
 func TestSaveManifest(t *testing.T) {
@@ -296,3 +393,134 @@ func isErrorType(err, target error) bool {
 		(target == ErrInvalidName && err.Error() != "" && err.Error() != ErrInvalidManifest.Error() && err.Error() != ErrInvalidVersion.Error()) ||
 		(target == ErrInvalidVersion && err.Error() != "" && err.Error() != ErrInvalidManifest.Error() && err.Error() != ErrInvalidName.Error())
 }
+
+func TestProjectManifestOverrides(t *testing.T) {
+	pm := &ProjectManifest{
+		Version: "1.0.0",
+		Dependencies: map[string]string{
+			"security-rules": "1.0.0",
+			"style-rules":    "2.0.0",
+			"logging-rules":  "1.1.0",
+		},
+		Overrides: map[string]string{
+			"security-rules": "1.0.1",
+			"style-rules":    "style-rules-fork@2.0.3",
+		},
+	}
+
+	if err := pm.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	tests := []struct {
+		dependency     string
+		expectName     string
+		expectVersion  string
+		expectOverride bool
+	}{
+		{"security-rules", "security-rules", "1.0.1", true},
+		{"style-rules", "style-rules-fork", "2.0.3", true},
+		{"logging-rules", "logging-rules", "1.1.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dependency, func(t *testing.T) {
+			name, version, overridden := pm.ResolveDependency(tt.dependency, pm.Dependencies[tt.dependency])
+			if name != tt.expectName || version != tt.expectVersion || overridden != tt.expectOverride {
+				t.Errorf("ResolveDependency() = (%s, %s, %v), want (%s, %s, %v)",
+					name, version, overridden, tt.expectName, tt.expectVersion, tt.expectOverride)
+			}
+		})
+	}
+
+	resolved := pm.ResolvedDependencies()
+	if resolved["style-rules-fork"] != "2.0.3" {
+		t.Errorf("expected fork in resolved dependencies, got %v", resolved)
+	}
+	if _, ok := resolved["style-rules"]; ok {
+		t.Errorf("expected replaced package to be absent from resolved dependencies, got %v", resolved)
+	}
+
+	t.Run("invalid override", func(t *testing.T) {
+		invalid := &ProjectManifest{
+			Version:      "1.0.0",
+			Dependencies: map[string]string{},
+			Overrides:    map[string]string{"security-rules": "latest"},
+		}
+		if err := invalid.Validate(); err == nil {
+			t.Error("expected invalid override version to fail validation")
+		}
+	})
+}
+
+func TestProjectManifestAliases(t *testing.T) {
+	pm := &ProjectManifest{
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"security-rules": "2.0.0"},
+		Aliases:      map[string]string{"security-rules-v1": "security-rules@1.4.0"},
+	}
+
+	if err := pm.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	name, version, ok := pm.ResolveAlias("security-rules-v1")
+	if !ok || name != "security-rules" || version != "1.4.0" {
+		t.Errorf("ResolveAlias() = (%s, %s, %v), want (security-rules, 1.4.0, true)", name, version, ok)
+	}
+	if _, _, ok := pm.ResolveAlias("security-rules"); ok {
+		t.Error("expected a dependency name not to resolve as an alias")
+	}
+
+	invalid := []struct {
+		name    string
+		aliases map[string]string
+	}{
+		{"clashes with dependency", map[string]string{"security-rules": "security-rules@1.4.0"}},
+		{"missing version", map[string]string{"security-rules-v1": "security-rules"}},
+		{"version range", map[string]string{"security-rules-v1": "security-rules@^1.0.0"}},
+		{"source spec", map[string]string{"security-rules-v1": "security-rules@file:../rules"}},
+		{"invalid alias name", map[string]string{"Security Rules": "security-rules@1.4.0"}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := &ProjectManifest{Version: "1.0.0", Dependencies: map[string]string{"security-rules": "2.0.0"}, Aliases: tt.aliases}
+			if err := pm.Validate(); err == nil {
+				t.Error("expected validation to fail")
+			}
+		})
+	}
+}
+
+func TestPackageManifestPath(t *testing.T) {
+	dir := t.TempDir()
+	if path, legacy := PackageManifestPath(dir); path != filepath.Join(dir, PackageManifestName) || legacy {
+		t.Errorf("expected rulestack.pkg.json for an empty directory, got %s (legacy %v)", path, legacy)
+	}
+
+	legacyPath := filepath.Join(dir, ProjectManifestName)
+	if err := os.WriteFile(legacyPath, []byte(`{"name": "security-rules", "version": "1.0.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if path, legacy := PackageManifestPath(dir); path != legacyPath || !legacy {
+		t.Errorf("expected the legacy rulestack.json, got %s (legacy %v)", path, legacy)
+	}
+
+	// rulestack.pkg.json wins over a rulestack.json
+	if err := os.WriteFile(filepath.Join(dir, PackageManifestName), []byte(`{"name": "security-rules", "version": "1.0.1"}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if path, legacy := PackageManifestPath(dir); path != filepath.Join(dir, PackageManifestName) || legacy {
+		t.Errorf("expected rulestack.pkg.json, got %s (legacy %v)", path, legacy)
+	}
+
+	// A project's rulestack.json is not a package manifest
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ProjectManifestName), []byte(`{"version": "1.0.0", "dependencies": {}}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if _, legacy := PackageManifestPath(projectDir); legacy {
+		t.Error("expected a project manifest not to be taken for a package manifest")
+	}
+}
@@ -0,0 +1,310 @@
+package manifest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// JSON Schemas of both rulestack.json modes. Editors load them from the URL in
+// a manifest's "$schema" field; rfh validates against the copies built in.
+const (
+	ProjectSchemaURL = "https://raw.githubusercontent.com/richardhannah/rfh/main/internal/manifest/schema/project.schema.json"
+	PackageSchemaURL = "https://raw.githubusercontent.com/richardhannah/rfh/main/internal/manifest/schema/package.schema.json"
+)
+
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+// Schema kinds, as accepted by Schema
+const (
+	SchemaProject = "project"
+	SchemaPackage = "package"
+)
+
+// SchemaError is a place where a manifest does not follow its schema
+type SchemaError struct {
+	Pointer string // JSON pointer to the offending value, "" for the whole document
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Pointer == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Pointer, e.Message)
+}
+
+var (
+	schemaOnce     sync.Once
+	schemaErr      error
+	compiledSchema map[string]*jsonschema.Schema
+)
+
+// Schema returns the JSON Schema of a manifest kind, SchemaProject or SchemaPackage
+func Schema(kind string) ([]byte, error) {
+	data, err := schemaFS.ReadFile("schema/" + kind + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown manifest schema '%s' (use %s or %s)", kind, SchemaProject, SchemaPackage)
+	}
+	return data, nil
+}
+
+// ValidateSchema checks manifest data against the schema of its mode: the
+// project schema when it lists dependencies without a name, the package schema
+// otherwise. It returns the kind it checked against and every violation.
+func ValidateSchema(data []byte) (string, []SchemaError, error) {
+	kind := SchemaPackage
+	if IsProjectManifestData(data) {
+		kind = SchemaProject
+	}
+	problems, err := ValidateSchemaAs(kind, data)
+	return kind, problems, err
+}
+
+// SchemaKindOf returns the schema kind of the manifest file at path: package
+// for rulestack.pkg.json, and for other files the kind their data looks like
+func SchemaKindOf(path string, data []byte) string {
+	if filepath.Base(path) == PackageManifestName || !IsProjectManifestData(data) {
+		return SchemaPackage
+	}
+	return SchemaProject
+}
+
+// ValidateSchemaAs checks manifest data against the schema of kind, applied to
+// each package of a multi-package file. It returns every violation, in
+// document order. Data that is not JSON yields a single violation at the
+// syntax error.
+func ValidateSchemaAs(kind string, data []byte) ([]SchemaError, error) {
+	schemaOnce.Do(compileSchemas)
+	if schemaErr != nil {
+		return nil, schemaErr
+	}
+	if _, ok := compiledSchema[kind]; !ok {
+		_, err := Schema(kind)
+		return nil, err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return []SchemaError{syntaxError(data, err)}, nil
+	}
+
+	var problems []SchemaError
+	validate := func(value any, prefix []string) {
+		var validationErr *jsonschema.ValidationError
+		if err := compiledSchema[kind].Validate(value); errors.As(err, &validationErr) {
+			problems = append(problems, schemaErrors(data, validationErr, prefix)...)
+		}
+	}
+
+	if items, ok := instance.([]any); ok && kind == SchemaPackage {
+		for i, item := range items {
+			validate(item, []string{strconv.Itoa(i)})
+		}
+	} else {
+		validate(instance, nil)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems, nil
+}
+
+func compileSchemas() {
+	compiler := jsonschema.NewCompiler()
+	urls := map[string]string{SchemaProject: ProjectSchemaURL, SchemaPackage: PackageSchemaURL}
+	for kind, url := range urls {
+		data, err := Schema(kind)
+		if err != nil {
+			schemaErr = err
+			return
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			schemaErr = fmt.Errorf("invalid %s schema: %w", kind, err)
+			return
+		}
+		if err := compiler.AddResource(url, doc); err != nil {
+			schemaErr = fmt.Errorf("invalid %s schema: %w", kind, err)
+			return
+		}
+	}
+
+	compiledSchema = make(map[string]*jsonschema.Schema)
+	for kind, url := range urls {
+		schema, err := compiler.Compile(url)
+		if err != nil {
+			schemaErr = fmt.Errorf("invalid %s schema: %w", kind, err)
+			return
+		}
+		compiledSchema[kind] = schema
+	}
+}
+
+// schemaErrors flattens a validation error into its innermost causes, located
+// in data. prefix is the location of the validated value within data.
+func schemaErrors(data []byte, validationErr *jsonschema.ValidationError, prefix []string) []SchemaError {
+	printer := message.NewPrinter(language.English)
+	location := append(append([]string{}, prefix...), validationErr.InstanceLocation...)
+
+	// Unknown fields and badly named packages are each reported at their key
+	if additional, ok := validationErr.ErrorKind.(*kind.AdditionalProperties); ok {
+		var problems []SchemaError
+		for _, property := range additional.Properties {
+			keyLocation := append(slices.Clone(location), property)
+			line, column := position(data, valueOffset(data, keyLocation, true))
+			problems = append(problems, SchemaError{
+				Pointer: pointer(keyLocation),
+				Line:    line,
+				Column:  column,
+				Message: fmt.Sprintf("'%s' is not an allowed name here", property),
+			})
+		}
+		return problems
+	}
+
+	if len(validationErr.Causes) > 0 {
+		var problems []SchemaError
+		for _, cause := range validationErr.Causes {
+			problems = append(problems, schemaErrors(data, cause, prefix)...)
+		}
+		return problems
+	}
+
+	line, column := position(data, valueOffset(data, location, false))
+	return []SchemaError{{
+		Pointer: pointer(location),
+		Line:    line,
+		Column:  column,
+		Message: validationErr.ErrorKind.LocalizedString(printer),
+	}}
+}
+
+// syntaxError locates a JSON parse error
+func syntaxError(data []byte, err error) SchemaError {
+	offset := int64(len(data))
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+	line, column := position(data, offset)
+	return SchemaError{Line: line, Column: column, Message: "invalid JSON: " + err.Error()}
+}
+
+// pointer formats a location as a JSON pointer
+func pointer(location []string) string {
+	var b strings.Builder
+	for _, token := range location {
+		token = strings.ReplaceAll(token, "~", "~0")
+		b.WriteString("/" + strings.ReplaceAll(token, "/", "~1"))
+	}
+	return b.String()
+}
+
+// valueOffset returns the byte offset of the value at location in data, or of
+// its key when atKey is set. Missing values give the deepest enclosing value.
+func valueOffset(data []byte, location []string, atKey bool) int64 {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	offset := int64(0)
+
+	for {
+		offset = skipSpace(data, decoder.InputOffset())
+		if len(location) == 0 {
+			return offset
+		}
+
+		token, err := decoder.Token()
+		if err != nil {
+			return offset
+		}
+		delim, ok := token.(json.Delim)
+		if !ok || (delim != '{' && delim != '[') {
+			return offset
+		}
+
+		found := false
+		for index := 0; decoder.More(); index++ {
+			if delim == '{' {
+				keyOffset := skipSpace(data, decoder.InputOffset())
+				key, err := decoder.Token()
+				if err != nil {
+					return offset
+				}
+				found = key == location[0]
+				if found && atKey && len(location) == 1 {
+					return keyOffset
+				}
+			} else {
+				found = strconv.Itoa(index) == location[0]
+			}
+			if found {
+				break
+			}
+			if skipValue(decoder) != nil {
+				return offset
+			}
+		}
+		if !found {
+			return offset
+		}
+		location = location[1:]
+	}
+}
+
+// skipValue reads past the next value, however deeply nested
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// skipSpace moves offset past whitespace and the separators between values
+func skipSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// position converts a byte offset in data to a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,102 @@
+package manifest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	project := `{
+  "version": "1.0.0",
+  "dependencies": {
+    "security-rules": "1.2.0",
+    "Bad_Name": "1.0.0"
+  },
+  "targets": ["cursor", "vim"],
+  "storage": "disk"
+}`
+	kind, problems, err := ValidateSchema([]byte(project))
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if kind != SchemaProject {
+		t.Errorf("expected a project manifest, got %s", kind)
+	}
+
+	want := map[string][2]int{
+		"/dependencies/Bad_Name": {5, 5},
+		"/targets/1":             {7, 25},
+		"/storage":               {8, 14},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), problems)
+	}
+	for _, problem := range problems {
+		position, ok := want[problem.Pointer]
+		if !ok {
+			t.Errorf("unexpected problem %v", problem)
+			continue
+		}
+		if problem.Line != position[0] || problem.Column != position[1] {
+			t.Errorf("expected %s at %d:%d, got %d:%d", problem.Pointer, position[0], position[1], problem.Line, problem.Column)
+		}
+	}
+
+	// Each package of a multi-package file is checked
+	packages := `[
+  {"name": "security-rules", "version": "1.0.0", "files": ["rules/*.md"]},
+  {"name": "logging-rules", "version": "1.0", "files": ["rules/*.md"], "flies": []}
+]`
+	kind, problems, err = ValidateSchema([]byte(packages))
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if kind != SchemaPackage || len(problems) != 2 {
+		t.Fatalf("expected two package problems, got %s %v", kind, problems)
+	}
+	for _, problem := range problems {
+		if problem.Line != 3 || !strings.HasPrefix(problem.Pointer, "/1") {
+			t.Errorf("expected problems in the second package on line 3, got %v", problem)
+		}
+	}
+
+	_, problems, _ = ValidateSchema([]byte("{\n  \"version\": \"1.0.0\",\n}"))
+	if len(problems) != 1 || problems[0].Line != 3 || !strings.Contains(problems[0].Message, "invalid JSON") {
+		t.Errorf("expected a located syntax error, got %v", problems)
+	}
+}
+
+func TestSchemaCoversManifests(t *testing.T) {
+	// Every field rfh writes must be allowed by the schemas
+	project := CreateProjectManifest()
+	project.Dependencies["security-rules"] = "1.2.0"
+	project.Priority = []string{"security-rules"}
+	project.Constraints = "policy/constraints.json"
+	project.Overrides = map[string]string{"security-rules": "security-rules-fork@1.2.1"}
+	project.Mirrors = []string{"public"}
+	project.Aliases = map[string]string{"security-rules-v1": "security-rules@1.0.0"}
+	project.Targets = []string{"claude-code"}
+	project.Extends = "org-base@^2"
+	project.Quarantine = true
+	project.Template = "1.0.0"
+	project.Storage = StorageCache
+	project.Registry = "team"
+	project.Conditions = map[string]Condition{"security-rules": {Targets: []string{"cursor"}, OS: []string{"linux"}}}
+
+	meta := PackageManifest{
+		Schema:       PackageSchemaURL,
+		Name:         "backend-profile",
+		Version:      "1.0.0",
+		Type:         PackageTypeMeta,
+		Dependencies: map[string]string{"security-rules": "1.2.0"},
+		Requires:     &Requirements{Targets: []string{"cursor"}, Core: "1.0.0", Peers: map[string]string{"logging-rules": "1.0.0"}},
+	}
+
+	for _, value := range []any{project, meta} {
+		data, _ := json.Marshal(value)
+		if _, problems, err := ValidateSchema(data); err != nil || len(problems) > 0 {
+			t.Errorf("expected %s to match its schema, got %v %v", data, problems, err)
+		}
+	}
+}
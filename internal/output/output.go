@@ -0,0 +1,170 @@
+// Package output writes the CLI's human-readable output. Every command prints
+// through it so that plain mode applies everywhere: emoji and other decorative
+// symbols, colour codes and live progress are dropped, leaving clean lines for
+// CI logs and screen readers. Status symbols become words, so "✅ Installed"
+// reads "ok: Installed" and "❌ rules.md" reads "error: rules.md".
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+var (
+	mu    sync.Mutex
+	plain = detectPlain()
+)
+
+// detectPlain turns plain mode on by default when NO_COLOR is set, the terminal
+// is dumb, or stdout is not a terminal, e.g. when piped to a file or a CI log
+func detectPlain() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	return !term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// SetPlain turns plain mode on or off, overriding the detected default
+func SetPlain(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	plain = on
+}
+
+// Plain reports whether plain mode is on
+func Plain() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return plain
+}
+
+// Printf formats and writes to stdout
+func Printf(format string, args ...interface{}) {
+	Fprintf(os.Stdout, format, args...)
+}
+
+// Println writes its arguments to stdout, separated by spaces, and a newline
+func Println(args ...interface{}) {
+	write(os.Stdout, fmt.Sprintln(args...))
+}
+
+// Print writes its arguments to stdout, as fmt.Print does
+func Print(args ...interface{}) {
+	write(os.Stdout, fmt.Sprint(args...))
+}
+
+// Fprintf formats and writes to w, typically os.Stderr
+func Fprintf(w io.Writer, format string, args ...interface{}) {
+	write(w, fmt.Sprintf(format, args...))
+}
+
+// Data writes machine-readable output, such as JSON, to stdout unchanged and
+// ends it with a newline
+func Data(data []byte) {
+	os.Stdout.Write(append(data, '\n'))
+}
+
+// Live returns where live progress that redraws itself, such as git's, is
+// written: stdout, or nil in plain mode
+func Live() io.Writer {
+	if Plain() {
+		return nil
+	}
+	return os.Stdout
+}
+
+func write(w io.Writer, s string) {
+	if Plain() {
+		s = Strip(s)
+	}
+	io.WriteString(w, s)
+}
+
+var (
+	// ansiSequence matches terminal escape sequences such as colour codes
+	ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+	// trailingSpaces matches the spaces a removed symbol leaves at a line's end
+	trailingSpaces = regexp.MustCompile(` +\n`)
+)
+
+// statusWords replace the symbols that carry meaning
+var statusWords = map[rune]string{
+	'✅': "ok",
+	'❌': "error",
+	'⚠': "warning",
+}
+
+// plainSymbols replace decorative characters that screen readers announce by name
+var plainSymbols = map[rune]string{
+	'═': "=",
+	'•': "-",
+	'…': "...",
+	'—': "-",
+}
+
+// Strip removes escape sequences and emoji from s, with the spaces that follow
+// each emoji, and replaces status symbols with words. Indentation before a
+// symbol is kept.
+func Strip(s string) string {
+	s = ansiSequence.ReplaceAllString(s, "")
+
+	var b strings.Builder
+	skipSpaces := false
+	for i, r := range s {
+		if skipSpaces {
+			if r == ' ' || isEmoji(r) {
+				continue
+			}
+			skipSpaces = false
+		}
+
+		if word, ok := statusWords[r]; ok {
+			skipSpaces = true
+			// "⚠️ Warning: ..." already says it
+			rest := strings.TrimLeft(s[i+utf8.RuneLen(r):], " \ufe0f")
+			if strings.HasPrefix(strings.ToLower(rest), word) || rest == "" || rest[0] == '\n' {
+				continue
+			}
+			b.WriteString(word + ": ")
+			continue
+		}
+		if isEmoji(r) {
+			skipSpaces = true
+			continue
+		}
+		if replacement, ok := plainSymbols[r]; ok {
+			b.WriteString(replacement)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return trailingSpaces.ReplaceAllString(b.String(), "\n")
+}
+
+// isEmoji reports whether r is an emoji or symbol used only to decorate output,
+// or a character that joins or styles one
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport and supplemental symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Technical symbols such as ⏳ and ⏭
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Stars and arrows such as ⭐ and ⬆
+		return true
+	case r == 0x2139: // ℹ
+		return true
+	case r == 0xFE0F || r == 0xFE0E || r == 0x200D || r == 0x20E3: // Variation selectors, joiner, keycap
+		return true
+	}
+	return false
+}
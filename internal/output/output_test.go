@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"📦 Package: security-rules\n", "Package: security-rules\n"},
+		{"   📄 rules/secure.mdc\n", "   rules/secure.mdc\n"},
+		{"✅ Installed security-rules@1.2.0\n", "ok: Installed security-rules@1.2.0\n"},
+		{"❌ fixture (case.json)\n", "error: fixture (case.json)\n"},
+		{"⚠️ Warning: Failed to update CLAUDE.md\n", "Warning: Failed to update CLAUDE.md\n"},
+		{"\n⚠️  🚨 SECURITY WARNING 🚨 ⚠️\n", "\nwarning: SECURITY WARNING\n"},
+		{"🛡️  Security: ❌ unsafe path\n", "Security: error: unsafe path\n"},
+		{"\x1b[32mgreen\x1b[0m 1.0.0 → 1.1.0\n", "green 1.0.0 → 1.1.0\n"},
+		{"═══ Summary ═══\n• one…\n", "=== Summary ===\n- one...\n"},
+		{"Continue? (y/N) ", "Continue? (y/N) "},
+	}
+
+	for _, tc := range testCases {
+		if got := Strip(tc.input); got != tc.want {
+			t.Errorf("Strip(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestPlainMode(t *testing.T) {
+	defer SetPlain(Plain())
+
+	var buf bytes.Buffer
+	SetPlain(false)
+	Fprintf(&buf, "✅ %s\n", "done")
+	SetPlain(true)
+	Fprintf(&buf, "✅ %s\n", "done")
+
+	if buf.String() != "✅ done\nok: done\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+	if Live() != nil {
+		t.Error("expected no live progress in plain mode")
+	}
+}
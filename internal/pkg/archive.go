@@ -4,22 +4,35 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"rulestack/internal/compression"
+	"rulestack/internal/integrity"
+	"rulestack/internal/progress"
 	"rulestack/internal/security"
 
 	"github.com/bmatcuk/doublestar/v4"
 )
 
+// Compression selects how an archive is compressed. The zero value is gzip at its
+// default level.
+type Compression struct {
+	Format string // compression.Gzip or compression.Zstd
+	Level  int    // Format-specific level; 0 uses the format's default
+}
+
 // ArchiveInfo contains information about a created archive
 type ArchiveInfo struct {
 	Path      string
 	SHA256    string
+	Integrity string // Digests in every supported algorithm, as sha256-... sha512-...
 	SizeBytes int64
+	Files     []PackedFile // Files in the archive, in the order they were added
 }
 
 // Pack creates a tar.gz archive from file patterns
@@ -61,7 +74,7 @@ func Pack(patterns []string, outputPath string) (*ArchiveInfo, error) {
 	defer outFile.Close()
 
 	// Create hash writer
-	hasher := sha256.New()
+	hasher := integrity.NewHasher()
 	multiWriter := io.MultiWriter(outFile, hasher)
 
 	// Create gzip writer
@@ -73,15 +86,21 @@ func Pack(patterns []string, outputPath string) (*ArchiveInfo, error) {
 	defer tarWriter.Close()
 
 	var totalSize int64
+	var packed []PackedFile
+	paths := security.NewPathSet()
 
 	// Add each file to the archive
 	for _, filePath := range files {
+		if err := checkPackPath(paths, filepath.ToSlash(filePath)); err != nil {
+			return nil, err
+		}
 		if err := addFileToArchive(tarWriter, filePath); err != nil {
 			return nil, fmt.Errorf("failed to add file %s: %w", filePath, err)
 		}
 
 		if info, err := os.Stat(filePath); err == nil {
 			totalSize += info.Size()
+			packed = append(packed, PackedFile{Path: filepath.ToSlash(filePath), Size: info.Size()})
 		}
 	}
 
@@ -98,14 +117,28 @@ func Pack(patterns []string, outputPath string) (*ArchiveInfo, error) {
 
 	return &ArchiveInfo{
 		Path:      outputPath,
-		SHA256:    fmt.Sprintf("%x", hasher.Sum(nil)),
+		SHA256:    hasher.SHA256(),
+		Integrity: hasher.Integrity().String(),
 		SizeBytes: info.Size(),
+		Files:     packed,
 	}, nil
 }
 
+// checkPackPath refuses to pack a file that could not be extracted on every
+// platform, rather than publishing a package some users cannot install
+func checkPackPath(paths *security.PathSet, name string) error {
+	if err := security.ValidatePortablePath(name); err != nil {
+		return fmt.Errorf("cannot pack %s: %w", name, err)
+	}
+	if err := paths.Add(name); err != nil {
+		return fmt.Errorf("cannot pack %s: %w", name, err)
+	}
+	return nil
+}
+
 // addFileToArchive adds a single file to the tar archive
 func addFileToArchive(tarWriter *tar.Writer, filePath string) error {
-	file, err := os.Open(filePath)
+	file, err := os.Open(security.LongPath(filePath))
 	if err != nil {
 		return err
 	}
@@ -135,121 +168,21 @@ func addFileToArchive(tarWriter *tar.Writer, filePath string) error {
 	return err
 }
 
-// Unpack extracts a tar.gz archive to a destination directory with security validation
-func Unpack(archivePath string, destDir string) error {
-	// First, validate the archive for security
-	validator := security.NewPackageValidator(nil)
-	if err := validator.ValidateArchive(archivePath, destDir); err != nil {
-		return fmt.Errorf("security validation failed: %w", err)
-	}
-
-	// If validation passes, proceed with extraction
-	return UnpackValidated(archivePath, destDir)
-}
-
-// UnpackValidated extracts a pre-validated archive (internal use)
-func UnpackValidated(archivePath string, destDir string) error {
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("failed to open archive: %w", err)
-	}
-	defer file.Close()
-
-	// Create gzip reader
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
-
-	// Create tar reader
-	tarReader := tar.NewReader(gzReader)
-
-	// Extract files
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to read tar header: %w", err)
-		}
-
-		if err := extractFileSecure(tarReader, header, destDir); err != nil {
-			return fmt.Errorf("failed to extract file %s: %w", header.Name, err)
+// Unpack validates and extracts an archive to a destination directory in one
+// pass, and reports the files extracted with their sizes and hashes
+func Unpack(archivePath string, destDir string) (*security.ExtractReport, error) {
+	// Fail before writing anything when the files will not fit
+	if size, err := UncompressedSize(archivePath); err == nil {
+		if err := CheckFreeSpace(destDir, size, "extract "+filepath.Base(archivePath)); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
-}
-
-// extractFileSecure extracts a single file from tar archive with enhanced security
-func extractFileSecure(tarReader *tar.Reader, header *tar.Header, destDir string) error {
-	// Validate file path (redundant with validator, but defense in depth)
-	if err := validateExtractionPath(header.Name, destDir); err != nil {
-		return err
-	}
-
-	destPath := filepath.Join(destDir, header.Name)
-
-	// Handle directories
-	if header.Typeflag == tar.TypeDir {
-		return os.MkdirAll(destPath, 0o755)
-	}
-
-	// Only handle regular files (symlinks and other types rejected by validator)
-	if header.Typeflag != tar.TypeReg {
-		return fmt.Errorf("unsupported file type: %c", header.Typeflag)
-	}
-
-	// Create directory if needed
-	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
-		return err
-	}
-
-	// Create file with safe permissions
-	outFile, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	report, err := security.NewPackageValidator(nil).ExtractArchive(archivePath, destDir)
 	if err != nil {
-		return err
-	}
-	defer outFile.Close()
-
-	// Copy file content with size limit (defense in depth)
-	_, err = io.CopyN(outFile, tarReader, security.MaxFileSize)
-	if err != nil && err != io.EOF {
-		return err
+		return nil, fmt.Errorf("security validation failed: %w", err)
 	}
-
-	return nil
-}
-
-// validateExtractionPath validates the extraction path for security
-func validateExtractionPath(filePath, destDir string) error {
-	// Reject absolute paths
-	if filepath.IsAbs(filePath) {
-		return fmt.Errorf("absolute paths not allowed: %s", filePath)
-	}
-
-	// Reject paths with .. segments
-	if strings.Contains(filePath, "..") {
-		return fmt.Errorf("path traversal attempt: %s", filePath)
-	}
-
-	// Ensure the final path is within the destination directory
-	destPath := filepath.Join(destDir, filePath)
-	cleanDest := filepath.Clean(destPath)
-	cleanDestDir := filepath.Clean(destDir)
-
-	if !strings.HasPrefix(cleanDest, cleanDestDir) {
-		return fmt.Errorf("path escapes destination directory: %s", filePath)
-	}
-
-	return nil
-}
-
-// extractFile extracts a single file from tar archive (legacy function for compatibility)
-func extractFile(tarReader *tar.Reader, header *tar.Header, destDir string) error {
-	return extractFileSecure(tarReader, header, destDir)
+	return report, nil
 }
 
 // CalculateSHA256 calculates SHA256 hash of a file
@@ -270,6 +203,12 @@ func CalculateSHA256(filePath string) (string, error) {
 
 // PackFromDirectory creates a tar.gz archive from all files in a directory
 func PackFromDirectory(sourceDir string, outputPath string) (*ArchiveInfo, error) {
+	return PackFromDirectoryCompressed(sourceDir, outputPath, Compression{})
+}
+
+// PackFromDirectoryCompressed creates an archive from all files in a directory,
+// compressed with the given format and level
+func PackFromDirectoryCompressed(sourceDir string, outputPath string, c Compression) (*ArchiveInfo, error) {
 	// Walk the directory and collect all files
 	var files []string
 	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
@@ -298,11 +237,20 @@ func PackFromDirectory(sourceDir string, outputPath string) (*ArchiveInfo, error
 
 	// Use the existing Pack function but we need to handle the paths differently
 	// Let's create the archive manually
-	return packFiles(files, sourceDir, outputPath)
+	return packFiles(files, sourceDir, outputPath, c)
 }
 
 // packFiles creates archive from specific files with a base directory
-func packFiles(filePaths []string, baseDir string, outputPath string) (*ArchiveInfo, error) {
+func packFiles(filePaths []string, baseDir string, outputPath string, c Compression) (*ArchiveInfo, error) {
+	subject := filepath.Base(outputPath)
+	progress.Start("pack", subject)
+	info, err := writeArchive(filePaths, baseDir, outputPath, c)
+	progress.Done("pack", subject, err)
+	return info, err
+}
+
+// writeArchive does the work of packFiles, reporting each file as a pack step
+func writeArchive(filePaths []string, baseDir string, outputPath string, c Compression) (*ArchiveInfo, error) {
 	// Create output file
 	outputFile, err := os.Create(outputPath)
 	if err != nil {
@@ -310,38 +258,38 @@ func packFiles(filePaths []string, baseDir string, outputPath string) (*ArchiveI
 	}
 	defer outputFile.Close()
 
-	// Create gzip writer
-	gzWriter := gzip.NewWriter(outputFile)
-	defer gzWriter.Close()
-
-	// Create tar writer
-	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
-
-	// Hash calculator for final SHA256
-	hasher := sha256.New()
+	// Hash calculator for the final digests
+	hasher := integrity.NewHasher()
 	multiWriter := io.MultiWriter(outputFile, hasher)
 
-	// Reset and create new writers with hash calculation
-	outputFile.Seek(0, 0)
-	outputFile.Truncate(0)
-
-	gzWriter = gzip.NewWriter(multiWriter)
-	defer gzWriter.Close()
+	// Create compressing writer
+	compressor, err := compression.NewWriter(multiWriter, c.Format, c.Level)
+	if err != nil {
+		return nil, err
+	}
+	defer compressor.Close()
 
-	tarWriter = tar.NewWriter(gzWriter)
+	// Create tar writer
+	tarWriter := tar.NewWriter(compressor)
 	defer tarWriter.Close()
 
+	var packed []PackedFile
+	paths := security.NewPathSet()
+
 	// Add files to archive
-	for _, filePath := range filePaths {
+	for i, filePath := range filePaths {
 		// Get relative path from base directory
 		relPath, err := filepath.Rel(baseDir, filePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get relative path for %s: %w", filePath, err)
 		}
+		if err := checkPackPath(paths, filepath.ToSlash(relPath)); err != nil {
+			return nil, err
+		}
+		progress.Step("pack", "file", filepath.ToSlash(relPath), i, len(filePaths))
 
 		// Open file
-		file, err := os.Open(filePath)
+		file, err := os.Open(security.LongPath(filePath))
 		if err != nil {
 			return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
 		}
@@ -374,11 +322,16 @@ func packFiles(filePaths []string, baseDir string, outputPath string) (*ArchiveI
 		}
 
 		file.Close()
+		packed = append(packed, PackedFile{Path: header.Name, Size: header.Size})
 	}
 
 	// Close writers to flush data
-	tarWriter.Close()
-	gzWriter.Close()
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
 
 	// Get file size
 	stat, err := outputFile.Stat()
@@ -388,49 +341,104 @@ func packFiles(filePaths []string, baseDir string, outputPath string) (*ArchiveI
 
 	return &ArchiveInfo{
 		Path:      outputPath,
-		SHA256:    fmt.Sprintf("%x", hasher.Sum(nil)),
+		SHA256:    hasher.SHA256(),
+		Integrity: hasher.Integrity().String(),
 		SizeBytes: stat.Size(),
+		Files:     packed,
 	}, nil
 }
 
+// errStopWalk ends a walk over an archive early
+var errStopWalk = errors.New("stop walk")
+
 // ExtractManifest extracts only the rulestack.json manifest from an archive
 func ExtractManifest(archivePath string) ([]byte, error) {
+	var manifestData []byte
+	err := WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		if header.Name != "rulestack.json" && !strings.HasSuffix(header.Name, "/rulestack.json") {
+			return nil
+		}
+
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest from archive: %w", err)
+		}
+		manifestData = data
+		return errStopWalk
+	})
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("no manifest (rulestack.json) found in archive")
+	}
+
+	return manifestData, nil
+}
+
+// HashFiles returns the SHA-256 of every regular file in an archive, keyed by its
+// slash-separated path as it is extracted
+func HashFiles(archivePath string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, content); err != nil {
+			return fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		hashes[filepath.ToSlash(filepath.Clean(header.Name))] = fmt.Sprintf("%x", hasher.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// WalkFiles calls fn with every regular file in an archive, in archive order. The
+// content reader is only valid until fn returns. Files are not validated; use
+// Unpack for archives that will be installed.
+func WalkFiles(archivePath string, fn func(header *tar.Header, content io.Reader) error) error {
+	return security.WalkArchive(archivePath, func(header *tar.Header, content io.Reader) error {
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		return fn(header, content)
+	})
+}
+
+// Recompress writes a copy of an archive compressed with c. The tar stream inside
+// is copied unchanged.
+func Recompress(archivePath, outputPath string, c Compression) error {
 	file, err := os.Open(archivePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open archive: %w", err)
+		return fmt.Errorf("failed to open archive: %w", err)
 	}
 	defer file.Close()
 
-	// Create gzip reader
-	gzReader, err := gzip.NewReader(file)
+	decompressed, err := compression.NewReader(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return err
 	}
-	defer gzReader.Close()
-
-	// Create tar reader
-	tarReader := tar.NewReader(gzReader)
+	defer decompressed.Close()
 
-	// Look for rulestack.json file
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read tar header: %w", err)
-		}
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFile.Close()
 
-		// Check if this is the manifest file
-		if header.Name == "rulestack.json" || strings.HasSuffix(header.Name, "/rulestack.json") {
-			// Read the manifest content
-			manifestData, err := io.ReadAll(tarReader)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read manifest from archive: %w", err)
-			}
-			return manifestData, nil
-		}
+	compressor, err := compression.NewWriter(outputFile, c.Format, c.Level)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(compressor, decompressed); err != nil {
+		compressor.Close()
+		return fmt.Errorf("failed to recompress archive: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("failed to recompress archive: %w", err)
 	}
 
-	return nil, fmt.Errorf("no manifest (rulestack.json) found in archive")
+	return outputFile.Close()
 }
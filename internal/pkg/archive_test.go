@@ -1,9 +1,15 @@
 package pkg
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+
+	"rulestack/internal/compression"
 )
 
 func TestCalculateSHA256(t *testing.T) {
@@ -206,9 +212,18 @@ func TestUnpack(t *testing.T) {
 	}
 
 	t.Run("unpacks archive successfully", func(t *testing.T) {
-		err := Unpack(archivePath, destDir)
+		report, err := Unpack(archivePath, destDir)
 		if err != nil {
-			t.Errorf("unexpected error: %v", err)
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The report hashes each file as HashFiles does
+		hashes, _ := HashFiles(archivePath)
+		if len(report.Files) != len(testFiles) || fmt.Sprint(report.Hashes()) != fmt.Sprint(hashes) {
+			t.Errorf("unexpected extraction report %+v", report)
+		}
+		if report.TotalSize != int64(len("content1")+len("content2")) {
+			t.Errorf("expected total size %d, got %d", len("content1")+len("content2"), report.TotalSize)
 		}
 
 		// Verify files were extracted
@@ -232,11 +247,24 @@ func TestUnpack(t *testing.T) {
 	})
 
 	t.Run("fails with non-existent archive", func(t *testing.T) {
-		err := Unpack("nonexistent.tgz", destDir)
+		_, err := Unpack("nonexistent.tgz", destDir)
 		if err == nil {
 			t.Error("expected error for non-existent archive")
 		}
 	})
+
+	t.Run("hashes archived files", func(t *testing.T) {
+		hashes, err := HashFiles(archivePath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(hashes) != len(testFiles) {
+			t.Fatalf("expected %d hashes, got %v", len(testFiles), hashes)
+		}
+		if hashes["subdir/file2.txt"] != fmt.Sprintf("%x", sha256.Sum256([]byte("content2"))) {
+			t.Errorf("unexpected hash for subdir/file2.txt: %s", hashes["subdir/file2.txt"])
+		}
+	})
 }
 
 func TestExtractFileDirectoryTraversal(t *testing.T) {
@@ -267,3 +295,149 @@ func TestExtractFileDirectoryTraversal(t *testing.T) {
 		}
 	}
 }
+
+func TestPackFromDirectoryCompressed(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := map[string]string{
+		"rulestack.json":   `{"name": "my-rules", "version": "1.0.0"}`,
+		"rules/secure.mdc": "never log secrets",
+	}
+	for name, content := range files {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	for _, format := range compression.Formats {
+		t.Run(format, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "my-rules-1.0.0"+compression.Extension(format))
+			info, err := PackFromDirectoryCompressed(sourceDir, archivePath, Compression{Format: format, Level: 3})
+			if err != nil {
+				t.Fatalf("PackFromDirectoryCompressed failed: %v", err)
+			}
+
+			// The reported hash and size describe the file as written
+			if sum, _ := CalculateSHA256(archivePath); sum != info.SHA256 {
+				t.Errorf("expected reported sha256 %s to match the file's %s", info.SHA256, sum)
+			}
+			if stat, _ := os.Stat(archivePath); stat.Size() != info.SizeBytes {
+				t.Errorf("expected reported size %d to match the file's %d", info.SizeBytes, stat.Size())
+			}
+			if detected, _ := compression.DetectFile(archivePath); detected != format {
+				t.Errorf("expected a %s archive, got %q", format, detected)
+			}
+
+			if data, err := ExtractManifest(archivePath); err != nil || string(data) != files["rulestack.json"] {
+				t.Errorf("expected the manifest extracted, got %q (%v)", data, err)
+			}
+
+			destDir := t.TempDir()
+			if _, err := Unpack(archivePath, destDir); err != nil {
+				t.Fatalf("Unpack failed: %v", err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(destDir, "rules", "secure.mdc")); string(data) != files["rules/secure.mdc"] {
+				t.Errorf("unexpected unpacked content %q", data)
+			}
+
+			// Recompressing keeps the files and switches the format
+			gzipPath := filepath.Join(t.TempDir(), "copy.tgz")
+			if err := Recompress(archivePath, gzipPath, Compression{}); err != nil {
+				t.Fatalf("Recompress failed: %v", err)
+			}
+			original, _ := HashFiles(archivePath)
+			recompressed, err := HashFiles(gzipPath)
+			if err != nil || fmt.Sprint(recompressed) != fmt.Sprint(original) {
+				t.Errorf("expected the same files after recompressing, got %v (%v)", recompressed, err)
+			}
+			if detected, _ := compression.DetectFile(gzipPath); detected != compression.Gzip {
+				t.Errorf("expected a gzip copy, got %q", detected)
+			}
+		})
+	}
+}
+
+func TestPackRejectsUnportablePaths(t *testing.T) {
+	sourceDir := t.TempDir()
+	for _, name := range []string{"rulestack.json", "Rules.md", "rules.md"} {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte("{}"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	_, err := PackFromDirectory(sourceDir, filepath.Join(t.TempDir(), "out.tgz"))
+	if err == nil || !strings.Contains(err.Error(), "differ only in case") {
+		t.Errorf("expected a case collision error, got %v", err)
+	}
+}
+
+// writeRulePack fills dir with a pack the size of a typical published one: a
+// manifest and a few dozen rule files of a few kilobytes each
+func writeRulePack(tb testing.TB, dir string) {
+	tb.Helper()
+
+	files := map[string]string{
+		"rulestack.json": `{"name": "bench-rules", "version": "1.0.0", "description": "Benchmark rules"}`,
+	}
+	for i := 0; i < 48; i++ {
+		var rule strings.Builder
+		fmt.Fprintf(&rule, "# Rule %d\n\n", i)
+		for j := 0; j < 40; j++ {
+			fmt.Fprintf(&rule, "- Always validate input %d before passing it to handler %d; reject and log otherwise.\n", j, i)
+		}
+		files[fmt.Sprintf("rules/area-%d/rule-%d.mdc", i%6, i)] = rule.String()
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tb.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func BenchmarkPack(b *testing.B) {
+	sourceDir := b.TempDir()
+	writeRulePack(b, sourceDir)
+
+	for _, format := range compression.Formats {
+		b.Run(format, func(b *testing.B) {
+			archivePath := filepath.Join(b.TempDir(), "bench-rules"+compression.Extension(format))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := PackFromDirectoryCompressed(sourceDir, archivePath, Compression{Format: format}); err != nil {
+					b.Fatalf("PackFromDirectoryCompressed failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnpack(b *testing.B) {
+	sourceDir := b.TempDir()
+	writeRulePack(b, sourceDir)
+
+	for _, format := range compression.Formats {
+		b.Run(format, func(b *testing.B) {
+			archivePath := filepath.Join(b.TempDir(), "bench-rules"+compression.Extension(format))
+			if _, err := PackFromDirectoryCompressed(sourceDir, archivePath, Compression{Format: format}); err != nil {
+				b.Fatalf("PackFromDirectoryCompressed failed: %v", err)
+			}
+			destRoot := b.TempDir()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := Unpack(archivePath, filepath.Join(destRoot, strconv.Itoa(i))); err != nil {
+					b.Fatalf("Unpack failed: %v", err)
+				}
+			}
+		})
+	}
+}
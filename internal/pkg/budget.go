@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PackedFile is a file added to an archive, with its uncompressed size
+type PackedFile struct {
+	Path string
+	Size int64
+}
+
+// Budget bounds the size of a package archive. Zero fields are not checked.
+type Budget struct {
+	MaxArchiveSize int64 // Compressed archive size in bytes
+	MaxFiles       int   // Number of files in the archive
+	MaxFileSize    int64 // Uncompressed size of any single file in bytes
+}
+
+// Check returns a problem for every budget the archive exceeds
+func (b Budget) Check(info *ArchiveInfo) []error {
+	var problems []error
+
+	if b.MaxArchiveSize > 0 && info.SizeBytes > b.MaxArchiveSize {
+		problems = append(problems, fmt.Errorf("archive is %s, over the %s budget",
+			FormatSize(info.SizeBytes), FormatSize(b.MaxArchiveSize)))
+	}
+
+	if b.MaxFiles > 0 && len(info.Files) > b.MaxFiles {
+		problems = append(problems, fmt.Errorf("archive has %d files, over the %d file budget",
+			len(info.Files), b.MaxFiles))
+	}
+
+	if b.MaxFileSize > 0 {
+		for _, file := range info.Files {
+			if file.Size > b.MaxFileSize {
+				problems = append(problems, fmt.Errorf("%s is %s, over the %s per-file budget",
+					file.Path, FormatSize(file.Size), FormatSize(b.MaxFileSize)))
+			}
+		}
+	}
+
+	return problems
+}
+
+// LargestFiles returns up to n files, largest first
+func LargestFiles(files []PackedFile, n int) []PackedFile {
+	sorted := append([]PackedFile(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// FormatSize renders a byte count for display, e.g. "512 B" or "1.5 MiB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
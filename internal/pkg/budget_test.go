@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBudgetCheck(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := map[string]int{"rules/small.mdc": 100, "rules/large.mdc": 4096, "rulestack.json": 50}
+	for name, size := range files {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	info, err := PackFromDirectory(sourceDir, filepath.Join(t.TempDir(), "test.tgz"))
+	if err != nil {
+		t.Fatalf("PackFromDirectory failed: %v", err)
+	}
+	if len(info.Files) != 3 {
+		t.Fatalf("expected 3 packed files, got %v", info.Files)
+	}
+
+	if problems := (Budget{}).Check(info); len(problems) != 0 {
+		t.Errorf("expected an empty budget to check nothing, got %v", problems)
+	}
+
+	budget := Budget{MaxArchiveSize: 10, MaxFiles: 2, MaxFileSize: 1024}
+	problems := budget.Check(info)
+	if len(problems) != 3 {
+		t.Fatalf("expected archive size, file count and large file problems, got %v", problems)
+	}
+	if !strings.Contains(problems[2].Error(), "rules/large.mdc is 4.0 KiB") {
+		t.Errorf("expected the large file named, got %v", problems[2])
+	}
+
+	largest := LargestFiles(info.Files, 2)
+	if len(largest) != 2 || largest[0].Path != "rules/large.mdc" || largest[1].Path != "rules/small.mdc" {
+		t.Errorf("expected large.mdc then small.mdc, got %v", largest)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:               "512 B",
+		1536:              "1.5 KiB",
+		10 << 20:          "10.0 MiB",
+		3 * (1 << 30) / 2: "1.5 GiB",
+	}
+
+	for bytes, want := range tests {
+		if got := FormatSize(bytes); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
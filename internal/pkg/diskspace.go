@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrInsufficientSpace is returned when a file system has too little free space
+// for a download or extraction
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// freeSpaceMargin is kept free on top of what a write needs, for the lock file,
+// manifests and anything else written meanwhile
+const freeSpaceMargin = 1 << 20
+
+// CheckFreeSpace fails with ErrInsufficientSpace when the file system holding
+// dir has less than need bytes free, so a download or extraction fails up front
+// instead of partway through. dir need not exist yet. Unknown sizes (zero or
+// less) and platforms where free space cannot be read always pass.
+func CheckFreeSpace(dir string, need int64, purpose string) error {
+	if need <= 0 {
+		return nil
+	}
+
+	free, err := freeSpace(existingDir(dir))
+	if err != nil {
+		return nil
+	}
+	if free < need+freeSpaceMargin {
+		return fmt.Errorf("%w in %s to %s: %s needed, %s available",
+			ErrInsufficientSpace, dir, purpose, FormatSize(need), FormatSize(free))
+	}
+	return nil
+}
+
+// UncompressedSize returns the total size of the files in an archive, read from
+// its headers without extracting it
+func UncompressedSize(archivePath string) (int64, error) {
+	var total int64
+	err := WalkFiles(archivePath, func(header *tar.Header, content io.Reader) error {
+		total += header.Size
+		return nil
+	})
+	return total, err
+}
+
+// existingDir returns dir, or its nearest ancestor that exists
+func existingDir(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package pkg
+
+import "errors"
+
+// freeSpace is not implemented on this platform, so space checks pass
+func freeSpace(dir string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
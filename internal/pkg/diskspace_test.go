@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	// Directories that do not exist yet are checked on their nearest ancestor
+	missing := filepath.Join(dir, "not", "created")
+	if err := CheckFreeSpace(missing, 1024, "extract rules"); err != nil {
+		t.Errorf("expected a small write to fit, got %v", err)
+	}
+	if err := CheckFreeSpace(dir, 0, "extract rules"); err != nil {
+		t.Errorf("expected an unknown size to pass, got %v", err)
+	}
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd", "windows":
+	default:
+		t.Skip("free space is not read on " + runtime.GOOS)
+	}
+	err := CheckFreeSpace(missing, math.MaxInt64/2, "extract rules")
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("expected ErrInsufficientSpace, got %v", err)
+	}
+	if !strings.Contains(err.Error(), missing) || !strings.Contains(err.Error(), "to extract rules") {
+		t.Errorf("expected the directory and purpose in %q", err)
+	}
+}
+
+func TestUncompressedSize(t *testing.T) {
+	sourceDir := t.TempDir()
+	writeRulePack(t, sourceDir)
+	archivePath := filepath.Join(t.TempDir(), "rules.tgz")
+	if _, err := PackFromDirectory(sourceDir, archivePath); err != nil {
+		t.Fatalf("PackFromDirectory failed: %v", err)
+	}
+
+	size, err := UncompressedSize(archivePath)
+	if err != nil {
+		t.Fatalf("UncompressedSize failed: %v", err)
+	}
+	report, err := Unpack(archivePath, t.TempDir())
+	if err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if size != report.TotalSize {
+		t.Errorf("expected %d bytes, the size of the extracted files, got %d", report.TotalSize, size)
+	}
+}
@@ -15,6 +15,7 @@ type Lockfile struct {
 type LockfileEntry struct {
 	Version     string   `json:"version"`
 	SHA256      string   `json:"sha256"`
+	Integrity   string   `json:"integrity,omitempty"`
 	Targets     []string `json:"targets"`
 	InstallPath string   `json:"install_path"`
 	Registry    string   `json:"registry,omitempty"`
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"rulestack/internal/compression"
+	"rulestack/internal/integrity"
+)
+
+// ReproducibleFile is a file to add to a reproducible archive
+type ReproducibleFile struct {
+	Path   string // Slash-separated path inside the archive
+	Mode   int64  // Permission bits
+	Source string // File the content is read from
+}
+
+// PackReproducible creates a gzip archive whose bytes depend only on the paths,
+// modes and contents of files, in the order given. Packing the same files again
+// produces an identical archive with the same SHA-256, so an archive can be
+// rebuilt from its files instead of being stored.
+func PackReproducible(files []ReproducibleFile, outputPath string) (*ArchiveInfo, error) {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	hasher := integrity.NewHasher()
+	compressor, err := compression.NewWriter(io.MultiWriter(outputFile, hasher), compression.Gzip, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer compressor.Close()
+
+	tarWriter := tar.NewWriter(compressor)
+	defer tarWriter.Close()
+
+	var packed []PackedFile
+	for _, file := range files {
+		if err := addReproducibleFile(tarWriter, file); err != nil {
+			return nil, err
+		}
+		stat, err := os.Stat(file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", file.Source, err)
+		}
+		packed = append(packed, PackedFile{Path: file.Path, Size: stat.Size()})
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	stat, err := outputFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	return &ArchiveInfo{
+		Path:      outputPath,
+		SHA256:    hasher.SHA256(),
+		Integrity: hasher.Integrity().String(),
+		SizeBytes: stat.Size(),
+		Files:     packed,
+	}, nil
+}
+
+func addReproducibleFile(tarWriter *tar.Writer, file ReproducibleFile) error {
+	source, err := os.Open(file.Source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.Source, err)
+	}
+	defer source.Close()
+
+	stat, err := source.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", file.Source, err)
+	}
+
+	mode := file.Mode & 0777
+	if mode == 0 {
+		mode = 0644
+	}
+
+	// Fixed timestamps and ownership keep the tar stream independent of when and
+	// where the archive is built
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     file.Path,
+		Size:     stat.Size(),
+		Mode:     mode,
+		ModTime:  time.Unix(0, 0),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", file.Path, err)
+	}
+	if _, err := io.Copy(tarWriter, source); err != nil {
+		return fmt.Errorf("failed to copy file content for %s: %w", file.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package progress emits machine-readable progress events for long operations,
+// as JSON lines, for wrappers such as IDE extensions that show native progress.
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is one progress update. Percent is -1 when the total is unknown.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`                // "pack", "publish", "install", "clone", "fetch", "upload" or "download"
+	Phase   string    `json:"phase"`             // "start", "done", "failed", "progress", or a step within the operation
+	Subject string    `json:"subject,omitempty"` // Package, repository or blob the operation works on
+	Percent float64   `json:"percent"`
+	Bytes   int64     `json:"bytes,omitempty"`
+	Total   int64     `json:"total,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+var (
+	mu  sync.Mutex
+	out io.Writer // nil while progress events are off
+	now = time.Now
+)
+
+// Enable writes events to w from now on; a nil w turns them off
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Enabled reports whether events are being written
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return out != nil
+}
+
+// Emit writes an event, if events are on
+func Emit(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return
+	}
+
+	e.Time = now().UTC()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	out.Write(append(data, '\n'))
+}
+
+// Step emits an event for step i (from 0) of n in an operation
+func Step(op, phase, subject string, i, n int) {
+	percent := 100.0
+	if n > 0 {
+		percent = float64(i) * 100 / float64(n)
+	}
+	Emit(Event{Op: op, Phase: phase, Subject: subject, Percent: percent})
+}
+
+// Start emits the start of an operation
+func Start(op, subject string) {
+	Emit(Event{Op: op, Phase: "start", Subject: subject})
+}
+
+// Done emits the end of an operation: "done" with 100%, or "failed" with the error
+func Done(op, subject string, err error) {
+	if err != nil {
+		Emit(Event{Op: op, Phase: "failed", Subject: subject, Percent: -1, Message: err.Error()})
+		return
+	}
+	Emit(Event{Op: op, Phase: "done", Subject: subject, Percent: 100})
+}
+
+// counter emits byte events as data passes through it, at most once per percent,
+// or per 256KB when the total is unknown
+type counter struct {
+	event   Event
+	total   int64
+	bytes   int64
+	emitted int64
+}
+
+const unknownTotalStep = 256 * 1024
+
+func (c *counter) add(n int) {
+	if n == 0 {
+		return
+	}
+	c.bytes += int64(n)
+	step := c.total / 100
+	if c.total <= 0 {
+		step = unknownTotalStep
+	}
+	if c.bytes-c.emitted < step && c.bytes != c.total {
+		return
+	}
+	c.emitted = c.bytes
+
+	e := c.event
+	e.Bytes, e.Total, e.Percent = c.bytes, c.total, -1
+	if c.total > 0 {
+		e.Percent = float64(c.bytes) * 100 / float64(c.total)
+	}
+	Emit(e)
+}
+
+type countingReader struct {
+	io.Reader
+	*counter
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.add(n)
+	return n, err
+}
+
+// Reader emits byte events like e as r is read, e.g. with Phase "progress". total may be 0 if unknown.
+// Without events on, r is returned as is.
+func Reader(r io.Reader, e Event, total int64) io.Reader {
+	if !Enabled() {
+		return r
+	}
+	return countingReader{Reader: r, counter: &counter{event: e, total: total}}
+}
+
+type countingWriter struct {
+	io.Writer
+	*counter
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.add(n)
+	return n, err
+}
+
+// Writer emits byte events like e as w is written to. total may be 0 if unknown.
+// Without events on, w is returned as is.
+func Writer(w io.Writer, e Event, total int64) io.Writer {
+	if !Enabled() {
+		return w
+	}
+	return countingWriter{Writer: w, counter: &counter{event: e, total: total}}
+}
+
+// gitProgressLine matches git's sideband progress, e.g. "Receiving objects:  45% (9/20)"
+var gitProgressLine = regexp.MustCompile(`^([A-Za-z ]+):\s+(\d+)% \((\d+)/(\d+)\)`)
+
+// gitProgress turns git sideband progress into events
+type gitProgress struct {
+	event Event
+	last  string
+	buf   []byte
+}
+
+// GitProgress returns a writer for go-git's Progress option that emits each
+// percent of each git phase ("counting objects", "receiving objects", ...) as an
+// event like e. Without events on it returns nil, which go-git treats as silent.
+func GitProgress(e Event) io.Writer {
+	if !Enabled() {
+		return nil
+	}
+	return &gitProgress{event: e}
+}
+
+func (g *gitProgress) Write(p []byte) (int, error) {
+	g.buf = append(g.buf, p...)
+	for {
+		// Progress lines are ended by \r while they update, \n once complete
+		i := strings.IndexAny(string(g.buf), "\r\n")
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(g.buf[:i])
+		g.buf = g.buf[i+1:]
+		g.line(line)
+	}
+}
+
+func (g *gitProgress) line(line string) {
+	match := gitProgressLine.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return
+	}
+
+	phase := strings.ToLower(strings.TrimSpace(match[1]))
+	key := phase + match[2]
+	if key == g.last {
+		return
+	}
+	g.last = key
+
+	e := g.event
+	e.Phase = phase
+	e.Percent, _ = strconv.ParseFloat(match[2], 64)
+	Emit(e)
+}
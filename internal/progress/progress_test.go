@@ -0,0 +1,134 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// capture enables events into a buffer for the rest of the test
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	Enable(&buf)
+	t.Cleanup(func() { Enable(nil) })
+	return &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("event is not a JSON line: %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEmitDisabled(t *testing.T) {
+	Emit(Event{Op: "pack", Phase: "start"})
+	if Enabled() {
+		t.Fatal("events should be off by default")
+	}
+
+	r := strings.NewReader("data")
+	if Reader(r, Event{}, 4) != io.Reader(r) {
+		t.Error("Reader should return its reader unwrapped while events are off")
+	}
+	if GitProgress(Event{}) != nil {
+		t.Error("GitProgress should be nil while events are off")
+	}
+}
+
+func TestStartStepDone(t *testing.T) {
+	buf := capture(t)
+
+	Start("install", "")
+	Step("install", "package", "a", 0, 2)
+	Step("install", "package", "b", 1, 2)
+	Done("install", "", nil)
+	Done("publish", "x.tgz", io.ErrUnexpectedEOF)
+
+	events := decode(t, buf)
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5", len(events))
+	}
+	if events[0].Phase != "start" || events[0].Time.IsZero() {
+		t.Errorf("start event = %+v", events[0])
+	}
+	if events[2].Subject != "b" || events[2].Percent != 50 {
+		t.Errorf("step event = %+v, want subject b at 50%%", events[2])
+	}
+	if events[3].Phase != "done" || events[3].Percent != 100 {
+		t.Errorf("done event = %+v", events[3])
+	}
+	if events[4].Phase != "failed" || events[4].Message != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("failed event = %+v", events[4])
+	}
+}
+
+func TestReaderReportsBytes(t *testing.T) {
+	buf := capture(t)
+
+	data := bytes.Repeat([]byte("x"), 1000)
+	r := Reader(bytes.NewReader(data), Event{Op: "download", Phase: "progress"}, int64(len(data)))
+	if _, err := io.Copy(io.Discard, iotest.OneByteReader(r)); err != nil {
+		t.Fatal(err)
+	}
+
+	events := decode(t, buf)
+	// One event per percent, however small the reads
+	if len(events) != 100 {
+		t.Fatalf("got %d events, want 100", len(events))
+	}
+	last := events[len(events)-1]
+	if last.Bytes != 1000 || last.Total != 1000 || last.Percent != 100 {
+		t.Errorf("last event = %+v, want all 1000 bytes", last)
+	}
+}
+
+func TestReaderUnknownTotal(t *testing.T) {
+	buf := capture(t)
+
+	data := bytes.Repeat([]byte("x"), 3*unknownTotalStep)
+	r := Reader(bytes.NewReader(data), Event{Op: "download", Phase: "progress"}, 0)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+
+	events := decode(t, buf)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if events[0].Percent != -1 || events[2].Bytes != int64(len(data)) {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestGitProgress(t *testing.T) {
+	buf := capture(t)
+
+	w := GitProgress(Event{Op: "clone", Subject: "https://example.com/repo.git"})
+	io.WriteString(w, "Enumerating objects: 20, done.\n")
+	io.WriteString(w, "Receiving objects:  45% (9/20)\rReceiving obj")
+	io.WriteString(w, "ects:  45% (9/20)\rReceiving objects: 100% (20/20), done.\n")
+
+	events := decode(t, buf)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if events[0].Phase != "receiving objects" || events[0].Percent != 45 {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if events[1].Percent != 100 || events[1].Subject != "https://example.com/repo.git" {
+		t.Errorf("second event = %+v", events[1])
+	}
+}
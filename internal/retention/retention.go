@@ -0,0 +1,76 @@
+// Package retention decides which published package versions a registry may
+// remove under its storage retention policy.
+package retention
+
+import (
+	"sort"
+	"time"
+
+	"rulestack/internal/version"
+)
+
+// Policy limits how many versions of each package a registry keeps
+type Policy struct {
+	// KeepVersions keeps only the newest N versions of each package (0 keeps all)
+	KeepVersions int
+	// PrereleaseMaxAge expires pre-release versions older than this (0 never expires them)
+	PrereleaseMaxAge time.Duration
+}
+
+// Enabled reports whether the policy can expire anything
+func (p Policy) Enabled() bool {
+	return p.KeepVersions > 0 || p.PrereleaseMaxAge > 0
+}
+
+// Candidate is a published version considered for expiry
+type Candidate struct {
+	Version     string
+	PublishedAt time.Time
+}
+
+// Expired returns the versions of one package that the policy removes, newest first.
+// The newest version is always kept so a package never disappears, and so is the
+// newest stable version so ranges such as ^1.2.0 keep resolving when pre-releases
+// are newer. Versions that are not valid semver are kept because their order is unknown.
+func Expired(candidates []Candidate, policy Policy, now time.Time) []Candidate {
+	type parsed struct {
+		Candidate
+		version *version.Version
+	}
+
+	var versions []parsed
+	for _, candidate := range candidates {
+		v, err := version.Parse(candidate.Version)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, parsed{Candidate: candidate, version: v})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].version.IsGreaterThan(versions[j].version)
+	})
+
+	newestStable := -1
+	for i, v := range versions {
+		if v.version.Pre == "" {
+			newestStable = i
+			break
+		}
+	}
+
+	var expired []Candidate
+	for i, v := range versions {
+		if i == 0 || i == newestStable {
+			continue
+		}
+
+		tooMany := policy.KeepVersions > 0 && i >= policy.KeepVersions
+		stalePrerelease := policy.PrereleaseMaxAge > 0 && v.version.Pre != "" && now.Sub(v.PublishedAt) > policy.PrereleaseMaxAge
+		if tooMany || stalePrerelease {
+			expired = append(expired, v.Candidate)
+		}
+	}
+
+	return expired
+}
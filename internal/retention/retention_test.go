@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+
+	candidates := []Candidate{
+		{Version: "1.0.0", PublishedAt: daysAgo(100)},
+		{Version: "1.2.0", PublishedAt: daysAgo(10)},
+		{Version: "1.1.0", PublishedAt: daysAgo(50)},
+		{Version: "1.2.0-beta.1", PublishedAt: daysAgo(20)},
+		{Version: "1.3.0-rc.1", PublishedAt: daysAgo(40)},
+		{Version: "legacy", PublishedAt: daysAgo(400)},
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []string
+	}{
+		{
+			name:     "disabled policy keeps everything",
+			policy:   Policy{},
+			expected: nil,
+		},
+		{
+			name:     "keep newest versions",
+			policy:   Policy{KeepVersions: 3},
+			expected: []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name:     "expire old pre-releases but never the newest version",
+			policy:   Policy{PrereleaseMaxAge: 15 * 24 * time.Hour},
+			expected: []string{"1.2.0-beta.1"},
+		},
+		{
+			name:     "both rules",
+			policy:   Policy{KeepVersions: 4, PrereleaseMaxAge: 15 * 24 * time.Hour},
+			expected: []string{"1.2.0-beta.1", "1.0.0"},
+		},
+		{
+			name:     "keep one also keeps the newest stable release",
+			policy:   Policy{KeepVersions: 1},
+			expected: []string{"1.2.0-beta.1", "1.1.0", "1.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, candidate := range Expired(candidates, tt.policy, now) {
+				got = append(got, candidate.Version)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expired() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
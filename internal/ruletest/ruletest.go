@@ -0,0 +1,342 @@
+// Package ruletest runs rule test fixtures against the rules in a package.
+//
+// A fixture describes an example situation (a prompt and/or a set of file paths)
+// and lists the rule IDs expected to apply to it. Fixtures live as JSON files in
+// the package's tests directory:
+//
+//	{
+//	  "name": "handler edits trigger API rules",
+//	  "prompt": "add a new REST endpoint",
+//	  "files": ["internal/api/handlers.go"],
+//	  "expect": ["api-style"],
+//	  "expect_not": ["frontend-style"]
+//	}
+//
+// A rule applies to a fixture when its frontmatter sets alwaysApply, when any
+// fixture file matches one of its globs, or when the prompt contains one of its
+// keywords (case-insensitive).
+package ruletest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"rulestack/internal/pkg"
+	"rulestack/internal/tempdir"
+)
+
+// DefaultDir is the tests directory used when the package manifest does not set one
+const DefaultDir = "tests"
+
+// ErrNoFixtures is returned when a package has no test fixtures
+var ErrNoFixtures = errors.New("no test fixtures found")
+
+// Fixture is a single rule test case
+type Fixture struct {
+	Name      string   `json:"name"`
+	Prompt    string   `json:"prompt,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Expect    []string `json:"expect,omitempty"`
+	ExpectNot []string `json:"expect_not,omitempty"`
+
+	Path string `json:"-"` // Fixture file the case was loaded from
+}
+
+// Rule is the matching-relevant part of a rule file's frontmatter
+type Rule struct {
+	ID          string
+	Path        string
+	Globs       []string
+	Keywords    []string
+	AlwaysApply bool
+}
+
+// Result is the outcome of one fixture
+type Result struct {
+	Fixture    Fixture
+	Matched    []string // Rule IDs that applied
+	Missing    []string // Expected rule IDs that did not apply
+	Unexpected []string // Rule IDs listed in expect_not that applied
+	Unknown    []string // Rule IDs referenced by the fixture that no rule defines
+}
+
+// Passed reports whether the fixture met all of its expectations
+func (r Result) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0 && len(r.Unknown) == 0
+}
+
+// Report is the outcome of running all fixtures for a package
+type Report struct {
+	Rules   []Rule
+	Results []Result
+}
+
+// Failed returns the number of fixtures that did not pass
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run loads rules from packageDir and fixtures from testsDir (relative to packageDir)
+// and evaluates every fixture
+func Run(packageDir, testsDir string) (*Report, error) {
+	if testsDir == "" {
+		testsDir = DefaultDir
+	}
+
+	fixtures, err := LoadFixtures(filepath.Join(packageDir, testsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := LoadRules(packageDir, testsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Rules: rules}
+	for _, fixture := range fixtures {
+		report.Results = append(report.Results, Evaluate(rules, fixture))
+	}
+
+	return report, nil
+}
+
+// RunArchive extracts a package archive and runs its fixtures
+func RunArchive(archivePath, testsDir string) (*Report, error) {
+	extractDir, err := tempdir.MkdirTemp("ruletest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	if _, err := pkg.Unpack(archivePath, extractDir); err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	return Run(extractDir, testsDir)
+}
+
+// LoadFixtures reads every *.json fixture file in dir. A file may hold a single
+// fixture object or an array of fixtures.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	sort.Strings(paths)
+
+	var fixtures []Fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+
+		var cases []Fixture
+		trimmed := strings.TrimSpace(string(data))
+		if strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(data, &cases)
+		} else {
+			var single Fixture
+			err = json.Unmarshal(data, &single)
+			cases = []Fixture{single}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+
+		for i, fixture := range cases {
+			if fixture.Name == "" {
+				fixture.Name = fmt.Sprintf("%s#%d", strings.TrimSuffix(filepath.Base(path), ".json"), i+1)
+			}
+			if fixture.Prompt == "" && len(fixture.Files) == 0 {
+				return nil, fmt.Errorf("fixture %q in %s needs a prompt or files", fixture.Name, path)
+			}
+			fixture.Path = path
+			fixtures = append(fixtures, fixture)
+		}
+	}
+
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoFixtures, dir)
+	}
+
+	return fixtures, nil
+}
+
+// LoadRules parses the frontmatter of every rule file under packageDir, skipping
+// the tests directory. Rules without an id use their file name.
+func LoadRules(packageDir, testsDir string) ([]Rule, error) {
+	testsPath := filepath.Join(packageDir, testsDir)
+
+	var rules []Rule
+	err := filepath.Walk(packageDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path == testsPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".md" && ext != ".mdc" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(packageDir, path)
+		rule := ParseRule(string(content))
+		rule.Path = filepath.ToSlash(relPath)
+		if rule.ID == "" {
+			rule.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		rules = append(rules, rule)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules from %s: %w", packageDir, err)
+	}
+
+	return rules, nil
+}
+
+// ParseRule reads the id, globs, keywords and alwaysApply fields from a rule's frontmatter.
+// List fields may be comma-separated values or YAML block lists.
+func ParseRule(content string) Rule {
+	var rule Rule
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return rule
+	}
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end == -1 {
+		return rule
+	}
+
+	var currentList *[]string
+	for _, line := range strings.Split(rest[:end], "\n") {
+		trimmed := strings.TrimSpace(line)
+		if item, isItem := strings.CutPrefix(trimmed, "- "); isItem && currentList != nil {
+			*currentList = append(*currentList, unquote(item))
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		currentList = nil
+		switch strings.TrimSpace(key) {
+		case "id":
+			rule.ID = unquote(value)
+		case "alwaysApply":
+			rule.AlwaysApply = value == "true"
+		case "globs":
+			rule.Globs = splitList(value)
+			currentList = &rule.Globs
+		case "keywords":
+			rule.Keywords = splitList(value)
+			currentList = &rule.Keywords
+		}
+	}
+
+	return rule
+}
+
+// Evaluate checks which rules apply to a fixture and compares them to its expectations
+func Evaluate(rules []Rule, fixture Fixture) Result {
+	result := Result{Fixture: fixture}
+
+	known := make(map[string]bool, len(rules))
+	matched := make(map[string]bool)
+	for _, rule := range rules {
+		known[rule.ID] = true
+		if Applies(rule, fixture) && !matched[rule.ID] {
+			matched[rule.ID] = true
+			result.Matched = append(result.Matched, rule.ID)
+		}
+	}
+
+	for _, id := range fixture.Expect {
+		if !known[id] {
+			result.Unknown = append(result.Unknown, id)
+		} else if !matched[id] {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	for _, id := range fixture.ExpectNot {
+		if !known[id] {
+			result.Unknown = append(result.Unknown, id)
+		} else if matched[id] {
+			result.Unexpected = append(result.Unexpected, id)
+		}
+	}
+
+	return result
+}
+
+// Applies reports whether a rule would be applied in the fixture's situation
+func Applies(rule Rule, fixture Fixture) bool {
+	if rule.AlwaysApply {
+		return true
+	}
+
+	for _, file := range fixture.Files {
+		for _, glob := range rule.Globs {
+			if ok, _ := doublestar.Match(glob, filepath.ToSlash(file)); ok {
+				return true
+			}
+		}
+	}
+
+	prompt := strings.ToLower(fixture.Prompt)
+	for _, keyword := range rule.Keywords {
+		if keyword != "" && strings.Contains(prompt, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitList parses an inline frontmatter list: "a, b", "[a, b]" or a single value
+func splitList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquote(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
@@ -0,0 +1,154 @@
+package ruletest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Rule
+	}{
+		{
+			name:    "inline lists",
+			content: "---\nid: api-style\nglobs: \"**/*.go\", internal/api/**\nkeywords: [endpoint, REST]\nalwaysApply: false\n---\n# API\n",
+			want:    Rule{ID: "api-style", Globs: []string{"**/*.go", "internal/api/**"}, Keywords: []string{"endpoint", "REST"}},
+		},
+		{
+			name:    "block lists",
+			content: "---\nid: docs\nglobs:\n  - \"**/*.md\"\n  - docs/**\nalwaysApply: true\n---\n",
+			want:    Rule{ID: "docs", Globs: []string{"**/*.md", "docs/**"}, AlwaysApply: true},
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Just a rule\n",
+			want:    Rule{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRule(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{ID: "api-style", Globs: []string{"internal/api/**"}},
+		{ID: "rest", Keywords: []string{"endpoint"}},
+		{ID: "frontend", Globs: []string{"web/**/*.tsx"}},
+		{ID: "always", AlwaysApply: true},
+	}
+
+	tests := []struct {
+		name       string
+		fixture    Fixture
+		passed     bool
+		missing    []string
+		unexpected []string
+		unknown    []string
+	}{
+		{
+			name:    "glob and keyword matches",
+			fixture: Fixture{Prompt: "Add an ENDPOINT", Files: []string{"internal/api/handlers.go"}, Expect: []string{"api-style", "rest", "always"}, ExpectNot: []string{"frontend"}},
+			passed:  true,
+		},
+		{
+			name:    "missing match",
+			fixture: Fixture{Files: []string{"cmd/main.go"}, Expect: []string{"api-style"}},
+			missing: []string{"api-style"},
+		},
+		{
+			name:       "unexpected match",
+			fixture:    Fixture{Files: []string{"web/app/page.tsx"}, ExpectNot: []string{"frontend"}},
+			unexpected: []string{"frontend"},
+		},
+		{
+			name:    "unknown rule",
+			fixture: Fixture{Prompt: "x", Expect: []string{"nope"}},
+			unknown: []string{"nope"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(rules, tt.fixture)
+			if result.Passed() != tt.passed {
+				t.Errorf("Passed() = %v, want %v (%+v)", result.Passed(), tt.passed, result)
+			}
+			if !reflect.DeepEqual(result.Missing, tt.missing) {
+				t.Errorf("Missing = %v, want %v", result.Missing, tt.missing)
+			}
+			if !reflect.DeepEqual(result.Unexpected, tt.unexpected) {
+				t.Errorf("Unexpected = %v, want %v", result.Unexpected, tt.unexpected)
+			}
+			if !reflect.DeepEqual(result.Unknown, tt.unknown) {
+				t.Errorf("Unknown = %v, want %v", result.Unknown, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	packageDir := t.TempDir()
+	writeFile(t, filepath.Join(packageDir, "rules", "api-style.mdc"), "---\nid: api-style\nglobs: internal/api/**\n---\n# API\n")
+	writeFile(t, filepath.Join(packageDir, "rules", "loose.md"), "# No frontmatter\n")
+	writeFile(t, filepath.Join(packageDir, "tests", "api.json"), `{"name": "api", "files": ["internal/api/x.go"], "expect": ["api-style"], "expect_not": ["loose"]}`)
+	writeFile(t, filepath.Join(packageDir, "tests", "more.json"), `[{"prompt": "hello", "expect_not": ["api-style"]}, {"files": ["a.go"], "expect": ["api-style"]}]`)
+	// Markdown inside the tests dir is not a rule
+	writeFile(t, filepath.Join(packageDir, "tests", "README.md"), "---\nid: readme\nalwaysApply: true\n---\n")
+
+	report, err := Run(packageDir, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Rules) != 2 {
+		t.Errorf("Expected 2 rules, got %d: %+v", len(report.Rules), report.Rules)
+	}
+	if len(report.Results) != 3 {
+		t.Fatalf("Expected 3 fixtures, got %d", len(report.Results))
+	}
+	if report.Results[1].Fixture.Name != "more#1" {
+		t.Errorf("Expected generated fixture name more#1, got %q", report.Results[1].Fixture.Name)
+	}
+	if report.Failed() != 1 {
+		t.Errorf("Expected 1 failed fixture, got %d", report.Failed())
+	}
+}
+
+func TestRunWithoutFixtures(t *testing.T) {
+	packageDir := t.TempDir()
+	writeFile(t, filepath.Join(packageDir, "rule.md"), "# Rule\n")
+
+	if _, err := Run(packageDir, ""); !errors.Is(err, ErrNoFixtures) {
+		t.Errorf("Expected ErrNoFixtures, got %v", err)
+	}
+}
+
+func TestLoadFixturesRejectsEmptyCase(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "empty.json"), `{"name": "nothing", "expect": ["x"]}`)
+
+	if _, err := LoadFixtures(dir); err == nil {
+		t.Error("Expected fixture without prompt or files to be rejected")
+	}
+}
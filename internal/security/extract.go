@@ -0,0 +1,154 @@
+package security
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"rulestack/internal/compression"
+)
+
+// ExtractedFile is a regular file read from an archive
+type ExtractedFile struct {
+	Path   string // Slash-separated path, relative to the extraction directory
+	Size   int64
+	SHA256 string
+}
+
+// ExtractReport describes the files of an archive, in archive order
+type ExtractReport struct {
+	Files     []ExtractedFile
+	TotalSize int64 // Uncompressed size of all files
+}
+
+// Hashes returns the SHA-256 of every file, keyed by its path, as recorded in
+// the lock file
+func (r *ExtractReport) Hashes() map[string]string {
+	hashes := make(map[string]string, len(r.Files))
+	for _, file := range r.Files {
+		hashes[file.Path] = file.SHA256
+	}
+	return hashes
+}
+
+// WalkArchive calls fn with every entry of an archive, in archive order. The
+// content reader is only valid until fn returns.
+func WalkArchive(archivePath string, fn func(header *tar.Header, content io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	decompressed, err := compression.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	tarReader := tar.NewReader(decompressed)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		if err := fn(header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// ScanArchive validates an archive like ValidateArchive and reports its files
+func (v *PackageValidator) ScanArchive(archivePath, extractDir string) (*ExtractReport, error) {
+	return v.extract(archivePath, extractDir, nil)
+}
+
+// ExtractArchive validates and extracts an archive in a single pass. Each file
+// is validated in full before it is written, and if any entry fails the files
+// already written are removed, so a rejected archive leaves nothing behind.
+func (v *PackageValidator) ExtractArchive(archivePath, destDir string) (*ExtractReport, error) {
+	_, statErr := os.Stat(destDir)
+	created := os.IsNotExist(statErr)
+
+	var written []string
+	report, err := v.extract(archivePath, destDir, &written)
+	if err != nil {
+		if created {
+			os.RemoveAll(destDir)
+		} else {
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// extract streams through an archive once, validating and hashing every entry.
+// When written is not nil the entries are also extracted to extractDir, and the
+// paths of the files written are appended to it.
+func (v *PackageValidator) extract(archivePath, extractDir string, written *[]string) (*ExtractReport, error) {
+	report := &ExtractReport{}
+	paths := NewPathSet()
+	var fileCount int
+
+	err := WalkArchive(archivePath, func(header *tar.Header, content io.Reader) error {
+		fileCount++
+		if err := v.validateEntry(header, extractDir, fileCount, report.TotalSize+header.Size); err != nil {
+			return err
+		}
+		if err := paths.Add(header.Name); err != nil {
+			return fmt.Errorf("unsafe file path '%s': %w", header.Name, err)
+		}
+
+		destPath := LongPath(filepath.Join(extractDir, header.Name))
+		if header.Typeflag == tar.TypeDir {
+			if written != nil {
+				return os.MkdirAll(destPath, 0o755)
+			}
+			return nil
+		}
+
+		// Files are small enough to hold in memory, so each is checked in full
+		// before any of it reaches the disk
+		data, err := io.ReadAll(io.LimitReader(content, v.config.MaxFileSize))
+		if err != nil {
+			return fmt.Errorf("invalid content in '%s': failed to read file content: %w", header.Name, err)
+		}
+		if err := v.validateFileContent(data, header); err != nil {
+			return fmt.Errorf("invalid content in '%s': %w", header.Name, err)
+		}
+
+		report.TotalSize += header.Size
+		report.Files = append(report.Files, ExtractedFile{
+			Path:   filepath.ToSlash(filepath.Clean(header.Name)),
+			Size:   int64(len(data)),
+			SHA256: fmt.Sprintf("%x", sha256.Sum256(data)),
+		})
+
+		if written == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			return fmt.Errorf("failed to extract file %s: %w", header.Name, err)
+		}
+		*written = append(*written, destPath)
+		if err := os.WriteFile(destPath, data, 0o644); err != nil {
+			return fmt.Errorf("failed to extract file %s: %w", header.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,103 @@
+package security
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeOrderedArchive writes an archive with the files in the given order, as
+// name/content pairs
+func writeOrderedArchive(t *testing.T, files ...string) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "archive.tgz")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzWriter)
+	for i := 0; i < len(files); i += 2 {
+		header := &tar.Header{Name: files[i], Size: int64(len(files[i+1])), Typeflag: tar.TypeReg, Mode: 0644}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tarWriter.Write([]byte(files[i+1]))
+	}
+	tarWriter.Close()
+	gzWriter.Close()
+	return archivePath
+}
+
+func TestExtractArchive(t *testing.T) {
+	validator := NewPackageValidator(nil)
+
+	t.Run("reports files", func(t *testing.T) {
+		archivePath := writeOrderedArchive(t, "rules/b.md", "# B", "./rules/a.md", "# A rule")
+		destDir := filepath.Join(t.TempDir(), "pkg")
+
+		report, err := validator.ExtractArchive(archivePath, destDir)
+		if err != nil {
+			t.Fatalf("ExtractArchive failed: %v", err)
+		}
+		if len(report.Files) != 2 || report.Files[1].Path != "rules/a.md" || report.Files[1].Size != 8 {
+			t.Errorf("unexpected files %+v", report.Files)
+		}
+		if report.TotalSize != 11 {
+			t.Errorf("expected total size 11, got %d", report.TotalSize)
+		}
+		if hash := report.Hashes()["rules/b.md"]; hash != fmt.Sprintf("%x", sha256.Sum256([]byte("# B"))) {
+			t.Errorf("unexpected hash %s", hash)
+		}
+		if data, _ := os.ReadFile(filepath.Join(destDir, "rules", "a.md")); string(data) != "# A rule" {
+			t.Errorf("unexpected extracted content %q", data)
+		}
+
+		scanned, err := validator.ScanArchive(archivePath, t.TempDir())
+		if err != nil || fmt.Sprint(scanned.Hashes()) != fmt.Sprint(report.Hashes()) {
+			t.Errorf("expected scanning to report the same files, got %+v (%v)", scanned, err)
+		}
+	})
+
+	t.Run("rejected archive leaves nothing behind", func(t *testing.T) {
+		archivePath := writeOrderedArchive(t, "rules/good.md", "# Good", "rules/bad.md", "<script>alert(1)</script>")
+
+		newDir := filepath.Join(t.TempDir(), "pkg")
+		if _, err := validator.ExtractArchive(archivePath, newDir); err == nil {
+			t.Fatal("expected the archive to be rejected")
+		}
+		if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", newDir, err)
+		}
+
+		existingDir := t.TempDir()
+		os.WriteFile(filepath.Join(existingDir, "keep.md"), []byte("kept"), 0644)
+		if _, err := validator.ExtractArchive(archivePath, existingDir); err == nil {
+			t.Fatal("expected the archive to be rejected")
+		}
+		if _, err := os.Stat(filepath.Join(existingDir, "rules", "good.md")); !os.IsNotExist(err) {
+			t.Errorf("expected the extracted file to be removed, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(existingDir, "keep.md")); err != nil {
+			t.Errorf("expected existing files to be kept: %v", err)
+		}
+	})
+
+	t.Run("rejects case collisions and reserved names", func(t *testing.T) {
+		for _, files := range [][]string{
+			{"rules/Secure.md", "# A", "rules/secure.md", "# B"},
+			{"rules/aux.md", "# Aux"},
+		} {
+			archivePath := writeOrderedArchive(t, files...)
+			if _, err := validator.ScanArchive(archivePath, t.TempDir()); err == nil {
+				t.Errorf("expected %v to be rejected", files)
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsReservedNames are device names Windows reserves in every directory,
+// with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// windowsInvalidChars cannot appear in Windows file names. A colon would also
+// write to an alternate data stream rather than the named file.
+const windowsInvalidChars = `<>:"|?*\`
+
+// windowsMaxPath is the longest path Windows opens without the long-path prefix,
+// less the 12 characters it reserves for an 8.3 file name in a directory
+const windowsMaxPath = 260 - 12
+
+// ValidatePortablePath rejects slash-separated archive paths that cannot be
+// created on every platform rulestack runs on. Packages are shared between
+// platforms, so Windows rules apply everywhere: no reserved device names, no
+// names ending in a dot or space, and none of the characters Windows forbids.
+func ValidatePortablePath(name string) error {
+	for _, component := range strings.Split(name, "/") {
+		if component == "" || component == "." {
+			continue
+		}
+
+		if strings.ContainsAny(component, windowsInvalidChars) {
+			return fmt.Errorf("'%s' contains a character not allowed on Windows (%s)", component, windowsInvalidChars)
+		}
+		if strings.HasSuffix(component, ".") || strings.HasSuffix(component, " ") {
+			return fmt.Errorf("'%s' ends in a dot or space, which Windows strips", component)
+		}
+
+		base, _, _ := strings.Cut(component, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			return fmt.Errorf("'%s' is a reserved device name on Windows", component)
+		}
+	}
+	return nil
+}
+
+// PathSet collects archive paths and detects two that differ only in case,
+// which would overwrite each other on case-insensitive filesystems such as
+// those of Windows and macOS
+type PathSet struct {
+	seen map[string]string // Lower-cased path to its first spelling
+}
+
+// NewPathSet creates an empty path set
+func NewPathSet() *PathSet {
+	return &PathSet{seen: make(map[string]string)}
+}
+
+// Add records a slash-separated path and the directories leading to it. It
+// fails if the path or one of its directories was added spelt differently.
+// Adding the same spelling twice is not a collision.
+func (s *PathSet) Add(name string) error {
+	parts := strings.Split(path.Clean(filepath.ToSlash(name)), "/")
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "/")
+		key := strings.ToLower(prefix)
+		if existing, ok := s.seen[key]; ok {
+			if existing != prefix {
+				return fmt.Errorf("'%s' and '%s' differ only in case and collide on case-insensitive filesystems", existing, prefix)
+			}
+			continue
+		}
+		s.seen[key] = prefix
+	}
+	return nil
+}
+
+// LongPath returns a form of path that can be opened on Windows even when it is
+// longer than MAX_PATH: absolute, with the \\?\ prefix. Other platforms have no
+// such limit and get path unchanged.
+func LongPath(p string) string {
+	if runtime.GOOS != "windows" {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	return windowsLongPath(abs)
+}
+
+// windowsLongPath adds the long-path prefix to a clean, absolute Windows path
+// that needs it
+func windowsLongPath(abs string) string {
+	if len(abs) < windowsMaxPath || strings.HasPrefix(abs, `\\?\`) {
+		return abs
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
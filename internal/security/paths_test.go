@@ -0,0 +1,70 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePortablePath(t *testing.T) {
+	testCases := []struct {
+		path  string
+		valid bool
+	}{
+		{"rules/secure-coding.md", true},
+		{"./docs/console.md", true},
+		{"rules/CON", false},
+		{"rules/nul.md", false},
+		{"com1.txt/rules.md", false},
+		{"lpt9 .md", false},
+		{"rules./a.md", false},
+		{"rules/a.md ", false},
+		{"rules/a:stream.md", false},
+		{"rules/what?.md", false},
+		{`rules\a.md`, false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidatePortablePath(tc.path)
+		if tc.valid && err != nil {
+			t.Errorf("%q: unexpected error %v", tc.path, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%q: expected an error", tc.path)
+		}
+	}
+}
+
+func TestPathSet(t *testing.T) {
+	paths := NewPathSet()
+	for _, name := range []string{"rules/a.md", "rules/b.md", "./rules/a.md", "docs/"} {
+		if err := paths.Add(name); err != nil {
+			t.Errorf("Add(%q): unexpected error %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"rules/A.md", "Rules/c.md", "DOCS/readme.md"} {
+		if err := paths.Add(name); err == nil || !strings.Contains(err.Error(), "differ only in case") {
+			t.Errorf("Add(%q): expected a case collision, got %v", name, err)
+		}
+	}
+}
+
+func TestWindowsLongPath(t *testing.T) {
+	short := `C:\project\.rulestack\rules.md`
+	if got := windowsLongPath(short); got != short {
+		t.Errorf("expected a short path unchanged, got %s", got)
+	}
+
+	long := `C:\project\` + strings.Repeat(`nested\`, 40) + "rules.md"
+	if got := windowsLongPath(long); got != `\\?\`+long {
+		t.Errorf("expected the long-path prefix, got %s", got)
+	}
+	if got := windowsLongPath(`\\?\` + long); got != `\\?\`+long {
+		t.Errorf("expected a prefixed path unchanged, got %s", got)
+	}
+
+	unc := `\\server\share\` + strings.Repeat(`nested\`, 40) + "rules.md"
+	if got := windowsLongPath(unc); got != `\\?\UNC\`+unc[2:] {
+		t.Errorf("expected the UNC long-path prefix, got %s", got)
+	}
+}
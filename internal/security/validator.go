@@ -3,10 +3,7 @@ package security
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
 	"strings"
 	"unicode/utf8"
@@ -79,72 +76,44 @@ func NewPackageValidator(config *SecurityConfig) *PackageValidator {
 	}
 }
 
-// ValidateArchive validates the security of a package archive
+// ValidateArchive validates the security of a package archive without
+// extracting it. Paths are checked as if extracted to extractDir.
 func (v *PackageValidator) ValidateArchive(archivePath, extractDir string) error {
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("failed to open archive: %w", err)
-	}
-	defer file.Close()
+	_, err := v.extract(archivePath, extractDir, nil)
+	return err
+}
 
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
+// validateEntry checks an entry's header against the path, type and size rules
+// and the archive-wide limits, given the entries and bytes read before it
+func (v *PackageValidator) validateEntry(header *tar.Header, extractDir string, fileCount int, totalSize int64) error {
+	if fileCount > v.config.MaxFiles {
+		return fmt.Errorf("archive contains too many files (max %d)", v.config.MaxFiles)
 	}
-	defer gzReader.Close()
-
-	tarReader := tar.NewReader(gzReader)
-
-	var totalSize int64
-	var fileCount int
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to read tar entry: %w", err)
-		}
-
-		fileCount++
-		if fileCount > v.config.MaxFiles {
-			return fmt.Errorf("archive contains too many files (max %d)", v.config.MaxFiles)
-		}
 
-		// Validate file path security
-		if err := v.validateFilePath(header.Name, extractDir); err != nil {
-			return fmt.Errorf("unsafe file path '%s': %w", header.Name, err)
-		}
-
-		// Validate file type
-		if err := v.validateFileType(header.Name); err != nil {
-			return fmt.Errorf("invalid file type '%s': %w", header.Name, err)
-		}
+	// Validate file path security
+	if err := v.validateFilePath(header.Name, extractDir); err != nil {
+		return fmt.Errorf("unsafe file path '%s': %w", header.Name, err)
+	}
 
-		// Check file size
-		if header.Size > v.config.MaxFileSize {
-			return fmt.Errorf("file '%s' too large (%d bytes, max %d)",
-				header.Name, header.Size, v.config.MaxFileSize)
-		}
+	// Validate file type
+	if err := v.validateFileType(header.Name); err != nil {
+		return fmt.Errorf("invalid file type '%s': %w", header.Name, err)
+	}
 
-		totalSize += header.Size
-		if totalSize > v.config.MaxTotalSize {
-			return fmt.Errorf("archive too large (%d bytes, max %d)",
-				totalSize, v.config.MaxTotalSize)
-		}
+	// Check file size
+	if header.Size > v.config.MaxFileSize {
+		return fmt.Errorf("file '%s' too large (%d bytes, max %d)",
+			header.Name, header.Size, v.config.MaxFileSize)
+	}
 
-		// Validate file content for regular files
-		if header.Typeflag == tar.TypeReg {
-			if err := v.validateFileContent(tarReader, header); err != nil {
-				return fmt.Errorf("invalid content in '%s': %w", header.Name, err)
-			}
-		}
+	if totalSize > v.config.MaxTotalSize {
+		return fmt.Errorf("archive too large (%d bytes, max %d)",
+			totalSize, v.config.MaxTotalSize)
+	}
 
-		// Reject symlinks and other special file types
-		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
-			return fmt.Errorf("unsupported file type for '%s': %c", header.Name, header.Typeflag)
-		}
+	// Reject symlinks and other special file types
+	if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+		return fmt.Errorf("unsupported file type for '%s': %c", header.Name, header.Typeflag)
 	}
 
 	return nil
@@ -175,7 +144,7 @@ func (v *PackageValidator) validateFilePath(filePath, extractDir string) error {
 		}
 	}
 
-	return nil
+	return ValidatePortablePath(filePath)
 }
 
 // validateFileType checks if the file extension is allowed
@@ -197,13 +166,7 @@ func (v *PackageValidator) validateFileType(filePath string) error {
 }
 
 // validateFileContent validates the content of a file
-func (v *PackageValidator) validateFileContent(reader io.Reader, header *tar.Header) error {
-	// Read file content
-	content, err := io.ReadAll(io.LimitReader(reader, v.config.MaxFileSize))
-	if err != nil {
-		return fmt.Errorf("failed to read file content: %w", err)
-	}
-
+func (v *PackageValidator) validateFileContent(content []byte, header *tar.Header) error {
 	// Check for NUL bytes
 	if bytes.Contains(content, []byte{0}) {
 		return fmt.Errorf("file contains NUL bytes")
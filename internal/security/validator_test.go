@@ -335,3 +335,35 @@ func TestPackageValidator_TooManyFiles(t *testing.T) {
 		t.Error("Archive with too many files was not rejected")
 	}
 }
+
+func BenchmarkPackageValidator_ValidateArchive(b *testing.B) {
+	validator := NewPackageValidator(nil)
+
+	// A few dozen rule files of a few kilobytes each, as in a typical pack
+	files := map[string][]byte{
+		"rulestack.json": []byte(`{"name": "bench-rules", "version": "1.0.0"}`),
+	}
+	for i := 0; i < 48; i++ {
+		var rule bytes.Buffer
+		fmt.Fprintf(&rule, "# Rule %d\n\n", i)
+		for j := 0; j < 40; j++ {
+			fmt.Fprintf(&rule, "- Always validate input %d before passing it to handler %d; see [docs](https://example.com/%d).\n", j, i, j)
+		}
+		files[fmt.Sprintf("rules/rule-%d.md", i)] = rule.Bytes()
+	}
+
+	archivePath, err := createTestArchive(files)
+	if err != nil {
+		b.Fatalf("Failed to create test archive: %v", err)
+	}
+	defer os.Remove(archivePath)
+	extractDir := b.TempDir()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := validator.ValidateArchive(archivePath, extractDir); err != nil {
+			b.Fatalf("ValidateArchive failed: %v", err)
+		}
+	}
+}
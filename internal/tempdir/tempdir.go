@@ -0,0 +1,149 @@
+// Package tempdir manages the directory rfh keeps its temporary files in, under
+// the system temp directory. Files get unique names, are removed when rfh is
+// interrupted, and are swept on a later run when a crash left them behind.
+package tempdir
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// MaxAge is how old a temp file must be before Sweep treats it as left behind
+// by a crashed run rather than in use by a running one
+const MaxAge = 24 * time.Hour
+
+var (
+	mu       sync.Mutex
+	tracked  []string
+	watching bool
+)
+
+// Dir returns rfh's temp directory, creating it if needed. Each user has their
+// own, readable only by them.
+func Dir() (string, error) {
+	name := "rfh"
+	if uid := os.Getuid(); uid >= 0 {
+		name = fmt.Sprintf("rfh-%d", uid)
+	}
+	dir := filepath.Join(os.TempDir(), name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// CreateTemp creates a new temp file like os.CreateTemp, in rfh's temp
+// directory. Path separators in pattern, as in scoped package names, are
+// replaced so the file lands directly in the directory.
+func CreateTemp(pattern string) (*os.File, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(dir, sanitize(pattern))
+	if err != nil {
+		return nil, err
+	}
+	track(file.Name())
+	return file, nil
+}
+
+// Path reserves a unique temp file path for callers that write the file
+// themselves, creating it empty
+func Path(pattern string) (string, error) {
+	file, err := CreateTemp(pattern)
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// MkdirTemp creates a new temp directory like os.MkdirTemp, in rfh's temp directory
+func MkdirTemp(pattern string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	path, err := os.MkdirTemp(dir, sanitize(pattern))
+	if err != nil {
+		return "", err
+	}
+	track(path)
+	return path, nil
+}
+
+// Cleanup removes every temp file and directory this process created. Callers
+// still remove their own when done; this catches the ones an interrupt left.
+func Cleanup() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, path := range tracked {
+		os.RemoveAll(path)
+	}
+	tracked = nil
+}
+
+// Sweep removes entries of rfh's temp directory last modified more than maxAge
+// ago, and returns how many it removed
+func Sweep(maxAge time.Duration) (int, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func sanitize(pattern string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(pattern)
+}
+
+// track records a temp path for Cleanup. The first one also starts watching
+// for interrupts, so a Ctrl-C does not leave files behind.
+func track(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	tracked = append(tracked, path)
+	if watching {
+		return
+	}
+	watching = true
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		Cleanup()
+
+		// Deliver the signal again without this handler, so the process stops
+		// as it would have, or a command handling it itself carries on
+		signal.Stop(signals)
+		if process, err := os.FindProcess(os.Getpid()); err == nil && process.Signal(sig) == nil {
+			return
+		}
+		os.Exit(130)
+	}()
+}
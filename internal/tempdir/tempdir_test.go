@@ -0,0 +1,87 @@
+package tempdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateTempAndCleanup(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Fatalf("expected a private temp directory, got %v (%v)", info, err)
+	}
+
+	file, err := CreateTemp("@security/rules-*.tgz")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	file.Close()
+	if filepath.Dir(file.Name()) != dir || !strings.HasPrefix(filepath.Base(file.Name()), "@security-rules-") {
+		t.Errorf("expected the file directly in %s, got %s", dir, file.Name())
+	}
+
+	other, err := CreateTemp("@security/rules-*.tgz")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	other.Close()
+	if other.Name() == file.Name() {
+		t.Errorf("expected unique names, got %s twice", file.Name())
+	}
+
+	staging, err := MkdirTemp("stage-*")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(staging, "rule.md"), []byte("# Rule"), 0644)
+
+	Cleanup()
+	for _, path := range []string{file.Name(), other.Name(), staging} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s removed by Cleanup", path)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+	stale := filepath.Join(dir, "rules-123.tgz")
+	staleDir := filepath.Join(dir, "stage-456")
+	fresh := filepath.Join(dir, "rules-789.tgz")
+	os.WriteFile(stale, []byte("old"), 0600)
+	os.MkdirAll(filepath.Join(staleDir, "rules"), 0700)
+	os.WriteFile(fresh, []byte("new"), 0600)
+
+	old := time.Now().Add(-2 * MaxAge)
+	os.Chtimes(stale, old, old)
+	os.Chtimes(staleDir, old, old)
+
+	removed, err := Sweep(MaxAge)
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 stale entries removed, got %d", removed)
+	}
+	for _, path := range []string{stale, staleDir} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s swept", path)
+		}
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("expected %s kept: %v", fresh, err)
+	}
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanTotal is the time spent in the spans of one name
+type SpanTotal struct {
+	Name     string
+	Count    int
+	Duration time.Duration // Time in the spans themselves, not in their child spans
+}
+
+// Profile is a span processor that totals the time spent in spans by name, to
+// report where a command's time went. Each span is charged only the time not
+// spent in its child spans, so a download's registry request is not counted
+// twice, and the totals add up to the time covered by spans.
+type Profile struct {
+	mu       sync.Mutex
+	children map[trace.SpanID]time.Duration // Time in the finished children of each span
+	totals   map[string]*SpanTotal
+	order    []string
+}
+
+// NewProfile creates an empty profile; register it with AddProcessor
+func NewProfile() *Profile {
+	return &Profile{
+		children: make(map[trace.SpanID]time.Duration),
+		totals:   make(map[string]*SpanTotal),
+	}
+}
+
+// Totals returns the time spent in each span name, in the order the names were
+// first seen
+func (p *Profile) Totals() []SpanTotal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	totals := make([]SpanTotal, 0, len(p.order))
+	for _, name := range p.order {
+		totals = append(totals, *p.totals[name])
+	}
+	return totals
+}
+
+func (p *Profile) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+func (p *Profile) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := s.SpanContext().SpanID()
+	duration := s.EndTime().Sub(s.StartTime())
+	if s.Parent().IsValid() {
+		p.children[s.Parent().SpanID()] += duration
+	}
+
+	// Children running in parallel can add up to more than their parent
+	self := max(duration-p.children[id], 0)
+	delete(p.children, id)
+
+	total, ok := p.totals[s.Name()]
+	if !ok {
+		total = &SpanTotal{Name: s.Name()}
+		p.totals[s.Name()] = total
+		p.order = append(p.order, s.Name())
+	}
+	total.Count++
+	total.Duration += self
+}
+
+func (p *Profile) Shutdown(ctx context.Context) error { return nil }
+
+func (p *Profile) ForceFlush(ctx context.Context) error { return nil }
+
+// AddProcessor registers a processor for the spans started from now on. When
+// spans are not being exported, a tracer provider is installed for it.
+func AddProcessor(processor sdktrace.SpanProcessor) {
+	if provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		provider.RegisterSpanProcessor(processor)
+		return
+	}
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor)))
+}
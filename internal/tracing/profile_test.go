@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestProfile(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	profile := NewProfile()
+	AddProcessor(profile)
+
+	ctx, download := Start(context.Background(), "download")
+	for i := 0; i < 2; i++ {
+		_, request := Start(ctx, "HTTP GET")
+		time.Sleep(20 * time.Millisecond)
+		request.End()
+	}
+	download.End()
+
+	totals := profile.Totals()
+	if len(totals) != 2 || totals[0].Name != "HTTP GET" || totals[1].Name != "download" {
+		t.Fatalf("unexpected totals %+v", totals)
+	}
+	if totals[0].Count != 2 || totals[0].Duration < 40*time.Millisecond {
+		t.Errorf("expected two requests of at least 40ms in all, got %+v", totals[0])
+	}
+	// The download is only charged for the time outside its requests
+	if totals[1].Duration >= 20*time.Millisecond {
+		t.Errorf("expected the requests excluded from the download, got %v", totals[1].Duration)
+	}
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider recording finished spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if _, err := Setup(context.Background(), "test"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestTransportPropagatesTraceContext(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "rfh publish")
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/v1/packages?token=secret", nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	traceID := TraceID(ctx)
+	if !strings.Contains(traceparent, traceID) {
+		t.Errorf("expected traceparent %q to carry trace %s", traceparent, traceID)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("expected the caller's request headers to be left untouched")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected client and parent spans, got %d", len(spans))
+	}
+	client := spans[0]
+	if client.Name() != "HTTP GET" || client.Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("unexpected client span %q with parent %s", client.Name(), client.Parent().SpanID())
+	}
+	if client.Status().Code.String() != "Error" {
+		t.Errorf("expected 5xx response to mark the span as failed, got %s", client.Status().Code)
+	}
+	for _, attr := range client.Attributes() {
+		if strings.Contains(attr.Value.Emit(), "secret") {
+			t.Errorf("query string leaked into span attribute %s", attr.Key)
+		}
+	}
+}
+
+func TestSetupWithoutEndpointDoesNotExport(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	if Enabled() {
+		t.Fatal("expected tracing export to be disabled without an endpoint")
+	}
+
+	shutdown, err := Setup(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+}
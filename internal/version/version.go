@@ -1,7 +1,9 @@
 package version
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -65,6 +67,42 @@ func Parse(versionStr string) (*Version, error) {
 	}, nil
 }
 
+// ParseLenient parses a version the way people and some registries write it:
+// a leading "v" or "V" is dropped and missing minor and patch segments are
+// zero, so "v1.2" is 1.2.0. It is meant for command-line input; manifests only
+// accept the canonical x.y.z form checked by Parse.
+func ParseLenient(versionStr string) (*Version, error) {
+	trimmed := versionStr
+	if strings.HasPrefix(trimmed, "v") || strings.HasPrefix(trimmed, "V") {
+		trimmed = trimmed[1:]
+	}
+
+	core, suffix := trimmed, ""
+	if idx := strings.IndexAny(trimmed, "-+"); idx != -1 {
+		core, suffix = trimmed[:idx], trimmed[idx:]
+	}
+	if core == "" {
+		return nil, fmt.Errorf("invalid version format: expected x.y.z, got %s", versionStr)
+	}
+
+	parts := strings.Split(core, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	return Parse(strings.Join(parts, ".") + suffix)
+}
+
+// Canonicalize returns the canonical x.y.z form of a version ParseLenient
+// accepts, e.g. "1.2.0" for "v1.2"
+func Canonicalize(versionStr string) (string, error) {
+	v, err := ParseLenient(versionStr)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
 // String returns the string representation of the version
 func (v *Version) String() string {
 	result := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
@@ -117,18 +155,60 @@ func (v *Version) Compare(other *Version) int {
 		return -1 // Pre-release < normal version
 	}
 	if v.Pre != "" && other.Pre != "" {
-		// Both are pre-releases, compare lexicographically
-		if v.Pre > other.Pre {
-			return 1
-		} else if v.Pre < other.Pre {
-			return -1
-		}
+		return comparePre(v.Pre, other.Pre)
 	}
 
 	// Build metadata is ignored in precedence comparison
 	return 0
 }
 
+// comparePre compares pre-release identifiers per semver §11: dot-separated
+// fields in order, numeric fields by value and below alphanumeric ones, which
+// compare in ASCII order. When all shared fields are equal, more fields win,
+// so 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.2 < 1.0.0-alpha.10 < 1.0.0-beta.
+func comparePre(a, b string) int {
+	aFields, bFields := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aFields) && i < len(bFields); i++ {
+		if c := comparePreField(aFields[i], bFields[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aFields), len(bFields))
+}
+
+// comparePreField compares one pre-release field
+func comparePreField(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		// Compare by length first so fields too long for an int still order by value
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// isNumeric reports whether a pre-release field is only digits
+func isNumeric(field string) bool {
+	if field == "" {
+		return false
+	}
+	for _, r := range field {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // IsGreaterThan returns true if v > other
 func (v *Version) IsGreaterThan(other *Version) bool {
 	return v.Compare(other) > 0
@@ -268,3 +348,155 @@ func GetNextVersions(currentVersion string) (patch, minor, major string, err err
 		v.IncrementMajor().String(),
 		nil
 }
+
+// RANGES
+
+// Range is a version requirement: an exact version, "^1" / "^1.2" / "^1.2.3"
+// (same major version, at least the given one) or "~1.2" / "~1.2.3" (same
+// major and minor version, at least the given one)
+type Range struct {
+	op   byte // '^', '~' or 0 for an exact version
+	base *Version
+}
+
+// ParseRange parses a version range
+func ParseRange(rangeStr string) (*Range, error) {
+	if rangeStr == "" {
+		return nil, fmt.Errorf("version range cannot be empty")
+	}
+
+	op := rangeStr[0]
+	if op != '^' && op != '~' {
+		v, err := Parse(rangeStr)
+		if err != nil {
+			return nil, err
+		}
+		return &Range{base: v}, nil
+	}
+
+	// Missing minor and patch parts default to zero
+	parts := strings.Split(rangeStr[1:], ".")
+	if len(parts) > 3 || (op == '~' && len(parts) < 2) {
+		return nil, fmt.Errorf("invalid version range: %s", rangeStr)
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	base, err := Parse(strings.Join(parts, "."))
+	if err != nil || base.Pre != "" || base.Build != "" {
+		return nil, fmt.Errorf("invalid version range: %s", rangeStr)
+	}
+
+	return &Range{op: op, base: base}, nil
+}
+
+// Contains reports whether v is in the range. Pre-releases only match an exact range.
+func (r *Range) Contains(v *Version) bool {
+	switch r.op {
+	case '^':
+		return v.Pre == "" && v.Major == r.base.Major && !v.IsLessThan(r.base)
+	case '~':
+		return v.Pre == "" && v.Major == r.base.Major && v.Minor == r.base.Minor && !v.IsLessThan(r.base)
+	default:
+		return v.IsEqual(r.base)
+	}
+}
+
+// IsExact reports whether the range names a single version
+func (r *Range) IsExact() bool {
+	return r.op == 0
+}
+
+// Highest returns the highest of versions in the range, or an empty string if none is
+func (r *Range) Highest(versions []string) string {
+	var best *Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := Parse(raw)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+		if best == nil || v.IsGreaterThan(best) {
+			best, bestRaw = v, raw
+		}
+	}
+	return bestRaw
+}
+
+// Satisfies reports whether versionStr is within rangeStr
+func Satisfies(versionStr, rangeStr string) (bool, error) {
+	r, err := ParseRange(rangeStr)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := Parse(versionStr)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Contains(v), nil
+}
+
+// SORTING AND SELECTION
+
+// SortVersions sorts versions from lowest to highest precedence. Strings that
+// are not versions sort first, in string order.
+func SortVersions(versions []string) {
+	parsed := make(map[string]*Version, len(versions))
+	for _, raw := range versions {
+		if v, err := Parse(raw); err == nil {
+			parsed[raw] = v
+		}
+	}
+
+	slices.SortStableFunc(versions, func(a, b string) int {
+		va, vb := parsed[a], parsed[b]
+		switch {
+		case va == nil && vb == nil:
+			return strings.Compare(a, b)
+		case va == nil:
+			return -1
+		case vb == nil:
+			return 1
+		}
+		return va.Compare(vb)
+	})
+}
+
+// Latest returns the highest stable version, or the highest pre-release when
+// there is no stable one. Strings that are not versions are ignored; the
+// result is empty when none is a version.
+func Latest(versions []string) string {
+	var latest, latestPre string
+	var highest, highestPre *Version
+	for _, raw := range versions {
+		v, err := Parse(raw)
+		if err != nil {
+			continue
+		}
+		if v.Pre != "" {
+			if highestPre == nil || v.IsGreaterThan(highestPre) {
+				highestPre, latestPre = v, raw
+			}
+		} else if highest == nil || v.IsGreaterThan(highest) {
+			highest, latest = v, raw
+		}
+	}
+
+	if latest != "" {
+		return latest
+	}
+	return latestPre
+}
+
+// MaxSatisfying returns the highest of versions within the range constraint,
+// or an empty string if none is
+func MaxSatisfying(versions []string, constraint string) (string, error) {
+	r, err := ParseRange(constraint)
+	if err != nil {
+		return "", err
+	}
+	return r.Highest(versions), nil
+}
@@ -1,6 +1,8 @@
 package version
 
 import (
+	"cmp"
+	"slices"
 	"testing"
 )
 
@@ -120,6 +122,48 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{name: "canonical version", version: "1.2.3", want: "1.2.3"},
+		{name: "leading v", version: "v1.2.3", want: "1.2.3"},
+		{name: "leading V", version: "V1.2.3", want: "1.2.3"},
+		{name: "missing patch", version: "1.2", want: "1.2.0"},
+		{name: "major only", version: "v2", want: "2.0.0"},
+		{name: "pre-release without patch", version: "v1.2-beta.1", want: "1.2.0-beta.1"},
+		{name: "build without minor", version: "1+build.5", want: "1.0.0+build.5"},
+		{name: "empty", version: "", wantErr: true},
+		{name: "only v", version: "v", wantErr: true},
+		{name: "double v", version: "vv1.2.3", wantErr: true},
+		{name: "too many parts", version: "1.2.3.4", wantErr: true},
+		{name: "invalid characters", version: "v1.x", wantErr: true},
+		{name: "range", version: "^1.2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Canonicalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Canonicalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Strict parsing is unchanged
+	for _, lenient := range []string{"v1.2.3", "1.2"} {
+		if IsValidVersion(lenient) {
+			t.Errorf("expected %s to stay invalid for Parse", lenient)
+		}
+	}
+}
+
 func TestVersion_String(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -185,6 +229,20 @@ func TestVersion_Compare(t *testing.T) {
 		{name: "1.0.0-beta vs 1.0.0-alpha", version1: "1.0.0-beta", version2: "1.0.0-alpha", want: 1},
 		{name: "1.0.0-alpha vs 1.0.0-alpha", version1: "1.0.0-alpha", version2: "1.0.0-alpha", want: 0},
 
+		// Pre-release identifiers compare field by field (semver §11)
+		{name: "numeric fields by value", version1: "1.0.0-alpha.10", version2: "1.0.0-alpha.9", want: 1},
+		{name: "numeric fields by value reversed", version1: "1.0.0-alpha.9", version2: "1.0.0-alpha.10", want: -1},
+		{name: "numeric only identifiers", version1: "1.0.0-2", version2: "1.0.0-11", want: -1},
+		{name: "numeric below alphanumeric", version1: "1.0.0-1", version2: "1.0.0-alpha", want: -1},
+		{name: "alphanumeric above numeric", version1: "1.0.0-alpha.beta", version2: "1.0.0-alpha.1", want: 1},
+		{name: "more fields win", version1: "1.0.0-alpha.1", version2: "1.0.0-alpha", want: 1},
+		{name: "fewer fields lose", version1: "1.0.0-alpha", version2: "1.0.0-alpha.1", want: -1},
+		{name: "alphanumeric fields in ASCII order", version1: "1.0.0-beta.2", version2: "1.0.0-beta.11", want: -1},
+		{name: "uppercase sorts before lowercase", version1: "1.0.0-RC.1", version2: "1.0.0-rc.1", want: -1},
+		{name: "hyphen inside a field", version1: "1.0.0-alpha-2", version2: "1.0.0-alpha-10", want: 1},
+		{name: "numeric longer than an int", version1: "1.0.0-99999999999999999999", version2: "1.0.0-100000000000000000000", want: -1},
+		{name: "equal numeric fields", version1: "1.0.0-rc.1+build.1", version2: "1.0.0-rc.1+build.2", want: 0},
+
 		// Build metadata should be ignored
 		{name: "1.0.0+build1 vs 1.0.0+build2", version1: "1.0.0+build1", version2: "1.0.0+build2", want: 0},
 		{name: "1.0.0+build vs 1.0.0", version1: "1.0.0+build", version2: "1.0.0", want: 0},
@@ -208,6 +266,20 @@ func TestVersion_Compare(t *testing.T) {
 	}
 }
 
+func TestVersion_ComparePrecedence(t *testing.T) {
+	// The precedence example of semver §11
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			v1, _ := Parse(ordered[i])
+			v2, _ := Parse(ordered[j])
+			if got, want := v1.Compare(v2), cmp.Compare(i, j); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
 func TestVersion_Increment(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -496,3 +568,113 @@ func TestGetNextVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		rangeStr string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "exact match", version: "2.1.0", rangeStr: "2.1.0", want: true},
+		{name: "exact mismatch", version: "2.1.1", rangeStr: "2.1.0", want: false},
+		{name: "caret major only", version: "2.9.3", rangeStr: "^2", want: true},
+		{name: "caret excludes next major", version: "3.0.0", rangeStr: "^2", want: false},
+		{name: "caret lower bound", version: "2.0.9", rangeStr: "^2.1", want: false},
+		{name: "tilde same minor", version: "2.1.7", rangeStr: "~2.1.3", want: true},
+		{name: "tilde excludes next minor", version: "2.2.0", rangeStr: "~2.1", want: false},
+		{name: "range skips pre-release", version: "2.5.0-beta", rangeStr: "^2", want: false},
+		{name: "exact pre-release", version: "2.5.0-beta", rangeStr: "2.5.0-beta", want: true},
+		{name: "tilde needs minor", version: "2.0.0", rangeStr: "~2", wantErr: true},
+		{name: "invalid range", version: "2.0.0", rangeStr: "^x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Satisfies(tt.version, tt.rangeStr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Satisfies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Satisfies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeHighest(t *testing.T) {
+	r, err := ParseRange("^2")
+	if err != nil {
+		t.Fatalf("ParseRange() error = %v", err)
+	}
+
+	if got := r.Highest([]string{"1.9.0", "2.0.0", "2.10.1", "2.3.0", "3.0.0", "2.11.0-rc1"}); got != "2.10.1" {
+		t.Errorf("Highest() = %q, want 2.10.1", got)
+	}
+	if got := r.Highest([]string{"1.0.0"}); got != "" {
+		t.Errorf("Highest() = %q, want no match", got)
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"1.10.0", "latest", "1.0.0-alpha.10", "1.2.0", "1.0.0", "1.0.0-alpha.9", "0.9.0", "dev"}
+	SortVersions(versions)
+
+	want := []string{"dev", "latest", "0.9.0", "1.0.0-alpha.9", "1.0.0-alpha.10", "1.0.0", "1.2.0", "1.10.0"}
+	if !slices.Equal(versions, want) {
+		t.Errorf("SortVersions() = %v, want %v", versions, want)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		expected string
+	}{
+		{"highest stable", []string{"1.2.0", "1.10.0", "1.9.0"}, "1.10.0"},
+		{"stable beats newer pre-release", []string{"1.0.0", "2.0.0-rc.1"}, "1.0.0"},
+		{"pre-release only", []string{"1.0.0-alpha", "1.0.0-beta"}, "1.0.0-beta"},
+		{"numeric pre-release fields", []string{"1.0.0-rc.9", "1.0.0-rc.10"}, "1.0.0-rc.10"},
+		{"backport published last", []string{"1.0.0", "2.0.0", "1.0.1"}, "2.0.0"},
+		{"unparseable ignored", []string{"latest", "0.1.0"}, "0.1.0"},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Latest(tt.versions); got != tt.expected {
+				t.Errorf("Latest() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"1.9.0", "2.0.0", "2.10.1", "2.3.0", "3.0.0", "2.11.0-rc1"}
+	tests := []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{constraint: "^2", want: "2.10.1"},
+		{constraint: "~2.3", want: "2.3.0"},
+		{constraint: "3.0.0", want: "3.0.0"},
+		{constraint: "^4", want: ""},
+		{constraint: "^x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := MaxSatisfying(versions, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MaxSatisfying() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MaxSatisfying() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+-- V10__package_index_sync.sql
+-- Delta index sync: track when anything about a package last changed
+
+ALTER TABLE rulestack.packages ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX idx_packages_updated_at ON rulestack.packages(updated_at);
+
+-- Any change to a version (publish, approval, deprecation, removal) marks its package changed
+CREATE OR REPLACE FUNCTION rulestack.touch_package_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE rulestack.packages SET updated_at = now() WHERE id = OLD.package_id;
+        RETURN OLD;
+    END IF;
+    UPDATE rulestack.packages SET updated_at = now() WHERE id = NEW.package_id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER touch_package_on_version_change AFTER INSERT OR UPDATE OR DELETE ON rulestack.package_versions
+    FOR EACH ROW EXECUTE FUNCTION rulestack.touch_package_updated_at();
@@ -0,0 +1,4 @@
+-- V11__upstream_versions.sql
+-- Pull-through mode: remember which versions were cached from the upstream registry
+
+ALTER TABLE rulestack.package_versions ADD COLUMN upstream TEXT;
@@ -0,0 +1,3 @@
+-- License: the SPDX license expression a version was published under
+
+ALTER TABLE rulestack.package_versions ADD COLUMN license TEXT;
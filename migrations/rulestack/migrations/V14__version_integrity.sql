@@ -0,0 +1,4 @@
+-- Integrity: digests of a version's archive in every supported algorithm, as
+-- "sha256-... blake3-... sha512-...". Versions published earlier only have sha256.
+
+ALTER TABLE rulestack.package_versions ADD COLUMN integrity TEXT;
@@ -0,0 +1,4 @@
+-- Dependencies: the packages a version builds on, as a JSON object of package
+-- names to version ranges, e.g. {"base-rules": "^1.2"}
+
+ALTER TABLE rulestack.package_versions ADD COLUMN dependencies TEXT;
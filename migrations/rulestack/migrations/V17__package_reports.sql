@@ -0,0 +1,20 @@
+-- Abuse reports: users flag malicious or spam packages for admins to dismiss,
+-- yank the package, or ban its publishers
+
+CREATE TABLE rulestack.package_reports (
+    id SERIAL PRIMARY KEY,
+    package_id INT NOT NULL REFERENCES rulestack.packages(id) ON DELETE CASCADE,
+    version TEXT,
+    reporter_id INT NOT NULL REFERENCES rulestack.users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('malicious', 'spam', 'impersonation', 'other')),
+    message TEXT,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'yanked', 'banned')),
+    resolved_by INT REFERENCES rulestack.users(id),
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_package_reports_status ON rulestack.package_reports(status);
+
+-- One open report per user and package
+CREATE UNIQUE INDEX one_open_report_per_reporter ON rulestack.package_reports(package_id, reporter_id) WHERE status = 'open';
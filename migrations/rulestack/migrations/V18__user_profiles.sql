@@ -0,0 +1,7 @@
+-- Public profiles: optional fields users fill in about themselves, shown on
+-- their author page with the packages they publish
+
+ALTER TABLE rulestack.users ADD COLUMN display_name VARCHAR(100);
+ALTER TABLE rulestack.users ADD COLUMN profile_url TEXT;
+ALTER TABLE rulestack.users ADD COLUMN avatar_url TEXT;
+ALTER TABLE rulestack.users ADD COLUMN bio TEXT;
@@ -0,0 +1,4 @@
+-- Sessions used from outside the network or device they were logged in from
+-- are flagged once, so the account is only notified the first time
+
+ALTER TABLE rulestack.user_sessions ADD COLUMN flagged_at TIMESTAMPTZ;
@@ -0,0 +1,23 @@
+-- V6__package_version_checks.sql
+-- Publish gating: versions stay pending until registry-side validation checks pass
+
+-- Existing versions were accepted before gating existed and stay visible
+ALTER TABLE rulestack.package_versions
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('pending', 'published', 'rejected'));
+
+CREATE INDEX idx_package_versions_status ON rulestack.package_versions(status);
+
+-- One row per validation check run against a package version
+CREATE TABLE rulestack.package_version_checks (
+    id SERIAL PRIMARY KEY,
+    version_id INT NOT NULL REFERENCES rulestack.package_versions(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
+    message TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    UNIQUE (version_id, name)
+);
+
+CREATE INDEX idx_package_version_checks_version_id ON rulestack.package_version_checks(version_id);
@@ -0,0 +1,13 @@
+-- V7__version_approvals.sql
+-- Two-person review: versions that passed validation can wait for a second approver
+
+ALTER TABLE rulestack.package_versions DROP CONSTRAINT package_versions_status_check;
+ALTER TABLE rulestack.package_versions
+    ADD CONSTRAINT package_versions_status_check
+    CHECK (status IN ('pending', 'awaiting_approval', 'published', 'rejected'));
+
+-- Who uploaded and who approved each version
+ALTER TABLE rulestack.package_versions
+    ADD COLUMN published_by INT REFERENCES rulestack.users(id) ON DELETE SET NULL,
+    ADD COLUMN approved_by INT REFERENCES rulestack.users(id) ON DELETE SET NULL,
+    ADD COLUMN approved_at TIMESTAMPTZ;
@@ -0,0 +1,11 @@
+-- Package name reservations: claim a name ahead of its first publish
+
+CREATE TABLE rulestack.package_reservations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES rulestack.users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_package_reservations_expires_at ON rulestack.package_reservations(expires_at);
@@ -0,0 +1,3 @@
+-- Deprecation: a published version can carry a message steering users elsewhere
+
+ALTER TABLE rulestack.package_versions ADD COLUMN deprecated TEXT;
@@ -0,0 +1,169 @@
+// Command benchcmp compares two runs of go test -bench and fails when a
+// benchmark got slower or allocates more than the threshold allows.
+//
+//	go run ./scripts/benchcmp [-threshold 10] bench_baseline.txt bench_output.txt
+//
+// Runs repeated with -count are summarized by their median.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// gatedUnits are the metrics whose regressions fail the comparison; B/op is
+// shown but varies too much with buffer growth to gate on
+var gatedUnits = map[string]bool{"ns/op": true, "allocs/op": true}
+
+// results maps "package Benchmark" to each metric's samples by unit
+type results map[string]map[string][]float64
+
+func main() {
+	threshold := flag.Float64("threshold", 10, "percentage a gated metric may regress by")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: benchcmp [-threshold percent] old.txt new.txt\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old, err := parseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	current, err := parseFile(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(2)
+	}
+
+	if regressions := compare(os.Stdout, old, current, *threshold); regressions > 0 {
+		fmt.Printf("\n❌ %d metric(s) regressed by more than %.0f%%\n", regressions, *threshold)
+		os.Exit(1)
+	}
+	fmt.Printf("\n✅ No regressions beyond %.0f%%\n", *threshold)
+}
+
+func parseFile(path string) (results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open benchmark results: %w", err)
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parse reads go test -bench output, keying benchmarks by package so names
+// may repeat across packages
+func parse(r io.Reader) (results, error) {
+	res := make(results)
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			continue
+		}
+		if !strings.HasPrefix(line, "Benchmark") {
+			continue
+		}
+
+		// BenchmarkName-8  100  12345 ns/op  678 B/op  9 allocs/op
+		fields := strings.Fields(line)
+		if len(fields) < 4 || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		key := strings.TrimSpace(pkg + " " + name)
+
+		if res[key] == nil {
+			res[key] = make(map[string][]float64)
+		}
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in %q", fields[i], line)
+			}
+			res[key][fields[i+1]] = append(res[key][fields[i+1]], value)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// compare writes a table of old and new medians and returns how many gated
+// metrics regressed beyond threshold percent
+func compare(w io.Writer, old, current results, threshold float64) int {
+	var names, added []string
+	for name := range current {
+		if _, ok := old[name]; ok {
+			names = append(names, name)
+		} else {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(added)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "benchmark\tmetric\told\tnew\tdelta\t")
+	regressions := 0
+	for _, name := range names {
+		var units []string
+		for unit := range current[name] {
+			if _, ok := old[name][unit]; ok {
+				units = append(units, unit)
+			}
+		}
+		sort.Strings(units)
+
+		for _, unit := range units {
+			before, after := median(old[name][unit]), median(current[name][unit])
+			delta := 0.0
+			if before != 0 {
+				delta = (after - before) / before * 100
+			}
+			mark := ""
+			if gatedUnits[unit] && delta > threshold {
+				mark = "⚠️"
+				regressions++
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.0f\t%+.1f%%\t%s\n", name, unit, before, after, delta, mark)
+		}
+	}
+	tw.Flush()
+
+	for _, name := range added {
+		fmt.Fprintf(w, "💡 %s has no baseline yet\n", name)
+	}
+	return regressions
+}
+
+func median(samples []float64) float64 {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
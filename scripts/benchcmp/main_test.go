@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const baseline = `goos: linux
+pkg: rulestack/internal/pkg
+BenchmarkPack/gzip-8   	     500	   2000000 ns/op	 2700000 B/op	    1200 allocs/op
+BenchmarkPack/gzip-8   	     500	   2200000 ns/op	 2700000 B/op	    1200 allocs/op
+BenchmarkPack/gzip-8   	     500	   9000000 ns/op	 2700000 B/op	    1200 allocs/op
+pkg: rulestack/internal/security
+BenchmarkPackageValidator_ValidateArchive-8 	     400	   3000000 ns/op	 2100000 B/op	    2400 allocs/op
+PASS
+`
+
+func TestCompare(t *testing.T) {
+	old, err := parse(strings.NewReader(baseline))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got := median(old["rulestack/internal/pkg BenchmarkPack/gzip"]["ns/op"]); got != 2200000 {
+		t.Errorf("expected the median of repeated runs, got %v", got)
+	}
+
+	// Slower validation and more allocations in packing both regress; B/op is not gated
+	current, err := parse(strings.NewReader(`pkg: rulestack/internal/pkg
+BenchmarkPack/gzip-4   	     500	   2300000 ns/op	 5000000 B/op	    1500 allocs/op
+pkg: rulestack/internal/security
+BenchmarkPackageValidator_ValidateArchive-4 	     400	   3600000 ns/op	 2100000 B/op	    2400 allocs/op
+BenchmarkNew-4 	     400	   100 ns/op
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if regressions := compare(&out, old, current, 10); regressions != 2 {
+		t.Errorf("expected 2 regressions, got %d:\n%s", regressions, out.String())
+	}
+	if !strings.Contains(out.String(), "BenchmarkNew has no baseline") {
+		t.Errorf("expected new benchmarks reported, got:\n%s", out.String())
+	}
+}